@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// checker checks the files given to gojs check.
+type checker struct {
+	module bool
+
+	stderr io.Writer
+	failed bool
+}
+
+// runCheck implements gojs check, which reports the syntax errors and the
+// early errors of files, or of the standard input when given no path.
+// Directories are walked for .js, .mjs and .cjs files.
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &checker{stderr: stderr}
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&c.module, "module", false, "parse every file as a module (default: .mjs files, and files that only parse as modules)")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gojs check [flags] [path ...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		c.checkFile("<standard input>", stdin)
+	}
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			c.report(err)
+		case info.IsDir():
+			walkDir(path, c.checkPath, c.report)
+		default:
+			c.checkPath(path)
+		}
+	}
+	if c.failed {
+		return 2
+	}
+	return 0
+}
+
+func (c *checker) report(err error) {
+	fmt.Fprintf(c.stderr, "%v\n", err)
+	c.failed = true
+}
+
+func (c *checker) checkPath(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	defer file.Close()
+	c.checkFile(path, file)
+}
+
+// earlyErrors are the early errors of a source, which are all reported.
+type earlyErrors []*parser.EarlyError
+
+func (e earlyErrors) Error() string {
+	return e[0].Error()
+}
+
+// checkFile reports the syntax error of the source named name read from in,
+// or all of its early errors.
+func (c *checker) checkFile(name string, in io.Reader) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	_, err = transform(name, string(src), c.module, func(src string, options parser.Options) (string, error) {
+		file, err := parser.ParseFile(src, options)
+		if err != nil {
+			return "", err
+		}
+		if errs := parser.Validate(file.Program, options); len(errs) > 0 {
+			return "", earlyErrors(errs)
+		}
+		return "", nil
+	})
+	var errs earlyErrors
+	switch {
+	case errors.As(err, &errs):
+		for _, err := range errs {
+			c.report(sourceError(name, err))
+		}
+	case err != nil:
+		c.report(sourceError(name, err))
+	}
+}
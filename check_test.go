@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	run := func(stdin string, args ...string) (stdout, stderr string, code int) {
+		var out, errOut bytes.Buffer
+		code = runCheck(args, strings.NewReader(stdin), &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		if stdout, stderr, code := run("let a = 1; export {a as b};"); code != 0 || stdout != "" || stderr != "" {
+			t.Errorf("expected no errors, got %q, %q (exit %d)", stdout, stderr, code)
+		}
+	})
+
+	t.Run("early errors", func(t *testing.T) {
+		_, stderr, code := run("let a; let a;\nreturn 1;")
+		expected := "<standard input>:1:11: identifier 'a' has already been declared [duplicate-declaration]\n" +
+			"<standard input>:2:0: illegal return statement outside of a function [return-outside-function]\n"
+		if code != 2 || stderr != expected {
+			t.Errorf("expected %q, got %q (exit %d)", expected, stderr, code)
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, stderr, code := run("let = ;"); code != 2 || !strings.HasPrefix(stderr, "<standard input>:") {
+			t.Errorf("expected a syntax error, got %q (exit %d)", stderr, code)
+		}
+	})
+
+	t.Run("syntax errors are positioned", func(t *testing.T) {
+		cases := []struct{ src, expected string }{
+			{"let = ;", "<standard input>:1:4: unexpected token =\n"},
+			{"a;\nif (a) else b", "<standard input>:2:7: unexpected token else\n"},
+			{"f(a,", "<standard input>:1:4: unexpected end of input\n"},
+			{"a = 'b", "<standard input>:1:4: unterminated string literal\n"},
+			{"a;\nb @ c", "<standard input>:2:2: unexpected character '@'\n"},
+		}
+		for _, c := range cases {
+			if _, stderr, code := run(c.src); code != 2 || stderr != c.expected {
+				t.Errorf("expected %q, got %q (exit %d)", c.expected, stderr, code)
+			}
+		}
+	})
+
+	t.Run("modules", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "a.mjs")
+		if err := os.WriteFile(path, []byte("export {b};"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		expected := path + ":1:8: export 'b' is not defined in module [undeclared-export]\n"
+		if _, stderr, code := run("", dir); code != 2 || stderr != expected {
+			t.Errorf("expected %q, got %q (exit %d)", expected, stderr, code)
+		}
+	})
+}
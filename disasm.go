@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ruiconti/gojs/runtime"
+)
+
+// runDisasm implements gojs disasm, which prints the bytecode the runtime
+// compiles a file to, or the standard input when given no path.
+func runDisasm(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	module := flags.Bool("module", false, "parse the source as a module (default: .mjs files, and sources that only parse as modules)")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gojs disasm [flags] [path]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	name, in := "<standard input>", stdin
+	if flags.NArg() == 1 {
+		name = flags.Arg(0)
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		defer file.Close()
+		in = file
+	}
+	src, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	res, err := transform(name, string(src), *module, runtime.Disassemble)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", sourceError(name, err))
+		return 2
+	}
+	io.WriteString(stdout, res)
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDisasm(t *testing.T) {
+	run := func(stdin string, args ...string) (stdout, stderr string, code int) {
+		var out, errOut bytes.Buffer
+		code = runDisasm(args, strings.NewReader(stdin), &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+
+	t.Run("script", func(t *testing.T) {
+		stdout, _, code := run("function add(a, b) { return a + b }")
+		if code != 0 || !strings.Contains(stdout, "add: 2 locals, 0 cells") {
+			t.Errorf("expected the listing of add, got %q (exit %d)", stdout, code)
+		}
+	})
+
+	t.Run("module", func(t *testing.T) {
+		stdout, _, code := run("export const answer = 42", "-module")
+		if code != 0 || !strings.Contains(stdout, "<module>") || !strings.Contains(stdout, "init.name          answer") {
+			t.Errorf("expected the listing of the module, got %q (exit %d)", stdout, code)
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, stderr, code := run("let = ;"); code != 2 || !strings.HasPrefix(stderr, "<standard input>:") {
+			t.Errorf("expected a syntax error, got %q (exit %d)", stderr, code)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ruiconti/gojs/format"
+	"github.com/ruiconti/gojs/internal"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/printer"
+)
+
+var quotes = map[string]printer.Quote{
+	"double":   printer.QuoteDouble,
+	"single":   printer.QuoteSingle,
+	"preserve": printer.QuotePreserve,
+}
+
+// formatter formats the files given to gojs fmt.
+type formatter struct {
+	list, write, diff bool
+	module            bool
+	style             printer.Options
+
+	stdout, stderr io.Writer
+	failed         bool
+}
+
+// runFmt implements gojs fmt, which works like gofmt: without flags, it prints
+// the formatted files, or the standard input when given no path. Directories
+// are walked for .js, .mjs and .cjs files.
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	f := &formatter{style: format.DefaultOptions, stdout: stdout, stderr: stderr}
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&f.list, "l", false, "list files whose formatting differs from gojs fmt's")
+	flags.BoolVar(&f.write, "w", false, "write the result to the source file instead of stdout")
+	flags.BoolVar(&f.diff, "d", false, "display diffs instead of rewriting files")
+	flags.BoolVar(&f.module, "module", false, "parse every file as a module (default: .mjs files, and files that only parse as modules)")
+	flags.IntVar(&f.style.Width, "width", f.style.Width, "line width lists are broken at, 0 to never break them")
+	quote := flags.String("quote", "double", "quotes of string literals: double, single or preserve")
+	semi := flags.Bool("semi", true, "end statements with semicolons, rather than only where needed")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gojs fmt [flags] [path ...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	var ok bool
+	if f.style.Quote, ok = quotes[*quote]; !ok {
+		fmt.Fprintf(stderr, "gojs fmt: invalid -quote %q\n", *quote)
+		return 2
+	}
+	f.style.OmitSemicolons = !*semi
+
+	if flags.NArg() == 0 {
+		if f.write {
+			fmt.Fprintf(stderr, "gojs fmt: cannot use -w with standard input\n")
+			return 2
+		}
+		f.formatFile("<standard input>", stdin)
+	}
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			f.report(err)
+		case info.IsDir():
+			f.formatDir(path)
+		default:
+			f.formatPath(path)
+		}
+	}
+	if f.failed {
+		return 2
+	}
+	return 0
+}
+
+func (f *formatter) report(err error) {
+	fmt.Fprintf(f.stderr, "%v\n", err)
+	f.failed = true
+}
+
+// formatDir formats the JavaScript files within dir.
+func (f *formatter) formatDir(dir string) {
+	walkDir(dir, f.formatPath, f.report)
+}
+
+// walkDir calls fn with the path of every JavaScript file within dir,
+// skipping hidden directories and node_modules, and report with the errors
+// met on the way.
+func walkDir(dir string, fn func(path string), report func(err error)) {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			report(err)
+		case entry.IsDir():
+			if name := entry.Name(); path != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+		case isJavaScriptFile(entry.Name()):
+			fn(path)
+		}
+		return nil
+	})
+	if err != nil {
+		report(err)
+	}
+}
+
+func isJavaScriptFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+func (f *formatter) formatPath(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		f.report(err)
+		return
+	}
+	defer file.Close()
+	f.formatFile(path, file)
+}
+
+// formatFile formats the source named name read from in, printing it, listing
+// it, writing it back or printing its diff as the flags say.
+func (f *formatter) formatFile(name string, in io.Reader) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		f.report(err)
+		return
+	}
+	res, err := f.source(name, string(src))
+	if err != nil {
+		f.report(sourceError(name, err))
+		return
+	}
+
+	if res != string(src) {
+		if f.list {
+			fmt.Fprintln(f.stdout, name)
+		}
+		if f.write {
+			info, err := os.Stat(name)
+			if err != nil {
+				f.report(err)
+				return
+			}
+			if err := os.WriteFile(name, []byte(res), info.Mode().Perm()); err != nil {
+				f.report(err)
+				return
+			}
+		}
+		if f.diff {
+			io.WriteString(f.stdout, internal.Diff(name+".orig", string(src), name, res))
+		}
+	}
+	if !f.list && !f.write && !f.diff {
+		io.WriteString(f.stdout, res)
+	}
+}
+
+// source formats src.
+func (f *formatter) source(name, src string) (string, error) {
+	return transform(name, src, f.module, func(src string, options parser.Options) (string, error) {
+		return format.Source(src, options, f.style)
+	})
+}
+
+// transform applies fn to src, the source of the file name. Files are parsed
+// as modules with -module or a .mjs extension, as scripts with a .cjs
+// extension, and otherwise as scripts unless they only parse as modules.
+func transform(name, src string, module bool, fn func(src string, options parser.Options) (string, error)) (string, error) {
+	scriptOptions := parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true}
+	moduleOptions := parser.Options{SourceType: parser.SourceTypeModule}
+	switch {
+	case module || filepath.Ext(name) == ".mjs":
+		return fn(src, moduleOptions)
+	case filepath.Ext(name) == ".cjs":
+		return fn(src, scriptOptions)
+	}
+	res, err := fn(src, scriptOptions)
+	if err != nil {
+		if res, moduleErr := fn(src, moduleOptions); moduleErr == nil {
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// sourceError prefixes err with the name of the file it occurred in, and the
+// position of a syntax or early error.
+func sourceError(name string, err error) error {
+	var syntaxErr *parser.SyntaxError
+	var earlyErr *parser.EarlyError
+	if errors.As(err, &syntaxErr) || errors.As(err, &earlyErr) {
+		return fmt.Errorf("%s:%v", name, err)
+	}
+	return fmt.Errorf("%s: %v", name, err)
+}
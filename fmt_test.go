@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runFmtTest(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = runFmt(args, strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), code
+}
+
+func TestFmt(t *testing.T) {
+	const (
+		unformatted = "// a comment\nlet a = 'b'\n\n\nf(a)\n"
+		formatted   = "// a comment\nlet a = \"b\";\n\nf(a);\n"
+	)
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("standard input", func(t *testing.T) {
+		stdout, _, code := runFmtTest(t, unformatted)
+		if code != 0 || stdout != formatted {
+			t.Errorf("expected %q, got %q (exit %d)", formatted, stdout, code)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		stdout, _, code := runFmtTest(t, unformatted, "-quote", "single", "-semi=false")
+		expected := "// a comment\nlet a = 'b'\n\nf(a)\n"
+		if code != 0 || stdout != expected {
+			t.Errorf("expected %q, got %q (exit %d)", expected, stdout, code)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		bad := write("list/bad.js", unformatted)
+		write("list/good.js", formatted)
+		write("list/node_modules/dep.js", unformatted)
+		write("list/notes.txt", unformatted)
+		stdout, _, code := runFmtTest(t, "", "-l", filepath.Join(dir, "list"))
+		if code != 0 || stdout != bad+"\n" {
+			t.Errorf("expected only %s to be listed, got %q (exit %d)", bad, stdout, code)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		path := write("diff.js", unformatted)
+		stdout, _, code := runFmtTest(t, "", "-d", path)
+		expected := "--- " + path + ".orig\n+++ " + path + "\n@@ -1,5 +1,4 @@\n" +
+			" // a comment\n-let a = 'b'\n+let a = \"b\";\n \n-\n-f(a)\n+f(a);\n"
+		if code != 0 || stdout != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s(exit %d)", expected, stdout, code)
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		path := write("write.mjs", "export { a } from 'a'\n")
+		if stdout, stderr, code := runFmtTest(t, "", "-w", path); code != 0 || stdout != "" {
+			t.Fatalf("expected no output, got %q %q (exit %d)", stdout, stderr, code)
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "export { a } from \"a\";\n"; string(src) != expected {
+			t.Errorf("expected the file to be rewritten as %q, got %q", expected, src)
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		path := write("error.js", "a = (\n")
+		_, stderr, code := runFmtTest(t, "", path)
+		if code != 2 || !strings.HasPrefix(stderr, path+":") {
+			t.Errorf("expected a syntax error in %s, got %q (exit %d)", path, stderr, code)
+		}
+	})
+
+	t.Run("write without files", func(t *testing.T) {
+		if _, _, code := runFmtTest(t, unformatted, "-w"); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+}
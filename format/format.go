@@ -0,0 +1,30 @@
+// Package format formats JavaScript source text in a canonical style, the way
+// gofmt formats Go. The layout of the source is discarded and its AST printed
+// back, keeping only its comments and the blank lines between statements.
+// Formatting a formatted source leaves it unchanged.
+package format
+
+import (
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/printer"
+)
+
+// DefaultOptions is the canonical style: double quotes, semicolons, two space
+// indents and lines of at most 80 columns where lists can be broken.
+var DefaultOptions = printer.Options{
+	Mode:  printer.Readable,
+	Width: 80,
+	Quote: printer.QuoteDouble,
+}
+
+// Source formats src, parsed with parseOptions, in the style of options, whose
+// Mode is always Readable. It returns the syntax error of a source that does
+// not parse.
+func Source(src string, parseOptions parser.Options, options printer.Options) (string, error) {
+	file, err := parser.ParseFile(src, parseOptions)
+	if err != nil {
+		return "", err
+	}
+	options.Mode = printer.Readable
+	return printer.PrintFile(file, options), nil
+}
@@ -0,0 +1,190 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/printer"
+)
+
+// assertFormat checks that src formats as expected, and that formatting is
+// idempotent.
+func assertFormat(t *testing.T, src, expected string, options printer.Options) {
+	t.Helper()
+	got, err := Source(src, parser.Options{SourceType: parser.SourceTypeModule}, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+	again, err := Source(got, parser.Options{SourceType: parser.SourceTypeModule}, options)
+	if err != nil {
+		t.Fatalf("unexpected error formatting again: %v", err)
+	}
+	if again != got {
+		t.Errorf("expected formatting to be idempotent, got:\n%s", again)
+	}
+}
+
+func TestSource(t *testing.T) {
+	tcs := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name: "comments and blank lines",
+			src: `// leading
+import a from 'a' // trailing
+
+
+
+/* block */ function f(x) {
+  // first
+  if (x) { return 1 } // after the if
+
+
+  return 2
+  // last
+}
+`,
+			expected: `// leading
+import a from "a"; // trailing
+
+/* block */
+function f(x) {
+  // first
+  if (x) {
+    return 1;
+  } // after the if
+
+  return 2;
+  // last
+}
+`,
+		},
+		{
+			name: "comments within expressions stay next to them",
+			src: `y = [function () {
+  return 1
+}, /* two */ 2]
+f(a, /* b */ b)
+f(a /* a */)
+let c = /* c */ -1, d = e.f /* f */;
+`,
+			expected: `y = [function () {
+  return 1;
+}, /* two */ 2];
+f(a, /* b */ b);
+f(a /* a */);
+let c = /* c */ -1, d = e.f /* f */;
+`,
+		},
+		{
+			name: "single line comments within lists",
+			src: `x = {
+  a: 1, // one
+  b: 2
+}
+f(a, // a
+  b)
+`,
+			expected: `x = {
+  a: 1,
+  // one
+  b: 2
+};
+f(
+  a,
+  // a
+  b
+);
+`,
+		},
+		{
+			name: "comments within empty blocks",
+			src: `if (a) { /* a */ } else {
+  // b
+}
+function f() { /* f */ }
+g = () => {
+  // g
+}
+`,
+			expected: `if (a) {
+  /* a */
+} else {
+  // b
+}
+function f() {
+  /* f */
+}
+g = () => {
+  // g
+};
+`,
+		},
+		{
+			name: "wrapping",
+			src: `const result = someFunction(firstArgument, secondArgument, [thirdArgument, fourth]);
+import { aVeryLongSpecifierName, anotherVeryLongSpecifierName, yetAnotherOne } from "m";
+call(function () { return 1 }, b);
+`,
+			expected: `const result = someFunction(
+  firstArgument,
+  secondArgument,
+  [thirdArgument, fourth]
+);
+import {
+  aVeryLongSpecifierName,
+  anotherVeryLongSpecifierName,
+  yetAnotherOne
+} from "m";
+call(function () {
+  return 1;
+}, b);
+`,
+		},
+		{
+			name:     "quotes",
+			src:      `a = 'b'; c = 'it\'s'; d = 'say "hi"';` + "\n",
+			expected: `a = "b";` + "\n" + `c = "it's";` + "\n" + `d = 'say "hi"';` + "\n",
+		},
+		{
+			name:     "numbers as member objects",
+			src:      "(1.5).toFixed(1); (.5).x; (1e21).x\n",
+			expected: "(1.5).toFixed(1);\n(.5).x;\n(1e21).x;\n",
+		},
+		{
+			name:     "empty",
+			src:      "\n\n",
+			expected: "",
+		},
+		{
+			name:     "only comments",
+			src:      "// a\n\n\n/* b */\n",
+			expected: "// a\n\n/* b */\n",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assertFormat(t, tc.src, tc.expected, DefaultOptions)
+		})
+	}
+
+	t.Run("without semicolons", func(t *testing.T) {
+		options := DefaultOptions
+		options.OmitSemicolons = true
+		options.Quote = printer.QuoteSingle
+		src := "let a = \"b\"; (function () {})(); [1, 2].map(f); `t`.length; if (a) ;\n"
+		expected := "let a = 'b'\n;(function () {})()\n;[1, 2].map(f)\n;`t`.length\nif (a) ;\n"
+		assertFormat(t, src, expected, options)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, err := Source("a = (", parser.Options{}, DefaultOptions); err == nil {
+			t.Errorf("expected a syntax error")
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around the changes of a hunk.
+const contextLines = 3
+
+// Diff returns the unified diff of the lines of old and new, named oldName and
+// newName in its header, or an empty string if they are equal.
+func Diff(oldName, old, newName, new string) string {
+	if old == new {
+		return ""
+	}
+	a, b := splitLines(old), splitLines(new)
+	edits := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(edits); {
+		first := nextChange(edits, start)
+		if first == len(edits) {
+			break
+		}
+		// a hunk takes the following changes that are at most 2*contextLines
+		// unchanged lines apart
+		last := lastChange(edits, first)
+		for {
+			next := nextChange(edits, last+1)
+			if next == len(edits) || next-last-1 > 2*contextLines {
+				break
+			}
+			last = lastChange(edits, next)
+		}
+		from := first - contextLines
+		if from < start {
+			from = start
+		}
+		to := last + 1 + contextLines
+		if to > len(edits) {
+			to = len(edits)
+		}
+		writeHunk(&out, edits[from:to])
+		start = to
+	}
+	return out.String()
+}
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+	a, b int // the 0-based line of the edit in old and new
+}
+
+// nextChange returns the index of the first change from the given one.
+func nextChange(edits []edit, from int) int {
+	for from < len(edits) && edits[from].op == ' ' {
+		from++
+	}
+	return from
+}
+
+// lastChange returns the index of the last of the changes in a row starting at
+// the given one.
+func lastChange(edits []edit, from int) int {
+	for from+1 < len(edits) && edits[from+1].op != ' ' {
+		from++
+	}
+	return from
+}
+
+func writeHunk(out *strings.Builder, edits []edit) {
+	oldLines, newLines := 0, 0
+	for _, e := range edits {
+		if e.op != '+' {
+			oldLines++
+		}
+		if e.op != '-' {
+			newLines++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[0].a, oldLines), hunkRange(edits[0].b, newLines))
+	for _, e := range edits {
+		out.WriteByte(e.op)
+		out.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		// an empty range is positioned at the line before it
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, following
+// Myers' O(ND) algorithm.
+//
+// http://www.xmailserver.org/diff2.pdf
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	// the furthest reaching x of each diagonal k, for each number of edits d
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1] // down, inserting b[y]
+			} else {
+				x = v[offset+k-1] + 1 // right, deleting a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				break search
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	// walk the trace back from (n, m) to (0, 0)
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		var prevK int
+		if d == 0 {
+			prevK = 0
+		} else if k == -d || k != d && trace[d-1][k-1+d-1] < trace[d-1][k+1+d-1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = trace[d-1][prevK+d-1]
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			edits = append(edits, edit{op: ' ', line: a[x], a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				edits = append(edits, edit{op: '+', line: b[y], a: x, b: y})
+			} else {
+				x--
+				edits = append(edits, edit{op: '-', line: a[x], a: x, b: y})
+			}
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,46 @@
+package internal
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	tcs := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{name: "equal", old: "a\nb\n", new: "a\nb\n", expected: ""},
+		{
+			name: "changed line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n" +
+				" a\n-b\n+B\n c\n",
+		},
+		{
+			name: "distant changes get a hunk each",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+				"@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			name:     "empty old",
+			old:      "",
+			new:      "a\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:     "missing final newline",
+			old:      "a",
+			new:      "a\n",
+			expected: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Diff("old", tc.old, "new", tc.new); got != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -51,6 +51,12 @@ func NewSimpleLogger(mode LoggerMode) *SimpleLogger {
 	return &SimpleLogger{mode: mode, writer: writer}
 }
 
+// IsDebug reports whether debug logs are kept, which callers check before
+// building costly ones.
+func (l *SimpleLogger) IsDebug() bool {
+	return ModeDebug&l.mode > 0
+}
+
 func (l *SimpleLogger) Debug(format string, args ...any) {
 	if ModeDebug&l.mode > 0 {
 		l.writer.WriteString(fmt.Sprintf(format, args...))
@@ -0,0 +1,93 @@
+package lexer
+
+import "strings"
+
+// Comments are scanned as whitespace, except that a MultiLineComment spanning
+// several lines counts as a LineTerminator. They are recorded apart from the
+// tokens, for tools that print the source back.
+//
+// https://262.ecma-international.org/#sec-comments
+
+// Comment is a comment of the source text, positioned like a token.
+type Comment struct {
+	// Text is the source text of the comment, delimiters included
+	Text   string
+	Line   int
+	Column int
+}
+
+// EndLine returns the line the comment ends at.
+func (c Comment) EndLine() int {
+	return c.Line + strings.Count(c.Text, "\n")
+}
+
+// Comments returns the comments found by ScanAll, in source order.
+func (s *Lexer) Comments() []Comment {
+	return s.comments
+}
+
+// isCommentStart reports whether a comment starts at the cursor. Besides '//'
+// and '/*', Annex B allows '<!--' anywhere and '-->' as the first token of a
+// line.
+//
+// https://262.ecma-international.org/#sec-html-like-comments
+func (s *Lexer) isCommentStart() bool {
+	rest := s.src[s.srcCursorHead:]
+	switch {
+	case strings.HasPrefix(rest, "//"), strings.HasPrefix(rest, "/*"):
+		return true
+	case strings.HasPrefix(rest, "<!--"):
+		return s.options.AnnexB
+	case strings.HasPrefix(rest, "-->"):
+		return s.options.AnnexB && s.newline
+	}
+	return false
+}
+
+// scanComment skips the comment at the cursor, leaving it at the comment's
+// last char.
+func (s *Lexer) scanComment() Token {
+	start := s.srcCursorHead
+	comment := Comment{Line: s.line, Column: start - s.lineStart}
+
+	var token Token
+	if strings.HasPrefix(s.src[start:], "/*") {
+		token = s.scanMultiLineComment()
+	} else {
+		token = s.scanSingleLineComment()
+	}
+	if token.Type == TWhitespace {
+		comment.Text = strings.TrimRight(s.src[start:s.srcCursorHead+1], "\r")
+		s.comments = append(s.comments, comment)
+	}
+	return token
+}
+
+// SingleLineComment ::
+// | '//' SingleLineCommentChars?
+//
+// The LineTerminator ending it is not part of the comment.
+func (s *Lexer) scanSingleLineComment() Token {
+	for next := s.PeekN(1); next != EOF && !isNewline(next); next = s.PeekN(1) {
+		s.Next()
+	}
+	return Token{Type: TWhitespace, Lexeme: " ", Literal: " "}
+}
+
+// MultiLineComment ::
+// | '/*' MultiLineCommentChars? '*/'
+func (s *Lexer) scanMultiLineComment() Token {
+	end := strings.Index(s.src[s.srcCursorHead+2:], "*/")
+	if end < 0 {
+		s.eofErrorf(errUnterminatedComment.Error())
+		return TokenUnknown
+	}
+	last := s.srcCursorHead + 2 + end + 1 // offset of the closing '/'
+	for i := s.srcCursorHead; i < last; i++ {
+		if isNewline(rune(s.src[i])) {
+			s.markNewline(i)
+		}
+	}
+	s.Jump(uint(last - s.srcCursorHead))
+	return Token{Type: TWhitespace, Lexeme: " ", Literal: " "}
+}
@@ -0,0 +1,117 @@
+package lexer
+
+import (
+	"fmt"
+	"testing"
+
+	gojs "github.com/ruiconti/gojs/internal"
+)
+
+func TestComments(t *testing.T) {
+	src := "a // one\n/* two */ b /* three\nfour */ c /**/ / d //"
+	expected := []Token{
+		{Type: TIdentifier, Lexeme: "a", Line: 1, Column: 0},
+		{Type: TIdentifier, Lexeme: "b", Line: 2, Column: 10, NewlineBefore: true},
+		{Type: TIdentifier, Lexeme: "c", Line: 3, Column: 8, NewlineBefore: true},
+		{Type: TSlash, Lexeme: "/", Line: 3, Column: 15},
+		{Type: TIdentifier, Lexeme: "d", Line: 3, Column: 17},
+	}
+
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	got, errs := NewLexer(src, logger).ScanAll()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	assertInternal(
+		t,
+		logger,
+		got,
+		expected,
+		func(a, b Token) bool {
+			return a.Type == b.Type && a.Lexeme == b.Lexeme && a.Line == b.Line && a.Column == b.Column && a.NewlineBefore == b.NewlineBefore
+		},
+		func(a Token) string {
+			return fmt.Sprintf("%v %d:%d (newline:%v)", a.Lexeme, a.Line, a.Column, a.NewlineBefore)
+		},
+		func(a Token) string { return a.Lexeme + " " },
+	)
+}
+
+func TestComments_Recorded(t *testing.T) {
+	src := "a // one\r\n/* two */ b /* three\nfour */ c //"
+	expected := []Comment{
+		{Text: "// one", Line: 1, Column: 2},
+		{Text: "/* two */", Line: 2, Column: 0},
+		{Text: "/* three\nfour */", Line: 2, Column: 12},
+		{Text: "//", Line: 3, Column: 10},
+	}
+
+	lexer := NewLexer(src, gojs.NewSimpleLogger(gojs.ModeDebug))
+	if _, errs := lexer.ScanAll(); len(errs) > 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	got := lexer.Comments()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d comments, got %d: %v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], got[i])
+		}
+	}
+	if line := got[2].EndLine(); line != 3 {
+		t.Errorf("expected the third comment to end at line 3, got %d", line)
+	}
+}
+
+func TestComments_Unterminated(t *testing.T) {
+	for _, src := range []string{"/*", "a /* b", "/* a *"} {
+		t.Run(src, func(t *testing.T) {
+			logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+			if _, errs := NewLexer(src, logger).ScanAll(); len(errs) == 0 {
+				t.Errorf("expected an unterminated comment error")
+			}
+		})
+	}
+}
+
+// SingleLineHTMLOpenComment ::
+// | '<!--' SingleLineCommentChars?
+//
+// SingleLineHTMLCloseComment ::
+// | LineTerminatorSequence HTMLCloseComment
+func TestComments_HTMLLike(t *testing.T) {
+	src := "a <!-- b\nc --> d\n--> e\n /* f */ --> g\nh"
+	t.Run("with Annex B", func(t *testing.T) {
+		expected := []Token{
+			{Type: TIdentifier, Lexeme: "a", Literal: "a"},
+			{Type: TIdentifier, Lexeme: "c", Literal: "c"},
+			{Type: TMinusMinus, Lexeme: "--"},
+			{Type: TGreaterThan, Lexeme: ">"},
+			{Type: TIdentifier, Lexeme: "d", Literal: "d"},
+			{Type: TIdentifier, Lexeme: "h", Literal: "h"},
+		}
+		logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+		got, errs := NewLexerWithOptions(src, logger, Options{AnnexB: true}).ScanAll()
+		if len(errs) > 0 {
+			t.Fatalf("unexpected error: %v", errs)
+		}
+		assertTokens(t, logger, got, expected)
+	})
+
+	t.Run("without Annex B", func(t *testing.T) {
+		expected := []Token{
+			{Type: TIdentifier, Lexeme: "a", Literal: "a"},
+			{Type: TLessThan, Lexeme: "<"},
+			{Type: TBang, Lexeme: "!"},
+			{Type: TMinusMinus, Lexeme: "--"},
+			{Type: TIdentifier, Lexeme: "b", Literal: "b"},
+		}
+		logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+		got, errs := NewLexer("a <!-- b", logger).ScanAll()
+		if len(errs) > 0 {
+			t.Fatalf("unexpected error: %v", errs)
+		}
+		assertTokens(t, logger, got, expected)
+	})
+}
@@ -1,5 +1,10 @@
 package lexer
 
+import (
+	"strconv"
+	"strings"
+)
+
 // String literals
 type StringLiteralType string
 
@@ -37,31 +42,42 @@ func (s *Lexer) scanStringLiteral() Token {
 	// we are capturing only the contents, literal part of the string, so we advance
 	// the start index too
 	// literalStart := s.srcCursorHead
+	closed, failed := false, false
 	s.PeekLoop(func(ch rune) bool {
 		if ch == EOF {
-			// we found an EOF in mid-string, so this is an error
-			s.Errorf(errUnterminatedStringLiteral.Error())
+			// we found an EOF in mid-string, which more source text may complete
 			return false
 		}
 
 		// strings can accept virtually all characters, so we just check for the exceptions
-		// which are the escape sequence and the end quote
+		// which are the escape sequence, line terminators and the end quote
 		switch ch {
 		case '\\':
-			if err = s.rejectEscapedSequence(); err != nil {
+			if err = s.rejectEscapedSequence(); err == errEOF {
+				return false
+			} else if err != nil {
 				s.Errorf(errUnterminatedStringLiteral.Error())
+				failed = true
 				return false
 			}
+		case '\n', '\r':
+			// only escaped line terminators, which are line continuations, may
+			// appear in strings
+			s.Errorf(errUnterminatedStringLiteral.Error())
+			failed = true
+			return false
 		case '"':
 			s.Next()
 			if strType == DoubleQuote {
 				// end of string
+				closed = true
 				return false
 			}
 		case '\'':
 			s.Next()
 			if strType == SingleQuote {
 				// end of string
+				closed = true
 				return false
 			}
 		default:
@@ -70,6 +86,10 @@ func (s *Lexer) scanStringLiteral() Token {
 		}
 		return true
 	})
+	if !closed && !failed {
+		s.eofErrorf(errUnterminatedStringLiteral.Error())
+		return TokenUnknown
+	}
 
 	var typ TokenType
 	if strType == SingleQuote {
@@ -81,6 +101,238 @@ func (s *Lexer) scanStringLiteral() Token {
 	return tok
 }
 
+// Template literals
+//
+// Template ::
+// | NoSubstitutionTemplate :: '`' TemplateCharacters? '`'
+// | TemplateHead :: '`' TemplateCharacters? '${'
+//
+// TemplateSubstitutionTail ::
+// | TemplateMiddle :: '}' TemplateCharacters? '${'
+// | TemplateTail :: '}' TemplateCharacters? '`'
+//
+// The token's literal holds the template raw value (TRV) of its characters.
+//
+// https://262.ecma-international.org/#sec-template-literal-lexical-components
+func (s *Lexer) scanTemplate() Token {
+	opening := s.Peek()
+	s.Next() // consume '`' or '}'
+
+	var terminated, substitution bool
+	s.PeekLoop(func(ch rune) bool {
+		switch {
+		case ch == EOF || s.srcCursorOOB:
+			return false
+		case ch == '\\':
+			// escapes are only validated when cooking, as tagged templates
+			// accept invalid ones
+			s.Next() // consume '\\'
+			if isNewline(s.Peek()) {
+				s.markNewline(s.srcCursorHead)
+			}
+			s.Next() // consume the escaped char
+		case ch == '`':
+			s.Next() // consume '`'
+			terminated = true
+			return false
+		case ch == '$' && s.PeekN(1) == '{':
+			if s.srcCursorHead+1 == s.srcEnd {
+				// the substitution starts at the end of the source
+				return false
+			}
+			s.Jump(2) // consume '${'
+			terminated, substitution = true, true
+			return false
+		case isNewline(ch):
+			s.markNewline(s.srcCursorHead)
+			s.Next()
+		default:
+			s.Next()
+		}
+		return true
+	})
+	if !terminated {
+		s.eofErrorf(errUnterminatedTemplateLiteral.Error())
+		return TokenUnknown
+	}
+
+	var typ TokenType
+	switch {
+	case opening == '`' && !substitution:
+		typ = TTemplateLiteral
+	case opening == '`':
+		typ = TTemplateHead
+		s.templateDepths = append(s.templateDepths, s.braceDepth)
+	case substitution:
+		typ = TTemplateMiddle
+	default:
+		typ = TTemplateTail
+		s.templateDepths = s.templateDepths[:len(s.templateDepths)-1]
+	}
+
+	tok := s.CreateLiteralToken(typ)
+	closing := 1
+	if substitution {
+		closing = 2
+	}
+	raw := tok.Lexeme[1 : len(tok.Lexeme)-closing]
+	// TRV normalizes both <CR><LF> and <CR> to <LF>
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	tok.Literal = strings.ReplaceAll(raw, "\r", "\n")
+	return tok
+}
+
+// inTemplateSubstitution reports whether a '}' at the current brace depth
+// closes a template substitution rather than a block or an object literal.
+func (s *Lexer) inTemplateSubstitution() bool {
+	n := len(s.templateDepths)
+	return n > 0 && s.templateDepths[n-1] == s.braceDepth
+}
+
+// regExpAllowed reports whether a '/' at the cursor starts a regular
+// expression literal rather than a division. Lacking the parser's context, it
+// is a division when the previous token ends an expression, as in `a / b` or
+// `f() / 2`, and a regular expression otherwise, as in `x = /a/` or
+// `return /a/`.
+func (s *Lexer) regExpAllowed() bool {
+	if next := s.PeekN(1); next == '/' || next == '*' {
+		return false
+	}
+	if len(s.tokens) == 0 {
+		return true
+	}
+	switch s.tokens[len(s.tokens)-1].Type {
+	case TIdentifier, TNumericLiteral, TStringLiteral_SingleQuote, TStringLiteral_DoubleQuote,
+		TRegularExpressionLiteral, TTemplateLiteral, TTemplateTail,
+		TRightParen, TRightBracket, TPlusPlus, TMinusMinus,
+		TThis, TSuper, TTrue, TFalse, TNull, TUndefined, TAsync, TLet:
+		return false
+	}
+	return true
+}
+
+// RegularExpressionLiteral ::
+// | '/' RegularExpressionBody '/' RegularExpressionFlags
+//
+// The body ends at the first '/' that is neither escaped nor inside a class,
+// and may not contain a LineTerminator. Its pattern and flags are validated
+// by the parser, see the regexp package.
+//
+// https://262.ecma-international.org/#sec-literals-regular-expression-literals
+func (s *Lexer) scanRegularExpression() Token {
+	s.Next() // consume '/'
+
+	var terminated, inClass bool
+	s.PeekLoop(func(ch rune) bool {
+		switch {
+		case ch == EOF || s.srcCursorOOB || isNewline(ch):
+			return false
+		case ch == '\\':
+			s.Next() // consume '\\'
+			if isNewline(s.Peek()) {
+				return false
+			}
+		case ch == '[':
+			inClass = true
+		case ch == ']':
+			inClass = false
+		case ch == '/' && !inClass:
+			terminated = true
+		}
+		s.Next()
+		return !terminated
+	})
+	if !terminated {
+		s.Errorf(errUnterminatedRegExpLiteral.Error())
+		return TokenUnknown
+	}
+
+	s.PeekLoop(func(ch rune) bool {
+		if s.srcCursorOOB || !(isId(ch) || isDec(ch)) {
+			return false
+		}
+		s.Next()
+		return true
+	})
+	return s.CreateLiteralToken(TRegularExpressionLiteral)
+}
+
+// CookTemplate computes the template value (TV) of a template's raw value,
+// reporting false when it contains an escape sequence that is only allowed
+// in tagged templates, e.g. `\unicode` or `\01`.
+func CookTemplate(raw string) (string, bool) {
+	var cooked strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			cooked.WriteByte(raw[i])
+			continue
+		}
+
+		i++ // skip '\\'
+		if i >= len(raw) {
+			return "", false
+		}
+		switch esc := raw[i]; {
+		case esc == 'b':
+			cooked.WriteByte('\b')
+		case esc == 'f':
+			cooked.WriteByte('\f')
+		case esc == 'n':
+			cooked.WriteByte('\n')
+		case esc == 'r':
+			cooked.WriteByte('\r')
+		case esc == 't':
+			cooked.WriteByte('\t')
+		case esc == 'v':
+			cooked.WriteByte('\v')
+		case esc == '\n':
+			// LineContinuation contributes nothing
+		case esc == '0' && (i+1 >= len(raw) || !isDec(rune(raw[i+1]))):
+			cooked.WriteByte(0)
+		case isDec(rune(esc)):
+			// LegacyOctalEscapeSequence and NonOctalDecimalEscapeSequence
+			return "", false
+		case esc == 'x':
+			// HexEscapeSequence :: x HexDigit HexDigit
+			if i+2 >= len(raw) {
+				return "", false
+			}
+			value, err := strconv.ParseUint(raw[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", false
+			}
+			cooked.WriteRune(rune(value))
+			i += 2
+		case esc == 'u':
+			// UnicodeEscapeSequence :: u Hex4Digits | u{ CodePoint }
+			var digits string
+			if i+1 < len(raw) && raw[i+1] == '{' {
+				end := strings.IndexByte(raw[i:], '}')
+				if end < 0 {
+					return "", false
+				}
+				digits = raw[i+2 : i+end]
+				i += end
+			} else {
+				if i+4 >= len(raw) {
+					return "", false
+				}
+				digits = raw[i+1 : i+5]
+				i += 4
+			}
+			value, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil || digits == "" || value > 0x10FFFF {
+				return "", false
+			}
+			cooked.WriteRune(rune(value))
+		default:
+			// SingleEscapeCharacter or NonEscapeCharacter
+			cooked.WriteByte(esc)
+		}
+	}
+	return cooked.String(), true
+}
+
 // Numeric literal
 type NumericLiteralType string
 
@@ -260,13 +512,14 @@ func (s *Lexer) scanNumericLiteral() Token {
 		// because we are already at a valid number
 		numberType = LiteralDecimal
 	} else if char == '.' {
-		hasDot = true
-		err := s.rejectDot()
-		if err != nil {
-			// not a valid number starting with . but can be valid punctuation
+		if !isDec(charNext) {
+			// not a valid number starting with . but can be valid punctuation,
+			// as the . of a member named e
 			return s.scanPunctuation()
 		}
-		numberType = LiteralDecimal // can be a valid number
+		hasDot = true
+		s.Next() // consume '.'
+		numberType = LiteralDecimal
 	}
 
 	// 1: parse digits
@@ -406,12 +659,13 @@ func (s *Lexer) rejectEscapedUnicode() error {
 // EscapeSequence ::
 // | CharacterEscapeSequence
 // | 0 [lookahead ∉ DecimalDigit]
-// | LegacyOctalEscapeSequence(!)
-// | NonOctalDecimalEscapeSequence(!)
+// | LegacyOctalEscapeSequence
+// | NonOctalDecimalEscapeSequence
 // | HexEscapeSequence
 // | UnicodeEscapeSequence
 //
-// !: TODO
+// LegacyOctalEscapeSequence and NonOctalDecimalEscapeSequence are forbidden in
+// strict mode code, which is left to the parser.
 func (s *Lexer) rejectEscapedSequence() error {
 	var char rune
 
@@ -424,7 +678,7 @@ func (s *Lexer) rejectEscapedSequence() error {
 
 	switch {
 	case char == EOF:
-		s.Errorf(errEOF.Error())
+		// the caller reports the literal the end of the source interrupts
 		return errEOF
 	case char == 'u':
 		err := s.rejectEscapedUnicode()
@@ -443,9 +697,6 @@ func (s *Lexer) rejectEscapedSequence() error {
 			s.Errorf(errInvalidEscapedSequence.Error())
 			return errInvalidEscapedSequence
 		}
-	case isDec(char) && char != '0':
-		// EscapeSequence -> 0 [lookahead ∉ DecimalDigit]
-		return errInvalidEscapedSequence
 	default:
 		// EscapeSequence ->
 		// CharacterEscapeSequence :: SingleEscapeCharacter | NonEscapeCharacter
@@ -37,11 +37,12 @@ func TestString_DoubleQuote(t *testing.T) {
 // StringLiteral ::
 // | " (SourceCharacter | \ EscapeSequence)? "
 func TestString_DoubleQuote_Escaped(t *testing.T) {
-	src := `"\\\\" "\"\'\\a\b\c\d\e\f\g\h\i\j\k\l\m\n\o\p\q\r\s\t\v\w\y\z" "\00\01\02\03\04\05\06\07\08\09" "\x10\x20\x30\x40\x50\x60\x70\x80\x90\xA0\xB0\xC0\xD0\xE0\xF0" "\u0000\u0001\u0005\u9999"`
+	src := `"\\\\" "\"\'\\a\b\c\d\e\f\g\h\i\j\k\l\m\n\o\p\q\r\s\t\v\w\y\z" "\00\01\02\03\04\05\06\07\08\09" "\1\7\8\9\377" "\x10\x20\x30\x40\x50\x60\x70\x80\x90\xA0\xB0\xC0\xD0\xE0\xF0" "\u0000\u0001\u0005\u9999"`
 	expected := []Token{
 		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\\\\"`, Literal: `"\\\\"`, Line: 0, Column: 0},
 		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\"\'\\a\b\c\d\e\f\g\h\i\j\k\l\m\n\o\p\q\r\s\t\v\w\y\z"`, Literal: `"\a\b\c\d\e\f\g\h\i\j\k\l\m\n\o\p\q\r\s\t\v\w\y\z"`, Line: 0, Column: 0},
 		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\00\01\02\03\04\05\06\07\08\09"`, Literal: `"\00\01\02\03\04\05\06\07\08\09"`, Line: 0, Column: 0},
+		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\1\7\8\9\377"`, Literal: `"\1\7\8\9\377"`, Line: 0, Column: 0},
 		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\x10\x20\x30\x40\x50\x60\x70\x80\x90\xA0\xB0\xC0\xD0\xE0\xF0"`, Literal: `"\x10\x20\x30\x40\x50\x60\x70\x80\x90\xA0\xB0\xC0\xD0\xE0\xF0"`, Line: 0, Column: 0},
 		{Type: TStringLiteral_DoubleQuote, Lexeme: `"\u0000\u0001\u0005\u9999"`, Literal: `"\u0000\u0001\u0005\u9999"`, Line: 0, Column: 0},
 	}
@@ -190,7 +191,6 @@ func TestLiteral_Digit_Decimal_Prod2_Err(t *testing.T) {
 		"10E",
 		".0e",
 		"0.e",
-		".e",
 		"10_e",
 		"10_",
 		"10_.",
@@ -233,6 +233,29 @@ func TestLiteral_Digit_Decimal_Prod2(t *testing.T) {
 	}
 	assertLexemes(t, logger, got, expected)
 }
+func TestLiteral_Digit_Decimal_MemberE(t *testing.T) {
+	// a . before e is the . of a member, not a number, even with no object
+	// before it: .e is no longer a malformed number but a . and an e
+	src := `o.entries x.e .e`
+	expected := []Token{
+		{Type: TIdentifier, Lexeme: "o", Line: 0, Column: 0},
+		{Type: TPeriod, Lexeme: ".", Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "entries", Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "x", Line: 0, Column: 0},
+		{Type: TPeriod, Lexeme: ".", Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "e", Line: 0, Column: 0},
+		{Type: TPeriod, Lexeme: ".", Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "e", Line: 0, Column: 0},
+	}
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	lexer := NewLexer(src, logger)
+	got, err := lexer.ScanAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertLexemes(t, logger, got, expected)
+}
+
 func TestLiteral_Digit_Decimal_Prod3(t *testing.T) {
 	src := `1_35E-50_0 00000E-50_00000 000000e000000 007654321e+1 000e+1`
 	expected := []Token{
@@ -431,7 +454,6 @@ func TestIdentifier_Keywords(t *testing.T) {
 // | NoSubstitutionTemplate
 // | TemplateHead
 func TestLiteral_Template(t *testing.T) {
-	t.Skip("NotImplemented")
 	src := "`$end$` `$$$` `        ` `scan\n\nthis\n\ntoo!` `bla\n\n\nbla`"
 	expected := []Token{
 		{Type: TTemplateLiteral, Lexeme: "`$end$`", Literal: "$end$", Line: 0, Column: 0},
@@ -445,3 +467,112 @@ func TestLiteral_Template(t *testing.T) {
 	got, _ := lexer.ScanAll()
 	assertTokens(t, logger, got, expected)
 }
+
+// Template ::
+// | TemplateHead Expression TemplateSubstitutionTail
+//
+// TemplateSubstitutionTail ::
+// | TemplateMiddle Expression TemplateSubstitutionTail
+// | TemplateTail
+func TestLiteral_TemplateSubstitutions(t *testing.T) {
+	src := "`a${b}c${ {d: `e${f}`} }g\\`h`"
+	expected := []Token{
+		{Type: TTemplateHead, Lexeme: "`a${", Literal: "a"},
+		{Type: TIdentifier, Lexeme: "b", Literal: "b"},
+		{Type: TTemplateMiddle, Lexeme: "}c${", Literal: "c"},
+		{Type: TLeftBrace, Lexeme: "{"},
+		{Type: TIdentifier, Lexeme: "d", Literal: "d"},
+		{Type: TColon, Lexeme: ":"},
+		{Type: TTemplateHead, Lexeme: "`e${", Literal: "e"},
+		{Type: TIdentifier, Lexeme: "f", Literal: "f"},
+		{Type: TTemplateTail, Lexeme: "}`", Literal: ""},
+		{Type: TRightBrace, Lexeme: "}"},
+		{Type: TTemplateTail, Lexeme: "}g\\`h`", Literal: "g\\`h"},
+	}
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	lexer := NewLexer(src, logger)
+	got, errs := lexer.ScanAll()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	assertTokens(t, logger, got, expected)
+}
+
+func TestLiteral_TemplateRawValue(t *testing.T) {
+	src := "`a\r\nb\rc` `"
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	lexer := NewLexer(src, logger)
+	got, errs := lexer.ScanAll()
+	if len(errs) != 1 {
+		t.Fatalf("expected an unterminated template error, got %v", errs)
+	}
+	expected := []Token{
+		{Type: TTemplateLiteral, Lexeme: "`a\r\nb\rc`", Literal: "a\nb\nc"},
+	}
+	assertTokens(t, logger, got, expected)
+}
+
+func TestCookTemplate(t *testing.T) {
+	cases := []struct {
+		raw    string
+		cooked string
+		ok     bool
+	}{
+		{`plain`, "plain", true},
+		{"\\n\\t\\\\\\`", "\n\t\\`", true},
+		{`\x41\u0042\u{43}\0`, "ABC\x00", true},
+		{"line\\\ncontinuation", "linecontinuation", true},
+		{`\$\{`, "${", true},
+		{`\unicode`, "", false},
+		{`\xZ`, "", false},
+		{`\01`, "", false},
+		{`\8`, "", false},
+		{`\u{110000}`, "", false},
+	}
+	for _, c := range cases {
+		cooked, ok := CookTemplate(c.raw)
+		if ok != c.ok || cooked != c.cooked {
+			t.Errorf("CookTemplate(%q) = %q, %v; expected %q, %v", c.raw, cooked, ok, c.cooked, c.ok)
+		}
+	}
+}
+
+// RegularExpressionLiteral ::
+// | / RegularExpressionBody / RegularExpressionFlags
+func TestLiteral_RegularExpression(t *testing.T) {
+	src := `x = /[/\]]+\/a/gi.test(a / b / c) || /=/`
+	expected := []Token{
+		{Type: TIdentifier, Lexeme: "x", Literal: "x"},
+		{Type: TAssign, Lexeme: "="},
+		{Type: TRegularExpressionLiteral, Lexeme: `/[/\]]+\/a/gi`, Literal: `/[/\]]+\/a/gi`},
+		{Type: TPeriod, Lexeme: "."},
+		{Type: TIdentifier, Lexeme: "test", Literal: "test"},
+		{Type: TLeftParen, Lexeme: "("},
+		{Type: TIdentifier, Lexeme: "a", Literal: "a"},
+		{Type: TSlash, Lexeme: "/"},
+		{Type: TIdentifier, Lexeme: "b", Literal: "b"},
+		{Type: TSlash, Lexeme: "/"},
+		{Type: TIdentifier, Lexeme: "c", Literal: "c"},
+		{Type: TRightParen, Lexeme: ")"},
+		{Type: TLogicalOr, Lexeme: "||"},
+		{Type: TRegularExpressionLiteral, Lexeme: `/=/`, Literal: `/=/`},
+	}
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	lexer := NewLexer(src, logger)
+	got, errs := lexer.ScanAll()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	assertTokens(t, logger, got, expected)
+}
+
+func TestLiteral_RegularExpression_Unterminated(t *testing.T) {
+	for _, src := range []string{"/abc", "/a\nb/", "/[/", `/a\`} {
+		t.Run(src, func(t *testing.T) {
+			logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+			if _, errs := NewLexer(src, logger).ScanAll(); len(errs) == 0 {
+				t.Errorf("expected an unterminated regular expression error")
+			}
+		})
+	}
+}
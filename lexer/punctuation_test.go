@@ -125,11 +125,15 @@ func TestPunctuation_PlusMinusAssign(t *testing.T) {
 	assertLexemes(t, logger, got, expected)
 }
 func TestPunctuation_StarSlashAssign(t *testing.T) {
-	src := `* *= / /= **`
+	// a '/' that follows an operator starts a regular expression, so the
+	// slashes follow identifiers
+	src := `* *= a / b /= **`
 	expected := []Token{
 		{Type: TStar, Lexeme: "*", Literal: nil, Line: 0, Column: 0},
 		{Type: TStarAssign, Lexeme: "*=", Literal: nil, Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "a", Literal: "a", Line: 0, Column: 0},
 		{Type: TSlash, Lexeme: "/", Literal: nil, Line: 0, Column: 0},
+		{Type: TIdentifier, Lexeme: "b", Literal: "b", Line: 0, Column: 0},
 		{Type: TSlashAssign, Lexeme: "/=", Literal: nil, Line: 0, Column: 0},
 		{Type: TStarStar, Lexeme: "**", Literal: nil, Line: 0, Column: 0},
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ruiconti/gojs/internal"
 	gojs "github.com/ruiconti/gojs/internal"
@@ -13,7 +14,7 @@ const EOF rune = -1
 
 var (
 	ReservedKeywords       = internal.MapInvert(ReservedWordNames)
-	TokenUnknown     Token = Token{TUnknown, "", "", 0, 0}
+	TokenUnknown     Token = Token{Type: TUnknown, Lexeme: "", Literal: ""}
 )
 
 // Errors
@@ -22,13 +23,54 @@ var (
 	errNoLiteralAfterNumber = fmt.Errorf("no literal after number")
 	errUnexpectedToken      = fmt.Errorf("unexpected token")
 	// TODO: below are untested
-	errUnterminatedStringLiteral = fmt.Errorf("unterminated string literal")
-	errInvalidEscapedSequence    = errors.New("invalid escaped sequence")
+	errUnterminatedStringLiteral   = fmt.Errorf("unterminated string literal")
+	errUnterminatedTemplateLiteral = fmt.Errorf("unterminated template literal")
+	errUnterminatedRegExpLiteral   = fmt.Errorf("unterminated regular expression literal")
+	errUnterminatedComment         = fmt.Errorf("unterminated comment")
+	errInvalidEscapedSequence      = errors.New("invalid escaped sequence")
+
+	// ErrUnexpectedEOF is matched, with errors.Is, by the errors of tokens
+	// the end of the source interrupts, which more source text may
+	// complete: strings, templates and multi-line comments
+	ErrUnexpectedEOF = errors.New("unexpected end of input")
 )
 
+// Error is a lexical error, positioned at the first char of the token it was
+// found in.
+type Error struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// unexpectedEOF is an error found at the end of the source.
+type unexpectedEOF struct {
+	error
+}
+
+func (e unexpectedEOF) Unwrap() error        { return e.error }
+func (e unexpectedEOF) Is(target error) bool { return target == ErrUnexpectedEOF }
+
+// Options configures the grammar a source text is scanned with.
+type Options struct {
+	// AnnexB enables the HTML-like comments of Annex B, which are only
+	// allowed in scripts
+	AnnexB bool
+}
+
 type Lexer struct {
 	// source string being scanned
 	src string
+	// grammar the source is scanned with
+	options Options
 	// token slice
 	tokens []Token
 	// reference to the logging mechanism
@@ -43,27 +85,45 @@ type Lexer struct {
 	srcEnd int
 	// store errors found while scanning
 	errors []error
-
-	// TODO: implement proper positioning
-	line   uint
-	column uint
+	// comments found while scanning
+	comments []Comment
+
+	// 1-based line of the current char
+	line int
+	// offset of the first char of the current line
+	lineStart int
+	// whether a LineTerminator was found since the last token
+	newline bool
+	// 1-based line and 0-based column of the first char of the token being
+	// scanned, where its errors are reported
+	tokenLine, tokenColumn int
+
+	// number of '{' currently open
+	braceDepth int
+	// brace depth at each open template substitution ('${'), innermost last
+	templateDepths []int
 }
 
 func NewLexer(src string, logger *gojs.SimpleLogger) *Lexer {
+	return NewLexerWithOptions(src, logger, Options{})
+}
+
+func NewLexerWithOptions(src string, logger *gojs.SimpleLogger, options Options) *Lexer {
 	if logger == nil {
 		logger = gojs.NewSimpleLogger(gojs.ModeDebug)
 	}
 
 	return &Lexer{
 		src:           src,
+		options:       options,
 		logger:        logger,
 		srcCursor:     -2,
 		srcCursorHead: 0,
 		srcCursorOOB:  false,
 		srcEnd:        len(src) - 1,
 		tokens:        []Token{},
-		line:          0,
-		column:        0,
+		line:          1,
+		lineStart:     0,
 	}
 }
 
@@ -166,15 +226,22 @@ func (s *Lexer) Scan() Token {
 		token = s.scanIdentifier()
 	case isStr(ch):
 		token = s.scanStringLiteral()
+	case ch == '`':
+		token = s.scanTemplate()
+	case ch == '}' && s.inTemplateSubstitution():
+		token = s.scanTemplate()
 	case isNumeric(ch):
 		token = s.scanNumericLiteral()
+	case s.isCommentStart():
+		token = s.scanComment()
+	case ch == '/' && s.regExpAllowed():
+		token = s.scanRegularExpression()
 	case isPunctuation(ch):
 		token = s.scanPunctuation()
 	case isWhitespace(ch):
 		token = Token{Type: TWhitespace, Lexeme: " ", Literal: " "}
 	case isNewline(ch):
-		s.line++
-		s.Next()
+		s.markNewline(s.srcCursorHead)
 		token = Token{Type: TWhitespace, Lexeme: " ", Literal: " "}
 	default:
 		token = TokenUnknown
@@ -200,14 +267,34 @@ mainloop:
 			panic("infinite loop found, aborting")
 		}
 		s.srcCursor = s.srcCursorHead
+		// a token may span several lines (e.g. templates), so its position
+		// is taken before scanning it
+		s.tokenLine, s.tokenColumn = s.line, s.srcCursor-s.lineStart
+		line, column, newline := s.tokenLine, s.tokenColumn, s.newline
 
+		errs := len(s.errors)
 		tok := s.Scan()
 		switch tok.Type {
 		case TUnknown:
+			if len(s.errors) == errs {
+				// no token starts with the char
+				ch, _ := utf8.DecodeRuneInString(s.src[s.srcCursorHead:])
+				s.Errorf("unexpected character %q", ch)
+			}
 			break mainloop
 		case TWhitespace:
 			s.Next()
 		default:
+			switch tok.Type {
+			case TLeftBrace:
+				s.braceDepth++
+			case TRightBrace:
+				s.braceDepth--
+			}
+			tok.Line = line
+			tok.Column = column
+			tok.NewlineBefore = newline
+			s.newline = false
 			s.tokens = append(s.tokens, tok)
 		}
 
@@ -221,6 +308,14 @@ mainloop:
 	return s.tokens, s.errors
 }
 
+// markNewline records a LineTerminator found at offset, so that the following
+// tokens are positioned in the next line.
+func (s *Lexer) markNewline(offset int) {
+	s.line++
+	s.lineStart = offset + 1
+	s.newline = true
+}
+
 // Printing utilities: all tokens
 func (s *Lexer) Tokens() string {
 	var sb strings.Builder
@@ -237,6 +332,10 @@ func (s *Lexer) Tokens() string {
 
 // Printing utilities: where the cursor is
 func (s *Lexer) PrettyPrintSrc() {
+	// the source and a line as long are written on every move of the cursor
+	if !s.logger.IsDebug() {
+		return
+	}
 	s.logger.Debug("%v", s.src)
 	cursor := []byte{}
 	for i := 0; i < s.srcCursorHead; i++ {
@@ -251,7 +350,13 @@ func (s *Lexer) Errorf(format string, values ...any) {
 	formatted := fmt.Sprintf(format, values...)
 	serr := fmt.Sprintf("%s COL:%d CH:%c", formatted, s.srcCursorHead, s.Peek())
 
-	s.errors = append(s.errors, errors.New(serr))
+	s.errors = append(s.errors, &Error{Line: s.tokenLine, Column: s.tokenColumn, Err: errors.New(formatted)})
 	s.PrettyPrintSrc()
 	s.logger.Error(serr + "\n")
 }
+
+// eofErrorf logs an error as Errorf does, found at the end of the source.
+func (s *Lexer) eofErrorf(format string, values ...any) {
+	s.Errorf(format, values...)
+	s.errors[len(s.errors)-1] = unexpectedEOF{s.errors[len(s.errors)-1]}
+}
@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"fmt"
+	"testing"
+
+	gojs "github.com/ruiconti/gojs/internal"
+)
+
+func TestScanner_Positions(t *testing.T) {
+	src := "a\n\nbc = 1;\n  foo"
+	expected := []Token{
+		{Type: TIdentifier, Lexeme: "a", Literal: "a", Line: 1, Column: 0},
+		{Type: TIdentifier, Lexeme: "bc", Literal: "bc", Line: 3, Column: 0, NewlineBefore: true},
+		{Type: TAssign, Lexeme: "=", Line: 3, Column: 3},
+		{Type: TNumericLiteral, Lexeme: "1", Literal: "1", Line: 3, Column: 5},
+		{Type: TSemicolon, Lexeme: ";", Line: 3, Column: 6},
+		{Type: TIdentifier, Lexeme: "foo", Literal: "foo", Line: 4, Column: 2, NewlineBefore: true},
+	}
+
+	logger := gojs.NewSimpleLogger(gojs.ModeDebug)
+	lexer := NewLexer(src, logger)
+	got, errs := lexer.ScanAll()
+	if len(errs) > 0 {
+		logger.DumpLogs()
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	assertInternal(
+		t,
+		logger,
+		got,
+		expected,
+		func(a, b Token) bool {
+			return a.Lexeme == b.Lexeme && a.Line == b.Line && a.Column == b.Column && a.NewlineBefore == b.NewlineBefore
+		},
+		func(a Token) string {
+			return fmt.Sprintf("%v %d:%d (newline:%v)", a.Lexeme, a.Line, a.Column, a.NewlineBefore)
+		},
+		func(a Token) string { return a.Lexeme + " " },
+	)
+}
+
+func TestScanner_ErrorPositions(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{"a = 'b\n", "1:4: unterminated string literal"},
+		{"a;\n  `b\nc", "2:2: unterminated template literal"},
+		{"a;\nb @ c", "2:2: unexpected character '@'"},
+		{"a = é", "1:4: unexpected character 'é'"},
+	}
+	for _, c := range cases {
+		t.Run(c.src, func(t *testing.T) {
+			logger := gojs.NewSimpleLogger(gojs.ModeError)
+			_, errs := NewLexer(c.src, logger).ScanAll()
+			if len(errs) != 1 || errs[0].Error() != c.expected {
+				t.Errorf("expected %q, got %v", c.expected, errs)
+			}
+		})
+	}
+}
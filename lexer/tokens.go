@@ -8,6 +8,8 @@ type Token struct {
 	Literal interface{}
 	Line    int
 	Column  int
+	// whether a LineTerminator appears between the previous token and this one
+	NewlineBefore bool
 }
 
 func (t *Token) String() string {
@@ -52,7 +54,10 @@ const (
 	TStringLiteral_SingleQuote
 	TStringLiteral_DoubleQuote
 	TRegularExpressionLiteral
-	TTemplateLiteral
+	TTemplateLiteral // NoSubstitutionTemplate
+	TTemplateHead
+	TTemplateMiddle
+	TTemplateTail
 	TEOF
 	TBOF
 	TUnknown
@@ -165,6 +170,9 @@ var LiteralNames = map[TokenType]string{
 	TStringLiteral_DoubleQuote: "StringLiteral_DoubleQuote",
 	TRegularExpressionLiteral:  "RegularExpressionLiteral",
 	TTemplateLiteral:           "TemplateLiteral",
+	TTemplateHead:              "TemplateHead",
+	TTemplateMiddle:            "TemplateMiddle",
+	TTemplateTail:              "TemplateTail",
 }
 
 var ReservedWordNames = map[TokenType]string{
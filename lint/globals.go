@@ -0,0 +1,53 @@
+package lint
+
+// Presets are the environments whose globals can be declared known, by name.
+var Presets = map[string][]string{
+	"es2024":  es2024Globals,
+	"browser": browserGlobals,
+	"node":    nodeGlobals,
+}
+
+// es2024Globals are the value properties, functions and constructors of the
+// global object of ECMAScript 2024.
+//
+// https://262.ecma-international.org/15.0/#sec-global-object
+var es2024Globals = []string{
+	"globalThis", "Infinity", "NaN", "undefined",
+	"eval", "isFinite", "isNaN", "parseFloat", "parseInt",
+	"decodeURI", "decodeURIComponent", "encodeURI", "encodeURIComponent",
+	"AggregateError", "Array", "ArrayBuffer", "BigInt", "BigInt64Array", "BigUint64Array",
+	"Boolean", "DataView", "Date", "Error", "EvalError", "FinalizationRegistry",
+	"Float32Array", "Float64Array", "Function", "Int8Array", "Int16Array", "Int32Array",
+	"Map", "Number", "Object", "Promise", "Proxy", "RangeError", "ReferenceError",
+	"RegExp", "Set", "SharedArrayBuffer", "String", "Symbol", "SyntaxError", "TypeError",
+	"Uint8Array", "Uint8ClampedArray", "Uint16Array", "Uint32Array", "URIError",
+	"WeakMap", "WeakRef", "WeakSet",
+	"Atomics", "JSON", "Math", "Reflect",
+}
+
+// browserGlobals are the most used globals of web browsers.
+var browserGlobals = []string{
+	"window", "self", "document", "navigator", "location", "history", "screen", "frames",
+	"console", "alert", "confirm", "prompt", "performance", "crypto",
+	"localStorage", "sessionStorage", "indexedDB", "caches",
+	"setTimeout", "clearTimeout", "setInterval", "clearInterval",
+	"requestAnimationFrame", "cancelAnimationFrame", "requestIdleCallback", "queueMicrotask",
+	"fetch", "Headers", "Request", "Response", "FormData", "URL", "URLSearchParams",
+	"Blob", "File", "FileReader", "XMLHttpRequest", "WebSocket", "EventSource",
+	"Event", "EventTarget", "CustomEvent", "AbortController", "AbortSignal",
+	"Node", "Element", "HTMLElement", "MutationObserver", "IntersectionObserver", "ResizeObserver",
+	"Worker", "MessageChannel", "BroadcastChannel", "TextEncoder", "TextDecoder",
+	"atob", "btoa", "structuredClone", "getComputedStyle", "matchMedia",
+}
+
+// nodeGlobals are the globals of Node.js, including those of CommonJS modules.
+var nodeGlobals = []string{
+	"global", "process", "Buffer", "console",
+	"require", "module", "exports", "__dirname", "__filename",
+	"setTimeout", "clearTimeout", "setInterval", "clearInterval",
+	"setImmediate", "clearImmediate", "queueMicrotask", "structuredClone",
+	"URL", "URLSearchParams", "TextEncoder", "TextDecoder", "AbortController", "AbortSignal",
+	"fetch", "Headers", "Request", "Response", "FormData", "Blob",
+	"Event", "EventTarget", "MessageChannel", "BroadcastChannel", "performance", "crypto",
+	"atob", "btoa",
+}
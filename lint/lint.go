@@ -0,0 +1,184 @@
+// Package lint reports suspicious uses of variables, found by the scope
+// analysis of a parsed program.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/scope"
+)
+
+// Code identifies the rule a Finding violates. Codes are stable, so tools may
+// match on them.
+type Code string
+
+const (
+	// a variable that is never read
+	UnusedVariable Code = "unused-variable"
+	// a function parameter that is never read, unless prefixed with '_'
+	UnusedParameter Code = "unused-parameter"
+	// an imported binding that is never read
+	UnusedImport Code = "unused-import"
+	// a read of a name that is neither declared nor a known global
+	UndeclaredVariable Code = "undeclared-variable"
+	// a write to a name that is neither declared nor a known global, which
+	// creates a global in sloppy mode code and throws in strict mode code
+	UndeclaredAssignment Code = "undeclared-assignment"
+	// a reference to a let or const variable before its declaration, which
+	// throws a ReferenceError
+	UseBeforeDeclaration Code = "use-before-declaration"
+)
+
+// DefaultEnv are the environment presets used when Config.Env is nil.
+var DefaultEnv = []string{"es2024"}
+
+// Config configures the globals known to the program being checked.
+type Config struct {
+	// Env names the Presets whose globals are known
+	Env []string
+	// Globals are known globals besides those of the presets
+	Globals []string
+}
+
+// Finding is a suspicious use of a variable, reported at the span of the
+// offending identifier.
+type Finding struct {
+	Code    Code
+	Message string
+	Span    parser.Span
+}
+
+func (f *Finding) Error() string {
+	return fmt.Sprintf("%s: %s [%s]", f.Span.Start, f.Message, f.Code)
+}
+
+// Check analyzes the scopes of a parsed program and returns its findings,
+// sorted by position. It fails on unknown environment presets.
+func Check(program parser.Node, options parser.Options, config Config) ([]*Finding, error) {
+	env := config.Env
+	if env == nil {
+		env = DefaultEnv
+	}
+	known := make(map[string]bool)
+	for _, name := range env {
+		globals, ok := Presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown environment %q", name)
+		}
+		for _, global := range globals {
+			known[global] = true
+		}
+	}
+	for _, global := range config.Globals {
+		known[global] = true
+	}
+
+	c := &checker{known: known}
+	global := scope.Analyze(program, options)
+	c.checkScope(global)
+	for _, ref := range global.Implicit {
+		c.checkImplicit(ref)
+	}
+
+	sort.SliceStable(c.findings, func(i, j int) bool {
+		a, b := c.findings[i].Span.Start, c.findings[j].Span.Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return c.findings, nil
+}
+
+type checker struct {
+	findings []*Finding
+
+	known map[string]bool // names of the known globals
+}
+
+func (c *checker) report(code Code, id *parser.ExprIdentifier, format string, args ...interface{}) {
+	c.findings = append(c.findings, &Finding{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Span:    id.Span,
+	})
+}
+
+func (c *checker) checkScope(s *scope.Scope) {
+	for _, variable := range s.Variables {
+		c.checkVariable(variable)
+	}
+	for _, child := range s.Children {
+		c.checkScope(child)
+	}
+}
+
+func (c *checker) checkVariable(variable *scope.Variable) {
+	for _, ref := range variable.References {
+		if ref.TDZ {
+			c.report(UseBeforeDeclaration, ref.Identifier, "'%s' is used before its declaration", variable.Name)
+		}
+	}
+
+	if variable.Kind == scope.DeclImplicit || len(variable.Identifiers) == 0 || isRead(variable) {
+		return
+	}
+	id := variable.Identifiers[0]
+	switch {
+	case variable.Exported || variable.Scope.Eval || isFunctionExpressionName(variable):
+		// used from elsewhere or by code that eval runs, or only there to be
+		// referred to recursively
+	case variable.Kind == scope.DeclParameter:
+		if !strings.HasPrefix(variable.Name, "_") {
+			c.report(UnusedParameter, id, "parameter '%s' is never used", variable.Name)
+		}
+	case variable.Kind == scope.DeclImport:
+		c.report(UnusedImport, id, "'%s' is imported but never used", variable.Name)
+	case isWritten(variable):
+		c.report(UnusedVariable, id, "'%s' is assigned a value but never used", variable.Name)
+	default:
+		c.report(UnusedVariable, id, "'%s' is declared but never used", variable.Name)
+	}
+}
+
+// checkImplicit checks a reference that resolves to no declaration. Within a
+// with statement it may still denote a property of the object.
+func (c *checker) checkImplicit(ref *scope.Reference) {
+	name := ref.Identifier.Name
+	if c.known[name] || ref.Dynamic {
+		return
+	}
+	switch {
+	case ref.Write:
+		c.report(UndeclaredAssignment, ref.Identifier, "assignment to undeclared variable '%s'", name)
+	case !ref.Typeof:
+		c.report(UndeclaredVariable, ref.Identifier, "'%s' is not defined", name)
+	}
+}
+
+// isRead reports whether variable is read anywhere.
+func isRead(variable *scope.Variable) bool {
+	for _, ref := range variable.References {
+		if ref.Read {
+			return true
+		}
+	}
+	return false
+}
+
+// isWritten reports whether variable is assigned anywhere.
+func isWritten(variable *scope.Variable) bool {
+	for _, ref := range variable.References {
+		if ref.Write {
+			return true
+		}
+	}
+	return false
+}
+
+// isFunctionExpressionName reports whether variable is the name of a function
+// expression, bound within the function itself.
+func isFunctionExpressionName(variable *scope.Variable) bool {
+	fn, ok := variable.Scope.Node.(*parser.ExprFunction)
+	return ok && fn.BindingIdentifier == variable.Identifiers[0]
+}
@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	"github.com/ruiconti/gojs/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tcs := []struct {
+		src    string
+		module bool
+		config Config
+		code   Code
+		span   parser.Span
+	}{
+		{src: "let a = 1;", code: UnusedVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 4}, End: parser.Position{Line: 1, Column: 5}}},
+		{src: "var a; a = 1;", code: UnusedVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 4}, End: parser.Position{Line: 1, Column: 5}}},
+		{src: "{ function f() {} }", code: UnusedVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 11}, End: parser.Position{Line: 1, Column: 12}}},
+		{src: "const { a, b } = c; b;", config: Config{Globals: []string{"c"}}, code: UnusedVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 8}, End: parser.Position{Line: 1, Column: 9}}},
+		{src: "function f(a, b) { return b; } f();", code: UnusedParameter, span: parser.Span{Start: parser.Position{Line: 1, Column: 11}, End: parser.Position{Line: 1, Column: 12}}},
+		{src: "((a) => 1)();", code: UnusedParameter, span: parser.Span{Start: parser.Position{Line: 1, Column: 2}, End: parser.Position{Line: 1, Column: 3}}},
+		{src: "import { a, b } from 'm'; b;", module: true, code: UnusedImport, span: parser.Span{Start: parser.Position{Line: 1, Column: 9}, End: parser.Position{Line: 1, Column: 10}}},
+		{src: "import a from 'm';\na = 1;", module: true, code: UnusedImport, span: parser.Span{Start: parser.Position{Line: 1, Column: 7}, End: parser.Position{Line: 1, Column: 8}}},
+		{src: "f(x);", config: Config{Globals: []string{"f"}}, code: UndeclaredVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 2}, End: parser.Position{Line: 1, Column: 3}}},
+		{src: "document.title;", config: Config{Env: []string{"node"}}, code: UndeclaredVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 0}, End: parser.Position{Line: 1, Column: 8}}},
+		{src: "function f() { x = 1; } f();", code: UndeclaredAssignment, span: parser.Span{Start: parser.Position{Line: 1, Column: 15}, End: parser.Position{Line: 1, Column: 16}}},
+		{src: "a; let a; a;", code: UseBeforeDeclaration, span: parser.Span{Start: parser.Position{Line: 1, Column: 0}, End: parser.Position{Line: 1, Column: 1}}},
+		{src: "const a = a + 1; a;", code: UseBeforeDeclaration, span: parser.Span{Start: parser.Position{Line: 1, Column: 10}, End: parser.Position{Line: 1, Column: 11}}},
+		{src: "function f() { var x; } function g() { eval(''); } f(); g();", code: UnusedVariable, span: parser.Span{Start: parser.Position{Line: 1, Column: 19}, End: parser.Position{Line: 1, Column: 20}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			options := parser.Options{}
+			if tc.module {
+				options.SourceType = parser.SourceTypeModule
+			}
+			findings, err := Check(parser.ParseWithOptions(logger, tc.src, options), options, tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 finding, got %v", findings)
+			}
+			if findings[0].Code != tc.code || findings[0].Span != tc.span {
+				t.Errorf("expected %s at %s, got %s at %s", tc.code, tc.span, findings[0].Code, findings[0].Span)
+			}
+		})
+	}
+}
+
+func TestCheck_Clean(t *testing.T) {
+	tcs := []struct {
+		src    string
+		module bool
+		config Config
+	}{
+		{src: "var a = 1; function f(_a, b) { return a + b; } f(NaN, parseInt('1'));"},
+		{src: "(function g() {})(); typeof missing;"},
+		{src: "with (o) { p = q; }", config: Config{Globals: []string{"o"}}},
+		{src: "function f() { return g(); } const g = () => 1; f();"},
+		{src: "window.a = setTimeout; process;", config: Config{Env: []string{"browser", "node"}}},
+		{src: "import { a } from 'm'; export { a as b }; export const c = 1; export function f() {}", module: true},
+		{src: "function f() { return arguments; } f();"},
+		{src: "function ev(a) { var hidden = 1; eval('hidden + a'); } ev();"},
+		{src: "function ev() { var hidden = 1; return () => eval('hidden'); } ev();"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			options := parser.Options{}
+			if tc.module {
+				options.SourceType = parser.SourceTypeModule
+			}
+			findings, err := Check(parser.ParseWithOptions(logger, tc.src, options), options, tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(findings) > 0 {
+				t.Errorf("unexpected findings: %v", findings)
+			}
+		})
+	}
+}
+
+func TestCheck_UnknownEnv(t *testing.T) {
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	if _, err := Check(parser.Parse(logger, "a;"), parser.Options{}, Config{Env: []string{"deno"}}); err == nil {
+		t.Errorf("expected an unknown environment error")
+	}
+}
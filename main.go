@@ -0,0 +1,54 @@
+// Command gojs is a toolbox for JavaScript source code.
+//
+// Usage:
+//
+//	gojs <command> [arguments]
+//
+// The commands are:
+//
+//	check   report the syntax errors of JavaScript source files
+//	disasm  print the bytecode a JavaScript source file compiles to
+//	fmt     reformat JavaScript source files
+//	minify  minify a JavaScript source file
+//	repl    run JavaScript interactively
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of gojs, which returns the exit code of the process.
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+var commands = map[string]command{
+	"check":  runCheck,
+	"disasm": runDisasm,
+	"fmt":    runFmt,
+	"minify": runMinify,
+	"repl":   runRepl,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "gojs: unknown command %q\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	os.Exit(run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: gojs <command> [arguments]\n\ncommands:\n")
+	fmt.Fprintf(w, "\tcheck\treport the syntax errors of JavaScript source files\n")
+	fmt.Fprintf(w, "\tdisasm\tprint the bytecode a JavaScript source file compiles to\n")
+	fmt.Fprintf(w, "\tfmt\treformat JavaScript source files\n")
+	fmt.Fprintf(w, "\tminify\tminify a JavaScript source file\n")
+	fmt.Fprintf(w, "\trepl\trun JavaScript interactively\n")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ruiconti/gojs/minify"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/sourcemap"
+)
+
+// runMinify implements gojs minify, which prints the minified source of a
+// file, or of the standard input when given no path.
+func runMinify(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	options := minify.DefaultOptions
+	flags := flag.NewFlagSet("minify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	module := flags.Bool("module", false, "parse the source as a module (default: .mjs files, and sources that only parse as modules)")
+	flags.BoolVar(&options.Mangle, "mangle", options.Mangle, "rename local variables to short names")
+	flags.BoolVar(&options.Compress, "compress", options.Compress, "fold constants and rewrite syntax into shorter equivalents")
+	output := flags.String("o", "", "write the result to `file` instead of stdout")
+	mapFile := flags.String("map", "", "write a source map to `file`, referenced from the result")
+	inputMap := flags.String("in-map", "", "compose the source map with the source map of the input in `file`, produced by the tool that generated it")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gojs minify [flags] [path]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	if *inputMap != "" && *mapFile == "" {
+		fmt.Fprintf(stderr, "gojs minify: -in-map requires -map\n")
+		return 2
+	}
+
+	name, in := "<standard input>", stdin
+	if flags.NArg() == 1 {
+		name = flags.Arg(0)
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		defer file.Close()
+		in = file
+	}
+	src, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	var sourceMap *sourcemap.Map
+	res, err := transform(name, string(src), *module, func(src string, parseOptions parser.Options) (string, error) {
+		if *mapFile == "" {
+			return minify.Source(src, parseOptions, options)
+		}
+		var res string
+		var err error
+		res, sourceMap, err = minify.SourceWithMap(src, relativePath(*mapFile, name), parseOptions, options)
+		return res, err
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", sourceError(name, err))
+		return 2
+	}
+
+	if *mapFile != "" {
+		if err := writeSourceMap(sourceMap, *mapFile, *inputMap, *output); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 2
+		}
+		res += "\n//# sourceMappingURL=" + relativePath(*output, *mapFile)
+	}
+	res += "\n"
+	if *output == "" {
+		io.WriteString(stdout, res)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(res), 0o644); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// writeSourceMap writes sourceMap to path, composed with the source map read
+// from inputMap if set, as the map of the file output.
+func writeSourceMap(sourceMap *sourcemap.Map, path, inputMap, output string) error {
+	if inputMap != "" {
+		data, err := os.ReadFile(inputMap)
+		if err != nil {
+			return err
+		}
+		inner, err := sourcemap.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", inputMap, err)
+		}
+		if sourceMap, err = sourcemap.Compose(sourceMap, inner); err != nil {
+			return fmt.Errorf("%s: %v", inputMap, err)
+		}
+	}
+	if output != "" {
+		sourceMap.File = filepath.Base(output)
+	}
+	data, err := json.Marshal(sourceMap)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// relativePath returns target relative to the directory of the file from,
+// the way a source map or a sourceMappingURL comment refers to it. From is
+// in the working directory when empty, as is the standard output.
+func relativePath(from, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(from), target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	return filepath.ToSlash(rel)
+}
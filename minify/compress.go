@@ -0,0 +1,320 @@
+package minify
+
+import (
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// Compression rewrites the AST in place into a shorter equivalent: constants
+// are folded, blocks flattened, consecutive declarations merged and if
+// statements turned into expressions where they hold only expressions or
+// returns.
+
+// body compresses the statements of a program or function body. Statements
+// that end up in its Directive Prologue without being directives of the
+// source, as the "b" of "a" + "b", are parenthesized to stay out of it.
+func body(stmts []parser.Stmt) []parser.Stmt {
+	directives := 0
+	for directives < len(stmts) && isDirective(stmts[directives]) {
+		directives++
+	}
+	stmts = statements(stmts)
+	for i := directives; i < len(stmts) && isDirective(stmts[i]); i++ {
+		stmt := stmts[i].(*parser.ExpressionStatement)
+		stmt.Expression = &parser.ExprParenthesized{Expression: stmt.Expression}
+	}
+	return stmts
+}
+
+func isDirective(stmt parser.Stmt) bool {
+	exprStmt, ok := stmt.(*parser.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	literal, ok := exprStmt.Expression.(*parser.ExprLiteral[string])
+	return ok && isString(literal)
+}
+
+func isString(literal *parser.ExprLiteral[string]) bool {
+	return literal.Token.Type == l.TStringLiteral_SingleQuote || literal.Token.Type == l.TStringLiteral_DoubleQuote
+}
+
+// statements compresses a StatementList. Empty statements are dropped, blocks
+// without lexical declarations are flattened into the list, and statements
+// are merged with the previous one where possible.
+func statements(stmts []parser.Stmt) []parser.Stmt {
+	var compressed []parser.Stmt
+	for _, stmt := range stmts {
+		switch stmt := statement(stmt).(type) {
+		case nil, *parser.EmptyStatement:
+		case *parser.BlockStatement:
+			if hasLexicalDeclaration(stmt.Stmts) {
+				compressed = appendStatement(compressed, stmt)
+				continue
+			}
+			for _, inner := range stmt.Stmts {
+				compressed = appendStatement(compressed, inner)
+			}
+		default:
+			compressed = appendStatement(compressed, stmt)
+		}
+	}
+	return compressed
+}
+
+// appendStatement appends stmt to stmts, merging
+//
+//	var a = 1; var b = 2   into   var a = 1, b = 2
+//	if (a) return b; return c   into   return a ? b : c
+func appendStatement(stmts []parser.Stmt, stmt parser.Stmt) []parser.Stmt {
+	if len(stmts) == 0 {
+		return append(stmts, stmt)
+	}
+	switch last := stmts[len(stmts)-1].(type) {
+	case *parser.VariableStatement:
+		if next, ok := stmt.(*parser.VariableStatement); ok && next.Kind.Type == last.Kind.Type {
+			last.Declarations = append(last.Declarations, next.Declarations...)
+			return stmts
+		}
+	case *parser.IfStatement:
+		then, ok := last.ThenStmt.(*parser.ReturnStatement)
+		next, nextOk := stmt.(*parser.ReturnStatement)
+		if ok && nextOk && last.ElseStmt == nil && then.Argument != nil && next.Argument != nil {
+			stmts[len(stmts)-1] = &parser.ReturnStatement{Argument: &parser.ExprConditional{
+				Test:       last.Condition,
+				Consequent: then.Argument,
+				Alternate:  next.Argument,
+			}}
+			return stmts
+		}
+	}
+	return append(stmts, stmt)
+}
+
+// hasLexicalDeclaration reports whether stmts declare names scoped to their
+// block, which is then kept.
+func hasLexicalDeclaration(stmts []parser.Stmt) bool {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *parser.VariableStatement:
+			if stmt.Kind.Type != l.TVar {
+				return true
+			}
+		case *parser.FunctionDeclarationStmt:
+			return true
+		}
+	}
+	return false
+}
+
+// hasVarDeclaration reports whether stmt declares a name hoisted out of it:
+// removing it would remove the declaration too.
+func hasVarDeclaration(stmt parser.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *parser.VariableStatement:
+		return stmt.Kind.Type == l.TVar
+	case *parser.FunctionDeclarationStmt:
+		return true
+	case *parser.BlockStatement:
+		for _, stmt := range stmt.Stmts {
+			if hasVarDeclaration(stmt) {
+				return true
+			}
+		}
+	case *parser.IfStatement:
+		return hasVarDeclaration(stmt.ThenStmt) || stmt.ElseStmt != nil && hasVarDeclaration(stmt.ElseStmt)
+	case *parser.WithStatement:
+		return hasVarDeclaration(stmt.Body)
+	case *parser.LabelledStatement:
+		return hasVarDeclaration(stmt.Body)
+	}
+	return false
+}
+
+// statement compresses stmt, returning the statement replacing it, or nil
+// when it is removed.
+func statement(stmt parser.Stmt) parser.Stmt {
+	switch s := stmt.(type) {
+	case *parser.ExpressionStatement:
+		if paren, ok := s.Expression.(*parser.ExprParenthesized); ok {
+			if literal, ok := parser.Unparenthesized(paren).(*parser.ExprLiteral[string]); ok && isString(literal) {
+				// ("a") is not a directive
+				return s
+			}
+		}
+		s.Expression = expression(s.Expression)
+	case *parser.VariableStatement:
+		for _, decl := range s.Declarations {
+			if decl.Pattern != nil {
+				decl.Pattern = pattern(decl.Pattern)
+			}
+			if decl.Init != nil {
+				decl.Init = expression(decl.Init)
+			}
+		}
+	case *parser.ReturnStatement:
+		if s.Argument != nil {
+			s.Argument = expression(s.Argument)
+			if v, ok := constant(s.Argument); ok && v.kind == kindUndefined {
+				s.Argument = nil
+			}
+		}
+	case *parser.IfStatement:
+		return ifStatement(s)
+	case *parser.BlockStatement:
+		s.Stmts = statements(s.Stmts)
+	case *parser.WithStatement:
+		s.Object = expression(s.Object)
+		s.Body = substatement(s.Body)
+	case *parser.LabelledStatement:
+		s.Body = substatement(s.Body)
+	case *parser.FunctionDeclarationStmt:
+		params(s.Params)
+		s.Body = body(s.Body)
+	case *parser.ExportNamedDeclaration:
+		if s.Declaration != nil {
+			s.Declaration = statement(s.Declaration)
+		}
+	case *parser.ExportDefaultDeclaration:
+		if fn, ok := s.Declaration.(*parser.FunctionDeclarationStmt); ok {
+			statement(fn)
+		} else {
+			s.Declaration = expression(s.Declaration)
+		}
+	}
+	return stmt
+}
+
+// substatement compresses the body of an if, with or labelled statement. A
+// block is replaced by its only statement, or by a sequence of the
+// expressions of its expression statements.
+func substatement(stmt parser.Stmt) parser.Stmt {
+	stmt = statement(stmt)
+	if stmt == nil {
+		return &parser.EmptyStatement{}
+	}
+	block, ok := stmt.(*parser.BlockStatement)
+	if !ok || hasLexicalDeclaration(block.Stmts) {
+		return stmt
+	}
+	switch len(block.Stmts) {
+	case 0:
+		return &parser.EmptyStatement{}
+	case 1:
+		return block.Stmts[0]
+	}
+	var exprs []parser.Expr
+	for _, stmt := range block.Stmts {
+		expr, ok := asExpression(stmt)
+		if !ok {
+			return block
+		}
+		exprs = append(exprs, expr)
+	}
+	return &parser.ExpressionStatement{Expression: &parser.ExprSequence{Expressions: exprs}}
+}
+
+// ifStatement compresses an if statement, folding it when its condition is
+// constant, and rewriting
+//
+//	if (a) b         into   a && b
+//	if (!a) b        into   a || b
+//	if (a) b; else c into   a ? b : c
+//
+// as well as if (a) return b; else return c into return a ? b : c.
+func ifStatement(s *parser.IfStatement) parser.Stmt {
+	s.Condition = expression(s.Condition)
+	s.ThenStmt = substatement(s.ThenStmt)
+	if s.ElseStmt != nil {
+		s.ElseStmt = substatement(s.ElseStmt)
+		if isEmpty(s.ElseStmt) {
+			s.ElseStmt = nil
+		}
+	}
+
+	if v, ok := constant(s.Condition); ok {
+		kept, dropped := s.ThenStmt, s.ElseStmt
+		if !v.truthy() {
+			kept, dropped = dropped, kept
+		}
+		// function declarations are only allowed in if statements by Annex B
+		_, isFunction := kept.(*parser.FunctionDeclarationStmt)
+		if (dropped == nil || !hasVarDeclaration(dropped)) && !isFunction {
+			return kept
+		}
+	}
+
+	if isEmpty(s.ThenStmt) {
+		if s.ElseStmt == nil {
+			return &parser.ExpressionStatement{Expression: s.Condition}
+		}
+		s.Condition, s.ThenStmt, s.ElseStmt = unary(l.TBang, s.Condition), s.ElseStmt, nil
+	}
+	condition, negated := negation(s.Condition)
+	if negated && s.ElseStmt != nil {
+		s.Condition, s.ThenStmt, s.ElseStmt = condition, s.ElseStmt, s.ThenStmt
+		negated = false
+	}
+
+	then, thenOk := asExpression(s.ThenStmt)
+	if s.ElseStmt == nil {
+		if !thenOk {
+			return s
+		}
+		if negated {
+			return &parser.ExpressionStatement{Expression: logical(l.TLogicalOr, condition, then)}
+		}
+		return &parser.ExpressionStatement{Expression: logical(l.TLogicalAnd, s.Condition, then)}
+	}
+	if otherwise, ok := asExpression(s.ElseStmt); ok && thenOk {
+		return &parser.ExpressionStatement{Expression: &parser.ExprConditional{
+			Test:       s.Condition,
+			Consequent: then,
+			Alternate:  otherwise,
+		}}
+	}
+	thenReturn, ok := s.ThenStmt.(*parser.ReturnStatement)
+	elseReturn, elseOk := s.ElseStmt.(*parser.ReturnStatement)
+	if ok && elseOk && (thenReturn.Argument != nil || elseReturn.Argument != nil) {
+		return &parser.ReturnStatement{Argument: &parser.ExprConditional{
+			Test:       s.Condition,
+			Consequent: returned(thenReturn),
+			Alternate:  returned(elseReturn),
+		}}
+	}
+	return s
+}
+
+// returned returns the value returned by stmt, void 0 for a bare return.
+func returned(stmt *parser.ReturnStatement) parser.Expr {
+	if stmt.Argument == nil {
+		return literal(value{kind: kindUndefined})
+	}
+	return stmt.Argument
+}
+
+func isEmpty(stmt parser.Stmt) bool {
+	_, ok := stmt.(*parser.EmptyStatement)
+	return ok
+}
+
+func asExpression(stmt parser.Stmt) (parser.Expr, bool) {
+	exprStmt, ok := stmt.(*parser.ExpressionStatement)
+	if !ok {
+		return nil, false
+	}
+	return exprStmt.Expression, true
+}
+
+// negation returns a of the condition !a.
+func negation(expr parser.Expr) (parser.Expr, bool) {
+	not, ok := parser.Unparenthesized(expr).(*parser.ExprUnaryOp)
+	if !ok || not.Postfix || not.Operator.Type != l.TBang {
+		return expr, false
+	}
+	return not.Operand, true
+}
+
+func logical(op l.TokenType, left, right parser.Expr) *parser.ExprBinaryOp {
+	return &parser.ExprBinaryOp{Operator: l.Token{Type: op, Lexeme: op.S()}, Left: left, Right: right}
+}
@@ -0,0 +1,251 @@
+package minify
+
+import (
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// expression compresses expr, returning the expression replacing it.
+// Parentheses are dropped, as the printer only prints those needed.
+func expression(expr parser.Expr) parser.Expr {
+	switch e := expr.(type) {
+	case *parser.ExprParenthesized:
+		return expression(e.Expression)
+	case *parser.ExprLiteral[float64]:
+		if num, ok := e.Token.Literal.(float64); ok && len(formatNumber(num)) < len(e.Token.Lexeme) {
+			return number(num)
+		}
+	case *parser.ExprLiteral[string]:
+		switch e.Token.Type {
+		case l.TTrue, l.TFalse, l.TUndefined:
+			v, _ := constant(e)
+			return literal(v)
+		}
+	case *parser.ExprUnaryOp:
+		switch e.Operator.Type {
+		case l.TDelete, l.TTypeof:
+			e.Operand = reference(e.Operand)
+		default:
+			e.Operand = expression(e.Operand)
+		}
+		if e.Operator.Type != l.TMinus {
+			return fold(e)
+		}
+	case *parser.ExprBinaryOp:
+		e.Left = expression(e.Left)
+		e.Right = expression(e.Right)
+		if left, ok := constant(e.Left); ok {
+			switch e.Operator.Type {
+			case l.TLogicalAnd:
+				return pick(left.truthy(), e.Right, e.Left)
+			case l.TLogicalOr:
+				return pick(left.truthy(), e.Left, e.Right)
+			case l.TDoubleQuestionMark:
+				return pick(left.kind == kindNull || left.kind == kindUndefined, e.Right, e.Left)
+			}
+		}
+		return fold(e)
+	case *parser.ExprConditional:
+		e.Test = expression(e.Test)
+		e.Consequent = expression(e.Consequent)
+		e.Alternate = expression(e.Alternate)
+		if test, ok := constant(e.Test); ok {
+			return pick(test.truthy(), e.Consequent, e.Alternate)
+		}
+		if test, negated := negation(e.Test); negated {
+			// !a ? b : c is a ? c : b
+			e.Test, e.Consequent, e.Alternate = test, e.Alternate, e.Consequent
+		}
+	case *parser.ExprAssign:
+		if _, ok := e.Left.(*parser.ExprIdentifier); !ok {
+			e.Left = pattern(e.Left)
+		}
+		e.Right = expression(e.Right)
+	case *parser.ExprSequence:
+		expressions(e.Expressions)
+	case *parser.ExprNew:
+		e.Callee = expression(e.Callee)
+		expressions(e.Arguments)
+	case *parser.ExprCall:
+		e.Callee = reference(e.Callee)
+		expressions(e.Arguments)
+	case *parser.ExprMemberAccess:
+		e.Object = expression(e.Object)
+		if !e.Computed {
+			break
+		}
+		e.Property = expression(e.Property)
+		// a["b"] is a.b
+		if property, ok := constant(e.Property); ok && property.kind == kindString && isIdentifierName(property.str) {
+			e.Property = &parser.ExprIdentifier{Name: property.str}
+			e.Computed = false
+		}
+	case *parser.ExprChain:
+		e.Expression = expression(e.Expression)
+	case *parser.ExprImportCall:
+		e.Source = expression(e.Source)
+	case *parser.SpreadElement:
+		e.Argument = expression(e.Argument)
+	case *parser.ExprYield:
+		if e.Argument != nil {
+			e.Argument = expression(e.Argument)
+		}
+	case *parser.ExprAwait:
+		e.Argument = expression(e.Argument)
+	case *parser.ExprArray:
+		for i, element := range e.Elements {
+			if element != parser.ArrayHole {
+				e.Elements[i] = expression(element)
+			}
+		}
+	case *parser.ExprObject:
+		for _, property := range e.Properties {
+			if property.Computed {
+				property.Key = expression(property.Key)
+			}
+			switch value := property.Value.(type) {
+			case *parser.ExprIdentifier:
+				// the shorthand { a }
+			case *parser.ExprAssign:
+				// the CoverInitializedName { a = 1 } of a pattern
+				value.Right = expression(value.Right)
+			default:
+				property.Value = expression(value)
+			}
+		}
+	case *parser.ExprTemplateLiteral:
+		expressions(e.Expressions)
+	case *parser.ExprTaggedTemplate:
+		e.Tag = reference(e.Tag)
+		expressions(e.Quasi.Expressions)
+	case *parser.ExprFunction:
+		params(e.Params)
+		e.Body = body(e.Body)
+	case *parser.ExprArrowFunction:
+		params(e.Params)
+		if e.Expression != nil {
+			e.Expression = expression(e.Expression)
+		} else {
+			e.Body = body(e.Body)
+		}
+	}
+	return expr
+}
+
+func expressions(exprs []parser.Expr) {
+	for i, expr := range exprs {
+		exprs[i] = expression(expr)
+	}
+}
+
+// reference compresses the callee of a call, or the operand of delete or
+// typeof, whose operations are not folded into one of their operands:
+// (0, a.b)() calls a.b with an undefined this, unlike a.b(), and
+// typeof (0, a) does not throw if a is not declared.
+func reference(expr parser.Expr) parser.Expr {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprBinaryOp:
+		switch e.Operator.Type {
+		case l.TLogicalAnd, l.TLogicalOr, l.TDoubleQuestionMark:
+			e.Left = expression(e.Left)
+			e.Right = expression(e.Right)
+			return e
+		}
+	case *parser.ExprConditional:
+		e.Test = expression(e.Test)
+		e.Consequent = expression(e.Consequent)
+		e.Alternate = expression(e.Alternate)
+		return e
+	}
+	return expression(expr)
+}
+
+// fold replaces an operation on constants by its result, if shorter.
+func fold(expr parser.Expr) parser.Expr {
+	var v value
+	var ok bool
+	switch e := expr.(type) {
+	case *parser.ExprUnaryOp:
+		v, ok = constant(e)
+	case *parser.ExprBinaryOp:
+		var x, y value
+		if x, ok = constant(e.Left); ok {
+			if y, ok = constant(e.Right); ok {
+				v, ok = foldBinary(e.Operator.Type, x, y)
+			}
+		}
+	}
+	if !ok {
+		return expr
+	}
+	if folded := literal(v); shorter(folded, expr) {
+		return folded
+	}
+	return expr
+}
+
+// pick returns a if cond holds, and b otherwise.
+func pick(cond bool, a, b parser.Expr) parser.Expr {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// pattern compresses the default values and computed keys of a binding or
+// assignment pattern.
+func pattern(node parser.Node) parser.Node {
+	switch n := node.(type) {
+	case *parser.ExprParenthesized:
+		n.Expression = pattern(n.Expression).(parser.Expr)
+	case *parser.ObjectPattern:
+		for i, property := range n.Properties {
+			n.Properties[i] = pattern(property)
+		}
+	case *parser.PatternProperty:
+		if n.Computed {
+			n.Key = expression(n.Key)
+		}
+		n.Value = pattern(n.Value)
+	case *parser.ArrayPattern:
+		for i, element := range n.Elements {
+			if element != nil {
+				n.Elements[i] = pattern(element)
+			}
+		}
+	case *parser.AssignmentPattern:
+		n.Left = pattern(n.Left)
+		n.Right = expression(n.Right)
+	case *parser.RestElement:
+		n.Argument = pattern(n.Argument)
+	case *parser.ExprMemberAccess:
+		return expression(n)
+	}
+	return node
+}
+
+func params(params []parser.Node) {
+	for i, param := range params {
+		params[i] = pattern(param)
+	}
+}
+
+// isIdentifierName reports whether name can be written as the IdentifierName
+// of a member expression. Reserved words are left out.
+func isIdentifierName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if _, reserved := l.ReservedKeywords[name]; reserved {
+		return false
+	}
+	for i, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch == '_', ch == '$':
+		case ch >= '0' && ch <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+package minify
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/printer"
+)
+
+// Constant folding evaluates the operations on literals whose result is
+// known without running the program, as 60 * 60 or "a" + "b". A result is
+// only kept when it prints no longer than the operation: 1 / 3 is left alone.
+
+// kind is the type of a constant value.
+type kind int
+
+const (
+	kindNumber kind = iota + 1
+	kindString
+	kindBoolean
+	kindNull
+	kindUndefined
+)
+
+// value is the value of a constant expression.
+type value struct {
+	kind  kind
+	num   float64
+	str   string
+	truth bool
+}
+
+// truthy converts v to a boolean.
+//
+// https://262.ecma-international.org/#sec-toboolean
+func (v value) truthy() bool {
+	switch v.kind {
+	case kindNumber:
+		return v.num != 0 && !math.IsNaN(v.num)
+	case kindString:
+		return v.str != ""
+	case kindBoolean:
+		return v.truth
+	}
+	return false
+}
+
+// toString converts v to a string. Only integers below 1e21 are converted, as
+// other numbers do not print the same in Go and JavaScript.
+//
+// https://262.ecma-international.org/#sec-tostring
+func (v value) toString() (string, bool) {
+	switch v.kind {
+	case kindNumber:
+		if v.num != math.Trunc(v.num) || math.Abs(v.num) >= 1e21 {
+			return "", false
+		}
+		if v.num == 0 {
+			return "0", true // and not -0
+		}
+		return strconv.FormatFloat(v.num, 'f', -1, 64), true
+	case kindString:
+		return v.str, true
+	case kindBoolean:
+		if v.truth {
+			return "true", true
+		}
+		return "false", true
+	case kindNull:
+		return "null", true
+	}
+	return "undefined", true
+}
+
+// typeOf returns the result of the typeof operator on v.
+func (v value) typeOf() string {
+	switch v.kind {
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	case kindBoolean:
+		return "boolean"
+	case kindNull:
+		return "object"
+	}
+	return "undefined"
+}
+
+// constant returns the value of expr if it is a literal, or one of the
+// operations on literals folding leaves as they are shorter, as !0 or -1.
+func constant(expr parser.Node) (value, bool) {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprLiteral[float64]:
+		num, ok := e.Token.Literal.(float64)
+		return value{kind: kindNumber, num: num}, ok
+	case *parser.ExprLiteral[string]:
+		switch e.Token.Type {
+		case l.TTrue:
+			return value{kind: kindBoolean, truth: true}, true
+		case l.TFalse:
+			return value{kind: kindBoolean}, true
+		case l.TNull:
+			return value{kind: kindNull}, true
+		case l.TUndefined:
+			return value{kind: kindUndefined}, true
+		case l.TStringLiteral_SingleQuote, l.TStringLiteral_DoubleQuote:
+			if e.Token.Lexeme == "" {
+				str, ok := e.Token.Literal.(string)
+				return value{kind: kindString, str: str}, ok
+			}
+			// legacy octal escapes are not cooked, and leave the string alone
+			str, ok := l.CookTemplate(e.Token.Lexeme[1 : len(e.Token.Lexeme)-1])
+			return value{kind: kindString, str: str}, ok
+		}
+	case *parser.ExprUnaryOp:
+		if e.Postfix {
+			break
+		}
+		operand, ok := constant(e.Operand)
+		if !ok {
+			break
+		}
+		switch e.Operator.Type {
+		case l.TBang:
+			return value{kind: kindBoolean, truth: !operand.truthy()}, true
+		case l.TVoid:
+			return value{kind: kindUndefined}, true
+		case l.TTypeof:
+			return value{kind: kindString, str: operand.typeOf()}, true
+		case l.TMinus:
+			if operand.kind == kindNumber {
+				return value{kind: kindNumber, num: -operand.num}, true
+			}
+		case l.TPlus:
+			if operand.kind == kindNumber {
+				return operand, true
+			}
+		}
+	}
+	return value{}, false
+}
+
+// foldBinary computes the constant result of a binary operation.
+func foldBinary(op l.TokenType, x, y value) (value, bool) {
+	if x.kind == kindNumber && y.kind == kindNumber {
+		var num float64
+		switch op {
+		case l.TPlus:
+			num = x.num + y.num
+		case l.TMinus:
+			num = x.num - y.num
+		case l.TStar:
+			num = x.num * y.num
+		case l.TSlash:
+			num = x.num / y.num
+		case l.TPercent:
+			num = math.Mod(x.num, y.num)
+		case l.TStarStar:
+			num = math.Pow(x.num, y.num)
+		case l.TLessThan:
+			return value{kind: kindBoolean, truth: x.num < y.num}, true
+		case l.TLessThanEqual:
+			return value{kind: kindBoolean, truth: x.num <= y.num}, true
+		case l.TGreaterThan:
+			return value{kind: kindBoolean, truth: x.num > y.num}, true
+		case l.TGreaterThanEqual:
+			return value{kind: kindBoolean, truth: x.num >= y.num}, true
+		default:
+			return foldEquality(op, x, y)
+		}
+		// NaN and Infinity are globals, which may be shadowed
+		return value{kind: kindNumber, num: num}, !math.IsNaN(num) && !math.IsInf(num, 0)
+	}
+	if op == l.TPlus && (x.kind == kindString || y.kind == kindString) {
+		a, ok := x.toString()
+		if !ok {
+			return value{}, false
+		}
+		b, ok := y.toString()
+		return value{kind: kindString, str: a + b}, ok
+	}
+	return foldEquality(op, x, y)
+}
+
+// foldEquality compares two constants, with == only when it needs no type
+// conversion besides null == undefined.
+func foldEquality(op l.TokenType, x, y value) (value, bool) {
+	var equal bool
+	switch op {
+	case l.TStrictEqual, l.TStrictNotEqual:
+		equal = x == y
+	case l.TEqual, l.TNotEqual:
+		nullish := func(v value) bool { return v.kind == kindNull || v.kind == kindUndefined }
+		switch {
+		case nullish(x) || nullish(y):
+			equal = nullish(x) && nullish(y)
+		case x.kind == y.kind:
+			equal = x == y
+		default:
+			return value{}, false
+		}
+	default:
+		return value{}, false
+	}
+	if op == l.TStrictNotEqual || op == l.TNotEqual {
+		equal = !equal
+	}
+	return value{kind: kindBoolean, truth: equal}, true
+}
+
+// literal returns the shortest expression of a constant: booleans are !0
+// and !1, and undefined is void 0.
+func literal(v value) parser.Expr {
+	switch v.kind {
+	case kindNumber:
+		if v.num < 0 || v.num == 0 && math.Signbit(v.num) {
+			return unary(l.TMinus, number(-v.num))
+		}
+		return number(v.num)
+	case kindString:
+		return &parser.ExprLiteral[string]{Token: l.Token{Type: l.TStringLiteral_DoubleQuote, Literal: v.str}}
+	case kindBoolean:
+		if v.truth {
+			return unary(l.TBang, number(0))
+		}
+		return unary(l.TBang, number(1))
+	case kindNull:
+		return parser.ExprLitNull
+	}
+	return unary(l.TVoid, number(0))
+}
+
+func unary(op l.TokenType, operand parser.Expr) *parser.ExprUnaryOp {
+	return &parser.ExprUnaryOp{Operator: l.Token{Type: op, Lexeme: op.S()}, Operand: operand}
+}
+
+func number(num float64) *parser.ExprLiteral[float64] {
+	return &parser.ExprLiteral[float64]{Token: l.Token{Type: l.TNumericLiteral, Lexeme: formatNumber(num), Literal: num}}
+}
+
+// formatNumber returns the shortest source text of a non-negative number,
+// as 1e3 for 1000 and .5 for 0.5.
+func formatNumber(num float64) string {
+	decimal := strconv.FormatFloat(num, 'f', -1, 64)
+	if strings.HasPrefix(decimal, "0.") {
+		decimal = decimal[1:]
+	}
+	// 1e+06 is 1e6, and 1e-07 is 1e-7
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(num, 'e', -1, 64), "e")
+	sign := ""
+	if exponent[0] == '-' {
+		sign = "-"
+	}
+	exponent = strings.TrimLeft(exponent[1:], "0")
+	if exponent == "" {
+		return decimal
+	}
+	if scientific := mantissa + "e" + sign + exponent; len(scientific) < len(decimal) {
+		return scientific
+	}
+	return decimal
+}
+
+// shorter reports whether expr prints no longer than the expression it would
+// replace.
+func shorter(expr, original parser.Node) bool {
+	return len(compact(expr)) <= len(compact(original))
+}
+
+func compact(node parser.Node) string {
+	return printer.Print(node, printOptions)
+}
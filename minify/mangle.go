@@ -0,0 +1,220 @@
+package minify
+
+import (
+	"sort"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/scope"
+)
+
+// Mangling renames the variables of every scope, but the global one, to the
+// shortest names that keep each reference resolving to the same variable.
+// Scopes are named top-down, so that a scope avoids the names of the outer
+// variables referenced within it, and the variables referenced most get the
+// shortest names. Nested scopes reuse the names of their siblings.
+//
+// Names are left alone where they are visible outside the program, or looked
+// up at run time: the global scope, exported declarations, and the scopes
+// enclosing a direct eval or a with statement.
+
+const (
+	nameStart = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$"
+	namePart  = nameStart + "0123456789"
+)
+
+// unsafeNames are the identifiers generated names avoid besides reserved
+// words: those reserved in strict mode code, and those with a meaning of
+// their own.
+var unsafeNames = map[string]bool{
+	"implements": true, "interface": true, "package": true, "private": true,
+	"protected": true, "public": true, "static": true,
+	"arguments": true, "eval": true,
+}
+
+// generatedName returns the i-th shortest identifier name: a, b, ..., $, aa,
+// ba, ...
+func generatedName(i int) string {
+	name := []byte{nameStart[i%len(nameStart)]}
+	for i /= len(nameStart); i > 0; i /= len(namePart) {
+		i--
+		name = append(name, namePart[i%len(namePart)])
+	}
+	return string(name)
+}
+
+func isSafeName(name string) bool {
+	_, reserved := l.ReservedKeywords[name]
+	return !reserved && !unsafeNames[name]
+}
+
+type mangler struct {
+	names map[*scope.Variable]string
+	// scopes whose names may be looked up at run time
+	tainted map[*scope.Scope]bool
+	// the references from within a scope to the variables of enclosing
+	// scopes, and to implicit globals
+	outer map[*scope.Scope][]*scope.Reference
+	// the names of the variables declared within a scope, in nested scopes,
+	// that keep their name
+	keptWithin map[*scope.Scope]map[string]bool
+}
+
+// mangle renames the local variables of program.
+func mangle(program *parser.NodeRoot, options parser.Options) {
+	unshare(program)
+	global := scope.Analyze(program, options)
+	m := &mangler{
+		names:      make(map[*scope.Variable]string),
+		tainted:    make(map[*scope.Scope]bool),
+		outer:      make(map[*scope.Scope][]*scope.Reference),
+		keptWithin: make(map[*scope.Scope]map[string]bool),
+	}
+	m.taint(global)
+	m.collect(global)
+	m.assign(global)
+
+	for variable, name := range m.names {
+		for _, id := range variable.Identifiers {
+			id.Name = name
+		}
+		for _, ref := range variable.References {
+			ref.Identifier.Name = name
+		}
+	}
+}
+
+// taint marks the scopes containing a with statement or a direct call of
+// eval, along with the scopes enclosing them, reporting whether s is tainted.
+func (m *mangler) taint(s *scope.Scope) bool {
+	tainted := s.Kind == scope.ScopeWith || s.Eval
+	for _, child := range s.Children {
+		if m.taint(child) {
+			tainted = true
+		}
+	}
+	m.tainted[s] = tainted
+	return tainted
+}
+
+// collect computes the outer references of s and of its nested scopes, and
+// the kept names within them.
+func (m *mangler) collect(s *scope.Scope) {
+	escapes := func(ref *scope.Reference) bool {
+		return ref.Variable == nil || ref.Variable.Scope != s
+	}
+	for _, ref := range s.References {
+		if escapes(ref) {
+			m.outer[s] = append(m.outer[s], ref)
+		}
+	}
+	kept := make(map[string]bool)
+	for _, child := range s.Children {
+		m.collect(child)
+		for _, ref := range m.outer[child] {
+			if escapes(ref) {
+				m.outer[s] = append(m.outer[s], ref)
+			}
+		}
+		for name := range m.keptWithin[child] {
+			kept[name] = true
+		}
+		for _, variable := range child.Variables {
+			if m.keep(variable) {
+				kept[variable.Name] = true
+			}
+		}
+	}
+	m.keptWithin[s] = kept
+}
+
+// keep reports whether variable keeps its name.
+func (m *mangler) keep(variable *scope.Variable) bool {
+	s := variable.Scope
+	switch {
+	case s.Kind == scope.ScopeGlobal, m.tainted[s]:
+		return true
+	case variable.Kind == scope.DeclImplicit, variable.Exported:
+		return true
+	case variable.Kind == scope.DeclFunction && s.Kind == scope.ScopeBlock && !s.Strict:
+		// Annex B also binds a function declared in a block in the
+		// enclosing function, where it is referred to by name
+		return true
+	}
+	return false
+}
+
+// assign names the variables of s, then those of its nested scopes.
+func (m *mangler) assign(s *scope.Scope) {
+	taken := make(map[string]bool)
+	for _, ref := range m.outer[s] {
+		taken[m.name(ref)] = true
+	}
+	for name := range m.keptWithin[s] {
+		taken[name] = true
+	}
+	var renamed []*scope.Variable
+	for _, variable := range s.Variables {
+		if m.keep(variable) {
+			taken[variable.Name] = true
+		} else {
+			renamed = append(renamed, variable)
+		}
+	}
+	sort.SliceStable(renamed, func(i, j int) bool {
+		return len(renamed[i].References) > len(renamed[j].References)
+	})
+	next := 0
+	for _, variable := range renamed {
+		name := generatedName(next)
+		for next++; taken[name] || !isSafeName(name); next++ {
+			name = generatedName(next)
+		}
+		m.names[variable] = name
+		taken[name] = true
+	}
+
+	for _, child := range s.Children {
+		m.assign(child)
+	}
+}
+
+// name returns the name a reference ends up with.
+func (m *mangler) name(ref *scope.Reference) string {
+	if name, ok := m.names[ref.Variable]; ok {
+		return name
+	}
+	return ref.Identifier.Name
+}
+
+// unshare gives their own identifier to the names that the parser shares
+// between a property key and its value, as in { a }, so that renaming the
+// variable keeps the key: { a: b }.
+func unshare(program *parser.NodeRoot) {
+	parser.Walk(program, func(node parser.Node) bool {
+		switch n := node.(type) {
+		case *parser.PropertyDefinition:
+			if n.Shorthand {
+				n.Key = clone(n.Key)
+			}
+		case *parser.PatternProperty:
+			if n.Shorthand {
+				n.Key = clone(n.Key)
+			}
+		case *parser.ExportSpecifier:
+			if n.Exported == n.Local {
+				n.Exported = clone(n.Exported)
+			}
+		}
+		return true
+	})
+}
+
+func clone(expr parser.Expr) parser.Expr {
+	id, ok := expr.(*parser.ExprIdentifier)
+	if !ok {
+		return expr
+	}
+	clone := *id
+	return &clone
+}
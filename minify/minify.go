@@ -0,0 +1,99 @@
+// Package minify shrinks JavaScript source text: whitespace and comments are
+// dropped, local variables renamed to short names, constants folded and
+// statements rewritten into shorter equivalents.
+//
+// The minified source is checked by parsing it back: Source fails rather than
+// return a source that does not parse to the program it printed.
+package minify
+
+import (
+	"fmt"
+
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/printer"
+	"github.com/ruiconti/gojs/sourcemap"
+)
+
+// Options selects the transformations besides dropping whitespace and
+// comments.
+type Options struct {
+	// Mangle renames local variables, leaving globals, exports and the
+	// scopes reached by eval or with statements alone
+	Mangle bool
+	// Compress folds constants and rewrites syntax into shorter equivalents,
+	// as !0 for true or a && b for if (a) b
+	Compress bool
+}
+
+// DefaultOptions applies every transformation.
+var DefaultOptions = Options{Mangle: true, Compress: true}
+
+var printOptions = printer.Options{Mode: printer.Compact, Quote: printer.QuoteDouble}
+
+// Source minifies src, parsed with parseOptions. It returns the syntax error
+// of a source that does not parse.
+func Source(src string, parseOptions parser.Options, options Options) (string, error) {
+	file, err := parse(src, parseOptions, options)
+	if err != nil {
+		return "", err
+	}
+	minified := printer.Print(file.Program, printOptions)
+	if err := check(minified, parseOptions); err != nil {
+		return "", err
+	}
+	return minified, nil
+}
+
+// SourceWithMap minifies src like Source, along with the source map of the
+// minified source back to src, named source in the map.
+func SourceWithMap(src, source string, parseOptions parser.Options, options Options) (string, *sourcemap.Map, error) {
+	file, err := parse(src, parseOptions, options)
+	if err != nil {
+		return "", nil, err
+	}
+	minified, sourceMap := printer.PrintFileWithMap(file, printOptions, source)
+	if err := check(minified, parseOptions); err != nil {
+		return "", nil, err
+	}
+	return minified, sourceMap, nil
+}
+
+// parse parses src and minifies its program.
+func parse(src string, parseOptions parser.Options, options Options) (*parser.File, error) {
+	file, err := parser.ParseFile(src, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	Program(file.Program, parseOptions, options)
+	return file, nil
+}
+
+// check parses the minified source back, failing unless it prints the same.
+func check(minified string, parseOptions parser.Options) error {
+	reparsed, err := parser.ParseFile(minified, parseOptions)
+	if err != nil {
+		return fmt.Errorf("minified source does not parse: %v", err)
+	}
+	if printer.Print(reparsed.Program, printOptions) != minified {
+		return fmt.Errorf("minified source does not parse back to the same program")
+	}
+	return nil
+}
+
+// Program minifies program in place, which was parsed with parseOptions.
+func Program(program *parser.NodeRoot, parseOptions parser.Options, options Options) {
+	if options.Compress {
+		stmts := make([]parser.Stmt, len(program.Children))
+		for i, child := range program.Children {
+			stmts[i] = child
+		}
+		stmts = body(stmts)
+		program.Children = make([]parser.Node, len(stmts))
+		for i, stmt := range stmts {
+			program.Children[i] = stmt
+		}
+	}
+	if options.Mangle {
+		mangle(program, parseOptions)
+	}
+}
@@ -0,0 +1,205 @@
+package minify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+var (
+	script = parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true}
+	module = parser.Options{SourceType: parser.SourceTypeModule}
+)
+
+func assertMinify(t *testing.T, src, expected string, parseOptions parser.Options, options Options) {
+	t.Helper()
+	got, err := Source(src, parseOptions, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestSource_Whitespace(t *testing.T) {
+	src := "// a comment\nlet a = 'b' /* another */\n\nf(a, [1, 2])\n"
+	assertMinify(t, src, `let a="b";f(a,[1,2]);`, script, Options{})
+}
+
+func TestSource_Compress(t *testing.T) {
+	tcs := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"booleans", "a = true; b = false; c = undefined", "a=!0;b=!1;c=void 0;"},
+		{"arithmetic", "a = 60 * 60 * 24; b = 1 / 3; c = 2 - 5", "a=86400;b=1/3;c=-3;"},
+		{"concatenation", `a = "a" + 'b' + 1`, `a="ab1";`},
+		{"comparisons", "a = 1 < 2; b = 'a' === 'b'; c = null == undefined", "a=!0;b=!1;c=!0;"},
+		{"unary", "a = !1; b = typeof 1; c = void f()", `a=!1;b="number";c=void f();`},
+		{"logical", "a = 0 || b; c = 1 && d; e = null ?? f; g = 1 ? h : i", "a=b;c=d;e=f;g=h;"},
+		{"numbers", "a = 1000000; b = 0.5; c = 1_000; d = 1.50", "a=1e6;b=.5;c=1e3;d=1.5;"},
+		{"number members", "a = (1.50).toFixed(); b = (1000000).x; c = (0.5).x", "a=(1.5).toFixed();b=(1e6).x;c=(.5).x;"},
+		{"member access", "a['b'] = a['if'] + a['1']", `a.b=a["if"]+a["1"];`},
+		{"this of calls", "(0, a.b)(); (1 && a.b)(); (1 && a)()", "(0,a.b)();(1&&a.b)();(1&&a)();"},
+		{"var merging", "var a = 1; var b; let c; let d = 2; const e = 3", "var a=1,b;let c,d=2;const e=3;"},
+		{"if and", "if (a) b()", "a&&b();"},
+		{"if or", "if (!a) b()", "a||b();"},
+		{"if ternary", "if (a) { b(); c() } else d()", "a?(b(),c()):d();"},
+		{"negated if else", "if (!a) { var b } else c()", "if(a)c();else var b;"},
+		{"if return", "function f(a) { if (a) { return 1 } else { return 2 } }", "function f(a){return a?1:2;}"},
+		{"if return fallthrough", "function f(a) { if (a) return 1; return 2 }", "function f(a){return a?1:2;}"},
+		{"bare return", "function f(a) { if (a) return; else return 1 }", "function f(a){return a?void 0:1;}"},
+		{"return undefined", "function f() { g(); return undefined }", "function f(){g();return;}"},
+		{"constant if", "if (1) { a() } else { b() } if (0) c()", "a();"},
+		{"constant if keeping var", "if (0) { var a }", "if(0)var a;"},
+		{"blocks", "{ a(); { b() } } { let c }", "a();b();{let c;}"},
+		{"directive prologue", "'a' + 'b'; 'use strict'", `("ab");("use strict");`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assertMinify(t, tc.src, tc.expected, script, Options{Compress: true})
+		})
+	}
+}
+
+func TestSource_Mangle(t *testing.T) {
+	tcs := []struct {
+		name     string
+		src      string
+		expected string
+		options  parser.Options
+	}{
+		{
+			name:     "locals",
+			src:      "function f(first, second) { var sum = first + second; return sum * sum }",
+			expected: "function f(b,c){var a=b+c;return a*a;}",
+			options:  script,
+		},
+		{
+			name:     "globals",
+			src:      "var total = 0; function add(value) { total += value; return total }",
+			expected: "var total=0;function add(a){total+=a;return total;}",
+			options:  script,
+		},
+		{
+			name:     "outer references",
+			src:      "(function (outer) { return function (inner) { return outer + inner + a } })()",
+			expected: "(function(b){return function(c){return b+c+a;};})();",
+			options:  script,
+		},
+		{
+			name:     "shorthand properties",
+			src:      "function f(value) { const { key } = value; return { key, value } }",
+			expected: "function f(a){const{key:b}=a;return{key:b,value:a};}",
+			options:  script,
+		},
+		{
+			name:     "eval",
+			src:      "function f(value) { return function g(other) { return eval('value') } }",
+			expected: "function f(value){return function g(other){return eval(\"value\");};}",
+			options:  script,
+		},
+		{
+			name:     "with",
+			src:      "function f(value, object) { with (object) { return value } }",
+			expected: "function f(value,object){with(object){return value;}}",
+			options:  script,
+		},
+		{
+			name:     "arguments",
+			src:      "function f(value) { return arguments[value] }",
+			expected: "function f(a){return arguments[a];}",
+			options:  script,
+		},
+		{
+			name:     "exports",
+			src:      "import { load } from 'm'; const local = load(); export const exported = local; export { local }",
+			expected: `import{load as b}from"m";const a=b();export const exported=a;export{a as local};`,
+			options:  module,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assertMinify(t, tc.src, tc.expected, tc.options, Options{Mangle: true})
+		})
+	}
+}
+
+func TestSource_Default(t *testing.T) {
+	src := `function greet(name, enthusiastic) {
+  var greeting = "Hello, " + name;
+  if (enthusiastic) {
+    return greeting + "!";
+  } else {
+    return greeting + ".";
+  }
+}
+`
+	expected := `function greet(b,c){var a="Hello, "+b;return c?a+"!":a+".";}`
+	assertMinify(t, src, expected, script, DefaultOptions)
+}
+
+func TestSource_SyntaxError(t *testing.T) {
+	if _, err := Source("a = (", script, DefaultOptions); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestSourceWithMap(t *testing.T) {
+	src := `function greet(name, enthusiastic) {
+  var greeting = "Hello, " + name;
+  if (enthusiastic) return greeting + "!";
+  return greeting;
+}
+`
+	got, sourceMap, err := SourceWithMap(src, "greet.js", script, DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `function greet(b,c){var a="Hello, "+b;return c?a+"!":a;}`
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+	mappings, err := sourceMap.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every renamed variable maps back to where it is written in the source,
+	// under its original name
+	lines := strings.Split(src, "\n")
+	renamed := make(map[string]string)
+	for _, mapping := range mappings {
+		if mapping.GeneratedLine != 0 || mapping.Source != "greet.js" {
+			t.Fatalf("unexpected mapping %+v", mapping)
+		}
+		if mapping.Name == "" {
+			continue
+		}
+		original := lines[mapping.OriginalLine][mapping.OriginalColumn:]
+		if !strings.HasPrefix(original, mapping.Name) {
+			t.Errorf("expected %q at %d:%d of the source, got %q", mapping.Name, mapping.OriginalLine, mapping.OriginalColumn, original)
+		}
+		generated := got[mapping.GeneratedColumn:]
+		end := strings.IndexAny(generated, "(){}=+;,?:")
+		renamed[generated[:end]] = mapping.Name
+	}
+	expectedNames := map[string]string{"greet": "greet", "a": "greeting", "b": "name", "c": "enthusiastic"}
+	for name, original := range expectedNames {
+		if renamed[name] != original {
+			t.Errorf("expected %s to map back to %s, got %q", name, original, renamed[name])
+		}
+	}
+}
+
+func TestGeneratedName(t *testing.T) {
+	tcs := map[int]string{0: "a", 25: "z", 53: "$", 54: "aa", 55: "ba", 54 + 54: "ab"}
+	for i, expected := range tcs {
+		if got := generatedName(i); got != expected {
+			t.Errorf("generatedName(%d): expected %q, got %q", i, expected, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ruiconti/gojs/sourcemap"
+)
+
+func TestMinify(t *testing.T) {
+	const src = "// a comment\nfunction f(value) {\n  if (value) {\n    return true;\n  }\n}\n"
+	run := func(stdin string, args ...string) (stdout, stderr string, code int) {
+		var out, errOut bytes.Buffer
+		code = runMinify(args, strings.NewReader(stdin), &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+
+	t.Run("standard input", func(t *testing.T) {
+		expected := "function f(a){if(a)return!0;}\n"
+		if stdout, _, code := run(src); code != 0 || stdout != expected {
+			t.Errorf("expected %q, got %q (exit %d)", expected, stdout, code)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		expected := "function f(value){if(value){return true;}}\n"
+		if stdout, _, code := run(src, "-mangle=false", "-compress=false"); code != 0 || stdout != expected {
+			t.Errorf("expected %q, got %q (exit %d)", expected, stdout, code)
+		}
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "in.mjs")
+		if err := os.WriteFile(path, []byte("export const answer = 6 * 7\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		output := filepath.Join(dir, "out.mjs")
+		if stdout, stderr, code := run("", "-o", output, path); code != 0 || stdout != "" {
+			t.Fatalf("expected no output, got %q %q (exit %d)", stdout, stderr, code)
+		}
+		res, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "export const answer=42;\n"; string(res) != expected {
+			t.Errorf("expected %q, got %q", expected, res)
+		}
+	})
+
+	t.Run("source map", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "in.js")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// in.js was generated from f.ts, a line below
+		inner := sourcemap.NewGenerator("in.js")
+		for line := 0; line < 5; line++ {
+			inner.Add(sourcemap.Mapping{GeneratedLine: line, Source: "f.ts", OriginalLine: line + 1})
+		}
+		data, err := json.Marshal(inner.Map())
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputMap := filepath.Join(dir, "in.js.map")
+		if err := os.WriteFile(inputMap, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		output := filepath.Join(dir, "out", "out.js")
+		if err := os.Mkdir(filepath.Dir(output), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			args   []string
+			source string
+		}{
+			{args: nil, source: "../in.js"},
+			{args: []string{"-in-map", inputMap}, source: "f.ts"},
+		} {
+			mapFile := output + ".map"
+			args := append(tc.args, "-o", output, "-map", mapFile, path)
+			if stdout, stderr, code := run("", args...); code != 0 {
+				t.Fatalf("unexpected failure: %q %q (exit %d)", stdout, stderr, code)
+			}
+			res, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := "function f(a){if(a)return!0;}\n//# sourceMappingURL=out.js.map\n"; string(res) != expected {
+				t.Errorf("expected %q, got %q", expected, res)
+			}
+			data, err := os.ReadFile(mapFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := sourcemap.Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.File != "out.js" || len(m.Sources) != 1 || m.Sources[0] != tc.source {
+				t.Errorf("expected a map of out.js from %s, got %s", tc.source, data)
+			}
+			// value, renamed to a, on the second line of in.js
+			mapping, ok := m.Lookup(0, strings.Index(string(res), "a"))
+			if expected := 1 + len(tc.args)/2; !ok || mapping.Name != "value" || mapping.OriginalLine != expected {
+				t.Errorf("expected a to map to value on line %d, got %+v", expected, mapping)
+			}
+		}
+
+		if _, stderr, code := run("", "-in-map", inputMap, path); code != 2 || !strings.Contains(stderr, "-in-map requires -map") {
+			t.Errorf("expected -in-map to require -map, got %q (exit %d)", stderr, code)
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, stderr, code := run("a = (\n"); code != 2 || !strings.HasPrefix(stderr, "<standard input>:") {
+			t.Errorf("expected a syntax error, got %q (exit %d)", stderr, code)
+		}
+	})
+}
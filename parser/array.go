@@ -10,7 +10,10 @@ import (
 const EArrayLiteral ExprType = "EExprArray"
 
 type ExprArray struct {
-	elements []Expr
+	Elements []Expr
+	// whether a ',' ends the elements, which it can't after the rest
+	// element of an ArrayAssignmentPattern
+	trailingComma bool
 }
 
 func (e *ExprArray) Type() ExprType {
@@ -20,9 +23,9 @@ func (e *ExprArray) Type() ExprType {
 func (e *ExprArray) S() string {
 	src := strings.Builder{}
 	src.Write([]byte("(cons "))
-	for i, element := range e.elements {
+	for i, element := range e.Elements {
 		src.Write([]byte(element.S()))
-		if i < len(e.elements)-1 {
+		if i < len(e.Elements)-1 {
 			src.Write([]byte(" "))
 		}
 	}
@@ -30,6 +33,11 @@ func (e *ExprArray) S() string {
 	return src.String()
 }
 
+// ArrayHole is the element of an Elision, as in [a, , b]. It prints as null,
+// but unlike a null literal it stands for a hole once the array is
+// reinterpreted as an ArrayPattern.
+var ArrayHole = MakeLiteralExpr(l.TNull)
+
 // ArrayLiteral :
 // | '[' Elision? ']'
 // | '[' ElementList ']'
@@ -50,7 +58,7 @@ func (p *Parser) parseArrayInitializer() (Expr, error) {
 		for {
 			switch token := p.Peek(); token.Type {
 			case l.TEOF:
-				break loop
+				return nil, p.needToken(fmt.Errorf("expected ']' at the end of the array literal"))
 			case l.TRightBracket:
 				p.Next() // consume ']'
 				break loop
@@ -63,23 +71,30 @@ func (p *Parser) parseArrayInitializer() (Expr, error) {
 					//   |
 					//   | consumed on this iteration
 					//
-					exprArray.elements = append(exprArray.elements, ExprLitNull)
+					exprArray.Elements = append(exprArray.Elements, ArrayHole)
 				}
 				p.Next() // consume ','
+				exprArray.trailingComma = true
 			case l.TEllipsis:
 				p.Next() // consume '...'
-				arg, err := p.parseAssignExpr()
+				arg, err := p.parseCoverAssignExpr()
 				if err != nil {
 					return nil, err
 				}
-				exprArray.elements = append(exprArray.elements, &SpreadElement{argument: arg})
+				exprArray.Elements = append(exprArray.Elements, &SpreadElement{Argument: arg})
+				exprArray.trailingComma = false
 
 			default:
-				exprAssign, err := p.parseAssignExpr()
+				exprAssign, err := p.parseCoverAssignExpr()
 				if err != nil {
 					return nil, err
 				}
-				exprArray.elements = append(exprArray.elements, exprAssign)
+				exprArray.Elements = append(exprArray.Elements, exprAssign)
+				exprArray.trailingComma = false
+			}
+			// elements are separated by commas
+			if next := p.Peek().Type; !exprArray.trailingComma && next != l.TComma && next != l.TRightBracket {
+				return nil, p.needToken(fmt.Errorf("expected ',' or ']' after an array element, got %s", p.Peek().Lexeme))
 			}
 		}
 		return &exprArray, nil
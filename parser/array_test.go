@@ -12,7 +12,7 @@ func TestParseArray_Simple(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `[]`
 		expected := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{},
 			},
 		}
@@ -24,9 +24,9 @@ func TestParseArray_Simple(t *testing.T) {
 		src := `[,,, ,,   , ]`
 		// src := `[null,null,null,null,null,null,]`
 		expected := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{
-					elements: []Expr{
+					Elements: []Expr{
 						ExprLitNull,
 						ExprLitNull,
 						ExprLitNull,
@@ -44,14 +44,14 @@ func TestParseArray_Simple(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `[1,2,true,\u3340xa,undefined, null,'foo', "bar",]`
 		expected := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{
-					elements: []Expr{
+					Elements: []Expr{
 						&ExprLiteral[float64]{l.Token{Type: l.TNumericLiteral, Literal: "1"}},
 						&ExprLiteral[float64]{l.Token{Type: l.TNumericLiteral, Literal: "2"}},
 						ExprLitTrue,
 						&ExprIdentifier{
-							name: `\u3340xa`,
+							Name: `\u3340xa`,
 						},
 						ExprLitUndefined,
 						ExprLitNull,
@@ -118,29 +118,74 @@ func TestParseArrayElementList_Assignment_Yield(t *testing.T) {
 }
 
 func TestParseArrayElementList_Assignment_ArrowFunc(t *testing.T) {
-	t.Skip()
-	// src := `[, (a) => ({}), a => {}, ([a,b,{c}]) => c]`
-	// expected := NodeRoot{}
-	// got := Parse(src)
-	// CompareRootChildren(
-	// 	t,
-	// 	src,
-	// 	(got.children[0]).(*ExprArray).elements,
-	// 	(expected.children[0]).(*ExprArray).elements,
-	// )
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	src := `[, (a) => ({}), a => {}, ([a,b,{c}]) => c]`
+	exp := &NodeRoot{
+		Children: []Node{
+			&ExprArray{
+				Elements: []Expr{
+					ExprLitNull,
+					&ExprArrowFunction{
+						Params:     []Node{idExpr("a")},
+						Expression: &ExprParenthesized{Expression: &ExprObject{}},
+					},
+					&ExprArrowFunction{
+						Params: []Node{idExpr("a")},
+						Body:   []Stmt{},
+					},
+					&ExprArrowFunction{
+						Params: []Node{
+							&ArrayPattern{
+								Elements: []Node{
+									idExpr("a"),
+									idExpr("b"),
+									&ObjectPattern{
+										Properties: []Node{
+											&PatternProperty{Key: idExpr("c"), Value: idExpr("c"), Shorthand: true},
+										},
+									},
+								},
+							},
+						},
+						Expression: idExpr("c"),
+					},
+				},
+			},
+		},
+	}
+	got := Parse(logger, src)
+	AssertExprEqual(t, logger, got, exp)
 }
 
 func TestParseArrayElementList_Assignment_AsyncArrowFunc(t *testing.T) {
-	t.Skip()
-	// src := `[, async (a) => ({}), async a => {}, async b => await b]`
-	// expected := NodeRoot{}
-	// got := Parse(src)
-	// CompareRootChildren(
-	// 	t,
-	// 	src,
-	// 	(got.children[0]).(*ExprArray).elements,
-	// 	(expected.children[0]).(*ExprArray).elements,
-	// )
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	src := `[, async (a) => ({}), async a => {}, async b => await b]`
+	exp := &NodeRoot{
+		Children: []Node{
+			&ExprArray{
+				Elements: []Expr{
+					ExprLitNull,
+					&ExprArrowFunction{
+						Params:     []Node{idExpr("a")},
+						Expression: &ExprParenthesized{Expression: &ExprObject{}},
+						Async:      true,
+					},
+					&ExprArrowFunction{
+						Params: []Node{idExpr("a")},
+						Body:   []Stmt{},
+						Async:  true,
+					},
+					&ExprArrowFunction{
+						Params:     []Node{idExpr("b")},
+						Expression: &ExprAwait{Argument: idExpr("b")},
+						Async:      true,
+					},
+				},
+			},
+		},
+	}
+	got := Parse(logger, src)
+	AssertExprEqual(t, logger, got, exp)
 }
 
 func TestParseArrayElementList_Assignment_LeftHS_NewExp1(t *testing.T) {
@@ -148,17 +193,17 @@ func TestParseArrayElementList_Assignment_LeftHS_NewExp1(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `[, new Map([1, 2]), ]`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{
-					elements: []Expr{
+					Elements: []Expr{
 						ExprLitNull,
 						&ExprNew{
-							callee: &ExprIdentifier{
-								name: "Map",
+							Callee: &ExprIdentifier{
+								Name: "Map",
 							},
-							arguments: []Expr{
+							Arguments: []Expr{
 								&ExprArray{
-									elements: []Expr{idExpr("1"), idExpr("2")},
+									Elements: []Expr{idExpr("1"), idExpr("2")},
 								},
 							},
 						},
@@ -175,35 +220,35 @@ func TestParseArrayElementList_Assignment_LeftHS_NewExp1(t *testing.T) {
 	})
 	t.Run("new call expr and member access", func(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
-		src := `[new t.p, new t.p(...x), a[b[c[d[e]]]]`
+		src := `[new t.p, new t.p(...x), a[b[c[d[e]]]]]`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{
-					elements: []Expr{
+					Elements: []Expr{
 						&ExprNew{
-							callee: &ExprMemberAccess{
-								object:   idExpr("t"),
-								property: idExpr("p"),
+							Callee: &ExprMemberAccess{
+								Object:   idExpr("t"),
+								Property: idExpr("p"),
 							},
 						},
 						&ExprNew{
-							callee: &ExprMemberAccess{
-								object:   idExpr("t"),
-								property: idExpr("p"),
+							Callee: &ExprMemberAccess{
+								Object:   idExpr("t"),
+								Property: idExpr("p"),
 							},
-							arguments: []Expr{
-								&SpreadElement{argument: idExpr("x")},
+							Arguments: []Expr{
+								&SpreadElement{Argument: idExpr("x")},
 							},
 						},
 						&ExprMemberAccess{
-							object: idExpr("a"),
-							property: &ExprMemberAccess{
-								object: idExpr("b"),
-								property: &ExprMemberAccess{
-									object: idExpr("c"),
-									property: &ExprMemberAccess{
-										object:   idExpr("d"),
-										property: idExpr("e"),
+							Object: idExpr("a"),
+							Property: &ExprMemberAccess{
+								Object: idExpr("b"),
+								Property: &ExprMemberAccess{
+									Object: idExpr("c"),
+									Property: &ExprMemberAccess{
+										Object:   idExpr("d"),
+										Property: idExpr("e"),
 									},
 								},
 							},
@@ -220,17 +265,17 @@ func TestParseArrayElementList_Assignment_LeftHS_NewExp1(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `[import(a), super(a,...b,)]`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprArray{
-					elements: []Expr{
+					Elements: []Expr{
 						&ExprImportCall{
-							source: idExpr("a"),
+							Source: idExpr("a"),
 						},
 						&ExprCall{
-							callee: MakeLiteralExpr(l.TSuper),
-							arguments: []Expr{
+							Callee: MakeLiteralExpr(l.TSuper),
+							Arguments: []Expr{
 								idExpr("a"),
-								&SpreadElement{argument: idExpr("b")},
+								&SpreadElement{Argument: idExpr("b")},
 							},
 						},
 					},
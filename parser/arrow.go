@@ -0,0 +1,419 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// ////////////////////
+// ExprArrowFunction //
+// ////////////////////
+const EArrowFunction ExprType = "ExprArrowFunction"
+
+type ExprArrowFunction struct {
+	Params []Node
+	// Body holds the statements of a '{' FunctionBody '}' body, while
+	// Expression holds a concise ExpressionBody. Only one of them is set.
+	Body       []Stmt
+	Expression Expr
+	Async      bool
+	Strict     bool // whether the function is strict mode code
+}
+
+func (e *ExprArrowFunction) Type() ExprType {
+	return EArrowFunction
+}
+
+func (e *ExprArrowFunction) S() string {
+	params := strings.Builder{}
+	for i, param := range e.Params {
+		params.WriteString(param.S())
+		if i < len(e.Params)-1 {
+			params.WriteString(" ")
+		}
+	}
+
+	var body string
+	if e.Expression != nil {
+		body = e.Expression.S()
+	} else {
+		body = (&BlockStatement{Stmts: e.Body}).S()
+	}
+
+	arrow := "=>"
+	if e.Async {
+		arrow = "async=>"
+	}
+	return fmt.Sprintf("(%s (%s) %s)", arrow, params.String(), body)
+}
+
+// ////////////////////
+// ExprParenthesized //
+// ////////////////////
+const EParenthesized ExprType = "ExprParenthesized"
+
+type ExprParenthesized struct {
+	Expression Expr
+}
+
+func (e *ExprParenthesized) Type() ExprType {
+	return EParenthesized
+}
+
+func (e *ExprParenthesized) S() string {
+	return fmt.Sprintf("(paren %s)", e.Expression.S())
+}
+
+// Unparenthesized returns the expression wrapped in any number of
+// parentheses.
+func Unparenthesized(expr Node) Node {
+	for {
+		parenthesized, ok := expr.(*ExprParenthesized)
+		if !ok {
+			return expr
+		}
+		expr = parenthesized.Expression
+	}
+}
+
+// ///////////////
+// ExprSequence //
+// ///////////////
+const ESequence ExprType = "ExprSequence"
+
+type ExprSequence struct {
+	Expressions []Expr
+}
+
+func (e *ExprSequence) Type() ExprType {
+	return ESequence
+}
+
+func (e *ExprSequence) S() string {
+	exprs := strings.Builder{}
+	for i, expr := range e.Expressions {
+		exprs.WriteString(expr.S())
+		if i < len(e.Expressions)-1 {
+			exprs.WriteString(" ")
+		}
+	}
+	return fmt.Sprintf("(, %s)", exprs.String())
+}
+
+// coverParenthesized holds the result of parsing
+// CoverParenthesizedExpressionAndArrowParameterList, before we know whether it
+// is a ParenthesizedExpression or ArrowParameters.
+type coverParenthesized struct {
+	exprs         []Expr
+	rest          *RestElement // '...' BindingIdentifier | '...' BindingPattern
+	trailingComma bool
+}
+
+// CoverParenthesizedExpressionAndArrowParameterList[Yield, Await] :
+// | '(' Expression[+In, ?Yield, ?Await] ')'
+// | '(' Expression[+In, ?Yield, ?Await] ',' ')'
+// | '(' ')'
+// | '(' '...' BindingIdentifier[?Yield, ?Await] ')'
+// | '(' '...' BindingPattern[?Yield, ?Await] ')'
+// | '(' Expression[+In, ?Yield, ?Await] ',' '...' BindingIdentifier[?Yield, ?Await] ')'
+// | '(' Expression[+In, ?Yield, ?Await] ',' '...' BindingPattern[?Yield, ?Await] ')'
+//
+// simplifying
+//
+// CoverParenthesizedExpressionAndArrowParameterList :
+// '(' (AssignmentExpression (',' AssignmentExpression)* ','?)? ('...' (BindingIdentifier | BindingPattern))? ')'
+func (p *Parser) parseCoverParenthesized() (*coverParenthesized, error) {
+	p.Log("parseCoverParenthesized")
+	if p.Peek().Type != l.TLeftParen {
+		return nil, p.needToken(fmt.Errorf("expected '(', got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume '('
+
+	cover := &coverParenthesized{}
+	lastCursor := p.cursor
+	for {
+		switch token := p.Peek(); token.Type {
+		case l.TRightParen:
+			p.Next() // consume ')'
+			return cover, nil
+		case l.TEllipsis:
+			rest, err := p.parseBindingRestElement(true)
+			if err != nil {
+				return nil, err
+			}
+			cover.rest = rest
+			if p.Peek().Type != l.TRightParen {
+				return nil, fmt.Errorf("rest element must be last, got %s", p.Peek().Lexeme)
+			}
+			cover.trailingComma = false
+		default:
+			expr, err := p.parseCoverAssignExpr()
+			if err != nil {
+				return nil, err
+			}
+			cover.exprs = append(cover.exprs, expr)
+			cover.trailingComma = false
+
+			switch p.Peek().Type {
+			case l.TComma:
+				p.Next() // consume ','
+				cover.trailingComma = true
+			case l.TRightParen:
+			default:
+				return nil, p.needToken(fmt.Errorf("expected ',' or ')', got %s", p.Peek().Lexeme))
+			}
+		}
+		p.guardInfiniteLoop(&lastCursor)
+	}
+}
+
+// ParenthesizedExpression[Yield, Await] :
+// | '(' Expression[+In, ?Yield, ?Await] ')'
+func (p *Parser) parseParenthesizedExpr() (Expr, error) {
+	p.Log("parseParenthesizedExpr")
+	cover, err := p.parseCoverParenthesized()
+	if err != nil {
+		return nil, err
+	}
+	if len(cover.exprs) == 0 || cover.rest != nil || cover.trailingComma {
+		return nil, fmt.Errorf("invalid parenthesized expression: expected '=>' after arrow parameters")
+	}
+	for _, expr := range cover.exprs {
+		if err := checkCoverGrammar(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cover.exprs) == 1 {
+		return &ExprParenthesized{Expression: cover.exprs[0]}, nil
+	}
+	return &ExprParenthesized{Expression: &ExprSequence{Expressions: cover.exprs}}, nil
+}
+
+// isArrowAhead reports whether the tokens starting at the cursor are
+// ArrowParameters followed by '=>' on the same line, without parsing them.
+// This avoids parsing a parenthesized list twice when it is not an arrow.
+func (p *Parser) isArrowAhead() bool {
+	var end uint32
+	switch token := p.Peek(); {
+	case p.isIdentifier(token):
+		end = p.cursor
+	case token.Type == l.TLeftParen:
+		var ok bool
+		if end, ok = p.closingParen(p.cursor); !ok {
+			return false
+		}
+	default:
+		return false
+	}
+
+	arrow := p.PeekN(int32(end-p.cursor) + 1)
+	return arrow.Type == l.TArrow && !arrow.NewlineBefore
+}
+
+// closingParen returns the index of the ')' that closes the '(' at index
+// open. The matches of the nested parentheses are recorded along the way, so
+// each token is scanned once however deep the nesting is. An unclosed '(' is
+// recorded as closed past the last token.
+func (p *Parser) closingParen(open uint32) (uint32, bool) {
+	if end, ok := p.closingParens[open]; ok {
+		return end, end <= p.seqEnd
+	}
+	if p.closingParens == nil {
+		p.closingParens = make(map[uint32]uint32)
+	}
+
+	var opened []uint32
+scan:
+	for i := open; i <= p.seqEnd; i++ {
+		switch p.tokens[i].Type {
+		case l.TLeftParen:
+			if end, ok := p.closingParens[i]; ok && i != open {
+				if end > p.seqEnd {
+					break scan // so are the enclosing ones
+				}
+				i = end
+				continue
+			}
+			opened = append(opened, i)
+		case l.TRightParen:
+			last := opened[len(opened)-1]
+			opened = opened[:len(opened)-1]
+			p.closingParens[last] = i
+			if len(opened) == 0 {
+				return i, true
+			}
+		}
+	}
+	for _, i := range opened {
+		p.closingParens[i] = p.seqEnd + 1
+	}
+	return 0, false
+}
+
+// errNotArrowFunction is returned by parseArrowFunction when the next tokens
+// are not ArrowParameters followed by '=>'.
+var errNotArrowFunction = errors.New("rejected on parseArrowFunction")
+
+// ArrowFunction[In, Yield, Await] :
+// | ArrowParameters[?Yield, ?Await] [no LineTerminator here] '=>' ConciseBody[?In]
+//
+// ArrowParameters[Yield, Await] :
+// | BindingIdentifier[?Yield, ?Await]
+// | CoverParenthesizedExpressionAndArrowParameterList[?Yield, ?Await]
+//
+// AsyncArrowFunction[In, Yield, Await] :
+// | 'async' [no LineTerminator here] AsyncArrowBindingIdentifier[?Yield] [no LineTerminator here] '=>' AsyncConciseBody[?In]
+// | CoverCallExpressionAndAsyncArrowHead[?Yield, ?Await] [no LineTerminator here] '=>' AsyncConciseBody[?In]
+//
+// AsyncArrowHead :
+// | 'async' [no LineTerminator here] ArrowFormalParameters[~Yield, +Await]
+func (p *Parser) parseArrowFunction() (Expr, error) {
+	p.Log("parseArrowFunction")
+	var (
+		params []Node
+		async  bool
+		start  = p.Peek()
+	)
+
+	if next := p.PeekN(1); p.Peek().Type == l.TAsync && !next.NewlineBefore &&
+		(p.isIdentifier(next) || next.Type == l.TLeftParen) {
+		p.Next() // consume 'async'
+		async = true
+	}
+	if !p.isArrowAhead() {
+		return nil, errNotArrowFunction
+	}
+
+	// AsyncArrowHead : 'async' ArrowFormalParameters[~Yield, +Await]
+	err := p.withContext(p.yield && !async, p.await || async, func() error {
+		switch token := p.Peek(); {
+		case p.isIdentifier(token):
+			// ArrowParameters : BindingIdentifier
+			p.Next() // consume identifier
+			params = []Node{newIdentifier(token)}
+		case token.Type == l.TLeftParen:
+			// ArrowParameters : CoverParenthesizedExpressionAndArrowParameterList
+			cover, err := p.parseCoverParenthesized()
+			if err != nil {
+				return err
+			}
+			if params, err = cover.arrowParameters(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek().Type != l.TArrow {
+		return nil, p.needToken(fmt.Errorf("expected '=>' after arrow parameters, got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume '=>'
+
+	// ConciseBody[~Yield, ~Await] | AsyncConciseBody[~Yield, +Await]
+	exprArrow := &ExprArrowFunction{Params: params, Async: async}
+	err = p.withContext(false, async, func() error {
+		return p.parseConciseBody(exprArrow)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.recordSpan(exprArrow, start)
+	return exprArrow, nil
+}
+
+// ConciseBody[In] :
+// | [lookahead ≠ {] ExpressionBody[?In, ~Await]
+// | '{' FunctionBody[~Yield, ~Await] '}'
+//
+// ExpressionBody[In, Await] :
+// | AssignmentExpression[?In, ~Yield, ?Await]
+func (p *Parser) parseConciseBody(exprArrow *ExprArrowFunction) error {
+	defer func() {
+		exprArrow.Strict = p.strict
+	}()
+	if p.Peek().Type == l.TLeftBrace {
+		body, err := p.parseFunctionBody()
+		if err != nil {
+			return err
+		}
+		exprArrow.Body = body
+		return nil
+	}
+
+	expr, err := p.parseAssignExpr()
+	if err != nil {
+		return err
+	}
+	exprArrow.Expression = expr
+	return nil
+}
+
+// arrowParameters reinterprets the covered list as ArrowFormalParameters.
+//
+// ArrowFormalParameters[Yield, Await] :
+// | '(' UniqueFormalParameters[?Yield, ?Await] ')'
+func (c *coverParenthesized) arrowParameters() ([]Node, error) {
+	params := make([]Node, 0, len(c.exprs)+1)
+	for _, expr := range c.exprs {
+		param, err := reinterpretAsParameter(expr)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	if c.rest != nil {
+		params = append(params, c.rest)
+	}
+	for _, param := range params {
+		if err := checkArrowParameter(param); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// checkArrowParameter rejects the yield and await expressions in an arrow
+// parameter, which the parameter takes from the generator or async function
+// the arrow function is nested in, or from its own async head, but not those
+// in the functions nested in the parameter.
+//
+// https://262.ecma-international.org/#sec-arrow-function-definitions-static-semantics-early-errors
+// https://262.ecma-international.org/#sec-async-arrow-function-definitions-static-semantics-early-errors
+func checkArrowParameter(param Node) (err error) {
+	Walk(param, func(node Node) bool {
+		switch node.(type) {
+		case *ExprYield:
+			err = fmt.Errorf("invalid arrow parameter: yield expression")
+		case *ExprAwait:
+			err = fmt.Errorf("invalid arrow parameter: await expression")
+		case *FunctionDeclarationStmt, *ExprFunction, *ExprArrowFunction:
+			return false
+		}
+		return err == nil
+	})
+	return err
+}
+
+// reinterpretAsParameter checks whether an expression parsed within the cover
+// grammar is also a valid FormalParameter.
+//
+// FormalParameter[Yield, Await] :
+// | BindingElement[?Yield, ?Await]
+//
+// BindingElement[Yield, Await] :
+// | SingleNameBinding[?Yield, ?Await]
+// | BindingPattern[?Yield, ?Await] Initializer[+In, ?Yield, ?Await]?
+func reinterpretAsParameter(expr Expr) (Node, error) {
+	param, err := reinterpretAsElement(expr, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arrow parameter: %w", err)
+	}
+	return param, nil
+}
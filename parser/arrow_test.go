@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+func TestArrowFunction(t *testing.T) {
+	t.Run("single identifier parameter with concise body", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a => a + 1`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params:     []Node{idExpr("a")},
+					Expression: binExpr(idExpr("a"), intExpr(1), l.TPlus),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("empty parameters with block body", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `() => { return 1; }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params: []Node{},
+					Body: []Stmt{
+						&ReturnStatement{Argument: intExpr(1)},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("cover grammar reinterpreted as parameters", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a, {b}, [c], d = 1, ...e) => b`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params: []Node{
+						idExpr("a"),
+						&ObjectPattern{
+							Properties: []Node{
+								&PatternProperty{Key: idExpr("b"), Value: idExpr("b"), Shorthand: true},
+							},
+						},
+						&ArrayPattern{Elements: []Node{idExpr("c")}},
+						&AssignmentPattern{Left: idExpr("d"), Right: intExpr(1)},
+						&RestElement{Argument: idExpr("e")},
+					},
+					Expression: idExpr("b"),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("object literal as concise body", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a,) => ({})`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params:     []Node{idExpr("a")},
+					Expression: &ExprParenthesized{Expression: &ExprObject{}},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("nested arrow functions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a => b => (c) => a`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params: []Node{idExpr("a")},
+					Expression: &ExprArrowFunction{
+						Params: []Node{idExpr("b")},
+						Expression: &ExprArrowFunction{
+							Params:     []Node{idExpr("c")},
+							Expression: idExpr("a"),
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("async arrow functions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `async a => a; async (a, b) => {}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params:     []Node{idExpr("a")},
+					Expression: idExpr("a"),
+					Async:      true,
+				},
+				&ExprArrowFunction{
+					Params: []Node{idExpr("a"), idExpr("b")},
+					Body:   []Stmt{},
+					Async:  true,
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("async as an identifier", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `async(a, b)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprCall{
+					Callee:    idExpr("async"),
+					Arguments: []Expr{idExpr("a"), idExpr("b")},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("arrow function as an argument", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `xs.map((x, i) => x * i)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprCall{
+					Callee: &ExprMemberAccess{Object: idExpr("xs"), Property: idExpr("map")},
+					Arguments: []Expr{
+						&ExprArrowFunction{
+							Params:     []Node{idExpr("x"), idExpr("i")},
+							Expression: binExpr(idExpr("x"), idExpr("i"), l.TStar),
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("yield in a function nested in a parameter", func(t *testing.T) {
+		src := `function* g() { (x = function* () { yield }) => 1 }`
+		if _, err := ParseFile(src, Options{}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestParenthesizedExpression(t *testing.T) {
+	t.Run("grouping changes precedence", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a + b) * c`
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(
+					&ExprParenthesized{Expression: binExpr(idExpr("a"), idExpr("b"), l.TPlus)},
+					idExpr("c"),
+					l.TStar,
+				),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("sequence expression", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a, b, c)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprParenthesized{
+					Expression: &ExprSequence{Expressions: []Expr{idExpr("a"), idExpr("b"), idExpr("c")}},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestArrowFunction_Rejected(t *testing.T) {
+	srcs := []string{
+		"a\n=> a",
+		"(a)\n=> a",
+		"()",
+		"(...a)",
+		"(a, ...b)",
+		"(a + 1) => a",
+		"((a)) => a",
+		"(...a, b) => a",
+		"function* g() { (x = yield) => 1 }",
+		"function* g() { (x, [y = yield 1]) => 1 }",
+		"function* g() { (...[x = yield]) => 1 }",
+		"async function f() { (x = await 1) => 1 }",
+		"async function f() { async (x = await 1) => 1 }",
+		"async (x = await 1) => 1",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
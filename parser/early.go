@@ -0,0 +1,740 @@
+package parser
+
+import (
+	"fmt"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// EarlyErrorCode identifies the early error rule of the specification that an
+// EarlyError violates. Codes are stable, so tools may match on them.
+type EarlyErrorCode string
+
+const (
+	// a name is lexically declared twice, or both lexically and var-declared
+	// in the same scope
+	ErrDuplicateDeclaration EarlyErrorCode = "duplicate-declaration"
+	// a name lexically declared in a function body is also a parameter
+	ErrParameterRedeclared EarlyErrorCode = "parameter-redeclared"
+	// a parameter name is repeated where parameters must be unique
+	ErrDuplicateParameter EarlyErrorCode = "duplicate-parameter"
+	// a 'return' statement outside of a function body
+	ErrReturnOutsideFunction EarlyErrorCode = "return-outside-function"
+	// a 'const' declaration without an initializer
+	ErrMissingInitializer EarlyErrorCode = "missing-initializer"
+	// 'delete' applied to an identifier in strict mode code
+	ErrDeleteIdentifier EarlyErrorCode = "delete-identifier"
+	// a legacy octal (or octal-like decimal) literal or escape sequence in
+	// strict mode code
+	ErrLegacyOctal EarlyErrorCode = "legacy-octal"
+	// 'eval' or 'arguments' bound or assigned to in strict mode code
+	ErrEvalOrArguments EarlyErrorCode = "eval-or-arguments"
+	// an identifier that is reserved in strict mode code, e.g. 'implements'
+	ErrStrictReservedWord EarlyErrorCode = "strict-reserved-word"
+	// a Use Strict Directive in a function with non-simple parameters
+	ErrIllegalUseStrict EarlyErrorCode = "illegal-use-strict"
+	// a 'with' statement in strict mode code
+	ErrStrictWith EarlyErrorCode = "strict-with"
+	// a label nested within a statement labelled with the same name
+	ErrDuplicateLabel EarlyErrorCode = "duplicate-label"
+	// a name exported twice by a module
+	ErrDuplicateExport EarlyErrorCode = "duplicate-export"
+	// a local name exported by a module that declares no such name
+	ErrUndeclaredExport EarlyErrorCode = "undeclared-export"
+	// 'new.target' outside of any non-arrow function
+	ErrNewTargetOutsideFunction EarlyErrorCode = "new-target-outside-function"
+	// a super property outside of any method, or a super call, which only
+	// class constructors may contain
+	ErrUnexpectedSuper EarlyErrorCode = "unexpected-super"
+)
+
+// strictReservedWords are the identifiers reserved in strict mode code only.
+var strictReservedWords = newSet(
+	"implements", "interface", "let", "package", "private", "protected", "public", "static", "yield",
+)
+
+// EarlyError is a violation of the static semantics of the language found
+// after parsing, reported at the span of the offending node.
+type EarlyError struct {
+	Code    EarlyErrorCode
+	Message string
+	Span    Span
+}
+
+func (e *EarlyError) Error() string {
+	return fmt.Sprintf("%s: %s [%s]", e.Span.Start, e.Message, e.Code)
+}
+
+// Validate applies the early error rules of the specification to a parsed
+// program. It does not stop at the first violation, but returns all of them.
+func Validate(program Node, options Options) []*EarlyError {
+	root, ok := program.(*NodeRoot)
+	if !ok {
+		return nil
+	}
+
+	stmts := make([]Stmt, 0, len(root.Children))
+	for _, child := range root.Children {
+		stmts = append(stmts, child)
+	}
+
+	v := &validator{
+		module:    options.SourceType == SourceTypeModule,
+		strict:    root.Strict,
+		newTarget: options.NewTarget,
+		super:     options.SuperProperty,
+		annexB:    options.AnnexB,
+	}
+	v.checkDeclarations(stmts, true, nil)
+	if v.module {
+		v.checkExports(stmts)
+	}
+	for _, stmt := range stmts {
+		v.walkStmt(stmt)
+	}
+	return v.errors
+}
+
+type validator struct {
+	errors []*EarlyError
+
+	module    bool // whether the program is parsed with the Module goal
+	strict    bool // whether the code being walked is strict mode code
+	function  bool // whether the code being walked is within a function body
+	newTarget bool // whether the code being walked is within a non-arrow function
+	super     bool // whether the code being walked is within a method
+	annexB    bool // whether the web compatibility semantics of Annex B apply
+
+	labels map[string]bool // the labels of the statements enclosing the code being walked
+}
+
+func (v *validator) report(code EarlyErrorCode, span Span, format string, args ...interface{}) {
+	v.errors = append(v.errors, &EarlyError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Span:    span,
+	})
+}
+
+// useStrictDirective returns the Use Strict Directive of the Directive
+// Prologue of stmts, if there is one.
+func useStrictDirective(stmts []Stmt) *ExprLiteral[string] {
+	for _, stmt := range stmts {
+		directive := directiveOf(stmt)
+		if directive == nil {
+			return nil
+		}
+		if isUseStrict(directive) {
+			return directive
+		}
+	}
+	return nil
+}
+
+// isLegacyOctalLike reports whether the source text of a numeric literal is a
+// LegacyOctalIntegerLiteral (e.g. 017) or a NonOctalDecimalIntegerLiteral
+// (e.g. 019), which are both forbidden in strict mode code.
+func isLegacyOctalLike(lexeme string) bool {
+	return len(lexeme) > 1 && lexeme[0] == '0' && lexeme[1] >= '0' && lexeme[1] <= '9'
+}
+
+// hasLegacyOctalEscape reports whether the source text of a string literal
+// contains a LegacyOctalEscapeSequence (e.g. \01) or a
+// NonOctalDecimalEscapeSequence (\8 or \9), which are both forbidden in
+// strict mode code. \0 alone is the null character escape.
+func hasLegacyOctalEscape(lexeme string) bool {
+	for i := 0; i < len(lexeme)-1; i++ {
+		if lexeme[i] != '\\' {
+			continue
+		}
+		i++ // skip the escaped character
+		switch ch := lexeme[i]; {
+		case ch >= '1' && ch <= '9':
+			return true
+		case ch == '0' && i+1 < len(lexeme) && lexeme[i+1] >= '0' && lexeme[i+1] <= '9':
+			return true
+		}
+	}
+	return false
+}
+
+// checkStrictBinding reports 'eval' and 'arguments' being bound, or assigned
+// to, in strict mode code.
+func (v *validator) checkStrictBinding(name *ExprIdentifier) {
+	if v.strict && (name.Name == "eval" || name.Name == "arguments") {
+		v.report(ErrEvalOrArguments, name.Span, "unexpected '%s' in strict mode", name.Name)
+	}
+}
+
+// checkDeclarations applies the early errors of a statement list that depend
+// on the names it declares:
+//
+// - It is a Syntax Error if the LexicallyDeclaredNames of StatementList
+// contains any duplicate entries.
+//
+// - It is a Syntax Error if any element of the LexicallyDeclaredNames of
+// StatementList also occurs in the VarDeclaredNames of StatementList.
+//
+// - (FunctionBody) It is a Syntax Error if any element of the BoundNames of
+// FormalParameters also occurs in the LexicallyDeclaredNames of FunctionBody.
+//
+// topLevel is set for function and script bodies, where function declarations
+// are var-scoped, and params holds the bound names of the function parameters.
+//
+// Annex B allows duplicate entries in sloppy mode code when they are all bound
+// by plain function declarations.
+func (v *validator) checkDeclarations(stmts []Stmt, topLevel bool, params []*ExprIdentifier) {
+	lexical := make(map[string]*ExprIdentifier)
+	functions := plainFunctionNames(stmts)
+	for _, name := range v.lexicallyDeclaredNames(stmts, topLevel) {
+		if declared, ok := lexical[name.Name]; ok {
+			if v.annexB && !v.strict && functions[declared] && functions[name] {
+				continue
+			}
+			v.report(ErrDuplicateDeclaration, name.Span, "identifier '%s' has already been declared", name.Name)
+			continue
+		}
+		lexical[name.Name] = name
+	}
+
+	for _, name := range varDeclaredNames(stmts, topLevel && !v.module) {
+		if declared, ok := lexical[name.Name]; ok {
+			v.report(ErrDuplicateDeclaration, laterOf(declared, name).Span, "identifier '%s' has already been declared", name.Name)
+		}
+	}
+
+	for _, param := range params {
+		if declared, ok := lexical[param.Name]; ok {
+			v.report(ErrParameterRedeclared, declared.Span, "identifier '%s' has already been declared as a parameter", param.Name)
+		}
+	}
+}
+
+// checkExports applies the early errors of a module that depend on the names
+// it exports:
+//
+// - It is a Syntax Error if the ExportedNames of ModuleItemList contains any
+// duplicate entries.
+//
+// - It is a Syntax Error if any element of the ExportedBindings of
+// ModuleItemList does not also occur in either the VarDeclaredNames of
+// ModuleItemList, or the LexicallyDeclaredNames of ModuleItemList.
+//
+// Exported names written as string literals are compared by their source
+// text, so escape sequences are not resolved.
+func (v *validator) checkExports(stmts []Stmt) {
+	declared := make(map[string]bool)
+	for _, name := range v.lexicallyDeclaredNames(stmts, true) {
+		declared[name.Name] = true
+	}
+	for _, name := range varDeclaredNames(stmts, false) {
+		declared[name.Name] = true
+	}
+
+	exported := make(map[string]bool)
+	export := func(name string, span Span) {
+		if exported[name] {
+			v.report(ErrDuplicateExport, span, "duplicate export of '%s'", name)
+		}
+		exported[name] = true
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				declaration := []Stmt{stmt.Declaration}
+				for _, name := range append(v.lexicallyDeclaredNames(declaration, true), varDeclaredNames(declaration, false)...) {
+					export(name.Name, name.Span)
+				}
+			}
+			for _, specifier := range stmt.Specifiers {
+				export(moduleExportName(specifier.Exported))
+				if local, ok := specifier.Local.(*ExprIdentifier); ok && stmt.Source == nil && !declared[local.Name] {
+					v.report(ErrUndeclaredExport, local.Span, "export '%s' is not defined in module", local.Name)
+				}
+			}
+		case *ExportDefaultDeclaration:
+			export("default", stmt.Default)
+		case *ExportAllDeclaration:
+			if stmt.Exported != nil {
+				export(moduleExportName(stmt.Exported))
+			}
+		}
+	}
+}
+
+// moduleExportName returns the name of a ModuleExportName, either an
+// IdentifierName or a StringLiteral, and its span.
+func moduleExportName(expr Expr) (string, Span) {
+	if literal, ok := expr.(*ExprLiteral[string]); ok {
+		lexeme := literal.Token.Lexeme
+		return lexeme[1 : len(lexeme)-1], tokenSpan(literal.Token)
+	}
+	identifier := expr.(*ExprIdentifier)
+	return identifier.Name, identifier.Span
+}
+
+// plainFunctionNames returns the names bound by the function declarations of
+// stmts that are neither generators nor async, labelled or not.
+func plainFunctionNames(stmts []Stmt) map[*ExprIdentifier]bool {
+	names := make(map[*ExprIdentifier]bool)
+	for _, stmt := range stmts {
+		for labelled, ok := stmt.(*LabelledStatement); ok; labelled, ok = stmt.(*LabelledStatement) {
+			stmt = labelled.Body
+		}
+		if fn, ok := stmt.(*FunctionDeclarationStmt); ok && !fn.Generator && !fn.Async && fn.BindingIdentifier != nil {
+			names[fn.BindingIdentifier] = true
+		}
+	}
+	return names
+}
+
+// laterOf returns the identifier that appears last in the source text.
+func laterOf(a, b *ExprIdentifier) *ExprIdentifier {
+	if a.Span.Start.Line > b.Span.Start.Line ||
+		(a.Span.Start.Line == b.Span.Start.Line && a.Span.Start.Column > b.Span.Start.Column) {
+		return a
+	}
+	return b
+}
+
+// lexicallyDeclaredNames returns the names declared by let, const, imports and,
+// outside of function and script bodies, by function declarations, in order.
+func (v *validator) lexicallyDeclaredNames(stmts []Stmt, topLevel bool) []*ExprIdentifier {
+	var names []*ExprIdentifier
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *VariableStatement:
+			if stmt.Kind.Type != l.TVar {
+				names = append(names, declarationNames(stmt)...)
+			}
+		case *FunctionDeclarationStmt:
+			// Module code declares its top-level functions lexically
+			if (!topLevel || v.module) && stmt.BindingIdentifier != nil {
+				names = append(names, stmt.BindingIdentifier)
+			}
+		case *ImportDeclaration:
+			for _, specifier := range stmt.Specifiers {
+				names = append(names, specifier.Local)
+			}
+		case *ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				names = append(names, v.lexicallyDeclaredNames([]Stmt{stmt.Declaration}, topLevel)...)
+			}
+		case *ExportDefaultDeclaration:
+			if fn, ok := stmt.Declaration.(*FunctionDeclarationStmt); ok && fn.BindingIdentifier != nil {
+				names = append(names, fn.BindingIdentifier)
+			}
+		case *LabelledStatement:
+			names = append(names, v.lexicallyDeclaredNames([]Stmt{stmt.Body}, topLevel)...)
+		}
+	}
+	return names
+}
+
+// varDeclaredNames returns the names declared by var statements within stmts,
+// including those of nested blocks but not of nested functions, in order. When
+// hoistFunctions is set, top-level function declarations are included too.
+func varDeclaredNames(stmts []Stmt, hoistFunctions bool) []*ExprIdentifier {
+	var names []*ExprIdentifier
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *VariableStatement:
+			if stmt.Kind.Type == l.TVar {
+				names = append(names, declarationNames(stmt)...)
+			}
+		case *FunctionDeclarationStmt:
+			if hoistFunctions && stmt.BindingIdentifier != nil {
+				names = append(names, stmt.BindingIdentifier)
+			}
+		case *BlockStatement:
+			names = append(names, varDeclaredNames(stmt.Stmts, false)...)
+		case *IfStatement:
+			names = append(names, varDeclaredNames([]Stmt{stmt.ThenStmt}, false)...)
+			if stmt.ElseStmt != nil {
+				names = append(names, varDeclaredNames([]Stmt{stmt.ElseStmt}, false)...)
+			}
+		case *ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				names = append(names, varDeclaredNames([]Stmt{stmt.Declaration}, hoistFunctions)...)
+			}
+		case *LabelledStatement:
+			names = append(names, varDeclaredNames([]Stmt{stmt.Body}, hoistFunctions)...)
+		case *WithStatement:
+			names = append(names, varDeclaredNames([]Stmt{stmt.Body}, false)...)
+		}
+	}
+	return names
+}
+
+// declarationNames returns the BoundNames of the declarations of stmt.
+func declarationNames(stmt *VariableStatement) []*ExprIdentifier {
+	var names []*ExprIdentifier
+	for _, decl := range stmt.Declarations {
+		if decl.Identifier != nil {
+			names = append(names, decl.Identifier)
+		} else {
+			names = append(names, boundNames(decl.Pattern)...)
+		}
+	}
+	return names
+}
+
+// boundNames returns the identifiers bound by a binding target, in order.
+func boundNames(node Node) []*ExprIdentifier {
+	switch node := node.(type) {
+	case *ExprIdentifier:
+		return []*ExprIdentifier{node}
+	case *ObjectPattern:
+		var names []*ExprIdentifier
+		for _, property := range node.Properties {
+			names = append(names, boundNames(property)...)
+		}
+		return names
+	case *PatternProperty:
+		return boundNames(node.Value)
+	case *ArrayPattern:
+		var names []*ExprIdentifier
+		for _, element := range node.Elements {
+			if element != nil {
+				names = append(names, boundNames(element)...)
+			}
+		}
+		return names
+	case *AssignmentPattern:
+		return boundNames(node.Left)
+	case *RestElement:
+		return boundNames(node.Argument)
+	}
+	return nil
+}
+
+// isSimpleParameterList reports whether params are only identifiers, without
+// patterns, initializers or a rest parameter.
+func isSimpleParameterList(params []Node) bool {
+	for _, param := range params {
+		if _, ok := param.(*ExprIdentifier); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// functionParts are the parts of a function that are validated alike, whatever
+// its syntactic form.
+type functionParts struct {
+	name    *ExprIdentifier // nil on anonymous functions
+	params  []Node
+	body    []Stmt
+	concise Expr // the ExpressionBody of arrow functions, which have no body
+	strict  bool // whether the function is strict mode code
+	unique  bool // UniqueFormalParameters: arrow functions and methods
+	arrow   bool // arrow functions see the new.target and super of the enclosing code
+	method  bool // methods, getters and setters, whose code may refer to super
+}
+
+// checkFunction validates a function and its body. The strictness of the
+// function applies to its name and parameters as well, even when it comes
+// from the directive prologue of its body.
+//
+// Parameter names of UniqueFormalParameters may never repeat. Otherwise, they
+// may not repeat in strict mode code or when the parameter list is not simple.
+func (v *validator) checkFunction(fn functionParts) {
+	prevStrict, prevFunction, prevNewTarget, prevSuper, prevLabels := v.strict, v.function, v.newTarget, v.super, v.labels
+	v.strict = v.strict || fn.strict
+	v.function = true
+	v.newTarget = v.newTarget || !fn.arrow
+	v.super = fn.method || fn.arrow && v.super
+	v.labels = nil // labels do not cross function boundaries
+	defer func() {
+		v.strict, v.function, v.newTarget, v.super, v.labels = prevStrict, prevFunction, prevNewTarget, prevSuper, prevLabels
+	}()
+
+	// It is a Syntax Error if FunctionBodyContainsUseStrict of FunctionBody is
+	// true and IsSimpleParameterList of FormalParameters is false.
+	if directive := useStrictDirective(fn.body); directive != nil && !isSimpleParameterList(fn.params) {
+		v.report(ErrIllegalUseStrict, tokenSpan(directive.Token), "illegal 'use strict' directive in function with non-simple parameter list")
+	}
+	if fn.name != nil {
+		v.checkStrictBinding(fn.name)
+		v.walkExpr(fn.name)
+	}
+
+	params, body, concise := fn.params, fn.body, fn.concise
+	var names []*ExprIdentifier
+	for _, param := range params {
+		names = append(names, boundNames(param)...)
+	}
+	for _, name := range names {
+		v.checkStrictBinding(name)
+	}
+	if fn.unique || v.strict || !isSimpleParameterList(params) {
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if seen[name.Name] {
+				v.report(ErrDuplicateParameter, name.Span, "duplicate parameter name '%s'", name.Name)
+			}
+			seen[name.Name] = true
+		}
+	}
+
+	for _, param := range params {
+		v.walkExpr(param)
+	}
+	v.checkDeclarations(body, true, names)
+	for _, stmt := range body {
+		v.walkStmt(stmt)
+	}
+	if concise != nil {
+		v.walkExpr(concise)
+	}
+}
+
+func (v *validator) walkStmt(stmt Node) {
+	switch stmt := stmt.(type) {
+	case *ExpressionStatement:
+		v.walkExpr(stmt.Expression)
+	case *VariableStatement:
+		for _, decl := range stmt.Declarations {
+			if stmt.Kind.Type == l.TConst && decl.Init == nil && decl.Identifier != nil {
+				v.report(ErrMissingInitializer, decl.Identifier.Span, "missing initializer in const declaration '%s'", decl.Identifier.Name)
+			}
+			for _, name := range declarationNames(&VariableStatement{Declarations: []*VariableDeclaration{decl}}) {
+				v.checkStrictBinding(name)
+			}
+			if decl.Identifier != nil {
+				v.walkExpr(decl.Identifier)
+			} else {
+				v.walkExpr(decl.Pattern)
+			}
+			if decl.Init != nil {
+				v.walkExpr(decl.Init)
+			}
+		}
+	case *ReturnStatement:
+		if !v.function {
+			v.report(ErrReturnOutsideFunction, stmt.Span, "illegal return statement outside of a function")
+		}
+		if stmt.Argument != nil {
+			v.walkExpr(stmt.Argument)
+		}
+	case *IfStatement:
+		v.walkExpr(stmt.Condition)
+		v.walkStmt(stmt.ThenStmt)
+		if stmt.ElseStmt != nil {
+			v.walkStmt(stmt.ElseStmt)
+		}
+	case *WithStatement:
+		if v.strict {
+			v.report(ErrStrictWith, stmt.Span, "strict mode code may not include a with statement")
+		}
+		v.walkExpr(stmt.Object)
+		v.walkStmt(stmt.Body)
+	case *LabelledStatement:
+		// It is a Syntax Error if any source text is matched by this production
+		// within the LabelledItem of a statement labelled with the same name.
+		name := stmt.Label.Name
+		if v.labels[name] {
+			v.report(ErrDuplicateLabel, stmt.Label.Span, "label '%s' has already been declared", name)
+		}
+		v.walkExpr(stmt.Label)
+		if v.labels == nil {
+			v.labels = make(map[string]bool)
+		}
+		enclosing := v.labels[name]
+		v.labels[name] = true
+		v.walkStmt(stmt.Body)
+		v.labels[name] = enclosing
+	case *BlockStatement:
+		v.checkDeclarations(stmt.Stmts, false, nil)
+		for _, stmt := range stmt.Stmts {
+			v.walkStmt(stmt)
+		}
+	case *FunctionDeclarationStmt:
+		v.checkFunction(functionParts{
+			name:   stmt.BindingIdentifier,
+			params: stmt.Params,
+			body:   stmt.Body,
+			strict: stmt.Strict,
+		})
+	case *ImportDeclaration:
+		for _, specifier := range stmt.Specifiers {
+			v.checkStrictBinding(specifier.Local)
+			v.walkExpr(specifier.Local)
+		}
+	case *ExportNamedDeclaration:
+		if stmt.Declaration != nil {
+			v.walkStmt(stmt.Declaration)
+		}
+	case *ExportDefaultDeclaration:
+		if _, ok := stmt.Declaration.(*FunctionDeclarationStmt); ok {
+			v.walkStmt(stmt.Declaration)
+		} else {
+			v.walkExpr(stmt.Declaration)
+		}
+	}
+}
+
+func (v *validator) walkExpr(expr Node) {
+	switch expr := expr.(type) {
+	case *ExprIdentifier:
+		if _, reserved := strictReservedWords[expr.Name]; reserved && v.strict {
+			v.report(ErrStrictReservedWord, expr.Span, "unexpected strict mode reserved word '%s'", expr.Name)
+		}
+	case *ExprLiteral[float64]:
+		if v.strict && isLegacyOctalLike(expr.Token.Lexeme) {
+			v.report(ErrLegacyOctal, tokenSpan(expr.Token), "octal literals are not allowed in strict mode")
+		}
+	case *ExprLiteral[string]:
+		switch expr.Token.Type {
+		case l.TStringLiteral_SingleQuote, l.TStringLiteral_DoubleQuote:
+			if v.strict && hasLegacyOctalEscape(expr.Token.Lexeme) {
+				v.report(ErrLegacyOctal, tokenSpan(expr.Token), "octal escape sequences are not allowed in strict mode")
+			}
+		}
+	case *ExprUnaryOp:
+		// UnaryExpression : 'delete' UnaryExpression
+		// It is a Syntax Error if the UnaryExpression is contained in strict
+		// mode code and the derived UnaryExpression is an IdentifierReference,
+		// or a ParenthesizedExpression that ultimately derives one.
+		operand, isIdentifier := Unparenthesized(expr.Operand).(*ExprIdentifier)
+		switch expr.Operator.Type {
+		case l.TDelete:
+			if v.strict && isIdentifier {
+				v.report(ErrDeleteIdentifier, expr.Span, "delete of an unqualified identifier in strict mode")
+			}
+		case l.TPlusPlus, l.TMinusMinus:
+			if isIdentifier {
+				v.checkStrictBinding(operand)
+			}
+		}
+		v.walkExpr(expr.Operand)
+	case *ExprBinaryOp:
+		v.walkExpr(expr.Left)
+		v.walkExpr(expr.Right)
+	case *ExprAssign:
+		for _, name := range boundNames(Unparenthesized(expr.Left)) {
+			v.checkStrictBinding(name)
+		}
+		v.walkExpr(expr.Left)
+		v.walkExpr(expr.Right)
+	case *ExprConditional:
+		v.walkExpr(expr.Test)
+		v.walkExpr(expr.Consequent)
+		v.walkExpr(expr.Alternate)
+	case *ExprNew:
+		v.walkExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			v.walkExpr(argument)
+		}
+	case *ExprCall:
+		// It is a Syntax Error if a SuperCall is not contained within the
+		// constructor of a derived class, which are not parsed.
+		if super, ok := expr.Callee.(*ExprLiteral[string]); ok && super.Token.Type == l.TSuper {
+			v.report(ErrUnexpectedSuper, tokenSpan(super.Token), "'super' keyword unexpected here")
+		}
+		v.walkExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			v.walkExpr(argument)
+		}
+	case *ExprMemberAccess:
+		// It is a Syntax Error if a SuperProperty is not contained within a
+		// method, other than through arrow functions.
+		if super, ok := expr.Object.(*ExprLiteral[string]); ok && super.Token.Type == l.TSuper && !v.super {
+			v.report(ErrUnexpectedSuper, tokenSpan(super.Token), "'super' keyword unexpected here")
+		}
+		v.walkExpr(expr.Object)
+		if expr.Computed {
+			v.walkExpr(expr.Property)
+		}
+	case *ExprChain:
+		v.walkExpr(expr.Expression)
+	case *ExprImportCall:
+		v.walkExpr(expr.Source)
+	case *ExprMetaProperty:
+		// It is a Syntax Error if new.target is not contained within a
+		// function, other than an arrow function, at any level of nesting.
+		if property, ok := expr.Property.(*ExprIdentifier); ok && property.Name == "target" && !v.newTarget {
+			v.report(ErrNewTargetOutsideFunction, expr.Span, "new.target expression is not allowed here")
+		}
+	case *SpreadElement:
+		v.walkExpr(expr.Argument)
+	case *ExprYield:
+		if expr.Argument != nil {
+			v.walkExpr(expr.Argument)
+		}
+	case *ExprAwait:
+		v.walkExpr(expr.Argument)
+	case *ExprParenthesized:
+		v.walkExpr(expr.Expression)
+	case *ExprSequence:
+		for _, expr := range expr.Expressions {
+			v.walkExpr(expr)
+		}
+	case *ExprArray:
+		for _, element := range expr.Elements {
+			v.walkExpr(element)
+		}
+	case *ExprObject:
+		for _, property := range expr.Properties {
+			if property.Computed {
+				v.walkExpr(property.Key)
+			}
+			if fn, ok := property.Value.(*ExprFunction); ok && (property.Method || property.Kind != PropertyInit) {
+				// MethodDefinition : ClassElementName '(' UniqueFormalParameters ')' '{' FunctionBody '}'
+				v.checkFunction(functionParts{
+					params: fn.Params,
+					body:   fn.Body,
+					strict: fn.Strict,
+					unique: true,
+					method: true,
+				})
+				continue
+			}
+			v.walkExpr(property.Value)
+		}
+	case *ExprTemplateLiteral:
+		for _, expr := range expr.Expressions {
+			v.walkExpr(expr)
+		}
+	case *ExprTaggedTemplate:
+		v.walkExpr(expr.Tag)
+		v.walkExpr(expr.Quasi)
+	case *ExprFunction:
+		v.checkFunction(functionParts{
+			name:   expr.BindingIdentifier,
+			params: expr.Params,
+			body:   expr.Body,
+			strict: expr.Strict,
+		})
+	case *ExprArrowFunction:
+		v.checkFunction(functionParts{
+			params:  expr.Params,
+			body:    expr.Body,
+			concise: expr.Expression,
+			strict:  expr.Strict,
+			unique:  true,
+			arrow:   true,
+		})
+	case *ObjectPattern:
+		for _, property := range expr.Properties {
+			v.walkExpr(property)
+		}
+	case *PatternProperty:
+		if expr.Computed {
+			v.walkExpr(expr.Key)
+		}
+		v.walkExpr(expr.Value)
+	case *ArrayPattern:
+		for _, element := range expr.Elements {
+			if element != nil {
+				v.walkExpr(element)
+			}
+		}
+	case *AssignmentPattern:
+		v.walkExpr(expr.Left)
+		v.walkExpr(expr.Right)
+	case *RestElement:
+		v.walkExpr(expr.Argument)
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+)
+
+func TestValidate(t *testing.T) {
+	tcs := []struct {
+		src    string
+		module bool
+		annexB bool
+		code   EarlyErrorCode
+		span   Span
+	}{
+		{src: "let a; let a;", code: ErrDuplicateDeclaration, span: Span{Position{1, 11}, Position{1, 12}}},
+		{src: "{ const a = 1; let [b, a] = c; }", code: ErrDuplicateDeclaration, span: Span{Position{1, 23}, Position{1, 24}}},
+		{src: "let a; { var a; }", code: ErrDuplicateDeclaration, span: Span{Position{1, 13}, Position{1, 14}}},
+		{src: "var a; let a;", code: ErrDuplicateDeclaration, span: Span{Position{1, 11}, Position{1, 12}}},
+		{src: "function f() {}\nlet f;", code: ErrDuplicateDeclaration, span: Span{Position{2, 4}, Position{2, 5}}},
+		{src: "{ function f() {} var f; }", code: ErrDuplicateDeclaration, span: Span{Position{1, 22}, Position{1, 23}}},
+		{src: "function f() {} function f() {}", module: true, code: ErrDuplicateDeclaration, span: Span{Position{1, 25}, Position{1, 26}}},
+		{src: "function f(a) { let a; }", code: ErrParameterRedeclared, span: Span{Position{1, 20}, Position{1, 21}}},
+		{src: "(a) => { const a = 1; }", code: ErrParameterRedeclared, span: Span{Position{1, 15}, Position{1, 16}}},
+		{src: "return 1;", code: ErrReturnOutsideFunction, span: Span{Position{1, 0}, Position{1, 9}}},
+		{src: "if (a) { return; }", code: ErrReturnOutsideFunction, span: Span{Position{1, 9}, Position{1, 16}}},
+		{src: "const a = 1, b;", code: ErrMissingInitializer, span: Span{Position{1, 13}, Position{1, 14}}},
+		{src: "'use strict'; function f(a, a) {}", code: ErrDuplicateParameter, span: Span{Position{1, 28}, Position{1, 29}}},
+		{src: "function f(a, a) { 'use strict'; }", code: ErrDuplicateParameter, span: Span{Position{1, 14}, Position{1, 15}}},
+		{src: "function f(a, [a]) {}", code: ErrDuplicateParameter, span: Span{Position{1, 15}, Position{1, 16}}},
+		{src: "(a, a) => 1", code: ErrDuplicateParameter, span: Span{Position{1, 4}, Position{1, 5}}},
+		{src: "o = { m(a, a) {} }", code: ErrDuplicateParameter, span: Span{Position{1, 11}, Position{1, 12}}},
+		{src: "function f(a, a) {}", module: true, code: ErrDuplicateParameter, span: Span{Position{1, 14}, Position{1, 15}}},
+		{src: "\"use strict\"; delete a;", code: ErrDeleteIdentifier, span: Span{Position{1, 14}, Position{1, 22}}},
+		{src: "function f() { 'use strict'; delete ((a)); }", code: ErrDeleteIdentifier, span: Span{Position{1, 29}, Position{1, 41}}},
+		{src: "delete a;", module: true, code: ErrDeleteIdentifier, span: Span{Position{1, 0}, Position{1, 8}}},
+		{src: "'use strict'; a = 017;", annexB: true, code: ErrLegacyOctal, span: Span{Position{1, 18}, Position{1, 21}}},
+		{src: "'use strict'; a = 08;", annexB: true, code: ErrLegacyOctal, span: Span{Position{1, 18}, Position{1, 20}}},
+		{src: "function f() { '\\01'; 'use strict'; }", code: ErrLegacyOctal, span: Span{Position{1, 15}, Position{1, 20}}},
+		{src: "a = '\\8';", module: true, code: ErrLegacyOctal, span: Span{Position{1, 4}, Position{1, 8}}},
+		{src: "'use strict'; var eval;", code: ErrEvalOrArguments, span: Span{Position{1, 18}, Position{1, 22}}},
+		{src: "'use strict'; let [a, arguments] = b;", code: ErrEvalOrArguments, span: Span{Position{1, 22}, Position{1, 31}}},
+		{src: "function eval() { 'use strict'; }", code: ErrEvalOrArguments, span: Span{Position{1, 9}, Position{1, 13}}},
+		{src: "function f(arguments) { 'use strict'; }", code: ErrEvalOrArguments, span: Span{Position{1, 11}, Position{1, 20}}},
+		{src: "eval = 1;", module: true, code: ErrEvalOrArguments, span: Span{Position{1, 0}, Position{1, 4}}},
+		{src: "'use strict'; ({ a: eval } = b);", code: ErrEvalOrArguments, span: Span{Position{1, 20}, Position{1, 24}}},
+		{src: "'use strict'; arguments++;", code: ErrEvalOrArguments, span: Span{Position{1, 14}, Position{1, 23}}},
+		{src: "'use strict'; var implements;", code: ErrStrictReservedWord, span: Span{Position{1, 18}, Position{1, 28}}},
+		{src: "function f() { 'use strict'; return yield; }", code: ErrStrictReservedWord, span: Span{Position{1, 36}, Position{1, 41}}},
+		{src: "a = (static) => 1;", module: true, code: ErrStrictReservedWord, span: Span{Position{1, 5}, Position{1, 11}}},
+		{src: "function f(a = 1) { 'use strict'; }", code: ErrIllegalUseStrict, span: Span{Position{1, 20}, Position{1, 32}}},
+		{src: "([a]) => { \"use strict\"; }", code: ErrIllegalUseStrict, span: Span{Position{1, 11}, Position{1, 23}}},
+		{src: "'use strict'; with (a) b;", code: ErrStrictWith, span: Span{Position{1, 14}, Position{1, 25}}},
+		{src: "with (a) b;", module: true, code: ErrStrictWith, span: Span{Position{1, 0}, Position{1, 11}}},
+		{src: "{ function f() {} function f() {} }", code: ErrDuplicateDeclaration, span: Span{Position{1, 27}, Position{1, 28}}},
+		{src: "'use strict'; { function f() {} function f() {} }", annexB: true, code: ErrDuplicateDeclaration, span: Span{Position{1, 41}, Position{1, 42}}},
+		{src: "{ function f() {} function* f() {} }", annexB: true, code: ErrDuplicateDeclaration, span: Span{Position{1, 28}, Position{1, 29}}},
+		{src: "{ function f() {} let f; }", annexB: true, code: ErrDuplicateDeclaration, span: Span{Position{1, 22}, Position{1, 23}}},
+		{src: "a: a: 1;", code: ErrDuplicateLabel, span: Span{Position{1, 3}, Position{1, 4}}},
+		{src: "a: { a: 1; }", code: ErrDuplicateLabel, span: Span{Position{1, 5}, Position{1, 6}}},
+		{src: "a: if (b) { c: { a: 1; } }", code: ErrDuplicateLabel, span: Span{Position{1, 17}, Position{1, 18}}},
+		{src: "let a, c; export {a as b, c as b};", module: true, code: ErrDuplicateExport, span: Span{Position{1, 31}, Position{1, 32}}},
+		{src: "export * as x from 'm'; let y; export {y as x};", module: true, code: ErrDuplicateExport, span: Span{Position{1, 44}, Position{1, 45}}},
+		{src: "export default 1; let z; export {z as default};", module: true, code: ErrDuplicateExport, span: Span{Position{1, 38}, Position{1, 45}}},
+		{src: "let z; export {z as default}; export default 1;", module: true, code: ErrDuplicateExport, span: Span{Position{1, 37}, Position{1, 44}}},
+		{src: "export function f() {} export var g; export {f as g};", module: true, code: ErrDuplicateExport, span: Span{Position{1, 50}, Position{1, 51}}},
+		{src: "export {undeclared};", module: true, code: ErrUndeclaredExport, span: Span{Position{1, 8}, Position{1, 18}}},
+		{src: "new.target;", code: ErrNewTargetOutsideFunction, span: Span{Position{1, 0}, Position{1, 10}}},
+		{src: "a = () => new.target;", code: ErrNewTargetOutsideFunction, span: Span{Position{1, 10}, Position{1, 20}}},
+		{src: "super.a;", code: ErrUnexpectedSuper, span: Span{Position{1, 0}, Position{1, 5}}},
+		{src: "function f() { return super[a]; }", code: ErrUnexpectedSuper, span: Span{Position{1, 22}, Position{1, 27}}},
+		{src: "o = { a: () => super.b };", code: ErrUnexpectedSuper, span: Span{Position{1, 15}, Position{1, 20}}},
+		{src: "o = { m() { return function () { super.a; }; } };", code: ErrUnexpectedSuper, span: Span{Position{1, 33}, Position{1, 38}}},
+		{src: "o = { m() { super(); } };", code: ErrUnexpectedSuper, span: Span{Position{1, 12}, Position{1, 17}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			options := Options{AnnexB: tc.annexB}
+			if tc.module {
+				options.SourceType = SourceTypeModule
+			}
+			errs := Validate(ParseWithOptions(logger, tc.src, options), options)
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 early error, got %v", errs)
+			}
+			if errs[0].Code != tc.code || errs[0].Span != tc.span {
+				t.Errorf("expected %s at %s, got %s at %s", tc.code, tc.span, errs[0].Code, errs[0].Span)
+			}
+		})
+	}
+}
+
+func TestValidate_Accepted(t *testing.T) {
+	srcs := []string{
+		"var a; var a;",
+		"let a; { let a; }",
+		"function f() {} function f() {} var f;",
+		"function f(a) { var a; }",
+		"function f(a, a) {}",
+		"function f(a) { { let a; } }",
+		"function f() { return; }",
+		"() => { return 1; }",
+		"o = { m() { return 1; } }",
+		"delete a.b;",
+		"delete a;",
+		"function f() { delete a; } 'use strict';",
+		"(a) => 'use strict'",
+		"function f(a, a) { ('use strict'); }",
+		"'use strict'; a = 0.5 + 0 + '\\0' + '\\\\1';",
+		"var eval, arguments, implements, yield; eval = 1;",
+		"'use strict'; a.implements = eval.arguments; a = { static: 1, eval };",
+		"'use strict'; function f(a = 1) {}",
+		"a: b; a: c;",
+		"a: { b: c; } b: d;",
+		"a: f = function () { a: b; };",
+		"function f() { return () => new.target; }",
+		"o = { m() { return new.target; } }",
+		"o = { m() { return super.a; }, get b() { return () => super[c]; } }",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			if errs := Validate(Parse(logger, src), Options{}); len(errs) > 0 {
+				t.Errorf("unexpected early errors: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidate_AcceptedModule(t *testing.T) {
+	srcs := []string{
+		"export {u} from 'm'; export {u as v} from 'm'; export * from 'm'; export * from 'n';",
+		"var a; let b; function c() {} export {a, b as 'b c', c as default};",
+		"import d from 'm'; export {d}; export * as e from 'm';",
+		"export default function f() {} export {f as g};",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			options := Options{SourceType: SourceTypeModule}
+			if errs := Validate(ParseWithOptions(logger, src, options), options); len(errs) > 0 {
+				t.Errorf("unexpected early errors: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidate_AnnexB(t *testing.T) {
+	srcs := []string{
+		"a = 017 + 08 + '\\01' + '\\0';",
+		"'use\\x20strict'; a = 017;",
+		"a = 0; 'use strict'; a = 017;",
+		"{ function f() {} function f() {} }",
+		"{ a: function f() {} function f() {} }",
+		"with (a) { var b; }",
+		"function f() { with (a) b; } 'use strict';",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			options := Options{AnnexB: true}
+			if errs := Validate(ParseWithOptions(logger, src, options), options); len(errs) > 0 {
+				t.Errorf("unexpected early errors: %v", errs)
+			}
+		})
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	t.Run("directive prologues", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function f() { "a"; 'use strict'; } function g() { h(); 'use strict'; } (() => { 'use strict' })`
+		got := Parse(logger, src).(*NodeRoot)
+		if got.Strict {
+			t.Errorf("expected the script not to be strict")
+		}
+		if f := got.Children[0].(*FunctionDeclarationStmt); !f.Strict {
+			t.Errorf("expected f to be strict")
+		}
+		if g := got.Children[1].(*FunctionDeclarationStmt); g.Strict {
+			t.Errorf("expected g not to be strict")
+		}
+		arrow := got.Children[2].(*ExpressionStatement).Expression.(*ExprParenthesized).Expression
+		if !arrow.(*ExprArrowFunction).Strict {
+			t.Errorf("expected the arrow function to be strict")
+		}
+	})
+
+	t.Run("strictness is inherited", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `'use strict'; function f() { return function () {}; }`
+		got := Parse(logger, src).(*NodeRoot)
+		if !got.Strict {
+			t.Errorf("expected the script to be strict")
+		}
+		f := got.Children[1].(*FunctionDeclarationStmt)
+		inner := f.Body[0].(*ReturnStatement).Argument.(*ExprFunction)
+		if !f.Strict || !inner.Strict {
+			t.Errorf("expected f and the inner function to be strict")
+		}
+	})
+
+	t.Run("modules are strict", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		got := ParseWithOptions(logger, `function f() {}`, Options{SourceType: SourceTypeModule}).(*NodeRoot)
+		if !got.Strict || !got.Children[0].(*FunctionDeclarationStmt).Strict {
+			t.Errorf("expected the module and f to be strict")
+		}
+	})
+}
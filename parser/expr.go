@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -20,7 +22,8 @@ const (
 const EIdentifier ExprType = "EIdentifier"
 
 type ExprIdentifier struct {
-	name string
+	Name string
+	Span Span
 }
 
 func (e *ExprIdentifier) Type() ExprType {
@@ -28,7 +31,7 @@ func (e *ExprIdentifier) Type() ExprType {
 }
 
 func (e *ExprIdentifier) S() string {
-	return e.name
+	return e.Name
 }
 
 // ////////////////////////
@@ -37,7 +40,7 @@ func (e *ExprIdentifier) S() string {
 const EPrivateIdentifierReference ExprType = "EPrivateIdentifierReference"
 
 type ExprPrivateIdentifier struct {
-	name string
+	Name string
 }
 
 func (e *ExprPrivateIdentifier) Type() ExprType {
@@ -45,7 +48,7 @@ func (e *ExprPrivateIdentifier) Type() ExprType {
 }
 
 func (e *ExprPrivateIdentifier) S() string {
-	return fmt.Sprintf("#%s", e.name)
+	return fmt.Sprintf("#%s", e.Name)
 }
 
 // ///////////////
@@ -66,11 +69,11 @@ type Literal interface {
 }
 
 type ExprLiteral[Value Literal] struct {
-	tok l.Token
+	Token l.Token
 }
 
 func (e *ExprLiteral[Value]) Source() string {
-	return fmt.Sprintf("%v", e.tok.Literal)
+	return fmt.Sprintf("%v", e.Token.Literal)
 }
 
 func (e *ExprLiteral[Value]) Type() ExprType {
@@ -78,7 +81,7 @@ func (e *ExprLiteral[Value]) Type() ExprType {
 }
 
 func (e *ExprLiteral[Value]) S() string {
-	return fmt.Sprintf("%v", e.tok.Literal)
+	return fmt.Sprintf("%v", e.Token.Literal)
 }
 
 func MakeLiteralExpr(typ l.TokenType) *ExprLiteral[string] {
@@ -125,8 +128,10 @@ var UnaryOperators = []l.TokenType{
 }
 
 type ExprUnaryOp struct {
-	operand  Expr
-	operator l.Token
+	Operand  Expr
+	Operator l.Token
+	Postfix  bool // a++, rather than ++a
+	Span     Span
 }
 
 func (e *ExprUnaryOp) Name() string {
@@ -138,7 +143,7 @@ func (e *ExprUnaryOp) Type() ExprType {
 }
 
 func (e *ExprUnaryOp) S() string {
-	return fmt.Sprintf("(%s %s)", e.operator.Type.S(), e.operand.S())
+	return fmt.Sprintf("(%s %s)", e.Operator.Type.S(), e.Operand.S())
 }
 
 // Parser
@@ -154,9 +159,9 @@ var UpdateOperators = []l.TokenType{
 const EBinaryOp ExprType = "ExprBinaryOp"
 
 type ExprBinaryOp struct {
-	left     Expr
-	right    Expr
-	operator l.Token
+	Left     Expr
+	Right    Expr
+	Operator l.Token
 }
 
 func (e *ExprBinaryOp) Type() ExprType {
@@ -164,7 +169,7 @@ func (e *ExprBinaryOp) Type() ExprType {
 }
 
 func (e *ExprBinaryOp) S() string {
-	return fmt.Sprintf("(%s %s %s)", e.operator.Type.S(), e.left.S(), e.right.S())
+	return fmt.Sprintf("(%s %s %s)", e.Operator.Type.S(), e.Left.S(), e.Right.S())
 }
 
 // //////////
@@ -173,8 +178,8 @@ func (e *ExprBinaryOp) S() string {
 const ENew ExprType = "ExprNew"
 
 type ExprNew struct {
-	callee    Expr
-	arguments []Expr
+	Callee    Expr
+	Arguments []Expr
 }
 
 func (e *ExprNew) Type() ExprType {
@@ -182,7 +187,7 @@ func (e *ExprNew) Type() ExprType {
 }
 
 func (e *ExprNew) S() string {
-	return fmt.Sprintf("(new %s)", e.callee.S())
+	return fmt.Sprintf("(new %s)", e.Callee.S())
 }
 
 // ///////////////////
@@ -191,9 +196,10 @@ func (e *ExprNew) S() string {
 const EMemberAccess ExprType = "ExprMemberAccess"
 
 type ExprMemberAccess struct {
-	object   Expr
-	property Expr
-	optional bool
+	Object   Expr
+	Property Expr
+	Computed bool // a[b], rather than a.b
+	Optional bool
 }
 
 func (e *ExprMemberAccess) Type() ExprType {
@@ -205,12 +211,12 @@ func (e *ExprMemberAccess) S() string {
 		panic("invalid object: nil")
 	}
 	var sarg string
-	if e.optional {
+	if e.Optional {
 		sarg = "get?"
 	} else {
 		sarg = "get"
 	}
-	return fmt.Sprintf("(%s '%s %s)", sarg, e.property.S(), e.object.S())
+	return fmt.Sprintf("(%s '%s %s)", sarg, e.Property.S(), e.Object.S())
 }
 
 // ///////////////////
@@ -219,8 +225,9 @@ func (e *ExprMemberAccess) S() string {
 const EMetaProperty ExprType = "ExprMetaProperty"
 
 type ExprMetaProperty struct {
-	meta     Expr
-	property Expr
+	Meta     Expr
+	Property Expr
+	Span     Span
 }
 
 func (e *ExprMetaProperty) Type() ExprType {
@@ -231,7 +238,7 @@ func (e *ExprMetaProperty) S() string {
 	if e == nil {
 		panic("invalid object: nil")
 	}
-	return fmt.Sprintf("(getmeta %s %s)", e.meta.S(), e.property.S())
+	return fmt.Sprintf("(getmeta %s %s)", e.Meta.S(), e.Property.S())
 }
 
 // ///////////
@@ -240,9 +247,9 @@ func (e *ExprMetaProperty) S() string {
 const ECall ExprType = "ExprCall"
 
 type ExprCall struct {
-	callee    Expr
-	arguments []Expr
-	optional  bool
+	Callee    Expr
+	Arguments []Expr
+	Optional  bool
 }
 
 func (e *ExprCall) Type() ExprType {
@@ -254,14 +261,14 @@ func (e *ExprCall) S() string {
 		panic("invalid object: nil")
 	}
 	var args strings.Builder
-	for i, arg := range e.arguments {
+	for i, arg := range e.Arguments {
 		args.WriteString(arg.S())
-		if i < len(e.arguments)-1 {
+		if i < len(e.Arguments)-1 {
 			args.WriteString(" ")
 		}
 	}
-	callee := e.callee.S()
-	if e.optional {
+	callee := e.Callee.S()
+	if e.Optional {
 		callee += "?"
 	}
 	return fmt.Sprintf("(%s %s)", callee, args.String())
@@ -273,7 +280,7 @@ func (e *ExprCall) S() string {
 const NSpreadElement ExprType = "SpreadElement"
 
 type SpreadElement struct {
-	argument Expr
+	Argument Expr
 }
 
 func (e *SpreadElement) Type() ExprType {
@@ -284,7 +291,26 @@ func (e *SpreadElement) S() string {
 	if e == nil {
 		panic("invalid object: nil")
 	}
-	return fmt.Sprintf("(... %s)", e.argument.S())
+	return fmt.Sprintf("(... %s)", e.Argument.S())
+}
+
+// ////////////
+// ExprChain //
+// ////////////
+const EChain ExprType = "ExprChain"
+
+// ExprChain wraps an OptionalExpression, marking how far the evaluation
+// short-circuits when an optional link finds a nullish object.
+type ExprChain struct {
+	Expression Expr
+}
+
+func (e *ExprChain) Type() ExprType {
+	return EChain
+}
+
+func (e *ExprChain) S() string {
+	return fmt.Sprintf("(chain %s)", e.Expression.S())
 }
 
 // /////////////////
@@ -293,7 +319,7 @@ func (e *SpreadElement) S() string {
 const EImportCall ExprType = "ExprImportCall"
 
 type ExprImportCall struct {
-	source Expr
+	Source Expr
 }
 
 func (e *ExprImportCall) Type() ExprType {
@@ -304,7 +330,7 @@ func (e *ExprImportCall) S() string {
 	if e == nil {
 		panic("invalid object: nil")
 	}
-	return fmt.Sprintf("(import %s)", e.source.S())
+	return fmt.Sprintf("(import %s)", e.Source.S())
 }
 
 // /////////////
@@ -313,9 +339,9 @@ func (e *ExprImportCall) S() string {
 const EAssign ExprType = "ExprAssign"
 
 type ExprAssign struct {
-	operator l.Token
-	left     Node
-	right    Node
+	Operator l.Token
+	Left     Node
+	Right    Node
 }
 
 func (e *ExprAssign) Type() ExprType {
@@ -326,7 +352,7 @@ func (e *ExprAssign) S() string {
 	if e == nil {
 		panic("invalid object: nil")
 	}
-	return fmt.Sprintf("(%s %s <- %s)", e.operator.Type.S(), e.left.S(), e.right.S())
+	return fmt.Sprintf("(%s %s <- %s)", e.Operator.Type.S(), e.Left.S(), e.Right.S())
 }
 
 // ///////////////
@@ -339,6 +365,9 @@ type ExprFunction struct {
 	BindingIdentifier *ExprIdentifier
 	Params            []Node
 	Body              []Stmt
+	Generator         bool
+	Async             bool
+	Strict            bool // whether the function is strict mode code
 }
 
 func (e *ExprFunction) Type() ExprType {
@@ -362,25 +391,107 @@ func (e *ExprFunction) S() string {
 		}
 	}
 
+	fn := functionKind("λ", e.Generator, e.Async)
 	if e.BindingIdentifier == nil {
-		return fmt.Sprintf("(λ (%s) %s)", stmts.String(), params.String())
+		return fmt.Sprintf("(%s (%s) %s)", fn, stmts.String(), params.String())
 	} else {
-		return fmt.Sprintf("(λ %s (%s) %s)", e.BindingIdentifier.S(), stmts.String(), params.String())
+		return fmt.Sprintf("(%s %s (%s) %s)", fn, e.BindingIdentifier.S(), stmts.String(), params.String())
 	}
 }
 
+// ////////////
+// ExprYield //
+// ////////////
+const EYield ExprType = "ExprYield"
+
+type ExprYield struct {
+	Argument Expr // nil on a bare 'yield'
+	Delegate bool // yield* AssignmentExpression
+}
+
+func (e *ExprYield) Type() ExprType {
+	return EYield
+}
+
+func (e *ExprYield) S() string {
+	op := "yield"
+	if e.Delegate {
+		op = "yield*"
+	}
+	if e.Argument == nil {
+		return fmt.Sprintf("(%s)", op)
+	}
+	return fmt.Sprintf("(%s %s)", op, e.Argument.S())
+}
+
+// ////////////
+// ExprAwait //
+// ////////////
+const EAwait ExprType = "ExprAwait"
+
+type ExprAwait struct {
+	Argument Expr
+}
+
+func (e *ExprAwait) Type() ExprType {
+	return EAwait
+}
+
+func (e *ExprAwait) S() string {
+	return fmt.Sprintf("(await %s)", e.Argument.S())
+}
+
+// //////////////////
+// ExprConditional //
+// //////////////////
+const EConditional ExprType = "ExprConditional"
+
+type ExprConditional struct {
+	Test       Expr
+	Consequent Expr
+	Alternate  Expr
+}
+
+func (e *ExprConditional) Type() ExprType {
+	return EConditional
+}
+
+func (e *ExprConditional) S() string {
+	return fmt.Sprintf("(? %s %s %s)", e.Test.S(), e.Consequent.S(), e.Alternate.S())
+}
+
 // //////////////////////////
 // Expressions productions //
 // //////////////////////////
+// Expression[In, Yield, Await] :
+// | AssignmentExpression[?In, ?Yield, ?Await]
+// | Expression[?In, ?Yield, ?Await] ',' AssignmentExpression[?In, ?Yield, ?Await]
 func (p *Parser) parseExpr() (Expr, error) {
-	return p.parseAssignExpr()
+	expr, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TComma {
+		return expr, nil
+	}
+
+	exprSeq := &ExprSequence{Expressions: []Expr{expr}}
+	for p.Peek().Type == l.TComma {
+		p.Next() // consume ','
+		expr, err := p.parseAssignExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprSeq.Expressions = append(exprSeq.Expressions, expr)
+	}
+	return exprSeq, nil
 }
 
 // AssignmentExpression :
 // | ConditionalExpression
-// | [+Yield] YieldExpression (TODO)
-// | ArrowFunction (TODO)
-// | AsyncArrowFunction (TODO)
+// | [+Yield] YieldExpression
+// | ArrowFunction
+// | AsyncArrowFunction
 // | LeftHandSideExpression '=' AssignmentExpression
 // | LeftHandSideExpression AssignmentOperator AssignmentExpression
 // | LeftHandSideExpression &&= AssignmentExpression
@@ -403,6 +514,68 @@ var assignmentOperators = []l.TokenType{
 	l.TUnsignedRightShiftAssign,
 }
 
+var (
+	errInvalidAssignmentTarget = errors.New("invalid assignment target")
+	errInvalidCoverGrammar     = errors.New("invalid object literal")
+)
+
+// checkAssignmentTarget reports an early error when expr cannot be the operand
+// of an assignment or update operator, i.e. when its AssignmentTargetType is
+// not simple. Object and array literals are handled by reinterpretAsPattern,
+// as they are only valid targets of '='.
+func checkAssignmentTarget(expr Node) error {
+	switch e := expr.(type) {
+	case *ExprIdentifier:
+		return nil
+	case *ExprMemberAccess:
+		if !e.Optional {
+			return nil
+		}
+	case *ExprParenthesized:
+		// (a) = 1 and (a.b)++ are valid, but (a, b) = 1 is not
+		return checkAssignmentTarget(e.Expression)
+	case *ExprChain:
+		return fmt.Errorf("%w: optional chain", errInvalidAssignmentTarget)
+	}
+	return fmt.Errorf("%w: %s", errInvalidAssignmentTarget, expr.S())
+}
+
+// checkCoverGrammar reports the early errors of object and array literals that
+// are only lifted when they are reinterpreted as assignment patterns:
+// CoverInitializedNames, e.g. { a = 1 }, and duplicate __proto__ definitions.
+// Nested literals are checked too, as they would be nested patterns.
+func checkCoverGrammar(expr Node) error {
+	switch expr := expr.(type) {
+	case *ExprObject:
+		proto := false
+		for _, prop := range expr.Properties {
+			if prop.isCoverInitializedName() {
+				return fmt.Errorf("%w: invalid shorthand property initializer %s", errInvalidCoverGrammar, prop.Key.S())
+			}
+			if prop.isProtoSetter() {
+				if proto {
+					return fmt.Errorf("%w: duplicate __proto__ fields are not allowed", errInvalidCoverGrammar)
+				}
+				proto = true
+			}
+			if !prop.Method && prop.Kind == PropertyInit {
+				if err := checkCoverGrammar(prop.Value); err != nil {
+					return err
+				}
+			}
+		}
+	case *ExprArray:
+		for _, element := range expr.Elements {
+			if err := checkCoverGrammar(element); err != nil {
+				return err
+			}
+		}
+	case *SpreadElement:
+		return checkCoverGrammar(expr.Argument)
+	}
+	return nil
+}
+
 func (p *Parser) consumeAssignOp() (*l.Token, error) {
 	cur := p.Peek()
 	found := false
@@ -419,59 +592,210 @@ func (p *Parser) consumeAssignOp() (*l.Token, error) {
 	return &cur, nil
 }
 
+// parseAssignExpr parses an AssignmentExpression that is used as a value, so
+// object literals in it must not contain CoverInitializedNames.
 func (p *Parser) parseAssignExpr() (Expr, error) {
-	var err error
+	expr, err := p.parseCoverAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCoverGrammar(expr); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// parseCoverAssignExpr parses an AssignmentExpression that may later be
+// reinterpreted as an AssignmentPattern, e.g. the elements of an array literal
+// or of a parenthesized list, where { a = 1 } is not yet an error.
+func (p *Parser) parseCoverAssignExpr() (Expr, error) {
+	// AssignmentExpression : [+Yield] YieldExpression
+	if p.yield && p.Peek().Type == l.TYield {
+		return p.parseYieldExpr()
+	}
 
 	cp := p.saveCheckpoint()
-	// AssignmentExpression : LeftHandSideExpression '=' AssignmentExpression
-	parseLhs := func() (Node, error) {
-		lhs, err := p.parseLeftHandSideExpr()
-		if err != nil {
-			return nil, err
-		}
+	// AssignmentExpression : ArrowFunction | AsyncArrowFunction
+	if exprArrow, err := p.parseArrowFunction(); err == nil {
+		return exprArrow, nil
+	} else if !errors.Is(err, errNotArrowFunction) {
+		// the parameters are followed by '=>', so this cannot be another
+		// production
+		return nil, err
+	}
 
-		assignOp, err := p.consumeAssignOp()
-		if err != nil {
+	p.restoreCheckpoint(cp)
+	// AssignmentExpression : ConditionalExpression
+	//
+	// A LeftHandSideExpression is also a ConditionalExpression, so the left
+	// operand is parsed once and only checked as a target when an assignment
+	// operator follows it.
+	start := p.Peek()
+	expr, err := p.parseCondExpr()
+	if err != nil {
+		if isSyntaxError(err) {
 			return nil, err
 		}
+		return nil, fmt.Errorf("rejected on AssignExpr: %w", err)
+	}
+	if _, isAssignOp := newSet(assignmentOperators...)[p.Peek().Type]; !isAssignOp {
+		return expr, nil
+	}
 
-		rhs, err := p.parseAssignExpr()
-		if err != nil {
-			return nil, err
+	// AssignmentExpression : LeftHandSideExpression '=' AssignmentExpression
+	assignOp, err := p.consumeAssignOp()
+	if err != nil {
+		return nil, err
+	}
+	if isLiteralPattern(expr) && assignOp.Type == l.TAssign {
+		// only '=' reinterprets the target as an AssignmentPattern
+		if expr, err = reinterpretAsPattern(expr, false); err != nil {
+			return nil, errorAt(start, err)
 		}
-		return &ExprAssign{
-			left:     lhs,
-			right:    rhs,
-			operator: *assignOp,
-		}, nil
+	} else if err := checkAssignmentTarget(expr); err != nil {
+		return nil, errorAt(start, err)
 	}
-	if assignExpr, err := parseLhs(); err == nil {
-		return assignExpr, nil
+
+	rhs, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprAssign{
+		Left:     expr,
+		Right:    rhs,
+		Operator: *assignOp,
+	}, nil
+}
+
+// YieldExpression[In, Await] :
+// | 'yield'
+// | 'yield' [no LineTerminator here] AssignmentExpression[?In, +Yield, ?Await]
+// | 'yield' [no LineTerminator here] '*' AssignmentExpression[?In, +Yield, ?Await]
+func (p *Parser) parseYieldExpr() (Expr, error) {
+	p.Log("parseYieldExpr")
+	if p.Peek().Type != l.TYield {
+		return nil, p.needToken(fmt.Errorf("expected 'yield', got %s", p.Peek().Lexeme))
 	}
+	p.Next() // consume 'yield'
 
-	p.restoreCheckpoint(cp)
-	// AssignmentExpression : ConditionalExpression
-	exprCond, err := p.parseCondExpr()
-	if err == nil {
-		return exprCond, nil
+	exprYield := &ExprYield{}
+	next := p.Peek()
+	if next.NewlineBefore {
+		return exprYield, nil
+	}
+	if next.Type == l.TStar {
+		p.Next() // consume '*'
+		exprYield.Delegate = true
+	} else if !p.startsExpression(next) {
+		return exprYield, nil
 	}
 
-	return nil, fmt.Errorf("rejected on AssignExpr: %s", err.Error())
+	argument, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	exprYield.Argument = argument
+	return exprYield, nil
 }
 
-// ArrowFunction[In, Yield, Await] :
-// | ArrowParameters[?Yield, ?Await] [no LineTerminator here] => ConciseBody[?In]
-// ArrowParameters[Yield, Await] :
-// | BindingIdentifier[?Yield, ?Await]
-// | CoverParenthesizedExpressionAndArrowParameterList[?Yield, ?Await]
-// ConciseBody[In] :
-// | [lookahead ≠ {] ExpressionBody[?In, ~Await]
-// | '{' FunctionBody[~Yield, ~Await] '}'
-// ExpressionBody[In, Await] :
-// AssignmentExpression[?In, ~Yield, ?Await]
+// startsExpression reports whether token can be the first token of an
+// AssignmentExpression; it is used to decide whether an optional operand
+// (e.g. of 'yield') is present.
+func (p *Parser) startsExpression(token l.Token) bool {
+	switch token.Type {
+	case l.TEOF, l.TRightParen, l.TRightBracket, l.TRightBrace, l.TComma,
+		l.TSemicolon, l.TColon, l.TQuestionMark, l.TDoubleQuestionMark, l.TArrow, l.TIn, l.TInstanceof:
+		return false
+	}
+	_, isAssignOp := newSet(assignmentOperators...)[token.Type]
+	return !isAssignOp
+}
 
+// ConditionalExpression[In, Yield, Await] :
+// | ShortCircuitExpression[?In, ?Yield, ?Await]
+// | ShortCircuitExpression[?In, ?Yield, ?Await] '?' AssignmentExpression[+In, ?Yield, ?Await] ':' AssignmentExpression[?In, ?Yield, ?Await]
 func (p *Parser) parseCondExpr() (Expr, error) {
-	return p.parseLogOrExpr()
+	p.Log("parseCondExpr")
+	test, err := p.parseShortCircuitExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TQuestionMark {
+		return test, nil
+	}
+	p.Next() // consume '?'
+
+	consequent, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TColon {
+		return nil, p.needToken(fmt.Errorf("expected ':' in conditional expression, got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume ':'
+
+	alternate, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprConditional{
+		Test:       test,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}, nil
+}
+
+// ShortCircuitExpression[In, Yield, Await] :
+// | LogicalORExpression[?In, ?Yield, ?Await]
+// | CoalesceExpression[?In, ?Yield, ?Await]
+//
+// CoalesceExpression[In, Yield, Await] :
+// | CoalesceExpressionHead[?In, ?Yield, ?Await] '??' BitwiseORExpression[?In, ?Yield, ?Await]
+//
+// CoalesceExpressionHead[In, Yield, Await] :
+// | CoalesceExpression[?In, ?Yield, ?Await]
+// | BitwiseORExpression[?In, ?Yield, ?Await]
+//
+// Since neither production derives the other, '??' cannot be mixed with '||'
+// or '&&' unless one of the operands is parenthesized.
+func (p *Parser) parseShortCircuitExpr() (Expr, error) {
+	p.Log("parseShortCircuitExpr")
+	left, err := p.parseLogOrExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TDoubleQuestionMark {
+		return left, nil
+	}
+	if isLogicalExpr(left) {
+		return nil, fmt.Errorf("cannot mix '??' with '||' or '&&' without parentheses")
+	}
+
+	for p.Peek().Type == l.TDoubleQuestionMark {
+		operator := p.Peek()
+		p.Next() // consume '??'
+		right, err := p.parseBitOrExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &ExprBinaryOp{
+			Operator: operator,
+			Left:     left,
+			Right:    right,
+		}
+	}
+	if next := p.Peek().Type; next == l.TLogicalOr || next == l.TLogicalAnd {
+		return nil, fmt.Errorf("cannot mix '??' with '%s' without parentheses", next.S())
+	}
+	return left, nil
+}
+
+func isLogicalExpr(expr Expr) bool {
+	exprBinary, ok := expr.(*ExprBinaryOp)
+	if !ok {
+		return false
+	}
+	return exprBinary.Operator.Type == l.TLogicalOr || exprBinary.Operator.Type == l.TLogicalAnd
 }
 
 func newSet[C comparable](items ...C) map[C]struct{} {
@@ -520,12 +844,12 @@ func (p *Parser) parseBinaryOperators(
 		p.Next() // Consumes operator
 
 		if right, err := higherExprRight(); err != nil {
-			break
+			return nil, err
 		} else {
 			left = &ExprBinaryOp{
-				operator: token,
-				left:     left,
-				right:    right,
+				Operator: token,
+				Left:     left,
+				Right:    right,
 			}
 		}
 		p.guardInfiniteLoop(&lastCursor)
@@ -624,59 +948,96 @@ func (p *Parser) parseMultiplicativeExpr() (Expr, error) {
 	)
 }
 
+// ExponentiationExpression ::=
+// | UnaryExpression
+// | UpdateExpression ** ExponentiationExpression
+//
+// The base of ** is an UpdateExpression: -2 ** 2 is ambiguous and rejected,
+// while (-2) ** 2 is not.
 func (p *Parser) parseExponentialExpr() (Expr, error) {
 	p.Log("parseExponentialExpr")
-	return p.parseBinaryOperators(
-		[]l.TokenType{l.TStarStar},
-		p.parseUnaryOperator,
-		p.parseUpdateExpr,
-	)
+	left, err := p.parseUnaryOperator()
+	if err != nil {
+		return nil, err
+	}
+	token := p.Peek()
+	if token.Type != l.TStarStar {
+		return left, nil
+	}
+	if isUnaryExpression(left) {
+		return nil, errorAt(token, fmt.Errorf("unary operator used immediately before exponentiation expression"))
+	}
+	p.Next() // consume '**'
+
+	// right-associative: a ** b ** c is a ** (b ** c)
+	right, err := p.parseExponentialExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprBinaryOp{Operator: token, Left: left, Right: right}, nil
+}
+
+// isUnaryExpression reports whether e is a UnaryExpression that is not an
+// UpdateExpression: an await or a unary operator, not ++ or --.
+func isUnaryExpression(e Expr) bool {
+	switch e := e.(type) {
+	case *ExprAwait:
+		return true
+	case *ExprUnaryOp:
+		return e.Operator.Type != l.TPlusPlus && e.Operator.Type != l.TMinusMinus
+	}
+	return false
 }
 
 // UnaryExpression ::=
 // | UnaryOp UnaryExpression
 // | UpdateExpression
-// | AwaitExpression (TODO)
+// | [+Await] AwaitExpression
 //
 // UnaryOp ::= delete | void | typeof | + | - | ~ | !
+//
+// AwaitExpression[Yield] :
+// | 'await' UnaryExpression[?Yield, +Await]
 func (p *Parser) parseUnaryOperator() (Expr, error) {
 	p.Log("parseUnaryOperator")
 	var (
-		exprUnary, exprUpdate Expr
-		err                   error
-		unaryOpSet            = newSet(UnaryOperators...)
+		exprUpdate Expr
+		err        error
+		unaryOpSet = newSet(UnaryOperators...)
 	)
 
+	// UnaryExpression ::= AwaitExpression
+	if p.await && p.Peek().Type == l.TAwait {
+		p.Next() // consume 'await'
+		argument, err := p.parseUnaryOperator()
+		if err != nil {
+			return nil, err
+		}
+		return &ExprAwait{Argument: argument}, nil
+	}
+
 	// UnaryExpression ::= UpdateExpression
 	if exprUpdate, err = p.parseUpdateExpr(); err == nil {
 		return exprUpdate, nil
+	} else if isSyntaxError(err) {
+		return nil, err
 	}
 
 	// UnaryExpression ::= UnaryOp UnaryExpression
-	lastCursor := p.cursor
-	for {
-		token := p.Peek()
-		if _, ok := unaryOpSet[token.Type]; !ok {
-			break
-		}
-
-		p.Next() // consume operator
-		exprUnary, err = p.parseUnaryOperator()
-		if err != nil {
-			return nil, err
-		}
-
-		exprUnary = &ExprUnaryOp{
-			operator: token,
-			operand:  exprUnary,
-		}
-		p.guardInfiniteLoop(&lastCursor)
+	token := p.Peek()
+	if _, ok := unaryOpSet[token.Type]; !ok {
+		return nil, err
 	}
-
+	p.Next() // consume operator
+	operand, err := p.parseUnaryOperator()
 	if err != nil {
 		return nil, err
 	}
-	return exprUnary, nil
+	return &ExprUnaryOp{
+		Operator: token,
+		Operand:  operand,
+		Span:     p.spanFrom(token),
+	}, nil
 }
 
 // UpdateExpression ::=
@@ -692,19 +1053,29 @@ func (p *Parser) parseUpdateExpr() (Expr, error) {
 	)
 
 	// LeftHandSideExpression (++ | --)?
+	start := p.Peek()
 	exprUpdate, err = p.parseLeftHandSideExpr()
 	if err == nil {
 		token := p.Peek()
-		if _, ok := unaryOpSet[token.Type]; ok {
-			// UpdateExpression ::= LeftHandSideExpression (++ | --)
+		if _, ok := unaryOpSet[token.Type]; ok && !token.NewlineBefore {
+			// UpdateExpression ::= LeftHandSideExpression [no LineTerminator here] (++ | --)
+			if err := checkAssignmentTarget(exprUpdate); err != nil {
+				return nil, errorAt(start, err)
+			}
+			p.Next() // consume operator
+
 			return &ExprUnaryOp{ // TODO: make an UpdateExpr
-				operand:  exprUpdate,
-				operator: token,
+				Operand:  exprUpdate,
+				Operator: token,
+				Postfix:  true,
+				Span:     p.spanFrom(start),
 			}, nil
 		} else {
 			// UpdateExpression ::= LeftHandSideExpression
 			return exprUpdate, nil
 		}
+	} else if isSyntaxError(err) {
+		return nil, err
 	}
 
 	// (++ | --) UnaryExpression
@@ -716,15 +1087,20 @@ func (p *Parser) parseUpdateExpr() (Expr, error) {
 		}
 
 		p.Next() // consume operator
+		start := p.Peek()
 		operand, err := p.parseUnaryOperator()
 		if err != nil {
 			return nil, err
 		}
+		if err := checkAssignmentTarget(operand); err != nil {
+			return nil, errorAt(start, err)
+		}
 
 		match = true
 		exprUpdate = &ExprUnaryOp{
-			operator: token,
-			operand:  operand,
+			Operator: token,
+			Operand:  operand,
+			Span:     p.spanFrom(token),
 		}
 		p.guardInfiniteLoop(&lastCursor)
 	}
@@ -752,60 +1128,72 @@ func (p *Parser) parseLeftHandSideExpr() (Expr, error) {
 	)
 
 	cp = p.saveCheckpoint()
-	if expr, err = p.parseCallExpr(); err == nil {
-		return expr, nil
+	if expr, err = p.parseCallExpr(); err == nil || isSyntaxError(err) {
+		return expr, err
 	}
 	p.restoreCheckpoint(cp)
 
 	cp = p.saveCheckpoint()
-	if expr, err = p.parseNewExpr(); err == nil {
-		return expr, nil
+	if expr, err = p.parseNewExpr(); err == nil || isSyntaxError(err) {
+		return expr, err
 	}
 	p.restoreCheckpoint(cp)
 
 	return nil, fmt.Errorf("parseLeftHandSideExpr rejected")
 }
 
-// OptionalExpression ::=
+// OptionalExpression :
 // | MemberExpression OptionalChain
 // | CallExpression OptionalChain
 // | OptionalExpression OptionalChain
 //
-// simplifying the terms
+// OptionalChain :
+// | '?.' Arguments
+// | '?.' '[' Expression ']'
+// | '?.' IdentifierName
+// | '?.' TemplateLiteral (early error)
+// | '?.' PrivateIdentifier
+// | OptionalChain Arguments
+// | OptionalChain '[' Expression ']'
+// | OptionalChain '.' IdentifierName
+// | OptionalChain TemplateLiteral (early error)
+// | OptionalChain '.' PrivateIdentifier
 //
-// OptionalExpression : ((MemberExpression | CallExpression) OptionalChain)*
-//
-// OptionalChain ::=
-// '?.' Arguments
-// '?.' '[' Expression ']'
-// '?.' IdentifierName
-// '?.' TemplateLiteral (TODO)
-// '?.' PrivateIdentifier
-// OptionalChain Arguments
-// OptionalChain '[' Expression ']'
-// OptionalChain '.' IdentifierName
-// OptionalChain TemplateLiteral (TODO)
-// OptionalChain '.' PrivateIdentifier
-//
-// (MemberExpression | CallExpression) OptionalChain OptionalExpressionRest
-// OptionalExpressionRest ::= OptionalChain OptionalExpressionRest | ε
-func (p *Parser) parseOptionalExpression() (bool, error) {
-	if p.Peek().Type == l.TQuestionMark {
-		p.Next() // consume '?'
-		if p.Peek().Type != l.TPeriod {
-			return false, fmt.Errorf("parseOptionalExpression rejected")
+// isOptionalChainAhead reports whether the next tokens are the '?.' punctuator
+// that starts an OptionalChain.
+func (p *Parser) isOptionalChainAhead() bool {
+	return p.Peek().Type == l.TQuestionMark && p.PeekN(1).Type == l.TPeriod
+}
+
+// parseOptionalChainLink parses the link that immediately follows '?.', whose
+// object (or callee) is short-circuited when it is null or undefined.
+func (p *Parser) parseOptionalChainLink(object Expr) (Expr, error) {
+	p.Log("parseOptionalChainLink")
+	p.Next() // consume '?'
+	p.Next() // consume '.'
+
+	switch token := p.Peek(); token.Type {
+	case l.TTemplateLiteral, l.TTemplateHead:
+		return nil, errorAt(token, fmt.Errorf("tagged template cannot be used in optional chain"))
+	case l.TLeftParen:
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
 		}
-		if p.PeekN(1).Type == l.TLeftBracket {
-			// only consume '.' if '[' follows, so that we can have a clear and simple
-			// separation of the two productions.
-			p.Next() // consume '.'
-		} else if p.PeekN(1).Type == l.TLeftParen {
-			p.Next() // consume '.'
+		return &ExprCall{Callee: object, Arguments: arguments, Optional: true}, nil
+	case l.TLeftBracket:
+		property, err := p.parseMemberAccess()
+		if err != nil {
+			return nil, err
 		}
-		return true, nil
+		return &ExprMemberAccess{Object: object, Property: property, Computed: true, Optional: true}, nil
+	default:
+		property, err := p.parseMemberName()
+		if err != nil {
+			return nil, err
+		}
+		return &ExprMemberAccess{Object: object, Property: property, Optional: true}, nil
 	}
-
-	return false, nil
 }
 
 func (p *Parser) parseImportCall() (Expr, error) {
@@ -824,7 +1212,7 @@ func (p *Parser) parseImportCall() (Expr, error) {
 		}
 		p.Next() // consume ')'
 		return &ExprImportCall{
-			source: expr,
+			Source: expr,
 		}, nil
 	}
 	return nil, fmt.Errorf("parseImportCall rejected")
@@ -895,7 +1283,7 @@ func (p *Parser) parseArguments() ([]Expr, error) {
 						return nil, err
 					}
 
-					exprAssign = &SpreadElement{argument: exprAssign}
+					exprAssign = &SpreadElement{Argument: exprAssign}
 				} else if p.Peek().Type == l.TRightParen {
 					p.Next() // consume ')'
 					break argumentsLoop
@@ -909,6 +1297,8 @@ func (p *Parser) parseArguments() ([]Expr, error) {
 			case l.TRightParen:
 				p.Next() // consume ')'
 				break argumentsLoop
+			default:
+				return nil, p.needToken(fmt.Errorf("expected ',' or ')' after an argument, got %s", p.Peek().Lexeme))
 			}
 		}
 	}
@@ -932,27 +1322,7 @@ func (p *Parser) parseMemberAccess() (Expr, error) {
 	case l.TPeriod:
 		// MemberExpression ::= ('.' IdentifierName MemberExpression')*
 		p.Next() // consume '.'
-		afterPeriod := p.Peek()
-		switch afterPeriod.Type {
-		case l.TNumberSign:
-			p.Next() // consume '#'
-			afterHash := p.Peek()
-			if afterHash.Type == l.TIdentifier {
-				p.Next() // consume IdentifierName
-				return &ExprPrivateIdentifier{
-					name: afterHash.Lexeme,
-				}, nil
-			} else {
-				return nil, fmt.Errorf("expected identifier after '#.'")
-			}
-		case l.TIdentifier:
-			p.Next() // consume IdentifierName
-			return &ExprIdentifier{
-				name: afterPeriod.Lexeme,
-			}, nil
-		default:
-			return nil, fmt.Errorf("expected identifier after '.'")
-		}
+		return p.parseMemberName()
 	case l.TLeftBracket:
 		// MemberExpression ::= '[' Expression ']'
 		p.Next()                                    // consume '['
@@ -961,16 +1331,42 @@ func (p *Parser) parseMemberAccess() (Expr, error) {
 				p.Next() // consume ']'
 				return expr, nil
 			} else {
-				return nil, fmt.Errorf("expected ']' after expression")
+				return nil, p.needToken(fmt.Errorf("expected ']' after expression"))
 			}
 		} else {
-			return nil, fmt.Errorf("expected valid expression after '['")
+			return nil, p.needToken(fmt.Errorf("expected valid expression after '['"))
 		}
 	default:
 		break
 	}
 
-	return nil, fmt.Errorf("expected '.' or '['")
+	return nil, p.needToken(fmt.Errorf("expected '.' or '['"))
+}
+
+// parseMemberName parses the name that follows a '.' or '?.' punctuator:
+//
+// MemberName ::= IdentifierName | PrivateIdentifier
+func (p *Parser) parseMemberName() (Expr, error) {
+	token := p.Peek()
+	if token.Type == l.TNumberSign {
+		p.Next() // consume '#'
+		afterHash := p.Peek()
+		if afterHash.Type != l.TIdentifier {
+			return nil, p.needToken(fmt.Errorf("expected identifier after '#'"))
+		}
+		p.Next() // consume IdentifierName
+		return &ExprPrivateIdentifier{
+			Name: afterHash.Lexeme,
+		}, nil
+	}
+
+	// IdentifierName includes reserved words, e.g. `promise.catch`
+	_, reserved := l.ReservedWordNames[token.Type]
+	if token.Type != l.TIdentifier && !reserved {
+		return nil, p.needToken(fmt.Errorf("expected identifier after '.'"))
+	}
+	p.Next() // consume IdentifierName
+	return newIdentifier(token), nil
 }
 
 // parseCallExpr parses the following grammar:
@@ -982,7 +1378,7 @@ func (p *Parser) parseMemberAccess() (Expr, error) {
 // | CallExpression '(' ArgumentList? ')'
 // | CallExpression '[' Expression ']'
 // | CallExpression '.' IdentifierName
-// | CallExpression TemplateLiteral (TODO)
+// | CallExpression TemplateLiteral[?Yield, ?Await, +Tagged]
 // | CallExpression '.' PrivateIdentifier
 //
 // transforming the productions removing left recursion and expanding:
@@ -1007,12 +1403,15 @@ func (p *Parser) parseCallExpr() (Expr, error) {
 	)
 
 	exprCall, err = p.parseMemberExpr()
+	if isSyntaxError(err) {
+		return nil, err
+	}
 	if err != nil {
 		// CallExpression : SuperCall CallExpressionRest
 		switch p.Peek().Type {
 		case l.TSuper:
 			exprCall = &ExprCall{
-				callee: MakeLiteralExpr(l.TSuper),
+				Callee: MakeLiteralExpr(l.TSuper),
 			}
 		case l.TImport:
 			// CallExpression : ImportCall CallExpressionRest
@@ -1029,11 +1428,18 @@ func (p *Parser) parseCallExpr() (Expr, error) {
 		}
 	}
 
+	// whether the expression is an OptionalExpression, which short-circuits
+	// up to its end
+	chained := false
 restLoop:
 	for {
-		optional, err := p.parseOptionalExpression()
-		if err != nil {
-			return nil, err
+		if p.isOptionalChainAhead() {
+			// T ::= OptionalChain CallExpressionRest
+			if exprCall, err = p.parseOptionalChainLink(exprCall); err != nil {
+				return nil, err
+			}
+			chained = true
+			continue
 		}
 
 		token := p.Peek()
@@ -1044,9 +1450,8 @@ restLoop:
 				return nil, err
 			} else {
 				exprCall = &ExprCall{
-					callee:    exprCall,
-					arguments: arguments,
-					optional:  optional,
+					Callee:    exprCall,
+					Arguments: arguments,
 				}
 			}
 		case l.TPeriod, l.TLeftBracket:
@@ -1055,16 +1460,27 @@ restLoop:
 				return nil, err
 			} else {
 				exprCall = &ExprMemberAccess{
-					object:   exprCall,
-					property: property,
-					optional: optional,
+					Object:   exprCall,
+					Property: property,
+					Computed: token.Type == l.TLeftBracket,
 				}
 			}
+		case l.TTemplateLiteral, l.TTemplateHead:
+			// T ::= TemplateLiteral CallExpressionRest
+			if chained {
+				return nil, errorAt(token, fmt.Errorf("tagged template cannot be used in optional chain"))
+			}
+			if exprCall, err = p.parseTaggedTemplate(exprCall); err != nil {
+				return nil, err
+			}
 		default:
 			break restLoop
 		}
 	}
 
+	if chained {
+		return &ExprChain{Expression: exprCall}, nil
+	}
 	return exprCall, nil
 }
 
@@ -1092,7 +1508,7 @@ loop:
 					return nil, err
 				}
 				exprNew = &ExprNew{
-					callee: newExprRest,
+					Callee: newExprRest,
 				}
 				return exprNew, nil
 			}
@@ -1101,6 +1517,9 @@ loop:
 			if err != nil {
 				return nil, err
 			}
+			if p.isOptionalChainAhead() {
+				return nil, errorAt(p.Peek(), fmt.Errorf("invalid optional chain from new expression"))
+			}
 
 			// NewExpression ::= 'new' MemberExpression Arguments?
 			arguments, err := p.parseArguments()
@@ -1110,8 +1529,8 @@ loop:
 
 			// NewExpression ::= 'new' (Arguments)? MemberExpression
 			return &ExprNew{
-				callee:    exprNew,
-				arguments: arguments,
+				Callee:    exprNew,
+				Arguments: arguments,
 			}, nil
 
 		default:
@@ -1122,6 +1541,30 @@ loop:
 	return nil, fmt.Errorf("rejected on newExpression")
 }
 
+// parseNewWithArguments parses a new expression with arguments, which unlike
+// one without them may be the object of a member access or the callee of a
+// call, as in `new a().b` or `new a()()`.
+//
+// MemberExpression ::= 'new' MemberExpression Arguments
+func (p *Parser) parseNewWithArguments() (Expr, error) {
+	p.Next() // consume 'new'
+	callee, err := p.parseMemberExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOptionalChainAhead() {
+		return nil, errorAt(p.Peek(), fmt.Errorf("invalid optional chain from new expression"))
+	}
+	if p.Peek().Type != l.TLeftParen {
+		return nil, p.needToken(fmt.Errorf("expected arguments after new expression"))
+	}
+	arguments, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprNew{Callee: callee, Arguments: arguments}, nil
+}
+
 // parseMemberExpr parses the following grammar:
 //
 // MemberExpression ::=
@@ -1140,7 +1583,7 @@ loop:
 // | MemberExpressionRest ('[' Expr ']' MemberExpressionRest)*
 // | MemberExpressionRest ('.' IdentifierName MemberExpression')*
 // | MemberExpressionRest ('.' PrivateIdentifier)*
-// | MemberExpressionRest (TemplateLiteral MemberExpression')* (TODO)
+// | MemberExpressionRest (TemplateLiteral MemberExpression')*
 // | 'new' MemberExpression Arguments
 //
 // MemberExpressionRest ::=
@@ -1159,33 +1602,58 @@ func (p *Parser) parseMemberExpr() (Expr, error) {
 		switch p.Peek().Type {
 		case l.TSuper:
 			// SuperProperty :: = 'super' ('[' Expression ']' | '.' IdentifierName)
+			// SuperCall ::= 'super' Arguments
+			token := p.Peek()
 			p.Next() // consume 'super'
-			exprMember = MakeLiteralExpr(l.TSuper)
+			switch p.Peek().Type {
+			case l.TPeriod, l.TLeftBracket, l.TLeftParen:
+			default:
+				return nil, errorAt(token, fmt.Errorf("'super' keyword unexpected here"))
+			}
+			// positioned at the keyword, where its early errors are reported
+			super := MakeLiteralExpr(l.TSuper)
+			super.Token.Lexeme, super.Token.Line, super.Token.Column = token.Lexeme, token.Line, token.Column
+			exprMember = super
 		case l.TNew:
 			// MetaProperty ::= 'new' '.' 'target'
 			if p.PeekN(1).Type == l.TPeriod && p.PeekN(2).Lexeme == "target" {
+				start := p.Peek()
 				p.Next() // consume 'new'
 				p.Next() // consume '.'
 				p.Next() // consume 'target'
 				return &ExprMetaProperty{
-					meta: MakeLiteralExpr(l.TNew),
-					property: &ExprIdentifier{
-						name: "target",
+					Meta: MakeLiteralExpr(l.TNew),
+					Property: &ExprIdentifier{
+						Name: "target",
 					},
+					Span: p.spanFrom(start),
 				}, nil
 			}
-			return nil, fmt.Errorf("invalid meta property")
+			// MemberExpression ::= 'new' MemberExpression Arguments
+			cp := p.saveCheckpoint()
+			if exprMember, err = p.parseNewWithArguments(); err != nil {
+				if isSyntaxError(err) {
+					return nil, err
+				}
+				p.restoreCheckpoint(cp)
+				return nil, err
+			}
 		case l.TImport:
 			// MetaProperty ::= 'import' '.' 'meta'
 			if p.PeekN(1).Type == l.TPeriod && p.PeekN(2).Lexeme == "meta" {
+				if !p.isModule() {
+					return nil, fmt.Errorf("import.meta may only appear in modules")
+				}
+				start := p.Peek()
 				p.Next() // consume 'import'
 				p.Next() // consume '.'
 				p.Next() // consume 'meta'
 				return &ExprMetaProperty{
-					meta: MakeLiteralExpr(l.TImport),
-					property: &ExprIdentifier{
-						name: "meta",
+					Meta: MakeLiteralExpr(l.TImport),
+					Property: &ExprIdentifier{
+						Name: "meta",
 					},
+					Span: p.spanFrom(start),
 				}, nil
 			}
 			return nil, fmt.Errorf("invalid meta property")
@@ -1206,10 +1674,16 @@ loop:
 				return nil, err
 			} else {
 				exprMember = &ExprMemberAccess{
-					object:   exprMember,
-					property: property,
+					Object:   exprMember,
+					Property: property,
+					Computed: token.Type == l.TLeftBracket,
 				}
 			}
+		case l.TTemplateLiteral, l.TTemplateHead:
+			// MemberExpression ::= MemberExpression TemplateLiteral
+			if exprMember, err = p.parseTaggedTemplate(exprMember); err != nil {
+				return nil, err
+			}
 		default:
 			break loop
 		}
@@ -1229,49 +1703,68 @@ loop:
 // | GeneratorExpression (TODO)
 // | AsyncFunctionExpression (TODO)
 // | AsyncGeneratorExpression (TODO)
-// | RegularExpressionLiteral (TODO)
-// | TemplateLiteral (TODO)
-// | CoverParenthesizedExpressionAndArrowParameterList (TODO)
+// | RegularExpressionLiteral
+// | TemplateLiteral[?Yield, ?Await, ~Tagged]
+// | CoverParenthesizedExpressionAndArrowParameterList
 func (p *Parser) parsePrimaryExpr() (Expr, error) {
 	var err error
 	p.Log("parsePrimaryExpr")
+	// 'function', and 'async function' on a line, start a FunctionExpression,
+	// whose errors are final: 'async' is an identifier otherwise
+	if token := p.Peek(); token.Type == l.TFunction || isAsyncFunction(token, p.PeekN(1)) {
+		return p.parseFunctionExpression()
+	}
+
 	cp := p.saveCheckpoint()
-	if literal, err := p.parseLiteralAndIdentifier(); err == nil {
-		return literal, nil
+	if literal, err := p.parseLiteralAndIdentifier(); err == nil || isSyntaxError(err) {
+		return literal, err
 	}
 	p.restoreCheckpoint(cp)
 
 	cp = p.saveCheckpoint()
-	if array, err := p.parseArrayInitializer(); err == nil {
-		return array, nil
+	if array, err := p.parseArrayInitializer(); err == nil || isSyntaxError(err) {
+		return array, err
 	}
 	p.restoreCheckpoint(cp)
 
 	cp = p.saveCheckpoint()
-	if object, err := p.parseObjectInitializer(); err == nil {
-		return object, nil
+	if object, err := p.parseObjectInitializer(); err == nil || isSyntaxError(err) {
+		return object, err
 	}
 	p.restoreCheckpoint(cp)
 
 	cp = p.saveCheckpoint()
-	if fn, err := p.parseFunctionExpression(); err == nil {
-		return fn, nil
+	if paren, err := p.parseParenthesizedExpr(); err == nil || isSyntaxError(err) {
+		return paren, err
 	}
 	p.restoreCheckpoint(cp)
 
-	return nil, fmt.Errorf("rejected on primaryExpression: %v", err)
+	if isTemplateStart(p.Peek()) {
+		return p.parseTemplateLiteral(false)
+	}
+	if p.Peek().Type == l.TRegularExpressionLiteral {
+		return p.parseRegularExpressionLiteral()
+	}
+
+	return nil, p.needToken(fmt.Errorf("rejected on primaryExpression: %v", err))
 }
 
 func (p *Parser) parseFunctionExpression() (Expr, error) {
+	start := p.Peek()
 	if fnDecl, err := p.parseFunctionDeclaration(); err != nil {
 		return nil, err
 	} else {
 		fn := fnDecl.(*FunctionDeclarationStmt)
-		return &ExprFunction{
+		expr := &ExprFunction{
 			Body:              fn.Body,
 			BindingIdentifier: fn.BindingIdentifier,
 			Params:            fn.Params,
-		}, nil
+			Generator:         fn.Generator,
+			Async:             fn.Async,
+			Strict:            fn.Strict,
+		}
+		p.recordSpan(expr, start)
+		return expr, nil
 	}
 }
 
@@ -1281,22 +1774,19 @@ func (p *Parser) parseLiteralAndIdentifier() (Expr, error) {
 	token := p.Peek()
 
 	switch token.Type {
-	case l.TIdentifier:
-		primaryExpr = &ExprIdentifier{
-			name: token.Lexeme,
+	case l.TIdentifier, l.TAsync, l.TYield, l.TAwait:
+		if !p.isIdentifier(token) {
+			return nil, fmt.Errorf("primaryExpr rejected: reserved word %s", token.Lexeme)
 		}
+		primaryExpr = newIdentifier(token)
 	case l.TNumericLiteral:
-		if num, err := strconv.ParseFloat(token.Lexeme, 64); err == nil {
-			primaryExpr = &ExprLiteral[float64]{
-				tok: l.Token{
-					Type:    l.TNumericLiteral,
-					Literal: num,
-					Lexeme:  token.Lexeme,
-				},
-			}
-		} else {
+		num, err := p.numericValue(token)
+		if err != nil {
 			return nil, err
 		}
+		tok := token
+		tok.Literal = num
+		primaryExpr = &ExprLiteral[float64]{tok}
 	case l.TStringLiteral_SingleQuote:
 		primaryExpr = &ExprLiteral[string]{token}
 	case l.TStringLiteral_DoubleQuote:
@@ -1318,3 +1808,50 @@ func (p *Parser) parseLiteralAndIdentifier() (Expr, error) {
 	p.Next() // consume token
 	return primaryExpr, nil
 }
+
+// isNonDecimalLiteral reports whether the source text of a numeric literal is
+// a NonDecimalIntegerLiteral (e.g. 0xff, 0o17 or 0b101).
+func isNonDecimalLiteral(lexeme string) bool {
+	return len(lexeme) > 1 && lexeme[0] == '0' && strings.ContainsRune("xXoObB", rune(lexeme[1]))
+}
+
+// numericValue computes the value of a NumericLiteral. LegacyOctalIntegerLiteral
+// (e.g. 017) and NonOctalDecimalIntegerLiteral (e.g. 08) are only allowed with
+// Annex B.
+//
+// https://262.ecma-international.org/#sec-additional-syntax-numeric-literals
+func (p *Parser) numericValue(token l.Token) (float64, error) {
+	lexeme := token.Lexeme
+	if isNonDecimalLiteral(lexeme) {
+		// big.Int parses the 0x, 0o and 0b prefixes and the separators
+		n, ok := new(big.Int).SetString(lexeme, 0)
+		if !ok {
+			return 0, errorAt(token, fmt.Errorf("invalid numeric literal %s", lexeme))
+		}
+		value, _ := new(big.Float).SetInt(n).Float64()
+		return value, nil
+	}
+	if !isLegacyOctalLike(lexeme) {
+		return parseDecimal(lexeme)
+	}
+	if !p.options.AnnexB {
+		return 0, errorAt(token, fmt.Errorf("legacy octal-like literal %s is only allowed with Annex B", lexeme))
+	}
+	if value, err := strconv.ParseUint(lexeme, 8, 64); err == nil {
+		return float64(value), nil
+	}
+	if strings.ContainsAny(lexeme, "89") && !strings.Contains(lexeme, "_") {
+		return parseDecimal(lexeme)
+	}
+	return 0, errorAt(token, fmt.Errorf("invalid legacy octal literal %s", lexeme))
+}
+
+// parseDecimal parses a decimal literal, the literals too large for a float64,
+// such as 1e400, being Infinity.
+func parseDecimal(lexeme string) (float64, error) {
+	value, err := strconv.ParseFloat(lexeme, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return value, nil
+	}
+	return value, err
+}
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/ruiconti/gojs/internal"
@@ -10,24 +13,24 @@ import (
 
 func idExpr(name string) *ExprIdentifier {
 	return &ExprIdentifier{
-		name: name,
+		Name: name,
 	}
 }
 func spreadExpr(expr Expr) *SpreadElement {
 	return &SpreadElement{
-		argument: expr,
+		Argument: expr,
 	}
 }
 
 func idPrivateExpr(name string) *ExprPrivateIdentifier {
 	return &ExprPrivateIdentifier{
-		name: name,
+		Name: name,
 	}
 }
 
 func intExpr(n int32) *ExprLiteral[float64] {
 	return &ExprLiteral[float64]{
-		tok: l.Token{
+		Token: l.Token{
 			Literal: float64(n),
 			Lexeme:  fmt.Sprintf("%d", n),
 			Type:    l.TNumericLiteral,
@@ -37,9 +40,9 @@ func intExpr(n int32) *ExprLiteral[float64] {
 
 func binExpr(left, right Expr, op l.TokenType) *ExprBinaryOp {
 	return &ExprBinaryOp{
-		left:     left,
-		right:    right,
-		operator: op.Token(),
+		Left:     left,
+		Right:    right,
+		Operator: op.Token(),
 	}
 }
 
@@ -51,7 +54,7 @@ func stringExpr(s string) *ExprLiteral[string] {
 		st = l.TStringLiteral_SingleQuote
 	}
 	return &ExprLiteral[string]{
-		tok: l.Token{
+		Token: l.Token{
 			Literal: s,
 			Lexeme:  s,
 			Type:    st,
@@ -59,6 +62,21 @@ func stringExpr(s string) *ExprLiteral[string] {
 	}
 }
 
+// templateExpr builds a template literal whose quasis have no escape
+// sequences, interleaved with exprs.
+func templateExpr(raws []string, exprs ...Expr) *ExprTemplateLiteral {
+	template := &ExprTemplateLiteral{Expressions: exprs}
+	for i, raw := range raws {
+		cooked := raw
+		template.Quasis = append(template.Quasis, &TemplateElement{
+			Cooked: &cooked,
+			Raw:    raw,
+			Tail:   i == len(raws)-1,
+		})
+	}
+	return template
+}
+
 // /////////////////////
 // PrimaryExpression //
 // /////////////////////
@@ -68,7 +86,7 @@ func TestPrimaryLiterals(t *testing.T) {
 		src := "123 true false null undefined \"foo\" 'bar'"
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprLiteral[float64]{l.Token{Type: l.TNumericLiteral, Literal: "123"}},
 				ExprLitTrue,
 				ExprLitFalse,
@@ -90,21 +108,42 @@ func TestPrimaryLiterals(t *testing.T) {
 		src := `\u3034baz; \u9023\u4930\u1102x; b\u400e\u99a0`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprIdentifier{
-					name: `\u3034baz`,
+					Name: `\u3034baz`,
 				},
 				&ExprIdentifier{
-					name: `\u9023\u4930\u1102x`,
+					Name: `\u9023\u4930\u1102x`,
 				},
 				&ExprIdentifier{
-					name: `b\u400e\u99a0`,
+					Name: `b\u400e\u99a0`,
 				},
 			},
 		}
 		AssertExprEqual(t, logger, got, exp)
 	})
-
+	t.Run("literals non-decimal", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		got := Parse(logger, "0xff; 0X1_0; 0o17; 0b101; 0x1fffffffffffff1")
+		expected := []float64{255, 16, 15, 5, 0x1fffffffffffff1}
+		for i, child := range got.(*NodeRoot).Children {
+			literal := child.(*ExpressionStatement).Expression.(*ExprLiteral[float64])
+			if literal.Token.Literal != expected[i] {
+				t.Errorf("expected %v, got %v", expected[i], literal.Token.Literal)
+			}
+		}
+	})
+	t.Run("literals out of range", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		got := Parse(logger, "1e400; 1e-400; 0x1"+strings.Repeat("0", 300))
+		expected := []float64{math.Inf(1), 0, math.Inf(1)}
+		for i, child := range got.(*NodeRoot).Children {
+			literal := child.(*ExpressionStatement).Expression.(*ExprLiteral[float64])
+			if literal.Token.Literal != expected[i] {
+				t.Errorf("expected %v, got %v", expected[i], literal.Token.Literal)
+			}
+		}
+	})
 }
 
 //////////////////////////
@@ -117,15 +156,15 @@ func TestExprIdentifierReference(t *testing.T) {
 
 	got := Parse(logger, src)
 	exp := &NodeRoot{
-		children: []Node{
+		Children: []Node{
 			&ExprIdentifier{
-				name: "foo",
+				Name: "foo",
 			},
 			&ExprIdentifier{
-				name: "bar",
+				Name: "bar",
 			},
 			&ExprIdentifier{
-				name: "baz",
+				Name: "baz",
 			},
 		},
 	}
@@ -171,7 +210,7 @@ func TestBinaryOperators(t *testing.T) {
 			}
 
 			expected := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					binExpr(
 						binExpr(
 							binExpr(
@@ -313,12 +352,12 @@ func TestUnaryOperators(t *testing.T) {
 			src := fmt.Sprintf("%s foo", operator.S())
 			got := Parse(logger, src)
 			exp := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					&ExprUnaryOp{
-						operand: &ExprIdentifier{
-							name: "foo",
+						Operand: &ExprIdentifier{
+							Name: "foo",
 						},
-						operator: l.Token{
+						Operator: l.Token{
 							Type:    operator,
 							Literal: operator.S(),
 						},
@@ -334,12 +373,12 @@ func TestUnaryOperators(t *testing.T) {
 		for _, operator := range UpdateOperators {
 			src := fmt.Sprintf("%s foo", operator.S())
 			exp := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					&ExprUnaryOp{
-						operand: &ExprIdentifier{
-							name: "foo",
+						Operand: &ExprIdentifier{
+							Name: "foo",
 						},
-						operator: operator.Token(),
+						Operator: operator.Token(),
 					},
 				},
 			}
@@ -406,21 +445,21 @@ func TestUnaryOperators(t *testing.T) {
 			src := fmt.Sprintf("%s %s %s %s bar", operatorName, operatorName, operatorName, operatorName)
 			got := Parse(logger, src)
 			exp := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					&ExprUnaryOp{
-						operand: &ExprUnaryOp{
-							operand: &ExprUnaryOp{
-								operand: &ExprUnaryOp{
-									operand: &ExprIdentifier{
-										name: "bar",
+						Operand: &ExprUnaryOp{
+							Operand: &ExprUnaryOp{
+								Operand: &ExprUnaryOp{
+									Operand: &ExprIdentifier{
+										Name: "bar",
 									},
-									operator: operatorToken,
+									Operator: operatorToken,
 								},
-								operator: operatorToken,
+								Operator: operatorToken,
 							},
-							operator: operatorToken,
+							Operator: operatorToken,
 						},
-						operator: operatorToken,
+						Operator: operatorToken,
 					},
 				},
 			}
@@ -435,12 +474,12 @@ func TestUnaryOperators(t *testing.T) {
 				src := fmt.Sprintf("%s %s foo", unaryOp.S(), updateOp.S())
 				got := Parse(logger, src)
 				exp := &NodeRoot{
-					children: []Node{
+					Children: []Node{
 						&ExprUnaryOp{
-							operator: unaryOp.Token(),
-							operand: &ExprUnaryOp{
-								operator: updateOp.Token(),
-								operand:  idExpr("foo"),
+							Operator: unaryOp.Token(),
+							Operand: &ExprUnaryOp{
+								Operator: updateOp.Token(),
+								Operand:  idExpr("foo"),
 							},
 						},
 					},
@@ -451,6 +490,69 @@ func TestUnaryOperators(t *testing.T) {
 		}
 
 	})
+
+	t.Run("unary expression as the left operand of a binary operator", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		for _, operator := range UnaryOperators {
+			src := fmt.Sprintf("%s a + b", operator.S())
+			got := Parse(logger, src)
+			exp := &NodeRoot{
+				Children: []Node{
+					binExpr(&ExprUnaryOp{Operator: operator.Token(), Operand: idExpr("a")}, idExpr("b"), l.TPlus),
+				},
+			}
+			AssertExprEqual(t, logger, got, exp)
+		}
+	})
+
+	t.Run("exponentiation is right-associative and takes unary right operands", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		minus := l.TMinus
+		got := Parse(logger, "a ** b ** -c")
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(
+					idExpr("a"),
+					binExpr(idExpr("b"), &ExprUnaryOp{Operator: minus.Token(), Operand: idExpr("c")}, l.TStarStar),
+					l.TStarStar,
+				),
+			},
+		}
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestExponentiation_Rejected(t *testing.T) {
+	srcs := []string{
+		"-2 ** 2",
+		"typeof a ** 2",
+		"delete a ** 2",
+		"!a ** 2",
+		"a ** -b ** 2",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
+
+func TestExponentiation_UpdateOrParenthesizedBase(t *testing.T) {
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	minus, plusPlus := l.TMinus, l.TPlusPlus
+	got := Parse(logger, "(-a) ** b; a++ ** b; ++a ** b")
+	exp := &NodeRoot{
+		Children: []Node{
+			binExpr(&ExprParenthesized{Expression: &ExprUnaryOp{Operator: minus.Token(), Operand: idExpr("a")}}, idExpr("b"), l.TStarStar),
+			binExpr(&ExprUnaryOp{Operator: plusPlus.Token(), Operand: idExpr("a"), Postfix: true}, idExpr("b"), l.TStarStar),
+			binExpr(&ExprUnaryOp{Operator: plusPlus.Token(), Operand: idExpr("a")}, idExpr("b"), l.TStarStar),
+		},
+	}
+	AssertExprEqual(t, logger, got, exp)
 }
 
 func TestMemberAndNewExpressions(t *testing.T) {
@@ -459,7 +561,7 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `foo`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				idExpr("foo"),
 			},
 		}
@@ -471,10 +573,10 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `foo[bar]`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object:   idExpr("foo"),
-					property: idExpr("bar"),
+					Object:   idExpr("foo"),
+					Property: idExpr("bar"),
 				},
 			},
 		}
@@ -486,10 +588,10 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `foo.bar`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object:   idExpr("foo"),
-					property: idExpr("bar"),
+					Object:   idExpr("foo"),
+					Property: idExpr("bar"),
 				},
 			},
 		}
@@ -497,16 +599,15 @@ func TestMemberAndNewExpressions(t *testing.T) {
 	})
 
 	t.Run("template literal as tagged", func(t *testing.T) {
-		t.Skip()
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
-		src := "foo`bar`"
+		src := "foo.bar`baz`"
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
-				// &ExprTaggedTemplate{
-				// 	tag:      idExpr("foo"),
-				// 	template: templateExpr("bar"),
-				// },
+			Children: []Node{
+				&ExprTaggedTemplate{
+					Tag:   &ExprMemberAccess{Object: idExpr("foo"), Property: idExpr("bar")},
+					Quasi: templateExpr([]string{"baz"}),
+				},
 			},
 		}
 		AssertExprEqual(t, logger, got, exp)
@@ -517,10 +618,10 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `super.foo`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object:   MakeLiteralExpr(l.TSuper),
-					property: idExpr("foo"),
+					Object:   MakeLiteralExpr(l.TSuper),
+					Property: idExpr("foo"),
 				},
 			},
 		}
@@ -532,10 +633,10 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `new.target`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMetaProperty{
-					meta:     idExpr("new"),
-					property: idExpr("target"),
+					Meta:     idExpr("new"),
+					Property: idExpr("target"),
 				},
 			},
 		}
@@ -545,12 +646,12 @@ func TestMemberAndNewExpressions(t *testing.T) {
 	t.Run("meta property: import.meta", func(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `import.meta`
-		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
+		got := ParseWithOptions(internal.NewSimpleLogger(internal.ModeDebug), src, Options{SourceType: SourceTypeModule})
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMetaProperty{
-					meta:     idExpr("import"),
-					property: idExpr("meta"),
+					Meta:     idExpr("import"),
+					Property: idExpr("meta"),
 				},
 			},
 		}
@@ -561,10 +662,10 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `new foo(bar)`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprNew{
-					callee: idExpr("foo"),
-					arguments: []Expr{
+					Callee: idExpr("foo"),
+					Arguments: []Expr{
 						idExpr("bar"),
 					},
 				},
@@ -572,17 +673,35 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		}
 		AssertExprEqual(t, logger, got, exp)
 	})
+	t.Run("new expression with arguments as object and callee", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `new foo(bar).baz; new foo()()`
+		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprMemberAccess{
+					Object:   &ExprNew{Callee: idExpr("foo"), Arguments: []Expr{idExpr("bar")}},
+					Property: idExpr("baz"),
+				},
+				&ExprCall{
+					Callee:    &ExprNew{Callee: idExpr("foo"), Arguments: []Expr{}},
+					Arguments: []Expr{},
+				},
+			},
+		}
+		AssertExprEqual(t, logger, got, exp)
+	})
 
 	t.Run("private identifier", func(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `foo.#bar`
 		got := Parse(internal.NewSimpleLogger(internal.ModeDebug), src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object: idExpr("foo"),
-					property: &ExprPrivateIdentifier{
-						name: "bar",
+					Object: idExpr("foo"),
+					Property: &ExprPrivateIdentifier{
+						Name: "bar",
 					},
 				},
 			},
@@ -594,19 +713,19 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `foo.bar[baz][foo2].bar2`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object: &ExprMemberAccess{
-						object: &ExprMemberAccess{
-							object: &ExprMemberAccess{
-								object:   idExpr("foo"),
-								property: idExpr("bar"),
+					Object: &ExprMemberAccess{
+						Object: &ExprMemberAccess{
+							Object: &ExprMemberAccess{
+								Object:   idExpr("foo"),
+								Property: idExpr("bar"),
 							},
-							property: idExpr("baz"),
+							Property: idExpr("baz"),
 						},
-						property: idExpr("foo2"),
+						Property: idExpr("foo2"),
 					},
-					property: idExpr("bar2"),
+					Property: idExpr("bar2"),
 				},
 			},
 		}
@@ -618,20 +737,20 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `new foo.bar[baz][foo2].bar2`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprNew{
-					callee: &ExprMemberAccess{
-						object: &ExprMemberAccess{
-							object: &ExprMemberAccess{
-								object: &ExprMemberAccess{
-									object:   idExpr("foo"),
-									property: idExpr("bar"),
+					Callee: &ExprMemberAccess{
+						Object: &ExprMemberAccess{
+							Object: &ExprMemberAccess{
+								Object: &ExprMemberAccess{
+									Object:   idExpr("foo"),
+									Property: idExpr("bar"),
 								},
-								property: idExpr("baz"),
+								Property: idExpr("baz"),
 							},
-							property: idExpr("foo2"),
+							Property: idExpr("foo2"),
 						},
-						property: idExpr("bar2"),
+						Property: idExpr("bar2"),
 					},
 				},
 			},
@@ -645,14 +764,14 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		src := `new new new new foo[0 >> 2]`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprNew{
-					callee: &ExprNew{
-						callee: &ExprNew{
-							callee: &ExprNew{
-								callee: &ExprMemberAccess{
-									object:   idExpr("foo"),
-									property: binExpr(intExpr(0), intExpr(2), l.TRightShift),
+					Callee: &ExprNew{
+						Callee: &ExprNew{
+							Callee: &ExprNew{
+								Callee: &ExprMemberAccess{
+									Object:   idExpr("foo"),
+									Property: binExpr(intExpr(0), intExpr(2), l.TRightShift),
 								},
 							},
 						},
@@ -682,11 +801,13 @@ func TestMemberAndNewExpressions(t *testing.T) {
 		for i := 0; i < len(srcs); i++ {
 			src, expectedProp := srcs[i], expectedProps[i]
 			exp := &NodeRoot{
-				children: []Node{
-					&ExprMemberAccess{
-						object:   idExpr("foo"),
-						property: expectedProp,
-						optional: true,
+				Children: []Node{
+					&ExprChain{
+						Expression: &ExprMemberAccess{
+							Object:   idExpr("foo"),
+							Property: expectedProp,
+							Optional: true,
+						},
 					},
 				},
 			}
@@ -703,10 +824,10 @@ func TestCallExpression(t *testing.T) {
 		src := `foo(bar)`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: idExpr("foo"),
-					arguments: []Expr{
+					Callee: idExpr("foo"),
+					Arguments: []Expr{
 						idExpr("bar"),
 					},
 				},
@@ -720,10 +841,10 @@ func TestCallExpression(t *testing.T) {
 		src := `foo(bar, baz, ...qux)`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: idExpr("foo"),
-					arguments: []Expr{
+					Callee: idExpr("foo"),
+					Arguments: []Expr{
 						idExpr("bar"), idExpr("baz"), spreadExpr(idExpr("qux")),
 					},
 				},
@@ -733,17 +854,14 @@ func TestCallExpression(t *testing.T) {
 	})
 
 	t.Run("call expression with template literal", func(t *testing.T) {
-		t.Skip()
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
-		src := "foo`bar`"
+		src := "foo()`bar`"
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
-				&ExprCall{
-					callee:    idExpr("foo"),
-					arguments: []Expr{
-						// templateExpr("bar"),
-					},
+			Children: []Node{
+				&ExprTaggedTemplate{
+					Tag:   &ExprCall{Callee: idExpr("foo"), Arguments: []Expr{}},
+					Quasi: templateExpr([]string{"bar"}),
 				},
 			},
 		}
@@ -755,12 +873,12 @@ func TestCallExpression(t *testing.T) {
 		src := `foo[bar()]`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprMemberAccess{
-					object: idExpr("foo"),
-					property: &ExprCall{
-						callee:    idExpr("bar"),
-						arguments: []Expr{},
+					Object: idExpr("foo"),
+					Property: &ExprCall{
+						Callee:    idExpr("bar"),
+						Arguments: []Expr{},
 					},
 				},
 			},
@@ -773,13 +891,13 @@ func TestCallExpression(t *testing.T) {
 		src := `super.foo()`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: &ExprMemberAccess{
-						object:   idExpr("super"),
-						property: idExpr("foo"),
+					Callee: &ExprMemberAccess{
+						Object:   idExpr("super"),
+						Property: idExpr("foo"),
 					},
-					arguments: []Expr{},
+					Arguments: []Expr{},
 				},
 			},
 		}
@@ -791,9 +909,9 @@ func TestCallExpression(t *testing.T) {
 		src := `import("foo.js")`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprImportCall{
-					source: stringExpr(`"foo.js"`),
+					Source: stringExpr(`"foo.js"`),
 				},
 			},
 		}
@@ -805,12 +923,12 @@ func TestCallExpression(t *testing.T) {
 		src := `import("foo.js")(bar,'baz')`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: &ExprImportCall{
-						source: stringExpr(`"foo.js"`),
+					Callee: &ExprImportCall{
+						Source: stringExpr(`"foo.js"`),
 					},
-					arguments: []Expr{
+					Arguments: []Expr{
 						idExpr("bar"),
 						stringExpr(`'baz'`),
 					},
@@ -825,15 +943,15 @@ func TestCallExpression(t *testing.T) {
 		src := `foo.#bar(a, b)`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: &ExprMemberAccess{
-						object: idExpr("foo"),
-						property: &ExprPrivateIdentifier{
-							name: "bar",
+					Callee: &ExprMemberAccess{
+						Object: idExpr("foo"),
+						Property: &ExprPrivateIdentifier{
+							Name: "bar",
 						},
 					},
-					arguments: []Expr{idExpr("a"), idExpr("b")},
+					Arguments: []Expr{idExpr("a"), idExpr("b")},
 				},
 			},
 		}
@@ -845,16 +963,16 @@ func TestCallExpression(t *testing.T) {
 		src := `foo(bar(baz(qux)))`
 		got := Parse(logger, src)
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprCall{
-					callee: idExpr("foo"),
-					arguments: []Expr{
+					Callee: idExpr("foo"),
+					Arguments: []Expr{
 						&ExprCall{
-							callee: idExpr("bar"),
-							arguments: []Expr{
+							Callee: idExpr("bar"),
+							Arguments: []Expr{
 								&ExprCall{
-									callee: idExpr("baz"),
-									arguments: []Expr{
+									Callee: idExpr("baz"),
+									Arguments: []Expr{
 										idExpr("qux"),
 									},
 								},
@@ -876,38 +994,38 @@ func TestCallExpression(t *testing.T) {
 		}
 		expectedExprs := []Expr{
 			&ExprCall{
-				callee:    idExpr("foo"),
-				arguments: []Expr{idExpr("bar")},
-				optional:  true,
+				Callee:    idExpr("foo"),
+				Arguments: []Expr{idExpr("bar")},
+				Optional:  true,
 			},
 			&ExprCall{
-				callee: &ExprCall{
-					callee:    idExpr("foo"),
-					arguments: []Expr{idExpr("a")},
-					optional:  true,
+				Callee: &ExprCall{
+					Callee:    idExpr("foo"),
+					Arguments: []Expr{idExpr("a")},
+					Optional:  true,
 				},
-				arguments: []Expr{idExpr("b")},
-				optional:  true,
+				Arguments: []Expr{idExpr("b")},
+				Optional:  true,
 			},
 			&ExprCall{
-				callee: &ExprCall{
-					callee: &ExprCall{
-						callee:    idExpr("foo"),
-						arguments: []Expr{},
-						optional:  true,
+				Callee: &ExprCall{
+					Callee: &ExprCall{
+						Callee:    idExpr("foo"),
+						Arguments: []Expr{},
+						Optional:  true,
 					},
-					arguments: []Expr{idExpr("bar")},
-					optional:  false,
+					Arguments: []Expr{idExpr("bar")},
+					Optional:  false,
 				},
-				arguments: []Expr{idExpr("baz")},
-				optional:  true,
+				Arguments: []Expr{idExpr("baz")},
+				Optional:  true,
 			},
 		}
 
 		for i := 0; i < len(srcs); i++ {
 			src, expectedExpr := srcs[i], expectedExprs[i]
 			exp := &NodeRoot{
-				children: []Node{expectedExpr},
+				Children: []Node{&ExprChain{Expression: expectedExpr}},
 			}
 			got := Parse(logger, src)
 			AssertExprEqual(t, logger, got, exp)
@@ -918,19 +1036,21 @@ func TestCallExpression(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `foo?.#bar(a, b)?.c`
 		exp := &NodeRoot{
-			children: []Node{
-				&ExprMemberAccess{
-					property: idExpr("c"),
-					optional: true,
-					object: &ExprCall{
-						callee: &ExprMemberAccess{
-							object:   idExpr("foo"),
-							optional: true,
-							property: &ExprPrivateIdentifier{
-								name: "bar",
+			Children: []Node{
+				&ExprChain{
+					Expression: &ExprMemberAccess{
+						Property: idExpr("c"),
+						Optional: true,
+						Object: &ExprCall{
+							Callee: &ExprMemberAccess{
+								Object:   idExpr("foo"),
+								Optional: true,
+								Property: &ExprPrivateIdentifier{
+									Name: "bar",
+								},
 							},
+							Arguments: []Expr{idExpr("a"), idExpr("b")},
 						},
-						arguments: []Expr{idExpr("a"), idExpr("b")},
 					},
 				},
 			},
@@ -945,11 +1065,11 @@ func TestAssignmentExpression(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `a = b`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					left:     idExpr("a"),
-					right:    idExpr("b"),
-					operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right:    idExpr("b"),
+					Operator: assignt.Token(),
 				},
 			},
 		}
@@ -961,23 +1081,23 @@ func TestAssignmentExpression(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `a = b = c = d = e = 20`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprAssign{
-						operator: assignt.Token(),
-						left:     idExpr("b"),
-						right: &ExprAssign{
-							operator: assignt.Token(),
-							left:     idExpr("c"),
-							right: &ExprAssign{
-								operator: assignt.Token(),
-								left:     idExpr("d"),
-								right: &ExprAssign{
-									operator: assignt.Token(),
-									left:     idExpr("e"),
-									right:    intExpr(20),
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprAssign{
+						Operator: assignt.Token(),
+						Left:     idExpr("b"),
+						Right: &ExprAssign{
+							Operator: assignt.Token(),
+							Left:     idExpr("c"),
+							Right: &ExprAssign{
+								Operator: assignt.Token(),
+								Left:     idExpr("d"),
+								Right: &ExprAssign{
+									Operator: assignt.Token(),
+									Left:     idExpr("e"),
+									Right:    intExpr(20),
 								},
 							},
 						},
@@ -995,11 +1115,11 @@ func TestAssignmentExpression(t *testing.T) {
 		for _, op := range assignmentOperators {
 			src := fmt.Sprintf(`a %s b`, op.S())
 			exp := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					&ExprAssign{
-						left:     idExpr("a"),
-						right:    idExpr("b"),
-						operator: op.Token(),
+						Left:     idExpr("a"),
+						Right:    idExpr("b"),
+						Operator: op.Token(),
 					},
 				},
 			}
@@ -1044,7 +1164,7 @@ func assertBinaryExprPrecedence(
 			// for example, if opLower is TPlus and opHigher is TRightShift
 			// equals to: ((a + (b / c)) + (d / e)) + f
 			expected := &NodeRoot{
-				children: []Node{
+				Children: []Node{
 					binLowerExpr(
 						binLowerExpr(
 							binLowerExpr(
@@ -1068,3 +1188,306 @@ func assertBinaryExprPrecedence(
 		}
 	}
 }
+
+func TestConditionalExpression(t *testing.T) {
+	t.Run("simple conditional", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a ? b : c`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprConditional{Test: idExpr("a"), Consequent: idExpr("b"), Alternate: idExpr("c")},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("conditional is right-associative", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a ? b : c ? d : e`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprConditional{
+					Test:       idExpr("a"),
+					Consequent: idExpr("b"),
+					Alternate:  &ExprConditional{Test: idExpr("c"), Consequent: idExpr("d"), Alternate: idExpr("e")},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("test binds tighter and branches are assignment expressions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a || b ? c = 1 : d => d`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprConditional{
+					Test:       binExpr(idExpr("a"), idExpr("b"), l.TLogicalOr),
+					Consequent: &ExprAssign{Operator: assignt.Token(), Left: idExpr("c"), Right: intExpr(1)},
+					Alternate:  &ExprArrowFunction{Params: []Node{idExpr("d")}, Expression: idExpr("d")},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("conditional is the right-hand side of an assignment", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `x = a?.b ? c : d`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left:     idExpr("x"),
+					Right: &ExprConditional{
+						Test:       &ExprChain{Expression: &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b"), Optional: true}},
+						Consequent: idExpr("c"),
+						Alternate:  idExpr("d"),
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestNullishCoalescing(t *testing.T) {
+	t.Run("coalesce is left-associative", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a ?? b ?? c`
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(binExpr(idExpr("a"), idExpr("b"), l.TDoubleQuestionMark), idExpr("c"), l.TDoubleQuestionMark),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("operands are bitwise OR expressions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a | b ?? c + d ? e : f`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprConditional{
+					Test: binExpr(
+						binExpr(idExpr("a"), idExpr("b"), l.TOr),
+						binExpr(idExpr("c"), idExpr("d"), l.TPlus),
+						l.TDoubleQuestionMark,
+					),
+					Consequent: idExpr("e"),
+					Alternate:  idExpr("f"),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("parenthesized logical operands may be mixed", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a || b) ?? (c && d)`
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(
+					&ExprParenthesized{Expression: binExpr(idExpr("a"), idExpr("b"), l.TLogicalOr)},
+					&ExprParenthesized{Expression: binExpr(idExpr("c"), idExpr("d"), l.TLogicalAnd)},
+					l.TDoubleQuestionMark,
+				),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestConditionalAndCoalesce_Rejected(t *testing.T) {
+	srcs := []string{
+		"(a ?? b || c)",
+		"(a || b ?? c)",
+		"(a ?? b && c)",
+		"(a && b ?? c)",
+		"(a ? b)",
+		"(a ? : c)",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	t.Run("chain spans the links after the optional one", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a?.b.c()`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprChain{
+					Expression: &ExprCall{
+						Callee: &ExprMemberAccess{
+							Object:   &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b"), Optional: true},
+							Property: idExpr("c"),
+						},
+						Arguments: []Expr{},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("optional links after plain ones", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a.b?.[x]?.()`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprChain{
+					Expression: &ExprCall{
+						Callee: &ExprMemberAccess{
+							Object:   &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b")},
+							Property: idExpr("x"),
+							Optional: true,
+						},
+						Arguments: []Expr{},
+						Optional:  true,
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("parentheses end the short-circuit", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `(a?.b).c`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprMemberAccess{
+					Object: &ExprParenthesized{
+						Expression: &ExprChain{
+							Expression: &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b"), Optional: true},
+						},
+					},
+					Property: idExpr("c"),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("reserved words as member names", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a?.default.catch`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprChain{
+					Expression: &ExprMemberAccess{
+						Object:   &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("default"), Optional: true},
+						Property: idExpr("catch"),
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("optional chain as an assignment value", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a.b = c?.d`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left:     &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b")},
+					Right: &ExprChain{
+						Expression: &ExprMemberAccess{Object: idExpr("c"), Property: idExpr("d"), Optional: true},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestOptionalChaining_Rejected(t *testing.T) {
+	srcs := []string{
+		"a?.b = 1",
+		"(a?.b = 1)",
+		"(a?.[b] += 1)",
+		"(a?.b++)",
+		"(--a?.b)",
+		"new a?.b()",
+		"new a?.()",
+		"a?.",
+		"a?.#",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
+
+func TestOptionalChaining_RejectedAt(t *testing.T) {
+	cases := []struct {
+		src    string
+		line   int
+		column int
+		err    string
+	}{
+		{"new a?.b()", 1, 5, "invalid optional chain from new expression"},
+		{"x = [new a.b?.c]", 1, 12, "invalid optional chain from new expression"},
+		{"a?.b`t`", 1, 4, "tagged template cannot be used in optional chain"},
+		{"f(a?.`t`)", 1, 5, "tagged template cannot be used in optional chain"},
+		{"super?.x", 1, 0, "'super' keyword unexpected here"},
+		{"o = { m() { return super?.x } }", 1, 19, "'super' keyword unexpected here"},
+	}
+	for _, c := range cases {
+		t.Run(c.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(c.src, logger).ScanAll()
+			_, err := NewParser(tokens, logger).parseProgram()
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != c.line || syntaxErr.Column != c.column || syntaxErr.Err.Error() != c.err {
+				t.Errorf("expected %d:%d: %s, got %v", c.line, c.column, c.err, syntaxErr)
+			}
+		})
+	}
+}
+
+func TestUpdateExpression(t *testing.T) {
+	t.Run("prefix and postfix operators", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a++; --b.c`
+		inc, dec := l.TPlusPlus, l.TMinusMinus
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprUnaryOp{Operator: inc.Token(), Operand: idExpr("a")},
+				&ExprUnaryOp{Operator: dec.Token(), Operand: &ExprMemberAccess{Object: idExpr("b"), Property: idExpr("c")}},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
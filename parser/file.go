@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// File is a parsed source file, along with what tools that print it back need
+// besides the AST.
+type File struct {
+	Program *NodeRoot
+	// Comments are the comments of the source text, in source order
+	Comments []l.Comment
+	// Spans holds the range of source text of every statement and function
+	// of Program, nested ones included. The span of a method starts at its
+	// parameters
+	Spans map[Node]Span
+	// Source is the source text the file was parsed from
+	Source string
+}
+
+// ParseFile parses src with the goal symbol and features set by options. Unlike
+// ParseWithOptions, it returns the first lexical or syntax error instead of
+// panicking, and keeps no debug logs. The errors of sources ending too early
+// match ErrUnexpectedEOF.
+func ParseFile(src string, options Options) (file *File, err error) {
+	logger := internal.NewSimpleLogger(internal.ModeError)
+	lexerOptions := l.Options{AnnexB: options.AnnexB && options.SourceType == SourceTypeScript}
+	lexer := l.NewLexerWithOptions(src, logger, lexerOptions)
+	tokens, errs := lexer.ScanAll()
+	if len(errs) > 0 {
+		return nil, lexicalError(errs[0])
+	}
+
+	parser := NewParser(tokens, logger)
+	parser.options = options
+	parser.spans = map[Node]Span{}
+	defer func() {
+		if stack := recover(); stack != nil {
+			file, err = nil, fmt.Errorf("parser: %v", stack)
+		}
+		if err != nil && parser.eof {
+			err = unexpectedEOF{err}
+		}
+	}()
+	program, err := parser.parseProgram()
+	if err != nil {
+		return nil, err
+	}
+	return &File{Program: program, Comments: lexer.Comments(), Spans: parser.spans, Source: src}, nil
+}
+
+// lexicalError returns the error of the lexer as a SyntaxError, which still
+// matches ErrUnexpectedEOF when the end of the source interrupted a token.
+func lexicalError(err error) error {
+	var lexErr *l.Error
+	if !errors.As(err, &lexErr) {
+		return err
+	}
+	positioned := &SyntaxError{Line: lexErr.Line, Column: lexErr.Column, Err: lexErr.Err}
+	if errors.Is(err, ErrUnexpectedEOF) {
+		return unexpectedEOF{positioned}
+	}
+	return positioned
+}
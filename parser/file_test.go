@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	t.Run("comments and statement spans", func(t *testing.T) {
+		src := "// leading\na = 1; /* trailing */\n\nif (b) {\n  debugger;\n}"
+		file, err := ParseFile(src, Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(file.Comments) != 2 || file.Comments[0].Text != "// leading" || file.Comments[1].Text != "/* trailing */" {
+			t.Fatalf("expected the two comments, got %v", file.Comments)
+		}
+
+		children := file.Program.Children
+		if len(children) != 2 {
+			t.Fatalf("expected 2 statements, got %d", len(children))
+		}
+		ifStmt := children[1].(*IfStatement)
+		debugger := ifStmt.ThenStmt.(*BlockStatement).Stmts[0]
+		expected := map[Node]string{
+			children[0]:     "2:0-2:6",
+			ifStmt:          "4:0-6:1",
+			ifStmt.ThenStmt: "4:7-6:1",
+			debugger:        "5:2-5:11",
+		}
+		for node, span := range expected {
+			got, ok := file.Spans[node]
+			if !ok {
+				t.Errorf("expected a span for %s", node.S())
+			} else if got.String() != span {
+				t.Errorf("expected %s to span %s, got %s", node.S(), span, got)
+			}
+		}
+	})
+
+	t.Run("module items", func(t *testing.T) {
+		file, err := ParseFile(`import a from "a"; export { a };`, Options{SourceType: SourceTypeModule})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, child := range file.Program.Children {
+			if _, ok := file.Spans[child]; !ok {
+				t.Errorf("expected a span for %s", child.S())
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{"a = /* b", "import a from;"} {
+			options := Options{SourceType: SourceTypeModule}
+			if _, err := ParseFile(src, options); err == nil {
+				t.Errorf("expected an error parsing %q", src)
+			}
+		}
+	})
+
+	t.Run("unexpected end of input", func(t *testing.T) {
+		tests := []struct {
+			src        string
+			incomplete bool
+		}{
+			{src: "function f() {", incomplete: true},
+			{src: "f(1,\n2", incomplete: true},
+			{src: "x = {a: 1,", incomplete: true},
+			{src: "[1, 2", incomplete: true},
+			{src: "1 +", incomplete: true},
+			{src: "a ? b :", incomplete: true},
+			{src: "if (x)", incomplete: true},
+			{src: "`a${", incomplete: true},
+			{src: "/* comment", incomplete: true},
+			{src: "'abc\\", incomplete: true},
+			{src: "({a", incomplete: true},
+			{src: "f(1 2)"},
+			{src: "let = 1;"},
+			{src: "a)"},
+			{src: "a b)"},
+			{src: "[a b"},
+			{src: "'abc\n"},
+		}
+		for _, tc := range tests {
+			_, err := ParseFile(tc.src, Options{AnnexB: true})
+			if err == nil {
+				t.Errorf("expected an error parsing %q", tc.src)
+			} else if errors.Is(err, ErrUnexpectedEOF) != tc.incomplete {
+				t.Errorf("expected %q to be incomplete: %v, got %v", tc.src, tc.incomplete, err)
+			}
+		}
+	})
+	t.Run("deep nesting", func(t *testing.T) {
+		// each level used to be parsed several times, which took exponential time
+		nest := func(n int, open, inner, close string) string {
+			return strings.Repeat(open, n) + inner + strings.Repeat(close, n)
+		}
+		for _, src := range []string{
+			nest(200, "(", "a", ")"),
+			nest(200, "a(function () { return ", "1", " })"),
+			nest(200, "(a) => (", "a", ")"),
+			nest(200, "a = (", "b", ")"),
+			nest(200, "[", "a", "]") + " = b",
+		} {
+			if _, err := ParseFile(src, Options{}); err != nil {
+				t.Errorf("unexpected error parsing %.20q...: %v", src, err)
+			}
+		}
+		for _, src := range []string{nest(200, "(", "a +", ")"), strings.Repeat("(", 200) + "a"} {
+			if _, err := ParseFile(src, Options{}); err == nil {
+				t.Errorf("expected an error parsing %.20q...", src)
+			}
+		}
+	})
+}
@@ -0,0 +1,589 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+const (
+	SImport StmtType = "SImport"
+	SExport StmtType = "SExport"
+)
+
+// //////////////////
+// ImportSpecifier //
+// //////////////////
+type ImportSpecifierKind int
+
+const (
+	ImportDefault   ImportSpecifierKind = iota // import a from "m"
+	ImportNamespace                            // import * as a from "m"
+	ImportNamed                                // import { a as b } from "m"
+)
+
+type ImportSpecifier struct {
+	Kind     ImportSpecifierKind
+	Imported Expr // ModuleExportName of named imports: IdentifierName | StringLiteral
+	Local    *ExprIdentifier
+}
+
+func (s *ImportSpecifier) S() string {
+	switch s.Kind {
+	case ImportDefault:
+		return fmt.Sprintf("(default %s)", s.Local.S())
+	case ImportNamespace:
+		return fmt.Sprintf("(* %s)", s.Local.S())
+	default:
+		return fmt.Sprintf("(%s as %s)", s.Imported.S(), s.Local.S())
+	}
+}
+
+// //////////////////
+// ImportAttribute //
+// //////////////////
+type ImportAttribute struct {
+	Key   Expr // IdentifierName | StringLiteral
+	Value Expr // StringLiteral
+}
+
+func (a *ImportAttribute) S() string {
+	return fmt.Sprintf("(%s %s)", a.Key.S(), a.Value.S())
+}
+
+func attributesS(attributes []*ImportAttribute) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+	src := strings.Builder{}
+	src.WriteString(" (with")
+	for _, attribute := range attributes {
+		src.WriteString(" ")
+		src.WriteString(attribute.S())
+	}
+	src.WriteString(")")
+	return src.String()
+}
+
+// ////////////////////
+// ImportDeclaration //
+// ////////////////////
+type ImportDeclaration struct {
+	Specifiers []*ImportSpecifier
+	Source     Expr
+	Attributes []*ImportAttribute
+}
+
+func (s *ImportDeclaration) Type() StmtType { return SImport }
+func (s *ImportDeclaration) S() string {
+	src := strings.Builder{}
+	src.WriteString("(import ")
+	src.WriteString(s.Source.S())
+	for _, specifier := range s.Specifiers {
+		src.WriteString(" ")
+		src.WriteString(specifier.S())
+	}
+	src.WriteString(attributesS(s.Attributes))
+	src.WriteString(")")
+	return src.String()
+}
+
+// //////////////////
+// ExportSpecifier //
+// //////////////////
+type ExportSpecifier struct {
+	Local    Expr // ModuleExportName: IdentifierName | StringLiteral
+	Exported Expr // ModuleExportName: IdentifierName | StringLiteral
+}
+
+func (s *ExportSpecifier) S() string {
+	return fmt.Sprintf("(%s as %s)", s.Local.S(), s.Exported.S())
+}
+
+// /////////////////////////
+// ExportNamedDeclaration //
+// /////////////////////////
+type ExportNamedDeclaration struct {
+	Declaration Stmt // export var a; export function f() {}
+	Specifiers  []*ExportSpecifier
+	Source      Expr // export { a } from "m"
+	Attributes  []*ImportAttribute
+}
+
+func (s *ExportNamedDeclaration) Type() StmtType { return SExport }
+func (s *ExportNamedDeclaration) S() string {
+	if s.Declaration != nil {
+		return fmt.Sprintf("(export %s)", s.Declaration.S())
+	}
+
+	src := strings.Builder{}
+	src.WriteString("(export")
+	for _, specifier := range s.Specifiers {
+		src.WriteString(" ")
+		src.WriteString(specifier.S())
+	}
+	if s.Source != nil {
+		src.WriteString(" from ")
+		src.WriteString(s.Source.S())
+	}
+	src.WriteString(attributesS(s.Attributes))
+	src.WriteString(")")
+	return src.String()
+}
+
+// ///////////////////////////
+// ExportDefaultDeclaration //
+// ///////////////////////////
+type ExportDefaultDeclaration struct {
+	Declaration Node // HoistableDeclaration[+Default] | AssignmentExpression
+	Default     Span // the 'default' keyword, which is the exported name
+}
+
+func (s *ExportDefaultDeclaration) Type() StmtType { return SExport }
+func (s *ExportDefaultDeclaration) S() string {
+	return fmt.Sprintf("(export default %s)", s.Declaration.S())
+}
+
+// ///////////////////////
+// ExportAllDeclaration //
+// ///////////////////////
+type ExportAllDeclaration struct {
+	Exported   Expr // export * as ns from "m"; nil on export * from "m"
+	Source     Expr
+	Attributes []*ImportAttribute
+}
+
+func (s *ExportAllDeclaration) Type() StmtType { return SExport }
+func (s *ExportAllDeclaration) S() string {
+	star := "*"
+	if s.Exported != nil {
+		star = fmt.Sprintf("(* as %s)", s.Exported.S())
+	}
+	return fmt.Sprintf("(export %s from %s%s)", star, s.Source.S(), attributesS(s.Attributes))
+}
+
+// isModuleItem reports whether the cursor is at an ImportDeclaration or an
+// ExportDeclaration, as opposed to ImportCall and import.meta expressions.
+func (p *Parser) isModuleItem() bool {
+	switch p.Peek().Type {
+	case l.TExport:
+		return true
+	case l.TImport:
+		next := p.PeekN(1).Type
+		return next != l.TLeftParen && next != l.TPeriod
+	}
+	return false
+}
+
+// isContextualKeyword reports whether token is the given keyword that is
+// not reserved, e.g. 'from', 'as' and 'of'.
+func isContextualKeyword(token l.Token, keyword string) bool {
+	return token.Type == l.TIdentifier && token.Lexeme == keyword
+}
+
+func isStringLiteral(token l.Token) bool {
+	return token.Type == l.TStringLiteral_DoubleQuote || token.Type == l.TStringLiteral_SingleQuote
+}
+
+func (p *Parser) parseModuleItem() (Stmt, error) {
+	token := p.Peek()
+	if !p.isModule() {
+		return nil, fmt.Errorf("'%s' declarations may only appear at the top level of a module", token.Lexeme)
+	}
+
+	switch token.Type {
+	case l.TImport:
+		return p.parseImportDeclaration()
+	case l.TExport:
+		return p.parseExportDeclaration()
+	}
+	return nil, p.needToken(fmt.Errorf("expected 'import' or 'export', got %s", token.Lexeme))
+}
+
+// ImportDeclaration :
+// | 'import' ImportClause FromClause WithClause? ';'
+// | 'import' ModuleSpecifier WithClause? ';'
+//
+// ImportClause :
+// | ImportedDefaultBinding
+// | NameSpaceImport
+// | NamedImports
+// | ImportedDefaultBinding ',' NameSpaceImport
+// | ImportedDefaultBinding ',' NamedImports
+//
+// NameSpaceImport :
+// | '*' 'as' ImportedBinding
+//
+// FromClause :
+// | 'from' ModuleSpecifier
+func (p *Parser) parseImportDeclaration() (*ImportDeclaration, error) {
+	p.Log("parseImportDeclaration")
+	if p.Peek().Type != l.TImport {
+		return nil, p.needToken(fmt.Errorf("expected 'import', got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume 'import'
+
+	importDecl := &ImportDeclaration{Specifiers: []*ImportSpecifier{}}
+	if !isStringLiteral(p.Peek()) {
+		hasClause := false
+		// ImportDeclaration : 'import' ImportClause FromClause
+		if token := p.Peek(); p.isIdentifier(token) {
+			p.Next() // consume ImportedDefaultBinding
+			hasClause = true
+			importDecl.Specifiers = append(importDecl.Specifiers, &ImportSpecifier{
+				Kind:  ImportDefault,
+				Local: newIdentifier(token),
+			})
+			if p.Peek().Type == l.TComma {
+				p.Next() // consume ','
+			} else if !isContextualKeyword(p.Peek(), "from") {
+				return nil, p.needToken(fmt.Errorf("expected ',' or 'from' after default import, got %s", p.Peek().Lexeme))
+			}
+		}
+
+		switch p.Peek().Type {
+		case l.TStar:
+			p.Next() // consume '*'
+			if !isContextualKeyword(p.Peek(), "as") {
+				return nil, p.needToken(fmt.Errorf("expected 'as' after '*', got %s", p.Peek().Lexeme))
+			}
+			p.Next() // consume 'as'
+			local, err := p.parseImportedBinding()
+			if err != nil {
+				return nil, err
+			}
+			hasClause = true
+			importDecl.Specifiers = append(importDecl.Specifiers, &ImportSpecifier{
+				Kind:  ImportNamespace,
+				Local: local,
+			})
+		case l.TLeftBrace:
+			specifiers, err := p.parseNamedImports()
+			if err != nil {
+				return nil, err
+			}
+			hasClause = true
+			importDecl.Specifiers = append(importDecl.Specifiers, specifiers...)
+		}
+
+		if !hasClause || p.PeekN(-1).Type == l.TComma {
+			return nil, p.needToken(fmt.Errorf("expected import clause, got %s", p.Peek().Lexeme))
+		}
+		if !isContextualKeyword(p.Peek(), "from") {
+			return nil, p.needToken(fmt.Errorf("expected 'from' after import clause, got %s", p.Peek().Lexeme))
+		}
+		p.Next() // consume 'from'
+	}
+
+	source, err := p.parseModuleSpecifier()
+	if err != nil {
+		return nil, err
+	}
+	importDecl.Source = source
+
+	if importDecl.Attributes, err = p.parseWithClause(); err != nil {
+		return nil, err
+	}
+	if p.Peek().Type == l.TSemicolon {
+		p.Next() // consume ';'
+	}
+	return importDecl, nil
+}
+
+// ImportedBinding :
+// | BindingIdentifier[~Yield, +Await]
+func (p *Parser) parseImportedBinding() (*ExprIdentifier, error) {
+	token := p.Peek()
+	if !p.isIdentifier(token) {
+		return nil, p.needToken(fmt.Errorf("expected identifier for imported binding, got %s", token.Lexeme))
+	}
+	p.Next() // consume identifier
+	return newIdentifier(token), nil
+}
+
+// NamedImports :
+// | '{' '}'
+// | '{' ImportsList ','? '}'
+//
+// ImportSpecifier :
+// | ImportedBinding
+// | ModuleExportName 'as' ImportedBinding
+func (p *Parser) parseNamedImports() ([]*ImportSpecifier, error) {
+	p.Next() // consume '{'
+
+	specifiers := []*ImportSpecifier{}
+	for p.Peek().Type != l.TRightBrace {
+		token := p.Peek()
+		imported, err := p.parseModuleExportName()
+		if err != nil {
+			return nil, err
+		}
+
+		specifier := &ImportSpecifier{Kind: ImportNamed, Imported: imported}
+		if isContextualKeyword(p.Peek(), "as") {
+			p.Next() // consume 'as'
+			if specifier.Local, err = p.parseImportedBinding(); err != nil {
+				return nil, err
+			}
+		} else if p.isIdentifier(token) {
+			specifier.Local = newIdentifier(token)
+		} else {
+			return nil, p.needToken(fmt.Errorf("expected 'as' after %s, it can't be used as a binding", token.Lexeme))
+		}
+		specifiers = append(specifiers, specifier)
+
+		if p.Peek().Type == l.TComma {
+			p.Next() // consume ','
+		} else if p.Peek().Type != l.TRightBrace {
+			return nil, p.needToken(fmt.Errorf("expected ',' or '}' in named imports, got %s", p.Peek().Lexeme))
+		}
+	}
+	p.Next() // consume '}'
+	return specifiers, nil
+}
+
+// ModuleExportName :
+// | IdentifierName
+// | StringLiteral
+func (p *Parser) parseModuleExportName() (Expr, error) {
+	token := p.Peek()
+	if isStringLiteral(token) {
+		p.Next() // consume string
+		return &ExprLiteral[string]{Token: token}, nil
+	}
+	if _, reserved := l.ReservedWordNames[token.Type]; reserved || token.Type == l.TIdentifier {
+		p.Next() // consume IdentifierName
+		return newIdentifier(token), nil
+	}
+	return nil, p.needToken(fmt.Errorf("expected identifier or string as module export name, got %s", token.Lexeme))
+}
+
+// ModuleSpecifier :
+// | StringLiteral
+func (p *Parser) parseModuleSpecifier() (Expr, error) {
+	token := p.Peek()
+	if !isStringLiteral(token) {
+		return nil, p.needToken(fmt.Errorf("expected module specifier string, got %s", token.Lexeme))
+	}
+	p.Next() // consume string
+	return &ExprLiteral[string]{Token: token}, nil
+}
+
+// WithClause :
+// | 'with' '{' '}'
+// | 'with' '{' WithEntries ','? '}'
+//
+// WithEntries :
+// | AttributeKey ':' StringLiteral
+// | AttributeKey ':' StringLiteral ',' WithEntries
+//
+// AttributeKey :
+// | IdentifierName
+// | StringLiteral
+func (p *Parser) parseWithClause() ([]*ImportAttribute, error) {
+	if p.Peek().Type != l.TWith {
+		return nil, nil
+	}
+	p.Next() // consume 'with'
+	if p.Peek().Type != l.TLeftBrace {
+		return nil, p.needToken(fmt.Errorf("expected '{' after 'with', got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume '{'
+
+	attributes := []*ImportAttribute{}
+	for p.Peek().Type != l.TRightBrace {
+		key, err := p.parseModuleExportName()
+		if err != nil {
+			return nil, err
+		}
+		for _, attribute := range attributes {
+			if attribute.Key.S() == key.S() {
+				return nil, fmt.Errorf("duplicate import attribute %s", key.S())
+			}
+		}
+		if p.Peek().Type != l.TColon {
+			return nil, p.needToken(fmt.Errorf("expected ':' after import attribute key, got %s", p.Peek().Lexeme))
+		}
+		p.Next() // consume ':'
+		value := p.Peek()
+		if !isStringLiteral(value) {
+			return nil, p.needToken(fmt.Errorf("expected string as import attribute value, got %s", value.Lexeme))
+		}
+		p.Next() // consume string
+		attributes = append(attributes, &ImportAttribute{Key: key, Value: &ExprLiteral[string]{Token: value}})
+
+		if p.Peek().Type == l.TComma {
+			p.Next() // consume ','
+		} else if p.Peek().Type != l.TRightBrace {
+			return nil, p.needToken(fmt.Errorf("expected ',' or '}' in import attributes, got %s", p.Peek().Lexeme))
+		}
+	}
+	p.Next() // consume '}'
+	return attributes, nil
+}
+
+// ExportDeclaration :
+// | 'export' ExportFromClause FromClause WithClause? ';'
+// | 'export' NamedExports ';'
+// | 'export' VariableStatement[~Yield, +Await]
+// | 'export' Declaration[~Yield, +Await]
+// | 'export' 'default' HoistableDeclaration[~Yield, +Await, +Default]
+// | 'export' 'default' ClassDeclaration[~Yield, +Await, +Default] (TODO)
+// | 'export' 'default' [lookahead ∉ { function, async function, class }] AssignmentExpression[+In, ~Yield, +Await] ';'
+//
+// ExportFromClause :
+// | '*'
+// | '*' 'as' ModuleExportName
+// | NamedExports
+func (p *Parser) parseExportDeclaration() (Stmt, error) {
+	p.Log("parseExportDeclaration")
+	if p.Peek().Type != l.TExport {
+		return nil, p.needToken(fmt.Errorf("expected 'export', got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume 'export'
+
+	var (
+		stmt Stmt
+		err  error
+	)
+	switch token := p.Peek(); token.Type {
+	case l.TStar:
+		stmt, err = p.parseExportAll()
+	case l.TLeftBrace:
+		stmt, err = p.parseExportNamed()
+	case l.TDefault:
+		stmt, err = p.parseExportDefault()
+	case l.TVar, l.TLet, l.TConst:
+		var varStmt *VariableStatement
+		if varStmt, err = p.parseVariableStatement(); err == nil {
+			stmt = &ExportNamedDeclaration{Declaration: varStmt}
+		}
+	case l.TFunction, l.TAsync:
+		var fnDecl Node
+		if fnDecl, err = p.parseFunctionDeclaration(); err == nil {
+			fn := fnDecl.(*FunctionDeclarationStmt)
+			if fn.BindingIdentifier == nil {
+				return nil, fmt.Errorf("exported function declarations must have a name")
+			}
+			stmt = &ExportNamedDeclaration{Declaration: fn}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected token after 'export': %s", token.Lexeme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek().Type == l.TSemicolon {
+		p.Next() // consume ';'
+	}
+	return stmt, nil
+}
+
+// 'export' '*' ('as' ModuleExportName)? FromClause WithClause?
+func (p *Parser) parseExportAll() (*ExportAllDeclaration, error) {
+	p.Next() // consume '*'
+
+	exportAll := &ExportAllDeclaration{}
+	if isContextualKeyword(p.Peek(), "as") {
+		p.Next() // consume 'as'
+		exported, err := p.parseModuleExportName()
+		if err != nil {
+			return nil, err
+		}
+		exportAll.Exported = exported
+	}
+
+	if !isContextualKeyword(p.Peek(), "from") {
+		return nil, p.needToken(fmt.Errorf("expected 'from' after 'export *', got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume 'from'
+
+	source, err := p.parseModuleSpecifier()
+	if err != nil {
+		return nil, err
+	}
+	exportAll.Source = source
+	if exportAll.Attributes, err = p.parseWithClause(); err != nil {
+		return nil, err
+	}
+	return exportAll, nil
+}
+
+// NamedExports :
+// | '{' '}'
+// | '{' ExportsList ','? '}'
+//
+// ExportSpecifier :
+// | ModuleExportName
+// | ModuleExportName 'as' ModuleExportName
+func (p *Parser) parseExportNamed() (*ExportNamedDeclaration, error) {
+	p.Next() // consume '{'
+
+	// without a FromClause, the exported names must refer to local bindings
+	var localTokens []l.Token
+	exportNamed := &ExportNamedDeclaration{Specifiers: []*ExportSpecifier{}}
+	for p.Peek().Type != l.TRightBrace {
+		localTokens = append(localTokens, p.Peek())
+		local, err := p.parseModuleExportName()
+		if err != nil {
+			return nil, err
+		}
+
+		specifier := &ExportSpecifier{Local: local, Exported: local}
+		if isContextualKeyword(p.Peek(), "as") {
+			p.Next() // consume 'as'
+			if specifier.Exported, err = p.parseModuleExportName(); err != nil {
+				return nil, err
+			}
+		}
+		exportNamed.Specifiers = append(exportNamed.Specifiers, specifier)
+
+		if p.Peek().Type == l.TComma {
+			p.Next() // consume ','
+		} else if p.Peek().Type != l.TRightBrace {
+			return nil, p.needToken(fmt.Errorf("expected ',' or '}' in named exports, got %s", p.Peek().Lexeme))
+		}
+	}
+	p.Next() // consume '}'
+
+	if isContextualKeyword(p.Peek(), "from") {
+		p.Next() // consume 'from'
+		source, err := p.parseModuleSpecifier()
+		if err != nil {
+			return nil, err
+		}
+		exportNamed.Source = source
+		if exportNamed.Attributes, err = p.parseWithClause(); err != nil {
+			return nil, err
+		}
+		return exportNamed, nil
+	}
+
+	for _, token := range localTokens {
+		if !p.isIdentifier(token) {
+			return nil, fmt.Errorf("%s is not a local binding and can't be exported without 'from'", token.Lexeme)
+		}
+	}
+	return exportNamed, nil
+}
+
+// 'export' 'default' HoistableDeclaration[~Yield, +Await, +Default]
+// 'export' 'default' [lookahead ∉ { function, async function, class }] AssignmentExpression[+In, ~Yield, +Await] ';'
+func (p *Parser) parseExportDefault() (*ExportDefaultDeclaration, error) {
+	keyword := tokenSpan(p.Peek())
+	p.Next() // consume 'default'
+
+	cp := p.saveCheckpoint()
+	if fnDecl, err := p.parseFunctionDeclaration(); err == nil {
+		return &ExportDefaultDeclaration{Declaration: fnDecl, Default: keyword}, nil
+	}
+	p.restoreCheckpoint(cp)
+
+	expr, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ExportDefaultDeclaration{Declaration: expr, Default: keyword}, nil
+}
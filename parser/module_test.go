@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+var moduleOptions = Options{SourceType: SourceTypeModule}
+
+func moduleSpecifier(s string) *ExprLiteral[string] {
+	return stringExpr(s)
+}
+
+func TestImportDeclaration(t *testing.T) {
+	t.Run("all import forms", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `import "side-effect";
+import a from "a";
+import * as ns from "ns";
+import { b, c as d, default as e, "string name" as f, } from 'named';
+import g, * as h from "mixed";
+import i, { j } from "mixed";
+import {} from "empty";`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ImportDeclaration{Source: moduleSpecifier(`"side-effect"`)},
+				&ImportDeclaration{
+					Source:     moduleSpecifier(`"a"`),
+					Specifiers: []*ImportSpecifier{{Kind: ImportDefault, Local: idExpr("a")}},
+				},
+				&ImportDeclaration{
+					Source:     moduleSpecifier(`"ns"`),
+					Specifiers: []*ImportSpecifier{{Kind: ImportNamespace, Local: idExpr("ns")}},
+				},
+				&ImportDeclaration{
+					Source: moduleSpecifier(`'named'`),
+					Specifiers: []*ImportSpecifier{
+						{Kind: ImportNamed, Imported: idExpr("b"), Local: idExpr("b")},
+						{Kind: ImportNamed, Imported: idExpr("c"), Local: idExpr("d")},
+						{Kind: ImportNamed, Imported: idExpr("default"), Local: idExpr("e")},
+						{Kind: ImportNamed, Imported: stringExpr(`"string name"`), Local: idExpr("f")},
+					},
+				},
+				&ImportDeclaration{
+					Source: moduleSpecifier(`"mixed"`),
+					Specifiers: []*ImportSpecifier{
+						{Kind: ImportDefault, Local: idExpr("g")},
+						{Kind: ImportNamespace, Local: idExpr("h")},
+					},
+				},
+				&ImportDeclaration{
+					Source: moduleSpecifier(`"mixed"`),
+					Specifiers: []*ImportSpecifier{
+						{Kind: ImportDefault, Local: idExpr("i")},
+						{Kind: ImportNamed, Imported: idExpr("j"), Local: idExpr("j")},
+					},
+				},
+				&ImportDeclaration{Source: moduleSpecifier(`"empty"`)},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("import attributes", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `import data from "./data.json" with { type: "json" }; export * from "./x.json" with { "type": 'json' }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ImportDeclaration{
+					Source:     moduleSpecifier(`"./data.json"`),
+					Specifiers: []*ImportSpecifier{{Kind: ImportDefault, Local: idExpr("data")}},
+					Attributes: []*ImportAttribute{{Key: idExpr("type"), Value: stringExpr(`"json"`)}},
+				},
+				&ExportAllDeclaration{
+					Source:     moduleSpecifier(`"./x.json"`),
+					Attributes: []*ImportAttribute{{Key: stringExpr(`"type"`), Value: stringExpr(`'json'`)}},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+}
+
+func TestExportDeclaration(t *testing.T) {
+	t.Run("named exports and re-exports", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `export { a, b as c };
+export { default, d as "string name" } from "m";
+export * from "all";
+export * as ns from "all";`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExportNamedDeclaration{
+					Specifiers: []*ExportSpecifier{
+						{Local: idExpr("a"), Exported: idExpr("a")},
+						{Local: idExpr("b"), Exported: idExpr("c")},
+					},
+				},
+				&ExportNamedDeclaration{
+					Source: moduleSpecifier(`"m"`),
+					Specifiers: []*ExportSpecifier{
+						{Local: idExpr("default"), Exported: idExpr("default")},
+						{Local: idExpr("d"), Exported: stringExpr(`"string name"`)},
+					},
+				},
+				&ExportAllDeclaration{Source: moduleSpecifier(`"all"`)},
+				&ExportAllDeclaration{Source: moduleSpecifier(`"all"`), Exported: idExpr("ns")},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("exported declarations", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `export const a = 1; export function f() {} export async function* g() {}`
+		tconst := l.TConst
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExportNamedDeclaration{
+					Declaration: &VariableStatement{
+						Kind:         tconst.Token(),
+						Declarations: []*VariableDeclaration{{Identifier: idExpr("a"), Init: intExpr(1)}},
+					},
+				},
+				&ExportNamedDeclaration{
+					Declaration: &FunctionDeclarationStmt{BindingIdentifier: idExpr("f"), Params: []Node{}},
+				},
+				&ExportNamedDeclaration{
+					Declaration: &FunctionDeclarationStmt{BindingIdentifier: idExpr("g"), Params: []Node{}, Async: true, Generator: true},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("default exports", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `export default function () {}
+export default a + 1;
+export default async () => {};`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExportDefaultDeclaration{
+					Declaration: &FunctionDeclarationStmt{Params: []Node{}},
+				},
+				&ExportDefaultDeclaration{
+					Declaration: binExpr(idExpr("a"), intExpr(1), l.TPlus),
+				},
+				&ExportDefaultDeclaration{
+					Declaration: &ExprArrowFunction{Params: []Node{}, Body: []Stmt{}, Async: true},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+}
+
+func TestModuleFeatures(t *testing.T) {
+	t.Run("top-level await and import.meta", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `await import(import.meta.url)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAwait{
+					Argument: &ExprImportCall{
+						Source: &ExprMemberAccess{
+							Object: &ExprMetaProperty{
+								Meta:     idExpr("import"),
+								Property: idExpr("meta"),
+							},
+							Property: idExpr("url"),
+						},
+					},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, moduleOptions)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("await is an identifier in scripts", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `await`
+		exp := &NodeRoot{Children: []Node{idExpr("await")}}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestModule_Rejected(t *testing.T) {
+	cases := []struct {
+		src     string
+		options Options
+	}{
+		{`import a from "a"`, Options{}},
+		{`export const a = 1`, Options{}},
+		{`import.meta`, Options{}},
+		{`{ import a from "a" }`, moduleOptions},
+		{`function f() { export { a } }`, moduleOptions},
+		{`import { default } from "m"`, moduleOptions},
+		{`import { "a" } from "m"`, moduleOptions},
+		{`import * from "m"`, moduleOptions},
+		{`import a from b`, moduleOptions},
+		{`export { "a" }`, moduleOptions},
+		{`export { default }`, moduleOptions},
+		{`export function () {}`, moduleOptions},
+		{`export * as ns`, moduleOptions},
+		{`import a from "a" with { type: "json", type: "json" }`, moduleOptions},
+		{`var await`, moduleOptions},
+		{`export function f() { var await }`, moduleOptions},
+		{`function f(await) {}`, moduleOptions},
+		{`class C { m() { await: ; } }`, moduleOptions},
+	}
+	for _, c := range cases {
+		t.Run(c.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(c.src, logger).ScanAll()
+			parser := NewParser(tokens, logger)
+			parser.options = c.options
+			if _, err := parser.parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", c.src)
+			}
+		})
+	}
+}
@@ -12,17 +12,57 @@ import (
 // /////////////////////
 const EPropertyDefinition ExprType = "EPropertyDefinition"
 
+type PropertyKind int
+
+const (
+	PropertyInit PropertyKind = iota // { foo: 1 }, { foo() {} }
+	PropertyGet                      // { get foo() {} }
+	PropertySet                      // { set foo(v) {} }
+)
+
 type PropertyDefinition struct {
-	key       Expr
-	value     Expr
-	computed  bool // { [foo]: 1 }
-	method    bool // { foo() {} }
-	shorthand bool // { foo }
+	Key       Expr
+	Value     Expr
+	Kind      PropertyKind
+	Computed  bool // { [foo]: 1 }
+	Method    bool // { foo() {} }
+	Shorthand bool // { foo }, or the CoverInitializedName { foo = 1 }
 }
 
 func (p *PropertyDefinition) Type() ExprType { return EPropertyDefinition }
 func (p *PropertyDefinition) S() string {
-	return fmt.Sprintf("(k:%s v:%s (%v %v %v))", p.key.S(), p.value.S(), p.computed, p.method, p.shorthand)
+	prop := fmt.Sprintf("k:%s v:%s (%v %v %v)", p.Key.S(), p.Value.S(), p.Computed, p.Method, p.Shorthand)
+	switch p.Kind {
+	case PropertyGet:
+		return fmt.Sprintf("(get %s)", prop)
+	case PropertySet:
+		return fmt.Sprintf("(set %s)", prop)
+	}
+	return fmt.Sprintf("(%s)", prop)
+}
+
+// isCoverInitializedName reports whether p is a CoverInitializedName, e.g.
+// { foo = 1 }, which is only valid once the object is reinterpreted as an
+// ObjectAssignmentPattern.
+func (p *PropertyDefinition) isCoverInitializedName() bool {
+	_, initialized := p.Value.(*ExprAssign)
+	return p.Shorthand && initialized
+}
+
+// isProtoSetter reports whether p is a `__proto__: value` definition, which
+// sets the object's prototype instead of defining a property.
+func (p *PropertyDefinition) isProtoSetter() bool {
+	if p.Computed || p.Method || p.Shorthand || p.Kind != PropertyInit {
+		return false
+	}
+	switch key := p.Key.(type) {
+	case *ExprIdentifier:
+		return key.Name == "__proto__"
+	case *ExprLiteral[string]:
+		lexeme := key.Token.Lexeme
+		return len(lexeme) >= 2 && lexeme[1:len(lexeme)-1] == "__proto__"
+	}
+	return false
 }
 
 // /////////////
@@ -31,7 +71,10 @@ func (p *PropertyDefinition) S() string {
 const EObjectInitialization ExprType = "EObjectInitialization"
 
 type ExprObject struct {
-	properties []*PropertyDefinition
+	Properties []*PropertyDefinition
+	// whether a ',' ends the properties, which it can't after the rest
+	// property of an ObjectAssignmentPattern
+	trailingComma bool
 }
 
 func (e *ExprObject) Type() ExprType {
@@ -41,9 +84,9 @@ func (e *ExprObject) Type() ExprType {
 func (e *ExprObject) S() string {
 	src := strings.Builder{}
 	src.Write([]byte("(dict "))
-	for i, prop := range e.properties {
+	for i, prop := range e.Properties {
 		src.Write([]byte(prop.S()))
-		if i < len(e.properties)-1 {
+		if i < len(e.Properties)-1 {
 			src.Write([]byte(" "))
 		}
 	}
@@ -62,9 +105,9 @@ func (e *ExprObject) S() string {
 // | IdentifierReference
 // | IdentifierReference '=' AssignmentExpression
 // | PropertyName ':' AssignmentExpression
-// | MethodDefinition (TODO)
+// | MethodDefinition
 // | '...' AssignmentExpression
-// | CoverInitializedName (TODO)
+// | CoverInitializedName
 //
 // PropertyName :
 // | LiteralPropertyName
@@ -84,25 +127,25 @@ func (p *Parser) parseObjectInitializer() (Expr, error) {
 	if p.Peek().Type == l.TLeftBrace {
 		p.Next() // consume '{'
 
-	loop:
 		for {
 			switch token := p.Peek(); token.Type {
 			case l.TEOF:
-				break loop
+				return nil, p.needToken(fmt.Errorf("expected '}' at the end of the object literal"))
 			case l.TRightBrace:
 				p.Next() // consume '}'
 				return &exprObject, nil
 			case l.TComma:
 				p.Next() // consume ','
+				exprObject.trailingComma = true
 			default:
 				propDef, err := p.parsePropertyDefinition()
 				if err != nil {
 					return nil, err
 				}
-				exprObject.properties = append(exprObject.properties, propDef)
+				exprObject.Properties = append(exprObject.Properties, propDef)
+				exprObject.trailingComma = false
 			}
 		}
-		return &exprObject, nil
 	}
 	return nil, fmt.Errorf("rejected on parseObjectInitializer")
 }
@@ -112,68 +155,107 @@ func (p *Parser) parseObjectInitializer() (Expr, error) {
 // | (Identifier | StringLiteral| NumericLiteral | ComputedPropertyName) ':' AssignmentExpression
 // | MethodDefinition
 // | '...' AssignmentExpression
+//
+// Property values are parsed as possible assignment patterns, so it is up to
+// the caller to reject CoverInitializedNames, see checkCoverGrammar.
 func (p *Parser) parsePropertyDefinition() (*PropertyDefinition, error) {
 	var err error
+
+	// MethodDefinition : 'get' ClassElementName | 'set' ClassElementName
+	if kind, ok := p.parseAccessorPrefix(); ok {
+		propName, computed, err := p.parsePropertyName()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseAccessor(propName, computed, kind)
+	}
+
+	// MethodDefinition : GeneratorMethod | AsyncMethod | AsyncGeneratorMethod
+	if generator, async := p.parseMethodPrefix(); generator || async {
+		propName, computed, err := p.parsePropertyName()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseMethodDefinition(propName, computed, generator, async)
+	}
+
 	propName, computed, err := p.parsePropertyName()
 	if err != nil {
 		// PropertyDefinition : '...' AssignmentExpression
 		if p.Peek().Type == l.TEllipsis {
 			p.Next() // consume '...'
-			expr, err := p.parseAssignExpr()
+			expr, err := p.parseCoverAssignExpr()
 			if err != nil {
 				return nil, err
 			}
 			// TODO: reduce this to a single Expr
-			return &PropertyDefinition{key: expr, value: &SpreadElement{argument: expr}}, nil
+			return &PropertyDefinition{Key: expr, Value: &SpreadElement{Argument: expr}}, nil
 		}
 		return nil, err
 	}
 
 	// continuation of Identifier
 	switch token := p.Peek(); token.Type {
-	case l.TEqual:
-		// PropertyDefinition : (('yield' | 'await')? Identifier) ('=' AssignmentExpression)?
+	case l.TAssign:
+		// CoverInitializedName : IdentifierReference '=' AssignmentExpression
+		if computed || !p.isIdentifier(p.PeekN(-1)) {
+			return nil, fmt.Errorf("can't use %s as a shorthand property", propName.S())
+		}
 		p.Next() // consume '='
 		expr, err := p.parseAssignExpr()
 		if err != nil {
 			return nil, err
 		}
-		return &PropertyDefinition{key: &ExprIdentifier{name: token.Lexeme}, value: expr, shorthand: true}, nil
+		initializer := &ExprAssign{Operator: token, Left: propName, Right: expr}
+		return &PropertyDefinition{Key: propName, Value: initializer, Shorthand: true}, nil
 	case l.TColon:
 		// PropertyDefinition : (Identifier | StringLiteral| NumericLiteral | ComputedPropertyName) ':' AssignmentExpression
 		p.Next() // consume ':'
-		expr, err := p.parseAssignExpr()
+		expr, err := p.parseCoverAssignExpr()
 		if err != nil {
 			return nil, err
 		}
-		return &PropertyDefinition{key: propName, value: expr, computed: computed}, nil
+		return &PropertyDefinition{Key: propName, Value: expr, Computed: computed}, nil
 	case l.TRightBrace, l.TComma:
 		// PropertyDefinition : Identifier ('}' | ', )
 		if computed {
 			// invalid syntax
 			return nil, fmt.Errorf("can't use a computed property name in a shorthand fashion")
 		}
+		if !p.isIdentifier(p.PeekN(-1)) {
+			return nil, fmt.Errorf("can't use %s as a shorthand property", propName.S())
+		}
 		// we do not consume the token here, because it will be consumed by the caller
-		return &PropertyDefinition{key: propName, value: propName, computed: false, shorthand: true}, nil
+		return &PropertyDefinition{Key: propName, Value: propName, Computed: false, Shorthand: true}, nil
+	case l.TLeftParen:
+		// PropertyDefinition : MethodDefinition
+		return p.parseMethodDefinition(propName, computed, false, false)
 	}
 
-	// TODO: Implement MethodDefinition
-
-	return nil, fmt.Errorf("rejected on parsePropertyDefinition")
+	return nil, p.needToken(fmt.Errorf("rejected on parsePropertyDefinition"))
 }
 
 func (p *Parser) parsePropertyName() (Expr, bool /* computed */, error) {
 	token := p.Peek()
+	if _, reserved := l.ReservedWordNames[token.Type]; reserved {
+		// LiteralPropertyName : IdentifierName, which includes reserved words
+		p.Next() // consume reserved word
+		return newIdentifier(token), false, nil
+	}
+
 	switch token.Type {
 	case l.TIdentifier:
 		p.Next() // consume identifier
-		return &ExprIdentifier{name: token.Lexeme}, false, nil
+		return newIdentifier(token), false, nil
 	case l.TStringLiteral_DoubleQuote, l.TStringLiteral_SingleQuote:
 		p.Next() // consume string
-		return &ExprLiteral[string]{tok: token}, false, nil
+		return &ExprLiteral[string]{Token: token}, false, nil
 	case l.TNumericLiteral:
+		if _, err := p.numericValue(token); err != nil {
+			return nil, false, err
+		}
 		p.Next() // consume numeric
-		return &ExprLiteral[float64]{tok: token}, false, nil
+		return &ExprLiteral[float64]{Token: token}, false, nil
 	case l.TLeftBracket:
 		p.Next() // consume '['
 		expr, err := p.parseAssignExpr()
@@ -184,56 +266,111 @@ func (p *Parser) parsePropertyName() (Expr, bool /* computed */, error) {
 			p.Next() // consume ']'
 			return expr, true, nil
 		}
-		return nil, false, fmt.Errorf("expected ']' after ComputedPropertyName")
+		return nil, false, p.needToken(fmt.Errorf("expected ']' after ComputedPropertyName"))
 	}
 	return nil, false, fmt.Errorf("rejected on parsePropertyName")
 }
 
-// func (p *Parser) parseMethodDefinition() (*ExprFunction, error) {
-// 	// Check for async keyword
-// 	isAsync := false
-// 	if p.Peek().Type == l.TAsync {
-// 		p.Next() // consume 'async'
-// 		isAsync = true
-// 	}
-
-// 	// Check for generator
-// 	isGenerator := false
-// 	if p.Peek().Type == l.TStar {
-// 		p.Next() // consume '*'
-// 		isGenerator = true
-// 	}
-
-// 	// Parse property name
-// 	propName, _, err := p.parsePropertyName()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Parse function parameters and body
-// 	params, body, err := p.parseFunctionParametersAndBody()
-// 	if err != nil {
-// 		return nil, err
-// 	}
+// parseMethodPrefix consumes the tokens that precede the name of generator,
+// async and async generator methods, if there are any.
+//
+// GeneratorMethod[Yield, Await] :
+// | '*' ClassElementName[?Yield, ?Await] '(' UniqueFormalParameters[+Yield, ~Await] ')' '{' GeneratorBody '}'
+//
+// AsyncMethod[Yield, Await] :
+// | 'async' [no LineTerminator here] ClassElementName[?Yield, ?Await] '(' UniqueFormalParameters[~Yield, +Await] ')' '{' AsyncFunctionBody '}'
+//
+// AsyncGeneratorMethod[Yield, Await] :
+// | 'async' [no LineTerminator here] '*' ClassElementName[?Yield, ?Await] '(' UniqueFormalParameters[+Yield, +Await] ')' '{' AsyncGeneratorBody '}'
+func (p *Parser) parseMethodPrefix() (generator bool, async bool) {
+	if p.Peek().Type == l.TAsync {
+		// { async: 1 }, { async }, { async() {} } use 'async' as the property name
+		switch next := p.PeekN(1); next.Type {
+		case l.TColon, l.TComma, l.TRightBrace, l.TLeftParen, l.TAssign:
+			return false, false
+		default:
+			if next.NewlineBefore {
+				return false, false
+			}
+		}
+		p.Next() // consume 'async'
+		async = true
+	}
+	if p.Peek().Type == l.TStar {
+		p.Next() // consume '*'
+		generator = true
+	}
+	return generator, async
+}
 
-// 	return &MethodDefinition{
-// 		key:       propName,
-// 		params:    params,
-// 		body:      body,
-// 		async:     isAsync,
-// 		generator: isGenerator,
-// 	}, nil
-// }
+// parseAccessorPrefix consumes the 'get' or 'set' that precedes the name of an
+// accessor. Both are only keywords when followed by a property name, so
+// { get: 1 }, { get } and { get() {} } define a property named 'get'.
+func (p *Parser) parseAccessorPrefix() (PropertyKind, bool) {
+	token := p.Peek()
+	if token.Type != l.TIdentifier || (token.Lexeme != "get" && token.Lexeme != "set") {
+		return PropertyInit, false
+	}
+	switch next := p.PeekN(1); next.Type {
+	case l.TIdentifier, l.TStringLiteral_DoubleQuote, l.TStringLiteral_SingleQuote, l.TNumericLiteral, l.TLeftBracket:
+	default:
+		if _, reserved := l.ReservedWordNames[next.Type]; !reserved {
+			return PropertyInit, false
+		}
+	}
+	p.Next() // consume 'get' or 'set'
+	if token.Lexeme == "get" {
+		return PropertyGet, true
+	}
+	return PropertySet, true
+}
 
 // MethodDefinition[Yield, Await] :
-// | ClassElementName[?Yield, ?Await] ( UniqueFormalParameters[~Yield, ~Await] ) { FunctionBody[~Yield, ~Await] }
-// | GeneratorMethod[?Yield, ?Await]
-// | AsyncMethod[?Yield, ?Await]
-// | AsyncGeneratorMethod[?Yield, ?Await]
-// | get ClassElementName[?Yield, ?Await] ( ) { FunctionBody[~Yield, ~Await] }
-// | set ClassElementName[?Yield, ?Await] ( PropertySetParameterList ) { FunctionBody[~Yield, ~Await] }
-// | PropertySetParameterList :
+// | 'get' ClassElementName[?Yield, ?Await] '(' ')' '{' FunctionBody[~Yield, ~Await] '}'
+// | 'set' ClassElementName[?Yield, ?Await] '(' PropertySetParameterList ')' '{' FunctionBody[~Yield, ~Await] '}'
+//
+// PropertySetParameterList :
 // | FormalParameter[~Yield, ~Await]
+func (p *Parser) parseAccessor(key Expr, computed bool, kind PropertyKind) (*PropertyDefinition, error) {
+	prop, err := p.parseMethodDefinition(key, computed, false, false)
+	if err != nil {
+		return nil, err
+	}
+	params := prop.Value.(*ExprFunction).Params
+	switch {
+	case kind == PropertyGet && len(params) != 0:
+		return nil, fmt.Errorf("getter %s must not have parameters", key.S())
+	case kind == PropertySet && len(params) != 1:
+		return nil, fmt.Errorf("setter %s must have exactly one parameter", key.S())
+	case kind == PropertySet:
+		if _, rest := params[0].(*RestElement); rest {
+			return nil, fmt.Errorf("setter %s parameter must not be a rest parameter", key.S())
+		}
+	}
+	prop.Kind = kind
+	prop.Method = false
+	return prop, nil
+}
+
+// parseMethodDefinition parses the parameters and body of a method whose name
+// was already consumed.
+func (p *Parser) parseMethodDefinition(key Expr, computed, generator, async bool) (*PropertyDefinition, error) {
+	fn := &ExprFunction{Generator: generator, Async: async}
+	start := p.Peek()
+	err := p.withContext(generator, async, func() (err error) {
+		if fn.Params, err = p.parseFormalParameters(); err != nil {
+			return err
+		}
+		fn.Body, err = p.parseFunctionBody()
+		fn.Strict = p.strict
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.recordSpan(fn, start)
+	return &PropertyDefinition{Key: key, Value: fn, Computed: computed, Method: true}, nil
+}
 
 // ClassElement[Yield, Await] :
 // | MethodDefinition[?Yield, ?Await]
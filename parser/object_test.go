@@ -15,12 +15,12 @@ func TestObjectInitialization(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `a = {}`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprObject{
-						properties: []*PropertyDefinition{},
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{},
 					},
 				},
 			},
@@ -35,15 +35,15 @@ func TestObjectInitialization(t *testing.T) {
     foo: 42
 }`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprObject{
-						properties: []*PropertyDefinition{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
 							{
-								key:   idExpr("foo"),
-								value: intExpr(42),
+								Key:   idExpr("foo"),
+								Value: intExpr(42),
 							},
 						},
 					},
@@ -63,28 +63,28 @@ func TestObjectInitialization(t *testing.T) {
 		}`
 		op := l.TPlus
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprObject{
-						properties: []*PropertyDefinition{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
 							{
-								key:   idExpr("foo"),
-								value: stringExpr(`"bar"`),
+								Key:   idExpr("foo"),
+								Value: stringExpr(`"bar"`),
 							},
 							{
-								key:   idExpr("num"),
-								value: intExpr(42),
+								Key:   idExpr("num"),
+								Value: intExpr(42),
 							},
 							{
-								computed: true,
-								key: &ExprBinaryOp{
-									operator: op.Token(),
-									left:     intExpr(2),
-									right:    intExpr(2),
+								Computed: true,
+								Key: &ExprBinaryOp{
+									Operator: op.Token(),
+									Left:     intExpr(2),
+									Right:    intExpr(2),
 								},
-								value: MakeLiteralExpr(l.TTrue),
+								Value: MakeLiteralExpr(l.TTrue),
 							},
 						},
 					},
@@ -99,18 +99,18 @@ func TestObjectInitialization(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `a = {foo}`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprObject{
-						properties: []*PropertyDefinition{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
 							{
-								key:       &ExprIdentifier{name: "foo"},
-								value:     &ExprIdentifier{name: "foo"},
-								computed:  false,
-								method:    false,
-								shorthand: true,
+								Key:       &ExprIdentifier{Name: "foo"},
+								Value:     &ExprIdentifier{Name: "foo"},
+								Computed:  false,
+								Method:    false,
+								Shorthand: true,
 							},
 						},
 					},
@@ -125,37 +125,37 @@ func TestObjectInitialization(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `a = {...foo, ...bar, baz, [foo > 'bar']: {...bar}}`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				// write the expected AST here all at once
 				&ExprAssign{
-					operator: assignt.Token(),
-					left:     idExpr("a"),
-					right: &ExprObject{
-						properties: []*PropertyDefinition{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
 							{
-								key:   idExpr("foo"),
-								value: &SpreadElement{argument: idExpr("foo")},
+								Key:   idExpr("foo"),
+								Value: &SpreadElement{Argument: idExpr("foo")},
 							},
 							{
-								key:   idExpr("bar"),
-								value: &SpreadElement{argument: idExpr("bar")},
+								Key:   idExpr("bar"),
+								Value: &SpreadElement{Argument: idExpr("bar")},
 							},
 							{
-								key:       idExpr("baz"),
-								value:     idExpr("baz"),
-								shorthand: true,
+								Key:       idExpr("baz"),
+								Value:     idExpr("baz"),
+								Shorthand: true,
 							},
 							{
-								key: binExpr(idExpr("foo"), stringExpr(`'bar'`), l.TGreaterThan),
-								value: &ExprObject{
-									properties: []*PropertyDefinition{
+								Key: binExpr(idExpr("foo"), stringExpr(`'bar'`), l.TGreaterThan),
+								Value: &ExprObject{
+									Properties: []*PropertyDefinition{
 										{
-											key:   idExpr("bar"),
-											value: &SpreadElement{argument: idExpr("bar")},
+											Key:   idExpr("bar"),
+											Value: &SpreadElement{Argument: idExpr("bar")},
 										},
 									},
 								},
-								computed: true,
+								Computed: true,
 							},
 						},
 					},
@@ -166,3 +166,202 @@ func TestObjectInitialization(t *testing.T) {
 		AssertExprEqual(t, logger, got, exp)
 	})
 }
+
+func TestObjectMethods(t *testing.T) {
+	t.Run("generator and async methods", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a = { f(x) { return x; }, *g() { yield 1; }, async h() { await 2; }, async *i() {}, async: 1 }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
+							{
+								Key:    idExpr("f"),
+								Method: true,
+								Value: &ExprFunction{
+									Params: []Node{idExpr("x")},
+									Body:   []Stmt{&ReturnStatement{Argument: idExpr("x")}},
+								},
+							},
+							{
+								Key:    idExpr("g"),
+								Method: true,
+								Value: &ExprFunction{
+									Generator: true,
+									Params:    []Node{},
+									Body:      []Stmt{&ExpressionStatement{&ExprYield{Argument: intExpr(1)}}},
+								},
+							},
+							{
+								Key:    idExpr("h"),
+								Method: true,
+								Value: &ExprFunction{
+									Async:  true,
+									Params: []Node{},
+									Body:   []Stmt{&ExpressionStatement{&ExprAwait{Argument: intExpr(2)}}},
+								},
+							},
+							{
+								Key:    idExpr("i"),
+								Method: true,
+								Value:  &ExprFunction{Async: true, Generator: true, Params: []Node{}},
+							},
+							{Key: idExpr("async"), Value: intExpr(1)},
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestObjectAccessors(t *testing.T) {
+	t.Run("getters, setters and computed method names", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a = { get x() { return 1; }, set x(v) {}, get [k]() {}, async *[k]() {}, get: 1, set() {} }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left:     idExpr("a"),
+					Right: &ExprObject{
+						Properties: []*PropertyDefinition{
+							{
+								Key:  idExpr("x"),
+								Kind: PropertyGet,
+								Value: &ExprFunction{
+									Params: []Node{},
+									Body:   []Stmt{&ReturnStatement{Argument: intExpr(1)}},
+								},
+							},
+							{
+								Key:   idExpr("x"),
+								Kind:  PropertySet,
+								Value: &ExprFunction{Params: []Node{idExpr("v")}},
+							},
+							{
+								Key:      idExpr("k"),
+								Kind:     PropertyGet,
+								Computed: true,
+								Value:    &ExprFunction{Params: []Node{}},
+							},
+							{
+								Key:      idExpr("k"),
+								Computed: true,
+								Method:   true,
+								Value:    &ExprFunction{Async: true, Generator: true, Params: []Node{}},
+							},
+							{Key: idExpr("get"), Value: intExpr(1)},
+							{Key: idExpr("set"), Method: true, Value: &ExprFunction{Params: []Node{}}},
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestObjectCoverGrammar(t *testing.T) {
+	t.Run("CoverInitializedName in an assignment pattern", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `({ a = 1, b: [{ c = 2 }] } = d)`
+		initialized := func(name string, value Expr) *PatternProperty {
+			return &PatternProperty{
+				Key:       idExpr(name),
+				Value:     &AssignmentPattern{Left: idExpr(name), Right: value},
+				Shorthand: true,
+			}
+		}
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprParenthesized{
+					Expression: &ExprAssign{
+						Operator: assignt.Token(),
+						Left: &ObjectPattern{
+							Properties: []Node{
+								initialized("a", intExpr(1)),
+								&PatternProperty{
+									Key: idExpr("b"),
+									Value: &ArrayPattern{
+										Elements: []Node{
+											&ObjectPattern{Properties: []Node{initialized("c", intExpr(2))}},
+										},
+									},
+								},
+							},
+						},
+						Right: idExpr("d"),
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("CoverInitializedName in arrow parameters", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `({ a = 1 }) => a`
+		tokens, _ := l.NewLexer(src, logger).ScanAll()
+		if _, err := NewParser(tokens, logger).parseProgram(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("duplicate __proto__ in an assignment pattern", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `({ __proto__: a, "__proto__": b } = c)`
+		tokens, _ := l.NewLexer(src, logger).ScanAll()
+		if _, err := NewParser(tokens, logger).parseProgram(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("__proto__ may be repeated as a shorthand, computed or method", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a = { __proto__: b, __proto__, ["__proto__"]: c, __proto__() {} }`
+		tokens, _ := l.NewLexer(src, logger).ScanAll()
+		if _, err := NewParser(tokens, logger).parseProgram(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestObject_Rejected(t *testing.T) {
+	srcs := []string{
+		"({ a = 1 })",
+		"a = { b = 1 }",
+		"f({ a = 1 })",
+		"a = [{ b = 1 }]",
+		"a = { b: { c = 1 } }",
+		"({ a = 1 } += b)",
+		"({ [a] = 1 } = b)",
+		"({ 'a' = 1 } = b)",
+		"a = { __proto__: 1, __proto__: 2 }",
+		"a = { __proto__: 1, '__proto__': 2 }",
+		"a = { get x(y) {} }",
+		"a = { set x() {} }",
+		"a = { set x(a, b) {} }",
+		"a = { set x(...a) {} }",
+		"a = { get *x() {} }",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, errs := l.NewLexer(src, logger).ScanAll()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected lexer error: %v", errs)
+			}
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
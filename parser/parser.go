@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -8,8 +9,74 @@ import (
 	l "github.com/ruiconti/gojs/lexer"
 )
 
-var TokenEOF = l.Token{l.TEOF, "EOF", "EOF", 0, 0}
-var TokenBOF = l.Token{l.TEOF, "BOF", "BOF", 0, 0}
+var TokenEOF = l.Token{Type: l.TEOF, Lexeme: "EOF", Literal: "EOF"}
+var TokenBOF = l.Token{Type: l.TEOF, Lexeme: "BOF", Literal: "BOF"}
+
+// SyntaxError is an early error found at a token of the source.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnexpectedEOF is matched, with errors.Is, by the errors of sources that
+// end before their last token or production does, which more source text may
+// complete: an unclosed bracket, template or string, or an operator missing
+// its operand. Errors found before the end of the source never match it, even
+// when the parser looked past the end for another alternative.
+var ErrUnexpectedEOF = l.ErrUnexpectedEOF
+
+// unexpectedEOF is an error found once the parser ran out of tokens.
+type unexpectedEOF struct {
+	error
+}
+
+func (e unexpectedEOF) Unwrap() error        { return e.error }
+func (e unexpectedEOF) Is(target error) bool { return target == ErrUnexpectedEOF }
+
+// errorAt positions err at token, unless it was already positioned by a
+// nested production.
+func errorAt(token l.Token, err error) error {
+	var positioned *SyntaxError
+	if errors.As(err, &positioned) {
+		return err
+	}
+	return &SyntaxError{Line: token.Line, Column: token.Column, Err: err}
+}
+
+// positioned returns err positioned at the furthest token the parser reached
+// when no production positioned it: every alternative failed, at the latest
+// on that token. The errors of the alternatives are then only logged.
+func (p *Parser) positioned(err error) error {
+	if isSyntaxError(err) {
+		return err
+	}
+	p.Log("rejected: %v", err)
+	if p.furthest > p.seqEnd {
+		last := p.tokens[p.seqEnd]
+		end := tokenSpan(last).End
+		// only matches ErrUnexpectedEOF when a production needed more tokens
+		return &SyntaxError{Line: end.Line, Column: end.Column, Err: errors.New("unexpected end of input")}
+	}
+	token := p.tokens[p.furthest]
+	return errorAt(token, fmt.Errorf("unexpected token %s", token.Lexeme))
+}
+
+// isSyntaxError reports whether err is positioned, which a production only
+// does once no other alternative can match the source: the error is then
+// passed up instead of backtracking.
+func isSyntaxError(err error) bool {
+	var positioned *SyntaxError
+	return errors.As(err, &positioned)
+}
 
 // TODO: define clear boundary between Expression, Statement and Declaration
 // through a clear type model
@@ -19,15 +86,16 @@ type Node interface {
 }
 
 type NodeRoot struct {
-	children []Node
+	Children []Node
+	Strict   bool // whether the program is strict mode code
 }
 
 func (n *NodeRoot) S() string {
 	var src strings.Builder
 	src.WriteString("(js ")
-	for i, child := range n.children {
+	for i, child := range n.Children {
 		src.WriteString(child.S())
-		if i < len(n.children)-1 {
+		if i < len(n.Children)-1 {
 			src.WriteString(" ")
 		}
 	}
@@ -43,12 +111,57 @@ type ExprStmt interface {
 	S() string
 }
 
+// SourceType is the goal symbol the source text is parsed with.
+type SourceType int
+
+const (
+	SourceTypeScript SourceType = iota
+	SourceTypeModule
+)
+
+// Options configures how a source text is parsed.
+type Options struct {
+	SourceType SourceType
+	// AnnexB enables the web compatibility syntax of Annex B: function
+	// declarations as if bodies, labelled functions, HTML-like comments, legacy
+	// octal-like numeric literals and the RegExp pattern extensions
+	AnnexB bool
+	// Strict parses a script as strict mode code, like the eval code of strict
+	// mode callers
+	Strict bool
+	// NewTarget allows new.target outside of functions, like in the eval code
+	// of callers within a function
+	NewTarget bool
+	// SuperProperty allows super properties outside of methods, like in the
+	// eval code of callers within a method
+	SuperProperty bool
+}
+
 type Parser struct {
 	tokens      []l.Token // token slice
 	checkpoints []uint32  // checkpoints for backtracking
 	cursor      uint32    // current index of the token slice
+	furthest    uint32    // furthest index the cursor reached, backtracking aside
 	cursorOOB   bool      // whether cursor is out of bounds
 	seqEnd      uint32    // last index of the token slice
+	eof         bool      // whether a production needed a token past the last one
+
+	options Options
+
+	// grammar parameters of the production being parsed
+	yield bool // [+Yield]: within a generator
+	await bool // [+Await]: within an async function
+
+	// whether the code being parsed is strict mode code: module code and the
+	// code after a Use Strict Directive. Class bodies are strict mode code
+	// too, but the parser does not support classes yet.
+	strict bool
+
+	spans map[Node]Span // the span of every statement, when not nil
+
+	// the index of the ')' that closes each '(' scanned by isArrowAhead, so
+	// that nested parenthesized lists are scanned once
+	closingParens map[uint32]uint32
 
 	logger *internal.SimpleLogger
 }
@@ -98,18 +211,26 @@ func (p *Parser) consume(offset int32) {
 		return
 	}
 
-	var consumed strings.Builder
-	for i := int32(p.cursor); i < width; i++ {
-		consumed.WriteString(p.tokens[i].String())
-		if i < width-1 {
-			consumed.WriteString(", ")
+	if p.logger.IsDebug() {
+		var consumed strings.Builder
+		for i := int32(p.cursor); i < width; i++ {
+			consumed.WriteString(p.tokens[i].String())
+			if i < width-1 {
+				consumed.WriteString(", ")
+			}
 		}
+		p.Log("consuming %v", consumed.String())
 	}
-	p.Log("consuming %v", consumed.String())
 	p.cursor = uint32(width)
+	if p.cursor > p.furthest {
+		p.furthest = p.cursor
+	}
 }
 
 func (p *Parser) Log(msg string, format ...interface{}) {
+	if !p.logger.IsDebug() {
+		return
+	}
 	fmsg := fmt.Sprintf(msg, format...)
 	var logmsg string
 	if p.cursor > p.seqEnd {
@@ -134,19 +255,72 @@ func (p *Parser) saveCheckpoint() uint32 {
 	return p.cursor
 }
 
+// restoreCheckpoint moves the cursor back to a checkpoint, which may be the
+// end of the tokens when it was saved once they were all consumed.
 func (p *Parser) restoreCheckpoint(cursor uint32) {
-	if cursor > p.seqEnd {
+	if cursor > p.seqEnd+1 {
 		panic("invalid checkpoint: out-of-bounds")
 	}
 	p.cursor = cursor
+	p.cursorOOB = cursor > p.seqEnd
+}
+
+// needToken returns err, the error of a production that needs another token
+// than the current one. When there is none left, more source text may
+// complete the production, so the source is marked as ending too early.
+func (p *Parser) needToken(err error) error {
+	if p.Peek().Type == l.TEOF {
+		p.eof = true
+	}
+	return err
+}
+
+func (p *Parser) isModule() bool {
+	return p.options.SourceType == SourceTypeModule
+}
+
+// withContext parses a production with the given [Yield] and [Await] grammar
+// parameters, restoring the ones of the enclosing production afterwards. So
+// is restored the strictness, which a function body may enable.
+func (p *Parser) withContext(yield, await bool, parse func() error) error {
+	prevYield, prevAwait, prevStrict := p.yield, p.await, p.strict
+	p.yield, p.await = yield, await
+	defer func() {
+		p.yield, p.await, p.strict = prevYield, prevAwait, prevStrict
+	}()
+	return parse()
+}
+
+// isIdentifier reports whether token can be used as an Identifier under the
+// current grammar parameters: 'async' is never reserved, while 'yield' and
+// 'await' are only reserved within generators and async functions respectively,
+// 'await' being also reserved throughout module code.
+func (p *Parser) isIdentifier(token l.Token) bool {
+	switch token.Type {
+	case l.TIdentifier, l.TAsync:
+		return true
+	case l.TYield:
+		return !p.yield
+	case l.TAwait:
+		return !p.await && !p.isModule()
+	}
+	return false
 }
 
+// Parse parses src as a Script.
 func Parse(logger *internal.SimpleLogger, src string) Node {
+	return ParseWithOptions(logger, src, Options{})
+}
+
+// ParseWithOptions parses src with the goal symbol and features set by options.
+func ParseWithOptions(logger *internal.SimpleLogger, src string, options Options) Node {
 	var (
 		ast *NodeRoot
 		err error
 	)
-	lexer := l.NewLexer(src, logger)
+	// HTML-like comments are not allowed in modules
+	lexerOptions := l.Options{AnnexB: options.AnnexB && options.SourceType == SourceTypeScript}
+	lexer := l.NewLexerWithOptions(src, logger, lexerOptions)
 	tokens, errs := lexer.ScanAll()
 	if len(errs) > 0 {
 		for _, e := range errs {
@@ -156,6 +330,7 @@ func Parse(logger *internal.SimpleLogger, src string) Node {
 	}
 
 	parser := NewParser(tokens, logger)
+	parser.options = options
 	defer func() {
 		stack := recover()
 		if stack != nil {
@@ -179,11 +354,26 @@ func Parse(logger *internal.SimpleLogger, src string) Node {
 	return ast
 }
 
+// Script :
+// | ScriptBody?
+//
+// Module :
+// | ModuleBody?
+//
+// ModuleItem :
+// | ImportDeclaration
+// | ExportDeclaration
+// | StatementListItem[~Yield, +Await, ~Return]
 func (p *Parser) parseProgram() (*NodeRoot, error) {
 	var (
 		statements    []Node
 		lastCursorPos uint32 = 0
+		prologue             = true
 	)
+	// Module code is parsed with [+Await], allowing top-level await, and is
+	// always strict mode code
+	p.await = p.isModule()
+	p.strict = p.isModule() || p.options.Strict
 	defer func() {
 		stack := recover()
 		if stack != nil {
@@ -192,7 +382,8 @@ func (p *Parser) parseProgram() (*NodeRoot, error) {
 		}
 	}()
 
-	for !p.cursorOOB {
+	// an empty source has no token to parse
+	for len(p.tokens) > 0 && !p.cursorOOB {
 		token := p.Peek()
 		p.Log("loop %v", token.String())
 
@@ -202,15 +393,26 @@ func (p *Parser) parseProgram() (*NodeRoot, error) {
 			continue
 		}
 
-		stmt, err := p.parseStatement()
-		if err == nil {
-			statements = append(statements, stmt)
-		} else if stmt == nil {
-			panic("boo")
+		var (
+			stmt Stmt
+			err  error
+		)
+		if p.isModuleItem() {
+			stmt, err = p.parseModuleItem()
+			if err == nil {
+				p.recordSpan(stmt, token)
+			}
 		} else {
-			return &NodeRoot{children: statements}, err
+			stmt, err = p.parseStatement()
+		}
+		if err != nil {
+			return &NodeRoot{Children: statements, Strict: p.strict}, p.positioned(err)
+		}
+		if prologue {
+			prologue = p.applyDirective(stmt)
 		}
+		statements = append(statements, stmt)
 		p.guardInfiniteLoop(&lastCursorPos)
 	}
-	return &NodeRoot{children: statements}, nil
+	return &NodeRoot{Children: statements, Strict: p.strict}, nil
 }
@@ -0,0 +1,525 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// ////////////////
+// ObjectPattern //
+// ////////////////
+const EObjectPattern ExprType = "ObjectPattern"
+
+// ObjectPattern is an ObjectBindingPattern or ObjectAssignmentPattern. Its
+// properties are *PatternProperty, except for a trailing *RestElement.
+type ObjectPattern struct {
+	Properties []Node
+}
+
+func (e *ObjectPattern) Type() ExprType {
+	return EObjectPattern
+}
+
+func (e *ObjectPattern) S() string {
+	return fmt.Sprintf("(object-pattern%s)", joinS(e.Properties))
+}
+
+// //////////////////
+// PatternProperty //
+// //////////////////
+const EPatternProperty ExprType = "PatternProperty"
+
+type PatternProperty struct {
+	Key       Expr
+	Value     Node // the target, possibly an *AssignmentPattern with its default
+	Computed  bool // { [foo]: bar }
+	Shorthand bool // { foo }, { foo = 1 }
+}
+
+func (e *PatternProperty) Type() ExprType {
+	return EPatternProperty
+}
+
+func (e *PatternProperty) S() string {
+	if e.Computed {
+		return fmt.Sprintf("(k:[%s] v:%s)", e.Key.S(), e.Value.S())
+	}
+	return fmt.Sprintf("(k:%s v:%s)", e.Key.S(), e.Value.S())
+}
+
+// ///////////////
+// ArrayPattern //
+// ///////////////
+const EArrayPattern ExprType = "ArrayPattern"
+
+// ArrayPattern is an ArrayBindingPattern or ArrayAssignmentPattern. Holes,
+// as in [a, , b], are nil elements.
+type ArrayPattern struct {
+	Elements []Node
+}
+
+func (e *ArrayPattern) Type() ExprType {
+	return EArrayPattern
+}
+
+func (e *ArrayPattern) S() string {
+	elements := make([]string, len(e.Elements))
+	for i, element := range e.Elements {
+		if element == nil {
+			elements[i] = "_"
+		} else {
+			elements[i] = element.S()
+		}
+	}
+	if len(elements) == 0 {
+		return "(array-pattern)"
+	}
+	return fmt.Sprintf("(array-pattern %s)", strings.Join(elements, " "))
+}
+
+// ////////////////////
+// AssignmentPattern //
+// ////////////////////
+const EAssignmentPattern ExprType = "AssignmentPattern"
+
+// AssignmentPattern is a target with a default value, as in [a = 1] or
+// function f(a = 1) {}.
+type AssignmentPattern struct {
+	Left  Node
+	Right Expr
+}
+
+func (e *AssignmentPattern) Type() ExprType {
+	return EAssignmentPattern
+}
+
+func (e *AssignmentPattern) S() string {
+	return fmt.Sprintf("(default %s %s)", e.Left.S(), e.Right.S())
+}
+
+// //////////////
+// RestElement //
+// //////////////
+const ERestElement ExprType = "RestElement"
+
+type RestElement struct {
+	Argument Node
+}
+
+func (e *RestElement) Type() ExprType {
+	return ERestElement
+}
+
+func (e *RestElement) S() string {
+	return fmt.Sprintf("(... %s)", e.Argument.S())
+}
+
+func joinS(nodes []Node) string {
+	var src strings.Builder
+	for _, node := range nodes {
+		src.WriteString(" ")
+		src.WriteString(node.S())
+	}
+	return src.String()
+}
+
+// BindingPattern[Yield, Await] :
+// | ObjectBindingPattern[?Yield, ?Await]
+// | ArrayBindingPattern[?Yield, ?Await]
+func (p *Parser) parseBindingPattern() (Expr, error) {
+	switch p.Peek().Type {
+	case l.TLeftBrace:
+		return p.parseObjectBindingPattern()
+	case l.TLeftBracket:
+		return p.parseArrayBindingPattern()
+	default:
+		return nil, p.needToken(fmt.Errorf("expected an object or array binding pattern, got %s", p.Peek().Lexeme))
+	}
+}
+
+// ObjectBindingPattern[Yield, Await] :
+// | '{' '}'
+// | '{' BindingRestProperty[?Yield, ?Await] '}'
+// | '{' BindingPropertyList[?Yield, ?Await] '}'
+// | '{' BindingPropertyList[?Yield, ?Await] ',' BindingRestProperty[?Yield, ?Await]? '}'
+func (p *Parser) parseObjectBindingPattern() (*ObjectPattern, error) {
+	p.Log("parseObjectBindingPattern")
+	p.Next() // consume '{'
+
+	pattern := &ObjectPattern{Properties: []Node{}}
+	for {
+		switch p.Peek().Type {
+		case l.TRightBrace:
+			p.Next() // consume '}'
+			return pattern, nil
+		case l.TEllipsis:
+			// BindingRestProperty : '...' BindingIdentifier
+			rest, err := p.parseBindingRestElement(false)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Properties = append(pattern.Properties, rest)
+			if p.Peek().Type != l.TRightBrace {
+				return nil, fmt.Errorf("rest element must be last, got %s", p.Peek().Lexeme)
+			}
+			continue
+		}
+
+		prop, err := p.parseBindingProperty()
+		if err != nil {
+			return nil, err
+		}
+		pattern.Properties = append(pattern.Properties, prop)
+
+		switch token := p.Peek(); token.Type {
+		case l.TComma:
+			p.Next() // consume ','
+		case l.TRightBrace:
+		default:
+			return nil, p.needToken(fmt.Errorf("expected ',' or '}' in object pattern, got %s", token.Lexeme))
+		}
+	}
+}
+
+// BindingProperty[Yield, Await] :
+// | SingleNameBinding[?Yield, ?Await]
+// | PropertyName[?Yield, ?Await] ':' BindingElement[?Yield, ?Await]
+func (p *Parser) parseBindingProperty() (*PatternProperty, error) {
+	if token := p.Peek(); p.isIdentifier(token) && p.PeekN(1).Type != l.TColon {
+		// SingleNameBinding : BindingIdentifier Initializer?
+		p.Next() // consume identifier
+		identifier := newIdentifier(token)
+		value, err := p.parseBindingInitializer(identifier)
+		if err != nil {
+			return nil, err
+		}
+		return &PatternProperty{Key: identifier, Value: value, Shorthand: true}, nil
+	}
+
+	key, computed, err := p.parsePropertyName()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TColon {
+		return nil, p.needToken(fmt.Errorf("expected ':' after %s in object pattern, got %s", key.S(), p.Peek().Lexeme))
+	}
+	p.Next() // consume ':'
+	value, err := p.parseBindingElement()
+	if err != nil {
+		return nil, err
+	}
+	return &PatternProperty{Key: key, Value: value, Computed: computed}, nil
+}
+
+// ArrayBindingPattern[Yield, Await] :
+// | '[' Elision? BindingRestElement[?Yield, ?Await]? ']'
+// | '[' BindingElementList[?Yield, ?Await] ']'
+// | '[' BindingElementList[?Yield, ?Await] ',' Elision? BindingRestElement[?Yield, ?Await]? ']'
+func (p *Parser) parseArrayBindingPattern() (*ArrayPattern, error) {
+	p.Log("parseArrayBindingPattern")
+	p.Next() // consume '['
+
+	pattern := &ArrayPattern{Elements: []Node{}}
+	for {
+		switch p.Peek().Type {
+		case l.TRightBracket:
+			p.Next() // consume ']'
+			return pattern, nil
+		case l.TComma:
+			// Elision
+			p.Next() // consume ','
+			pattern.Elements = append(pattern.Elements, nil)
+			continue
+		case l.TEllipsis:
+			rest, err := p.parseBindingRestElement(true)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Elements = append(pattern.Elements, rest)
+			if p.Peek().Type != l.TRightBracket {
+				return nil, fmt.Errorf("rest element must be last, got %s", p.Peek().Lexeme)
+			}
+			continue
+		}
+
+		element, err := p.parseBindingElement()
+		if err != nil {
+			return nil, err
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		switch token := p.Peek(); token.Type {
+		case l.TComma:
+			p.Next() // consume ','
+		case l.TRightBracket:
+		default:
+			return nil, p.needToken(fmt.Errorf("expected ',' or ']' in array pattern, got %s", token.Lexeme))
+		}
+	}
+}
+
+// BindingElement[Yield, Await] :
+// | SingleNameBinding[?Yield, ?Await]
+// | BindingPattern[?Yield, ?Await] Initializer[+In, ?Yield, ?Await]?
+//
+// SingleNameBinding[Yield, Await] :
+// | BindingIdentifier[?Yield, ?Await] Initializer[+In, ?Yield, ?Await]?
+func (p *Parser) parseBindingElement() (Node, error) {
+	var target Node
+	if token := p.Peek(); p.isIdentifier(token) {
+		p.Next() // consume identifier
+		target = newIdentifier(token)
+	} else {
+		pattern, err := p.parseBindingPattern()
+		if err != nil {
+			return nil, err
+		}
+		target = pattern
+	}
+	return p.parseBindingInitializer(target)
+}
+
+// parseBindingInitializer wraps target in an AssignmentPattern if it is
+// followed by an Initializer.
+//
+// Initializer[In, Yield, Await] : '=' AssignmentExpression[?In, ?Yield, ?Await]
+func (p *Parser) parseBindingInitializer(target Node) (Node, error) {
+	if p.Peek().Type != l.TAssign {
+		return target, nil
+	}
+	p.Next() // consume '='
+	init, err := p.parseAssignExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &AssignmentPattern{Left: target, Right: init}, nil
+}
+
+// BindingRestElement[Yield, Await] :
+// | '...' BindingIdentifier[?Yield, ?Await]
+// | '...' BindingPattern[?Yield, ?Await]
+//
+// BindingRestProperty[Yield, Await] :
+// | '...' BindingIdentifier[?Yield, ?Await]
+func (p *Parser) parseBindingRestElement(allowPattern bool) (*RestElement, error) {
+	p.Next() // consume '...'
+	switch token := p.Peek(); {
+	case p.isIdentifier(token):
+		p.Next() // consume identifier
+		return &RestElement{Argument: newIdentifier(token)}, nil
+	case allowPattern && (token.Type == l.TLeftBrace || token.Type == l.TLeftBracket):
+		pattern, err := p.parseBindingPattern()
+		if err != nil {
+			return nil, err
+		}
+		return &RestElement{Argument: pattern}, nil
+	default:
+		return nil, fmt.Errorf("invalid rest element, got %s", token.Lexeme)
+	}
+}
+
+// isLiteralPattern reports whether expr is an object or array literal, which
+// stands for an AssignmentPattern on the left of '='.
+func isLiteralPattern(expr Node) bool {
+	switch expr.(type) {
+	case *ExprObject, *ExprArray:
+		return true
+	}
+	return false
+}
+
+var errInvalidDestructuringTarget = fmt.Errorf("%w: invalid destructuring target", errInvalidAssignmentTarget)
+
+// reinterpretAsPattern converts an object or array literal, which was parsed
+// with the cover grammar, into the pattern it stands for. With binding set, it
+// is an arrow function's BindingPattern, whose targets must be identifiers;
+// otherwise it is the AssignmentPattern of a destructuring assignment, whose
+// targets may also be member expressions.
+//
+// AssignmentPattern[Yield, Await] :
+// | ObjectAssignmentPattern[?Yield, ?Await]
+// | ArrayAssignmentPattern[?Yield, ?Await]
+func reinterpretAsPattern(expr Node, binding bool) (Node, error) {
+	switch e := expr.(type) {
+	case *ExprObject:
+		return reinterpretObjectAsPattern(e, binding)
+	case *ExprArray:
+		return reinterpretArrayAsPattern(e, binding)
+	case *ObjectPattern, *ArrayPattern:
+		// already reinterpreted as the target of a nested assignment
+		if binding {
+			if err := checkBindingPattern(e); err != nil {
+				return nil, err
+			}
+		}
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errInvalidDestructuringTarget, expr.S())
+}
+
+// ObjectAssignmentPattern[Yield, Await] :
+// | '{' '}'
+// | '{' AssignmentRestProperty[?Yield, ?Await] '}'
+// | '{' AssignmentPropertyList[?Yield, ?Await] '}'
+// | '{' AssignmentPropertyList[?Yield, ?Await] ',' AssignmentRestProperty[?Yield, ?Await]? '}'
+//
+// AssignmentProperty[Yield, Await] :
+// | IdentifierReference[?Yield, ?Await] Initializer[+In, ?Yield, ?Await]?
+// | PropertyName[?Yield, ?Await] ':' AssignmentElement[?Yield, ?Await]
+func reinterpretObjectAsPattern(object *ExprObject, binding bool) (*ObjectPattern, error) {
+	pattern := &ObjectPattern{Properties: make([]Node, 0, len(object.Properties))}
+	for i, prop := range object.Properties {
+		if spread, ok := prop.Value.(*SpreadElement); ok {
+			// AssignmentRestProperty : '...' DestructuringAssignmentTarget
+			if i != len(object.Properties)-1 {
+				return nil, fmt.Errorf("%w: rest element must be last", errInvalidDestructuringTarget)
+			}
+			if object.trailingComma {
+				return nil, fmt.Errorf("%w: rest element may not have a trailing comma", errInvalidDestructuringTarget)
+			}
+			if _, nested := spread.Argument.(*ExprObject); nested {
+				return nil, fmt.Errorf("%w: rest property can't be a pattern", errInvalidDestructuringTarget)
+			}
+			if _, nested := spread.Argument.(*ExprArray); nested {
+				return nil, fmt.Errorf("%w: rest property can't be a pattern", errInvalidDestructuringTarget)
+			}
+			target, err := reinterpretAsTarget(spread.Argument, binding)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Properties = append(pattern.Properties, &RestElement{Argument: target})
+			continue
+		}
+		if prop.Method || prop.Kind != PropertyInit {
+			return nil, fmt.Errorf("%w: method %s", errInvalidDestructuringTarget, prop.Key.S())
+		}
+
+		value, err := reinterpretAsElement(prop.Value, binding)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Properties = append(pattern.Properties, &PatternProperty{
+			Key:       prop.Key,
+			Value:     value,
+			Computed:  prop.Computed,
+			Shorthand: prop.Shorthand,
+		})
+	}
+	return pattern, nil
+}
+
+// ArrayAssignmentPattern[Yield, Await] :
+// | '[' Elision? AssignmentRestElement[?Yield, ?Await]? ']'
+// | '[' AssignmentElementList[?Yield, ?Await] ']'
+// | '[' AssignmentElementList[?Yield, ?Await] ',' Elision? AssignmentRestElement[?Yield, ?Await]? ']'
+func reinterpretArrayAsPattern(array *ExprArray, binding bool) (*ArrayPattern, error) {
+	pattern := &ArrayPattern{Elements: make([]Node, 0, len(array.Elements))}
+	for i, element := range array.Elements {
+		if element == ArrayHole {
+			pattern.Elements = append(pattern.Elements, nil)
+			continue
+		}
+		if spread, ok := element.(*SpreadElement); ok {
+			// AssignmentRestElement : '...' DestructuringAssignmentTarget
+			if i != len(array.Elements)-1 {
+				return nil, fmt.Errorf("%w: rest element must be last", errInvalidDestructuringTarget)
+			}
+			if array.trailingComma {
+				return nil, fmt.Errorf("%w: rest element may not have a trailing comma", errInvalidDestructuringTarget)
+			}
+			target, err := reinterpretAsTarget(spread.Argument, binding)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Elements = append(pattern.Elements, &RestElement{Argument: target})
+			continue
+		}
+
+		target, err := reinterpretAsElement(element, binding)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Elements = append(pattern.Elements, target)
+	}
+	return pattern, nil
+}
+
+// reinterpretAsElement converts a property value or array element, which may
+// have an Initializer.
+//
+// AssignmentElement[Yield, Await] :
+// | DestructuringAssignmentTarget[?Yield, ?Await] Initializer[+In, ?Yield, ?Await]?
+func reinterpretAsElement(expr Node, binding bool) (Node, error) {
+	if assign, ok := expr.(*ExprAssign); ok {
+		if assign.Operator.Type != l.TAssign {
+			return nil, fmt.Errorf("%w: %s", errInvalidDestructuringTarget, assign.S())
+		}
+		target, err := reinterpretAsTarget(assign.Left, binding)
+		if err != nil {
+			return nil, err
+		}
+		return &AssignmentPattern{Left: target, Right: assign.Right}, nil
+	}
+	return reinterpretAsTarget(expr, binding)
+}
+
+// reinterpretAsTarget converts an element without its Initializer.
+//
+// DestructuringAssignmentTarget[Yield, Await] :
+// | LeftHandSideExpression[?Yield, ?Await]
+func reinterpretAsTarget(expr Node, binding bool) (Node, error) {
+	switch e := expr.(type) {
+	case *ExprIdentifier:
+		return e, nil
+	case *ExprMemberAccess:
+		if !binding && !e.Optional {
+			return e, nil
+		}
+	case *ExprParenthesized:
+		// (a) and (a.b) are simple targets, but ({a}) is not a pattern
+		switch e.Expression.(type) {
+		case *ExprIdentifier, *ExprMemberAccess, *ExprParenthesized:
+			if !binding {
+				return reinterpretAsTarget(e.Expression, binding)
+			}
+		}
+	default:
+		return reinterpretAsPattern(expr, binding)
+	}
+	return nil, fmt.Errorf("%w: %s", errInvalidDestructuringTarget, expr.S())
+}
+
+// checkBindingPattern checks that the targets of a pattern reinterpreted as
+// an AssignmentPattern are all identifiers, as required of a BindingPattern.
+func checkBindingPattern(node Node) error {
+	switch n := node.(type) {
+	case *ExprIdentifier:
+		return nil
+	case *ObjectPattern:
+		for _, prop := range n.Properties {
+			if err := checkBindingPattern(prop); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ArrayPattern:
+		for _, element := range n.Elements {
+			if element == nil {
+				continue
+			}
+			if err := checkBindingPattern(element); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *PatternProperty:
+		return checkBindingPattern(n.Value)
+	case *AssignmentPattern:
+		return checkBindingPattern(n.Left)
+	case *RestElement:
+		return checkBindingPattern(n.Argument)
+	}
+	return fmt.Errorf("%w: %s", errInvalidDestructuringTarget, node.S())
+}
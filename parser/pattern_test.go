@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+func TestBindingPattern(t *testing.T) {
+	t.Run("defaults, holes and nested patterns", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `let [a = 1, , { b: [c] = d, e = 2, [f]: g }, ...[h]] = i;`
+		kind := l.TLet
+		exp := &NodeRoot{
+			Children: []Node{
+				&VariableStatement{
+					Kind: kind.Token(),
+					Declarations: []*VariableDeclaration{
+						{
+							Pattern: &ArrayPattern{
+								Elements: []Node{
+									&AssignmentPattern{Left: idExpr("a"), Right: intExpr(1)},
+									nil,
+									&ObjectPattern{
+										Properties: []Node{
+											&PatternProperty{
+												Key:   idExpr("b"),
+												Value: &AssignmentPattern{Left: &ArrayPattern{Elements: []Node{idExpr("c")}}, Right: idExpr("d")},
+											},
+											&PatternProperty{
+												Key:       idExpr("e"),
+												Value:     &AssignmentPattern{Left: idExpr("e"), Right: intExpr(2)},
+												Shorthand: true,
+											},
+											&PatternProperty{Key: idExpr("f"), Value: idExpr("g"), Computed: true},
+										},
+									},
+									&RestElement{Argument: &ArrayPattern{Elements: []Node{idExpr("h")}}},
+								},
+							},
+							Init: idExpr("i"),
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("parameters with defaults", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function f(a, b = 1, { c } = {}, ...d) {}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("f"),
+					Params: []Node{
+						idExpr("a"),
+						&AssignmentPattern{Left: idExpr("b"), Right: intExpr(1)},
+						&AssignmentPattern{
+							Left: &ObjectPattern{
+								Properties: []Node{&PatternProperty{Key: idExpr("c"), Value: idExpr("c"), Shorthand: true}},
+							},
+							Right: &ExprObject{},
+						},
+						&RestElement{Argument: idExpr("d")},
+					},
+					Body: []Stmt{},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+}
+
+func TestAssignmentPattern(t *testing.T) {
+	t.Run("array literal reinterpreted as a pattern", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `[a, , b.c, (d), e = 1, ...f] = g`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left: &ArrayPattern{
+						Elements: []Node{
+							idExpr("a"),
+							nil,
+							&ExprMemberAccess{Object: idExpr("b"), Property: idExpr("c")},
+							idExpr("d"),
+							&AssignmentPattern{Left: idExpr("e"), Right: intExpr(1)},
+							&RestElement{Argument: idExpr("f")},
+						},
+					},
+					Right: idExpr("g"),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("object literal reinterpreted as a pattern", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `({ a, b: [c] = d, ...e.f } = g)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprParenthesized{
+					Expression: &ExprAssign{
+						Operator: assignt.Token(),
+						Left: &ObjectPattern{
+							Properties: []Node{
+								&PatternProperty{Key: idExpr("a"), Value: idExpr("a"), Shorthand: true},
+								&PatternProperty{
+									Key:   idExpr("b"),
+									Value: &AssignmentPattern{Left: &ArrayPattern{Elements: []Node{idExpr("c")}}, Right: idExpr("d")},
+								},
+								&RestElement{Argument: &ExprMemberAccess{Object: idExpr("e"), Property: idExpr("f")}},
+							},
+						},
+						Right: idExpr("g"),
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("nested assignment as an arrow parameter", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `([a] = b) => a`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprArrowFunction{
+					Params: []Node{
+						&AssignmentPattern{Left: &ArrayPattern{Elements: []Node{idExpr("a")}}, Right: idExpr("b")},
+					},
+					Expression: idExpr("a"),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestPattern_Rejected(t *testing.T) {
+	srcs := []string{
+		// binding patterns
+		"let { a() {} } = b",
+		"let { a: 1 } = b",
+		"let { if } = b",
+		"let { ...{ a } } = b",
+		"let { ...a, b } = c",
+		"let { ...a, } = c",
+		"let [...a, b] = c",
+		"let [a, ...b,] = c",
+		"let [...a = 1] = b",
+		"let [a.b] = c",
+		"function f(...a = 1) {}",
+		"function f(...a, b) {}",
+		// destructuring assignment
+		"({ a() {} } = b)",
+		"({ get a() {} } = b)",
+		"({ a: 1 } = b)",
+		"({ ...{ a } } = b)",
+		"({ ...a, b } = c)",
+		"({ ...a, } = c)",
+		"([...a, b] = c)",
+		"([a, ...b,] = c)",
+		"[...a,] = b",
+		"([null] = a)",
+		"([a + 1] = b)",
+		"([(a = 1)] = b)",
+		"([({ a })] = b)",
+		"([a?.b] = c)",
+		// arrow parameters
+		"([a.b]) => a",
+		"({ a: (b) }) => b",
+		"([a.b] = c) => a",
+		"([...a,]) => a",
+		"({ ...a, }) => a",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, errs := l.NewLexer(src, logger).ScanAll()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected lexer error: %v", errs)
+			}
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
+
+func TestAssignmentTarget(t *testing.T) {
+	t.Run("swap with array patterns", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `[a, b] = [b, a]`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprAssign{
+					Operator: assignt.Token(),
+					Left:     &ArrayPattern{Elements: []Node{idExpr("a"), idExpr("b")}},
+					Right:    &ExprArray{Elements: []Expr{idExpr("b"), idExpr("a")}},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("simple targets", func(t *testing.T) {
+		srcs := []string{"(a) = 1", "((a.b)) = 1", "a.b += 1", "a[0] *= 2", "a.b++", "--(a)"}
+		for _, src := range srcs {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err != nil {
+				t.Errorf("unexpected error for %q: %v", src, err)
+			}
+		}
+	})
+
+	t.Run("errors are positioned at the target", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "a = 1;\nb = f() = 2"
+		tokens, _ := l.NewLexer(src, logger).ScanAll()
+		_, err := NewParser(tokens, logger).parseProgram()
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("expected a SyntaxError, got %v", err)
+		}
+		if exp := tokens[6]; syntaxErr.Line != exp.Line || syntaxErr.Column != exp.Column {
+			t.Errorf("expected the error at %d:%d, got %d:%d", exp.Line, exp.Column, syntaxErr.Line, syntaxErr.Column)
+		}
+	})
+}
+
+func TestAssignmentTarget_Rejected(t *testing.T) {
+	srcs := []string{
+		"(1 = 2)",
+		"(f() = x)",
+		"(a + b = c)",
+		"(this = a)",
+		"((a, b) = c)",
+		"(a?.b = c)",
+		"({ a } += b)",
+		"([a] *= b)",
+		"(({ a }) = b)",
+		"(1++)",
+		"(f()--)",
+		"(++f())",
+		"(--1)",
+		"(++[a])",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, errs := l.NewLexer(src, logger).ScanAll()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected lexer error: %v", errs)
+			}
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
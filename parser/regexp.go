@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/regexp"
+)
+
+// /////////////
+// ExprRegExp //
+// /////////////
+const ERegExp ExprType = "ExprRegExp"
+
+type ExprRegExp struct {
+	Pattern string // RegularExpressionBody, as written
+	Flags   string // RegularExpressionFlags, as written
+	Regexp  *regexp.Pattern
+}
+
+func (e *ExprRegExp) Type() ExprType {
+	return ERegExp
+}
+
+func (e *ExprRegExp) S() string {
+	return fmt.Sprintf("/%s/%s", e.Pattern, e.Flags)
+}
+
+// RegularExpressionLiteral ::
+// | '/' RegularExpressionBody '/' RegularExpressionFlags
+//
+// It is an early error for the body not to be a valid Pattern given the
+// flags, or for the flags to be invalid. The Annex B pattern extensions are
+// only allowed when the parser's AnnexB option is set.
+//
+// https://262.ecma-international.org/#sec-primary-expression-regular-expression-literals-static-semantics-early-errors
+func (p *Parser) parseRegularExpressionLiteral() (Expr, error) {
+	p.Log("parseRegularExpressionLiteral")
+	token := p.Peek()
+	if token.Type != l.TRegularExpressionLiteral {
+		return nil, p.needToken(fmt.Errorf("expected regular expression literal, got %s", token.Lexeme))
+	}
+
+	end := strings.LastIndexByte(token.Lexeme, '/')
+	body, flags := token.Lexeme[1:end], token.Lexeme[end+1:]
+	pattern, err := regexp.ParseWithOptions(body, flags, regexp.Options{AnnexB: p.options.AnnexB})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", token.Lexeme, err)
+	}
+	p.Next() // consume regular expression
+	return &ExprRegExp{Pattern: body, Flags: flags, Regexp: pattern}, nil
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+func TestRegularExpressionLiteral(t *testing.T) {
+	t.Run("pattern and flags", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `/(?<year>\d{4})-[^/]+/gu`
+		exp := &NodeRoot{
+			Children: []Node{&ExprRegExp{Pattern: `(?<year>\d{4})-[^/]+`, Flags: "gu"}},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+
+		regExp := got.(*NodeRoot).Children[0].(*ExpressionStatement).Expression.(*ExprRegExp)
+		if regExp.Regexp == nil || regExp.Regexp.GroupNames[0] != "year" || !regExp.Regexp.Flags.Unicode {
+			t.Errorf("expected the pattern to be parsed, got %v", regExp.Regexp)
+		}
+	})
+
+	t.Run("member access on a regular expression", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `/a/i.test(b)`
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprCall{
+					Callee: &ExprMemberAccess{
+						Object:   &ExprRegExp{Pattern: "a", Flags: "i"},
+						Property: idExpr("test"),
+					},
+					Arguments: []Expr{idExpr("b")},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("division is not a regular expression", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a / b / c`
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(binExpr(idExpr("a"), idExpr("b"), l.TSlash), idExpr("c"), l.TSlash),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("regular expression as an operand", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a || /=/`
+		exp := &NodeRoot{
+			Children: []Node{
+				binExpr(idExpr("a"), &ExprRegExp{Pattern: "=", Flags: ""}, l.TLogicalOr),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestRegularExpressionLiteral_Rejected(t *testing.T) {
+	srcs := []string{
+		"/a/gg",
+		"/a/x",
+		"/a/uv",
+		"/(?<n>a)(?<n>b)/",
+		`/\p{Foo}/u`,
+		`/\-/u`,
+		"/a**/",
+		"/[b-a]/",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, errs := l.NewLexer(src, logger).ScanAll()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected lexer error: %v", errs)
+			}
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
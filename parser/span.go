@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// Position is a location in the source text, with a 1-based Line and a
+// 0-based Column counted in bytes, just like the tokens produced by the lexer.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the range of source text a node was parsed from. End is exclusive.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
+// tokenSpan returns the range of source text covered by the lexeme of token,
+// which may span several lines (e.g. templates).
+func tokenSpan(token l.Token) Span {
+	start := Position{Line: token.Line, Column: token.Column}
+	end := Position{Line: token.Line, Column: token.Column + len(token.Lexeme)}
+	if newlines := strings.Count(token.Lexeme, "\n"); newlines > 0 {
+		end.Line += newlines
+		end.Column = len(token.Lexeme) - strings.LastIndex(token.Lexeme, "\n") - 1
+	}
+	return Span{Start: start, End: end}
+}
+
+// spanFrom returns the range of source text from the start token up to the
+// last consumed token.
+func (p *Parser) spanFrom(start l.Token) Span {
+	return Span{Start: tokenSpan(start).Start, End: tokenSpan(p.PeekN(-1)).End}
+}
+
+// newIdentifier returns the identifier named by token, positioned at it.
+func newIdentifier(token l.Token) *ExprIdentifier {
+	return &ExprIdentifier{Name: token.Lexeme, Span: tokenSpan(token)}
+}
+
+// recordSpan records the range of source text of a statement or function,
+// from the start token up to the last consumed token, when the parser keeps
+// track of them.
+func (p *Parser) recordSpan(node Node, start l.Token) {
+	if p.spans != nil {
+		p.spans[node] = p.spanFrom(start)
+	}
+}
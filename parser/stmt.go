@@ -24,11 +24,11 @@ type Stmt interface {
 // | ContinueStatement[?Yield, ?Await] (TODO)
 // | BreakStatement[?Yield, ?Await] (TODO)
 // | [+Return] ReturnStatement[?Yield, ?Await] (TODO)
-// | WithStatement[?Yield, ?Await, ?Return] (TODO)
-// | LabelledStatement[?Yield, ?Await, ?Return] (TODO)
+// | WithStatement[?Yield, ?Await, ?Return]
+// | LabelledStatement[?Yield, ?Await, ?Return]
 // | ThrowStatement[?Yield, ?Await] (TODO)
 // | TryStatement[?Yield, ?Await, ?Return] (TODO)
-// | DebuggerStatement (TODO)
+// | DebuggerStatement
 func (p *Parser) parseStatement() (Stmt, error) {
 	token := p.Peek()
 	var stmt Stmt
@@ -47,19 +47,45 @@ func (p *Parser) parseStatement() (Stmt, error) {
 		stmt, err = p.parseIfStatement()
 	case l.TReturn:
 		stmt, err = p.parseReturnStatement()
+	case l.TWith:
+		stmt, err = p.parseWithStatement()
+	case l.TDebugger:
+		stmt, err = p.parseDebuggerStatement()
+	case l.TIdentifier, l.TYield, l.TAwait:
+		if p.isIdentifier(token) && p.PeekN(1).Type == l.TColon {
+			stmt, err = p.parseLabelledStatement()
+		}
+	case l.TAsync:
+		if p.PeekN(1).Type == l.TColon {
+			stmt, err = p.parseLabelledStatement()
+		} else if isAsyncFunction(token, p.PeekN(1)) {
+			// 'async function' on a line is an async function, not the
+			// identifier async: its errors are final
+			if stmt, err = p.parseFunctionDeclaration(); err != nil {
+				return nil, err
+			}
+		}
 	case l.TFunction:
 		stmt, err = p.parseFunctionDeclaration()
 	}
 
+	if isSyntaxError(err) {
+		return nil, err
+	}
 	if err != nil || stmt == nil {
 		p.restoreCheckpoint(cp)
-		return p.parseExpressionStatement()
+		stmt, err = p.parseExpressionStatement()
+	}
+	if err == nil {
+		p.recordSpan(stmt, token)
 	}
 	return stmt, err
 }
 
 // EmptyStatement : ';'
-type EmptyStatement struct{}
+type EmptyStatement struct {
+	Span Span
+}
 
 const SStmt StmtType = "SStmt"
 
@@ -68,44 +94,54 @@ func (s *EmptyStatement) S() string      { return ";" }
 
 func (p *Parser) parseEmptyStatement() (*EmptyStatement, error) {
 	if p.Peek().Type != l.TSemicolon {
-		return nil, fmt.Errorf("expected ';', got %v", p.Peek().Type)
+		return nil, p.needToken(fmt.Errorf("expected ';', got %v", p.Peek().Type))
 	}
 	p.Next() // Consume the ';' token
-	return &EmptyStatement{}, nil
+	return &EmptyStatement{Span: tokenSpan(p.PeekN(-1))}, nil
 }
 
 // ReturnStatement
 const SReturn StmtType = "SReturn"
 
 type ReturnStatement struct {
-	expr Expr
+	Argument Expr
+	Span     Span
 }
 
 func (s *ReturnStatement) Type() StmtType { return SReturn }
 func (s *ReturnStatement) S() string {
 	var ret string
-	if s.expr == nil {
+	if s.Argument == nil {
 		ret = "undefined"
 	} else {
-		ret = s.expr.S()
+		ret = s.Argument.S()
 	}
 	return fmt.Sprintf("(return %s)", ret)
 }
 
+// ReturnStatement[Yield, Await] :
+// | 'return' ';'
+// | 'return' [no LineTerminator here] Expression[+In, ?Yield, ?Await] ';'
 func (p *Parser) parseReturnStatement() (*ReturnStatement, error) {
 	if p.Peek().Type != l.TReturn {
-		return nil, fmt.Errorf("expected 'return', got %v", p.Peek().Type)
+		return nil, p.needToken(fmt.Errorf("expected 'return', got %v", p.Peek().Type))
 	}
 
 	var returnStmt ReturnStatement
+	start := p.Peek()
 	p.Next() // consume 'return'
-	if expr, err := p.parseExpr(); err == nil {
-		returnStmt.expr = expr
+	if next := p.Peek(); !next.NewlineBefore && p.startsExpression(next) {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		returnStmt.Argument = expr
 	}
 
 	if p.Peek().Type == l.TSemicolon {
 		p.Next() // consume ';'
 	}
+	returnStmt.Span = p.spanFrom(start)
 	return &returnStmt, nil
 }
 
@@ -132,11 +168,11 @@ func (s *IfStatement) S() string {
 
 func (p *Parser) parseIfStatement() (*IfStatement, error) {
 	if p.Peek().Type != l.TIf {
-		return nil, fmt.Errorf("expected 'if' keyword, got %v", p.Peek().Type)
+		return nil, p.needToken(fmt.Errorf("expected 'if' keyword, got %v", p.Peek().Type))
 	}
 	p.Next() // consume 'if'
 	if p.Peek().Type != l.TLeftParen {
-		return nil, fmt.Errorf("expected '(' after 'if' keyword, got %v", p.Peek().Type)
+		return nil, p.needToken(fmt.Errorf("expected '(' after 'if' keyword, got %v", p.Peek().Type))
 	}
 	p.Next() // consume '('
 	condition, err := p.parseExpr()
@@ -144,24 +180,165 @@ func (p *Parser) parseIfStatement() (*IfStatement, error) {
 		return nil, err
 	}
 	if p.Peek().Type != l.TRightParen {
-		return nil, fmt.Errorf("expected ')' after expression in 'if' statement, got %v", p.Peek().Type)
+		return nil, p.needToken(fmt.Errorf("expected ')' after expression in 'if' statement, got %v", p.Peek().Type))
 	}
 	p.Next() // consume ')'
-	thenStmt, err := p.parseStatement()
+	thenStmt, err := p.parseSubstatement(true)
 	if err != nil {
 		return nil, err
 	}
 	var elseStmt Stmt
 	if p.Peek().Type == l.TElse {
 		p.Next() // Consume the 'else' token
-		elseStmt, err = p.parseStatement()
+		elseStmt, err = p.parseSubstatement(true)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if isLabelledFunction(thenStmt) || isLabelledFunction(elseStmt) {
+		return nil, fmt.Errorf("labelled function declaration cannot be the body of an 'if' statement")
+	}
 	return &IfStatement{Condition: condition, ThenStmt: thenStmt, ElseStmt: elseStmt}, nil
 }
 
+// parseSubstatement parses the Statement nested in another statement, where
+// declarations are not allowed. Annex B still allows sloppy mode code to have a
+// plain function declaration as the body of an if or labelled statement, which
+// allowFunction tells apart.
+//
+// https://262.ecma-international.org/#sec-functiondeclarations-in-ifstatement-statement-clauses
+func (p *Parser) parseSubstatement(allowFunction bool) (Stmt, error) {
+	token, next := p.Peek(), p.PeekN(1)
+	switch {
+	case token.Type == l.TLet, token.Type == l.TConst:
+		return nil, errorAt(token, fmt.Errorf("lexical declaration cannot appear in a single-statement context"))
+	case isAsyncFunction(token, next):
+		return nil, errorAt(token, fmt.Errorf("async function declaration cannot appear in a single-statement context"))
+	case token.Type == l.TFunction:
+		if !allowFunction || !p.options.AnnexB || p.strict || next.Type == l.TStar {
+			return nil, errorAt(token, fmt.Errorf("function declaration cannot appear in a single-statement context"))
+		}
+	}
+
+	return p.parseStatement()
+}
+
+// isLabelledFunction reports whether stmt is a function declaration under one
+// or more labels, which cannot be the body of an if or with statement.
+func isLabelledFunction(stmt Stmt) bool {
+	labelled, ok := stmt.(*LabelledStatement)
+	if !ok {
+		return false
+	}
+	switch body := labelled.Body.(type) {
+	case *FunctionDeclarationStmt:
+		return true
+	case *LabelledStatement:
+		return isLabelledFunction(body)
+	}
+	return false
+}
+
+// WithStatement[Yield, Await, Return] :
+// | 'with' '(' Expression[+In, ?Yield, ?Await] ')' Statement[?Yield, ?Await, ?Return]
+//
+// It is an early error for strict mode code to contain a WithStatement, which
+// is checked by Validate.
+type WithStatement struct {
+	Object Expr
+	Body   Stmt
+	Span   Span
+}
+
+func (s *WithStatement) Type() StmtType { return SStmt }
+func (s *WithStatement) S() string {
+	return fmt.Sprintf("(with %s %s)", s.Object.S(), s.Body.S())
+}
+
+func (p *Parser) parseWithStatement() (*WithStatement, error) {
+	start := p.Peek()
+	if start.Type != l.TWith {
+		return nil, p.needToken(fmt.Errorf("expected 'with' keyword, got %v", start.Type))
+	}
+	p.Next() // consume 'with'
+	if p.Peek().Type != l.TLeftParen {
+		return nil, p.needToken(fmt.Errorf("expected '(' after 'with' keyword, got %v", p.Peek().Type))
+	}
+	p.Next() // consume '('
+	object, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Peek().Type != l.TRightParen {
+		return nil, p.needToken(fmt.Errorf("expected ')' after expression in 'with' statement, got %v", p.Peek().Type))
+	}
+	p.Next() // consume ')'
+	body, err := p.parseSubstatement(false)
+	if err != nil {
+		return nil, err
+	}
+	if isLabelledFunction(body) {
+		return nil, fmt.Errorf("labelled function declaration cannot be the body of a 'with' statement")
+	}
+	return &WithStatement{Object: object, Body: body, Span: p.spanFrom(start)}, nil
+}
+
+// DebuggerStatement :
+// | 'debugger' ';'
+type DebuggerStatement struct {
+	Span Span
+}
+
+func (s *DebuggerStatement) Type() StmtType { return SStmt }
+func (s *DebuggerStatement) S() string      { return "debugger" }
+
+func (p *Parser) parseDebuggerStatement() (*DebuggerStatement, error) {
+	start := p.Peek()
+	if start.Type != l.TDebugger {
+		return nil, p.needToken(fmt.Errorf("expected 'debugger' keyword, got %v", start.Type))
+	}
+	p.Next() // consume 'debugger'
+	if p.Peek().Type == l.TSemicolon {
+		p.Next() // consume ';'
+	}
+	return &DebuggerStatement{Span: p.spanFrom(start)}, nil
+}
+
+// LabelledStatement[Yield, Await, Return] :
+// | LabelIdentifier[?Yield, ?Await] ':' LabelledItem[?Yield, ?Await, ?Return]
+//
+// LabelledItem[Yield, Await, Return] :
+// | Statement[?Yield, ?Await, ?Return]
+// | FunctionDeclaration[?Yield, ?Await, ~Default]
+//
+// A labelled FunctionDeclaration is only allowed by Annex B, in sloppy mode
+// code.
+//
+// https://262.ecma-international.org/#sec-labelled-function-declarations
+type LabelledStatement struct {
+	Label *ExprIdentifier
+	Body  Stmt
+}
+
+func (s *LabelledStatement) Type() StmtType { return SStmt }
+func (s *LabelledStatement) S() string {
+	return fmt.Sprintf("(label %s %s)", s.Label.S(), s.Body.S())
+}
+
+func (p *Parser) parseLabelledStatement() (*LabelledStatement, error) {
+	token := p.Peek()
+	if !p.isIdentifier(token) || p.PeekN(1).Type != l.TColon {
+		return nil, p.needToken(fmt.Errorf("expected label, got %v", token.Lexeme))
+	}
+	p.Next() // consume label
+	p.Next() // consume ':'
+	body, err := p.parseSubstatement(true)
+	if err != nil {
+		return nil, err
+	}
+	return &LabelledStatement{Label: newIdentifier(token), Body: body}, nil
+}
+
 // BlockStatement[Yield, Await, Return] :
 // | Block[?Yield, ?Await, ?Return]
 //
@@ -193,7 +370,7 @@ func (s *BlockStatement) S() string {
 }
 func (p *Parser) parseBlockStatement() (Stmt, error) {
 	if p.Peek().Type != l.TLeftBrace {
-		return nil, fmt.Errorf("expected '{', got %v", p.Peek().Lexeme)
+		return nil, p.needToken(fmt.Errorf("expected '{', got %v", p.Peek().Lexeme))
 	}
 
 	p.Next() // Consume the '{' token
@@ -212,11 +389,11 @@ func (p *Parser) parseBlockStatement() (Stmt, error) {
 const StmtExpression = "StmtExpression"
 
 func (s *ExpressionStatement) S() string {
-	return s.expression.S()
+	return s.Expression.S()
 }
 
 type ExpressionStatement struct {
-	expression Expr
+	Expression Expr
 }
 
 // ExpressionStatement[Yield, Await] :
@@ -231,7 +408,7 @@ func (p *Parser) parseExpressionStatement() (*ExpressionStatement, error) {
 		p.Next() // consume ';'
 	}
 
-	return &ExpressionStatement{expression: expr}, nil
+	return &ExpressionStatement{Expression: expr}, nil
 }
 
 // BreakableStatement[Yield, Await, Return] :
@@ -293,12 +470,17 @@ func (p *Parser) parseExpressionStatement() (*ExpressionStatement, error) {
 // | GeneratorDeclaration[?Yield, ?Await, ?Default]
 // | AsyncFunctionDeclaration[?Yield, ?Await, ?Default]
 // | AsyncGeneratorDeclaration[?Yield, ?Await, ?Default]
-// func (p *Parser) parseHoistableDeclaration() (Node, error) {
 
 // FunctionDeclaration : 'default'? 'function' BindingIdentifier? '(' FormalParameters ')' '{' FunctionBody '}'
 //
 // FunctionExpression : 'function' BindingIdentifier? '(' FormalParameters ')' '{' FunctionBody '}'
 //
+// GeneratorDeclaration : 'function' '*' BindingIdentifier '(' FormalParameters[+Yield, ~Await] ')' '{' GeneratorBody '}'
+//
+// AsyncFunctionDeclaration : 'async' [no LineTerminator here] 'function' BindingIdentifier '(' FormalParameters[~Yield, +Await] ')' '{' AsyncFunctionBody '}'
+//
+// AsyncGeneratorDeclaration : 'async' [no LineTerminator here] 'function' '*' BindingIdentifier '(' FormalParameters[+Yield, +Await] ')' '{' AsyncGeneratorBody '}'
+//
 // FunctionBody : FunctionStatementList
 //
 // FunctionStatementList : StatementList
@@ -306,6 +488,9 @@ type FunctionDeclarationStmt struct {
 	BindingIdentifier *ExprIdentifier
 	Params            []Node
 	Body              []Stmt
+	Generator         bool
+	Async             bool
+	Strict            bool // whether the function is strict mode code
 }
 
 func (s *FunctionDeclarationStmt) Type() StmtType {
@@ -329,68 +514,117 @@ func (s *FunctionDeclarationStmt) S() string {
 		}
 	}
 
+	fn := functionKind("fn", s.Generator, s.Async)
 	if s.BindingIdentifier == nil {
-		return fmt.Sprintf("(fn (%s) %s)", stmts.String(), params.String())
+		return fmt.Sprintf("(%s (%s) %s)", fn, stmts.String(), params.String())
 	} else {
-		return fmt.Sprintf("(fn %s (%s) %s)", s.BindingIdentifier.S(), stmts.String(), params.String())
+		return fmt.Sprintf("(%s %s (%s) %s)", fn, s.BindingIdentifier.S(), stmts.String(), params.String())
 	}
 }
 
+// functionKind decorates the name used to print a function with its kind
+func functionKind(name string, generator, async bool) string {
+	if generator {
+		name += "*"
+	}
+	if async {
+		name = "async " + name
+	}
+	return name
+}
+
+// isAsyncFunction reports whether token and next start an async function,
+// 'async' [no LineTerminator here] 'function'.
+func isAsyncFunction(token, next l.Token) bool {
+	return token.Type == l.TAsync && next.Type == l.TFunction && !next.NewlineBefore
+}
+
 func (p *Parser) parseFunctionDeclaration() (Node, error) {
+	var generator, async bool
+	if isAsyncFunction(p.Peek(), p.PeekN(1)) {
+		p.Next() // consume 'async'
+		async = true
+	}
+
 	if p.Peek().Type != l.TFunction {
-		return nil, fmt.Errorf("expected function, got %s", p.Peek().Lexeme)
+		return nil, p.needToken(fmt.Errorf("expected function, got %s", p.Peek().Lexeme))
 	}
 	p.Next() // consume 'function'
 
+	if p.Peek().Type == l.TStar {
+		p.Next() // consume '*'
+		generator = true
+	}
+
 	var bindingIdentifier *ExprIdentifier
-	switch cur := p.Peek().Type; cur {
-	case l.TIdentifier:
-		bindingIdentifier = &ExprIdentifier{p.Peek().Lexeme}
+	switch cur := p.Peek(); {
+	case p.isIdentifier(cur):
+		bindingIdentifier = newIdentifier(cur)
 		p.Next() // consume identifier
-		if p.Peek().Type != l.TLeftParen {
-			return nil, fmt.Errorf("expected left paren, got %s", p.Peek().Lexeme)
-		}
-		p.Next() // consume '('
-	case l.TLeftParen:
+	case cur.Type == l.TLeftParen:
 		bindingIdentifier = nil
-		p.Next() // consume '('
 	default:
-		return nil, fmt.Errorf("expected identifier or left paren, got %s", cur.S())
+		return nil, p.needToken(fmt.Errorf("expected identifier or left paren, got %s", cur.Lexeme))
 	}
 
+	fnDecl := &FunctionDeclarationStmt{
+		BindingIdentifier: bindingIdentifier,
+		Generator:         generator,
+		Async:             async,
+	}
+	err := p.withContext(generator, async, func() (err error) {
+		if fnDecl.Params, err = p.parseFormalParameters(); err != nil {
+			return err
+		}
+		fnDecl.Body, err = p.parseFunctionBody()
+		fnDecl.Strict = p.strict
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fnDecl, nil
+}
+
+// FormalParameters[Yield, Await] :
+// | [empty]
+// | FunctionRestParameter[?Yield, ?Await]
+// | FormalParameterList[?Yield, ?Await]
+// | FormalParameterList[?Yield, ?Await] ','
+// | FormalParameterList[?Yield, ?Await] ',' FunctionRestParameter[?Yield, ?Await]
+//
+// it parses the surrounding parentheses as well.
+func (p *Parser) parseFormalParameters() ([]Node, error) {
+	if p.Peek().Type != l.TLeftParen {
+		return nil, p.needToken(fmt.Errorf("expected left paren, got %s", p.Peek().Lexeme))
+	}
+	p.Next() // consume '('
+
 	params := []Node{}
 loop:
 	for {
 		// parse current parameter
-		switch curParam := p.Peek(); curParam.Type {
-		case l.TRightParen:
+		switch curParam := p.Peek(); {
+		case curParam.Type == l.TRightParen:
 			p.Next() // consume ')'
 			break loop
-		case l.TIdentifier:
-			p.Next() // consume identifier
-			params = append(params, &ExprIdentifier{curParam.Lexeme})
-		case l.TLeftBrace, l.TLeftBracket:
-			if pattern, err := p.parseBindingPattern(); err != nil {
+		case curParam.Type == l.TEllipsis:
+			// FunctionRestParameter : BindingRestElement
+			rest, err := p.parseBindingRestElement(true)
+			if err != nil {
 				return nil, err
-			} else {
-				params = append(params, pattern)
 			}
-		case l.TEllipsis:
-			p.Next() // consume '...'
-			spreadExpr := &SpreadElement{}
-			switch restParam := p.Peek().Type; restParam {
-			case l.TIdentifier:
-				spreadExpr.argument = &ExprIdentifier{curParam.Lexeme}
-			case l.TLeftBrace, l.TLeftBracket:
-				if pattern, err := p.parseBindingPattern(); err != nil {
-					return nil, err
-				} else {
-					spreadExpr.argument = pattern
-				}
-			default:
-				return nil, fmt.Errorf("invalid rest parameter, got %s", restParam.S())
+			params = append(params, rest)
+			if p.Peek().Type != l.TRightParen {
+				return nil, fmt.Errorf("rest parameter must be last, got %s", p.Peek().Lexeme)
+			}
+		case p.isIdentifier(curParam), curParam.Type == l.TLeftBrace, curParam.Type == l.TLeftBracket:
+			// FormalParameter : BindingElement
+			param, err := p.parseBindingElement()
+			if err != nil {
+				return nil, err
 			}
-			params = append(params, spreadExpr)
+			params = append(params, param)
 		default:
 			return nil, fmt.Errorf("invalid formal params (id or pattern), got %s", curParam.Lexeme)
 		}
@@ -403,20 +637,80 @@ loop:
 			p.Next() // consume ')'
 			break loop
 		default:
-			return nil, fmt.Errorf("expected comma or right paren, got %s", curToken.Lexeme)
+			return nil, p.needToken(fmt.Errorf("expected comma or right paren, got %s", curToken.Lexeme))
 		}
 	}
+	return params, nil
+}
 
-	// var blockStmt *BlockStatement
-	if blockStmt, err := p.parseBlockStatement(); err != nil {
-		return nil, err
-	} else {
-		return &FunctionDeclarationStmt{
-			Body:              blockStmt.(*BlockStatement).Stmts,
-			Params:            params,
-			BindingIdentifier: bindingIdentifier,
-		}, nil
+// FunctionBody[Yield, Await] :
+// | FunctionStatementList[?Yield, ?Await]
+//
+// it parses the surrounding braces as well. A Use Strict Directive in its
+// Directive Prologue makes the rest of the body strict mode code, so callers
+// are expected to restore the strictness of the enclosing code afterwards.
+func (p *Parser) parseFunctionBody() ([]Stmt, error) {
+	if p.Peek().Type != l.TLeftBrace {
+		return nil, p.needToken(fmt.Errorf("expected '{', got %v", p.Peek().Lexeme))
+	}
+	p.Next() // consume '{'
+
+	stmts := []Stmt{}
+	prologue := true
+	for p.Peek().Type != l.TRightBrace {
+		if p.Peek().Type == l.TEOF {
+			return nil, p.needToken(fmt.Errorf("expected '}' at the end of the function body"))
+		}
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		if prologue {
+			prologue = p.applyDirective(stmt)
+		}
+		stmts = append(stmts, stmt)
+	}
+	p.Next() // consume '}'
+	return stmts, nil
+}
+
+// directiveOf returns the string literal of stmt if it may be part of a
+// Directive Prologue: an ExpressionStatement made of a single StringLiteral.
+func directiveOf(stmt Stmt) *ExprLiteral[string] {
+	exprStmt, ok := stmt.(*ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	literal, ok := exprStmt.Expression.(*ExprLiteral[string])
+	if !ok {
+		return nil
 	}
+	switch literal.Token.Type {
+	case l.TStringLiteral_SingleQuote, l.TStringLiteral_DoubleQuote:
+		return literal
+	}
+	return nil
+}
+
+// isUseStrict reports whether directive is a Use Strict Directive: the exact
+// code units of either 'use strict' or "use strict", without escapes.
+func isUseStrict(directive *ExprLiteral[string]) bool {
+	lexeme := directive.Token.Lexeme
+	return len(lexeme) >= 2 && lexeme[1:len(lexeme)-1] == "use strict"
+}
+
+// applyDirective makes the code that follows stmt strict mode code if stmt is
+// a Use Strict Directive. It reports whether stmt is a directive, i.e. whether
+// the Directive Prologue may continue.
+func (p *Parser) applyDirective(stmt Stmt) bool {
+	directive := directiveOf(stmt)
+	if directive == nil {
+		return false
+	}
+	if isUseStrict(directive) {
+		p.strict = true
+	}
+	return true
 }
 
 // LexicalDeclaration[In, Yield, Await] :
@@ -444,21 +738,21 @@ loop:
 // | BindingIdentifier[?Yield, ?Await] Initializer[?In, ?Yield, ?Await]opt
 // | BindingPattern[?Yield, ?Await] Initializer[?In, ?Yield, ?Await]
 type VariableDeclaration struct {
-	identifier *ExprIdentifier // todo: more accurate naming
-	init       Expr
-	pattern    Expr
+	Identifier *ExprIdentifier // todo: more accurate naming
+	Init       Expr
+	Pattern    Expr
 }
 
 func (s *VariableDeclaration) S() string {
 	var lhs, rhs string
-	if s.identifier != nil {
-		lhs = s.identifier.name
+	if s.Identifier != nil {
+		lhs = s.Identifier.Name
 	} else {
-		lhs = s.pattern.S()
+		lhs = s.Pattern.S()
 	}
 
-	if s.init != nil {
-		rhs = fmt.Sprintf(" <- %s", s.init.S())
+	if s.Init != nil {
+		rhs = fmt.Sprintf(" <- %s", s.Init.S())
 	} else {
 		rhs = ""
 	}
@@ -466,17 +760,17 @@ func (s *VariableDeclaration) S() string {
 }
 
 type VariableStatement struct {
-	kind         l.Token
-	declarations []*VariableDeclaration
+	Kind         l.Token
+	Declarations []*VariableDeclaration
 }
 
 func (s *VariableStatement) S() string {
 	var buf bytes.Buffer
 
-	buf.WriteString(fmt.Sprintf("(%s ", s.kind.Type.S()))
-	for i, decl := range s.declarations {
+	buf.WriteString(fmt.Sprintf("(%s ", s.Kind.Type.S()))
+	for i, decl := range s.Declarations {
 		buf.WriteString(decl.S())
-		if i < len(s.declarations)-1 {
+		if i < len(s.Declarations)-1 {
 			buf.WriteString(" ")
 		}
 	}
@@ -515,7 +809,7 @@ func (p *Parser) parseVariableStatement() (*VariableStatement, error) {
 		p.Next() // consume ';'
 	}
 
-	return &VariableStatement{declarations: varDeclList, kind: kind}, nil
+	return &VariableStatement{Declarations: varDeclList, Kind: kind}, nil
 }
 
 // VariableDeclarationList[In, Yield, Await] :
@@ -553,8 +847,8 @@ func (p *Parser) parseVariableDeclaration() (*VariableDeclaration, error) {
 
 	token := p.Peek()
 
-	if token.Type == l.TIdentifier {
-		identifier = &ExprIdentifier{name: token.Lexeme}
+	if p.isIdentifier(token) {
+		identifier = newIdentifier(token)
 		p.Next() // consume identifier
 	} else {
 		pattern, err = p.parseBindingPattern()
@@ -574,80 +868,12 @@ func (p *Parser) parseVariableDeclaration() (*VariableDeclaration, error) {
 	}
 
 	if identifier == nil && pattern == nil {
-		return nil, fmt.Errorf("expected identifier or pattern, got %s", token.String())
+		return nil, p.needToken(fmt.Errorf("expected identifier or pattern, got %s", token.String()))
 	}
 
 	if pattern != nil && init == nil {
-		return nil, fmt.Errorf("expected initializer for pattern, got %s", token.String())
+		return nil, p.needToken(fmt.Errorf("expected initializer for pattern, got %s", token.String()))
 	}
 
-	return &VariableDeclaration{identifier: identifier, pattern: pattern, init: init}, nil
-}
-
-// BindingIdentifier[Yield, Await] :
-// | Identifier
-// | yield
-// | await
-//
-// BindingPattern[Yield, Await] :
-// | ObjectBindingPattern[?Yield, ?Await]
-// | ArrayBindingPattern[?Yield, ?Await]
-//
-// ObjectBindingPattern[Yield, Await] :
-// | '{' '}'
-// | '{' BindingRestProperty[?Yield, ?Await] '}'
-// | '{' BindingPropertyList[?Yield, ?Await] '}'
-// | '{' BindingPropertyList[?Yield, ?Await] , BindingRestProperty[?Yield, ?Await]? '}'
-//
-// ArrayBindingPattern[Yield, Await] :
-// | '[' Elision? BindingRestElement[?Yield, ?Await]? ']'
-// | '[' BindingElementList[?Yield, ?Await] ']'
-// | '[' BindingElementList[?Yield, ?Await] ',' Elision? BindingRestElement[?Yield, ?Await]? ']'
-//
-// BindingRestProperty[Yield, Await] :
-// | '...' BindingIdentifier[?Yield, ?Await]
-//
-// BindingPropertyList[Yield, Await] :
-// | BindingProperty[?Yield, ?Await]
-// | BindingPropertyList[?Yield, ?Await] ',' BindingProperty[?Yield, ?Await]
-//
-// BindingElementList[Yield, Await] :
-// | BindingElisionElement[?Yield, ?Await]
-// | BindingElementList[?Yield, ?Await] ',' BindingElisionElement[?Yield, ?Await]
-//
-// BindingElisionElement[Yield, Await] :
-// | Elision? BindingElement[?Yield, ?Await]
-//
-// BindingProperty[Yield, Await] :
-// | SingleNameBinding[?Yield, ?Await]
-//
-// PropertyName[?Yield, ?Await] : BindingElement[?Yield, ?Await]
-//
-// BindingElement[Yield, Await] :
-// | SingleNameBinding[?Yield, ?Await]
-// | BindingPattern[?Yield, ?Await] Initializer[+In, ?Yield, ?Await?]
-//
-// SingleNameBinding[Yield, Await] :
-// | BindingIdentifier[?Yield, ?Await] Initializer[+In, ?Yield, ?Await?]
-//
-// BindingRestElement[Yield, Await] :
-// | '...' BindingIdentifier[?Yield, ?Await]
-// | '...' BindingPattern[?Yield, ?Await]
-//
-// Initializer[In, Yield, Await] : '=' AssignmentExpression[?In, ?Yield, ?Await]
-//
-// BindingPattern[Yield, Await] :
-// | ObjectBindingPattern[?Yield, ?Await]
-// | ArrayBindingPattern[?Yield, ?Await]
-func (p *Parser) parseBindingPattern() (Expr, error) {
-	switch p.Peek().Type {
-	// TODO: there are legal syntax in object init that are not legal in binding pattern
-	// e.g {a: 1, b: 2}, { a(), async b() } is legal in object init but not in binding pattern
-	case l.TLeftBrace:
-		return p.parseObjectInitializer()
-	case l.TLeftBracket:
-		return p.parseArrayInitializer()
-	default:
-		return nil, fmt.Errorf("expected an object or array binding pattern")
-	}
+	return &VariableDeclaration{Identifier: identifier, Pattern: pattern, Init: init}, nil
 }
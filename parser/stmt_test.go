@@ -12,7 +12,7 @@ func TestParseVariableStatement(t *testing.T) {
 		logger := internal.NewSimpleLogger(internal.ModeDebug)
 		src := `{}`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&BlockStatement{},
 			},
 		}
@@ -27,17 +27,17 @@ func TestParseVariableStatement(t *testing.T) {
 		kind := l.TVar
 
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: kind.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: kind.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							identifier: &ExprIdentifier{name: "x"},
-							init:       intExpr(10),
+							Identifier: &ExprIdentifier{Name: "x"},
+							Init:       intExpr(10),
 						},
 						{
-							pattern: &ExprIdentifier{name: "y"},
-							init:    intExpr(20),
+							Pattern: &ExprIdentifier{Name: "y"},
+							Init:    intExpr(20),
 						},
 					},
 				},
@@ -54,17 +54,17 @@ func TestParseVariableStatement(t *testing.T) {
 		kind := l.TConst
 
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: kind.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: kind.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							identifier: &ExprIdentifier{name: "a"},
-							init:       intExpr(5),
+							Identifier: &ExprIdentifier{Name: "a"},
+							Init:       intExpr(5),
 						},
 						{
-							identifier: &ExprIdentifier{name: "b"},
-							init:       intExpr(10),
+							Identifier: &ExprIdentifier{Name: "b"},
+							Init:       intExpr(10),
 						},
 					},
 				},
@@ -83,33 +83,30 @@ func TestParseBindingPattern(t *testing.T) {
 		kind := l.TLet
 
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: kind.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: kind.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							pattern: &ExprObject{
-								properties: []*PropertyDefinition{
-									{
-										key:       idExpr("u"),
-										value:     idExpr("u"),
-										shorthand: true,
-									},
-									{
-										key:   idExpr("a"),
-										value: idExpr("y"),
+							Pattern: &ObjectPattern{
+								Properties: []Node{
+									&PatternProperty{
+										Key:       idExpr("u"),
+										Value:     idExpr("u"),
+										Shorthand: true,
 									},
-									{
-										key:   idExpr("b"),
-										value: idExpr("x"),
+									&PatternProperty{
+										Key:   idExpr("a"),
+										Value: idExpr("y"),
 									},
-									{
-										key:   idExpr("a"),
-										value: &SpreadElement{argument: idExpr("a")},
+									&PatternProperty{
+										Key:   idExpr("b"),
+										Value: idExpr("x"),
 									},
+									&RestElement{Argument: idExpr("a")},
 								},
 							},
-							init: idExpr("obj"),
+							Init: idExpr("obj"),
 						},
 					},
 				},
@@ -126,19 +123,19 @@ func TestParseBindingPattern(t *testing.T) {
 		kind := l.TLet
 
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: kind.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: kind.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							pattern: &ExprArray{
-								elements: []Expr{
+							Pattern: &ArrayPattern{
+								Elements: []Node{
 									idExpr("f"),
 									idExpr("b"),
-									&SpreadElement{argument: idExpr("q")},
+									&RestElement{Argument: idExpr("q")},
 								},
 							},
-							init: idExpr("arr"),
+							Init: idExpr("arr"),
 						},
 					},
 				},
@@ -160,16 +157,16 @@ func TestParseIfStatement(t *testing.T) {
 		tlet := l.TLet
 
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&IfStatement{
 					Condition: binExpr(idExpr("x"), intExpr(10), l.TGreaterThan),
 					ThenStmt: &BlockStatement{
 						Stmts: []Stmt{
 							&ExpressionStatement{
-								expression: &ExprAssign{
-									operator: tassign.Token(),
-									left:     idExpr("a"),
-									right:    intExpr(1),
+								Expression: &ExprAssign{
+									Operator: tassign.Token(),
+									Left:     idExpr("a"),
+									Right:    intExpr(1),
 								},
 							},
 						},
@@ -177,11 +174,11 @@ func TestParseIfStatement(t *testing.T) {
 					ElseStmt: &BlockStatement{
 						Stmts: []Stmt{
 							&VariableStatement{
-								kind: tlet.Token(),
-								declarations: []*VariableDeclaration{
+								Kind: tlet.Token(),
+								Declarations: []*VariableDeclaration{
 									{
-										identifier: idExpr("b"),
-										init:       intExpr(2),
+										Identifier: idExpr("b"),
+										Init:       intExpr(2),
 									},
 								},
 							},
@@ -201,16 +198,16 @@ func TestParseIfStatement(t *testing.T) {
 
 		tassign := l.TAssign
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&IfStatement{
 					Condition: binExpr(idExpr("x"), intExpr(10), l.TGreaterThan),
 					ThenStmt: &BlockStatement{
 						Stmts: []Stmt{
 							&ExpressionStatement{
-								expression: &ExprAssign{
-									operator: tassign.Token(),
-									left:     idExpr("a"),
-									right:    idExpr("b"),
+								Expression: &ExprAssign{
+									Operator: tassign.Token(),
+									Left:     idExpr("a"),
+									Right:    idExpr("b"),
 								},
 							},
 						},
@@ -232,7 +229,7 @@ func TestFunctionDeclaration(t *testing.T) {
 			return a + b;
 		}`
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&FunctionDeclarationStmt{
 					BindingIdentifier: idExpr("testFunction"),
 					Params: []Node{
@@ -241,7 +238,7 @@ func TestFunctionDeclaration(t *testing.T) {
 					},
 					Body: []Stmt{
 						&ReturnStatement{
-							expr: binExpr(idExpr("a"), idExpr("b"), l.TPlus),
+							Argument: binExpr(idExpr("a"), idExpr("b"), l.TPlus),
 						},
 					},
 				},
@@ -258,31 +255,31 @@ func TestFunctionDeclaration(t *testing.T) {
 		}`
 		tlet := l.TLet
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: tlet.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: tlet.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							identifier: idExpr("fn"),
-							pattern:    nil,
-							init: &ExprFunction{
+							Identifier: idExpr("fn"),
+							Pattern:    nil,
+							Init: &ExprFunction{
 								BindingIdentifier: nil,
 								Params: []Node{
-									&ExprObject{
-										properties: []*PropertyDefinition{
-											{key: idExpr("a"), value: idExpr("a"), shorthand: true},
-											{key: idExpr("b"), value: idExpr("c")},
+									&ObjectPattern{
+										Properties: []Node{
+											&PatternProperty{Key: idExpr("a"), Value: idExpr("a"), Shorthand: true},
+											&PatternProperty{Key: idExpr("b"), Value: idExpr("c")},
 										},
 									},
-									&ExprArray{[]Expr{idExpr("d")}},
-									&SpreadElement{&ExprObject{
-										properties: []*PropertyDefinition{
-											{key: idExpr("e"), value: idExpr("e"), shorthand: true},
+									&ArrayPattern{Elements: []Node{idExpr("d")}},
+									&RestElement{&ObjectPattern{
+										Properties: []Node{
+											&PatternProperty{Key: idExpr("e"), Value: idExpr("e"), Shorthand: true},
 										},
 									}},
 								},
 								Body: []Stmt{
-									&ReturnStatement{binExpr(idExpr("e"), idExpr("d"), l.TPlus)},
+									&ReturnStatement{Argument: binExpr(idExpr("e"), idExpr("d"), l.TPlus)},
 								},
 							},
 						},
@@ -301,18 +298,18 @@ func TestFunctionDeclaration(t *testing.T) {
 		}`
 		tconst := l.TConst
 		exp := &NodeRoot{
-			children: []Node{
+			Children: []Node{
 				&VariableStatement{
-					kind: tconst.Token(),
-					declarations: []*VariableDeclaration{
+					Kind: tconst.Token(),
+					Declarations: []*VariableDeclaration{
 						{
-							identifier: idExpr("fn"),
-							pattern:    nil,
-							init: &ExprFunction{
+							Identifier: idExpr("fn"),
+							Pattern:    nil,
+							Init: &ExprFunction{
 								BindingIdentifier: nil,
 								Params:            []Node{},
 								Body: []Stmt{
-									&ReturnStatement{idExpr("a")},
+									&ReturnStatement{Argument: idExpr("a")},
 								},
 							},
 						},
@@ -323,4 +320,367 @@ func TestFunctionDeclaration(t *testing.T) {
 		got := Parse(logger, src)
 		AssertStmtEqual(t, logger, got, exp)
 	})
+
+	t.Run("return followed by a line terminator", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function f() {
+			return
+			a
+		}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("f"),
+					Params:            []Node{},
+					Body: []Stmt{
+						&ReturnStatement{},
+						&ExpressionStatement{Expression: idExpr("a")},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("rejected return arguments", func(t *testing.T) {
+		for _, src := range []string{
+			`function f() { return 1 + }`,
+			`a(function () { return (b })`,
+		} {
+			if _, err := ParseFile(src, Options{}); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		}
+	})
+}
+
+func TestGeneratorAndAsyncFunctions(t *testing.T) {
+	t.Run("generator declaration", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function* gen(a) { yield a; yield* other(); yield; }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("gen"),
+					Generator:         true,
+					Params:            []Node{idExpr("a")},
+					Body: []Stmt{
+						&ExpressionStatement{&ExprYield{Argument: idExpr("a")}},
+						&ExpressionStatement{&ExprYield{
+							Argument: &ExprCall{Callee: idExpr("other"), Arguments: []Expr{}},
+							Delegate: true,
+						}},
+						&ExpressionStatement{&ExprYield{}},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("async function and async generator declarations", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `async function f(x) { return await x; } async function* g() { yield await 1; }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("f"),
+					Async:             true,
+					Params:            []Node{idExpr("x")},
+					Body: []Stmt{
+						&ReturnStatement{Argument: &ExprAwait{Argument: idExpr("x")}},
+					},
+				},
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("g"),
+					Async:             true,
+					Generator:         true,
+					Params:            []Node{},
+					Body: []Stmt{
+						&ExpressionStatement{&ExprYield{Argument: &ExprAwait{Argument: intExpr(1)}}},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("generator and async function expressions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `const g = function*() {}, f = async function named() {}`
+		tconst := l.TConst
+		exp := &NodeRoot{
+			Children: []Node{
+				&VariableStatement{
+					Kind: tconst.Token(),
+					Declarations: []*VariableDeclaration{
+						{
+							Identifier: idExpr("g"),
+							Init:       &ExprFunction{Generator: true, Params: []Node{}},
+						},
+						{
+							Identifier: idExpr("f"),
+							Init:       &ExprFunction{BindingIdentifier: idExpr("named"), Async: true, Params: []Node{}},
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("yield and await are identifiers outside of their context", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `var yield = 1, await = 2; function f(yield) { return await; }`
+		tvar := l.TVar
+		exp := &NodeRoot{
+			Children: []Node{
+				&VariableStatement{
+					Kind: tvar.Token(),
+					Declarations: []*VariableDeclaration{
+						{Identifier: idExpr("yield"), Init: intExpr(1)},
+						{Identifier: idExpr("await"), Init: intExpr(2)},
+					},
+				},
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("f"),
+					Params:            []Node{idExpr("yield")},
+					Body: []Stmt{
+						&ReturnStatement{Argument: idExpr("await")},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("yield within a nested function is not a yield expression", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function* g() { function f() { return yield; } }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("g"),
+					Generator:         true,
+					Params:            []Node{},
+					Body: []Stmt{
+						&FunctionDeclarationStmt{
+							BindingIdentifier: idExpr("f"),
+							Params:            []Node{},
+							Body:              []Stmt{&ReturnStatement{Argument: idExpr("yield")}},
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("async before a line break is an identifier", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "async\nfunction f() {}"
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExpressionStatement{Expression: idExpr("async")},
+				&FunctionDeclarationStmt{BindingIdentifier: idExpr("f"), Params: []Node{}},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("rest parameter", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `function f(a, ...rest) {}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&FunctionDeclarationStmt{
+					BindingIdentifier: idExpr("f"),
+					Params:            []Node{idExpr("a"), spreadExpr(idExpr("rest"))},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+}
+
+func TestGeneratorAndAsyncFunctions_Rejected(t *testing.T) {
+	srcs := []string{
+		`(function* () { var yield; })`,
+		`(async function () { var await; })`,
+		`(async function () { function* g() { var yield; } })`,
+		`async () => { var await; }`,
+		`async function f() { var await }`,
+		`x = async function () { var await }`,
+		`async function f() { await a ** 2 }`,
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(src, logger).ScanAll()
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
+
+func TestWithAndDebuggerStatements(t *testing.T) {
+	t.Run("with statement", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `with (a.b) { c; } debugger;`
+		exp := &NodeRoot{
+			Children: []Node{
+				&WithStatement{
+					Object: &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b")},
+					Body: &BlockStatement{
+						Stmts: []Stmt{&ExpressionStatement{Expression: idExpr("c")}},
+					},
+				},
+				&DebuggerStatement{},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("labelled statement", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a: b: { c; }`
+		exp := &NodeRoot{
+			Children: []Node{
+				&LabelledStatement{
+					Label: idExpr("a"),
+					Body: &LabelledStatement{
+						Label: idExpr("b"),
+						Body: &BlockStatement{
+							Stmts: []Stmt{&ExpressionStatement{Expression: idExpr("c")}},
+						},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+}
+
+func TestAnnexB(t *testing.T) {
+	options := Options{AnnexB: true}
+	fn := func(name string) *FunctionDeclarationStmt {
+		return &FunctionDeclarationStmt{BindingIdentifier: idExpr(name), Body: []Stmt{}}
+	}
+
+	t.Run("function declarations as if bodies", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `if (a) function f() {} else function g() {}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&IfStatement{Condition: idExpr("a"), ThenStmt: fn("f"), ElseStmt: fn("g")},
+			},
+		}
+		got := ParseWithOptions(logger, src, options)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("labelled function declarations", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a: b: function f() {}`
+		exp := &NodeRoot{
+			Children: []Node{
+				&LabelledStatement{
+					Label: idExpr("a"),
+					Body:  &LabelledStatement{Label: idExpr("b"), Body: fn("f")},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, options)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("HTML-like comments", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "a; <!-- b\n--> c\nd;"
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExpressionStatement{Expression: idExpr("a")},
+				&ExpressionStatement{Expression: idExpr("d")},
+			},
+		}
+		got := ParseWithOptions(logger, src, options)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("legacy octal-like literals", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := `a = 010 + 08 + 019.5;`
+		tassign := l.TAssign
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExpressionStatement{
+					Expression: &ExprAssign{
+						Operator: tassign.Token(),
+						Left:     idExpr("a"),
+						Right: binExpr(
+							binExpr(intExpr(8), intExpr(8), l.TPlus),
+							&ExprLiteral[float64]{Token: l.Token{Type: l.TNumericLiteral, Literal: 19.5}},
+							l.TPlus,
+						),
+					},
+				},
+			},
+		}
+		got := ParseWithOptions(logger, src, options)
+		AssertStmtEqual(t, logger, got, exp)
+	})
+
+	t.Run("RegExp extensions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		got := ParseWithOptions(logger, `a = /\1{/;`, options)
+		regexp := got.(*NodeRoot).Children[0].(*ExpressionStatement).Expression.(*ExprAssign).Right
+		if pattern := regexp.(*ExprRegExp).Pattern; pattern != `\1{` {
+			t.Errorf("expected pattern \\1{, got %s", pattern)
+		}
+	})
+}
+
+func TestAnnexB_Rejected(t *testing.T) {
+	tcs := []struct {
+		src     string
+		options Options
+	}{
+		{src: `if (a) function f() {}`},
+		{src: `if (a) function f() {}`, options: Options{AnnexB: true, SourceType: SourceTypeModule}},
+		{src: `'use strict'; if (a) function f() {}`, options: Options{AnnexB: true}},
+		{src: `if (a) function* f() {}`, options: Options{AnnexB: true}},
+		{src: `if (a) async function f() {}`, options: Options{AnnexB: true}},
+		{src: `if (a) l: function f() {}`, options: Options{AnnexB: true}},
+		{src: `if (a) let b = 1;`, options: Options{AnnexB: true}},
+		{src: `with (a) function f() {}`, options: Options{AnnexB: true}},
+		{src: `l: function f() {}`},
+		{src: `function g() { 'use strict'; l: function f() {} }`, options: Options{AnnexB: true}},
+		{src: `l: function* f() {}`, options: Options{AnnexB: true}},
+		{src: `a = 010;`},
+		{src: `a = 08;`},
+		{src: `a = 017.5;`, options: Options{AnnexB: true}},
+		{src: `a = /\1{/;`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, _ := l.NewLexer(tc.src, logger).ScanAll()
+			parser := NewParser(tokens, logger)
+			parser.options = tc.options
+			if _, err := parser.parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", tc.src)
+			}
+		})
+	}
 }
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// //////////////////
+// TemplateElement //
+// //////////////////
+const ETemplateElement ExprType = "TemplateElement"
+
+// TemplateElement is one of the string parts (quasis) of a template literal.
+type TemplateElement struct {
+	Cooked *string // template value (TV); nil when Raw has an invalid escape sequence
+	Raw    string  // template raw value (TRV)
+	Tail   bool
+}
+
+func (e *TemplateElement) Type() ExprType {
+	return ETemplateElement
+}
+
+func (e *TemplateElement) S() string {
+	return strconv.Quote(e.Raw)
+}
+
+// //////////////////////
+// ExprTemplateLiteral //
+// //////////////////////
+const ETemplateLiteral ExprType = "ExprTemplateLiteral"
+
+// ExprTemplateLiteral interleaves its quasis with the substituted
+// expressions, so len(Quasis) == len(Expressions)+1.
+type ExprTemplateLiteral struct {
+	Quasis      []*TemplateElement
+	Expressions []Expr
+}
+
+func (e *ExprTemplateLiteral) Type() ExprType {
+	return ETemplateLiteral
+}
+
+func (e *ExprTemplateLiteral) S() string {
+	var src strings.Builder
+	src.WriteString("(template")
+	for i, quasi := range e.Quasis {
+		src.WriteString(" ")
+		src.WriteString(quasi.S())
+		if i < len(e.Expressions) {
+			src.WriteString(" ")
+			src.WriteString(e.Expressions[i].S())
+		}
+	}
+	src.WriteString(")")
+	return src.String()
+}
+
+// /////////////////////
+// ExprTaggedTemplate //
+// /////////////////////
+const ETaggedTemplate ExprType = "ExprTaggedTemplate"
+
+type ExprTaggedTemplate struct {
+	Tag   Expr
+	Quasi *ExprTemplateLiteral
+}
+
+func (e *ExprTaggedTemplate) Type() ExprType {
+	return ETaggedTemplate
+}
+
+func (e *ExprTaggedTemplate) S() string {
+	return fmt.Sprintf("(tag %s %s)", e.Tag.S(), e.Quasi.S())
+}
+
+func isTemplateStart(token l.Token) bool {
+	return token.Type == l.TTemplateLiteral || token.Type == l.TTemplateHead
+}
+
+// TemplateLiteral[Yield, Await, Tagged] :
+// | NoSubstitutionTemplate
+// | SubstitutionTemplate[?Yield, ?Await, ?Tagged]
+//
+// SubstitutionTemplate[Yield, Await, Tagged] :
+// | TemplateHead Expression[+In, ?Yield, ?Await] TemplateSpans[?Yield, ?Await, ?Tagged]
+//
+// TemplateSpans[Yield, Await, Tagged] :
+// | TemplateTail
+// | TemplateMiddleList[?Yield, ?Await, ?Tagged] TemplateTail
+//
+// TemplateMiddleList[Yield, Await, Tagged] :
+// | TemplateMiddle Expression[+In, ?Yield, ?Await]
+// | TemplateMiddleList[?Yield, ?Await, ?Tagged] TemplateMiddle Expression[+In, ?Yield, ?Await]
+//
+// Only [+Tagged] templates may contain invalid escape sequences, in which case
+// the cooked value of the quasi is undefined.
+func (p *Parser) parseTemplateLiteral(tagged bool) (*ExprTemplateLiteral, error) {
+	p.Log("parseTemplateLiteral")
+	token := p.Peek()
+	if !isTemplateStart(token) {
+		return nil, p.needToken(fmt.Errorf("expected template literal, got %s", token.Lexeme))
+	}
+
+	exprTemplate := &ExprTemplateLiteral{}
+	for {
+		p.Next() // consume template token
+		quasi, err := p.templateElement(token, tagged)
+		if err != nil {
+			return nil, err
+		}
+		exprTemplate.Quasis = append(exprTemplate.Quasis, quasi)
+		if quasi.Tail {
+			return exprTemplate, nil
+		}
+
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprTemplate.Expressions = append(exprTemplate.Expressions, expr)
+
+		token = p.Peek()
+		if token.Type != l.TTemplateMiddle && token.Type != l.TTemplateTail {
+			return nil, p.needToken(fmt.Errorf("expected '}' after template substitution, got %s", token.Lexeme))
+		}
+	}
+}
+
+func (p *Parser) templateElement(token l.Token, tagged bool) (*TemplateElement, error) {
+	raw, _ := token.Literal.(string)
+	quasi := &TemplateElement{
+		Raw:  raw,
+		Tail: token.Type == l.TTemplateLiteral || token.Type == l.TTemplateTail,
+	}
+	if cooked, ok := l.CookTemplate(raw); ok {
+		quasi.Cooked = &cooked
+	} else if !tagged {
+		return nil, fmt.Errorf("invalid escape sequence in template literal: %s", token.Lexeme)
+	}
+	return quasi, nil
+}
+
+// parseTaggedTemplate parses the TemplateLiteral that follows tag, as in the
+// MemberExpression TemplateLiteral and CallExpression TemplateLiteral
+// productions.
+func (p *Parser) parseTaggedTemplate(tag Expr) (Expr, error) {
+	quasi, err := p.parseTemplateLiteral(true)
+	if err != nil {
+		return nil, err
+	}
+	return &ExprTaggedTemplate{Tag: tag, Quasi: quasi}, nil
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+func TestTemplateLiteral(t *testing.T) {
+	t.Run("no substitution template", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "`hello world`"
+		exp := &NodeRoot{
+			Children: []Node{templateExpr([]string{"hello world"})},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("substitutions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "`a${b}c${d + 1}`"
+		exp := &NodeRoot{
+			Children: []Node{
+				templateExpr(
+					[]string{"a", "c", ""},
+					idExpr("b"),
+					binExpr(idExpr("d"), intExpr(1), l.TPlus),
+				),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("nested templates and object literals", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "`${ {a: `${b}`} }`"
+		exp := &NodeRoot{
+			Children: []Node{
+				templateExpr(
+					[]string{"", ""},
+					&ExprObject{
+						Properties: []*PropertyDefinition{
+							{Key: idExpr("a"), Value: templateExpr([]string{"", ""}, idExpr("b"))},
+						},
+					},
+				),
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("cooked and raw values", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "`a\\tb\\u{41}`"
+		got := Parse(logger, src).(*NodeRoot)
+		quasi := got.Children[0].(*ExpressionStatement).Expression.(*ExprTemplateLiteral).Quasis[0]
+		if quasi.Raw != `a\tb\u{41}` || quasi.Cooked == nil || *quasi.Cooked != "a\tbA" {
+			t.Errorf("unexpected quasi: raw %q cooked %v", quasi.Raw, quasi.Cooked)
+		}
+	})
+}
+
+func TestTaggedTemplate(t *testing.T) {
+	t.Run("tagged template with substitutions", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "html`<p>${name}</p>`"
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprTaggedTemplate{
+					Tag:   idExpr("html"),
+					Quasi: templateExpr([]string{"<p>", "</p>"}, idExpr("name")),
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("invalid escapes are allowed in tagged templates", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "latex`\\unicode`"
+		got := Parse(logger, src).(*NodeRoot)
+		quasi := got.Children[0].(*ExpressionStatement).Expression.(*ExprTaggedTemplate).Quasi.Quasis[0]
+		if quasi.Raw != `\unicode` || quasi.Cooked != nil {
+			t.Errorf("unexpected quasi: raw %q cooked %v", quasi.Raw, quasi.Cooked)
+		}
+	})
+
+	t.Run("tagged template in a new expression", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "new tag`a`(b)"
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprNew{
+					Callee: &ExprTaggedTemplate{
+						Tag:   idExpr("tag"),
+						Quasi: templateExpr([]string{"a"}),
+					},
+					Arguments: []Expr{idExpr("b")},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+
+	t.Run("template in an optional chain's arguments", func(t *testing.T) {
+		logger := internal.NewSimpleLogger(internal.ModeDebug)
+		src := "a?.b(`c`)"
+		exp := &NodeRoot{
+			Children: []Node{
+				&ExprChain{
+					Expression: &ExprCall{
+						Callee:    &ExprMemberAccess{Object: idExpr("a"), Property: idExpr("b"), Optional: true},
+						Arguments: []Expr{templateExpr([]string{"c"})},
+					},
+				},
+			},
+		}
+		got := Parse(logger, src)
+		AssertExprEqual(t, logger, got, exp)
+	})
+}
+
+func TestTemplate_Rejected(t *testing.T) {
+	srcs := []string{
+		"`\\unicode`",
+		"`${a`",
+		"`${}`",
+		"a?.`b`",
+		"a?.b`c`",
+		"a?.()`c${d}`",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			logger := internal.NewSimpleLogger(internal.ModeDebug)
+			tokens, errs := l.NewLexer(src, logger).ScanAll()
+			if len(errs) > 0 {
+				return
+			}
+			if _, err := NewParser(tokens, logger).parseProgram(); err == nil {
+				t.Errorf("expected %q to be rejected", src)
+			}
+		})
+	}
+}
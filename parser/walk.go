@@ -0,0 +1,130 @@
+package parser
+
+// Walk calls visit on node and, unless it returns false, on the statements,
+// expressions and patterns nested in it, parents first.
+func Walk(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *NodeRoot:
+		for _, child := range n.Children {
+			Walk(child, visit)
+		}
+	case *ExpressionStatement:
+		Walk(n.Expression, visit)
+	case *VariableStatement:
+		for _, decl := range n.Declarations {
+			if decl.Identifier != nil {
+				Walk(decl.Identifier, visit)
+			}
+			Walk(decl.Pattern, visit)
+			Walk(decl.Init, visit)
+		}
+	case *ReturnStatement:
+		Walk(n.Argument, visit)
+	case *IfStatement:
+		Walk(n.Condition, visit)
+		Walk(n.ThenStmt, visit)
+		Walk(n.ElseStmt, visit)
+	case *BlockStatement:
+		walkAll(n.Stmts, visit)
+	case *WithStatement:
+		Walk(n.Object, visit)
+		Walk(n.Body, visit)
+	case *LabelledStatement:
+		Walk(n.Body, visit)
+	case *FunctionDeclarationStmt:
+		walkAll(n.Params, visit)
+		walkAll(n.Body, visit)
+	case *ExportNamedDeclaration:
+		Walk(n.Declaration, visit)
+		for _, specifier := range n.Specifiers {
+			Walk(specifier, visit)
+		}
+	case *ExportDefaultDeclaration:
+		Walk(n.Declaration, visit)
+
+	case *ExprUnaryOp:
+		Walk(n.Operand, visit)
+	case *ExprBinaryOp:
+		Walk(n.Left, visit)
+		Walk(n.Right, visit)
+	case *ExprAssign:
+		Walk(n.Left, visit)
+		Walk(n.Right, visit)
+	case *ExprConditional:
+		Walk(n.Test, visit)
+		Walk(n.Consequent, visit)
+		Walk(n.Alternate, visit)
+	case *ExprNew:
+		Walk(n.Callee, visit)
+		walkAll(n.Arguments, visit)
+	case *ExprCall:
+		Walk(n.Callee, visit)
+		walkAll(n.Arguments, visit)
+	case *ExprMemberAccess:
+		Walk(n.Object, visit)
+		if n.Computed {
+			Walk(n.Property, visit)
+		}
+	case *ExprChain:
+		Walk(n.Expression, visit)
+	case *ExprImportCall:
+		Walk(n.Source, visit)
+	case *SpreadElement:
+		Walk(n.Argument, visit)
+	case *ExprYield:
+		Walk(n.Argument, visit)
+	case *ExprAwait:
+		Walk(n.Argument, visit)
+	case *ExprParenthesized:
+		Walk(n.Expression, visit)
+	case *ExprSequence:
+		walkAll(n.Expressions, visit)
+	case *ExprArray:
+		walkAll(n.Elements, visit)
+	case *ExprObject:
+		for _, property := range n.Properties {
+			Walk(property, visit)
+		}
+	case *PropertyDefinition:
+		if n.Computed {
+			Walk(n.Key, visit)
+		}
+		Walk(n.Value, visit)
+	case *ExprTemplateLiteral:
+		walkAll(n.Expressions, visit)
+	case *ExprTaggedTemplate:
+		Walk(n.Tag, visit)
+		Walk(n.Quasi, visit)
+	case *ExprFunction:
+		walkAll(n.Params, visit)
+		walkAll(n.Body, visit)
+	case *ExprArrowFunction:
+		walkAll(n.Params, visit)
+		walkAll(n.Body, visit)
+		Walk(n.Expression, visit)
+
+	case *ObjectPattern:
+		walkAll(n.Properties, visit)
+	case *PatternProperty:
+		if n.Computed {
+			Walk(n.Key, visit)
+		}
+		Walk(n.Value, visit)
+	case *ArrayPattern:
+		walkAll(n.Elements, visit)
+	case *AssignmentPattern:
+		Walk(n.Left, visit)
+		Walk(n.Right, visit)
+	case *RestElement:
+		Walk(n.Argument, visit)
+	}
+}
+
+func walkAll[T Node](nodes []T, visit func(Node) bool) {
+	for _, node := range nodes {
+		Walk(node, visit)
+	}
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{src: "a + b * c", expected: "a b c"},
+		{src: "var [x = y] = z; f(...w)", expected: "x y z f w"},
+		{src: "o.p[q]; ({ [k]: v, u })", expected: "o q k v u"},
+		{src: "if (a) { b } else c`${d}`", expected: "a b c d"},
+		// the visit stops at functions
+		{src: "f(function g(x) { return y }, z => z)", expected: "f"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			file, err := ParseFile(tc.src, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			Walk(file.Program, func(node Node) bool {
+				switch node := node.(type) {
+				case *ExprIdentifier:
+					names = append(names, node.Name)
+				case *ExprFunction, *ExprArrowFunction:
+					return false
+				}
+				return true
+			})
+			if got := strings.Join(names, " "); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package printer
+
+import (
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// Comments before a statement are printed in lines of their own above it, and
+// those after it in the same line are printed after it. Within a statement,
+// comments are printed next to the identifier, literal, unary operation or
+// function they are written before, or after the last of them, as in
+// f(a, /* b */ b) or f(a /* a */). Comments left at the end of a statement list,
+// or within an empty block, are printed in lines of their own before it is
+// closed. The few comments left, as those before a keyword or a template, are
+// printed after the statement.
+
+// statements prints a StatementList, a statement per line in Readable mode.
+// Empty statements are left out.
+func (p *printer) statements(stmts []parser.Stmt) {
+	p.lastLine = 0
+	for i, stmt := range stmts {
+		if _, ok := stmt.(*parser.EmptyStatement); ok {
+			continue
+		}
+		span, ok := p.spans[stmt]
+		if !ok {
+			p.newline()
+			p.listItem(stmt)
+			continue
+		}
+
+		p.leadingComments(span.Start)
+		p.startLine(span.Start.Line)
+		anchor, bound := p.anchor, p.bound
+		p.anchor, p.bound = p.lastAnchor(stmt, span.End)
+		p.within(stmt, func() { p.listItem(stmt) })
+		p.anchor, p.bound = anchor, bound
+		p.lastLine = span.End.Line
+		p.trailingComments(span.End, p.nextStart(stmts[i+1:]))
+	}
+
+	// the comments left before the end of the enclosing statement or function
+	for p.next < len(p.comments) && (p.limit == nil || before(p.comments[p.next], *p.limit)) {
+		p.ownLineComment(p.comments[p.next])
+	}
+}
+
+// within prints a statement or function, so that the comments left at the end
+// of its statement lists are those before its end.
+func (p *printer) within(node parser.Node, print func()) {
+	span, ok := p.spans[node]
+	if !ok {
+		print()
+		return
+	}
+	limit := p.limit
+	p.limit = &span.End
+	p.mark(span.Start, "")
+	print()
+	p.limit = limit
+}
+
+// lastAnchor returns the identifier, literal, unary operation or function of
+// stmt, outside its nested blocks and functions, that starts last, after which
+// the comments left before bound are printed: the start of the nested block
+// that follows it, or the end of stmt.
+func (p *printer) lastAnchor(stmt parser.Stmt, end parser.Position) (anchor parser.Node, bound parser.Position) {
+	if p.next == len(p.comments) {
+		return nil, end
+	}
+	var last parser.Position
+	var blocks []parser.Position
+	consider := func(node parser.Node) {
+		if start, ok := p.start(node); ok && (anchor == nil || less(last, start)) {
+			anchor, last = node, start
+		}
+	}
+	parser.Walk(stmt, func(node parser.Node) bool {
+		switch n := node.(type) {
+		case *parser.BlockStatement, *parser.FunctionDeclarationStmt:
+			if span, ok := p.spans[node]; ok && node != stmt {
+				blocks = append(blocks, span.Start)
+			}
+			return false
+		case *parser.ExprFunction, *parser.ExprArrowFunction:
+			consider(node)
+			return false
+		case *parser.ExprMemberAccess:
+			if !n.Computed {
+				consider(n.Property)
+			}
+		}
+		consider(node)
+		return true
+	})
+
+	bound = end
+	for _, start := range blocks {
+		if anchor != nil && less(last, start) && less(start, bound) {
+			bound = start
+		}
+	}
+	return anchor, bound
+}
+
+// start returns where node starts, if it is an identifier, literal, unary
+// operation, meta property or function whose position is known.
+func (p *printer) start(node parser.Node) (parser.Position, bool) {
+	var start parser.Position
+	switch n := node.(type) {
+	case *parser.ExprIdentifier:
+		start = n.Span.Start
+	case *parser.ExprUnaryOp:
+		start = n.Span.Start
+	case *parser.ExprMetaProperty:
+		start = n.Span.Start
+	case *parser.ExprLiteral[string]:
+		start = parser.Position{Line: n.Token.Line, Column: n.Token.Column}
+	case *parser.ExprLiteral[float64]:
+		start = parser.Position{Line: n.Token.Line, Column: n.Token.Column}
+	case *parser.ExprFunction, *parser.ExprArrowFunction:
+		start = p.spans[node].Start
+	}
+	return start, start.Line > 0
+}
+
+// commentsBefore prints, in the same line, the comments left before node.
+func (p *printer) commentsBefore(node parser.Node) {
+	if p.next == len(p.comments) {
+		return
+	}
+	start, ok := p.start(node)
+	for ok && p.next < len(p.comments) && before(p.comments[p.next], start) {
+		if p.last != ' ' && p.last != '\n' && p.buf.Len() > 0 {
+			p.write(" ")
+		}
+		if !p.inlineComment(p.comments[p.next]) {
+			p.space()
+		}
+	}
+}
+
+// commentsAfter prints, in the same line, the comments left after node when
+// it is the last anchor of the statement being printed.
+func (p *printer) commentsAfter(node parser.Node) {
+	if node != p.anchor {
+		return
+	}
+	p.anchor = nil
+	for p.next < len(p.comments) && before(p.comments[p.next], p.bound) {
+		p.write(" ")
+		p.inlineComment(p.comments[p.next])
+	}
+}
+
+// inlineComment prints a comment within a line and reports whether it is a
+// single line comment, which is followed by a new line so that it does not
+// comment out what follows.
+func (p *printer) inlineComment(comment l.Comment) bool {
+	p.comment(comment)
+	if strings.HasPrefix(comment.Text, "//") {
+		p.newline()
+		return true
+	}
+	return false
+}
+
+// lineCommentBefore reports whether a single line comment is left before
+// last, the last item of a list, which then gets an item per line, rather than
+// one followed by a new line within a line of items.
+func (p *printer) lineCommentBefore(last parser.Node) bool {
+	if p.next == len(p.comments) || last == nil {
+		return false
+	}
+	start, ok := p.firstStart(last)
+	for i := p.next; ok && i < len(p.comments) && before(p.comments[i], start); i++ {
+		if strings.HasPrefix(p.comments[i].Text, "//") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstStart returns the start of the first node within node whose position
+// is known.
+func (p *printer) firstStart(node parser.Node) (start parser.Position, found bool) {
+	parser.Walk(node, func(node parser.Node) bool {
+		if !found {
+			start, found = p.start(node)
+		}
+		return !found
+	})
+	return start, found
+}
+
+// danglingComments reports whether comments are left before the end of the
+// statement or function being printed, as in an empty block.
+func (p *printer) danglingComments() bool {
+	return p.limit != nil && p.next < len(p.comments) && before(p.comments[p.next], *p.limit)
+}
+
+// nextStart returns where the first of stmts starts, if known.
+func (p *printer) nextStart(stmts []parser.Stmt) *parser.Position {
+	for _, stmt := range stmts {
+		if span, ok := p.spans[stmt]; ok {
+			return &span.Start
+		}
+	}
+	return nil
+}
+
+// hasStatements reports whether a StatementList prints any statement.
+func hasStatements(stmts []parser.Stmt) bool {
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*parser.EmptyStatement); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// listItem prints a statement of a StatementList. Without semicolons, an
+// expression statement starting with one of ( [ ` + - / would continue the
+// previous statement, so it starts with a semicolon instead.
+func (p *printer) listItem(stmt parser.Stmt) {
+	if _, ok := stmt.(*parser.ExpressionStatement); ok && p.readable() && p.options.OmitSemicolons {
+		q := newPrinter(p.options)
+		q.flat = true
+		q.stmt(stmt)
+		switch q.buf.String()[0] {
+		case '(', '[', '`', '+', '-', '/':
+			p.print(";")
+		}
+	}
+	p.stmt(stmt)
+}
+
+// startLine starts the line of a statement or comment that starts at the given
+// line of the source, after a blank line if there was one before it.
+func (p *printer) startLine(line int) {
+	if p.readable() && p.lastLine > 0 && line > p.lastLine+1 && p.buf.Len() > 0 {
+		p.write("\n")
+	}
+	p.newline()
+}
+
+// leadingComments prints the comments before the given position.
+func (p *printer) leadingComments(pos parser.Position) {
+	for p.next < len(p.comments) && before(p.comments[p.next], pos) {
+		p.ownLineComment(p.comments[p.next])
+	}
+}
+
+func (p *printer) ownLineComment(comment l.Comment) {
+	p.startLine(comment.Line)
+	p.comment(comment)
+}
+
+// trailingComments prints, after a statement ending at the given position, the
+// comments left within it and those in its last line, up to the start of the
+// next statement or the end of the enclosing one.
+func (p *printer) trailingComments(end parser.Position, next *parser.Position) {
+	for p.next < len(p.comments) {
+		comment := p.comments[p.next]
+		if !before(comment, end) && (comment.Line != end.Line ||
+			next != nil && !before(comment, *next) || p.limit != nil && !before(comment, *p.limit)) {
+			return
+		}
+		p.write(" ")
+		p.comment(comment)
+	}
+}
+
+func (p *printer) comment(comment l.Comment) {
+	p.write(comment.Text)
+	p.last, p.visible = '/', '/'
+	if line := comment.EndLine(); line > p.lastLine {
+		// comments printed after a statement may come from within it
+		p.lastLine = line
+	}
+	p.next++
+}
+
+// less reports whether a is before b.
+func less(a, b parser.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// before reports whether comment starts before pos.
+func before(comment l.Comment, pos parser.Position) bool {
+	return comment.Line < pos.Line || comment.Line == pos.Line && comment.Column < pos.Column
+}
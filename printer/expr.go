@@ -0,0 +1,676 @@
+package printer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// Precedence levels, from the loosest to the tightest binding expression. An
+// operand is parenthesized when its level is lower than the one required by
+// its position.
+//
+// https://262.ecma-international.org/#sec-ecmascript-language-expressions
+const (
+	precLowest         = iota
+	precSequence       // a, b
+	precAssign         // a = b, a => b, yield a
+	precConditional    // a ? b : c
+	precShortCircuit   // a || b, a ?? b
+	precLogicalAnd     // a && b
+	precBitwiseOr      // a | b
+	precBitwiseXor     // a ^ b
+	precBitwiseAnd     // a & b
+	precEquality       // a == b
+	precRelational     // a < b, a in b
+	precShift          // a << b
+	precAdditive       // a + b
+	precMultiplicative // a * b
+	precExponent       // a ** b
+	precUnary          // -a, ++a, await a
+	precPostfix        // a++
+	precCall           // a(), a?.b
+	precMember         // a.b, new a()
+	precPrimary        // a, 1, [a], (a)
+)
+
+var binaryPrecedence = map[l.TokenType]int{
+	l.TDoubleQuestionMark: precShortCircuit,
+	l.TLogicalOr:          precShortCircuit,
+	l.TLogicalAnd:         precLogicalAnd,
+	l.TOr:                 precBitwiseOr,
+	l.TXor:                precBitwiseXor,
+	l.TAnd:                precBitwiseAnd,
+	l.TEqual:              precEquality,
+	l.TNotEqual:           precEquality,
+	l.TStrictEqual:        precEquality,
+	l.TStrictNotEqual:     precEquality,
+	l.TLessThan:           precRelational,
+	l.TGreaterThan:        precRelational,
+	l.TLessThanEqual:      precRelational,
+	l.TGreaterThanEqual:   precRelational,
+	l.TIn:                 precRelational,
+	l.TInstanceof:         precRelational,
+	l.TLeftShift:          precShift,
+	l.TRightShift:         precShift,
+	l.TUnsignedRightShift: precShift,
+	l.TPlus:               precAdditive,
+	l.TMinus:              precAdditive,
+	l.TStar:               precMultiplicative,
+	l.TSlash:              precMultiplicative,
+	l.TPercent:            precMultiplicative,
+	l.TStarStar:           precExponent,
+}
+
+// precedence returns the level of expr.
+func precedence(expr parser.Node) int {
+	switch e := expr.(type) {
+	case *parser.ExprSequence:
+		return precSequence
+	case *parser.ExprAssign, *parser.ExprArrowFunction, *parser.ExprYield:
+		return precAssign
+	case *parser.ExprConditional:
+		return precConditional
+	case *parser.ExprBinaryOp:
+		if prec, ok := binaryPrecedence[e.Operator.Type]; ok {
+			return prec
+		}
+		return precLowest
+	case *parser.ExprUnaryOp:
+		if e.Postfix {
+			return precPostfix
+		}
+		return precUnary
+	case *parser.ExprAwait:
+		return precUnary
+	case *parser.ExprCall, *parser.ExprChain, *parser.ExprImportCall:
+		return precCall
+	case *parser.ExprMemberAccess, *parser.ExprNew, *parser.ExprTaggedTemplate:
+		return precMember
+	}
+	return precPrimary
+}
+
+// expr prints expr within parentheses if its level is lower than level.
+func (p *printer) expr(expr parser.Node, level int) {
+	p.exprParen(expr, level, false)
+}
+
+// exprParen prints expr within parentheses if its level is lower than level,
+// if force is set or if it would be mistaken for a declaration or a block.
+func (p *printer) exprParen(expr parser.Node, level int, force bool) {
+	expr = parser.Unparenthesized(expr)
+	if force || precedence(expr) < level || p.ambiguousStart(expr) {
+		p.print("(")
+		p.exprBare(expr)
+		p.print(")")
+		return
+	}
+	p.exprBare(expr)
+}
+
+// ambiguousStart reports whether expr, printed at the current position, would
+// start an expression statement with '{', 'function' or 'async function',
+// an export default expression with a function, or the concise body of an
+// arrow function with '{'.
+func (p *printer) ambiguousStart(expr parser.Node) bool {
+	offset := p.buf.Len()
+	atStmt := offset == p.stmtStart
+	switch e := expr.(type) {
+	case *parser.ExprObject:
+		return atStmt || offset == p.arrowBodyStart
+	case *parser.ExprFunction:
+		return atStmt || offset == p.exportDefaultStart
+	case *parser.ExprAssign:
+		// ({ a } = b), which is not a block followed by '= b'
+		switch parser.Unparenthesized(e.Left).(type) {
+		case *parser.ObjectPattern, *parser.ExprObject:
+			return atStmt || offset == p.arrowBodyStart
+		}
+	}
+	return false
+}
+
+// exprBare prints expr along with the comments next to it.
+func (p *printer) exprBare(expr parser.Node) {
+	p.commentsBefore(expr)
+	p.exprNode(expr)
+	p.commentsAfter(expr)
+}
+
+func (p *printer) exprNode(expr parser.Node) {
+	switch e := expr.(type) {
+	case *parser.ExprIdentifier:
+		p.identifier(e)
+	case *parser.ExprPrivateIdentifier:
+		p.print("#" + e.Name)
+	case *parser.ExprLiteral[string]:
+		p.literal(e.Token)
+	case *parser.ExprLiteral[float64]:
+		p.literal(e.Token)
+	case *parser.ExprRegExp:
+		p.regexp(e)
+	case *parser.ExprTemplateLiteral:
+		p.template(e)
+	case *parser.ExprTaggedTemplate:
+		p.exprParen(e.Tag, precCall, isChain(e.Tag))
+		p.template(e.Quasi)
+	case *parser.ExprArray:
+		p.array(e)
+	case *parser.ExprObject:
+		p.object(e)
+	case *parser.ExprFunction:
+		p.within(e, func() { p.function(e.BindingIdentifier, e.Params, e.Body, e.Generator, e.Async) })
+	case *parser.ExprArrowFunction:
+		p.within(e, func() { p.arrowFunction(e) })
+	case *parser.ExprSequence:
+		for i, expr := range e.Expressions {
+			if i > 0 {
+				p.comma()
+			}
+			p.expr(expr, precAssign)
+		}
+	case *parser.ExprAssign:
+		p.expr(e.Left, precCall)
+		p.space()
+		p.print(operator(e.Operator))
+		p.space()
+		p.expr(e.Right, precAssign)
+	case *parser.ExprConditional:
+		p.expr(e.Test, precShortCircuit)
+		p.space()
+		p.print("?")
+		p.space()
+		p.expr(e.Consequent, precAssign)
+		p.space()
+		p.print(":")
+		p.space()
+		p.expr(e.Alternate, precAssign)
+	case *parser.ExprBinaryOp:
+		p.binary(e)
+	case *parser.ExprUnaryOp:
+		p.unary(e)
+	case *parser.ExprAwait:
+		p.print("await")
+		p.space()
+		p.expr(e.Argument, precUnary)
+	case *parser.ExprYield:
+		p.print("yield")
+		if e.Delegate {
+			p.print("*")
+		}
+		if e.Argument != nil {
+			p.space()
+			p.expr(e.Argument, precAssign)
+		}
+	case *parser.ExprNew:
+		p.print("new")
+		p.space()
+		p.exprParen(e.Callee, precMember, containsCall(e.Callee))
+		p.arguments(e.Arguments)
+	case *parser.ExprCall:
+		p.exprParen(e.Callee, precCall, isChain(e.Callee))
+		if e.Optional {
+			p.print("?.")
+		}
+		p.arguments(e.Arguments)
+	case *parser.ExprMemberAccess:
+		p.memberAccess(e)
+	case *parser.ExprChain:
+		p.exprBare(parser.Unparenthesized(e.Expression))
+	case *parser.ExprMetaProperty:
+		p.exprBare(e.Meta)
+		p.print(".")
+		p.exprBare(e.Property)
+	case *parser.ExprImportCall:
+		p.print("import")
+		p.print("(")
+		p.expr(e.Source, precAssign)
+		p.print(")")
+	case *parser.SpreadElement:
+		p.print("...")
+		p.expr(e.Argument, precAssign)
+	case *parser.ObjectPattern:
+		p.objectPattern(e)
+	case *parser.ArrayPattern:
+		p.list("[", e.Elements, "]")
+	case *parser.AssignmentPattern:
+		p.expr(e.Left, precCall)
+		p.space()
+		p.print("=")
+		p.space()
+		p.expr(e.Right, precAssign)
+	case *parser.RestElement:
+		p.print("...")
+		p.expr(e.Argument, precCall)
+	default:
+		panic(fmt.Sprintf("printer: unexpected node %T", expr))
+	}
+}
+
+// operator returns the source text of an operator token.
+func operator(token l.Token) string {
+	if op := token.Type.S(); op != l.UnknownLiteral {
+		return op
+	}
+	return token.Lexeme
+}
+
+func (p *printer) binary(e *parser.ExprBinaryOp) {
+	prec := precedence(e)
+	left, right := prec, prec+1
+	if e.Operator.Type == l.TStarStar {
+		// ** is right-associative, and its left operand may not be a unary
+		// expression, as in (-a) ** b
+		left, right = precPostfix, prec
+	}
+	p.exprParen(e.Left, left, mixesCoalesce(e, e.Left))
+	p.space()
+	p.print(operator(e.Operator))
+	p.space()
+	p.exprParen(e.Right, right, mixesCoalesce(e, e.Right))
+}
+
+// mixesCoalesce reports whether operand is an operand of e that mixes ?? with
+// && or ||, which requires parentheses.
+func mixesCoalesce(e *parser.ExprBinaryOp, operand parser.Node) bool {
+	child, ok := parser.Unparenthesized(operand).(*parser.ExprBinaryOp)
+	if !ok {
+		return false
+	}
+	isLogical := func(typ l.TokenType) bool { return typ == l.TLogicalAnd || typ == l.TLogicalOr }
+	switch {
+	case e.Operator.Type == l.TDoubleQuestionMark:
+		return isLogical(child.Operator.Type)
+	case isLogical(e.Operator.Type):
+		return child.Operator.Type == l.TDoubleQuestionMark
+	}
+	return false
+}
+
+func (p *printer) unary(e *parser.ExprUnaryOp) {
+	op := operator(e.Operator)
+	p.mark(e.Span.Start, "")
+	if e.Postfix {
+		p.expr(e.Operand, precCall)
+		p.print(op)
+		return
+	}
+	p.print(op)
+	if isIdentifierPart(rune(op[0])) {
+		// delete, typeof and void
+		p.space()
+	}
+	p.expr(e.Operand, precUnary)
+}
+
+func (p *printer) memberAccess(e *parser.ExprMemberAccess) {
+	object := parser.Unparenthesized(e.Object)
+	// (a?.b).c short-circuits differently from a?.b.c, (1).toString would be
+	// scanned as 1. followed by toString, the lexer does not end a number at
+	// the '.' of 1.5.toString or 1e21.toString, and let [ can not start a
+	// statement
+	force := isChain(object) ||
+		!e.Computed && isNumber(object) ||
+		e.Computed && isLet(object) && p.buf.Len() == p.stmtStart
+	p.exprParen(object, precCall, force)
+
+	switch {
+	case e.Computed && e.Optional:
+		p.print("?.")
+		p.print("[")
+	case e.Computed:
+		p.print("[")
+	case e.Optional:
+		p.print("?.")
+	default:
+		p.print(".")
+	}
+	if e.Computed {
+		p.expr(e.Property, precLowest)
+		p.print("]")
+	} else {
+		p.exprBare(e.Property)
+	}
+}
+
+func (p *printer) arguments(arguments []parser.Expr) {
+	p.group("(", ")", len(arguments), false, p.lineCommentBefore(last(arguments)), func(p *printer, i int) {
+		p.expr(arguments[i], precAssign)
+	})
+}
+
+func (p *printer) arrowFunction(e *parser.ExprArrowFunction) {
+	if e.Async {
+		p.print("async")
+		p.space()
+	}
+	if id, ok := singleIdentifier(e.Params); ok && !p.readable() {
+		p.identifier(id)
+	} else {
+		p.params(e.Params)
+	}
+	p.space()
+	p.print("=>")
+	p.space()
+	if e.Expression == nil {
+		p.block(e.Body)
+		return
+	}
+	p.arrowBodyStart = p.buf.Len()
+	p.expr(e.Expression, precAssign)
+}
+
+// singleIdentifier returns the parameter of an arrow function that takes a
+// single plain identifier, whose parentheses can be omitted.
+func singleIdentifier(params []parser.Node) (*parser.ExprIdentifier, bool) {
+	if len(params) != 1 {
+		return nil, false
+	}
+	id, ok := params[0].(*parser.ExprIdentifier)
+	return id, ok
+}
+
+func (p *printer) array(e *parser.ExprArray) {
+	elements := make([]parser.Node, len(e.Elements))
+	for i, element := range e.Elements {
+		if element != parser.ArrayHole {
+			elements[i] = element
+		}
+	}
+	p.list("[", elements, "]")
+}
+
+// list prints the elements of an array literal or pattern, where nil elements
+// are holes.
+func (p *printer) list(open string, elements []parser.Node, close string) {
+	p.group(open, close, len(elements), false, p.lineCommentBefore(last(elements)), func(p *printer, i int) {
+		switch {
+		case elements[i] != nil:
+			p.expr(elements[i], precAssign)
+		case i == len(elements)-1:
+			// a trailing hole needs a trailing comma, as in [a, ,]
+			p.print(",")
+		}
+	})
+}
+
+// object prints an object literal, with a property per line in Readable mode
+// when some property is a function.
+func (p *printer) object(e *parser.ExprObject) {
+	broken := hasFunctions(e) || p.lineCommentBefore(last(e.Properties))
+	p.group("{", "}", len(e.Properties), true, broken, func(p *printer, i int) {
+		p.property(e.Properties[i])
+	})
+}
+
+// hasFunctions reports whether some property of e is a method or a function.
+func hasFunctions(e *parser.ExprObject) bool {
+	for _, prop := range e.Properties {
+		switch parser.Unparenthesized(prop.Value).(type) {
+		case *parser.ExprFunction, *parser.ExprArrowFunction:
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) property(prop *parser.PropertyDefinition) {
+	if spread, ok := prop.Value.(*parser.SpreadElement); ok {
+		p.exprBare(spread)
+		return
+	}
+
+	if fn, ok := prop.Value.(*parser.ExprFunction); ok && (prop.Method || prop.Kind != parser.PropertyInit) {
+		switch {
+		case prop.Kind == parser.PropertyGet:
+			p.print("get")
+			p.space()
+		case prop.Kind == parser.PropertySet:
+			p.print("set")
+			p.space()
+		case fn.Async:
+			p.print("async")
+			p.space()
+		}
+		if fn.Generator {
+			p.print("*")
+		}
+		p.propertyKey(prop.Key, prop.Computed)
+		p.within(fn, func() {
+			p.params(fn.Params)
+			p.space()
+			p.block(fn.Body)
+		})
+		p.commentsAfter(fn)
+		return
+	}
+
+	if prop.Shorthand {
+		if value, ok := prop.Value.(*parser.ExprAssign); ok && sameName(prop.Key, value.Left) {
+			// the CoverInitializedName { a = 1 }
+			p.exprBare(value)
+			return
+		}
+		if sameName(prop.Key, prop.Value) {
+			p.propertyKey(prop.Key, false)
+			return
+		}
+	}
+	p.propertyKey(prop.Key, prop.Computed)
+	p.print(":")
+	p.space()
+	p.expr(prop.Value, precAssign)
+}
+
+func (p *printer) propertyKey(key parser.Expr, computed bool) {
+	if computed {
+		p.print("[")
+		p.expr(key, precAssign)
+		p.print("]")
+		return
+	}
+	p.exprBare(key)
+}
+
+func (p *printer) objectPattern(e *parser.ObjectPattern) {
+	p.group("{", "}", len(e.Properties), true, p.lineCommentBefore(last(e.Properties)), func(p *printer, i int) {
+		prop, ok := e.Properties[i].(*parser.PatternProperty)
+		if !ok {
+			p.expr(e.Properties[i], precAssign)
+			return
+		}
+		if prop.Shorthand && !prop.Computed {
+			target := prop.Value
+			if pattern, ok := target.(*parser.AssignmentPattern); ok {
+				target = pattern.Left
+			}
+			if sameName(prop.Key, parser.Unparenthesized(target)) {
+				p.expr(prop.Value, precAssign)
+				return
+			}
+		}
+		p.propertyKey(prop.Key, prop.Computed)
+		p.print(":")
+		p.space()
+		p.expr(prop.Value, precAssign)
+	})
+}
+
+func (p *printer) template(e *parser.ExprTemplateLiteral) {
+	var src strings.Builder
+	src.WriteString("`")
+	for i, quasi := range e.Quasis {
+		src.WriteString(quasi.Raw)
+		if i < len(e.Expressions) {
+			src.WriteString("${")
+			p.print(src.String())
+			src.Reset()
+			p.expr(e.Expressions[i], precLowest)
+			src.WriteString("}")
+		}
+	}
+	src.WriteString("`")
+	p.print(src.String())
+}
+
+func (p *printer) regexp(e *parser.ExprRegExp) {
+	src := "/" + e.Pattern + "/" + e.Flags
+	switch p.visible {
+	case ')', ']', '}':
+		// the lexer would take the '/' after these for a division, as in
+		// if (a) (/b/).test(c)
+		p.print("(")
+		p.print(src)
+		p.print(")")
+	default:
+		p.print(src)
+	}
+}
+
+// literal prints a literal as written in the source, when it was, with the
+// quotes set by the options.
+func (p *printer) literal(token l.Token) {
+	p.mark(parser.Position{Line: token.Line, Column: token.Column}, "")
+	if token.Lexeme != "" && isStringToken(token.Type) && p.options.Quote != QuotePreserve {
+		p.print(requote(token.Lexeme, p.options.Quote))
+		return
+	}
+	if token.Lexeme != "" {
+		p.print(token.Lexeme)
+		return
+	}
+	switch value := token.Literal.(type) {
+	case string:
+		if isStringToken(token.Type) {
+			p.print(quote(value))
+		} else {
+			p.print(value)
+		}
+	case float64:
+		p.print(formatNumber(value))
+	default:
+		p.print(fmt.Sprintf("%v", value))
+	}
+}
+
+func isStringToken(typ l.TokenType) bool {
+	return typ == l.TStringLiteral_SingleQuote || typ == l.TStringLiteral_DoubleQuote
+}
+
+// formatNumber returns the source text of a number that has no lexeme.
+func formatNumber(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// quote returns s as a double-quoted string literal.
+func quote(s string) string {
+	var src strings.Builder
+	src.WriteByte('"')
+	for _, ch := range s {
+		switch ch {
+		case '"':
+			src.WriteString(`\"`)
+		case '\\':
+			src.WriteString(`\\`)
+		case '\n':
+			src.WriteString(`\n`)
+		case '\r':
+			src.WriteString(`\r`)
+		case '\t':
+			src.WriteString(`\t`)
+		case '\u2028', '\u2029':
+			fmt.Fprintf(&src, `\u%04x`, ch)
+		default:
+			if ch < ' ' || ch == utf8.RuneError {
+				fmt.Fprintf(&src, `\x%02x`, ch)
+			} else {
+				src.WriteRune(ch)
+			}
+		}
+	}
+	src.WriteByte('"')
+	return src.String()
+}
+
+// requote returns the string literal src with the preferred quotes, unless
+// that takes more escapes than its other quotes.
+func requote(src string, preferred Quote) string {
+	quote, other := byte('"'), byte('\'')
+	if preferred == QuoteSingle {
+		quote, other = other, quote
+	}
+	if src[0] == quote {
+		return src
+	}
+	body := src[1 : len(src)-1]
+	if strings.Count(body, string(quote)) > strings.Count(body, string(other)) {
+		return src
+	}
+
+	var requoted strings.Builder
+	requoted.WriteByte(quote)
+	for i := 0; i < len(body); i++ {
+		switch ch := body[i]; {
+		case ch == '\\' && i+1 < len(body):
+			i++
+			if body[i] != other {
+				// \' needs no escape within double quotes, and vice versa
+				requoted.WriteByte('\\')
+			}
+			requoted.WriteByte(body[i])
+		case ch == quote:
+			requoted.WriteByte('\\')
+			requoted.WriteByte(ch)
+		default:
+			requoted.WriteByte(ch)
+		}
+	}
+	requoted.WriteByte(quote)
+	return requoted.String()
+}
+
+func isChain(expr parser.Node) bool {
+	_, ok := parser.Unparenthesized(expr).(*parser.ExprChain)
+	return ok
+}
+
+// containsCall reports whether the callee of a new expression contains a call
+// that would otherwise take the arguments of new, as in new (a())().
+func containsCall(callee parser.Node) bool {
+	for {
+		switch e := parser.Unparenthesized(callee).(type) {
+		case *parser.ExprCall, *parser.ExprChain, *parser.ExprImportCall:
+			return true
+		case *parser.ExprMemberAccess:
+			callee = e.Object
+		case *parser.ExprTaggedTemplate:
+			callee = e.Tag
+		default:
+			return false
+		}
+	}
+}
+
+func isNumber(expr parser.Node) bool {
+	_, ok := expr.(*parser.ExprLiteral[float64])
+	return ok
+}
+
+func isLet(expr parser.Node) bool {
+	id, ok := expr.(*parser.ExprIdentifier)
+	return ok && id.Name == "let"
+}
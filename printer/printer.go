@@ -0,0 +1,654 @@
+// Package printer generates JavaScript source text from a parsed AST.
+//
+// The printed source parses back to an equivalent AST: parentheses are
+// inserted only where the precedence and associativity of the operators, or
+// the restrictions on how a statement may start, require them. Parentheses
+// written in the original source are not preserved.
+package printer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/sourcemap"
+)
+
+// Mode selects the layout of the printed source.
+type Mode int
+
+const (
+	// Readable prints a statement per line, indented, with spaces around
+	// operators and after commas
+	Readable Mode = iota
+	// Compact omits every whitespace that is not needed to separate tokens
+	Compact
+)
+
+// Quote selects the quotes string literals are printed with.
+type Quote int
+
+const (
+	// QuotePreserve prints string literals as written in the source
+	QuotePreserve Quote = iota
+	// QuoteDouble prefers double quotes, unless the string contains more
+	// double quotes than single ones
+	QuoteDouble
+	// QuoteSingle prefers single quotes, unless the string contains more
+	// single quotes than double ones
+	QuoteSingle
+)
+
+// Options configures how an AST is printed.
+type Options struct {
+	Mode Mode
+	// Indent is the indentation unit of Readable output, two spaces when empty
+	Indent string
+	// Width is the line width Readable output tries to fit in: the lists of
+	// arguments, parameters, elements, properties and specifiers that do not
+	// fit in the rest of their line get an item per line. Lines are never
+	// broken when zero
+	Width int
+	Quote Quote
+	// OmitSemicolons leaves out the semicolons ending statements in Readable
+	// mode, relying on automatic semicolon insertion. Statements that would
+	// continue the previous one start with a semicolon instead
+	OmitSemicolons bool
+}
+
+// Print returns the source text of node, which is a program, a statement or
+// an expression.
+func Print(node parser.Node, options Options) string {
+	p := newPrinter(options)
+	switch node := node.(type) {
+	case *parser.NodeRoot:
+		p.program(node)
+	default:
+		if isStatement(node) {
+			p.stmt(node)
+		} else {
+			p.expr(node, precLowest)
+		}
+	}
+	return p.buf.String()
+}
+
+// PrintFile returns the source text of a parsed file. In Readable mode, its
+// comments are kept next to the statements and expressions they are written
+// next to, and so are the blank lines between statements, at most one in a
+// row.
+func PrintFile(file *parser.File, options Options) string {
+	p := newPrinter(options)
+	if p.readable() {
+		p.comments = file.Comments
+		p.spans = file.Spans
+	}
+	p.program(file.Program)
+	return p.buf.String()
+}
+
+func newPrinter(options Options) *printer {
+	if options.Indent == "" {
+		options.Indent = "  "
+	}
+	return &printer{
+		options:            options,
+		stmtStart:          -1,
+		exportDefaultStart: -1,
+		arrowBodyStart:     -1,
+	}
+}
+
+type printer struct {
+	options Options
+	buf     strings.Builder
+	indent  int
+	column  int  // the column of the next character, counted in runes
+	flat    bool // whether lists are kept in a line regardless of Width
+	suffix  int  // the width of what follows the next list in its line
+
+	last    rune // the last character written
+	visible rune // the last character written other than whitespace
+
+	// the offsets where an expression statement, the expression of an export
+	// default declaration and the concise body of an arrow function start,
+	// where some expressions are not allowed without parentheses
+	stmtStart          int
+	exportDefaultStart int
+	arrowBodyStart     int
+
+	// the comments and statement spans of the file being printed, the index of
+	// the next comment to print, the source line the last printed statement or
+	// comment ends at, the end of the statement being printed, and its last
+	// anchor along with where the comments printed after it end
+	comments []l.Comment
+	spans    map[parser.Node]parser.Span
+	next     int
+	lastLine int
+	limit    *parser.Position
+	anchor   parser.Node
+	bound    parser.Position
+
+	// the source map being generated, with the name and lines of the
+	// source, the generated line and UTF-16 column of the next character, and
+	// the mapping of the next token
+	sourceMap   *sourcemap.Generator
+	sourceName  string
+	sourceLines []string
+	line        int
+	column16    int
+	pending     *sourcemap.Mapping
+}
+
+func (p *printer) readable() bool {
+	return p.options.Mode == Readable
+}
+
+// print writes a token, separating it from the previous one when they would
+// otherwise be scanned differently, as in `a in b`, `a - -b` or `a / /b/`.
+func (p *printer) print(token string) {
+	if token == "" {
+		return
+	}
+	first, _ := utf8.DecodeRuneInString(token)
+	if needsSpace(p.last, first) {
+		p.write(" ")
+	}
+	p.flushMark()
+	p.write(token)
+	p.last, _ = utf8.DecodeLastRuneInString(token)
+	p.visible = p.last
+}
+
+// write writes src as is, keeping track of the column.
+func (p *printer) write(src string) {
+	p.buf.WriteString(src)
+	if i := strings.LastIndexByte(src, '\n'); i >= 0 {
+		p.column = utf8.RuneCountInString(src[i+1:])
+	} else {
+		p.column += utf8.RuneCountInString(src)
+	}
+	if p.sourceMap != nil {
+		p.trackPosition(src)
+	}
+}
+
+// space writes a space in Readable mode.
+func (p *printer) space() {
+	if p.readable() {
+		p.write(" ")
+		p.last = ' '
+	}
+}
+
+// newline starts an indented line in Readable mode.
+func (p *printer) newline() {
+	if p.readable() && p.buf.Len() > 0 {
+		p.write("\n" + strings.Repeat(p.options.Indent, p.indent))
+		p.last = '\n'
+	}
+}
+
+// comma writes the separator of a list.
+func (p *printer) comma() {
+	p.print(",")
+	p.space()
+}
+
+// semicolon ends a statement, unless semicolons are omitted.
+func (p *printer) semicolon() {
+	if !p.readable() || !p.options.OmitSemicolons {
+		p.print(";")
+	}
+}
+
+// group prints a list of n items between open and close, separated by commas,
+// within spaces when padded, as in { a, b }. In Readable mode, the list gets
+// an item per line when broken is set or when it does not fit in Width.
+func (p *printer) group(open, close string, n int, padded, broken bool, item func(p *printer, i int)) {
+	suffix := p.suffix
+	p.suffix = 0
+	p.print(open)
+	if n == 0 {
+		p.print(close)
+		return
+	}
+	flat := func(p *printer) {
+		if padded {
+			p.space()
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				p.comma()
+			}
+			item(p, i)
+		}
+		if padded {
+			p.space()
+		}
+		p.print(close)
+	}
+	if !p.readable() || !broken && (p.options.Width <= 0 || p.flat || p.column+p.measure(flat)+suffix <= p.options.Width) {
+		flat(p)
+		return
+	}
+
+	p.indent++
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			p.print(",")
+		}
+		p.newline()
+		item(p, i)
+	}
+	p.indent--
+	p.newline()
+	p.print(close)
+}
+
+// last returns the last of nodes, or nil.
+func last[T parser.Node](nodes []T) parser.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1]
+}
+
+// measure returns the width of the first line printed by render at the current
+// column, with every list kept in a line.
+func (p *printer) measure(render func(p *printer)) int {
+	q := newPrinter(p.options)
+	q.indent, q.column, q.flat = p.indent, p.column, true
+	q.last, q.visible = p.last, p.visible
+	render(q)
+	line := q.buf.String()
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return utf8.RuneCountInString(line)
+}
+
+func needsSpace(last, next rune) bool {
+	switch {
+	case isIdentifierPart(last) && isIdentifierPart(next):
+		return true
+	case last == '+' && next == '+', last == '-' && next == '-':
+		return true
+	case last == '/' && (next == '/' || next == '*'):
+		return true
+	case last == '<' && next == '!':
+		// <!-- starts an HTML-like comment
+		return true
+	}
+	return false
+}
+
+func isIdentifierPart(ch rune) bool {
+	return ch == '_' || ch == '$' || ch == '\\' || ch >= utf8.RuneSelf ||
+		'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9'
+}
+
+// //////////
+// Program //
+// //////////
+func (p *printer) program(root *parser.NodeRoot) {
+	stmts := make([]parser.Stmt, len(root.Children))
+	for i, child := range root.Children {
+		stmts[i] = child
+	}
+	p.statements(stmts)
+	if p.readable() && p.buf.Len() > 0 {
+		p.write("\n")
+	}
+}
+
+// /////////////
+// Statements //
+// /////////////
+
+// isStatement reports whether node is printed as a statement.
+func isStatement(node parser.Node) bool {
+	switch node.(type) {
+	case *parser.ExpressionStatement, *parser.VariableStatement, *parser.FunctionDeclarationStmt,
+		*parser.BlockStatement, *parser.EmptyStatement, *parser.ReturnStatement, *parser.IfStatement,
+		*parser.WithStatement, *parser.DebuggerStatement, *parser.LabelledStatement,
+		*parser.ImportDeclaration, *parser.ExportNamedDeclaration, *parser.ExportDefaultDeclaration,
+		*parser.ExportAllDeclaration:
+		return true
+	}
+	return false
+}
+
+func (p *printer) stmt(node parser.Node) {
+	p.markNode(node)
+	switch s := node.(type) {
+	case *parser.ExpressionStatement:
+		p.stmtStart = p.buf.Len()
+		if isParenthesizedString(s.Expression) {
+			// a parenthesized string is not a directive, as in ('use strict')
+			p.print("(")
+			p.expr(s.Expression, precLowest)
+			p.print(")")
+		} else {
+			p.expr(s.Expression, precLowest)
+		}
+		p.semicolon()
+	case *parser.VariableStatement:
+		p.variableStatement(s)
+		p.semicolon()
+	case *parser.FunctionDeclarationStmt:
+		p.function(s.BindingIdentifier, s.Params, s.Body, s.Generator, s.Async)
+	case *parser.BlockStatement:
+		p.within(s, func() { p.block(s.Stmts) })
+	case *parser.EmptyStatement:
+		p.print(";") // never omitted, as in if (a) ;
+	case *parser.ReturnStatement:
+		p.print("return")
+		if s.Argument != nil {
+			p.space()
+			p.expr(s.Argument, precLowest)
+		}
+		p.semicolon()
+	case *parser.IfStatement:
+		p.ifStatement(s)
+	case *parser.WithStatement:
+		p.print("with")
+		p.space()
+		p.print("(")
+		p.expr(s.Object, precLowest)
+		p.print(")")
+		p.substatement(s.Body)
+	case *parser.DebuggerStatement:
+		p.print("debugger")
+		p.semicolon()
+	case *parser.LabelledStatement:
+		p.identifier(s.Label)
+		p.print(":")
+		p.substatement(s.Body)
+	case *parser.ImportDeclaration:
+		p.importDeclaration(s)
+	case *parser.ExportNamedDeclaration:
+		p.exportNamedDeclaration(s)
+	case *parser.ExportDefaultDeclaration:
+		p.exportDefaultDeclaration(s)
+	case *parser.ExportAllDeclaration:
+		p.exportAllDeclaration(s)
+	}
+}
+
+// block prints a Block, with a statement per line in Readable mode, or the
+// comments within it when empty.
+func (p *printer) block(stmts []parser.Stmt) {
+	p.print("{")
+	if hasStatements(stmts) || p.danglingComments() {
+		p.indent++
+		p.statements(stmts)
+		p.indent--
+		p.newline()
+	}
+	p.print("}")
+}
+
+// substatement prints the body of an if, with or labelled statement.
+func (p *printer) substatement(stmt parser.Stmt) {
+	p.space()
+	p.stmt(stmt)
+}
+
+func (p *printer) ifStatement(s *parser.IfStatement) {
+	p.print("if")
+	p.space()
+	p.print("(")
+	p.expr(s.Condition, precLowest)
+	p.print(")")
+	if s.ElseStmt == nil {
+		p.substatement(s.ThenStmt)
+		return
+	}
+
+	if endsWithElselessIf(s.ThenStmt) {
+		// the else would otherwise bind to the nested if
+		p.space()
+		p.block([]parser.Stmt{s.ThenStmt})
+	} else {
+		p.substatement(s.ThenStmt)
+	}
+	if _, ok := s.ThenStmt.(*parser.BlockStatement); ok || endsWithElselessIf(s.ThenStmt) {
+		p.space()
+	} else {
+		p.newline()
+	}
+	p.print("else")
+	p.substatement(s.ElseStmt)
+}
+
+// endsWithElselessIf reports whether stmt ends with an if statement without
+// an else clause, which would take the else clause of an enclosing if.
+func endsWithElselessIf(stmt parser.Stmt) bool {
+	switch s := stmt.(type) {
+	case *parser.IfStatement:
+		if s.ElseStmt == nil {
+			return true
+		}
+		return endsWithElselessIf(s.ElseStmt)
+	case *parser.WithStatement:
+		return endsWithElselessIf(s.Body)
+	case *parser.LabelledStatement:
+		return endsWithElselessIf(s.Body)
+	}
+	return false
+}
+
+func (p *printer) variableStatement(s *parser.VariableStatement) {
+	p.print(s.Kind.Type.S())
+	p.space()
+	for i, decl := range s.Declarations {
+		if i > 0 {
+			p.comma()
+		}
+		if decl.Identifier != nil {
+			p.identifier(decl.Identifier)
+		} else {
+			p.expr(decl.Pattern, precAssign)
+		}
+		if decl.Init != nil {
+			p.space()
+			p.print("=")
+			p.space()
+			p.expr(decl.Init, precAssign)
+		}
+	}
+}
+
+// function prints a function declaration or expression.
+func (p *printer) function(name *parser.ExprIdentifier, params []parser.Node, body []parser.Stmt, generator, async bool) {
+	if async {
+		p.print("async")
+		p.space()
+	}
+	p.print("function")
+	if generator {
+		p.print("*")
+	}
+	p.space()
+	if name != nil {
+		p.identifier(name)
+	}
+	p.params(params)
+	p.space()
+	p.block(body)
+}
+
+func (p *printer) params(params []parser.Node) {
+	p.group("(", ")", len(params), false, p.lineCommentBefore(last(params)), func(p *printer, i int) {
+		p.expr(params[i], precAssign)
+	})
+}
+
+// isParenthesizedString reports whether expr is a string literal within
+// parentheses.
+func isParenthesizedString(expr parser.Expr) bool {
+	if _, ok := expr.(*parser.ExprParenthesized); !ok {
+		return false
+	}
+	literal, ok := parser.Unparenthesized(expr).(*parser.ExprLiteral[string])
+	return ok && isStringToken(literal.Token.Type)
+}
+
+// //////////
+// Modules //
+// //////////
+func (p *printer) importDeclaration(s *parser.ImportDeclaration) {
+	p.print("import")
+	if len(s.Specifiers) > 0 {
+		p.space()
+		var named []*parser.ImportSpecifier
+		for i, specifier := range s.Specifiers {
+			switch specifier.Kind {
+			case parser.ImportDefault:
+				p.identifier(specifier.Local)
+			case parser.ImportNamespace:
+				if i > 0 {
+					p.comma()
+				}
+				p.print("*")
+				p.space()
+				p.print("as")
+				p.space()
+				p.identifier(specifier.Local)
+			case parser.ImportNamed:
+				named = append(named, specifier)
+			}
+		}
+		if len(named) > 0 {
+			if len(named) < len(s.Specifiers) {
+				p.comma()
+			}
+			p.suffix = p.measure(func(p *printer) { p.importSource(s) })
+			p.group("{", "}", len(named), true, false, func(p *printer, i int) {
+				specifier := named[i]
+				if !sameName(specifier.Imported, specifier.Local) {
+					p.expr(specifier.Imported, precPrimary)
+					p.space()
+					p.print("as")
+					p.space()
+				}
+				p.identifier(specifier.Local)
+			})
+		}
+	}
+	p.importSource(s)
+}
+
+// importSource prints the end of an import declaration, from its module
+// specifier on.
+func (p *printer) importSource(s *parser.ImportDeclaration) {
+	if len(s.Specifiers) > 0 {
+		p.space()
+		p.print("from")
+	}
+	p.space()
+	p.expr(s.Source, precPrimary)
+	p.attributes(s.Attributes)
+	p.semicolon()
+}
+
+func (p *printer) exportNamedDeclaration(s *parser.ExportNamedDeclaration) {
+	p.print("export")
+	p.space()
+	if s.Declaration != nil {
+		p.stmt(s.Declaration)
+		return
+	}
+
+	p.suffix = p.measure(func(p *printer) { p.exportSource(s) })
+	p.group("{", "}", len(s.Specifiers), true, false, func(p *printer, i int) {
+		specifier := s.Specifiers[i]
+		p.expr(specifier.Local, precPrimary)
+		if !sameName(specifier.Local, specifier.Exported) {
+			p.space()
+			p.print("as")
+			p.space()
+			p.expr(specifier.Exported, precPrimary)
+		}
+	})
+	p.exportSource(s)
+}
+
+// exportSource prints the end of an export declaration, after its specifiers.
+func (p *printer) exportSource(s *parser.ExportNamedDeclaration) {
+	if s.Source != nil {
+		p.space()
+		p.print("from")
+		p.space()
+		p.expr(s.Source, precPrimary)
+		p.attributes(s.Attributes)
+	}
+	p.semicolon()
+}
+
+func (p *printer) exportDefaultDeclaration(s *parser.ExportDefaultDeclaration) {
+	p.print("export")
+	p.space()
+	p.print("default")
+	p.space()
+	if fn, ok := s.Declaration.(*parser.FunctionDeclarationStmt); ok {
+		p.function(fn.BindingIdentifier, fn.Params, fn.Body, fn.Generator, fn.Async)
+		return
+	}
+	p.exportDefaultStart = p.buf.Len()
+	p.expr(s.Declaration, precAssign)
+	p.semicolon()
+}
+
+func (p *printer) exportAllDeclaration(s *parser.ExportAllDeclaration) {
+	p.print("export")
+	p.space()
+	p.print("*")
+	if s.Exported != nil {
+		p.space()
+		p.print("as")
+		p.space()
+		p.expr(s.Exported, precPrimary)
+	}
+	p.space()
+	p.print("from")
+	p.space()
+	p.expr(s.Source, precPrimary)
+	p.attributes(s.Attributes)
+	p.semicolon()
+}
+
+func (p *printer) attributes(attributes []*parser.ImportAttribute) {
+	if len(attributes) == 0 {
+		return
+	}
+	p.space()
+	p.print("with")
+	p.space()
+	p.print("{")
+	p.space()
+	for i, attribute := range attributes {
+		if i > 0 {
+			p.comma()
+		}
+		p.expr(attribute.Key, precPrimary)
+		p.print(":")
+		p.space()
+		p.expr(attribute.Value, precPrimary)
+	}
+	p.space()
+	p.print("}")
+}
+
+// sameName reports whether two ModuleExportNames are the same identifier, so
+// that `a as a` can be printed as `a`.
+func sameName(a, b parser.Expr) bool {
+	x, ok := a.(*parser.ExprIdentifier)
+	if !ok {
+		return false
+	}
+	y, ok := b.(*parser.ExprIdentifier)
+	return ok && x.Name == y.Name
+}
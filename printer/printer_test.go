@@ -0,0 +1,280 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ruiconti/gojs/internal"
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+func parse(src string, options parser.Options) parser.Node {
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	return parser.ParseWithOptions(logger, src, options)
+}
+
+// assertRoundTrip checks that src prints as expected in Compact mode, and that
+// the output of both modes parses back to the same program.
+func assertRoundTrip(t *testing.T, src, expected string, options parser.Options) {
+	t.Helper()
+	program := parse(src, options)
+	compact := Print(program, Options{Mode: Compact})
+	if compact != expected {
+		t.Fatalf("expected %q, got %q", expected, compact)
+	}
+	readable := Print(program, Options{})
+	for _, out := range []string{compact, readable} {
+		if got := Print(parse(out, options), Options{Mode: Compact}); got != compact {
+			t.Errorf("expected %q to print back as %q, got %q", out, compact, got)
+		}
+	}
+}
+
+func TestPrint_Parentheses(t *testing.T) {
+	tcs := []struct {
+		src      string
+		expected string
+	}{
+		{src: "(function(){})();", expected: "(function(){})();"},
+		{src: "(async function(){});", expected: "(async function(){});"},
+		{src: "({}).x;", expected: "({}).x;"},
+		{src: "({ a } = b);", expected: "({a}=b);"},
+		{src: "x = y => ({}).a;", expected: "x=y=>({}).a;"},
+		{src: "x = () => ({ a: 1 });", expected: "x=()=>({a:1});"},
+		{src: "(() => {})();", expected: "(()=>{})();"},
+		{src: "a - -b;", expected: "a- -b;"},
+		{src: "a + +b;", expected: "a+ +b;"},
+		{src: "a - --b;", expected: "a- --b;"},
+		{src: "a++ + b;", expected: "a++ +b;"},
+		{src: "a < !b;", expected: "a< !b;"},
+		{src: "x = a / /re/;", expected: "x=a/ /re/;"},
+		{src: "a in b; typeof a; void 0;", expected: "a in b;typeof a;void 0;"},
+		{src: "((a + b)) * (c - d);", expected: "(a+b)*(c-d);"},
+		{src: "a - (b - c); (a - b) - c;", expected: "a-(b-c);a-b-c;"},
+		{src: "(-a) ** b;", expected: "(-a)**b;"},
+		{src: "(a || b) ?? c; a && (b ?? c);", expected: "(a||b)??c;a&&(b??c);"},
+		{src: "(a ? b : c) ? d : e; a ? b : (c ? d : e);", expected: "(a?b:c)?d:e;a?b:c?d:e;"},
+		{src: "x = (a, b); f((a, b), c);", expected: "x=(a,b);f((a,b),c);"},
+		{src: "a = (b = c); (a = b).c;", expected: "a=b=c;(a=b).c;"},
+		{src: "new (a())(); new (a.b().c)(); new a;", expected: "new(a())();new(a.b().c)();new a();"},
+		{src: "(new a).b; new a.b();", expected: "new a().b;new a.b();"},
+		{src: "(a?.b).c; a?.b.c; a?.[0]?.(1);", expected: "(a?.b).c;a?.b.c;a?.[0]?.(1);"},
+		{src: "(1).toString(); (1)[0]; ('a').b;", expected: "(1).toString();1[0];'a'.b;"},
+		{src: "(1.5).x; (.5).x; (1e21).x; (0x10).x; (1.5)[0];", expected: "(1.5).x;(.5).x;(1e21).x;(0x10).x;1.5[0];"},
+		{src: "typeof (() => 1); !(a = b);", expected: "typeof(()=>1);!(a=b);"},
+		{src: "x = (/a/).test(b); if (a) (/b/).test(c);", expected: "x=/a/.test(b);if(a)(/b/).test(c);"},
+		{src: "('use strict'); 'use strict';", expected: "('use strict');'use strict';"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			assertRoundTrip(t, tc.src, tc.expected, parser.Options{})
+		})
+	}
+
+	t.Run("right-associative exponentiation", func(t *testing.T) {
+		a, b, c := &parser.ExprIdentifier{Name: "a"}, &parser.ExprIdentifier{Name: "b"}, &parser.ExprIdentifier{Name: "c"}
+		star := l.TStarStar
+		pow := star.Token()
+		right := &parser.ExprBinaryOp{Left: a, Operator: pow, Right: &parser.ExprBinaryOp{Left: b, Operator: pow, Right: c}}
+		left := &parser.ExprBinaryOp{Left: &parser.ExprBinaryOp{Left: a, Operator: pow, Right: b}, Operator: pow, Right: c}
+		if got := Print(right, Options{}); got != "a ** b ** c" {
+			t.Errorf("expected a ** b ** c, got %q", got)
+		}
+		if got := Print(left, Options{}); got != "(a ** b) ** c" {
+			t.Errorf("expected (a ** b) ** c, got %q", got)
+		}
+	})
+
+	t.Run("let at the start of a statement", func(t *testing.T) {
+		let := &parser.ExprIdentifier{Name: "let"}
+		member := &parser.ExprMemberAccess{Object: let, Property: &parser.ExprIdentifier{Name: "a"}, Computed: true}
+		program := &parser.NodeRoot{Children: []parser.Node{
+			&parser.ExpressionStatement{Expression: member},
+			&parser.ExpressionStatement{Expression: &parser.ExprCall{Callee: member}},
+			&parser.ExpressionStatement{Expression: &parser.ExprMemberAccess{Object: let, Property: &parser.ExprIdentifier{Name: "a"}}},
+		}}
+		if got := Print(program, Options{Mode: Compact}); got != "(let)[a];(let)[a]();let.a;" {
+			t.Errorf("expected (let)[a];(let)[a]();let.a;, got %q", got)
+		}
+	})
+}
+
+func TestPrint_Statements(t *testing.T) {
+	tcs := []struct {
+		src      string
+		expected string
+	}{
+		{src: "var a = 1, b; let [c, , d = 2, ...e] = f; const { g, h: i, ...j } = k;", expected: "var a=1,b;let[c,,d=2,...e]=f;const{g,h:i,...j}=k;"},
+		{src: "x = [a, , b, ,];", expected: "x=[a,,b,,];"},
+		{src: "async function* f(a = 1, { b }, ...c) { yield* a; await b; return; }", expected: "async function*f(a=1,{b},...c){yield*a;await b;return;}"},
+		{src: "if (a) if (b) c; else d;", expected: "if(a)if(b)c;else d;"},
+		{src: "if (a) { if (b) c; } else d;", expected: "if(a){if(b)c;}else d;"},
+		{src: "if (a) b; else if (c) d; else { e; }", expected: "if(a)b;else if(c)d;else{e;}"},
+		{src: "l: { debugger; } with (a) b; if (a) ;", expected: "l:{debugger;}with(a)b;if(a);"},
+		{src: "o = { a, b: 1, [c]: 2, 'd': 3, 4: 5, ...e, f() {}, get g() { return 1; }, set g(v) {}, async *h() {} };", expected: "o={a,b:1,[c]:2,'d':3,4:5,...e,f(){},get g(){return 1;},set g(v){},async*h(){}};"},
+		{src: "t = `a${b}c${d + `e`}`; tag`x${y}`;", expected: "t=`a${b}c${d+`e`}`;tag`x${y}`;"},
+		{src: "async (a) => a; async a => {}; (a, ...b) => a;", expected: "async a=>a;async a=>{};(a,...b)=>a;"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			assertRoundTrip(t, tc.src, tc.expected, parser.Options{})
+		})
+	}
+
+	t.Run("dangling else", func(t *testing.T) {
+		inner := &parser.IfStatement{Condition: &parser.ExprIdentifier{Name: "b"}, ThenStmt: &parser.DebuggerStatement{}}
+		outer := &parser.IfStatement{Condition: &parser.ExprIdentifier{Name: "a"}, ThenStmt: inner, ElseStmt: &parser.EmptyStatement{}}
+		if got := Print(outer, Options{Mode: Compact}); got != "if(a){if(b)debugger;}else;" {
+			t.Errorf("expected the nested if within a block, got %q", got)
+		}
+	})
+}
+
+func TestPrint_Modules(t *testing.T) {
+	tcs := []struct {
+		src      string
+		expected string
+	}{
+		{src: `import a, * as b from "m"; import { c as d, e } from "n" with { type: "json" }; import "x";`, expected: `import a,*as b from"m";import{c as d,e}from"n"with{type:"json"};import"x";`},
+		{src: `export { a as b, c }; export * from "m"; export * as d from "m"; export { e } from "m";`, expected: `export{a as b,c};export*from"m";export*as d from"m";export{e}from"m";`},
+		{src: `export const a = 1; export function f() {}`, expected: `export const a=1;export function f(){}`},
+		{src: `export default function () {}`, expected: `export default function(){}`},
+		{src: `export default (function () {});`, expected: `export default(function(){});`},
+		{src: `export default { a: 1 };`, expected: `export default{a:1};`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			assertRoundTrip(t, tc.src, tc.expected, parser.Options{SourceType: parser.SourceTypeModule})
+		})
+	}
+}
+
+func TestPrint_Readable(t *testing.T) {
+	src := `function f(a, b) { if (a) { return a + b; } else return; } o = { a, b: [1, 2] }; m = { f() { return 1; } };`
+	expected := `function f(a, b) {
+  if (a) {
+    return a + b;
+  } else return;
+}
+o = { a, b: [1, 2] };
+m = {
+  f() {
+    return 1;
+  }
+};
+`
+	if got := Print(parse(src, parser.Options{}), Options{}); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	t.Run("indent", func(t *testing.T) {
+		got := Print(parse("{ a; }", parser.Options{}), Options{Indent: "\t"})
+		if got != "{\n\ta;\n}\n" {
+			t.Errorf("expected a tab indented block, got %q", got)
+		}
+	})
+}
+
+func TestPrint_Quotes(t *testing.T) {
+	tcs := []struct {
+		src    string
+		quote  Quote
+		expect string
+	}{
+		{src: `'a'`, quote: QuoteDouble, expect: `"a"`},
+		{src: `'it\'s'`, quote: QuoteDouble, expect: `"it's"`},
+		{src: `'say "hi"'`, quote: QuoteDouble, expect: `'say "hi"'`},
+		{src: `'a\nb\\'`, quote: QuoteDouble, expect: `"a\nb\\"`},
+		{src: `"a\"b"`, quote: QuoteSingle, expect: `'a"b'`},
+		{src: `"a'b"`, quote: QuoteSingle, expect: `"a'b"`},
+		{src: `'a'`, quote: QuotePreserve, expect: `'a'`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			program := parse("x = "+tc.src+";", parser.Options{})
+			expected := "x=" + tc.expect + ";"
+			if got := Print(program, Options{Mode: Compact, Quote: tc.quote}); got != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+// sourceAt returns the rest of the line of src from the 0-based line and
+// UTF-16 column of a source map.
+func sourceAt(src string, line, column int) string {
+	lines := strings.Split(src, "\n")
+	if line >= len(lines) {
+		return ""
+	}
+	text := lines[line]
+	for i, ch := range text {
+		if column <= 0 {
+			return text[i:]
+		}
+		column -= len(utf16.Encode([]rune{ch}))
+	}
+	return ""
+}
+
+// firstToken returns the identifier, string literal or punctuator src starts
+// with.
+func firstToken(src string) string {
+	if src == "" {
+		return ""
+	}
+	if quote := src[0]; quote == '"' || quote == '\'' {
+		if end := strings.IndexByte(src[1:], quote); end >= 0 {
+			return src[:end+2]
+		}
+		return src
+	}
+	end := strings.IndexFunc(src, func(ch rune) bool {
+		return !(ch == '_' || ch == '$' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z')
+	})
+	switch end {
+	case -1:
+		return src
+	case 0:
+		return src[:1]
+	}
+	return src[:end]
+}
+
+func TestPrintFileWithMap(t *testing.T) {
+	const src = "// greets\nexport function greet(name, greeting = 'Hi') {\n  const emoji = '\U0001F600', message = greeting + ' ' + name;\n  if (!name) return;\n  return [message, emoji, -1, typeof x];\n}\n"
+	file, err := parser.ParseFile(src, parser.Options{SourceType: parser.SourceTypeModule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []Mode{Readable, Compact} {
+		out, sourceMap := PrintFileWithMap(file, Options{Mode: mode}, "greet.js")
+		if len(sourceMap.Sources) != 1 || sourceMap.Sources[0] != "greet.js" {
+			t.Fatalf("expected the source greet.js, got %q", sourceMap.Sources)
+		}
+		mappings, err := sourceMap.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mapped := make(map[string]bool)
+		for _, mapping := range mappings {
+			generated := firstToken(sourceAt(out, mapping.GeneratedLine, mapping.GeneratedColumn))
+			original := sourceAt(src, mapping.OriginalLine, mapping.OriginalColumn)
+			if !strings.HasPrefix(original, generated) {
+				t.Errorf("expected %q at %d:%d of the source, got %q", generated, mapping.OriginalLine, mapping.OriginalColumn, original)
+			}
+			if mapping.Name != "" && mapping.Name != generated {
+				t.Errorf("expected the name %q at %d:%d, got %q", generated, mapping.GeneratedLine, mapping.GeneratedColumn, mapping.Name)
+			}
+			mapped[generated] = true
+		}
+		for _, token := range []string{"export", "greet", "name", "'Hi'", "const", "emoji", "message", "if", "!", "return", "-", "1", "typeof", "x"} {
+			if !mapped[token] {
+				t.Errorf("expected %q to be mapped in %q", token, out)
+			}
+		}
+	}
+}
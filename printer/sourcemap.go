@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/sourcemap"
+)
+
+// The source map of a printed file maps the first token of each statement and
+// function, and each identifier, literal and unary operator to where it was
+// parsed from. Nodes made up by tools, which have no position, are not
+// mapped.
+
+// PrintFileWithMap returns the source text of a parsed file like PrintFile,
+// along with its source map back to the source of the file, named source in
+// the map. Identifiers are mapped along with their name in the source, so that
+// renamed variables keep their original name.
+func PrintFileWithMap(file *parser.File, options Options, source string) (string, *sourcemap.Map) {
+	p := newPrinter(options)
+	if p.readable() {
+		p.comments = file.Comments
+	}
+	p.spans = file.Spans
+	p.sourceMap = sourcemap.NewGenerator("")
+	p.sourceName = source
+	p.sourceLines = strings.Split(file.Source, "\n")
+	p.program(file.Program)
+	return p.buf.String(), p.sourceMap.Map()
+}
+
+// mark maps the next token printed to pos, in the source, along with the
+// original name of an identifier.
+func (p *printer) mark(pos parser.Position, name string) {
+	if p.sourceMap == nil || pos.Line <= 0 {
+		return
+	}
+	column := pos.Column
+	if line := pos.Line - 1; line < len(p.sourceLines) && column <= len(p.sourceLines[line]) {
+		column = utf16Len(p.sourceLines[line][:column])
+	}
+	p.pending = &sourcemap.Mapping{
+		Source:         p.sourceName,
+		OriginalLine:   pos.Line - 1,
+		OriginalColumn: column,
+		Name:           name,
+	}
+}
+
+// markNode maps the next token printed to the start of node, if its span is
+// known.
+func (p *printer) markNode(node parser.Node) {
+	if span, ok := p.spans[node]; ok {
+		p.mark(span.Start, "")
+	}
+}
+
+// flushMark adds the pending mapping at the position of the token being
+// printed.
+func (p *printer) flushMark() {
+	if p.pending == nil {
+		return
+	}
+	p.pending.GeneratedLine, p.pending.GeneratedColumn = p.line, p.column16
+	p.sourceMap.Add(*p.pending)
+	p.pending = nil
+}
+
+// trackPosition advances the generated position of the source map past src.
+func (p *printer) trackPosition(src string) {
+	if i := strings.LastIndexByte(src, '\n'); i >= 0 {
+		p.line += strings.Count(src, "\n")
+		p.column16 = utf16Len(src[i+1:])
+	} else {
+		p.column16 += utf16Len(src)
+	}
+}
+
+func (p *printer) identifier(id *parser.ExprIdentifier) {
+	p.commentsBefore(id)
+	if p.sourceMap != nil {
+		p.mark(id.Span.Start, p.originalName(id))
+	}
+	p.print(id.Name)
+	p.commentsAfter(id)
+}
+
+// originalName returns the name of id as written in the source.
+func (p *printer) originalName(id *parser.ExprIdentifier) string {
+	start, end := id.Span.Start, id.Span.End
+	if start.Line != end.Line || start.Line <= 0 || start.Line > len(p.sourceLines) {
+		return id.Name
+	}
+	line := p.sourceLines[start.Line-1]
+	if start.Column >= end.Column || end.Column > len(line) {
+		return id.Name
+	}
+	return line[start.Column:end.Column]
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, ch := range s {
+		n++
+		if ch >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,327 @@
+package regexp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of a pattern's syntax tree. Like the JavaScript AST, its S
+// method renders an s-expression that is mostly useful for testing.
+type Node interface {
+	S() string
+}
+
+func joinS[N Node](nodes []N) string {
+	var src strings.Builder
+	for i, node := range nodes {
+		if i > 0 {
+			src.WriteString(" ")
+		}
+		src.WriteString(node.S())
+	}
+	return src.String()
+}
+
+// //////////
+// Pattern //
+// //////////
+
+// Pattern is the root of a parsed regular expression.
+type Pattern struct {
+	Body       *Disjunction
+	Flags      Flags
+	GroupCount int      // number of capturing groups
+	GroupNames []string // names of the capturing groups, "" when unnamed
+}
+
+func (n *Pattern) S() string {
+	return fmt.Sprintf("(regexp %s)", n.Body.S())
+}
+
+// //////////////
+// Disjunction //
+// //////////////
+type Disjunction struct {
+	Alternatives []*Alternative
+}
+
+func (n *Disjunction) S() string {
+	if len(n.Alternatives) == 1 {
+		return n.Alternatives[0].S()
+	}
+	return fmt.Sprintf("(| %s)", joinS(n.Alternatives))
+}
+
+// //////////////
+// Alternative //
+// //////////////
+type Alternative struct {
+	Terms []Node
+}
+
+func (n *Alternative) S() string {
+	if len(n.Terms) == 1 {
+		return n.Terms[0].S()
+	}
+	if len(n.Terms) == 0 {
+		return "(seq)"
+	}
+	return fmt.Sprintf("(seq %s)", joinS(n.Terms))
+}
+
+// ////////////
+// Assertion //
+// ////////////
+type AssertionKind int
+
+const (
+	AssertStart           AssertionKind = iota // ^
+	AssertEnd                                  // $
+	AssertWordBoundary                         // \b
+	AssertNotWordBoundary                      // \B
+)
+
+type Assertion struct {
+	Kind AssertionKind
+}
+
+func (n *Assertion) S() string {
+	return [...]string{`^`, `$`, `\b`, `\B`}[n.Kind]
+}
+
+// /////////////
+// Lookaround //
+// /////////////
+type Lookaround struct {
+	Behind bool
+	Negate bool
+	Body   *Disjunction
+}
+
+func (n *Lookaround) S() string {
+	op := "?"
+	if n.Behind {
+		op += "<"
+	}
+	if n.Negate {
+		op += "!"
+	} else {
+		op += "="
+	}
+	return fmt.Sprintf("(%s %s)", op, n.Body.S())
+}
+
+// /////////////
+// Quantifier //
+// /////////////
+
+// Unbounded is the Max of a quantifier without an upper bound.
+const Unbounded = -1
+
+type Quantifier struct {
+	Atom   Node
+	Min    int
+	Max    int
+	Greedy bool
+}
+
+func (n *Quantifier) S() string {
+	var op string
+	switch {
+	case n.Min == 0 && n.Max == Unbounded:
+		op = "*"
+	case n.Min == 1 && n.Max == Unbounded:
+		op = "+"
+	case n.Min == 0 && n.Max == 1:
+		op = "?"
+	case n.Min == n.Max:
+		op = fmt.Sprintf("{%d}", n.Min)
+	case n.Max == Unbounded:
+		op = fmt.Sprintf("{%d,}", n.Min)
+	default:
+		op = fmt.Sprintf("{%d,%d}", n.Min, n.Max)
+	}
+	if !n.Greedy {
+		op += "?"
+	}
+	return fmt.Sprintf("(%s %s)", op, n.Atom.S())
+}
+
+// ////////
+// Group //
+// ////////
+
+// Group is a capturing group when Index > 0, and may also carry the flags
+// modifiers of a non-capturing group, e.g. (?i-m:...).
+type Group struct {
+	Index   int // 1-based index of a capturing group, 0 otherwise
+	Name    string
+	Enable  Flags // modifiers turned on
+	Disable Flags // modifiers turned off
+	Body    *Disjunction
+}
+
+func (n *Group) S() string {
+	if n.Index == 0 {
+		modifiers := n.Enable.String()
+		if disabled := n.Disable.String(); disabled != "" {
+			modifiers += "-" + disabled
+		}
+		return fmt.Sprintf("(?%s: %s)", modifiers, n.Body.S())
+	}
+	if n.Name != "" {
+		return fmt.Sprintf("(group %d %s %s)", n.Index, n.Name, n.Body.S())
+	}
+	return fmt.Sprintf("(group %d %s)", n.Index, n.Body.S())
+}
+
+// ////////////////
+// Backreference //
+// ////////////////
+
+// Backreference refers to a capturing group either by index (\1) or by
+// name (\k<name>).
+type Backreference struct {
+	Index int
+	Name  string
+}
+
+func (n *Backreference) S() string {
+	if n.Name != "" {
+		return fmt.Sprintf(`(\k %s)`, n.Name)
+	}
+	return fmt.Sprintf(`(\ %d)`, n.Index)
+}
+
+// ///////
+// Char //
+// ///////
+
+// Char matches a single code point, whether written literally or escaped.
+type Char struct {
+	Value rune
+}
+
+func (n *Char) S() string {
+	return strconv.QuoteRune(n.Value)
+}
+
+// //////
+// Dot //
+// //////
+type Dot struct{}
+
+func (n *Dot) S() string {
+	return "."
+}
+
+// ///////////////////////
+// CharacterClassEscape //
+// ///////////////////////
+
+// CharacterClassEscape is one of \d, \D, \s, \S, \w and \W.
+type CharacterClassEscape struct {
+	Kind rune
+}
+
+func (n *CharacterClassEscape) S() string {
+	return `\` + string(n.Kind)
+}
+
+// ////////////////////////
+// UnicodePropertyEscape //
+// ////////////////////////
+
+// UnicodePropertyEscape is \p{Name=Value}, \p{Value} or its negation \P.
+type UnicodePropertyEscape struct {
+	Name    string // canonical property name, e.g. General_Category
+	Value   string // as written, e.g. Lu
+	Negate  bool
+	Strings bool // a property of strings, e.g. RGI_Emoji
+}
+
+func (n *UnicodePropertyEscape) S() string {
+	op := `\p`
+	if n.Negate {
+		op = `\P`
+	}
+	if n.Name == "" {
+		return fmt.Sprintf("%s{%s}", op, n.Value)
+	}
+	return fmt.Sprintf("%s{%s=%s}", op, n.Name, n.Value)
+}
+
+// /////////////////
+// CharacterClass //
+// /////////////////
+
+// CharacterClass is a [...] class. Without the 'v' flag its elements are a
+// union of Char, ClassRange and class escapes; with it they may also be
+// nested classes, ClassStrings, and a single ClassSetOperation.
+type CharacterClass struct {
+	Negate   bool
+	Elements []Node
+}
+
+func (n *CharacterClass) S() string {
+	if n.Negate {
+		return fmt.Sprintf("[^ %s]", joinS(n.Elements))
+	}
+	return fmt.Sprintf("[%s]", joinS(n.Elements))
+}
+
+// /////////////
+// ClassRange //
+// /////////////
+type ClassRange struct {
+	From *Char
+	To   *Char
+}
+
+func (n *ClassRange) S() string {
+	return fmt.Sprintf("(- %s %s)", n.From.S(), n.To.S())
+}
+
+// ////////////////////
+// ClassSetOperation //
+// ////////////////////
+type ClassSetOperator int
+
+const (
+	ClassIntersection ClassSetOperator = iota // &&
+	ClassSubtraction                          // --
+)
+
+// ClassSetOperation is the left-associative intersection or subtraction of
+// the operands of a 'v' flag class.
+type ClassSetOperation struct {
+	Operator ClassSetOperator
+	Left     Node
+	Right    Node
+}
+
+func (n *ClassSetOperation) S() string {
+	op := "&&"
+	if n.Operator == ClassSubtraction {
+		op = "--"
+	}
+	return fmt.Sprintf("(%s %s %s)", op, n.Left.S(), n.Right.S())
+}
+
+// ///////////////
+// ClassStrings //
+// ///////////////
+
+// ClassStrings is a \q{abc|d} ClassStringDisjunction of a 'v' flag class.
+type ClassStrings struct {
+	Strings []string
+}
+
+func (n *ClassStrings) S() string {
+	quoted := make([]string, len(n.Strings))
+	for i, s := range n.Strings {
+		quoted[i] = strconv.Quote(s)
+	}
+	return fmt.Sprintf(`(\q %s)`, strings.Join(quoted, " "))
+}
@@ -0,0 +1,246 @@
+package regexp
+
+import (
+	"strings"
+)
+
+// CharacterClass[+UnicodeSetsMode] ::
+// | '[' [lookahead ≠ '^'] ClassSetExpression ']'
+// | '[^' ClassSetExpression ']'
+//
+// ClassSetExpression ::
+// | ClassUnion
+// | ClassIntersection
+// | ClassSubtraction
+//
+// ClassIntersection ::
+// | ClassSetOperand '&&' [lookahead ≠ '&'] ClassSetOperand
+// | ClassIntersection '&&' [lookahead ≠ '&'] ClassSetOperand
+//
+// ClassSubtraction ::
+// | ClassSetOperand '--' ClassSetOperand
+// | ClassSubtraction '--' ClassSetOperand
+//
+// It is an early error for a negated class to contain strings.
+func (p *parser) parseClassSet() (Node, error) {
+	start := p.pos
+	p.pos++ // consume '['
+	class := &CharacterClass{Negate: p.eat("^"), Elements: []Node{}}
+	if p.eat("]") {
+		return class, nil
+	}
+
+	operand, isChar, err := p.parseClassSetOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.lookahead("&&") || p.lookahead("--") {
+		operator, token := ClassIntersection, "&&"
+		if p.lookahead("--") {
+			operator, token = ClassSubtraction, "--"
+		}
+		for p.eat(token) {
+			if p.peek() == '&' {
+				return nil, p.errorf("invalid set operation in character class")
+			}
+			right, _, err := p.parseClassSetOperand()
+			if err != nil {
+				return nil, err
+			}
+			operand = &ClassSetOperation{Operator: operator, Left: operand, Right: right}
+		}
+		if !p.eat("]") {
+			if p.eof() {
+				return nil, p.errorf("unterminated character class")
+			}
+			return nil, p.errorf("invalid set operation in character class")
+		}
+		class.Elements = append(class.Elements, operand)
+	} else if err := p.parseClassUnion(class, operand, isChar); err != nil {
+		return nil, err
+	}
+
+	if class.Negate && anyMayContainStrings(class.Elements) {
+		p.pos = start
+		return nil, p.errorf("negated character class may contain strings")
+	}
+	return class, nil
+}
+
+// ClassUnion ::
+// | ClassSetRange ClassUnion?
+// | ClassSetOperand ClassUnion?
+//
+// ClassSetRange ::
+// | ClassSetCharacter '-' ClassSetCharacter
+func (p *parser) parseClassUnion(class *CharacterClass, operand Node, isChar bool) error {
+	for {
+		if isChar && p.peek() == '-' && !p.lookahead("--") {
+			p.pos++ // consume '-'
+			to, isToChar, err := p.parseClassSetOperand()
+			if err != nil {
+				return err
+			}
+			if !isToChar {
+				return p.errorf("invalid character class")
+			}
+			from := operand.(*Char)
+			if from.Value > to.(*Char).Value {
+				return p.errorf("range out of order in character class")
+			}
+			class.Elements = append(class.Elements, &ClassRange{From: from, To: to.(*Char)})
+		} else {
+			class.Elements = append(class.Elements, operand)
+		}
+
+		if p.eat("]") {
+			return nil
+		}
+		if p.lookahead("&&") || p.lookahead("--") {
+			return p.errorf("invalid set operation in character class")
+		}
+		var err error
+		operand, isChar, err = p.parseClassSetOperand()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ClassSetOperand ::
+// | NestedClass
+// | ClassStringDisjunction
+// | ClassSetCharacter
+//
+// NestedClass ::
+// | '[' [lookahead ≠ '^'] ClassContents ']'
+// | '[^' ClassContents ']'
+// | '\' CharacterClassEscape
+//
+// The returned bool tells whether the operand is a ClassSetCharacter, and so
+// may start a ClassSetRange.
+func (p *parser) parseClassSetOperand() (Node, bool, error) {
+	if p.eof() {
+		return nil, false, p.errorf("unterminated character class")
+	}
+	if p.peek() == '[' {
+		class, err := p.parseClassSet()
+		return class, false, err
+	}
+	if p.peek() == '\\' {
+		start := p.pos
+		p.pos++ // consume '\'
+		if p.lookahead("q{") {
+			strs, err := p.parseClassStrings()
+			return strs, false, err
+		}
+		if escape, ok, err := p.parseClassEscape(); ok || err != nil {
+			return escape, false, err
+		}
+		p.pos = start
+	}
+	ch, err := p.parseClassSetCharacter()
+	if err != nil {
+		return nil, false, err
+	}
+	return &Char{Value: ch}, true, nil
+}
+
+// ClassStringDisjunction ::
+// | '\q{' ClassString ('|' ClassString)* '}'
+//
+// ClassString ::
+// | ClassSetCharacter*
+func (p *parser) parseClassStrings() (Node, error) {
+	p.pos += 2 // consume 'q{'
+	strs := &ClassStrings{}
+	var str strings.Builder
+	for {
+		switch {
+		case p.eof():
+			return nil, p.errorf("unterminated class string disjunction")
+		case p.eat("}"):
+			strs.Strings = append(strs.Strings, str.String())
+			return strs, nil
+		case p.eat("|"):
+			strs.Strings = append(strs.Strings, str.String())
+			str.Reset()
+		default:
+			ch, err := p.parseClassSetCharacter()
+			if err != nil {
+				return nil, err
+			}
+			str.WriteRune(ch)
+		}
+	}
+}
+
+// ClassSetCharacter ::
+// | [lookahead ∉ ClassSetReservedDoublePunctuator] SourceCharacter but not ClassSetSyntaxCharacter
+// | '\' CharacterEscape[+UnicodeMode]
+// | '\' ClassSetReservedPunctuator
+// | '\b'
+func (p *parser) parseClassSetCharacter() (rune, error) {
+	ch := p.peek()
+	switch {
+	case ch == '\\':
+		p.pos++ // consume '\'
+		if p.eof() {
+			return 0, p.errorf(`\ at end of pattern`)
+		}
+		if p.eat("b") {
+			return '\b', nil
+		}
+		if escaped := p.peek(); isClassSetReservedPunctuator(escaped) {
+			p.pos++
+			return escaped, nil
+		}
+		return p.parseCharacterEscape(true)
+	case strings.ContainsRune("&!#$%*+,.:;<=>?@^`~", ch) && p.peekN(1) == ch:
+		// ClassSetReservedDoublePunctuator
+		return 0, p.errorf("invalid set operation in character class")
+	case strings.ContainsRune(`()[]{}/-\|`, ch):
+		return 0, p.errorf("invalid character in character class")
+	}
+	p.pos++
+	return ch, nil
+}
+
+// ClassSetReservedPunctuator :: one of
+// '&' '-' '!' '#' '%' ',' ':' ';' '<' '=' '>' '@' '`' '~'
+func isClassSetReservedPunctuator(ch rune) bool {
+	return strings.ContainsRune("&-!#%,:;<=>@`~", ch)
+}
+
+// anyMayContainStrings implements MayContainStrings for the elements of a
+// class, which are a union unless they are a single ClassSetOperation.
+func anyMayContainStrings(elements []Node) bool {
+	for _, element := range elements {
+		if mayContainStrings(element) {
+			return true
+		}
+	}
+	return false
+}
+
+func mayContainStrings(node Node) bool {
+	switch n := node.(type) {
+	case *ClassStrings:
+		for _, s := range n.Strings {
+			if len([]rune(s)) != 1 {
+				return true
+			}
+		}
+	case *UnicodePropertyEscape:
+		return n.Strings
+	case *CharacterClass:
+		return !n.Negate && anyMayContainStrings(n.Elements)
+	case *ClassSetOperation:
+		if n.Operator == ClassIntersection {
+			return mayContainStrings(n.Left) && mayContainStrings(n.Right)
+		}
+		return mayContainStrings(n.Left)
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package regexp
+
+import "strings"
+
+// Flags are the RegularExpressionFlags that follow the body of a regular
+// expression literal.
+type Flags struct {
+	HasIndices  bool // d
+	Global      bool // g
+	IgnoreCase  bool // i
+	Multiline   bool // m
+	DotAll      bool // s
+	Unicode     bool // u
+	UnicodeSets bool // v
+	Sticky      bool // y
+}
+
+// ParseFlags parses the flags of a regular expression literal. It is an early
+// error for flags to contain an unknown or repeated code point, or both 'u'
+// and 'v'.
+func ParseFlags(flags string) (Flags, error) {
+	var f Flags
+	for i, ch := range flags {
+		var flag *bool
+		switch ch {
+		case 'd':
+			flag = &f.HasIndices
+		case 'g':
+			flag = &f.Global
+		case 'i':
+			flag = &f.IgnoreCase
+		case 'm':
+			flag = &f.Multiline
+		case 's':
+			flag = &f.DotAll
+		case 'u':
+			flag = &f.Unicode
+		case 'v':
+			flag = &f.UnicodeSets
+		case 'y':
+			flag = &f.Sticky
+		default:
+			return Flags{}, &Error{Offset: i, Message: "invalid regular expression flag '" + string(ch) + "'"}
+		}
+		if *flag {
+			return Flags{}, &Error{Offset: i, Message: "duplicate regular expression flag '" + string(ch) + "'"}
+		}
+		*flag = true
+	}
+	if f.Unicode && f.UnicodeSets {
+		return Flags{}, &Error{Offset: strings.IndexAny(flags, "uv"), Message: "regular expression flags 'u' and 'v' cannot be combined"}
+	}
+	return f, nil
+}
+
+func (f Flags) String() string {
+	var flags strings.Builder
+	for _, flag := range []struct {
+		set bool
+		ch  byte
+	}{
+		{f.HasIndices, 'd'},
+		{f.Global, 'g'},
+		{f.IgnoreCase, 'i'},
+		{f.Multiline, 'm'},
+		{f.DotAll, 's'},
+		{f.Unicode, 'u'},
+		{f.UnicodeSets, 'v'},
+		{f.Sticky, 'y'},
+	} {
+		if flag.set {
+			flags.WriteByte(flag.ch)
+		}
+	}
+	return flags.String()
+}
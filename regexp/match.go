@@ -0,0 +1,679 @@
+package regexp
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Matcher matches a parsed pattern against strings of UTF-16 code units,
+// backtracking through its alternatives and quantifiers in the order the
+// specification gives them.
+//
+// https://262.ecma-international.org/#sec-pattern-semantics
+type Matcher struct {
+	groups int
+	match  matcher
+}
+
+// state is the input being matched and the captures of the match so far, as
+// pairs of start and end indices, -1 when unset. Matchers set captures in
+// place and restore them when backtracking.
+type state struct {
+	input    []uint16
+	captures []int
+}
+
+// continuation matches the rest of the pattern from pos.
+type continuation func(s *state, pos int) bool
+
+// matcher matches a node from pos, then the rest of the pattern with c.
+type matcher func(s *state, pos int, c continuation) bool
+
+// mode holds the flags that group modifiers may change within a pattern.
+type mode struct {
+	ignoreCase bool
+	multiline  bool
+	dotAll     bool
+}
+
+type compiler struct {
+	pattern     *Pattern
+	unicodeMode bool // 'u' or 'v': the input is read as code points
+}
+
+// Compile returns the matcher of pattern. It fails for the Unicode
+// properties the unicode package has no data for, such as Emoji.
+func Compile(pattern *Pattern) (*Matcher, error) {
+	flags := pattern.Flags
+	c := &compiler{pattern: pattern, unicodeMode: flags.Unicode || flags.UnicodeSets}
+	m, err := c.disjunction(pattern.Body, mode{flags.IgnoreCase, flags.Multiline, flags.DotAll}, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{groups: pattern.GroupCount, match: m}, nil
+}
+
+// Match matches the pattern against input at index. It returns the start and
+// end of the match, followed by those of each capturing group, -1 for the
+// groups that did not participate, or nil when the pattern does not match at
+// index.
+func (m *Matcher) Match(input []uint16, index int) []int {
+	if index < 0 || index > len(input) {
+		return nil
+	}
+	s := &state{input: input, captures: make([]int, 2*(m.groups+1))}
+	for i := range s.captures {
+		s.captures[i] = -1
+	}
+	end := -1
+	if !m.match(s, index, func(s *state, pos int) bool {
+		end = pos
+		return true
+	}) {
+		return nil
+	}
+	s.captures[0], s.captures[1] = index, end
+	return s.captures
+}
+
+// ///////////
+// Patterns //
+// ///////////
+
+func (c *compiler) disjunction(n *Disjunction, md mode, forward bool) (matcher, error) {
+	alternatives := make([]matcher, len(n.Alternatives))
+	for i, alternative := range n.Alternatives {
+		m, err := c.alternative(alternative, md, forward)
+		if err != nil {
+			return nil, err
+		}
+		alternatives[i] = m
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return func(s *state, pos int, k continuation) bool {
+		for _, m := range alternatives {
+			if m(s, pos, k) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// alternative matches its terms in sequence, from the last one when matching
+// backward, as lookbehinds do.
+func (c *compiler) alternative(n *Alternative, md mode, forward bool) (matcher, error) {
+	terms := make([]matcher, len(n.Terms))
+	for i, term := range n.Terms {
+		m, err := c.term(term, md, forward)
+		if err != nil {
+			return nil, err
+		}
+		if forward {
+			terms[i] = m
+		} else {
+			terms[len(terms)-1-i] = m
+		}
+	}
+	return sequence(terms), nil
+}
+
+func sequence(terms []matcher) matcher {
+	switch len(terms) {
+	case 0:
+		return func(s *state, pos int, k continuation) bool { return k(s, pos) }
+	case 1:
+		return terms[0]
+	}
+	first, rest := terms[0], sequence(terms[1:])
+	return func(s *state, pos int, k continuation) bool {
+		return first(s, pos, func(s *state, pos int) bool { return rest(s, pos, k) })
+	}
+}
+
+func (c *compiler) term(node Node, md mode, forward bool) (matcher, error) {
+	switch n := node.(type) {
+	case *Assertion:
+		return c.assertion(n, md), nil
+	case *Lookaround:
+		return c.lookaround(n, md)
+	case *Quantifier:
+		return c.quantifier(n, md, forward)
+	case *Group:
+		return c.group(n, md, forward)
+	case *Backreference:
+		return c.backreference(n, md, forward), nil
+	case *Char:
+		if !c.unicodeMode && n.Value > 0xffff {
+			// outside UnicodeMode, a code point is matched as its surrogates
+			high, low := utf16.EncodeRune(n.Value)
+			return c.sequenceOf([]rune{high, low}, md, forward), nil
+		}
+		return c.set(func(ch rune) bool { return ch == n.Value }, md, forward), nil
+	case *Dot:
+		return c.set(func(ch rune) bool { return md.dotAll || !isLineTerminator(ch) }, mode{}, forward), nil
+	}
+	set, strings, err := c.charSet(node)
+	if err != nil {
+		return nil, err
+	}
+	if class, ok := node.(*CharacterClass); ok && class.Negate {
+		return c.set(func(ch rune) bool { return !c.member(set, ch, md) }, mode{}, forward), nil
+	}
+	m := c.set(set, md, forward)
+	if len(strings) == 0 {
+		return m, nil
+	}
+	// the longest strings of a class are tried first
+	sort.SliceStable(strings, func(i, j int) bool { return len(strings[i]) > len(strings[j]) })
+	alternatives := make([]matcher, 0, len(strings)+1)
+	for _, str := range strings {
+		alternatives = append(alternatives, c.sequenceOf(str, md, forward))
+	}
+	alternatives = append(alternatives, m)
+	return func(s *state, pos int, k continuation) bool {
+		for _, m := range alternatives {
+			if m(s, pos, k) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// https://262.ecma-international.org/#sec-compileassertion
+func (c *compiler) assertion(n *Assertion, md mode) matcher {
+	var test func(input []uint16, pos int) bool
+	switch n.Kind {
+	case AssertStart:
+		test = func(input []uint16, pos int) bool {
+			return pos == 0 || md.multiline && isLineTerminator(rune(input[pos-1]))
+		}
+	case AssertEnd:
+		test = func(input []uint16, pos int) bool {
+			return pos == len(input) || md.multiline && isLineTerminator(rune(input[pos]))
+		}
+	default:
+		boundary := n.Kind == AssertWordBoundary
+		test = func(input []uint16, pos int) bool {
+			a := pos > 0 && c.isWordChar(rune(input[pos-1]), md)
+			b := pos < len(input) && c.isWordChar(rune(input[pos]), md)
+			return (a != b) == boundary
+		}
+	}
+	return func(s *state, pos int, k continuation) bool {
+		return test(s.input, pos) && k(s, pos)
+	}
+}
+
+// lookaround matches its body at pos without consuming input. The captures
+// of a positive lookaround are kept, but its body is not backtracked into.
+//
+// https://262.ecma-international.org/#sec-compileassertion
+func (c *compiler) lookaround(n *Lookaround, md mode) (matcher, error) {
+	body, err := c.disjunction(n.Body, md, !n.Behind)
+	if err != nil {
+		return nil, err
+	}
+	return func(s *state, pos int, k continuation) bool {
+		saved := append([]int(nil), s.captures...)
+		matched := body(s, pos, func(*state, int) bool { return true })
+		if matched != n.Negate && k(s, pos) {
+			return true
+		}
+		copy(s.captures, saved)
+		return false
+	}, nil
+}
+
+// https://262.ecma-international.org/#sec-runtime-semantics-repeatmatcher-abstract-operation
+func (c *compiler) quantifier(n *Quantifier, md mode, forward bool) (matcher, error) {
+	atom, err := c.term(n.Atom, md, forward)
+	if err != nil {
+		return nil, err
+	}
+	// the captures of the groups within the atom, reset on each repetition
+	lo, hi := groupRange(n.Atom)
+	greedy := n.Greedy
+
+	var repeat func(s *state, pos int, k continuation, min, max int) bool
+	repeat = func(s *state, pos int, k continuation, min, max int) bool {
+		if max == 0 {
+			return k(s, pos)
+		}
+		next := func(s *state, end int) bool {
+			if min == 0 && end == pos {
+				// an empty repetition ends the quantifier
+				return false
+			}
+			min, max := min, max
+			if min > 0 {
+				min--
+			}
+			if max != Unbounded {
+				max--
+			}
+			return repeat(s, end, k, min, max)
+		}
+		again := func() bool {
+			saved := append([]int(nil), s.captures[2*lo:2*hi]...)
+			for i := 2 * lo; i < 2*hi; i++ {
+				s.captures[i] = -1
+			}
+			if atom(s, pos, next) {
+				return true
+			}
+			copy(s.captures[2*lo:2*hi], saved)
+			return false
+		}
+		switch {
+		case min > 0:
+			return again()
+		case !greedy:
+			return k(s, pos) || again()
+		}
+		return again() || k(s, pos)
+	}
+	return func(s *state, pos int, k continuation) bool {
+		return repeat(s, pos, k, n.Min, n.Max)
+	}, nil
+}
+
+// groupRange returns the range of the indices of the capturing groups within
+// node.
+func groupRange(node Node) (lo, hi int) {
+	var walk func(node Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *Group:
+			if n.Index > 0 {
+				if lo == 0 || n.Index < lo {
+					lo = n.Index
+				}
+				if n.Index+1 > hi {
+					hi = n.Index + 1
+				}
+			}
+			walkDisjunction(n.Body, walk)
+		case *Lookaround:
+			walkDisjunction(n.Body, walk)
+		case *Quantifier:
+			walk(n.Atom)
+		}
+	}
+	walk(node)
+	if hi == 0 {
+		return 0, 0
+	}
+	return lo, hi
+}
+
+func walkDisjunction(n *Disjunction, walk func(Node)) {
+	for _, alternative := range n.Alternatives {
+		for _, term := range alternative.Terms {
+			walk(term)
+		}
+	}
+}
+
+// https://262.ecma-international.org/#sec-compileatom
+func (c *compiler) group(n *Group, md mode, forward bool) (matcher, error) {
+	md = md.modified(n.Enable, true).modified(n.Disable, false)
+	body, err := c.disjunction(n.Body, md, forward)
+	if err != nil || n.Index == 0 {
+		return body, err
+	}
+	i := 2 * n.Index
+	return func(s *state, pos int, k continuation) bool {
+		return body(s, pos, func(s *state, end int) bool {
+			start, stop := s.captures[i], s.captures[i+1]
+			if forward {
+				s.captures[i], s.captures[i+1] = pos, end
+			} else {
+				s.captures[i], s.captures[i+1] = end, pos
+			}
+			if k(s, end) {
+				return true
+			}
+			s.captures[i], s.captures[i+1] = start, stop
+			return false
+		})
+	}, nil
+}
+
+// modified returns md with the modifiers in flags set to on.
+func (md mode) modified(flags Flags, on bool) mode {
+	if flags.IgnoreCase {
+		md.ignoreCase = on
+	}
+	if flags.Multiline {
+		md.multiline = on
+	}
+	if flags.DotAll {
+		md.dotAll = on
+	}
+	return md
+}
+
+// backreference matches what the group it refers to captured, or nothing when
+// the group did not participate. A name may refer to several groups in
+// different alternatives, of which at most one participates.
+//
+// https://262.ecma-international.org/#sec-backreference-matcher
+func (c *compiler) backreference(n *Backreference, md mode, forward bool) matcher {
+	indices := []int{n.Index}
+	if n.Name != "" {
+		indices = indices[:0]
+		for i, name := range c.pattern.GroupNames {
+			if name == n.Name {
+				indices = append(indices, i+1)
+			}
+		}
+	}
+	return func(s *state, pos int, k continuation) bool {
+		start, end := -1, -1
+		for _, i := range indices {
+			if s.captures[2*i] >= 0 {
+				start, end = s.captures[2*i], s.captures[2*i+1]
+				break
+			}
+		}
+		if start < 0 {
+			return k(s, pos)
+		}
+		length := end - start
+		from := pos
+		if !forward {
+			from = pos - length
+		}
+		if from < 0 || from+length > len(s.input) {
+			return false
+		}
+		for i := 0; i < length; i++ {
+			a, b := rune(s.input[start+i]), rune(s.input[from+i])
+			if a != b && !(md.ignoreCase && c.canonicalize(a) == c.canonicalize(b)) {
+				return false
+			}
+		}
+		if forward {
+			return k(s, pos+length)
+		}
+		return k(s, from)
+	}
+}
+
+// sequenceOf matches the characters of str in a row, as the strings of a
+// class do.
+func (c *compiler) sequenceOf(str []rune, md mode, forward bool) matcher {
+	chars := make([]matcher, len(str))
+	for i, ch := range str {
+		ch := ch
+		m := c.set(func(other rune) bool { return other == ch }, md, forward)
+		if forward {
+			chars[i] = m
+		} else {
+			chars[len(chars)-1-i] = m
+		}
+	}
+	return sequence(chars)
+}
+
+// set matches a character of the set, as compared with case folding when
+// ignoring case.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-charactersetmatcher-abstract-operation
+func (c *compiler) set(set func(rune) bool, md mode, forward bool) matcher {
+	return func(s *state, pos int, k continuation) bool {
+		ch, width := c.read(s.input, pos, forward)
+		if width == 0 || !c.member(set, ch, md) {
+			return false
+		}
+		if forward {
+			return k(s, pos+width)
+		}
+		return k(s, pos-width)
+	}
+}
+
+// read returns the character at pos, or before it when reading backward,
+// along with its width in code units, zero at the end of the input.
+func (c *compiler) read(input []uint16, pos int, forward bool) (rune, int) {
+	if forward {
+		if pos >= len(input) {
+			return 0, 0
+		}
+		ch := rune(input[pos])
+		if c.unicodeMode && utf16.IsSurrogate(ch) && ch < 0xdc00 && pos+1 < len(input) {
+			if low := rune(input[pos+1]); low >= 0xdc00 && low <= 0xdfff {
+				return utf16.DecodeRune(ch, low), 2
+			}
+		}
+		return ch, 1
+	}
+	if pos <= 0 {
+		return 0, 0
+	}
+	ch := rune(input[pos-1])
+	if c.unicodeMode && ch >= 0xdc00 && ch <= 0xdfff && pos >= 2 {
+		if high := rune(input[pos-2]); high >= 0xd800 && high < 0xdc00 {
+			return utf16.DecodeRune(high, ch), 2
+		}
+	}
+	return ch, 1
+}
+
+// member reports whether ch is in set, or, when ignoring case, whether a
+// character of the set canonicalizes to the same character as ch does.
+func (c *compiler) member(set func(rune) bool, ch rune, md mode) bool {
+	if set(ch) {
+		return true
+	}
+	if !md.ignoreCase {
+		return false
+	}
+	canonical := c.canonicalize(ch)
+	for other := unicode.SimpleFold(ch); other != ch; other = unicode.SimpleFold(other) {
+		if set(other) && c.canonicalize(other) == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalize maps ch to the character it is compared as when ignoring case:
+// its simple case folding in UnicodeMode, and its upper case otherwise, unless
+// that takes several code units or maps a non-ASCII character to ASCII.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-canonicalize-ch
+func (c *compiler) canonicalize(ch rune) rune {
+	if c.unicodeMode {
+		// the characters folding together are those of an orbit, which is
+		// represented by its smallest member
+		canonical := ch
+		for other := unicode.SimpleFold(ch); other != ch; other = unicode.SimpleFold(other) {
+			if other < canonical {
+				canonical = other
+			}
+		}
+		return canonical
+	}
+	if hasLongUpperCase(ch) {
+		return ch
+	}
+	upper := unicode.ToUpper(ch)
+	if upper > 0xffff || ch >= 128 && upper < 128 {
+		return ch
+	}
+	return upper
+}
+
+// hasLongUpperCase reports whether the upper case of ch, as given by the
+// unconditional mappings of SpecialCasing.txt, has several characters.
+func hasLongUpperCase(ch rune) bool {
+	switch {
+	case ch == 0xdf, ch == 0x149, ch == 0x1f0, ch == 0x390, ch == 0x3b0, ch == 0x587:
+		return true
+	case ch >= 0x1e96 && ch <= 0x1e9a, ch >= 0xfb00 && ch <= 0xfb06, ch >= 0xfb13 && ch <= 0xfb17:
+		return true
+	case ch >= 0x1f50 && ch <= 0x1f56 && ch%2 == 0:
+		return true
+	case ch >= 0x1f80 && ch <= 0x1faf, ch >= 0x1fb2 && ch <= 0x1fb4, ch == 0x1fb6, ch == 0x1fb7, ch == 0x1fbc,
+		ch >= 0x1fc2 && ch <= 0x1fc4, ch == 0x1fc6, ch == 0x1fc7, ch == 0x1fcc,
+		ch >= 0x1fd2 && ch <= 0x1fd3, ch == 0x1fd6, ch == 0x1fd7,
+		ch >= 0x1fe2 && ch <= 0x1fe4, ch == 0x1fe6, ch == 0x1fe7,
+		ch >= 0x1ff2 && ch <= 0x1ff4, ch == 0x1ff6, ch == 0x1ff7, ch == 0x1ffc:
+		return true
+	}
+	return false
+}
+
+// /////////////
+// Characters //
+// /////////////
+
+// charSet returns the characters matched by a Dot, class escape or class,
+// along with the strings a class of the 'v' flag matches.
+func (c *compiler) charSet(node Node) (func(rune) bool, [][]rune, error) {
+	switch n := node.(type) {
+	case *Char:
+		if !c.unicodeMode && n.Value > 0xffff {
+			high, low := utf16.EncodeRune(n.Value)
+			return func(ch rune) bool { return ch == high || ch == low }, nil, nil
+		}
+		return func(ch rune) bool { return ch == n.Value }, nil, nil
+	case *ClassRange:
+		from, to := n.From.Value, n.To.Value
+		return func(ch rune) bool { return ch >= from && ch <= to }, nil, nil
+	case *CharacterClassEscape:
+		return c.classEscape(n.Kind), nil, nil
+	case *UnicodePropertyEscape:
+		set, err := property(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n.Negate {
+			return func(ch rune) bool { return !set(ch) }, nil, nil
+		}
+		return set, nil, nil
+	case *ClassStrings:
+		var chars []rune
+		var strings [][]rune
+		for _, s := range n.Strings {
+			if str := []rune(s); len(str) == 1 {
+				chars = append(chars, str[0])
+			} else {
+				strings = append(strings, str)
+			}
+		}
+		return func(ch rune) bool {
+			for _, other := range chars {
+				if ch == other {
+					return true
+				}
+			}
+			return false
+		}, strings, nil
+	case *CharacterClass:
+		sets := make([]func(rune) bool, 0, len(n.Elements))
+		var strings [][]rune
+		for _, element := range n.Elements {
+			set, strs, err := c.charSet(element)
+			if err != nil {
+				return nil, nil, err
+			}
+			if nested, ok := element.(*CharacterClass); ok && nested.Negate {
+				complement := set
+				set = func(ch rune) bool { return !complement(ch) }
+			}
+			sets = append(sets, set)
+			strings = append(strings, strs...)
+		}
+		// a negated class is complemented by what matches it
+		return func(ch rune) bool {
+			for _, set := range sets {
+				if set(ch) {
+					return true
+				}
+			}
+			return false
+		}, strings, nil
+	case *ClassSetOperation:
+		left, leftStrings, err := c.charSet(n.Left)
+		if err != nil {
+			return nil, nil, err
+		}
+		right, rightStrings, err := c.charSet(n.Right)
+		if err != nil {
+			return nil, nil, err
+		}
+		var strings [][]rune
+		for _, str := range leftStrings {
+			if contains(rightStrings, str) == (n.Operator == ClassIntersection) {
+				strings = append(strings, str)
+			}
+		}
+		if n.Operator == ClassIntersection {
+			return func(ch rune) bool { return left(ch) && right(ch) }, strings, nil
+		}
+		return func(ch rune) bool { return left(ch) && !right(ch) }, strings, nil
+	}
+	return nil, nil, fmt.Errorf("unexpected node %T", node)
+}
+
+func contains(strings [][]rune, str []rune) bool {
+	for _, other := range strings {
+		if string(other) == string(str) {
+			return true
+		}
+	}
+	return false
+}
+
+// classEscape returns the characters of \d, \D, \s, \S, \w or \W.
+//
+// https://262.ecma-international.org/#sec-characterclassescape
+func (c *compiler) classEscape(kind rune) func(rune) bool {
+	var set func(rune) bool
+	switch kind {
+	case 'd', 'D':
+		set = func(ch rune) bool { return ch >= '0' && ch <= '9' }
+	case 's', 'S':
+		set = func(ch rune) bool { return isWhiteSpace(ch) || isLineTerminator(ch) }
+	default:
+		md := mode{ignoreCase: c.pattern.Flags.IgnoreCase}
+		set = func(ch rune) bool { return c.isWordChar(ch, md) }
+	}
+	if unicode.IsUpper(kind) {
+		return func(ch rune) bool { return !set(ch) }
+	}
+	return set
+}
+
+// isWordChar reports whether ch is one of the WordCharacters, which also
+// include the characters that fold to them when ignoring case in
+// UnicodeMode, as U+017F ſ and U+212A K.
+//
+// https://262.ecma-international.org/#sec-wordcharacters
+func (c *compiler) isWordChar(ch rune, md mode) bool {
+	if ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' {
+		return true
+	}
+	return c.unicodeMode && md.ignoreCase && (ch == 0x17f || ch == 0x212a)
+}
+
+func isLineTerminator(ch rune) bool {
+	return ch == '\n' || ch == '\r' || ch == 0x2028 || ch == 0x2029
+}
+
+// isWhiteSpace reports whether ch is a WhiteSpace of the lexical grammar.
+func isWhiteSpace(ch rune) bool {
+	switch ch {
+	case '\t', '\v', '\f', ' ', 0xa0, 0xfeff:
+		return true
+	}
+	return unicode.Is(unicode.Zs, ch)
+}
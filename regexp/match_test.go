@@ -0,0 +1,105 @@
+package regexp
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// match matches pattern against input from index on, as the global search of
+// RegExp.prototype.exec does, and renders the match and its groups as
+// "index:match,group,...", with "-" for groups that did not participate.
+func match(t *testing.T, pattern, flags, input string) string {
+	t.Helper()
+	parsed, err := Parse(pattern, flags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := Compile(parsed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	units := utf16.Encode([]rune(input))
+	for i := 0; i <= len(units); i++ {
+		captures := m.Match(units, i)
+		if captures == nil {
+			continue
+		}
+		groups := make([]string, len(captures)/2)
+		for j := range groups {
+			if captures[2*j] < 0 {
+				groups[j] = "-"
+			} else {
+				groups[j] = string(utf16.Decode(units[captures[2*j]:captures[2*j+1]]))
+			}
+		}
+		return string(rune('0'+i)) + ":" + strings.Join(groups, ",")
+	}
+	return "nil"
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		flags   string
+		input   string
+		exp     string
+	}{
+		{`a+`, "", "caat", "1:aa"},
+		{`a+?`, "", "caat", "1:a"},
+		{`x`, "", "caat", "nil"},
+		{`a|ab`, "", "abc", "0:a"},
+		{`(a|ab)(c|bcd)(d*)`, "", "abcd", "0:abcd,a,bcd,"},
+		{`^b`, "", "a\nb", "nil"},
+		{`^b`, "m", "a\nb", "2:b"},
+		{`a$`, "m", "a\nb", "0:a"},
+		{`\bb\w*`, "", "a bc", "2:bc"},
+		{`\Bc`, "", "a bc", "3:c"},
+		{`(z)((a+)?(b+)?(c))*`, "", "zaacbbbcac", "0:zaacbbbcac,z,ac,a,-,c"},
+		{`(a*)*`, "", "b", "0:,-"},
+		{`(a*)b\1+`, "", "baaaac", "0:b,"},
+		{`(?=(a+))a*b\1`, "", "baaabac", "3:aba,a"},
+		{`(.*?)a(?!(a+)b\2c)\2(.*)`, "", "baaabaac", "0:baaabaac,ba,-,abaac"},
+		{`(?<=\$)\d+(\.\d*)?`, "", "cost $10.53", "6:10.53,.53"},
+		{`(?<!\$)\d+`, "", "$10 20", "2:0"},
+		{`(?<=(\d+)(\d+))$`, "", "1053", "4:,1,053"},
+		{`(?<a>.)\k<a>`, "", "abba", "1:bb,b"},
+		{`(?:(?<a>x)|(?<a>y))\k<a>`, "", "xyy", "1:yy,-,y"},
+		{`[a-c]+`, "", "xbcay", "1:bca"},
+		{`[^a-c]+`, "", "abxyc", "2:xy"},
+		{`\d+\s\D`, "", "a 12 b", "2:12 b"},
+		{`.+`, "", "ab\ncd", "0:ab"},
+		{`.+`, "s", "ab\ncd", "0:ab\ncd"},
+		{`ABC`, "i", "xabc", "1:abc"},
+		{`[a-z]+`, "i", "1ABc", "1:ABc"},
+		{`ſ`, "i", "s", "nil"},
+		{`ſ`, "iu", "s", "0:s"},
+		{`\w`, "iu", "ſ", "0:ſ"},
+		{`(?i:a)b`, "", "ABAb", "2:Ab"},
+		{`^.$`, "", "😀", "nil"},
+		{`^.$`, "u", "😀", "0:😀"},
+		{`\p{Lu}+`, "u", "aBCd", "1:BC"},
+		{`\p{Script=Greek}`, "u", "aβ", "1:β"},
+		{`[\p{L}--[a-z]]`, "v", "aB", "1:B"},
+		{`[\q{abc|b}]`, "v", "abc", "0:abc"},
+		{`a{2,3}`, "", "aaaa", "0:aaa"},
+		{`a{2,3}?`, "", "aaaa", "0:aa"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pattern+"/"+tc.flags, func(t *testing.T) {
+			if got := match(t, tc.pattern, tc.flags, tc.input); got != tc.exp {
+				t.Errorf("expected %q, got %q", tc.exp, got)
+			}
+		})
+	}
+
+	t.Run("unsupported property", func(t *testing.T) {
+		parsed, err := Parse(`\p{Emoji}`, "u")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := Compile(parsed); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
@@ -0,0 +1,952 @@
+package regexp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Error is a SyntaxError found while parsing a regular expression.
+type Error struct {
+	Offset  int // offset, in code points, where the error was found
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid regular expression: %s at offset %d", e.Message, e.Offset)
+}
+
+// Options configures the grammar patterns are parsed with.
+type Options struct {
+	// AnnexB enables the web compatibility grammar of Annex B for patterns
+	// without the 'u' or 'v' flags, which accepts e.g. `\c`, `{` and legacy
+	// octal escapes as literal characters, and quantified lookaheads.
+	AnnexB bool
+}
+
+// Parse parses pattern, the body of a regular expression literal, with the
+// given flags. Just like web browsers do, patterns without the 'u' or 'v' flags
+// are parsed with the web compatibility grammar of Annex B.
+//
+// https://262.ecma-international.org/#sec-patterns
+func Parse(pattern, flags string) (*Pattern, error) {
+	return ParseWithOptions(pattern, flags, Options{AnnexB: true})
+}
+
+// ParseWithOptions parses pattern with the given flags and the grammar set by
+// options.
+func ParseWithOptions(pattern, flags string, options Options) (*Pattern, error) {
+	f, err := ParseFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	unicodeMode := f.Unicode || f.UnicodeSets
+	p := &parser{
+		src:         []rune(pattern),
+		flags:       f,
+		unicodeMode: unicodeMode,
+		unicodeSets: f.UnicodeSets,
+		annexB:      options.AnnexB && !unicodeMode,
+	}
+	return p.parsePattern()
+}
+
+type parser struct {
+	src []rune
+	pos int
+
+	flags       Flags
+	unicodeMode bool // [+UnicodeMode]: either 'u' or 'v'
+	unicodeSets bool // [+UnicodeSetsMode]: 'v'
+	namedGroups bool // [+NamedCaptureGroups]: \k is a backreference
+	annexB      bool // whether the Annex B grammar applies, never in UnicodeMode
+
+	groupCount int      // capturing groups in the whole pattern
+	groupNames []string // names of the capturing groups parsed so far
+	named      []*namedGroup
+	namedRefs  []*Backreference
+
+	// alternatives enclosing the term being parsed, outermost first
+	path         []alternativeRef
+	disjunctions int
+}
+
+// alternativeRef identifies the i-th Alternative of a Disjunction.
+type alternativeRef struct {
+	disjunction int
+	alternative int
+}
+
+type namedGroup struct {
+	name string
+	path []alternativeRef
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.peekN(0)
+}
+
+func (p *parser) peekN(n int) rune {
+	if p.pos+n >= len(p.src) {
+		return -1
+	}
+	return p.src[p.pos+n]
+}
+
+func (p *parser) lookahead(s string) bool {
+	for i, ch := range []rune(s) {
+		if p.peekN(i) != ch {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) eat(s string) bool {
+	if p.lookahead(s) {
+		p.pos += len([]rune(s))
+		return true
+	}
+	return false
+}
+
+// Pattern[UnicodeMode, UnicodeSetsMode, NamedCaptureGroups] ::
+// | Disjunction[?UnicodeMode, ?UnicodeSetsMode, ?NamedCaptureGroups]
+func (p *parser) parsePattern() (*Pattern, error) {
+	hasNames := false
+	p.groupCount, hasNames = p.scanGroups()
+	p.namedGroups = p.unicodeMode || hasNames
+
+	body, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		if p.peek() == ')' {
+			return nil, p.errorf("unmatched ')'")
+		}
+		return nil, p.errorf("unexpected character %q", p.peek())
+	}
+
+	for _, ref := range p.namedRefs {
+		found := false
+		for i, name := range p.groupNames {
+			if name == ref.Name {
+				found = true
+				if ref.Index == 0 {
+					ref.Index = i + 1
+				}
+			}
+		}
+		if !found {
+			return nil, &Error{Offset: len(p.src), Message: fmt.Sprintf("invalid named reference '%s'", ref.Name)}
+		}
+	}
+
+	return &Pattern{
+		Body:       body,
+		Flags:      p.flags,
+		GroupCount: p.groupCount,
+		GroupNames: p.groupNames,
+	}, nil
+}
+
+// scanGroups counts the capturing groups of the pattern ahead of parsing it,
+// as decimal escapes may refer to groups that come after them.
+func (p *parser) scanGroups() (count int, hasNames bool) {
+	classDepth := 0
+	for i := 0; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '[':
+			if classDepth == 0 || p.unicodeSets {
+				classDepth++
+			}
+		case ']':
+			if classDepth > 0 {
+				classDepth--
+			}
+		case '(':
+			if classDepth > 0 {
+				continue
+			}
+			rest := p.src[i+1:]
+			if len(rest) == 0 || rest[0] != '?' {
+				count++
+			} else if len(rest) > 2 && rest[1] == '<' && rest[2] != '=' && rest[2] != '!' {
+				count++
+				hasNames = true
+			}
+		}
+	}
+	return count, hasNames
+}
+
+// Disjunction ::
+// | Alternative
+// | Alternative '|' Disjunction
+func (p *parser) parseDisjunction() (*Disjunction, error) {
+	id := p.disjunctions
+	p.disjunctions++
+
+	disjunction := &Disjunction{}
+	for i := 0; ; i++ {
+		p.path = append(p.path, alternativeRef{disjunction: id, alternative: i})
+		alternative, err := p.parseAlternative()
+		p.path = p.path[:len(p.path)-1]
+		if err != nil {
+			return nil, err
+		}
+		disjunction.Alternatives = append(disjunction.Alternatives, alternative)
+		if !p.eat("|") {
+			return disjunction, nil
+		}
+	}
+}
+
+// Alternative ::
+// | [empty]
+// | Alternative Term
+func (p *parser) parseAlternative() (*Alternative, error) {
+	alternative := &Alternative{Terms: []Node{}}
+	for !p.eof() && p.peek() != '|' && p.peek() != ')' {
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		alternative.Terms = append(alternative.Terms, term)
+	}
+	return alternative, nil
+}
+
+// Term ::
+// | Assertion
+// | Atom Quantifier?
+//
+// Annex B also allows lookaheads to be quantified outside UnicodeMode:
+//
+// Term[~UnicodeMode] ::
+// | QuantifiableAssertion Quantifier
+func (p *parser) parseTerm() (Node, error) {
+	switch {
+	case p.eat("^"):
+		return &Assertion{Kind: AssertStart}, nil
+	case p.eat("$"):
+		return &Assertion{Kind: AssertEnd}, nil
+	case p.eat(`\b`):
+		return &Assertion{Kind: AssertWordBoundary}, nil
+	case p.eat(`\B`):
+		return &Assertion{Kind: AssertNotWordBoundary}, nil
+	case p.lookahead("(?=") || p.lookahead("(?!") || p.lookahead("(?<=") || p.lookahead("(?<!"):
+		lookaround, err := p.parseLookaround()
+		if err != nil {
+			return nil, err
+		}
+		if !p.annexB || lookaround.Behind {
+			return lookaround, nil
+		}
+		return p.parseQuantifier(lookaround)
+	}
+
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseQuantifier(atom)
+}
+
+// Assertion ::
+// | '(?=' Disjunction ')'
+// | '(?!' Disjunction ')'
+// | '(?<=' Disjunction ')'
+// | '(?<!' Disjunction ')'
+func (p *parser) parseLookaround() (*Lookaround, error) {
+	p.pos += 2 // consume '(?'
+	lookaround := &Lookaround{Behind: p.eat("<")}
+	lookaround.Negate = p.peek() == '!'
+	p.pos++ // consume '=' or '!'
+
+	body, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eat(")") {
+		return nil, p.errorf("unterminated group")
+	}
+	lookaround.Body = body
+	return lookaround, nil
+}
+
+// Quantifier ::
+// | QuantifierPrefix '?'?
+//
+// QuantifierPrefix ::
+// | '*' | '+' | '?'
+// | '{' DecimalDigits '}'
+// | '{' DecimalDigits ',}'
+// | '{' DecimalDigits ',' DecimalDigits '}'
+func (p *parser) parseQuantifier(atom Node) (Node, error) {
+	start := p.pos
+	quantifier := &Quantifier{Atom: atom, Greedy: true}
+	switch p.peek() {
+	case '*':
+		p.pos++
+		quantifier.Min, quantifier.Max = 0, Unbounded
+	case '+':
+		p.pos++
+		quantifier.Min, quantifier.Max = 1, Unbounded
+	case '?':
+		p.pos++
+		quantifier.Min, quantifier.Max = 0, 1
+	case '{':
+		min, max, ok := p.parseBracedQuantifier()
+		if !ok {
+			// Annex B reads a '{' that does not start a quantifier literally
+			return atom, nil
+		}
+		if max != Unbounded && min > max {
+			p.pos = start
+			return nil, p.errorf("numbers out of order in {} quantifier")
+		}
+		quantifier.Min, quantifier.Max = min, max
+	default:
+		return atom, nil
+	}
+	if p.eat("?") {
+		quantifier.Greedy = false
+	}
+	return quantifier, nil
+}
+
+// parseBracedQuantifier consumes a '{n}', '{n,}' or '{n,m}' quantifier,
+// leaving the cursor untouched if there is none.
+func (p *parser) parseBracedQuantifier() (min, max int, ok bool) {
+	start := p.pos
+	p.pos++ // consume '{'
+	min, ok = p.parseDecimalDigits()
+	if !ok {
+		p.pos = start
+		return 0, 0, false
+	}
+	max = min
+	if p.eat(",") {
+		max = Unbounded
+		if n, ok := p.parseDecimalDigits(); ok {
+			max = n
+		}
+	}
+	if !p.eat("}") {
+		p.pos = start
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// parseDecimalDigits parses a non-empty sequence of decimal digits, saturating
+// on overflow.
+func (p *parser) parseDecimalDigits() (int, bool) {
+	n, digits := 0, 0
+	for ch := p.peek(); ch >= '0' && ch <= '9'; ch = p.peek() {
+		if n <= (math.MaxInt32-9)/10 {
+			n = n*10 + int(ch-'0')
+		} else {
+			n = math.MaxInt32
+		}
+		p.pos++
+		digits++
+	}
+	return n, digits > 0
+}
+
+// Atom ::
+// | PatternCharacter
+// | '.'
+// | '\' AtomEscape
+// | CharacterClass
+// | '(' GroupSpecifier? Disjunction ')'
+// | '(?' RegularExpressionModifiers ':' Disjunction ')'
+// | '(?' RegularExpressionModifiers '-' RegularExpressionModifiers ':' Disjunction ')'
+//
+// ExtendedAtom[~UnicodeMode] (Annex B) ::
+// | InvalidBracedQuantifier
+// | ExtendedPatternCharacter
+func (p *parser) parseAtom() (Node, error) {
+	ch := p.peek()
+	switch ch {
+	case '.':
+		p.pos++
+		return &Dot{}, nil
+	case '(':
+		return p.parseGroup()
+	case '[':
+		if p.unicodeSets {
+			return p.parseClassSet()
+		}
+		return p.parseClass()
+	case '\\':
+		return p.parseAtomEscape()
+	case '*', '+', '?':
+		return nil, p.errorf("nothing to repeat")
+	case '{':
+		if _, _, ok := p.parseBracedQuantifier(); ok {
+			return nil, p.errorf("nothing to repeat")
+		}
+		if !p.annexB {
+			return nil, p.errorf("lone quantifier brackets")
+		}
+	case '}', ']':
+		if !p.annexB {
+			return nil, p.errorf("lone quantifier brackets")
+		}
+	}
+	p.pos++
+	return &Char{Value: ch}, nil
+}
+
+// Group ::
+// | '(' Disjunction ')'
+// | '(?<' GroupName '>' Disjunction ')'
+// | '(?:' Disjunction ')'
+// | '(?' Modifiers ('-' Modifiers)? ':' Disjunction ')'
+func (p *parser) parseGroup() (Node, error) {
+	p.pos++ // consume '('
+	group := &Group{}
+	switch {
+	case p.eat("?:"):
+	case p.lookahead("?<"):
+		p.pos++ // consume '?'
+		name, err := p.parseGroupName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.declareGroupName(name); err != nil {
+			return nil, err
+		}
+		group.Name = name
+		group.Index = len(p.groupNames)
+	case p.eat("?"):
+		if err := p.parseModifiers(group); err != nil {
+			return nil, err
+		}
+	default:
+		p.groupNames = append(p.groupNames, "")
+		group.Index = len(p.groupNames)
+	}
+
+	body, err := p.parseDisjunction()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eat(")") {
+		return nil, p.errorf("unterminated group")
+	}
+	group.Body = body
+	return group, nil
+}
+
+// declareGroupName registers a named capturing group. It is an early error
+// for two groups to share a name unless they are in different alternatives,
+// so that only one of them can ever participate in a match.
+func (p *parser) declareGroupName(name string) error {
+	path := append([]alternativeRef(nil), p.path...)
+	for _, other := range p.named {
+		if other.name == name && !exclusiveAlternatives(path, other.path) {
+			return p.errorf("duplicate capture group name '%s'", name)
+		}
+	}
+	p.named = append(p.named, &namedGroup{name: name, path: path})
+	p.groupNames = append(p.groupNames, name)
+	return nil
+}
+
+func exclusiveAlternatives(a, b []alternativeRef) bool {
+	for i := 0; i < len(a) && i < len(b) && a[i].disjunction == b[i].disjunction; i++ {
+		if a[i].alternative != b[i].alternative {
+			return true
+		}
+	}
+	return false
+}
+
+// RegularExpressionModifiers ::
+// | [empty]
+// | RegularExpressionModifiers RegularExpressionModifier (one of 'i' 'm' 's')
+//
+// It is an early error for a modifier to be repeated, or for both lists to be
+// empty when a '-' is present.
+func (p *parser) parseModifiers(group *Group) error {
+	seen := map[rune]bool{}
+	parse := func(flags *Flags) (int, error) {
+		count := 0
+		for {
+			ch := p.peek()
+			var flag *bool
+			switch ch {
+			case 'i':
+				flag = &flags.IgnoreCase
+			case 'm':
+				flag = &flags.Multiline
+			case 's':
+				flag = &flags.DotAll
+			default:
+				return count, nil
+			}
+			if seen[ch] {
+				return 0, p.errorf("repeated flag in group modifiers")
+			}
+			seen[ch] = true
+			*flag = true
+			p.pos++
+			count++
+		}
+	}
+
+	enabled, err := parse(&group.Enable)
+	if err != nil {
+		return err
+	}
+	if p.eat("-") {
+		disabled, err := parse(&group.Disable)
+		if err != nil {
+			return err
+		}
+		if enabled == 0 && disabled == 0 {
+			return p.errorf("invalid group modifiers")
+		}
+	}
+	if !p.eat(":") {
+		return p.errorf("invalid group")
+	}
+	return nil
+}
+
+// GroupName ::
+// | '<' RegExpIdentifierName '>'
+func (p *parser) parseGroupName() (string, error) {
+	if !p.eat("<") {
+		return "", p.errorf("invalid capture group name")
+	}
+	var name strings.Builder
+	for first := true; ; first = false {
+		ch := p.peek()
+		if ch == '>' && !first {
+			p.pos++
+			return name.String(), nil
+		}
+		if ch == '\\' {
+			p.pos++ // consume '\'
+			if p.peek() != 'u' {
+				return "", p.errorf("invalid capture group name")
+			}
+			escaped, ok := p.parseUnicodeEscape(true)
+			if !ok {
+				return "", p.errorf("invalid unicode escape")
+			}
+			ch = escaped
+		} else {
+			p.pos++
+		}
+		if (first && !isIDStart(ch)) || (!first && !isIDContinue(ch)) {
+			return "", p.errorf("invalid capture group name")
+		}
+		name.WriteRune(ch)
+	}
+}
+
+func isIDStart(ch rune) bool {
+	return ch == '$' || ch == '_' || unicode.IsLetter(ch) || unicode.In(ch, unicode.Nl, unicode.Other_ID_Start)
+}
+
+func isIDContinue(ch rune) bool {
+	return isIDStart(ch) || ch == 0x200C || ch == 0x200D ||
+		unicode.In(ch, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+// AtomEscape ::
+// | DecimalEscape
+// | CharacterClassEscape
+// | CharacterEscape
+// | [+NamedCaptureGroups] 'k' GroupName
+func (p *parser) parseAtomEscape() (Node, error) {
+	p.pos++ // consume '\'
+	if p.eof() {
+		return nil, p.errorf(`\ at end of pattern`)
+	}
+
+	ch := p.peek()
+	switch {
+	case ch == 'k' && p.namedGroups:
+		p.pos++ // consume 'k'
+		name, err := p.parseGroupName()
+		if err != nil {
+			return nil, p.errorf("invalid named reference")
+		}
+		ref := &Backreference{Name: name}
+		p.namedRefs = append(p.namedRefs, ref)
+		return ref, nil
+	case ch >= '1' && ch <= '9':
+		// DecimalEscape, unless Annex B reads it as a legacy octal escape
+		start := p.pos
+		n, _ := p.parseDecimalDigits()
+		if n <= p.groupCount {
+			return &Backreference{Index: n}, nil
+		}
+		if !p.annexB {
+			p.pos = start
+			return nil, p.errorf("invalid escape")
+		}
+		p.pos = start
+	}
+
+	if escape, ok, err := p.parseClassEscape(); ok || err != nil {
+		return escape, err
+	}
+	value, err := p.parseCharacterEscape(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Char{Value: value}, nil
+}
+
+// CharacterClassEscape ::
+// | 'd' | 'D' | 's' | 'S' | 'w' | 'W'
+// | [+UnicodeMode] 'p{' UnicodePropertyValueExpression '}'
+// | [+UnicodeMode] 'P{' UnicodePropertyValueExpression '}'
+func (p *parser) parseClassEscape() (Node, bool, error) {
+	switch ch := p.peek(); ch {
+	case 'd', 'D', 's', 'S', 'w', 'W':
+		p.pos++
+		return &CharacterClassEscape{Kind: ch}, true, nil
+	case 'p', 'P':
+		if !p.unicodeMode {
+			return nil, false, nil
+		}
+		escape, err := p.parsePropertyEscape()
+		return escape, true, err
+	}
+	return nil, false, nil
+}
+
+// UnicodePropertyValueExpression ::
+// | UnicodePropertyName '=' UnicodePropertyValue
+// | LoneUnicodePropertyNameOrValue
+func (p *parser) parsePropertyEscape() (Node, error) {
+	negate := p.peek() == 'P'
+	p.pos++ // consume 'p' or 'P'
+	if !p.eat("{") {
+		return nil, p.errorf("invalid property name")
+	}
+
+	start := p.pos
+	var name, value string
+	for !p.eof() && p.peek() != '}' {
+		if p.peek() == '=' && name == "" {
+			name = string(p.src[start:p.pos])
+			start = p.pos + 1
+		}
+		p.pos++
+	}
+	value = string(p.src[start:p.pos])
+	if !p.eat("}") || value == "" {
+		return nil, p.errorf("invalid property name")
+	}
+
+	canonical, ofStrings, ok := lookupProperty(name, value, p.unicodeSets)
+	if !ok || (ofStrings && negate) {
+		return nil, p.errorf("invalid property name")
+	}
+	return &UnicodePropertyEscape{
+		Name:    canonical,
+		Value:   value,
+		Negate:  negate,
+		Strings: ofStrings,
+	}, nil
+}
+
+// CharacterEscape ::
+// | ControlEscape (one of 'f' 'n' 'r' 't' 'v')
+// | 'c' AsciiLetter
+// | '0' [lookahead ∉ DecimalDigit]
+// | HexEscapeSequence
+// | RegExpUnicodeEscapeSequence
+// | IdentityEscape
+//
+// IdentityEscape is a SyntaxCharacter or '/' in UnicodeMode, and any other
+// SourceCharacter but UnicodeIDContinue outside of it. Annex B adds
+// LegacyOctalEscapeSequence and makes IdentityEscape any SourceCharacter but
+// 'c' (and 'k' with named groups).
+func (p *parser) parseCharacterEscape(inClass bool) (rune, error) {
+	ch := p.peek()
+	switch ch {
+	case 'f':
+		p.pos++
+		return '\f', nil
+	case 'n':
+		p.pos++
+		return '\n', nil
+	case 'r':
+		p.pos++
+		return '\r', nil
+	case 't':
+		p.pos++
+		return '\t', nil
+	case 'v':
+		p.pos++
+		return '\v', nil
+	case 'c':
+		next := p.peekN(1)
+		if (next >= 'a' && next <= 'z') || (next >= 'A' && next <= 'Z') {
+			p.pos += 2
+			return next % 32, nil
+		}
+		if !p.annexB {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		if inClass && ((next >= '0' && next <= '9') || next == '_') {
+			// ClassControlLetter
+			p.pos += 2
+			return next % 32, nil
+		}
+		// '\' is read as itself, and 'c' as the next PatternCharacter
+		return '\\', nil
+	case '0':
+		if next := p.peekN(1); next < '0' || next > '9' {
+			p.pos++
+			return 0, nil
+		}
+		if !p.annexB {
+			return 0, p.errorf("invalid decimal escape")
+		}
+		return p.parseLegacyOctalEscape(), nil
+	case '1', '2', '3', '4', '5', '6', '7':
+		if !p.annexB {
+			return 0, p.errorf("invalid escape")
+		}
+		return p.parseLegacyOctalEscape(), nil
+	case 'x':
+		if isHexDigit(p.peekN(1)) && isHexDigit(p.peekN(2)) {
+			value := hexValue(p.src[p.pos+1 : p.pos+3])
+			p.pos += 3
+			return value, nil
+		}
+		if !p.annexB {
+			return 0, p.errorf("invalid escape")
+		}
+	case 'u':
+		if value, ok := p.parseUnicodeEscape(p.unicodeMode); ok {
+			return value, nil
+		}
+		if !p.annexB {
+			return 0, p.errorf("invalid unicode escape")
+		}
+	case 'k':
+		if p.namedGroups || !p.annexB {
+			return 0, p.errorf("invalid named reference")
+		}
+	default:
+		if p.unicodeMode && !isSyntaxCharacter(ch) && ch != '/' && !(inClass && ch == '-') {
+			return 0, p.errorf("invalid escape")
+		}
+		if !p.unicodeMode && !p.annexB && isIDContinue(ch) && !isSyntaxCharacter(ch) {
+			return 0, p.errorf("invalid escape")
+		}
+	}
+
+	// IdentityEscape
+	p.pos++
+	return ch, nil
+}
+
+// LegacyOctalEscapeSequence ::
+// | ZeroToThree OctalDigit? OctalDigit?
+// | FourToSeven OctalDigit?
+func (p *parser) parseLegacyOctalEscape() rune {
+	first := p.peek()
+	if first == '8' || first == '9' {
+		p.pos++
+		return first
+	}
+	maxDigits := 2
+	if first <= '3' {
+		maxDigits = 3
+	}
+	value := rune(0)
+	for i := 0; i < maxDigits && p.peek() >= '0' && p.peek() <= '7'; i++ {
+		value = value*8 + p.peek() - '0'
+		p.pos++
+	}
+	return value
+}
+
+// RegExpUnicodeEscapeSequence ::
+// | 'u' HexLeadSurrogate '\u' HexTrailSurrogate
+// | 'u' Hex4Digits
+// | [+UnicodeMode] 'u{' CodePoint '}'
+//
+// The cursor is at 'u', and is left untouched when there is no valid escape.
+func (p *parser) parseUnicodeEscape(unicodeMode bool) (rune, bool) {
+	start := p.pos
+	p.pos++ // consume 'u'
+	if unicodeMode && p.eat("{") {
+		digits := p.pos
+		for isHexDigit(p.peek()) {
+			p.pos++
+		}
+		value := hexValue(p.src[digits:p.pos])
+		if p.pos == digits || p.pos-digits > 8 || value > unicode.MaxRune || !p.eat("}") {
+			p.pos = start
+			return 0, false
+		}
+		return value, true
+	}
+
+	value, ok := p.parseHex4Digits()
+	if !ok {
+		p.pos = start
+		return 0, false
+	}
+	if unicodeMode && value >= 0xD800 && value <= 0xDBFF && p.lookahead(`\u`) {
+		lead := p.pos
+		p.pos += 2
+		if trail, ok := p.parseHex4Digits(); ok && trail >= 0xDC00 && trail <= 0xDFFF {
+			return (value-0xD800)*0x400 + (trail - 0xDC00) + 0x10000, true
+		}
+		p.pos = lead
+	}
+	return value, true
+}
+
+func (p *parser) parseHex4Digits() (rune, bool) {
+	for i := 0; i < 4; i++ {
+		if !isHexDigit(p.peekN(i)) {
+			return 0, false
+		}
+	}
+	value := hexValue(p.src[p.pos : p.pos+4])
+	p.pos += 4
+	return value, true
+}
+
+func isHexDigit(ch rune) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func hexValue(digits []rune) rune {
+	value := rune(0)
+	for _, ch := range digits {
+		switch {
+		case ch >= 'a':
+			ch = ch - 'a' + 10
+		case ch >= 'A':
+			ch = ch - 'A' + 10
+		default:
+			ch = ch - '0'
+		}
+		if value > unicode.MaxRune {
+			return value
+		}
+		value = value*16 + ch
+	}
+	return value
+}
+
+// SyntaxCharacter :: one of
+// '^' '$' '\' '.' '*' '+' '?' '(' ')' '[' ']' '{' '}' '|'
+func isSyntaxCharacter(ch rune) bool {
+	return strings.ContainsRune(`^$\.*+?()[]{}|`, ch)
+}
+
+// CharacterClass ::
+// | '[' [lookahead ≠ '^'] ClassContents ']'
+// | '[^' ClassContents ']'
+//
+// NonemptyClassRanges ::
+// | ClassAtom
+// | ClassAtom NonemptyClassRangesNoDash
+// | ClassAtom '-' ClassAtom ClassContents
+func (p *parser) parseClass() (Node, error) {
+	p.pos++ // consume '['
+	class := &CharacterClass{Negate: p.eat("^"), Elements: []Node{}}
+	for {
+		if p.eof() {
+			return nil, p.errorf("unterminated character class")
+		}
+		if p.eat("]") {
+			return class, nil
+		}
+
+		from, err := p.parseClassAtom()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != '-' || p.peekN(1) == ']' || p.peekN(1) == -1 {
+			class.Elements = append(class.Elements, from)
+			continue
+		}
+
+		p.pos++ // consume '-'
+		to, err := p.parseClassAtom()
+		if err != nil {
+			return nil, err
+		}
+		fromChar, isFromChar := from.(*Char)
+		toChar, isToChar := to.(*Char)
+		if !isFromChar || !isToChar {
+			if !p.annexB {
+				return nil, p.errorf("invalid character class")
+			}
+			// Annex B reads a range with a class escape as its atoms and a '-'
+			class.Elements = append(class.Elements, from, &Char{Value: '-'}, to)
+			continue
+		}
+		if fromChar.Value > toChar.Value {
+			return nil, p.errorf("range out of order in character class")
+		}
+		class.Elements = append(class.Elements, &ClassRange{From: fromChar, To: toChar})
+	}
+}
+
+// ClassAtom ::
+// | '-'
+// | SourceCharacter but not one of '\' or ']' or '-'
+// | '\' ClassEscape
+//
+// ClassEscape ::
+// | 'b'
+// | [+UnicodeMode] '-'
+// | CharacterClassEscape
+// | CharacterEscape
+func (p *parser) parseClassAtom() (Node, error) {
+	ch := p.peek()
+	if ch != '\\' {
+		p.pos++
+		return &Char{Value: ch}, nil
+	}
+
+	p.pos++ // consume '\'
+	if p.eof() {
+		return nil, p.errorf(`\ at end of pattern`)
+	}
+	if p.eat("b") {
+		return &Char{Value: '\b'}, nil
+	}
+	if escape, ok, err := p.parseClassEscape(); ok || err != nil {
+		return escape, err
+	}
+	value, err := p.parseCharacterEscape(true)
+	if err != nil {
+		return nil, err
+	}
+	return &Char{Value: value}, nil
+}
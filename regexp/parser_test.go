@@ -0,0 +1,186 @@
+package regexp
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		pattern string
+		flags   string
+		exp     string
+	}{
+		{`abc`, "", `(regexp (seq 'a' 'b' 'c'))`},
+		{`a|b|`, "", `(regexp (| 'a' 'b' (seq)))`},
+		{`^a$\b\B`, "", `(regexp (seq ^ 'a' $ \b \B))`},
+		{`a*b+?c?d{2}e{2,}f{2,3}?`, "", `(regexp (seq (* 'a') (+? 'b') (? 'c') ({2} 'd') ({2,} 'e') ({2,3}? 'f')))`},
+		{`(a)(?:b)(?<name>c)\1\k<name>`, "", `(regexp (seq (group 1 'a') (?: 'b') (group 2 name 'c') (\ 1) (\k name)))`},
+		{`(?<=a)(?<!b)(?=c)(?!d)`, "", `(regexp (seq (?<= 'a') (?<! 'b') (?= 'c') (?! 'd')))`},
+		{`(?i-m:a)(?s:b)`, "", `(regexp (seq (?i-m: 'a') (?s: 'b')))`},
+		{`.\d\W`, "", `(regexp (seq . \d \W))`},
+		{`[^a-z\s-]`, "", `(regexp [^ (- 'a' 'z') \s '-'])`},
+		{`[\b\-]`, "u", `(regexp ['\b' '-'])`},
+		{`\u{1F600}😀`, "u", `(regexp (seq '😀' '😀'))`},
+		{`\x41B\cJ\0\n`, "", `(regexp (seq 'A' 'B' '\n' '\x00' '\n'))`},
+		{`\p{Lu}\P{Script=Greek}\p{ASCII}`, "u", `(regexp (seq \p{Lu} \P{Script=Greek} \p{ASCII}))`},
+		{`(?<\u{61}b>.)\k<ab>`, "", `(regexp (seq (group 1 ab .) (\k ab)))`},
+		{`(?<a>x)|(?<a>y)`, "", `(regexp (| (group 1 a 'x') (group 2 a 'y')))`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got, err := Parse(tc.pattern, tc.flags)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.S() != tc.exp {
+				t.Errorf("expected %s, got %s", tc.exp, got.S())
+			}
+		})
+	}
+
+	t.Run("group count and names", func(t *testing.T) {
+		got, err := Parse(`(a)(?<b>c)(?:d)(?=(e))`, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.GroupCount != 3 {
+			t.Errorf("expected 3 groups, got %d", got.GroupCount)
+		}
+		if exp := []string{"", "b", ""}; len(got.GroupNames) != 3 || got.GroupNames[1] != exp[1] {
+			t.Errorf("expected group names %q, got %q", exp, got.GroupNames)
+		}
+	})
+}
+
+func TestParse_AnnexB(t *testing.T) {
+	cases := []struct {
+		pattern string
+		exp     string
+	}{
+		{`a{`, `(regexp (seq 'a' '{'))`},
+		{`a{1,x}`, `(regexp (seq 'a' '{' '1' ',' 'x' '}'))`},
+		{`]}`, `(regexp (seq ']' '}'))`},
+		{`\c`, `(regexp (seq '\\' 'c'))`},
+		{`[\c1]`, `(regexp ['\x11'])`},
+		{`\8\1`, `(regexp (seq '8' '\x01'))`},
+		{`\101\400`, `(regexp (seq 'A' ' ' '0'))`},
+		{`\a\k`, `(regexp (seq 'a' 'k'))`},
+		{`(?=a)*`, `(regexp (* (?= 'a')))`},
+		{`[\d-a]`, `(regexp [\d '-' 'a'])`},
+		{`\p{Lu}`, `(regexp (seq 'p' '{' 'L' 'u' '}'))`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got, err := Parse(tc.pattern, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.S() != tc.exp {
+				t.Errorf("expected %s, got %s", tc.exp, got.S())
+			}
+			if _, err := ParseWithOptions(tc.pattern, "", Options{}); err == nil {
+				t.Errorf("expected %s to be rejected without Annex B", tc.pattern)
+			}
+		})
+	}
+
+	t.Run("without Annex B", func(t *testing.T) {
+		patterns := []string{`a{1,2}\{\}`, `\-\/\$\@`, `[\b\-]`, `\0`, `(a)\1`, `(?<n>a)\k<n>`, `\x41\u0041\cA`}
+		for _, pattern := range patterns {
+			if _, err := ParseWithOptions(pattern, "", Options{}); err != nil {
+				t.Errorf("unexpected error for %s: %v", pattern, err)
+			}
+		}
+	})
+}
+
+func TestParse_UnicodeSets(t *testing.T) {
+	cases := []struct {
+		pattern string
+		exp     string
+	}{
+		{`[a-c[d]\q{ef|g}]`, `(regexp [(- 'a' 'c') ['d'] (\q "ef" "g")])`},
+		{`[\w&&[a-z]&&\d]`, `(regexp [(&& (&& \w [(- 'a' 'z')]) \d)])`},
+		{`[\p{L}--[a]--\q{b}]`, `(regexp [(-- (-- \p{L} ['a']) (\q "b"))])`},
+		{`[^\q{a|b}]`, `(regexp [^ (\q "a" "b")])`},
+		{`[\p{RGI_Emoji}]`, `(regexp [\p{RGI_Emoji}])`},
+		{`[^\p{RGI_Emoji}&&a]`, `(regexp [^ (&& \p{RGI_Emoji} 'a')])`},
+		{`[\&\-\!]`, `(regexp ['&' '-' '!'])`},
+		{`[]`, `(regexp [])`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got, err := Parse(tc.pattern, "v")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.S() != tc.exp {
+				t.Errorf("expected %s, got %s", tc.exp, got.S())
+			}
+		})
+	}
+}
+
+func TestParse_Rejected(t *testing.T) {
+	cases := []struct {
+		pattern string
+		flags   string
+	}{
+		// flags
+		{`a`, "x"},
+		{`a`, "gg"},
+		{`a`, "uv"},
+		// quantifiers
+		{`*`, ""},
+		{`a**`, ""},
+		{`{1}`, ""},
+		{`a{2,1}`, ""},
+		{`(?<=a)*`, ""},
+		{`(?=a)*`, "u"},
+		{`a{`, "u"},
+		{`]`, "u"},
+		// groups
+		{`(`, ""},
+		{`a)`, ""},
+		{`(?a)`, ""},
+		{`(?ii:a)`, ""},
+		{`(?-:a)`, ""},
+		{`(?<a>x)(?<a>y)`, ""},
+		{`(?<a>x)|((?<a>y)(?<a>z))`, ""},
+		{`(?<1a>x)`, ""},
+		{`(?<>x)`, ""},
+		{`\k<a>(?<b>.)`, ""},
+		{`\k`, "u"},
+		// escapes
+		{`\`, ""},
+		{`\c`, "u"},
+		{`\a`, "u"},
+		{`\00`, "u"},
+		{`\1`, "u"},
+		{`\u{110000}`, "u"},
+		{`\x1`, "u"},
+		{`\p{Foo}`, "u"},
+		{`\p{General_Category=Greek}`, "u"},
+		{`\p{RGI_Emoji}`, "u"},
+		{`\P{RGI_Emoji}`, "v"},
+		// classes
+		{`[a`, ""},
+		{`[z-a]`, ""},
+		{`[\d-a]`, "u"},
+		{`[(]`, "v"},
+		{`[a&&&b]`, "v"},
+		{`[a&&b--c]`, "v"},
+		{`[ab--c]`, "v"},
+		{`[a!!b]`, "v"},
+		{`[^\q{ab}]`, "v"},
+		{`[^[\p{RGI_Emoji}]]`, "v"},
+		{`[\q{a]`, "v"},
+	}
+	for _, tc := range cases {
+		t.Run("/"+tc.pattern+"/"+tc.flags, func(t *testing.T) {
+			if got, err := Parse(tc.pattern, tc.flags); err == nil {
+				t.Errorf("expected an error, got %s", got.S())
+			}
+		})
+	}
+}
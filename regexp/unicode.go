@@ -0,0 +1,387 @@
+package regexp
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Property names and values accepted by \p{...} and \P{...}, as listed by the
+// specification's tables of Unicode property aliases.
+//
+// https://262.ecma-international.org/#table-nonbinary-unicode-properties
+
+var nonBinaryPropertyNames = map[string]string{
+	"General_Category":  "General_Category",
+	"gc":                "General_Category",
+	"Script":            "Script",
+	"sc":                "Script",
+	"Script_Extensions": "Script_Extensions",
+	"scx":               "Script_Extensions",
+}
+
+// generalCategoryValues maps the long names of General_Category values to
+// their aliases.
+var generalCategoryValues = map[string][]string{
+	"Cased_Letter":          {"LC"},
+	"Close_Punctuation":     {"Pe"},
+	"Connector_Punctuation": {"Pc"},
+	"Control":               {"Cc", "cntrl"},
+	"Currency_Symbol":       {"Sc"},
+	"Dash_Punctuation":      {"Pd"},
+	"Decimal_Number":        {"Nd", "digit"},
+	"Enclosing_Mark":        {"Me"},
+	"Final_Punctuation":     {"Pf"},
+	"Format":                {"Cf"},
+	"Initial_Punctuation":   {"Pi"},
+	"Letter":                {"L"},
+	"Letter_Number":         {"Nl"},
+	"Line_Separator":        {"Zl"},
+	"Lowercase_Letter":      {"Ll"},
+	"Mark":                  {"M", "Combining_Mark"},
+	"Math_Symbol":           {"Sm"},
+	"Modifier_Letter":       {"Lm"},
+	"Modifier_Symbol":       {"Sk"},
+	"Nonspacing_Mark":       {"Mn"},
+	"Number":                {"N"},
+	"Open_Punctuation":      {"Ps"},
+	"Other":                 {"C"},
+	"Other_Letter":          {"Lo"},
+	"Other_Number":          {"No"},
+	"Other_Punctuation":     {"Po"},
+	"Other_Symbol":          {"So"},
+	"Paragraph_Separator":   {"Zp"},
+	"Private_Use":           {"Co"},
+	"Punctuation":           {"P", "punct"},
+	"Separator":             {"Z"},
+	"Space_Separator":       {"Zs"},
+	"Spacing_Mark":          {"Mc"},
+	"Surrogate":             {"Cs"},
+	"Symbol":                {"S"},
+	"Titlecase_Letter":      {"Lt"},
+	"Unassigned":            {"Cn"},
+	"Uppercase_Letter":      {"Lu"},
+}
+
+// binaryProperties maps the binary Unicode properties to their aliases.
+var binaryProperties = map[string][]string{
+	"ASCII":                        nil,
+	"ASCII_Hex_Digit":              {"AHex"},
+	"Alphabetic":                   {"Alpha"},
+	"Any":                          nil,
+	"Assigned":                     nil,
+	"Bidi_Control":                 {"Bidi_C"},
+	"Bidi_Mirrored":                {"Bidi_M"},
+	"Case_Ignorable":               {"CI"},
+	"Cased":                        nil,
+	"Changes_When_Casefolded":      {"CWCF"},
+	"Changes_When_Casemapped":      {"CWCM"},
+	"Changes_When_Lowercased":      {"CWL"},
+	"Changes_When_NFKC_Casefolded": {"CWKCF"},
+	"Changes_When_Titlecased":      {"CWT"},
+	"Changes_When_Uppercased":      {"CWU"},
+	"Dash":                         nil,
+	"Default_Ignorable_Code_Point": {"DI"},
+	"Deprecated":                   {"Dep"},
+	"Diacritic":                    {"Dia"},
+	"Emoji":                        nil,
+	"Emoji_Component":              {"EComp"},
+	"Emoji_Modifier":               {"EMod"},
+	"Emoji_Modifier_Base":          {"EBase"},
+	"Emoji_Presentation":           {"EPres"},
+	"Extended_Pictographic":        {"ExtPict"},
+	"Extender":                     {"Ext"},
+	"Grapheme_Base":                {"Gr_Base"},
+	"Grapheme_Extend":              {"Gr_Ext"},
+	"Hex_Digit":                    {"Hex"},
+	"IDS_Binary_Operator":          {"IDSB"},
+	"IDS_Trinary_Operator":         {"IDST"},
+	"ID_Continue":                  {"IDC"},
+	"ID_Start":                     {"IDS"},
+	"Ideographic":                  {"Ideo"},
+	"Join_Control":                 {"Join_C"},
+	"Logical_Order_Exception":      {"LOE"},
+	"Lowercase":                    {"Lower"},
+	"Math":                         nil,
+	"Noncharacter_Code_Point":      {"NChar"},
+	"Pattern_Syntax":               {"Pat_Syn"},
+	"Pattern_White_Space":          {"Pat_WS"},
+	"Quotation_Mark":               {"QMark"},
+	"Radical":                      nil,
+	"Regional_Indicator":           {"RI"},
+	"Sentence_Terminal":            {"STerm"},
+	"Soft_Dotted":                  {"SD"},
+	"Terminal_Punctuation":         {"Term"},
+	"Unified_Ideograph":            {"UIdeo"},
+	"Uppercase":                    {"Upper"},
+	"Variation_Selector":           {"VS"},
+	"White_Space":                  {"space"},
+	"XID_Continue":                 {"XIDC"},
+	"XID_Start":                    {"XIDS"},
+}
+
+// stringProperties are the properties of strings, only available with the
+// 'v' flag.
+var stringProperties = map[string]bool{
+	"Basic_Emoji":                 true,
+	"Emoji_Keycap_Sequence":       true,
+	"RGI_Emoji_Modifier_Sequence": true,
+	"RGI_Emoji_Flag_Sequence":     true,
+	"RGI_Emoji_Tag_Sequence":      true,
+	"RGI_Emoji_ZWJ_Sequence":      true,
+	"RGI_Emoji":                   true,
+}
+
+// scriptAliases maps the Script values known to the unicode package to their
+// ISO 15924 aliases.
+var scriptAliases = map[string][]string{
+	"Adlam": {"Adlm"}, "Ahom": {"Ahom"}, "Anatolian_Hieroglyphs": {"Hluw"}, "Arabic": {"Arab"},
+	"Armenian": {"Armn"}, "Avestan": {"Avst"}, "Balinese": {"Bali"}, "Bamum": {"Bamu"},
+	"Bassa_Vah": {"Bass"}, "Batak": {"Batk"}, "Bengali": {"Beng"}, "Beria_Erfe": {"Berf"},
+	"Bhaiksuki": {"Bhks"}, "Bopomofo": {"Bopo"}, "Brahmi": {"Brah"}, "Braille": {"Brai"},
+	"Buginese": {"Bugi"}, "Buhid": {"Buhd"}, "Canadian_Aboriginal": {"Cans"}, "Carian": {"Cari"},
+	"Caucasian_Albanian": {"Aghb"}, "Chakma": {"Cakm"}, "Cham": {"Cham"}, "Cherokee": {"Cher"},
+	"Chorasmian": {"Chrs"}, "Common": {"Zyyy"}, "Coptic": {"Copt", "Qaac"}, "Cuneiform": {"Xsux"},
+	"Cypriot": {"Cprt"}, "Cypro_Minoan": {"Cpmn"}, "Cyrillic": {"Cyrl"}, "Deseret": {"Dsrt"},
+	"Devanagari": {"Deva"}, "Dives_Akuru": {"Diak"}, "Dogra": {"Dogr"}, "Duployan": {"Dupl"},
+	"Egyptian_Hieroglyphs": {"Egyp"}, "Elbasan": {"Elba"}, "Elymaic": {"Elym"}, "Ethiopic": {"Ethi"},
+	"Garay": {"Gara"}, "Georgian": {"Geor"}, "Glagolitic": {"Glag"}, "Gothic": {"Goth"},
+	"Grantha": {"Gran"}, "Greek": {"Grek"}, "Gujarati": {"Gujr"}, "Gunjala_Gondi": {"Gong"},
+	"Gurmukhi": {"Guru"}, "Gurung_Khema": {"Gukh"}, "Han": {"Hani"}, "Hangul": {"Hang"},
+	"Hanifi_Rohingya": {"Rohg"}, "Hanunoo": {"Hano"}, "Hatran": {"Hatr"}, "Hebrew": {"Hebr"},
+	"Hiragana": {"Hira"}, "Imperial_Aramaic": {"Armi"}, "Inherited": {"Zinh", "Qaai"},
+	"Inscriptional_Pahlavi": {"Phli"}, "Inscriptional_Parthian": {"Prti"}, "Javanese": {"Java"},
+	"Kaithi": {"Kthi"}, "Kannada": {"Knda"}, "Katakana": {"Kana"}, "Kawi": {"Kawi"},
+	"Kayah_Li": {"Kali"}, "Kharoshthi": {"Khar"}, "Khitan_Small_Script": {"Kits"}, "Khmer": {"Khmr"},
+	"Khojki": {"Khoj"}, "Khudawadi": {"Sind"}, "Kirat_Rai": {"Krai"}, "Lao": {"Laoo"},
+	"Latin": {"Latn"}, "Lepcha": {"Lepc"}, "Limbu": {"Limb"}, "Linear_A": {"Lina"},
+	"Linear_B": {"Linb"}, "Lisu": {"Lisu"}, "Lycian": {"Lyci"}, "Lydian": {"Lydi"},
+	"Mahajani": {"Mahj"}, "Makasar": {"Maka"}, "Malayalam": {"Mlym"}, "Mandaic": {"Mand"},
+	"Manichaean": {"Mani"}, "Marchen": {"Marc"}, "Masaram_Gondi": {"Gonm"}, "Medefaidrin": {"Medf"},
+	"Meetei_Mayek": {"Mtei"}, "Mende_Kikakui": {"Mend"}, "Meroitic_Cursive": {"Merc"},
+	"Meroitic_Hieroglyphs": {"Mero"}, "Miao": {"Plrd"}, "Modi": {"Modi"}, "Mongolian": {"Mong"},
+	"Mro": {"Mroo"}, "Multani": {"Mult"}, "Myanmar": {"Mymr"}, "Nabataean": {"Nbat"},
+	"Nag_Mundari": {"Nagm"}, "Nandinagari": {"Nand"}, "New_Tai_Lue": {"Talu"}, "Newa": {"Newa"},
+	"Nko": {"Nkoo"}, "Nushu": {"Nshu"}, "Nyiakeng_Puachue_Hmong": {"Hmnp"}, "Ogham": {"Ogam"},
+	"Ol_Chiki": {"Olck"}, "Ol_Onal": {"Onao"}, "Old_Hungarian": {"Hung"}, "Old_Italic": {"Ital"},
+	"Old_North_Arabian": {"Narb"}, "Old_Permic": {"Perm"}, "Old_Persian": {"Xpeo"},
+	"Old_Sogdian": {"Sogo"}, "Old_South_Arabian": {"Sarb"}, "Old_Turkic": {"Orkh"},
+	"Old_Uyghur": {"Ougr"}, "Oriya": {"Orya"}, "Osage": {"Osge"}, "Osmanya": {"Osma"},
+	"Pahawh_Hmong": {"Hmng"}, "Palmyrene": {"Palm"}, "Pau_Cin_Hau": {"Pauc"}, "Phags_Pa": {"Phag"},
+	"Phoenician": {"Phnx"}, "Psalter_Pahlavi": {"Phlp"}, "Rejang": {"Rjng"}, "Runic": {"Runr"},
+	"Samaritan": {"Samr"}, "Saurashtra": {"Saur"}, "Sharada": {"Shrd"}, "Shavian": {"Shaw"},
+	"Siddham": {"Sidd"}, "Sidetic": {"Sidt"}, "SignWriting": {"Sgnw"}, "Sinhala": {"Sinh"},
+	"Sogdian": {"Sogd"}, "Sora_Sompeng": {"Sora"}, "Soyombo": {"Soyo"}, "Sundanese": {"Sund"},
+	"Sunuwar": {"Sunu"}, "Syloti_Nagri": {"Sylo"}, "Syriac": {"Syrc"}, "Tagalog": {"Tglg"},
+	"Tagbanwa": {"Tagb"}, "Tai_Le": {"Tale"}, "Tai_Tham": {"Lana"}, "Tai_Viet": {"Tavt"},
+	"Tai_Yo": {"Tayo"}, "Takri": {"Takr"}, "Tamil": {"Taml"}, "Tangsa": {"Tnsa"},
+	"Tangut": {"Tang"}, "Telugu": {"Telu"}, "Thaana": {"Thaa"}, "Thai": {"Thai"},
+	"Tibetan": {"Tibt"}, "Tifinagh": {"Tfng"}, "Tirhuta": {"Tirh"}, "Todhri": {"Todr"},
+	"Tolong_Siki": {"Tols"}, "Toto": {"Toto"}, "Tulu_Tigalari": {"Tutg"}, "Ugaritic": {"Ugar"},
+	"Unknown": {"Zzzz"}, "Vai": {"Vaii"}, "Vithkuqi": {"Vith"}, "Wancho": {"Wcho"},
+	"Warang_Citi": {"Wara"}, "Yezidi": {"Yezi"}, "Yi": {"Yiii"}, "Zanabazar_Square": {"Zanb"},
+}
+
+var (
+	generalCategories = aliasSet(generalCategoryValues)
+	binaryNames       = aliasSet(binaryProperties)
+	scripts           = scriptSet()
+)
+
+func aliasSet(aliases map[string][]string) map[string]bool {
+	set := make(map[string]bool)
+	for name, alts := range aliases {
+		set[name] = true
+		for _, alt := range alts {
+			set[alt] = true
+		}
+	}
+	return set
+}
+
+func scriptSet() map[string]bool {
+	set := aliasSet(scriptAliases)
+	for name := range unicode.Scripts {
+		set[name] = true
+	}
+	return set
+}
+
+// lookupProperty validates the UnicodePropertyValueExpression of a \p{...}
+// escape, which is either 'name=value' or a lone value. It returns the
+// canonical property name of the former and whether the escape matches
+// strings rather than code points.
+func lookupProperty(name, value string, unicodeSets bool) (canonical string, ofStrings bool, ok bool) {
+	if name != "" {
+		canonical, ok = nonBinaryPropertyNames[name]
+		if !ok {
+			return "", false, false
+		}
+		if canonical == "General_Category" {
+			return canonical, false, generalCategories[value]
+		}
+		return canonical, false, scripts[value]
+	}
+
+	if generalCategories[value] || binaryNames[value] {
+		return "", false, true
+	}
+	if unicodeSets && stringProperties[value] {
+		return "", true, true
+	}
+	return "", false, false
+}
+
+// property returns the characters of a \p{...} escape, which the escape
+// negates. It fails for the properties the unicode package has no data for,
+// which are not derived from those it has: Script_Extensions, the emoji
+// properties, Bidi_Mirrored, the case folding ones, XID_Start, XID_Continue
+// and the properties of strings.
+func property(n *UnicodePropertyEscape) (func(rune) bool, error) {
+	unsupported := fmt.Errorf("the Unicode property %s is not supported", n.Value)
+	switch {
+	case n.Strings:
+		return nil, unsupported
+	case n.Name == "General_Category" || n.Name == "" && generalCategories[n.Value]:
+		return generalCategory(n.Value), nil
+	case n.Name == "Script":
+		name := longName(scriptAliases, n.Value)
+		if name == "Unknown" {
+			return func(ch rune) bool { return !inScript(ch) }, nil
+		}
+		if table, ok := unicode.Scripts[name]; ok {
+			return func(ch rune) bool { return unicode.Is(table, ch) }, nil
+		}
+		return nil, unsupported
+	case n.Name != "":
+		return nil, unsupported
+	}
+
+	name := longName(binaryProperties, n.Value)
+	if table, ok := unicode.Properties[name]; ok {
+		return func(ch rune) bool { return unicode.Is(table, ch) }, nil
+	}
+	is := func(tables ...*unicode.RangeTable) func(rune) bool {
+		return func(ch rune) bool { return unicode.In(ch, tables...) }
+	}
+	lowercase := is(unicode.Ll, unicode.Other_Lowercase)
+	uppercase := is(unicode.Lu, unicode.Other_Uppercase)
+	patterns := is(unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+	idStart := func(ch rune) bool {
+		return unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start) && !patterns(ch)
+	}
+	graphemeExtend := is(unicode.Me, unicode.Mn, unicode.Other_Grapheme_Extend)
+	changesWhenLowercased := func(ch rune) bool { return unicode.ToLower(ch) != ch }
+	changesWhenUppercased := func(ch rune) bool { return unicode.ToUpper(ch) != ch || hasLongUpperCase(ch) }
+	changesWhenTitlecased := func(ch rune) bool { return unicode.ToTitle(ch) != ch || hasLongUpperCase(ch) }
+
+	// https://www.unicode.org/reports/tr44/#Derived_Property_Table
+	switch name {
+	case "Any":
+		return func(rune) bool { return true }, nil
+	case "ASCII":
+		return func(ch rune) bool { return ch < 0x80 }, nil
+	case "Assigned":
+		return assigned, nil
+	case "Alphabetic":
+		return func(ch rune) bool {
+			return lowercase(ch) || uppercase(ch) || unicode.In(ch, unicode.Lt, unicode.Lm, unicode.Lo, unicode.Nl, unicode.Other_Alphabetic)
+		}, nil
+	case "Lowercase":
+		return lowercase, nil
+	case "Uppercase":
+		return uppercase, nil
+	case "Cased":
+		return func(ch rune) bool { return lowercase(ch) || uppercase(ch) || unicode.Is(unicode.Lt, ch) }, nil
+	case "Case_Ignorable":
+		return func(ch rune) bool {
+			return unicode.In(ch, unicode.Mn, unicode.Me, unicode.Cf, unicode.Lm, unicode.Sk) || isMidLetter(ch)
+		}, nil
+	case "Math":
+		return is(unicode.Sm, unicode.Other_Math), nil
+	case "ID_Start":
+		return idStart, nil
+	case "ID_Continue":
+		return func(ch rune) bool {
+			return idStart(ch) || unicode.In(ch, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) && !patterns(ch)
+		}, nil
+	case "Default_Ignorable_Code_Point":
+		return func(ch rune) bool {
+			return unicode.In(ch, unicode.Other_Default_Ignorable_Code_Point, unicode.Cf, unicode.Variation_Selector) &&
+				!unicode.In(ch, unicode.White_Space, unicode.Prepended_Concatenation_Mark) &&
+				!(ch >= 0xfff9 && ch <= 0xfffb) && !(ch >= 0x13430 && ch <= 0x1343f)
+		}, nil
+	case "Grapheme_Extend":
+		return graphemeExtend, nil
+	case "Grapheme_Base":
+		return func(ch rune) bool {
+			return assigned(ch) && !unicode.In(ch, unicode.Cc, unicode.Cf, unicode.Cs, unicode.Co, unicode.Zl, unicode.Zp) && !graphemeExtend(ch)
+		}, nil
+	case "Changes_When_Lowercased":
+		return changesWhenLowercased, nil
+	case "Changes_When_Uppercased":
+		return changesWhenUppercased, nil
+	case "Changes_When_Titlecased":
+		return changesWhenTitlecased, nil
+	case "Changes_When_Casemapped":
+		return func(ch rune) bool {
+			return changesWhenLowercased(ch) || changesWhenUppercased(ch) || changesWhenTitlecased(ch)
+		}, nil
+	}
+	return nil, unsupported
+}
+
+// generalCategory returns the characters of a General_Category value.
+func generalCategory(value string) func(rune) bool {
+	short := generalCategoryValues[longName(generalCategoryValues, value)][0]
+	switch short {
+	case "Cn":
+		return func(ch rune) bool { return !assigned(ch) }
+	case "C":
+		return func(ch rune) bool {
+			return !assigned(ch) || unicode.In(ch, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs)
+		}
+	case "LC":
+		return func(ch rune) bool { return unicode.In(ch, unicode.Lu, unicode.Ll, unicode.Lt) }
+	}
+	table := unicode.Categories[short]
+	return func(ch rune) bool { return unicode.Is(table, ch) }
+}
+
+// longName returns the name of which value is the name or an alias.
+func longName(aliases map[string][]string, value string) string {
+	if _, ok := aliases[value]; ok {
+		return value
+	}
+	for name, alts := range aliases {
+		for _, alt := range alts {
+			if alt == value {
+				return name
+			}
+		}
+	}
+	return value
+}
+
+func assigned(ch rune) bool {
+	return unicode.In(ch, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs)
+}
+
+func inScript(ch rune) bool {
+	for _, table := range unicode.Scripts {
+		if unicode.Is(table, ch) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMidLetter reports whether ch has the Word_Break property MidLetter,
+// MidNumLet or Single_Quote, which are Case_Ignorable.
+func isMidLetter(ch rune) bool {
+	switch ch {
+	case 0x27, 0x2e, 0x3a, 0xb7, 0x387, 0x55f, 0x5f4, 0x2018, 0x2019, 0x2024, 0x2027,
+		0xfe13, 0xfe52, 0xfe55, 0xff07, 0xff0e, 0xff1a:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ruiconti/gojs/internal"
+	"github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/runtime"
+)
+
+// replOptions are the options the inputs of the REPL are parsed with, as
+// runtime.RunScript parses them.
+var replOptions = parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true}
+
+const replHelp = `.ast     print the syntax tree of the last input
+.break   discard the input being continued
+.exit    exit the REPL
+.help    print this help
+.sexp    print the S-expression of the last input
+.tokens  print the tokens of the last input
+`
+
+// runRepl implements gojs repl, which runs the lines read from the standard
+// input as scripts sharing one runtime, printing their completion values. A
+// line that leaves its input incomplete, with an unclosed brace or a trailing
+// operator, is continued by the next ones.
+func runRepl(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	interpret := flags.Bool("interpret", false, "walk the syntax tree of the inputs rather than compiling them to bytecode")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gojs repl [flags]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	rt := runtime.New()
+	if *interpret {
+		rt.SetEngine(runtime.EngineInterpreter)
+	}
+	// the input being continued, and the last one run
+	var pending, last string
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(nil, 1<<20)
+	for {
+		if pending == "" {
+			io.WriteString(stdout, "> ")
+		} else {
+			io.WriteString(stdout, "... ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if command := strings.TrimSpace(line); isReplCommand(command) {
+			switch command {
+			case ".ast", ".sexp", ".tokens":
+				if last == "" {
+					fmt.Fprintf(stderr, "no input yet\n")
+					continue
+				}
+				if err := printSyntax(stdout, command, last); err != nil {
+					fmt.Fprintf(stderr, "%v\n", sourceError("<repl>", err))
+				}
+			case ".break":
+				pending = ""
+			case ".exit":
+				return 0
+			case ".help":
+				io.WriteString(stdout, replHelp)
+			default:
+				fmt.Fprintf(stderr, "unknown command %s, see .help\n", command)
+			}
+			continue
+		}
+
+		if pending == "" && strings.TrimSpace(line) == "" {
+			continue
+		}
+		pending += line + "\n"
+		if _, err := parser.ParseFile(pending, replOptions); errors.Is(err, parser.ErrUnexpectedEOF) {
+			continue
+		}
+		last, pending = pending, ""
+		evaluate(rt, last, stdout, stderr)
+	}
+	if pending != "" {
+		// the input ends before the last one does
+		evaluate(rt, pending, stdout, stderr)
+	}
+	io.WriteString(stdout, "\n")
+	return 0
+}
+
+// isReplCommand reports whether line is a command of the REPL: a '.' followed
+// by a letter, unlike numbers such as .5.
+func isReplCommand(line string) bool {
+	return len(line) > 1 && line[0] == '.' && ('a' <= line[1] && line[1] <= 'z' || 'A' <= line[1] && line[1] <= 'Z')
+}
+
+// evaluate runs src with rt, and prints its completion value or the error it
+// fails with.
+func evaluate(rt *runtime.Runtime, src string, stdout, stderr io.Writer) {
+	v, err := rt.RunScript("<repl>", src)
+	var exception *runtime.Exception
+	switch {
+	case errors.As(err, &exception):
+		fmt.Fprintf(stderr, "%v\n", exception)
+	case err != nil:
+		fmt.Fprintf(stderr, "%v\n", sourceError("<repl>", err))
+	default:
+		fmt.Fprintf(stdout, "%s\n", rt.Inspect(v))
+	}
+}
+
+// printSyntax prints the tokens, the syntax tree or the S-expression of src,
+// as command tells. Tokens are positioned like the spans of the syntax tree
+// and syntax errors: at a 1-based line and a 0-based column.
+func printSyntax(w io.Writer, command, src string) error {
+	if command == ".tokens" {
+		logger := internal.NewSimpleLogger(internal.ModeError)
+		tokens, errs := lexer.NewLexerWithOptions(src, logger, lexer.Options{AnnexB: true}).ScanAll()
+		if len(errs) > 0 {
+			return errs[0]
+		}
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, token := range tokens {
+			fmt.Fprintf(tw, "%d:%d\t%s\t%s\n", token.Line, token.Column, token.Type.S(), token.Lexeme)
+		}
+		return tw.Flush()
+	}
+	file, err := parser.ParseFile(src, replOptions)
+	if err != nil {
+		return err
+	}
+	if command == ".sexp" {
+		_, err := fmt.Fprintf(w, "%s\n", file.Program.S())
+		return err
+	}
+	var b strings.Builder
+	printNode(&b, reflect.ValueOf(file.Program), "", nil)
+	b.WriteString("\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+var (
+	sType        = reflect.TypeOf((*interface{ S() string })(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// printNode prints v, a node of a syntax tree or one of its fields, as the
+// exported fields of its structs with non-zero values, indented by their
+// depth. Tokens, token types and positions print on a line. The ancestors of
+// v stop the cycles of the tree, if any.
+func printNode(b *strings.Builder, v reflect.Value, indent string, ancestors []uintptr) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			for _, ancestor := range ancestors {
+				if ancestor == v.Pointer() {
+					b.WriteString("(cycle)")
+					return
+				}
+			}
+			ancestors = append(ancestors, v.Pointer())
+		}
+		printNode(b, v.Elem(), indent, ancestors)
+	case reflect.Struct:
+		if s, ok := stringer(v, stringerType); ok {
+			// positions and tokens print on a line
+			b.WriteString(s.(fmt.Stringer).String())
+			return
+		}
+		t := v.Type()
+		b.WriteString(t.Name())
+		empty := true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || v.Field(i).IsZero() {
+				continue
+			}
+			if empty {
+				b.WriteString(" {")
+				empty = false
+			}
+			fmt.Fprintf(b, "\n%s  %s: ", indent, field.Name)
+			printNode(b, v.Field(i), indent+"  ", ancestors)
+		}
+		if !empty {
+			fmt.Fprintf(b, "\n%s}", indent)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(b, "\n%s  ", indent)
+			printNode(b, v.Index(i), indent+"  ", ancestors)
+		}
+		fmt.Fprintf(b, "\n%s]", indent)
+	case reflect.Map:
+		fmt.Fprintf(b, "%s (%d entries)", v.Type(), v.Len())
+	default:
+		if s, ok := stringer(v, sType); ok {
+			// token types print as their names
+			b.WriteString(s.(interface{ S() string }).S())
+			return
+		}
+		fmt.Fprintf(b, "%#v", v.Interface())
+	}
+}
+
+// stringer returns v, or a pointer to a copy of v, as a value implementing
+// the interface t, if either does.
+func stringer(v reflect.Value, t reflect.Type) (interface{}, bool) {
+	if v.Type().Implements(t) {
+		return v.Interface(), true
+	}
+	if reflect.PointerTo(v.Type()).Implements(t) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	run := func(stdin string, args ...string) (stdout, stderr string, code int) {
+		var out, errOut bytes.Buffer
+		code = runRepl(args, strings.NewReader(stdin), &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+
+	t.Run("results", func(t *testing.T) {
+		stdout, stderr, code := run("let x = 1\nx + 1\n'a' + x\n({ a: [x], b: 'c' })\n")
+		expected := "> undefined\n> 2\n> 'a1'\n> { a: [ 1 ], b: 'c' }\n> \n"
+		if code != 0 || stdout != expected || stderr != "" {
+			t.Errorf("expected %q, got %q, %q (exit %d)", expected, stdout, stderr, code)
+		}
+	})
+
+	t.Run("continuation", func(t *testing.T) {
+		stdout, _, _ := run("function f(a) {\nreturn a *\n2\n}\nf(\n21)\n")
+		expected := "> ... ... ... undefined\n> ... 42\n> \n"
+		if stdout != expected {
+			t.Errorf("expected %q, got %q", expected, stdout)
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		stdout, _, _ := run("[1,\n.break\n3\n")
+		expected := "> ... > 3\n> \n"
+		if stdout != expected {
+			t.Errorf("expected %q, got %q", expected, stdout)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		stdout, stderr, code := run("null.x\nlet = 1\n1\n")
+		if code != 0 || !strings.HasPrefix(stderr, "Uncaught TypeError: ") || !strings.Contains(stderr, "\n<repl>:") {
+			t.Errorf("expected the exception and the syntax error, got %q (exit %d)", stderr, code)
+		}
+		if !strings.HasSuffix(stdout, "> 1\n> \n") {
+			t.Errorf("expected the REPL to go on, got %q", stdout)
+		}
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		stdout, stderr, _ := run("a)\na b)\n'a\\\nb'\n1e400\n")
+		expected := "> > > ... 'ab'\n> Infinity\n> \n"
+		if stdout != expected || strings.Count(stderr, "<repl>:") != 2 {
+			t.Errorf("expected two syntax errors and no continuation, got %q, %q", stdout, stderr)
+		}
+	})
+
+	t.Run("syntax", func(t *testing.T) {
+		stdout, _, _ := run("a + 1\n.sexp\n.tokens\n.ast\n")
+		for _, expected := range []string{"(+ a 1)", "1:0  Identifier      a", "1:2  +               +", "ExprBinaryOp {", "Span: 1:0-1:1"} {
+			if !strings.Contains(stdout, expected) {
+				t.Errorf("expected %q in %q", expected, stdout)
+			}
+		}
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		stdout, stderr, _ := run(".5\n.5 + 1\n")
+		expected := "> 0.5\n> 1.5\n> \n"
+		if stdout != expected || stderr != "" {
+			t.Errorf("expected %q, got %q, %q", expected, stdout, stderr)
+		}
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		stdout, stderr, _ := run(".tokens\n.help\n.nope\n.exit\n1\n")
+		if !strings.Contains(stdout, ".break   discard the input being continued") || strings.Contains(stdout, "1\n") {
+			t.Errorf("expected the help and an exit, got %q", stdout)
+		}
+		if stderr != "no input yet\nunknown command .nope, see .help\n" {
+			t.Errorf("unexpected errors %q", stderr)
+		}
+	})
+}
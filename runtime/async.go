@@ -0,0 +1,67 @@
+package runtime
+
+// startAsync runs body, the code of a call to an async function named name,
+// until it first awaits, and returns the promise it settles once complete.
+// The body runs as the body of a generator does, on a goroutine of its own,
+// each await suspending it until the awaited promise settles.
+//
+// https://262.ecma-international.org/#sec-asyncblockstart
+func (r *Runtime) startAsync(c *context, name string, body func() Value) Value {
+	capability := r.newIntrinsicCapability()
+	g := &generator{name: name, s: c.script, body: body}
+	c.generator = g
+	// the object holding the generator, ending its goroutine once collected
+	o := r.newObject(nil)
+	o.internal = g
+
+	var step, resume func(kind resumeKind, value Value)
+	step = func(kind resumeKind, value Value) {
+		s := g.step(o, kind, value)
+		switch {
+		case s.exception != nil:
+			r.callFunction(capability.reject, Undefined, s.exception.Value)
+		case s.panic != nil:
+			panic(s.panic)
+		case s.done:
+			r.callFunction(capability.resolve, Undefined, s.value)
+		default:
+			// https://262.ecma-international.org/#await
+			p, exception := r.try(func() Value {
+				return r.promiseResolve(r.promiseConstructor, s.value)
+			})
+			if exception != nil {
+				step(resumeThrow, exception.Value)
+				return
+			}
+			onFulfilled := r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+				resume(resumeNext, arg(args, 0))
+				return Undefined
+			})
+			onRejected := r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+				resume(resumeThrow, arg(args, 0))
+				return Undefined
+			})
+			r.performPromiseThen(p.(*Object), onFulfilled, onRejected, nil)
+		}
+	}
+	// the steps after the first run from jobs, in a call frame of their own
+	resume = func(kind resumeKind, value Value) {
+		r.enter(name, c.script)
+		defer r.leave()
+		step(kind, value)
+	}
+	step(resumeNext, Undefined)
+	return capability.promise
+}
+
+// await suspends the body of an async function until v settles, and returns
+// its value or throws its reason.
+//
+// https://262.ecma-international.org/#await
+func (g *generator) await(r *Runtime, v Value) Value {
+	received := g.suspendWith(suspension{value: v})
+	if received.kind == resumeThrow {
+		panic(r.throw(received.value))
+	}
+	return received.value
+}
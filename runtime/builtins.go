@@ -0,0 +1,924 @@
+package runtime
+
+import (
+	"math"
+	"strings"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// initIntrinsics creates the intrinsic objects of the realm and the global
+// object holding them.
+//
+// https://262.ecma-international.org/#sec-createintrinsics
+func (r *Runtime) initIntrinsics() {
+	r.objectPrototype = &Object{runtime: r, class: "Object", extensible: true}
+	r.functionPrototype = r.newObject(r.objectPrototype)
+	r.functionPrototype.class = "Function"
+	r.functionPrototype.call = func(Value, []Value) Value { return Undefined }
+	r.functionPrototype.defineOwnProperty(stringKey("length"), dataDescriptor(Number(0), configurable))
+	r.functionPrototype.defineOwnProperty(stringKey("name"), dataDescriptor(String(""), configurable))
+	r.global = r.newObject(r.objectPrototype)
+	r.global.class = "global"
+
+	r.initErrors()
+	r.throwTypeError = r.newNativeFunction("", 0, func(Value, []Value) Value {
+		panic(r.newTypeError("'caller', 'callee', and 'arguments' properties may not be accessed on strict mode functions or the arguments objects for calls to them"))
+	})
+	r.throwTypeError.preventExtensions()
+
+	r.initObject()
+	r.initFunction()
+	r.initSymbol()
+	r.initIterators()
+	r.initArray()
+	r.initString()
+	r.initNumber()
+	r.initBoolean()
+	r.initMath()
+	r.initJSON()
+	r.initMap()
+	r.initSet()
+	r.initWeakCollections()
+	r.initGenerators()
+	r.initPromise()
+	r.initAsyncFunctions()
+	r.initRegExp()
+	r.initGlobal()
+}
+
+// setGlobal defines the global property name, as the built-in constructors
+// and functions are.
+func (r *Runtime) setGlobal(name string, v Value) {
+	r.global.setHidden(stringKey(name), v)
+}
+
+// setSpecies defines the @@species accessor of a built-in constructor,
+// which returns the constructor.
+//
+// https://262.ecma-international.org/#sec-get-array-@@species
+func (r *Runtime) setSpecies(ctor *Object) {
+	species := r.newNativeFunction("get [Symbol.species]", 0, func(this Value, args []Value) Value {
+		return this
+	})
+	ctor.setAccessor(symbolKey(SymbolSpecies), species, nil)
+}
+
+// setToStringTag defines the @@toStringTag property of a built-in prototype.
+func setToStringTag(o *Object, tag string) {
+	o.defineOwnProperty(symbolKey(SymbolToStringTag), dataDescriptor(String(tag), configurable))
+}
+
+// /////////
+// Object //
+// /////////
+
+func (r *Runtime) initObject() {
+	proto := r.objectPrototype
+	var ctor *Object
+	ctor = r.newNativeConstructor("Object", 1, func(this Value, args []Value) Value {
+		return ctor.construct(args, nil)
+	}, func(args []Value, newTarget *Object) *Object {
+		if newTarget != nil && newTarget != ctor {
+			return r.newObject(r.prototypeFromConstructor(newTarget, r.objectPrototype))
+		}
+		if v := arg(args, 0); !isNullish(v) {
+			return r.toObject(v)
+		}
+		return r.newObject(r.objectPrototype)
+	}, proto)
+	r.setGlobal("Object", ctor)
+
+	r.method(ctor, "assign", 2, func(this Value, args []Value) Value {
+		target := r.toObject(arg(args, 0))
+		for _, source := range args[1:] {
+			if isNullish(source) {
+				continue
+			}
+			from := r.toObject(source)
+			for _, key := range from.ownKeys() {
+				if p, ok := from.getOwnProperty(key); ok && p.flags&enumerable != 0 {
+					r.putV(target, key, from.get(key, from), true)
+				}
+			}
+		}
+		return target
+	})
+	r.method(ctor, "create", 2, func(this Value, args []Value) Value {
+		o := r.newObject(r.objectOrNull(arg(args, 0), "Object prototype may only be an Object or null: %s"))
+		if props := arg(args, 1); props != Undefined {
+			r.defineProperties(o, props)
+		}
+		return o
+	})
+	r.method(ctor, "defineProperty", 3, func(this Value, args []Value) Value {
+		o, ok := arg(args, 0).(*Object)
+		if !ok {
+			panic(r.newTypeError("Object.defineProperty called on non-object"))
+		}
+		key := r.toPropertyKey(arg(args, 1))
+		r.definePropertyOrThrow(o, key, r.toPropertyDescriptor(arg(args, 2)))
+		return o
+	})
+	r.method(ctor, "defineProperties", 2, func(this Value, args []Value) Value {
+		o, ok := arg(args, 0).(*Object)
+		if !ok {
+			panic(r.newTypeError("Object.defineProperties called on non-object"))
+		}
+		r.defineProperties(o, arg(args, 1))
+		return o
+	})
+	for _, kind := range []string{"keys", "values", "entries"} {
+		kind := kind
+		r.method(ctor, kind, 1, func(this Value, args []Value) Value {
+			return r.newArray(r.enumerableOwnProperties(r.toObject(arg(args, 0)), kind)...)
+		})
+	}
+	r.method(ctor, "fromEntries", 1, func(this Value, args []Value) Value {
+		iterable := arg(args, 0)
+		if isNullish(iterable) {
+			panic(r.newTypeError("%s is not iterable", iterable))
+		}
+		o := r.newObject(r.objectPrototype)
+		r.iterate(iterable, func(entry Value) bool {
+			e, ok := entry.(*Object)
+			if !ok {
+				panic(r.newTypeError("Iterator value %s is not an entry object", r.describe(entry)))
+			}
+			key := r.toPropertyKey(e.get(stringKey("0"), e))
+			r.createDataProperty(o, key, e.get(stringKey("1"), e))
+			return true
+		})
+		return o
+	})
+	for _, level := range []struct {
+		name, test string
+		frozen     bool
+	}{{"seal", "isSealed", false}, {"freeze", "isFrozen", true}} {
+		level := level
+		r.method(ctor, level.name, 1, func(this Value, args []Value) Value {
+			if o, ok := arg(args, 0).(*Object); ok && !r.setIntegrityLevel(o, level.frozen) {
+				panic(r.newTypeError("Cannot %s %s", level.name, r.describe(o)))
+			}
+			return arg(args, 0)
+		})
+		r.method(ctor, level.test, 1, func(this Value, args []Value) Value {
+			o, ok := arg(args, 0).(*Object)
+			return Bool(!ok || testIntegrityLevel(o, level.frozen))
+		})
+	}
+	r.method(ctor, "preventExtensions", 1, func(this Value, args []Value) Value {
+		if o, ok := arg(args, 0).(*Object); ok {
+			o.preventExtensions()
+		}
+		return arg(args, 0)
+	})
+	r.method(ctor, "isExtensible", 1, func(this Value, args []Value) Value {
+		o, ok := arg(args, 0).(*Object)
+		return Bool(ok && o.extensible)
+	})
+	r.method(ctor, "getOwnPropertyDescriptor", 2, func(this Value, args []Value) Value {
+		o := r.toObject(arg(args, 0))
+		p, ok := o.getOwnProperty(r.toPropertyKey(arg(args, 1)))
+		if !ok {
+			return Undefined
+		}
+		return r.fromProperty(p)
+	})
+	r.method(ctor, "getOwnPropertyDescriptors", 1, func(this Value, args []Value) Value {
+		o := r.toObject(arg(args, 0))
+		descriptors := r.newObject(r.objectPrototype)
+		for _, key := range o.ownKeys() {
+			if p, ok := o.getOwnProperty(key); ok {
+				r.createDataProperty(descriptors, key, r.fromProperty(p))
+			}
+		}
+		return descriptors
+	})
+	r.method(ctor, "getOwnPropertyNames", 1, func(this Value, args []Value) Value {
+		return r.newArray(ownKeyValues(r.toObject(arg(args, 0)), false)...)
+	})
+	r.method(ctor, "getOwnPropertySymbols", 1, func(this Value, args []Value) Value {
+		return r.newArray(ownKeyValues(r.toObject(arg(args, 0)), true)...)
+	})
+	r.method(ctor, "getPrototypeOf", 1, func(this Value, args []Value) Value {
+		return objectOrNull(r.toObject(arg(args, 0)).proto)
+	})
+	r.method(ctor, "setPrototypeOf", 2, func(this Value, args []Value) Value {
+		v := arg(args, 0)
+		if isNullish(v) {
+			panic(r.newTypeError("Object.setPrototypeOf called on null or undefined"))
+		}
+		proto := r.objectOrNull(arg(args, 1), "Object prototype may only be an Object or null: %s")
+		if o, ok := v.(*Object); ok && !o.setPrototypeOf(proto) {
+			panic(r.newTypeError("Cannot set the prototype of %s", r.describe(o)))
+		}
+		return v
+	})
+	r.method(ctor, "is", 2, func(this Value, args []Value) Value {
+		return Bool(sameValue(arg(args, 0), arg(args, 1)))
+	})
+	r.method(ctor, "hasOwn", 2, func(this Value, args []Value) Value {
+		o := r.toObject(arg(args, 0))
+		return Bool(o.hasOwnProperty(r.toPropertyKey(arg(args, 1))))
+	})
+
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		return String(r.objectToString(this))
+	})
+	r.method(proto, "toLocaleString", 0, func(this Value, args []Value) Value {
+		return r.callFunction(r.getV(this, stringKey("toString")), this)
+	})
+	r.method(proto, "valueOf", 0, func(this Value, args []Value) Value {
+		return r.toObject(this)
+	})
+	r.method(proto, "hasOwnProperty", 1, func(this Value, args []Value) Value {
+		key := r.toPropertyKey(arg(args, 0))
+		return Bool(r.toObject(this).hasOwnProperty(key))
+	})
+	r.method(proto, "isPrototypeOf", 1, func(this Value, args []Value) Value {
+		v, ok := arg(args, 0).(*Object)
+		if !ok {
+			return Bool(false)
+		}
+		o := r.toObject(this)
+		for p := v.proto; p != nil; p = p.proto {
+			if p == o {
+				return Bool(true)
+			}
+		}
+		return Bool(false)
+	})
+	r.method(proto, "propertyIsEnumerable", 1, func(this Value, args []Value) Value {
+		key := r.toPropertyKey(arg(args, 0))
+		p, ok := r.toObject(this).getOwnProperty(key)
+		return Bool(ok && p.flags&enumerable != 0)
+	})
+
+	// https://262.ecma-international.org/#sec-object.prototype.__proto__
+	getProto := r.newNativeFunction("get __proto__", 0, func(this Value, args []Value) Value {
+		return objectOrNull(r.toObject(this).proto)
+	})
+	setProto := r.newNativeFunction("set __proto__", 1, func(this Value, args []Value) Value {
+		if isNullish(this) {
+			panic(r.newTypeError("Object.prototype.__proto__ called on null or undefined"))
+		}
+		proto, ok := arg(args, 0).(*Object)
+		if !ok && arg(args, 0) != Null {
+			return Undefined
+		}
+		if o, ok := this.(*Object); ok && !o.setPrototypeOf(proto) {
+			panic(r.newTypeError("Cannot set the prototype of %s", r.describe(o)))
+		}
+		return Undefined
+	})
+	proto.setAccessor(stringKey("__proto__"), getProto, setProto)
+}
+
+// objectToString returns the "[object Tag]" description of v.
+//
+// https://262.ecma-international.org/#sec-object.prototype.tostring
+func (r *Runtime) objectToString(v Value) string {
+	switch v {
+	case Undefined:
+		return "[object Undefined]"
+	case Null:
+		return "[object Null]"
+	}
+	o := r.toObject(v)
+	tag := "Object"
+	switch {
+	case o.array:
+		tag = "Array"
+	case o.call != nil:
+		tag = "Function"
+	default:
+		switch o.class {
+		case "Arguments", "Error", "Boolean", "Number", "String", "Date", "RegExp":
+			tag = o.class
+		}
+	}
+	if t, ok := o.get(symbolKey(SymbolToStringTag), o).(String); ok {
+		tag = string(t)
+	}
+	return "[object " + tag + "]"
+}
+
+// objectOrNull returns the prototype v, failing with a TypeError formatted
+// with format unless it is an object or null.
+func (r *Runtime) objectOrNull(v Value, format string) *Object {
+	if v == Null {
+		return nil
+	}
+	o, ok := v.(*Object)
+	if !ok {
+		panic(r.newTypeError(format, r.describe(v)))
+	}
+	return o
+}
+
+// objectOrNull returns o as a value, null when nil.
+func objectOrNull(o *Object) Value {
+	if o == nil {
+		return Null
+	}
+	return o
+}
+
+// toPropertyDescriptor converts the attributes object v to a descriptor.
+//
+// https://262.ecma-international.org/#sec-topropertydescriptor
+func (r *Runtime) toPropertyDescriptor(v Value) descriptor {
+	o, ok := v.(*Object)
+	if !ok {
+		panic(r.newTypeError("Property description must be an object: %s", r.describe(v)))
+	}
+	var desc descriptor
+	for _, field := range []struct {
+		name string
+		has  descriptorFields
+		flag propertyFlags
+	}{{"enumerable", hasEnumerable, enumerable}, {"configurable", hasConfigurable, configurable}, {"writable", hasWritable, writable}} {
+		key := stringKey(field.name)
+		if o.hasProperty(key) {
+			desc.has |= field.has
+			if toBoolean(o.get(key, o)) {
+				desc.flags |= field.flag
+			}
+		}
+	}
+	if o.hasProperty(stringKey("value")) {
+		desc.has |= hasValue
+		desc.value = o.get(stringKey("value"), o)
+	}
+	for _, accessor := range []struct {
+		name, kind string
+		has        descriptorFields
+		fn         *Value
+	}{{"get", "Getter", hasGet, &desc.getter}, {"set", "Setter", hasSet, &desc.setter}} {
+		key := stringKey(accessor.name)
+		if !o.hasProperty(key) {
+			continue
+		}
+		fn := o.get(key, o)
+		if fn != Undefined && !isCallable(fn) {
+			panic(r.newTypeError("%s must be a function: %s", accessor.kind, r.describe(fn)))
+		}
+		desc.has |= accessor.has
+		*accessor.fn = fn
+	}
+	if desc.isAccessor() && desc.isData() {
+		panic(r.newTypeError("Invalid property descriptor. Cannot both specify accessors and a value or writable attribute"))
+	}
+	if desc.has&hasValue != 0 && desc.value == nil {
+		desc.value = Undefined
+	}
+	return desc
+}
+
+// fromProperty returns the attributes object describing the property p.
+//
+// https://262.ecma-international.org/#sec-frompropertydescriptor
+func (r *Runtime) fromProperty(p property) *Object {
+	o := r.newObject(r.objectPrototype)
+	if p.isAccessor() {
+		o.setProperty("get", objectOrUndefined(p.getter))
+		o.setProperty("set", objectOrUndefined(p.setter))
+	} else {
+		o.setProperty("value", p.value)
+		o.setProperty("writable", Bool(p.flags&writable != 0))
+	}
+	o.setProperty("enumerable", Bool(p.flags&enumerable != 0))
+	o.setProperty("configurable", Bool(p.flags&configurable != 0))
+	return o
+}
+
+// defineProperties defines the properties described by the enumerable own
+// properties of props on o, once they are all converted to descriptors.
+//
+// https://262.ecma-international.org/#sec-objectdefineproperties
+func (r *Runtime) defineProperties(o *Object, props Value) {
+	from := r.toObject(props)
+	var keys []propertyKey
+	var descriptors []descriptor
+	for _, key := range from.ownKeys() {
+		if p, ok := from.getOwnProperty(key); ok && p.flags&enumerable != 0 {
+			keys = append(keys, key)
+			descriptors = append(descriptors, r.toPropertyDescriptor(from.get(key, from)))
+		}
+	}
+	for i, key := range keys {
+		r.definePropertyOrThrow(o, key, descriptors[i])
+	}
+}
+
+// enumerableOwnProperties returns the keys, values or entries of the
+// enumerable own properties of o with string keys, as kind tells.
+//
+// https://262.ecma-international.org/#sec-enumerableownproperties
+func (r *Runtime) enumerableOwnProperties(o *Object, kind string) []Value {
+	var values []Value
+	for _, key := range o.ownKeys() {
+		if key.symbol != nil {
+			continue
+		}
+		if p, ok := o.getOwnProperty(key); !ok || p.flags&enumerable == 0 {
+			continue
+		}
+		switch kind {
+		case "keys":
+			values = append(values, String(key.name))
+		case "values":
+			values = append(values, o.get(key, o))
+		default:
+			values = append(values, r.newArray(String(key.name), o.get(key, o)))
+		}
+	}
+	return values
+}
+
+// ownKeyValues returns the keys of the own properties of o, its symbols or
+// its strings.
+func ownKeyValues(o *Object, symbols bool) []Value {
+	var keys []Value
+	for _, key := range o.ownKeys() {
+		if (key.symbol != nil) == symbols {
+			keys = append(keys, key.value())
+		}
+	}
+	return keys
+}
+
+// setIntegrityLevel makes o non-extensible and its properties
+// non-configurable, and the data ones read-only when frozen.
+//
+// https://262.ecma-international.org/#sec-setintegritylevel
+func (r *Runtime) setIntegrityLevel(o *Object, frozen bool) bool {
+	o.preventExtensions()
+	for _, key := range o.ownKeys() {
+		desc := descriptor{has: hasConfigurable}
+		if p, ok := o.getOwnProperty(key); ok && frozen && !p.isAccessor() {
+			desc.has |= hasWritable
+		}
+		if !o.defineOwnProperty(key, desc) {
+			return false
+		}
+	}
+	return true
+}
+
+// testIntegrityLevel reports whether o is sealed, or frozen.
+//
+// https://262.ecma-international.org/#sec-testintegritylevel
+func testIntegrityLevel(o *Object, frozen bool) bool {
+	if o.extensible {
+		return false
+	}
+	for _, key := range o.ownKeys() {
+		p, ok := o.getOwnProperty(key)
+		if !ok {
+			continue
+		}
+		if p.flags&configurable != 0 || frozen && !p.isAccessor() && p.flags&writable != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ///////////
+// Function //
+// ///////////
+
+// boundFunction is the internal state of a function created by
+// Function.prototype.bind.
+//
+// https://262.ecma-international.org/#sec-bound-function-exotic-objects
+type boundFunction struct {
+	target *Object
+	this   Value
+	args   []Value
+}
+
+func (r *Runtime) initFunction() {
+	proto := r.functionPrototype
+	construct := func(args []Value, newTarget *Object) *Object {
+		return r.createDynamicFunction(args)
+	}
+	ctor := r.newNativeConstructor("Function", 1, func(this Value, args []Value) Value {
+		return construct(args, nil)
+	}, construct, proto)
+	r.setGlobal("Function", ctor)
+
+	r.method(proto, "call", 1, func(this Value, args []Value) Value {
+		var rest []Value
+		if len(args) > 1 {
+			rest = args[1:]
+		}
+		return r.callFunction(this, arg(args, 0), rest...)
+	})
+	r.method(proto, "apply", 2, func(this Value, args []Value) Value {
+		if !isCallable(this) {
+			panic(r.newTypeError("%s is not a function", r.describe(this)))
+		}
+		var list []Value
+		if v := arg(args, 1); !isNullish(v) {
+			list = r.createListFromArrayLike(v)
+		}
+		return r.callFunction(this, arg(args, 0), list...)
+	})
+	r.method(proto, "bind", 1, func(this Value, args []Value) Value {
+		target, ok := this.(*Object)
+		if !ok || target.call == nil {
+			panic(r.newTypeError("Bind must be called on a function"))
+		}
+		var boundArgs []Value
+		if len(args) > 1 {
+			boundArgs = append(boundArgs, args[1:]...)
+		}
+		return r.bind(target, arg(args, 0), boundArgs)
+	})
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		fn, ok := this.(*Object)
+		if !ok || fn.call == nil {
+			panic(r.newTypeError("Function.prototype.toString requires that 'this' be a Function"))
+		}
+		if cl, ok := fn.internal.(*closure); ok {
+			if src, ok := cl.sourceText(); ok {
+				return String(src)
+			}
+		}
+		return String("function " + functionName(fn) + "() { [native code] }")
+	})
+	hasInstance := r.newNativeFunction("[Symbol.hasInstance]", 1, func(this Value, args []Value) Value {
+		fn, ok := this.(*Object)
+		return Bool(ok && r.ordinaryHasInstance(fn, arg(args, 0)))
+	})
+	proto.setConstant(symbolKey(SymbolHasInstance), hasInstance)
+}
+
+// bind returns a function calling target with this and args, followed by
+// its own arguments.
+//
+// https://262.ecma-international.org/#sec-function.prototype.bind
+func (r *Runtime) bind(target *Object, this Value, args []Value) *Object {
+	fn := r.newFunctionObject(target.proto, "", 0)
+	fn.internal = &boundFunction{target: target, this: this, args: args}
+	fn.call = func(_ Value, rest []Value) Value {
+		return target.call(this, append(args[:len(args):len(args)], rest...))
+	}
+	if target.construct != nil {
+		fn.construct = func(rest []Value, newTarget *Object) *Object {
+			if newTarget == nil || newTarget == fn {
+				newTarget = target
+			}
+			return r.constructObject(target, append(args[:len(args):len(args)], rest...), newTarget)
+		}
+	}
+
+	length := 0.0
+	if target.hasOwnProperty(stringKey("length")) {
+		if n, ok := target.get(stringKey("length"), target).(Number); ok {
+			switch {
+			case math.IsInf(float64(n), 1):
+				length = float64(n)
+			case !math.IsInf(float64(n), -1):
+				length = math.Max(0, r.toIntegerOrInfinity(n)-float64(len(args)))
+			}
+		}
+	}
+	fn.defineOwnProperty(stringKey("length"), dataDescriptor(Number(length), configurable))
+	name, _ := target.get(stringKey("name"), target).(String)
+	setFunctionName(fn, stringKey(string(name)), "bound")
+	return fn
+}
+
+// createListFromArrayLike returns the elements of the array-like object v.
+//
+// https://262.ecma-international.org/#sec-createlistfromarraylike
+func (r *Runtime) createListFromArrayLike(v Value) []Value {
+	o, ok := v.(*Object)
+	if !ok {
+		panic(r.newTypeError("CreateListFromArrayLike called on non-object"))
+	}
+	n := r.lengthOfArrayLike(o)
+	values := make([]Value, n)
+	for i := range values {
+		values[i] = o.get(indexKey(uint32(i)), o)
+	}
+	return values
+}
+
+// createDynamicFunction returns the function created by the Function
+// constructor, whose last argument is its body and the others its
+// parameters. It is sloppy mode code in the global scope.
+//
+// https://262.ecma-international.org/#sec-createdynamicfunction
+func (r *Runtime) createDynamicFunction(args []Value) *Object {
+	params := make([]string, 0, len(args))
+	body := ""
+	for i, v := range args {
+		if i == len(args)-1 {
+			body = r.toString(v)
+		} else {
+			params = append(params, r.toString(v))
+		}
+	}
+	src := "(function (" + strings.Join(params, ",") + "\n) {\n" + body + "\n})"
+	file, err := parse(src, parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true})
+	if err != nil {
+		panic(r.syntaxError(err))
+	}
+	// the parameters and body must each parse on their own, rather than
+	// close the function early
+	var fn *parser.ExprFunction
+	if len(file.Program.Children) == 1 {
+		if stmt, ok := file.Program.Children[0].(*parser.ExpressionStatement); ok {
+			fn, _ = parser.Unparenthesized(stmt.Expression).(*parser.ExprFunction)
+		}
+	}
+	if fn == nil {
+		panic(r.newSyntaxError("Invalid function body or parameters"))
+	}
+	c := r.newContext(newScript("<anonymous>", file), false)
+	return c.newFunction(fn, r.globalEnv, stringKey("anonymous"), "", nil)
+}
+
+// ////////
+// Error //
+// ////////
+
+func (r *Runtime) initErrors() {
+	r.errorPrototypes = make(map[string]*Object)
+	proto := r.newObject(r.objectPrototype)
+	ctor := r.newErrorConstructor("Error", proto, r.functionPrototype)
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		o, ok := this.(*Object)
+		if !ok {
+			panic(r.newTypeError("Error.prototype.toString called on %s", r.describe(this)))
+		}
+		name, message := "Error", ""
+		if v := o.get(stringKey("name"), o); v != Undefined {
+			name = r.toString(v)
+		}
+		if v := o.get(stringKey("message"), o); v != Undefined {
+			message = r.toString(v)
+		}
+		switch {
+		case name == "":
+			return String(message)
+		case message == "":
+			return String(name)
+		}
+		return String(name + ": " + message)
+	})
+
+	for _, name := range []string{"EvalError", "RangeError", "ReferenceError", "SyntaxError", "TypeError", "URIError"} {
+		r.newErrorConstructor(name, r.newObject(proto), ctor)
+	}
+
+	// https://262.ecma-international.org/#sec-aggregate-error-objects
+	aggregate := r.newObject(proto)
+	r.errorPrototypes["AggregateError"] = aggregate
+	aggregate.setHidden(stringKey("name"), String("AggregateError"))
+	aggregate.setHidden(stringKey("message"), String(""))
+	construct := func(args []Value, newTarget *Object) *Object {
+		message := ""
+		if v := arg(args, 1); v != Undefined {
+			message = r.toString(v)
+		}
+		o := r.newErrorObject(r.prototypeFromConstructor(newTarget, aggregate), message)
+		if options, ok := arg(args, 2).(*Object); ok && options.hasProperty(stringKey("cause")) {
+			o.setHidden(stringKey("cause"), options.get(stringKey("cause"), options))
+		}
+		var errors []Value
+		r.iterate(arg(args, 0), func(v Value) bool {
+			errors = append(errors, v)
+			return true
+		})
+		o.setHidden(stringKey("errors"), r.newArray(errors...))
+		return o
+	}
+	aggregateCtor := r.newNativeConstructor("AggregateError", 2, func(this Value, args []Value) Value {
+		return construct(args, nil)
+	}, construct, aggregate)
+	aggregateCtor.proto = ctor
+	r.setGlobal("AggregateError", aggregateCtor)
+}
+
+// newErrorConstructor defines the error constructor with name on the global
+// object, creating errors inheriting from proto.
+//
+// https://262.ecma-international.org/#sec-nativeerror-constructors
+func (r *Runtime) newErrorConstructor(name string, proto, ctorProto *Object) *Object {
+	r.errorPrototypes[name] = proto
+	proto.setHidden(stringKey("name"), String(name))
+	proto.setHidden(stringKey("message"), String(""))
+
+	construct := func(args []Value, newTarget *Object) *Object {
+		message := ""
+		if v := arg(args, 0); v != Undefined {
+			message = r.toString(v)
+		}
+		o := r.newErrorObject(r.prototypeFromConstructor(newTarget, proto), message)
+		if options, ok := arg(args, 1).(*Object); ok && options.hasProperty(stringKey("cause")) {
+			o.setHidden(stringKey("cause"), options.get(stringKey("cause"), options))
+		}
+		return o
+	}
+	ctor := r.newNativeConstructor(name, 1, func(this Value, args []Value) Value {
+		return construct(args, nil)
+	}, construct, proto)
+	ctor.proto = ctorProto
+	r.setGlobal(name, ctor)
+	return ctor
+}
+
+// /////////
+// Symbol //
+// /////////
+
+func (r *Runtime) initSymbol() {
+	proto := r.newObject(r.objectPrototype)
+	r.symbolPrototype = proto
+	ctor := r.newNativeConstructor("Symbol", 0, func(this Value, args []Value) Value {
+		description := arg(args, 0)
+		if description == Undefined {
+			return &Symbol{Description: Undefined}
+		}
+		return &Symbol{Description: String(r.toString(description))}
+	}, func(args []Value, newTarget *Object) *Object {
+		panic(r.newTypeError("Symbol is not a constructor"))
+	}, proto)
+	r.setGlobal("Symbol", ctor)
+	r.method(ctor, "for", 1, func(this Value, args []Value) Value {
+		key := r.toString(arg(args, 0))
+		s, ok := r.symbols[key]
+		if !ok {
+			s = newSymbol(key)
+			r.symbols[key] = s
+		}
+		return s
+	})
+	r.method(ctor, "keyFor", 1, func(this Value, args []Value) Value {
+		s, ok := arg(args, 0).(*Symbol)
+		if !ok {
+			panic(r.newTypeError("%s is not a symbol", r.describe(arg(args, 0))))
+		}
+		if description, ok := s.Description.(String); ok && r.symbols[string(description)] == s {
+			return description
+		}
+		return Undefined
+	})
+	for _, symbol := range []*Symbol{
+		SymbolAsyncIterator, SymbolHasInstance, SymbolIsConcatSpreadable, SymbolIterator, SymbolMatch,
+		SymbolMatchAll, SymbolReplace, SymbolSearch, SymbolSpecies, SymbolSplit, SymbolToPrimitive,
+		SymbolToStringTag, SymbolUnscopables,
+	} {
+		name := strings.TrimPrefix(string(symbol.Description.(String)), "Symbol.")
+		ctor.setConstant(stringKey(name), symbol)
+	}
+
+	thisSymbolValue := func(v Value) *Symbol {
+		switch v := v.(type) {
+		case *Symbol:
+			return v
+		case *Object:
+			if s, ok := v.internal.(*Symbol); ok {
+				return s
+			}
+		}
+		panic(r.newTypeError("%s is not a symbol", r.describe(v)))
+	}
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		return String(thisSymbolValue(this).String())
+	})
+	r.method(proto, "valueOf", 0, func(this Value, args []Value) Value {
+		return thisSymbolValue(this)
+	})
+	description := r.newNativeFunction("get description", 0, func(this Value, args []Value) Value {
+		return thisSymbolValue(this).Description
+	})
+	proto.setAccessor(stringKey("description"), description, nil)
+	toPrimitive := r.newNativeFunction("[Symbol.toPrimitive]", 1, func(this Value, args []Value) Value {
+		return thisSymbolValue(this)
+	})
+	proto.defineOwnProperty(symbolKey(SymbolToPrimitive), dataDescriptor(toPrimitive, configurable))
+	setToStringTag(proto, "Symbol")
+}
+
+// ////////////
+// Iterators //
+// ////////////
+
+// nativeIterator is the state of the iterators of built-in objects, which
+// compute their next value with a Go function.
+type nativeIterator struct {
+	next func() (Value, bool)
+	done bool
+}
+
+func (r *Runtime) initIterators() {
+	r.iteratorPrototype = r.newObject(r.objectPrototype)
+	iterator := r.newNativeFunction("[Symbol.iterator]", 0, func(this Value, args []Value) Value {
+		return this
+	})
+	r.iteratorPrototype.setHidden(symbolKey(SymbolIterator), iterator)
+
+	r.arrayIteratorPrototype = r.newNativeIteratorPrototype("Array Iterator")
+	r.stringIteratorPrototype = r.newNativeIteratorPrototype("String Iterator")
+}
+
+// newNativeIteratorPrototype returns the prototype of the native iterators
+// of class, whose next method runs their Go function.
+func (r *Runtime) newNativeIteratorPrototype(class string) *Object {
+	proto := r.newObject(r.iteratorPrototype)
+	r.method(proto, "next", 0, func(this Value, args []Value) Value {
+		o, ok := this.(*Object)
+		if !ok || o.class != class {
+			panic(r.newTypeError("next method called on incompatible receiver %s", r.describe(this)))
+		}
+		it := o.internal.(*nativeIterator)
+		if !it.done {
+			if v, ok := it.next(); ok {
+				return r.iterResult(v, false)
+			}
+			it.done = true
+		}
+		return r.iterResult(Undefined, true)
+	})
+	setToStringTag(proto, class)
+	return proto
+}
+
+// newNativeIterator returns an iterator of class inheriting from proto,
+// whose values are those next returns until it reports false.
+func (r *Runtime) newNativeIterator(proto *Object, class string, next func() (Value, bool)) *Object {
+	it := r.newObject(proto)
+	it.class = class
+	it.internal = &nativeIterator{next: next}
+	return it
+}
+
+// newArrayIterator returns an iterator over the keys, values or entries of
+// the array-like object o, as kind tells.
+//
+// https://262.ecma-international.org/#sec-createarrayiterator
+func (r *Runtime) newArrayIterator(o *Object, kind string) *Object {
+	var index int64
+	return r.newNativeIterator(r.arrayIteratorPrototype, "Array Iterator", func() (Value, bool) {
+		if index >= r.lengthOfArrayLike(o) {
+			return nil, false
+		}
+		i := index
+		index++
+		switch kind {
+		case "keys":
+			return Number(i), true
+		case "entries":
+			return r.newArray(Number(i), o.get(indexKey(uint32(i)), o)), true
+		}
+		return o.get(indexKey(uint32(i)), o), true
+	})
+}
+
+// /////////////
+// Generators //
+// /////////////
+
+func (r *Runtime) initGenerators() {
+	r.generatorFunction = r.newObject(r.functionPrototype)
+	r.generatorPrototype = r.newObject(r.iteratorPrototype)
+	r.generatorFunction.defineOwnProperty(stringKey("prototype"), dataDescriptor(r.generatorPrototype, configurable))
+	r.generatorPrototype.defineOwnProperty(stringKey("constructor"), dataDescriptor(r.generatorFunction, configurable))
+	setToStringTag(r.generatorFunction, "GeneratorFunction")
+	setToStringTag(r.generatorPrototype, "Generator")
+
+	for _, kind := range []resumeKind{resumeNext, resumeReturn, resumeThrow} {
+		kind := kind
+		r.method(r.generatorPrototype, kind.String(), 1, func(this Value, args []Value) Value {
+			return r.resumeGenerator(this, kind, arg(args, 0))
+		})
+	}
+}
+
+// https://262.ecma-international.org/#sec-properties-of-asyncfunction-prototype
+func (r *Runtime) initAsyncFunctions() {
+	r.asyncFunction = r.newObject(r.functionPrototype)
+	setToStringTag(r.asyncFunction, "AsyncFunction")
+}
+
+// /////////
+// Global //
+// /////////
+
+func (r *Runtime) initGlobal() {
+	r.setGlobal("globalThis", r.global)
+	r.global.setConstant(stringKey("NaN"), Number(math.NaN()))
+	r.global.setConstant(stringKey("Infinity"), Number(math.Inf(1)))
+	r.global.setConstant(stringKey("undefined"), Undefined)
+	r.eval = r.newNativeFunction("eval", 1, func(this Value, args []Value) Value {
+		return r.indirectEval(arg(args, 0))
+	})
+	r.setGlobal("eval", r.eval)
+}
@@ -0,0 +1,857 @@
+package runtime
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ////////
+// Array //
+// ////////
+
+func (r *Runtime) initArray() {
+	proto := r.newArray()
+	proto.proto = r.objectPrototype
+	r.arrayPrototype = proto
+
+	construct := func(args []Value, newTarget *Object) *Object {
+		a := r.newArray()
+		a.proto = r.prototypeFromConstructor(newTarget, proto)
+		if len(args) != 1 {
+			a.elements = append([]Value(nil), args...)
+			a.length = uint32(len(args))
+			return a
+		}
+		if n, ok := args[0].(Number); ok {
+			if float64(toUint32(float64(n))) != float64(n) {
+				panic(r.newRangeError("Invalid array length"))
+			}
+			a.length = uint32(n)
+			return a
+		}
+		a.elements, a.length = []Value{args[0]}, 1
+		return a
+	}
+	ctor := r.newNativeConstructor("Array", 1, func(this Value, args []Value) Value {
+		return construct(args, nil)
+	}, construct, proto)
+	r.setGlobal("Array", ctor)
+	r.setSpecies(ctor)
+
+	r.method(ctor, "isArray", 1, func(this Value, args []Value) Value {
+		o, ok := arg(args, 0).(*Object)
+		return Bool(ok && o.array)
+	})
+	r.method(ctor, "of", 0, func(this Value, args []Value) Value {
+		a := r.newArrayFrom(this, Number(len(args)))
+		for i, v := range args {
+			r.createDataProperty(a, indexKey(uint32(i)), v)
+		}
+		r.putV(a, stringKey("length"), Number(len(args)), true)
+		return a
+	})
+	r.method(ctor, "from", 1, func(this Value, args []Value) Value {
+		items, mapFn, thisArg := arg(args, 0), arg(args, 1), arg(args, 2)
+		if mapFn != Undefined && !isCallable(mapFn) {
+			panic(r.newTypeError("%s is not a function", r.describe(mapFn)))
+		}
+		mapped := func(v Value, i int64) Value {
+			if mapFn == Undefined {
+				return v
+			}
+			return r.callFunction(mapFn, thisArg, v, Number(i))
+		}
+		if r.getMethod(items, symbolKey(SymbolIterator)) != nil {
+			a := r.newArrayFrom(this, nil)
+			var i int64
+			r.iterate(items, func(v Value) bool {
+				r.createDataProperty(a, elementKey(i), mapped(v, i))
+				i++
+				return true
+			})
+			r.putV(a, stringKey("length"), Number(i), true)
+			return a
+		}
+		o := r.toObject(items)
+		n := r.lengthOfArrayLike(o)
+		a := r.newArrayFrom(this, Number(n))
+		for i := int64(0); i < n; i++ {
+			r.createDataProperty(a, elementKey(i), mapped(o.get(elementKey(i), o), i))
+		}
+		r.putV(a, stringKey("length"), Number(n), true)
+		return a
+	})
+
+	values := r.method(proto, "values", 0, func(this Value, args []Value) Value {
+		return r.newArrayIterator(r.toObject(this), "values")
+	})
+	proto.setHidden(symbolKey(SymbolIterator), values)
+	r.arrayValues = values
+	r.method(proto, "keys", 0, func(this Value, args []Value) Value {
+		return r.newArrayIterator(r.toObject(this), "keys")
+	})
+	r.method(proto, "entries", 0, func(this Value, args []Value) Value {
+		return r.newArrayIterator(r.toObject(this), "entries")
+	})
+	r.method(proto, "join", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		separator := ","
+		if v := arg(args, 0); v != Undefined {
+			separator = r.toString(v)
+		}
+		return String(r.join(o, separator))
+	})
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		if fn, ok := o.get(stringKey("join"), o).(*Object); ok && fn.call != nil {
+			return fn.call(o, nil)
+		}
+		return String(r.objectToString(o))
+	})
+	r.method(proto, "toLocaleString", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		if r.joining[o] {
+			return String("")
+		}
+		r.joining[o] = true
+		defer delete(r.joining, o)
+		n := r.lengthOfArrayLike(o)
+		s := ""
+		for i := int64(0); i < n; i++ {
+			if i > 0 {
+				s += ","
+			}
+			if v := o.get(elementKey(i), o); !isNullish(v) {
+				s += r.toString(r.callFunction(r.getV(v, stringKey("toLocaleString")), v))
+			}
+		}
+		return String(s)
+	})
+
+	r.method(proto, "at", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		if i < 0 {
+			i += float64(n)
+		}
+		if i < 0 || i >= float64(n) {
+			return Undefined
+		}
+		return o.get(elementKey(int64(i)), o)
+	})
+	r.method(proto, "push", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		if n+int64(len(args)) > maxSafeInteger {
+			panic(r.newTypeError("Pushing %d elements on an array-like of length %d is disallowed", len(args), n))
+		}
+		for _, v := range args {
+			r.putV(o, elementKey(n), v, true)
+			n++
+		}
+		r.putV(o, stringKey("length"), Number(n), true)
+		return Number(n)
+	})
+	r.method(proto, "pop", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		if n == 0 {
+			r.putV(o, stringKey("length"), Number(0), true)
+			return Undefined
+		}
+		v := o.get(elementKey(n-1), o)
+		r.deleteProperty(o, elementKey(n-1), true)
+		r.putV(o, stringKey("length"), Number(n-1), true)
+		return v
+	})
+	r.method(proto, "shift", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		if n == 0 {
+			r.putV(o, stringKey("length"), Number(0), true)
+			return Undefined
+		}
+		first := o.get(stringKey("0"), o)
+		r.moveElements(o, 1, 0, n-1)
+		r.deleteProperty(o, elementKey(n-1), true)
+		r.putV(o, stringKey("length"), Number(n-1), true)
+		return first
+	})
+	r.method(proto, "unshift", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		count := int64(len(args))
+		if count > 0 {
+			if n+count > maxSafeInteger {
+				panic(r.newTypeError("Unshifting %d elements on an array-like of length %d is disallowed", count, n))
+			}
+			r.moveElements(o, 0, count, n)
+			for i, v := range args {
+				r.putV(o, elementKey(int64(i)), v, true)
+			}
+		}
+		r.putV(o, stringKey("length"), Number(n+count), true)
+		return Number(n + count)
+	})
+	r.method(proto, "slice", 2, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		start := r.relativeIndex(arg(args, 0), n, 0)
+		end := r.relativeIndex(arg(args, 1), n, n)
+		count := end - start
+		if count < 0 {
+			count = 0
+		}
+		a := r.arraySpeciesCreate(o, count)
+		var i int64
+		for k := start; k < end; k++ {
+			if key := elementKey(k); o.hasProperty(key) {
+				r.createDataProperty(a, elementKey(i), o.get(key, o))
+			}
+			i++
+		}
+		r.putV(a, stringKey("length"), Number(i), true)
+		return a
+	})
+	r.method(proto, "splice", 2, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		start := r.relativeIndex(arg(args, 0), n, 0)
+		var items []Value
+		deleteCount := int64(0)
+		switch len(args) {
+		case 0:
+		case 1:
+			deleteCount = n - start
+		default:
+			deleteCount = int64(math.Min(math.Max(r.toIntegerOrInfinity(args[1]), 0), float64(n-start)))
+			items = args[2:]
+		}
+		count := int64(len(items))
+		if n+count-deleteCount > maxSafeInteger {
+			panic(r.newTypeError("Splicing would make an array-like longer than 2^53 - 1"))
+		}
+		removed := r.arraySpeciesCreate(o, deleteCount)
+		for i := int64(0); i < deleteCount; i++ {
+			if key := elementKey(start + i); o.hasProperty(key) {
+				r.createDataProperty(removed, elementKey(i), o.get(key, o))
+			}
+		}
+		r.putV(removed, stringKey("length"), Number(deleteCount), true)
+
+		switch {
+		case count < deleteCount:
+			r.moveElements(o, start+deleteCount, start+count, n-start-deleteCount)
+			for k := n; k > n-deleteCount+count; k-- {
+				r.deleteProperty(o, elementKey(k-1), true)
+			}
+		case count > deleteCount:
+			r.moveElements(o, start+deleteCount, start+count, n-start-deleteCount)
+		}
+		for i, v := range items {
+			r.putV(o, elementKey(start+int64(i)), v, true)
+		}
+		r.putV(o, stringKey("length"), Number(n-deleteCount+count), true)
+		return removed
+	})
+	r.method(proto, "concat", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		a := r.arraySpeciesCreate(o, 0)
+		var n int64
+		for _, item := range append([]Value{o}, args...) {
+			e, ok := item.(*Object)
+			if !ok || !r.isConcatSpreadable(e) {
+				if n >= maxSafeInteger {
+					panic(r.newTypeError("Concatenating would make an array longer than 2^53 - 1"))
+				}
+				r.createDataProperty(a, elementKey(n), item)
+				n++
+				continue
+			}
+			length := r.lengthOfArrayLike(e)
+			if n+length > maxSafeInteger {
+				panic(r.newTypeError("Concatenating would make an array longer than 2^53 - 1"))
+			}
+			for k := int64(0); k < length; k++ {
+				if key := elementKey(k); e.hasProperty(key) {
+					r.createDataProperty(a, elementKey(n), e.get(key, e))
+				}
+				n++
+			}
+		}
+		r.putV(a, stringKey("length"), Number(n), true)
+		return a
+	})
+	r.method(proto, "copyWithin", 2, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		to := r.relativeIndex(arg(args, 0), n, 0)
+		from := r.relativeIndex(arg(args, 1), n, 0)
+		end := r.relativeIndex(arg(args, 2), n, n)
+		count := end - from
+		if n-to < count {
+			count = n - to
+		}
+		if count > 0 {
+			r.moveElements(o, from, to, count)
+		}
+		return o
+	})
+	r.method(proto, "fill", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		start := r.relativeIndex(arg(args, 1), n, 0)
+		end := r.relativeIndex(arg(args, 2), n, n)
+		for k := start; k < end; k++ {
+			r.putV(o, elementKey(k), arg(args, 0), true)
+		}
+		return o
+	})
+	r.method(proto, "reverse", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		for lower := int64(0); lower < n/2; lower++ {
+			upper := n - 1 - lower
+			lowerKey, upperKey := elementKey(lower), elementKey(upper)
+			lowerExists := o.hasProperty(lowerKey)
+			var lowerValue, upperValue Value
+			if lowerExists {
+				lowerValue = o.get(lowerKey, o)
+			}
+			upperExists := o.hasProperty(upperKey)
+			if upperExists {
+				upperValue = o.get(upperKey, o)
+			}
+			switch {
+			case lowerExists && upperExists:
+				r.putV(o, lowerKey, upperValue, true)
+				r.putV(o, upperKey, lowerValue, true)
+			case upperExists:
+				r.putV(o, lowerKey, upperValue, true)
+				r.deleteProperty(o, upperKey, true)
+			case lowerExists:
+				r.deleteProperty(o, lowerKey, true)
+				r.putV(o, upperKey, lowerValue, true)
+			}
+		}
+		return o
+	})
+	r.method(proto, "sort", 1, func(this Value, args []Value) Value {
+		compare := arg(args, 0)
+		if compare != Undefined && !isCallable(compare) {
+			panic(r.newTypeError("The comparison function must be either a function or undefined"))
+		}
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		sorted := r.sortIndexedProperties(o, n, compare, true)
+		for i, v := range sorted {
+			r.putV(o, elementKey(int64(i)), v, true)
+		}
+		for k := int64(len(sorted)); k < n; k++ {
+			if key := elementKey(k); o.hasProperty(key) {
+				r.deleteProperty(o, key, true)
+			}
+		}
+		return o
+	})
+
+	r.method(proto, "indexOf", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		for k := r.relativeIndex(arg(args, 1), n, 0); k < n; k++ {
+			if key := elementKey(k); o.hasProperty(key) && strictEquals(o.get(key, o), arg(args, 0)) {
+				return Number(k)
+			}
+		}
+		return Number(-1)
+	})
+	r.method(proto, "lastIndexOf", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		if n == 0 {
+			return Number(-1)
+		}
+		k := n - 1
+		if len(args) > 1 {
+			from := r.toIntegerOrInfinity(args[1])
+			if from < 0 {
+				from += float64(n)
+			}
+			k = int64(math.Min(from, float64(n-1)))
+		}
+		for ; k >= 0; k-- {
+			if key := elementKey(k); o.hasProperty(key) && strictEquals(o.get(key, o), arg(args, 0)) {
+				return Number(k)
+			}
+		}
+		return Number(-1)
+	})
+	r.method(proto, "includes", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		for k := r.relativeIndex(arg(args, 1), n, 0); k < n; k++ {
+			if sameValueZero(o.get(elementKey(k), o), arg(args, 0)) {
+				return Bool(true)
+			}
+		}
+		return Bool(false)
+	})
+	for _, find := range []struct {
+		name    string
+		last    bool
+		indexes bool
+	}{{"find", false, false}, {"findIndex", false, true}, {"findLast", true, false}, {"findLastIndex", true, true}} {
+		find := find
+		r.method(proto, find.name, 1, func(this Value, args []Value) Value {
+			o := r.toObject(this)
+			n := r.lengthOfArrayLike(o)
+			predicate := r.callback(arg(args, 0))
+			for i := int64(0); i < n; i++ {
+				k := i
+				if find.last {
+					k = n - 1 - i
+				}
+				v := o.get(elementKey(k), o)
+				if toBoolean(predicate.call(arg(args, 1), []Value{v, Number(k), o})) {
+					if find.indexes {
+						return Number(k)
+					}
+					return v
+				}
+			}
+			if find.indexes {
+				return Number(-1)
+			}
+			return Undefined
+		})
+	}
+	r.method(proto, "forEach", 1, func(this Value, args []Value) Value {
+		r.eachElement(this, args, func(o *Object, v Value, k int64, result Value) bool {
+			return true
+		})
+		return Undefined
+	})
+	r.method(proto, "every", 1, func(this Value, args []Value) Value {
+		every := true
+		r.eachElement(this, args, func(o *Object, v Value, k int64, result Value) bool {
+			every = toBoolean(result)
+			return every
+		})
+		return Bool(every)
+	})
+	r.method(proto, "some", 1, func(this Value, args []Value) Value {
+		some := false
+		r.eachElement(this, args, func(o *Object, v Value, k int64, result Value) bool {
+			some = toBoolean(result)
+			return !some
+		})
+		return Bool(some)
+	})
+	r.method(proto, "map", 1, func(this Value, args []Value) Value {
+		var a *Object
+		r.eachElement(this, args, func(o *Object, v Value, k int64, result Value) bool {
+			r.createDataProperty(a, elementKey(k), result)
+			return true
+		}, func(o *Object, n int64) {
+			a = r.arraySpeciesCreate(o, n)
+		})
+		return a
+	})
+	r.method(proto, "filter", 1, func(this Value, args []Value) Value {
+		var a *Object
+		var i int64
+		r.eachElement(this, args, func(o *Object, v Value, k int64, result Value) bool {
+			if toBoolean(result) {
+				r.createDataProperty(a, elementKey(i), v)
+				i++
+			}
+			return true
+		}, func(o *Object, n int64) {
+			a = r.arraySpeciesCreate(o, 0)
+		})
+		return a
+	})
+	for _, reduce := range []struct {
+		name  string
+		right bool
+	}{{"reduce", false}, {"reduceRight", true}} {
+		reduce := reduce
+		r.method(proto, reduce.name, 1, func(this Value, args []Value) Value {
+			o := r.toObject(this)
+			n := r.lengthOfArrayLike(o)
+			fn := r.callback(arg(args, 0))
+			k, step := int64(0), int64(1)
+			if reduce.right {
+				k, step = n-1, -1
+			}
+			var accumulator Value
+			if len(args) > 1 {
+				accumulator = args[1]
+			} else {
+				for ; k >= 0 && k < n; k += step {
+					if key := elementKey(k); o.hasProperty(key) {
+						accumulator = o.get(key, o)
+						k += step
+						break
+					}
+				}
+				if accumulator == nil {
+					panic(r.newTypeError("Reduce of empty array with no initial value"))
+				}
+			}
+			for ; k >= 0 && k < n; k += step {
+				if key := elementKey(k); o.hasProperty(key) {
+					accumulator = fn.call(Undefined, []Value{accumulator, o.get(key, o), Number(k), o})
+				}
+			}
+			return accumulator
+		})
+	}
+	r.method(proto, "flat", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		depth := 1.0
+		if v := arg(args, 0); v != Undefined {
+			depth = math.Max(r.toIntegerOrInfinity(v), 0)
+		}
+		a := r.arraySpeciesCreate(o, 0)
+		r.flattenIntoArray(a, o, n, 0, depth, nil, nil)
+		return a
+	})
+	r.method(proto, "flatMap", 1, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		fn := r.callback(arg(args, 0))
+		a := r.arraySpeciesCreate(o, 0)
+		r.flattenIntoArray(a, o, n, 0, 1, fn, arg(args, 1))
+		return a
+	})
+
+	r.method(proto, "toReversed", 0, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		values := make([]Value, r.arrayCreateLength(n))
+		for k := range values {
+			values[k] = o.get(elementKey(n-1-int64(k)), o)
+		}
+		return r.newArray(values...)
+	})
+	r.method(proto, "toSorted", 1, func(this Value, args []Value) Value {
+		compare := arg(args, 0)
+		if compare != Undefined && !isCallable(compare) {
+			panic(r.newTypeError("The comparison function must be either a function or undefined"))
+		}
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		r.arrayCreateLength(n)
+		return r.newArray(r.sortIndexedProperties(o, n, compare, false)...)
+	})
+	r.method(proto, "toSpliced", 2, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		start := r.relativeIndex(arg(args, 0), n, 0)
+		var items []Value
+		skip := int64(0)
+		switch len(args) {
+		case 0:
+		case 1:
+			skip = n - start
+		default:
+			skip = int64(math.Min(math.Max(r.toIntegerOrInfinity(args[1]), 0), float64(n-start)))
+			items = args[2:]
+		}
+		values := make([]Value, 0, r.arrayCreateLength(n+int64(len(items))-skip))
+		for k := int64(0); k < start; k++ {
+			values = append(values, o.get(elementKey(k), o))
+		}
+		values = append(values, items...)
+		for k := start + skip; k < n; k++ {
+			values = append(values, o.get(elementKey(k), o))
+		}
+		return r.newArray(values...)
+	})
+	r.method(proto, "with", 2, func(this Value, args []Value) Value {
+		o := r.toObject(this)
+		n := r.lengthOfArrayLike(o)
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		if i < 0 {
+			i += float64(n)
+		}
+		if i < 0 || i >= float64(n) {
+			panic(r.newRangeError("Invalid index : %s", numberToString(r.toIntegerOrInfinity(arg(args, 0)))))
+		}
+		values := make([]Value, r.arrayCreateLength(n))
+		for k := range values {
+			if int64(k) == int64(i) {
+				values[k] = arg(args, 1)
+			} else {
+				values[k] = o.get(elementKey(int64(k)), o)
+			}
+		}
+		return r.newArray(values...)
+	})
+
+	// https://262.ecma-international.org/#sec-array.prototype-@@unscopables
+	unscopables := r.newObject(nil)
+	for _, name := range []string{
+		"at", "copyWithin", "entries", "fill", "find", "findIndex", "findLast", "findLastIndex", "flat",
+		"flatMap", "includes", "keys", "toReversed", "toSorted", "toSpliced", "values",
+	} {
+		unscopables.setProperty(name, Bool(true))
+	}
+	proto.defineOwnProperty(symbolKey(SymbolUnscopables), dataDescriptor(unscopables, configurable))
+}
+
+// elementKey returns the key of the element of an array-like object at
+// index i, which may be past the indexes of arrays.
+func elementKey(i int64) propertyKey {
+	if i < math.MaxUint32 {
+		return indexKey(uint32(i))
+	}
+	return stringKey(strconv.FormatInt(i, 10))
+}
+
+// relativeIndex converts v to an index of an array-like object of length n,
+// counted from its end when negative, and fallback when undefined.
+func (r *Runtime) relativeIndex(v Value, n, fallback int64) int64 {
+	if v == Undefined {
+		return fallback
+	}
+	i := r.toIntegerOrInfinity(v)
+	if i < 0 {
+		return int64(math.Max(float64(n)+i, 0))
+	}
+	return int64(math.Min(i, float64(n)))
+}
+
+// callback returns the function v that a built-in calls, failing if it is
+// not one.
+func (r *Runtime) callback(v Value) *Object {
+	fn, ok := v.(*Object)
+	if !ok || fn.call == nil {
+		panic(r.newTypeError("%s is not a function", r.describe(v)))
+	}
+	return fn
+}
+
+// eachElement calls the callback of the iteration methods of arrays, the
+// first of args, on the elements of the array-like this that are present,
+// and passes its results to visit until it returns false. The before
+// functions are called with the length of the array-like before the first
+// element.
+func (r *Runtime) eachElement(this Value, args []Value, visit func(o *Object, v Value, k int64, result Value) bool, before ...func(o *Object, n int64)) {
+	o := r.toObject(this)
+	n := r.lengthOfArrayLike(o)
+	fn := r.callback(arg(args, 0))
+	for _, f := range before {
+		f(o, n)
+	}
+	thisArg := arg(args, 1)
+	for k := int64(0); k < n; k++ {
+		key := elementKey(k)
+		if !o.hasProperty(key) {
+			continue
+		}
+		v := o.get(key, o)
+		if !visit(o, v, k, fn.call(thisArg, []Value{v, Number(k), o})) {
+			return
+		}
+	}
+}
+
+// moveElements moves count elements of o from the index from to the index
+// to, deleting those missing, in the order that does not overwrite those
+// yet to move.
+func (r *Runtime) moveElements(o *Object, from, to, count int64) {
+	step := func(i int64) {
+		fromKey, toKey := elementKey(from+i), elementKey(to+i)
+		if o.hasProperty(fromKey) {
+			r.putV(o, toKey, o.get(fromKey, o), true)
+		} else {
+			r.deleteProperty(o, toKey, true)
+		}
+	}
+	if from < to && to < from+count {
+		for i := count - 1; i >= 0; i-- {
+			step(i)
+		}
+		return
+	}
+	for i := int64(0); i < count; i++ {
+		step(i)
+	}
+}
+
+// arrayCreateLength returns n, failing with a RangeError when it is too
+// large a length for an array.
+func (r *Runtime) arrayCreateLength(n int64) int64 {
+	if n > math.MaxUint32 {
+		panic(r.newRangeError("Invalid array length"))
+	}
+	return n
+}
+
+// newArrayFrom returns the object that Array.of and Array.from fill: a new
+// object created by the constructor c, called with length unless nil, or an
+// array when c is not a constructor.
+func (r *Runtime) newArrayFrom(c Value, length Value) *Object {
+	if !isConstructor(c) {
+		if n, ok := length.(Number); ok {
+			r.arrayCreateLength(int64(n))
+		}
+		return r.newArray()
+	}
+	var args []Value
+	if length != nil {
+		args = []Value{length}
+	}
+	return r.constructObject(c, args, nil)
+}
+
+// arraySpeciesCreate returns the array of length n that the methods of
+// arrays creating arrays return, created by the @@species constructor of
+// the array o.
+//
+// https://262.ecma-international.org/#sec-arrayspeciescreate
+func (r *Runtime) arraySpeciesCreate(o *Object, n int64) *Object {
+	if !o.array {
+		return r.newArray().withLength(r.arrayCreateLength(n))
+	}
+	c := o.get(stringKey("constructor"), o)
+	if ctor, ok := c.(*Object); ok {
+		c = ctor.get(symbolKey(SymbolSpecies), ctor)
+		if c == Null {
+			c = Undefined
+		}
+	}
+	if c == Undefined {
+		return r.newArray().withLength(r.arrayCreateLength(n))
+	}
+	if !isConstructor(c) {
+		panic(r.newTypeError("object.constructor[Symbol.species] is not a constructor"))
+	}
+	return r.constructObject(c, []Value{Number(n)}, nil)
+}
+
+// withLength sets the length of the new array o, whose elements are holes.
+func (o *Object) withLength(n int64) *Object {
+	o.length = uint32(n)
+	return o
+}
+
+// isConcatSpreadable reports whether Array.prototype.concat spreads the
+// elements of o, rather than adding it as an element.
+//
+// https://262.ecma-international.org/#sec-isconcatspreadable
+func (r *Runtime) isConcatSpreadable(o *Object) bool {
+	if v := o.get(symbolKey(SymbolIsConcatSpreadable), o); v != Undefined {
+		return toBoolean(v)
+	}
+	return o.array
+}
+
+// flattenIntoArray adds the elements of source to target from index start,
+// flattening the arrays among them up to depth, and returns the index past
+// the last one added. The elements are mapped by fn first unless nil.
+//
+// https://262.ecma-international.org/#sec-flattenintoarray
+func (r *Runtime) flattenIntoArray(target, source *Object, n, start int64, depth float64, fn *Object, thisArg Value) int64 {
+	i := start
+	for k := int64(0); k < n; k++ {
+		key := elementKey(k)
+		if !source.hasProperty(key) {
+			continue
+		}
+		v := source.get(key, source)
+		if fn != nil {
+			v = fn.call(thisArg, []Value{v, Number(k), source})
+		}
+		if e, ok := v.(*Object); ok && depth > 0 && e.array {
+			i = r.flattenIntoArray(target, e, r.lengthOfArrayLike(e), i, depth-1, nil, nil)
+			continue
+		}
+		if i >= maxSafeInteger {
+			panic(r.newTypeError("Flattening would make an array longer than 2^53 - 1"))
+		}
+		r.createDataProperty(target, elementKey(i), v)
+		i++
+	}
+	return i
+}
+
+// sortIndexedProperties returns the elements of o of length n sorted by
+// compare, or by their strings when undefined, undefined ones last. Holes
+// are skipped when skipHoles, and read as undefined otherwise.
+//
+// https://262.ecma-international.org/#sec-sortindexedproperties
+func (r *Runtime) sortIndexedProperties(o *Object, n int64, compare Value, skipHoles bool) []Value {
+	var values []Value
+	undefineds := 0
+	for k := int64(0); k < n; k++ {
+		key := elementKey(k)
+		if skipHoles && !o.hasProperty(key) {
+			continue
+		}
+		if v := o.get(key, o); v != Undefined {
+			values = append(values, v)
+		} else {
+			undefineds++
+		}
+	}
+
+	if compare == Undefined {
+		keys := make([]string, len(values))
+		for i, v := range values {
+			keys[i] = r.toString(v)
+		}
+		indexes := make([]int, len(values))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return compareStrings(keys[indexes[i]], keys[indexes[j]]) < 0
+		})
+		sorted := make([]Value, len(values), len(values)+undefineds)
+		for i, index := range indexes {
+			sorted[i] = values[index]
+		}
+		values = sorted
+	} else {
+		fn := compare.(*Object)
+		sort.SliceStable(values, func(i, j int) bool {
+			return r.toNumber(fn.call(Undefined, []Value{values[i], values[j]})) < 0
+		})
+	}
+	for ; undefineds > 0; undefineds-- {
+		values = append(values, Undefined)
+	}
+	return values
+}
+
+// join returns the elements of the array-like object o converted to strings
+// and separated by separator. Arrays that contain themselves are joined as
+// empty strings where they recur, rather than endlessly.
+//
+// https://262.ecma-international.org/#sec-array.prototype.join
+func (r *Runtime) join(o *Object, separator string) string {
+	if r.joining[o] {
+		return ""
+	}
+	r.joining[o] = true
+	defer delete(r.joining, o)
+
+	var s strings.Builder
+	length := r.lengthOfArrayLike(o)
+	for i := int64(0); i < length; i++ {
+		if i > 0 {
+			s.WriteString(separator)
+		}
+		if v := o.get(elementKey(i), o); !isNullish(v) {
+			s.WriteString(r.toString(v))
+		}
+	}
+	return joinSurrogates(s.String())
+}
@@ -0,0 +1,363 @@
+package runtime
+
+import "math"
+
+// //////////////
+// Collections //
+// //////////////
+
+// orderedMap is the entries of a Map or a Set, in order of insertion, keyed
+// by SameValueZero. Deleted entries stay linked to the entries before them,
+// for the iterators positioned on them to carry on from there.
+type orderedMap struct {
+	index map[Value]*mapEntry
+	head  *mapEntry // a sentinel before the first entry
+	tail  *mapEntry
+	size  int
+}
+
+type mapEntry struct {
+	key, value Value
+	prev, next *mapEntry
+	deleted    bool
+}
+
+func newOrderedMap() *orderedMap {
+	head := &mapEntry{}
+	return &orderedMap{index: make(map[Value]*mapEntry), head: head, tail: head}
+}
+
+// nanKey is the key of NaN in the index of ordered maps, where the NaN
+// numbers would not equal each other.
+type nanKey struct{}
+
+func (nanKey) Type() string { return "number" }
+
+// normalizeKey returns the key of the index of ordered maps for v: its
+// numbers are equal when SameValueZero tells them so.
+func normalizeKey(v Value) Value {
+	if n, ok := v.(Number); ok {
+		if math.IsNaN(float64(n)) {
+			return nanKey{}
+		}
+		if n == 0 {
+			return Number(0)
+		}
+	}
+	return v
+}
+
+func (m *orderedMap) get(key Value) (*mapEntry, bool) {
+	e, ok := m.index[normalizeKey(key)]
+	return e, ok
+}
+
+// set adds or updates the entry with key. A -0 key is added as +0.
+func (m *orderedMap) set(key, value Value) {
+	if e, ok := m.get(key); ok {
+		e.value = value
+		return
+	}
+	key = normalizeKey(key)
+	if _, ok := key.(nanKey); ok {
+		key = Number(math.NaN())
+	}
+	e := &mapEntry{key: key, value: value, prev: m.tail}
+	m.tail.next = e
+	m.tail = e
+	m.index[normalizeKey(key)] = e
+	m.size++
+}
+
+// delete removes the entry with key, and reports whether there was one.
+func (m *orderedMap) delete(key Value) bool {
+	e, ok := m.get(key)
+	if !ok {
+		return false
+	}
+	delete(m.index, normalizeKey(key))
+	e.deleted = true
+	e.prev.next = e.next
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		m.tail = e.prev
+	}
+	m.size--
+	return true
+}
+
+func (m *orderedMap) clear() {
+	for e := m.head.next; e != nil; e = e.next {
+		e.deleted = true
+		e.prev = m.head
+	}
+	m.index = make(map[Value]*mapEntry)
+	m.head.next = nil
+	m.tail = m.head
+	m.size = 0
+}
+
+// after returns the entry following e, skipping those deleted since, or
+// nil past the last one.
+func (m *orderedMap) after(e *mapEntry) *mapEntry {
+	for e.deleted {
+		e = e.prev
+	}
+	return e.next
+}
+
+// forEach calls fn on the entries in order, those added while iterating
+// included.
+func (m *orderedMap) forEach(fn func(e *mapEntry)) {
+	for e := m.after(m.head); e != nil; e = m.after(e) {
+		fn(e)
+	}
+}
+
+// newMapIterator returns an iterator over the keys, values or entries of
+// m, as kind tells.
+func (r *Runtime) newMapIterator(m *orderedMap, proto *Object, class, kind string) *Object {
+	current := m.head
+	return r.newNativeIterator(proto, class, func() (Value, bool) {
+		next := m.after(current)
+		if next == nil {
+			return nil, false
+		}
+		current = next
+		switch kind {
+		case "keys":
+			return next.key, true
+		case "values":
+			return next.value, true
+		}
+		return r.newArray(next.key, next.value), true
+	})
+}
+
+// initCollection creates the constructor of the collection name, whose
+// objects hold the state newState returns, added by the method named adder
+// from the iterable argument.
+func (r *Runtime) initCollection(name, adder string, newState func() interface{}) (ctor, proto *Object) {
+	proto = r.newObject(r.objectPrototype)
+	construct := func(args []Value, newTarget *Object) *Object {
+		o := r.newObject(r.prototypeFromConstructor(newTarget, proto))
+		o.class = name
+		o.internal = newState()
+		iterable := arg(args, 0)
+		if isNullish(iterable) {
+			return o
+		}
+		add := o.get(stringKey(adder), o)
+		if !isCallable(add) {
+			panic(r.newTypeError("'%s' returned for property '%s' of object '#<%s>' is not a function", r.describe(add), adder, name))
+		}
+		r.iterate(iterable, func(v Value) bool {
+			if adder == "add" {
+				r.callFunction(add, o, v)
+				return true
+			}
+			entry, ok := v.(*Object)
+			if !ok {
+				panic(r.newTypeError("Iterator value %s is not an entry object", r.describe(v)))
+			}
+			r.callFunction(add, o, entry.get(stringKey("0"), entry), entry.get(stringKey("1"), entry))
+			return true
+		})
+		return o
+	}
+	ctor = r.newNativeConstructor(name, 0, func(this Value, args []Value) Value {
+		panic(r.newTypeError("Constructor %s requires 'new'", name))
+	}, construct, proto)
+	r.setGlobal(name, ctor)
+	setToStringTag(proto, name)
+	return ctor, proto
+}
+
+// thisCollection returns the state of the collection this, of class, as
+// the method name of its prototype requires.
+func thisCollection[T any](r *Runtime, this Value, class, name string) T {
+	if o, ok := this.(*Object); ok && o.class == class {
+		if state, ok := o.internal.(T); ok {
+			return state
+		}
+	}
+	panic(r.newTypeError("Method %s.prototype.%s called on incompatible receiver %s", class, name, r.describe(this)))
+}
+
+// //////
+// Map //
+// //////
+
+func (r *Runtime) initMap() {
+	ctor, proto := r.initCollection("Map", "set", func() interface{} { return newOrderedMap() })
+	r.setSpecies(ctor)
+	iteratorProto := r.newNativeIteratorPrototype("Map Iterator")
+	this := func(v Value, name string) *orderedMap {
+		return thisCollection[*orderedMap](r, v, "Map", name)
+	}
+
+	r.method(proto, "get", 1, func(v Value, args []Value) Value {
+		if e, ok := this(v, "get").get(arg(args, 0)); ok {
+			return e.value
+		}
+		return Undefined
+	})
+	r.method(proto, "set", 2, func(v Value, args []Value) Value {
+		this(v, "set").set(arg(args, 0), arg(args, 1))
+		return v
+	})
+	r.method(proto, "has", 1, func(v Value, args []Value) Value {
+		_, ok := this(v, "has").get(arg(args, 0))
+		return Bool(ok)
+	})
+	r.method(proto, "delete", 1, func(v Value, args []Value) Value {
+		return Bool(this(v, "delete").delete(arg(args, 0)))
+	})
+	r.method(proto, "clear", 0, func(v Value, args []Value) Value {
+		this(v, "clear").clear()
+		return Undefined
+	})
+	r.method(proto, "forEach", 1, func(v Value, args []Value) Value {
+		m := this(v, "forEach")
+		fn := r.callback(arg(args, 0))
+		m.forEach(func(e *mapEntry) {
+			fn.call(arg(args, 1), []Value{e.value, e.key, v})
+		})
+		return Undefined
+	})
+	size := r.newNativeFunction("get size", 0, func(v Value, args []Value) Value {
+		return Number(this(v, "size").size)
+	})
+	proto.setAccessor(stringKey("size"), size, nil)
+	for _, kind := range []string{"keys", "values", "entries"} {
+		kind := kind
+		fn := r.method(proto, kind, 0, func(v Value, args []Value) Value {
+			return r.newMapIterator(this(v, kind), iteratorProto, "Map Iterator", kind)
+		})
+		if kind == "entries" {
+			proto.setHidden(symbolKey(SymbolIterator), fn)
+		}
+	}
+}
+
+// //////
+// Set //
+// //////
+
+func (r *Runtime) initSet() {
+	ctor, proto := r.initCollection("Set", "add", func() interface{} { return newOrderedMap() })
+	r.setSpecies(ctor)
+	iteratorProto := r.newNativeIteratorPrototype("Set Iterator")
+	this := func(v Value, name string) *orderedMap {
+		return thisCollection[*orderedMap](r, v, "Set", name)
+	}
+
+	r.method(proto, "add", 1, func(v Value, args []Value) Value {
+		key := arg(args, 0)
+		this(v, "add").set(key, key)
+		return v
+	})
+	r.method(proto, "has", 1, func(v Value, args []Value) Value {
+		_, ok := this(v, "has").get(arg(args, 0))
+		return Bool(ok)
+	})
+	r.method(proto, "delete", 1, func(v Value, args []Value) Value {
+		return Bool(this(v, "delete").delete(arg(args, 0)))
+	})
+	r.method(proto, "clear", 0, func(v Value, args []Value) Value {
+		this(v, "clear").clear()
+		return Undefined
+	})
+	r.method(proto, "forEach", 1, func(v Value, args []Value) Value {
+		m := this(v, "forEach")
+		fn := r.callback(arg(args, 0))
+		m.forEach(func(e *mapEntry) {
+			fn.call(arg(args, 1), []Value{e.key, e.key, v})
+		})
+		return Undefined
+	})
+	size := r.newNativeFunction("get size", 0, func(v Value, args []Value) Value {
+		return Number(this(v, "size").size)
+	})
+	proto.setAccessor(stringKey("size"), size, nil)
+	values := r.method(proto, "values", 0, func(v Value, args []Value) Value {
+		return r.newMapIterator(this(v, "values"), iteratorProto, "Set Iterator", "keys")
+	})
+	proto.setHidden(stringKey("keys"), values)
+	proto.setHidden(symbolKey(SymbolIterator), values)
+	r.method(proto, "entries", 0, func(v Value, args []Value) Value {
+		return r.newMapIterator(this(v, "entries"), iteratorProto, "Set Iterator", "entries")
+	})
+}
+
+// //////////////////////
+// WeakMap and WeakSet //
+// //////////////////////
+
+// weakCollection is the state of a WeakMap or a WeakSet. Without weak
+// references, its entries live as long as the collection does, which is
+// not observable.
+type weakCollection map[Value]Value
+
+// canBeHeldWeakly reports whether v may be the key of a weak collection:
+// an object, or a symbol that is not in the global registry.
+//
+// https://262.ecma-international.org/#sec-canbeheldweakly
+func (r *Runtime) canBeHeldWeakly(v Value) bool {
+	switch v := v.(type) {
+	case *Object:
+		return true
+	case *Symbol:
+		description, ok := v.Description.(String)
+		return !ok || r.symbols[string(description)] != v
+	}
+	return false
+}
+
+func (r *Runtime) initWeakCollections() {
+	for _, c := range []struct {
+		name, adder string
+	}{{"WeakMap", "set"}, {"WeakSet", "add"}} {
+		c := c
+		_, proto := r.initCollection(c.name, c.adder, func() interface{} { return weakCollection{} })
+		this := func(v Value, name string) weakCollection {
+			return thisCollection[weakCollection](r, v, c.name, name)
+		}
+		r.method(proto, "has", 1, func(v Value, args []Value) Value {
+			_, ok := this(v, "has")[arg(args, 0)]
+			return Bool(ok)
+		})
+		r.method(proto, "delete", 1, func(v Value, args []Value) Value {
+			m := this(v, "delete")
+			_, ok := m[arg(args, 0)]
+			delete(m, arg(args, 0))
+			return Bool(ok)
+		})
+		if c.name == "WeakSet" {
+			r.method(proto, "add", 1, func(v Value, args []Value) Value {
+				m := this(v, "add")
+				if !r.canBeHeldWeakly(arg(args, 0)) {
+					panic(r.newTypeError("Invalid value used in weak set"))
+				}
+				m[arg(args, 0)] = Bool(true)
+				return v
+			})
+			continue
+		}
+		r.method(proto, "get", 1, func(v Value, args []Value) Value {
+			if value, ok := this(v, "get")[arg(args, 0)]; ok {
+				return value
+			}
+			return Undefined
+		})
+		r.method(proto, "set", 2, func(v Value, args []Value) Value {
+			m := this(v, "set")
+			if !r.canBeHeldWeakly(arg(args, 0)) {
+				panic(r.newTypeError("Invalid value used as weak map key"))
+			}
+			m[arg(args, 0)] = arg(args, 1)
+			return v
+		})
+	}
+}
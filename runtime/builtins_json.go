@@ -0,0 +1,552 @@
+package runtime
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ///////
+// JSON //
+// ///////
+
+func (r *Runtime) initJSON() {
+	j := r.newObject(r.objectPrototype)
+	r.setGlobal("JSON", j)
+	setToStringTag(j, "JSON")
+
+	r.method(j, "parse", 2, func(this Value, args []Value) Value {
+		p := &jsonParser{r: r, src: r.toString(arg(args, 0))}
+		p.skipSpace()
+		v := p.value()
+		p.skipSpace()
+		if p.pos < len(p.src) {
+			p.fail()
+		}
+		reviver, ok := arg(args, 1).(*Object)
+		if !ok || reviver.call == nil {
+			return v
+		}
+		root := r.newObject(r.objectPrototype)
+		r.createDataProperty(root, stringKey(""), v)
+		return r.internalizeJSONProperty(root, stringKey(""), reviver)
+	})
+	r.method(j, "stringify", 3, func(this Value, args []Value) Value {
+		s := &jsonSerializer{r: r}
+		if replacer, ok := arg(args, 1).(*Object); ok {
+			if replacer.call != nil {
+				s.replacer = replacer
+			} else if replacer.array {
+				s.propertyList = r.jsonPropertyList(replacer)
+			}
+		}
+		s.gap = r.jsonGap(arg(args, 2))
+		wrapper := r.newObject(r.objectPrototype)
+		r.createDataProperty(wrapper, stringKey(""), arg(args, 0))
+		if str, ok := s.property(stringKey(""), wrapper); ok {
+			return String(str)
+		}
+		return Undefined
+	})
+}
+
+// internalizeJSONProperty calls reviver on the property of holder with key
+// once it is called on the properties of its value, replacing those with
+// its results, and deleting those it returns undefined for.
+//
+// https://262.ecma-international.org/#sec-internalizejsonproperty
+func (r *Runtime) internalizeJSONProperty(holder *Object, key propertyKey, reviver *Object) Value {
+	v := holder.get(key, holder)
+	if o, ok := v.(*Object); ok {
+		var keys []propertyKey
+		if o.array {
+			n := r.lengthOfArrayLike(o)
+			for i := int64(0); i < n; i++ {
+				keys = append(keys, elementKey(i))
+			}
+		} else {
+			for _, k := range r.enumerableOwnProperties(o, "keys") {
+				keys = append(keys, stringKey(string(k.(String))))
+			}
+		}
+		for _, k := range keys {
+			element := r.internalizeJSONProperty(o, k, reviver)
+			if element == Undefined {
+				o.delete(k)
+			} else {
+				o.defineOwnProperty(k, dataDescriptor(element, defaultFlags))
+			}
+		}
+	}
+	return reviver.call(holder, []Value{key.value(), v})
+}
+
+// /////////////
+// JSON.parse //
+// /////////////
+
+// jsonParser parses JSON text into values, failing with SyntaxErrors.
+//
+// https://262.ecma-international.org/#sec-json.parse
+type jsonParser struct {
+	r   *Runtime
+	src string
+	pos int
+}
+
+// fail throws the SyntaxError of an unexpected character at the current
+// position, or of the end of the text.
+func (p *jsonParser) fail() {
+	if p.pos >= len(p.src) {
+		panic(p.r.newSyntaxError("Unexpected end of JSON input"))
+	}
+	ch, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	panic(p.r.newSyntaxError("Unexpected token %c in JSON at position %d", ch, p.pos))
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// expect consumes the character ch, after white space.
+func (p *jsonParser) expect(ch byte) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != ch {
+		p.fail()
+	}
+	p.pos++
+}
+
+func (p *jsonParser) value() Value {
+	if p.pos >= len(p.src) {
+		p.fail()
+	}
+	switch ch := p.src[p.pos]; {
+	case ch == '{':
+		return p.object()
+	case ch == '[':
+		return p.array()
+	case ch == '"':
+		return String(p.string())
+	case ch == '-' || ch >= '0' && ch <= '9':
+		return p.number()
+	}
+	for _, literal := range []struct {
+		text  string
+		value Value
+	}{{"true", Bool(true)}, {"false", Bool(false)}, {"null", Null}} {
+		if strings.HasPrefix(p.src[p.pos:], literal.text) {
+			p.pos += len(literal.text)
+			return literal.value
+		}
+	}
+	p.fail()
+	return nil
+}
+
+func (p *jsonParser) object() Value {
+	o := p.r.newObject(p.r.objectPrototype)
+	p.pos++ // {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '}' {
+		p.pos++
+		return o
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			p.fail()
+		}
+		key := p.string()
+		p.expect(':')
+		p.skipSpace()
+		p.r.createDataProperty(o, stringKey(key), p.value())
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		p.expect('}')
+		return o
+	}
+}
+
+func (p *jsonParser) array() Value {
+	var elements []Value
+	p.pos++ // [
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == ']' {
+		p.pos++
+		return p.r.newArray()
+	}
+	for {
+		p.skipSpace()
+		elements = append(elements, p.value())
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		p.expect(']')
+		return p.r.newArray(elements...)
+	}
+}
+
+func (p *jsonParser) string() string {
+	p.pos++ // "
+	var s strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			panic(p.r.newSyntaxError("Unterminated string in JSON at position %d", p.pos))
+		}
+		switch ch := p.src[p.pos]; {
+		case ch == '"':
+			p.pos++
+			return joinSurrogates(s.String())
+		case ch < 0x20:
+			panic(p.r.newSyntaxError("Bad control character in string literal in JSON at position %d", p.pos))
+		case ch != '\\':
+			s.WriteByte(ch)
+			p.pos++
+			continue
+		}
+		p.pos++ // \
+		if p.pos >= len(p.src) {
+			p.fail()
+		}
+		escape := p.src[p.pos]
+		p.pos++
+		switch escape {
+		case '"', '\\', '/':
+			s.WriteByte(escape)
+		case 'b':
+			s.WriteByte('\b')
+		case 'f':
+			s.WriteByte('\f')
+		case 'n':
+			s.WriteByte('\n')
+		case 'r':
+			s.WriteByte('\r')
+		case 't':
+			s.WriteByte('\t')
+		case 'u':
+			unit := p.hex4()
+			if utf16.IsSurrogate(unit) && strings.HasPrefix(p.src[p.pos:], "\\u") {
+				start := p.pos
+				p.pos += 2
+				if ch := utf16.DecodeRune(unit, p.hex4()); ch != utf8.RuneError {
+					s.WriteRune(ch)
+					continue
+				}
+				p.pos = start
+			}
+			s.Write(appendRune(nil, unit))
+		default:
+			p.pos--
+			p.fail()
+		}
+	}
+}
+
+// hex4 parses the four hexadecimal digits of a \u escape.
+func (p *jsonParser) hex4() rune {
+	if p.pos+4 > len(p.src) {
+		p.pos = len(p.src)
+		p.fail()
+	}
+	var unit rune
+	for i := 0; i < 4; i++ {
+		digit := digitValue(rune(p.src[p.pos]))
+		if digit >= 16 {
+			p.fail()
+		}
+		unit = unit<<4 | rune(digit)
+		p.pos++
+	}
+	return unit
+}
+
+func (p *jsonParser) number() Value {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '0' {
+		p.pos++
+	} else if digits() == 0 {
+		p.fail()
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		if digits() == 0 {
+			p.fail()
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			p.fail()
+		}
+	}
+	n, _ := strconv.ParseFloat(p.src[start:p.pos], 64)
+	return Number(n)
+}
+
+// /////////////////
+// JSON.stringify //
+// /////////////////
+
+// jsonSerializer is the state of a call to JSON.stringify.
+//
+// https://262.ecma-international.org/#sec-json.stringify
+type jsonSerializer struct {
+	r            *Runtime
+	replacer     *Object
+	propertyList []propertyKey // nil when every key is serialized
+	gap          string
+	indent       string
+	stack        []*Object // the objects being serialized
+}
+
+// jsonPropertyList returns the keys of the properties to serialize, given
+// by the array replacer.
+func (r *Runtime) jsonPropertyList(replacer *Object) []propertyKey {
+	keys := []propertyKey{}
+	seen := make(map[string]bool)
+	n := r.lengthOfArrayLike(replacer)
+	for i := int64(0); i < n; i++ {
+		v := replacer.get(elementKey(i), replacer)
+		var item string
+		switch x := v.(type) {
+		case String:
+			item = string(x)
+		case Number:
+			item = numberToString(float64(x))
+		case *Object:
+			if x.class != "String" && x.class != "Number" {
+				continue
+			}
+			item = r.toString(x)
+		default:
+			continue
+		}
+		if !seen[item] {
+			seen[item] = true
+			keys = append(keys, stringKey(item))
+		}
+	}
+	return keys
+}
+
+// jsonGap returns the indentation of each level of JSON.stringify given by
+// space: up to 10 spaces or the first 10 characters of a string.
+func (r *Runtime) jsonGap(space Value) string {
+	if o, ok := space.(*Object); ok {
+		switch o.class {
+		case "Number":
+			space = Number(r.toNumber(o))
+		case "String":
+			space = String(r.toString(o))
+		}
+	}
+	switch s := space.(type) {
+	case Number:
+		n := math.Min(10, r.toIntegerOrInfinity(s))
+		if n < 1 {
+			return ""
+		}
+		return strings.Repeat(" ", int(n))
+	case String:
+		if stringLength(string(s)) > 10 {
+			return substring(string(s), 0, 10)
+		}
+		return string(s)
+	}
+	return ""
+}
+
+// property serializes the property of holder with key, and reports false
+// when it has no JSON representation, as undefined and functions do not.
+//
+// https://262.ecma-international.org/#sec-serializejsonproperty
+func (s *jsonSerializer) property(key propertyKey, holder *Object) (string, bool) {
+	r := s.r
+	v := holder.get(key, holder)
+	if o, ok := v.(*Object); ok {
+		if toJSON := o.get(stringKey("toJSON"), o); isCallable(toJSON) {
+			v = r.callFunction(toJSON, v, key.value())
+		}
+	}
+	if s.replacer != nil {
+		v = s.replacer.call(holder, []Value{key.value(), v})
+	}
+	if o, ok := v.(*Object); ok {
+		switch o.class {
+		case "Number":
+			v = Number(r.toNumber(o))
+		case "String":
+			v = String(r.toString(o))
+		case "Boolean":
+			if b, ok := o.internal.(Bool); ok {
+				v = b
+			}
+		}
+	}
+
+	switch x := v.(type) {
+	case Bool:
+		return x.String(), true
+	case String:
+		return quoteJSONString(string(x)), true
+	case Number:
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return "null", true
+		}
+		return numberToString(float64(x)), true
+	case *Object:
+		if x.call != nil {
+			return "", false
+		}
+		if x.array {
+			return s.array(x), true
+		}
+		return s.object(x), true
+	}
+	if v == Null {
+		return "null", true
+	}
+	return "", false
+}
+
+// enter pushes o on the stack of the objects being serialized, failing when
+// it is already there.
+func (s *jsonSerializer) enter(o *Object) {
+	for _, parent := range s.stack {
+		if parent == o {
+			panic(s.r.newTypeError("Converting circular structure to JSON"))
+		}
+	}
+	s.stack = append(s.stack, o)
+	s.indent += s.gap
+}
+
+func (s *jsonSerializer) leave(stepback string) {
+	s.stack = s.stack[:len(s.stack)-1]
+	s.indent = stepback
+}
+
+// join encloses the serialized members between open and close, one per
+// line when there is a gap.
+func (s *jsonSerializer) join(members []string, open, close, stepback string) string {
+	if len(members) == 0 {
+		return open + close
+	}
+	if s.gap == "" {
+		return open + strings.Join(members, ",") + close
+	}
+	separator := ",\n" + s.indent
+	return open + "\n" + s.indent + strings.Join(members, separator) + "\n" + stepback + close
+}
+
+// https://262.ecma-international.org/#sec-serializejsonobject
+func (s *jsonSerializer) object(o *Object) string {
+	stepback := s.indent
+	s.enter(o)
+	defer s.leave(stepback)
+	keys := s.propertyList
+	if keys == nil {
+		for _, k := range s.r.enumerableOwnProperties(o, "keys") {
+			keys = append(keys, stringKey(string(k.(String))))
+		}
+	}
+	var members []string
+	for _, key := range keys {
+		str, ok := s.property(key, o)
+		if !ok {
+			continue
+		}
+		member := quoteJSONString(key.name) + ":"
+		if s.gap != "" {
+			member += " "
+		}
+		members = append(members, member+str)
+	}
+	return s.join(members, "{", "}", stepback)
+}
+
+// https://262.ecma-international.org/#sec-serializejsonarray
+func (s *jsonSerializer) array(o *Object) string {
+	stepback := s.indent
+	s.enter(o)
+	defer s.leave(stepback)
+	n := s.r.lengthOfArrayLike(o)
+	members := make([]string, 0, n)
+	for i := int64(0); i < n; i++ {
+		str, ok := s.property(elementKey(i), o)
+		if !ok {
+			str = "null"
+		}
+		members = append(members, str)
+	}
+	return s.join(members, "[", "]", stepback)
+}
+
+// quoteJSONString returns s as a JSON string literal.
+//
+// https://262.ecma-international.org/#sec-quotejsonstring
+func quoteJSONString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		ch, width := decodeRune(s[i:])
+		i += width
+		switch ch {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if ch < 0x20 || utf16.IsSurrogate(ch) {
+				// control characters and lone surrogates are escaped
+				b.WriteString(`\u`)
+				for shift := 12; shift >= 0; shift -= 4 {
+					b.WriteByte("0123456789abcdef"[ch>>shift&0xf])
+				}
+			} else {
+				b.WriteRune(ch)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
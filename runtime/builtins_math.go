@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+)
+
+// ///////
+// Math //
+// ///////
+
+func (r *Runtime) initMath() {
+	m := r.newObject(r.objectPrototype)
+	r.setGlobal("Math", m)
+	setToStringTag(m, "Math")
+	for _, constant := range []struct {
+		name  string
+		value float64
+	}{
+		{"E", math.E}, {"LN10", math.Ln10}, {"LN2", math.Ln2}, {"LOG10E", math.Log10E}, {"LOG2E", math.Log2E},
+		{"PI", math.Pi}, {"SQRT1_2", math.Sqrt2 / 2}, {"SQRT2", math.Sqrt2},
+	} {
+		m.setConstant(stringKey(constant.name), Number(constant.value))
+	}
+
+	// the functions of a number, whose results follow the special cases
+	// of the spec for NaN, infinities and signed zeros as those of the
+	// math package do
+	for _, fn := range []struct {
+		name string
+		f    func(float64) float64
+	}{
+		{"abs", math.Abs}, {"acos", math.Acos}, {"acosh", math.Acosh}, {"asin", math.Asin}, {"asinh", math.Asinh},
+		{"atan", math.Atan}, {"atanh", math.Atanh}, {"cbrt", math.Cbrt}, {"ceil", math.Ceil}, {"cos", math.Cos},
+		{"cosh", math.Cosh}, {"exp", math.Exp}, {"expm1", math.Expm1}, {"floor", math.Floor}, {"log", math.Log},
+		{"log1p", math.Log1p}, {"log10", math.Log10}, {"log2", math.Log2}, {"sin", math.Sin}, {"sinh", math.Sinh},
+		{"sqrt", math.Sqrt}, {"tan", math.Tan}, {"tanh", math.Tanh}, {"trunc", math.Trunc},
+		{"fround", func(x float64) float64 { return float64(float32(x)) }},
+		{"round", round},
+		{"sign", func(x float64) float64 {
+			switch {
+			case x > 0:
+				return 1
+			case x < 0:
+				return -1
+			}
+			return x
+		}},
+	} {
+		fn := fn
+		r.method(m, fn.name, 1, func(this Value, args []Value) Value {
+			return Number(fn.f(r.toNumber(arg(args, 0))))
+		})
+	}
+	r.method(m, "atan2", 2, func(this Value, args []Value) Value {
+		y := r.toNumber(arg(args, 0))
+		return Number(math.Atan2(y, r.toNumber(arg(args, 1))))
+	})
+	r.method(m, "pow", 2, func(this Value, args []Value) Value {
+		base := r.toNumber(arg(args, 0))
+		return Number(exponentiate(base, r.toNumber(arg(args, 1))))
+	})
+	r.method(m, "clz32", 1, func(this Value, args []Value) Value {
+		return Number(bits.LeadingZeros32(r.toUint32(arg(args, 0))))
+	})
+	r.method(m, "imul", 2, func(this Value, args []Value) Value {
+		a := r.toUint32(arg(args, 0))
+		return Number(int32(a * r.toUint32(arg(args, 1))))
+	})
+	r.method(m, "random", 0, func(this Value, args []Value) Value {
+		return Number(rand.Float64())
+	})
+	r.method(m, "hypot", 2, func(this Value, args []Value) Value {
+		// every argument is converted before any is looked at
+		numbers := r.toNumbers(args)
+		sum, compensation, scale := 0.0, 0.0, 0.0
+		for _, n := range numbers {
+			if math.IsInf(n, 0) {
+				return Number(math.Inf(1))
+			}
+			scale = math.Max(scale, math.Abs(n))
+		}
+		for _, n := range numbers {
+			if math.IsNaN(n) {
+				return Number(math.NaN())
+			}
+		}
+		if scale == 0 {
+			return Number(0)
+		}
+		// Kahan summation of the squares, scaled not to overflow
+		for _, n := range numbers {
+			term := (n/scale)*(n/scale) - compensation
+			next := sum + term
+			compensation = (next - sum) - term
+			sum = next
+		}
+		return Number(math.Sqrt(sum) * scale)
+	})
+	r.method(m, "max", 2, func(this Value, args []Value) Value {
+		result := math.Inf(-1)
+		for _, n := range r.toNumbers(args) {
+			if math.IsNaN(n) || math.IsNaN(result) {
+				result = math.NaN()
+			} else if n > result || n == 0 && result == 0 && !math.Signbit(n) {
+				result = n
+			}
+		}
+		return Number(result)
+	})
+	r.method(m, "min", 2, func(this Value, args []Value) Value {
+		result := math.Inf(1)
+		for _, n := range r.toNumbers(args) {
+			if math.IsNaN(n) || math.IsNaN(result) {
+				result = math.NaN()
+			} else if n < result || n == 0 && result == 0 && math.Signbit(n) {
+				result = n
+			}
+		}
+		return Number(result)
+	})
+}
+
+// toNumbers converts each of args to a number, in order.
+func (r *Runtime) toNumbers(args []Value) []float64 {
+	numbers := make([]float64, len(args))
+	for i, v := range args {
+		numbers[i] = r.toNumber(v)
+	}
+	return numbers
+}
+
+// round rounds x to the nearest integer, halves towards +∞, keeping the
+// sign of zeros as Math.round does.
+//
+// https://262.ecma-international.org/#sec-math.round
+func round(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) || x == math.Trunc(x) {
+		return x
+	}
+	if x < 0 && x >= -0.5 {
+		return math.Copysign(0, -1)
+	}
+	floor := math.Floor(x)
+	if x-floor >= 0.5 {
+		return floor + 1
+	}
+	return floor
+}
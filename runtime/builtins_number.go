@@ -0,0 +1,481 @@
+package runtime
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// /////////
+// Number //
+// /////////
+
+func (r *Runtime) initNumber() {
+	proto := r.newPrimitiveObject(Number(0), r.objectPrototype, "Number")
+	r.numberPrototype = proto
+	ctor := r.newNativeConstructor("Number", 1, func(this Value, args []Value) Value {
+		if len(args) == 0 {
+			return Number(0)
+		}
+		return Number(r.toNumeric(args[0]))
+	}, func(args []Value, newTarget *Object) *Object {
+		n := 0.0
+		if len(args) > 0 {
+			n = r.toNumeric(args[0])
+		}
+		return r.newPrimitiveObject(Number(n), r.prototypeFromConstructor(newTarget, proto), "Number")
+	}, proto)
+	r.setGlobal("Number", ctor)
+
+	for _, constant := range []struct {
+		name  string
+		value float64
+	}{
+		{"EPSILON", math.Nextafter(1, 2) - 1},
+		{"MAX_SAFE_INTEGER", maxSafeInteger},
+		{"MIN_SAFE_INTEGER", -maxSafeInteger},
+		{"MAX_VALUE", math.MaxFloat64},
+		{"MIN_VALUE", math.SmallestNonzeroFloat64},
+		{"NaN", math.NaN()},
+		{"POSITIVE_INFINITY", math.Inf(1)},
+		{"NEGATIVE_INFINITY", math.Inf(-1)},
+	} {
+		ctor.setConstant(stringKey(constant.name), Number(constant.value))
+	}
+	r.method(ctor, "isFinite", 1, func(this Value, args []Value) Value {
+		n, ok := arg(args, 0).(Number)
+		return Bool(ok && !math.IsNaN(float64(n)) && !math.IsInf(float64(n), 0))
+	})
+	r.method(ctor, "isNaN", 1, func(this Value, args []Value) Value {
+		n, ok := arg(args, 0).(Number)
+		return Bool(ok && math.IsNaN(float64(n)))
+	})
+	r.method(ctor, "isInteger", 1, func(this Value, args []Value) Value {
+		n, ok := arg(args, 0).(Number)
+		return Bool(ok && isIntegral(float64(n)))
+	})
+	r.method(ctor, "isSafeInteger", 1, func(this Value, args []Value) Value {
+		n, ok := arg(args, 0).(Number)
+		return Bool(ok && isIntegral(float64(n)) && math.Abs(float64(n)) <= maxSafeInteger)
+	})
+	// Number.parseFloat and Number.parseInt are the global functions
+	parseFloat := r.newNativeFunction("parseFloat", 1, func(this Value, args []Value) Value {
+		return Number(parseFloatPrefix(r.toString(arg(args, 0))))
+	})
+	ctor.setHidden(stringKey("parseFloat"), parseFloat)
+	r.setGlobal("parseFloat", parseFloat)
+	parseInt := r.newNativeFunction("parseInt", 2, func(this Value, args []Value) Value {
+		s := r.toString(arg(args, 0))
+		return Number(parseIntPrefix(s, int(r.toInt32(arg(args, 1)))))
+	})
+	ctor.setHidden(stringKey("parseInt"), parseInt)
+	r.setGlobal("parseInt", parseInt)
+	r.method(r.global, "isNaN", 1, func(this Value, args []Value) Value {
+		return Bool(math.IsNaN(r.toNumber(arg(args, 0))))
+	})
+	r.method(r.global, "isFinite", 1, func(this Value, args []Value) Value {
+		n := r.toNumber(arg(args, 0))
+		return Bool(!math.IsNaN(n) && !math.IsInf(n, 0))
+	})
+
+	thisNumberValue := func(v Value) float64 {
+		switch v := v.(type) {
+		case Number:
+			return float64(v)
+		case *Object:
+			if n, ok := v.internal.(Number); ok && v.class == "Number" {
+				return float64(n)
+			}
+		}
+		panic(r.newTypeError("Number.prototype.valueOf requires that 'this' be a Number"))
+	}
+	r.method(proto, "toString", 1, func(this Value, args []Value) Value {
+		n := thisNumberValue(this)
+		radix := 10.0
+		if v := arg(args, 0); v != Undefined {
+			radix = r.toIntegerOrInfinity(v)
+		}
+		if radix < 2 || radix > 36 {
+			panic(r.newRangeError("toString() radix must be between 2 and 36"))
+		}
+		if radix == 10 {
+			return String(numberToString(n))
+		}
+		return String(numberToRadixString(n, int(radix)))
+	})
+	r.method(proto, "toLocaleString", 0, func(this Value, args []Value) Value {
+		return String(numberToString(thisNumberValue(this)))
+	})
+	r.method(proto, "valueOf", 0, func(this Value, args []Value) Value {
+		return Number(thisNumberValue(this))
+	})
+	r.method(proto, "toFixed", 1, func(this Value, args []Value) Value {
+		x := thisNumberValue(this)
+		f := r.toIntegerOrInfinity(arg(args, 0))
+		if f < 0 || f > 100 {
+			panic(r.newRangeError("toFixed() digits argument must be between 0 and 100"))
+		}
+		if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= 1e21 {
+			return String(numberToString(x))
+		}
+		return String(toFixed(x, int(f)))
+	})
+	r.method(proto, "toExponential", 1, func(this Value, args []Value) Value {
+		x := thisNumberValue(this)
+		f := r.toIntegerOrInfinity(arg(args, 0))
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return String(numberToString(x))
+		}
+		if f < 0 || f > 100 {
+			panic(r.newRangeError("toExponential() argument must be between 0 and 100"))
+		}
+		if arg(args, 0) == Undefined {
+			return String(toExponential(x, -1))
+		}
+		return String(toExponential(x, int(f)))
+	})
+	r.method(proto, "toPrecision", 1, func(this Value, args []Value) Value {
+		x := thisNumberValue(this)
+		if arg(args, 0) == Undefined {
+			return String(numberToString(x))
+		}
+		p := r.toIntegerOrInfinity(arg(args, 0))
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return String(numberToString(x))
+		}
+		if p < 1 || p > 100 {
+			panic(r.newRangeError("toPrecision() argument must be between 1 and 100"))
+		}
+		return String(toPrecision(x, int(p)))
+	})
+}
+
+// isIntegral reports whether n is a finite integral number.
+func isIntegral(n float64) bool {
+	return !math.IsInf(n, 0) && n == math.Trunc(n)
+}
+
+// ////////////////////
+// Number formatting //
+// ////////////////////
+
+// toFixed formats the finite x, less than 10^21 in magnitude, with f digits
+// after the decimal point.
+//
+// https://262.ecma-international.org/#sec-number.prototype.tofixed
+func toFixed(x float64, f int) string {
+	sign := ""
+	if x < 0 {
+		sign, x = "-", -x
+	}
+	digits := roundedDigits(x, f)
+	if f == 0 {
+		return sign + digits
+	}
+	if len(digits) <= f {
+		digits = strings.Repeat("0", f+1-len(digits)) + digits
+	}
+	return sign + digits[:len(digits)-f] + "." + digits[len(digits)-f:]
+}
+
+// toExponential formats the finite x in exponential notation with f digits
+// after the decimal point, or as many as it takes to tell x apart when f is
+// negative.
+//
+// https://262.ecma-international.org/#sec-number.prototype.toexponential
+func toExponential(x float64, f int) string {
+	sign := ""
+	if x < 0 {
+		sign, x = "-", -x
+	}
+	var digits string
+	var e int
+	switch {
+	case x == 0:
+		digits = strings.Repeat("0", abs(f)+1)
+		if f < 0 {
+			digits = "0"
+		}
+	case f < 0:
+		digits, e = shortestDigits(x)
+	default:
+		digits, e = exponentDigits(x, f+1)
+	}
+	return sign + exponentialNotation(digits, e)
+}
+
+// toPrecision formats the finite x with p significant digits, in
+// exponential notation when its exponent is below -6 or at least p.
+//
+// https://262.ecma-international.org/#sec-number.prototype.toprecision
+func toPrecision(x float64, p int) string {
+	sign := ""
+	if x < 0 {
+		sign, x = "-", -x
+	}
+	digits, e := strings.Repeat("0", p), 0
+	if x != 0 {
+		digits, e = exponentDigits(x, p)
+	}
+	switch {
+	case e < -6 || e >= p:
+		return sign + exponentialNotation(digits, e)
+	case e == p-1:
+		return sign + digits
+	case e >= 0:
+		return sign + digits[:e+1] + "." + digits[e+1:]
+	}
+	return sign + "0." + strings.Repeat("0", -(e+1)) + digits
+}
+
+// exponentialNotation formats the digits d1d2... of the number d1.d2... ×
+// 10^e.
+func exponentialNotation(digits string, e int) string {
+	s := digits[:1]
+	if len(digits) > 1 {
+		s += "." + digits[1:]
+	}
+	if e < 0 {
+		return s + "e-" + strconv.Itoa(-e)
+	}
+	return s + "e+" + strconv.Itoa(e)
+}
+
+// shortestDigits returns the fewest digits that tell apart the positive x,
+// and the exponent of its exponential notation.
+func shortestDigits(x float64) (string, int) {
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(x, 'e', -1, 64), "e")
+	e, _ := strconv.Atoi(exponent)
+	return strings.Replace(mantissa, ".", "", 1), e
+}
+
+// exponentDigits returns the p digits of the positive x rounded to p
+// significant digits, and the exponent of its exponential notation.
+func exponentDigits(x float64, p int) (string, int) {
+	_, e := shortestDigits(x)
+	for {
+		digits := roundedDigits(x, p-1-e)
+		switch {
+		case len(digits) > p:
+			e++
+		case len(digits) < p:
+			e--
+		default:
+			return digits, e
+		}
+	}
+}
+
+// roundedDigits returns the decimal digits of the integer nearest to the
+// positive x × 10^scale, the larger one when two are as near, as the
+// Number.prototype methods round rather than to even.
+func roundedDigits(x float64, scale int) string {
+	q := new(big.Rat).SetFloat64(x)
+	power := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(scale))), nil))
+	if scale >= 0 {
+		q.Mul(q, power)
+	} else {
+		q.Quo(q, power)
+	}
+	q.Add(q, big.NewRat(1, 2))
+	return new(big.Int).Quo(q.Num(), q.Denom()).String()
+}
+
+// numberToRadixString returns n written in radix, with as many fractional
+// digits as it takes to tell n apart from the neighbouring numbers, as V8
+// does.
+//
+// https://262.ecma-international.org/#sec-numeric-types-number-tostring
+func numberToRadixString(n float64, radix int) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	case n < 0:
+		return "-" + numberToRadixString(-n, radix)
+	}
+	const chars = "0123456789abcdefghijklmnopqrstuvwxyz"
+	base := float64(radix)
+	integer := math.Floor(n)
+	fraction := n - integer
+	// half the distance to the next number, below which digits do not
+	// tell n apart
+	delta := math.Max(0.5*(math.Nextafter(n, math.Inf(1))-n), math.SmallestNonzeroFloat64)
+
+	var fractionDigits []byte
+	for fraction >= delta {
+		fraction *= base
+		delta *= base
+		digit := int(fraction)
+		fractionDigits = append(fractionDigits, chars[digit])
+		fraction -= float64(digit)
+		if (fraction > 0.5 || fraction == 0.5 && digit&1 != 0) && fraction+delta > 1 {
+			// round up, carrying into the integer part
+			for {
+				last := len(fractionDigits) - 1
+				if last < 0 {
+					integer++
+					break
+				}
+				d := digitValue(rune(fractionDigits[last]))
+				fractionDigits = fractionDigits[:last]
+				if d+1 < radix {
+					fractionDigits = append(fractionDigits, chars[d+1])
+					break
+				}
+			}
+			break
+		}
+	}
+
+	var integerDigits []byte
+	// the digits of integers past 2^53 are not significant
+	for integer/base >= 1<<53 {
+		integer /= base
+		integerDigits = append(integerDigits, '0')
+	}
+	for {
+		digit := math.Mod(integer, base)
+		integerDigits = append(integerDigits, chars[int(digit)])
+		integer = (integer - digit) / base
+		if integer <= 0 {
+			break
+		}
+	}
+	for i, j := 0, len(integerDigits)-1; i < j; i, j = i+1, j-1 {
+		integerDigits[i], integerDigits[j] = integerDigits[j], integerDigits[i]
+	}
+	if len(fractionDigits) == 0 {
+		return string(integerDigits)
+	}
+	return string(integerDigits) + "." + string(fractionDigits)
+}
+
+// parseFloatPrefix parses the longest prefix of s, after white space, that
+// is a StrDecimalLiteral, returning NaN when there is none.
+//
+// https://262.ecma-international.org/#sec-parsefloat-string
+func parseFloatPrefix(s string) float64 {
+	s = strings.TrimLeftFunc(s, isWhiteSpace)
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	if strings.HasPrefix(s[i:], "Infinity") {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	digits := 0
+	scan := func() {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	scan()
+	if i < len(s) && s[i] == '.' {
+		i++
+		scan()
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for i = j; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			}
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s[:i], "."), 64)
+	if err != nil && !math.IsInf(n, 0) {
+		return math.NaN()
+	}
+	return n
+}
+
+// parseIntPrefix parses the longest prefix of s, after white space, that is
+// an integer in radix, or in base 16 after 0x when radix is 0 or 16. It
+// returns NaN when there is none.
+//
+// https://262.ecma-international.org/#sec-parseint-string-radix
+func parseIntPrefix(s string, radix int) float64 {
+	s = strings.TrimLeftFunc(s, isWhiteSpace)
+	negative := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	stripPrefix := true
+	if radix != 0 {
+		if radix < 2 || radix > 36 {
+			return math.NaN()
+		}
+		stripPrefix = radix == 16
+	} else {
+		radix = 10
+	}
+	if stripPrefix && len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s, radix = s[2:], 16
+	}
+	end := 0
+	for end < len(s) && digitValue(rune(s[end])) < radix {
+		end++
+	}
+	if end == 0 {
+		return math.NaN()
+	}
+	var n float64
+	if radix == 10 {
+		n, _ = strconv.ParseFloat(s[:end], 64)
+	} else {
+		n = parseInteger(s[:end], radix)
+	}
+	if negative {
+		return -n
+	}
+	return n
+}
+
+// //////////
+// Boolean //
+// //////////
+
+func (r *Runtime) initBoolean() {
+	proto := r.newPrimitiveObject(Bool(false), r.objectPrototype, "Boolean")
+	r.booleanPrototype = proto
+	ctor := r.newNativeConstructor("Boolean", 1, func(this Value, args []Value) Value {
+		return Bool(toBoolean(arg(args, 0)))
+	}, func(args []Value, newTarget *Object) *Object {
+		b := Bool(toBoolean(arg(args, 0)))
+		return r.newPrimitiveObject(b, r.prototypeFromConstructor(newTarget, proto), "Boolean")
+	}, proto)
+	r.setGlobal("Boolean", ctor)
+
+	thisBooleanValue := func(v Value) Bool {
+		switch v := v.(type) {
+		case Bool:
+			return v
+		case *Object:
+			if b, ok := v.internal.(Bool); ok && v.class == "Boolean" {
+				return b
+			}
+		}
+		panic(r.newTypeError("Boolean.prototype.valueOf requires that 'this' be a Boolean"))
+	}
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		return String(thisBooleanValue(this).String())
+	})
+	r.method(proto, "valueOf", 0, func(this Value, args []Value) Value {
+		return thisBooleanValue(this)
+	})
+}
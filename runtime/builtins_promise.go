@@ -0,0 +1,242 @@
+package runtime
+
+// //////////
+// Promise //
+// //////////
+
+func (r *Runtime) initPromise() {
+	proto := r.newObject(r.objectPrototype)
+	r.promisePrototype = proto
+	construct := func(args []Value, newTarget *Object) *Object {
+		executor := arg(args, 0)
+		if !isCallable(executor) {
+			panic(r.newTypeError("Promise resolver %s is not a function", r.describe(executor)))
+		}
+		o := r.newPromise(r.prototypeFromConstructor(newTarget, proto))
+		resolve, reject := r.createResolvingFunctions(o)
+		if _, exception := r.try(func() Value {
+			return r.callFunction(executor, Undefined, resolve, reject)
+		}); exception != nil {
+			reject.call(Undefined, []Value{exception.Value})
+		}
+		return o
+	}
+	ctor := r.newNativeConstructor("Promise", 1, func(this Value, args []Value) Value {
+		panic(r.newTypeError("Promise constructor cannot be invoked without 'new'"))
+	}, construct, proto)
+	r.promiseConstructor = ctor
+	r.setGlobal("Promise", ctor)
+	r.setSpecies(ctor)
+	setToStringTag(proto, "Promise")
+
+	// https://262.ecma-international.org/#sec-promise.prototype.then
+	r.method(proto, "then", 2, func(this Value, args []Value) Value {
+		if _, ok := thisPromise(this); !ok {
+			panic(r.newTypeError("Method Promise.prototype.then called on incompatible receiver %s", r.describe(this)))
+		}
+		o := this.(*Object)
+		capability := r.newPromiseCapability(r.speciesConstructor(o, ctor))
+		return r.performPromiseThen(o, arg(args, 0), arg(args, 1), capability)
+	})
+	r.method(proto, "catch", 1, func(this Value, args []Value) Value {
+		return r.invoke(this, "then", Undefined, arg(args, 0))
+	})
+	// https://262.ecma-international.org/#sec-promise.prototype.finally
+	r.method(proto, "finally", 1, func(this Value, args []Value) Value {
+		o, ok := this.(*Object)
+		if !ok {
+			panic(r.newTypeError("Method Promise.prototype.finally called on incompatible receiver %s", r.describe(this)))
+		}
+		c := r.speciesConstructor(o, ctor)
+		onFinally := arg(args, 0)
+		if !isCallable(onFinally) {
+			return r.invoke(o, "then", onFinally, onFinally)
+		}
+		thenFinally := r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+			value := arg(args, 0)
+			p := r.promiseResolve(c, r.callFunction(onFinally, Undefined))
+			return r.invoke(p, "then", r.newNativeFunction("", 0, func(Value, []Value) Value {
+				return value
+			}))
+		})
+		catchFinally := r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+			reason := arg(args, 0)
+			p := r.promiseResolve(c, r.callFunction(onFinally, Undefined))
+			return r.invoke(p, "then", r.newNativeFunction("", 0, func(Value, []Value) Value {
+				panic(r.throw(reason))
+			}))
+		})
+		return r.invoke(o, "then", thenFinally, catchFinally)
+	})
+
+	r.method(ctor, "resolve", 1, func(this Value, args []Value) Value {
+		c, ok := this.(*Object)
+		if !ok {
+			panic(r.newTypeError("PromiseResolve called on non-object"))
+		}
+		return r.promiseResolve(c, arg(args, 0))
+	})
+	r.method(ctor, "reject", 1, func(this Value, args []Value) Value {
+		capability := r.newPromiseCapability(this)
+		r.callFunction(capability.reject, Undefined, arg(args, 0))
+		return capability.promise
+	})
+	r.method(ctor, "withResolvers", 0, func(this Value, args []Value) Value {
+		capability := r.newPromiseCapability(this)
+		o := r.newObject(r.objectPrototype)
+		o.setProperty("promise", capability.promise)
+		o.setProperty("resolve", capability.resolve)
+		o.setProperty("reject", capability.reject)
+		return o
+	})
+
+	// https://262.ecma-international.org/#sec-promise.all
+	r.method(ctor, "all", 1, func(this Value, args []Value) Value {
+		return r.promiseCombinator(this, arg(args, 0), func(c *combinator, index int) (onFulfilled, onRejected Value) {
+			return c.element(index, func(x Value) {
+				c.values[index] = x
+			}), c.capability.reject
+		}, func(c *combinator) {
+			r.callFunction(c.capability.resolve, Undefined, r.newArray(c.values...))
+		})
+	})
+	// https://262.ecma-international.org/#sec-promise.allsettled
+	r.method(ctor, "allSettled", 1, func(this Value, args []Value) Value {
+		settled := func(status, key string, x Value) *Object {
+			o := r.newObject(r.objectPrototype)
+			o.setProperty("status", String(status))
+			o.setProperty(key, x)
+			return o
+		}
+		return r.promiseCombinator(this, arg(args, 0), func(c *combinator, index int) (onFulfilled, onRejected Value) {
+			// the two functions of an element settle it once between them
+			element := c.element(index, func(x Value) {
+				c.values[index] = x
+			})
+			onFulfilled = r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+				return element.call(this, []Value{settled("fulfilled", "value", arg(args, 0))})
+			})
+			onRejected = r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+				return element.call(this, []Value{settled("rejected", "reason", arg(args, 0))})
+			})
+			return onFulfilled, onRejected
+		}, func(c *combinator) {
+			r.callFunction(c.capability.resolve, Undefined, r.newArray(c.values...))
+		})
+	})
+	// https://262.ecma-international.org/#sec-promise.any
+	r.method(ctor, "any", 1, func(this Value, args []Value) Value {
+		return r.promiseCombinator(this, arg(args, 0), func(c *combinator, index int) (onFulfilled, onRejected Value) {
+			return c.capability.resolve, c.element(index, func(x Value) {
+				c.values[index] = x
+			})
+		}, func(c *combinator) {
+			err := r.newErrorObject(r.errorPrototypes["AggregateError"], "All promises were rejected")
+			err.setHidden(stringKey("errors"), r.newArray(c.values...))
+			r.callFunction(c.capability.reject, Undefined, err)
+		})
+	})
+	// https://262.ecma-international.org/#sec-promise.race
+	r.method(ctor, "race", 1, func(this Value, args []Value) Value {
+		return r.promiseCombinator(this, arg(args, 0), func(c *combinator, index int) (onFulfilled, onRejected Value) {
+			return c.capability.resolve, c.capability.reject
+		}, nil)
+	})
+}
+
+// speciesConstructor returns the @@species constructor of the constructor
+// of o, fallback when it has none.
+//
+// https://262.ecma-international.org/#sec-speciesconstructor
+func (r *Runtime) speciesConstructor(o *Object, fallback *Object) *Object {
+	c := o.get(stringKey("constructor"), o)
+	if c == Undefined {
+		return fallback
+	}
+	ctor, ok := c.(*Object)
+	if !ok {
+		panic(r.newTypeError("The .constructor property is not an object"))
+	}
+	species := ctor.get(symbolKey(SymbolSpecies), ctor)
+	if isNullish(species) {
+		return fallback
+	}
+	if !isConstructor(species) {
+		panic(r.newTypeError("object.constructor[Symbol.species] is not a constructor"))
+	}
+	return species.(*Object)
+}
+
+// invoke calls the method name of v with args.
+//
+// https://262.ecma-international.org/#sec-invoke
+func (r *Runtime) invoke(v Value, name string, args ...Value) Value {
+	return r.callFunction(r.getV(v, stringKey(name)), v, args...)
+}
+
+// combinator is the state of Promise.all, allSettled, any or race: the
+// promise they return, and the values its elements settle with.
+type combinator struct {
+	capability *promiseCapability
+	values     []Value
+	remaining  int
+	done       func(c *combinator)
+}
+
+// element returns the function settling the element at index of the
+// combinator with set, once, and the combinator once the last one is.
+//
+// https://262.ecma-international.org/#sec-promise.all-resolve-element-functions
+func (c *combinator) element(index int, set func(x Value)) *Object {
+	r := c.capability.promise.runtime
+	alreadyCalled := false
+	return r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+		if alreadyCalled {
+			return Undefined
+		}
+		alreadyCalled = true
+		set(arg(args, 0))
+		c.remaining--
+		if c.remaining == 0 {
+			c.done(c)
+		}
+		return Undefined
+	})
+}
+
+// promiseCombinator runs the steps shared by Promise.all, allSettled, any
+// and race with the constructor this, on the values of iterable: handlers
+// returns the functions handling the settlement of each, and done runs once
+// all of them have settled, when not nil.
+//
+// https://262.ecma-international.org/#sec-performpromiseall
+func (r *Runtime) promiseCombinator(this Value, iterable Value, handlers func(c *combinator, index int) (onFulfilled, onRejected Value), done func(c *combinator)) Value {
+	capability := r.newPromiseCapability(this)
+	c := &combinator{capability: capability, remaining: 1, done: done}
+	if _, exception := r.try(func() Value {
+		ctor := this.(*Object)
+		resolve := ctor.get(stringKey("resolve"), ctor)
+		if !isCallable(resolve) {
+			panic(r.newTypeError("Promise resolve or reject function is not callable"))
+		}
+		index := 0
+		r.iterate(iterable, func(v Value) bool {
+			c.values = append(c.values, Undefined)
+			next := r.callFunction(resolve, ctor, v)
+			c.remaining++
+			onFulfilled, onRejected := handlers(c, index)
+			r.invoke(next, "then", onFulfilled, onRejected)
+			index++
+			return true
+		})
+		return Undefined
+	}); exception != nil {
+		r.callFunction(capability.reject, Undefined, exception.Value)
+		return capability.promise
+	}
+	c.remaining--
+	if c.remaining == 0 && done != nil {
+		done(c)
+	}
+	return capability.promise
+}
@@ -0,0 +1,388 @@
+package runtime
+
+import (
+	"math"
+	"strings"
+
+	"github.com/ruiconti/gojs/regexp"
+)
+
+// /////////
+// RegExp //
+// /////////
+
+// regexpObject is the state of a RegExp object: its pattern, compiled into
+// a matcher of code units.
+type regexpObject struct {
+	source     string
+	flags      string
+	matcher    *regexp.Matcher
+	groupNames []string
+}
+
+func (r *Runtime) initRegExp() {
+	proto := r.newObject(r.objectPrototype)
+	r.regexpPrototype = proto
+	construct := func(args []Value, newTarget *Object) *Object {
+		pattern, flags := "", ""
+		if v := arg(args, 0); v != Undefined {
+			if re, ok := v.(*Object); ok && re.class == "RegExp" {
+				pattern, flags = re.internal.(*regexpObject).source, re.internal.(*regexpObject).flags
+			} else {
+				pattern = r.toString(v)
+			}
+		}
+		if v := arg(args, 1); v != Undefined {
+			flags = r.toString(v)
+		}
+		if pattern == "" {
+			pattern = "(?:)"
+		}
+		return r.newRegExp(pattern, flags, r.prototypeFromConstructor(newTarget, proto))
+	}
+	ctor := r.newNativeConstructor("RegExp", 2, func(this Value, args []Value) Value {
+		return construct(args, nil)
+	}, construct, proto)
+	r.setGlobal("RegExp", ctor)
+	r.setSpecies(ctor)
+	r.regexpConstructor = ctor
+
+	thisRegExp := func(v Value) *regexpObject {
+		if o, ok := v.(*Object); ok && o.class == "RegExp" {
+			return o.internal.(*regexpObject)
+		}
+		panic(r.newTypeError("%s is not a RegExp", r.describe(v)))
+	}
+	source := r.newNativeFunction("get source", 0, func(this Value, args []Value) Value {
+		if this == Value(proto) {
+			return String("(?:)")
+		}
+		return String(thisRegExp(this).source)
+	})
+	proto.setAccessor(stringKey("source"), source, nil)
+	flags := r.newNativeFunction("get flags", 0, func(this Value, args []Value) Value {
+		if this == Value(proto) {
+			return String("")
+		}
+		return String(thisRegExp(this).flags)
+	})
+	proto.setAccessor(stringKey("flags"), flags, nil)
+	for _, flag := range []struct {
+		name string
+		ch   string
+	}{
+		{"hasIndices", "d"}, {"global", "g"}, {"ignoreCase", "i"}, {"multiline", "m"},
+		{"dotAll", "s"}, {"unicode", "u"}, {"unicodeSets", "v"}, {"sticky", "y"},
+	} {
+		flag := flag
+		getter := r.newNativeFunction("get "+flag.name, 0, func(this Value, args []Value) Value {
+			if this == Value(proto) {
+				return Undefined
+			}
+			return Bool(strings.Contains(thisRegExp(this).flags, flag.ch))
+		})
+		proto.setAccessor(stringKey(flag.name), getter, nil)
+	}
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		o, ok := this.(*Object)
+		if !ok {
+			panic(r.newTypeError("RegExp.prototype.toString called on %s", r.describe(this)))
+		}
+		return String("/" + r.toString(o.get(stringKey("source"), o)) + "/" + r.toString(o.get(stringKey("flags"), o)))
+	})
+	r.method(proto, "exec", 1, func(this Value, args []Value) Value {
+		thisRegExp(this)
+		return r.regexpBuiltinExec(this.(*Object), r.toString(arg(args, 0)))
+	})
+	r.method(proto, "test", 1, func(this Value, args []Value) Value {
+		return Bool(r.regexpExec(r.thisObject(this, "RegExp.prototype.test"), r.toString(arg(args, 0))) != Null)
+	})
+	replace := r.newNativeFunction("[Symbol.replace]", 2, func(this Value, args []Value) Value {
+		return String(r.regexpReplace(r.thisObject(this, "RegExp.prototype[Symbol.replace]"), r.toString(arg(args, 0)), arg(args, 1)))
+	})
+	proto.setHidden(symbolKey(SymbolReplace), replace)
+	split := r.newNativeFunction("[Symbol.split]", 2, func(this Value, args []Value) Value {
+		return r.regexpSplit(r.thisObject(this, "RegExp.prototype[Symbol.split]"), r.toString(arg(args, 0)), arg(args, 1))
+	})
+	proto.setHidden(symbolKey(SymbolSplit), split)
+}
+
+// thisObject returns this, the receiver of the method name, failing if it is
+// not an object.
+func (r *Runtime) thisObject(this Value, name string) *Object {
+	o, ok := this.(*Object)
+	if !ok {
+		panic(r.newTypeError("%s called on %s", name, r.describe(this)))
+	}
+	return o
+}
+
+// regexpExec returns the match of the exec method of rx in s, an array or
+// null.
+//
+// https://262.ecma-international.org/#sec-regexpexec
+func (r *Runtime) regexpExec(rx *Object, s string) Value {
+	if exec, ok := rx.get(stringKey("exec"), rx).(*Object); ok && exec.call != nil {
+		result := exec.call(rx, []Value{String(s)})
+		if _, ok := result.(*Object); !ok && result != Null {
+			panic(r.newTypeError("%s is not an object or null", r.describe(result)))
+		}
+		return result
+	}
+	if rx.class != "RegExp" {
+		panic(r.newTypeError("%s is not a RegExp", r.describe(rx)))
+	}
+	return r.regexpBuiltinExec(rx, s)
+}
+
+// regexpBuiltinExec matches the RegExp object rx in s, from its lastIndex
+// property on when global or sticky, and returns the match array or null.
+//
+// https://262.ecma-international.org/#sec-regexpbuiltinexec
+func (r *Runtime) regexpBuiltinExec(rx *Object, s string) Value {
+	re := rx.internal.(*regexpObject)
+	lastIndex := r.toLength(rx.get(stringKey("lastIndex"), rx))
+	global, sticky := strings.Contains(re.flags, "g"), strings.Contains(re.flags, "y")
+	fullUnicode := strings.ContainsAny(re.flags, "uv")
+	if !global && !sticky {
+		lastIndex = 0
+	}
+	units := toUTF16(s)
+	var captures []int
+	for {
+		if lastIndex > int64(len(units)) {
+			if global || sticky {
+				r.putV(rx, stringKey("lastIndex"), Number(0), true)
+			}
+			return Null
+		}
+		if captures = re.matcher.Match(units, int(lastIndex)); captures != nil {
+			break
+		}
+		if sticky {
+			r.putV(rx, stringKey("lastIndex"), Number(0), true)
+			return Null
+		}
+		lastIndex = advanceStringIndex(units, lastIndex, fullUnicode)
+	}
+	if global || sticky {
+		r.putV(rx, stringKey("lastIndex"), Number(captures[1]), true)
+	}
+
+	groups := make([]Value, len(captures)/2)
+	for i := range groups {
+		groups[i] = Undefined
+		if captures[2*i] >= 0 {
+			groups[i] = String(fromUTF16(units[captures[2*i]:captures[2*i+1]]))
+		}
+	}
+	a := r.newArray(groups...)
+	r.createDataProperty(a, stringKey("index"), Number(captures[0]))
+	r.createDataProperty(a, stringKey("input"), String(s))
+	named := r.namedGroups(re, groups, func(i int) Value { return groups[i] })
+	r.createDataProperty(a, stringKey("groups"), named)
+	if strings.Contains(re.flags, "d") {
+		// https://262.ecma-international.org/#sec-makematchindicesindexpairarray
+		pairs := make([]Value, len(groups))
+		for i := range pairs {
+			pairs[i] = Undefined
+			if captures[2*i] >= 0 {
+				pairs[i] = r.newArray(Number(captures[2*i]), Number(captures[2*i+1]))
+			}
+		}
+		indices := r.newArray(pairs...)
+		r.createDataProperty(indices, stringKey("groups"), r.namedGroups(re, groups, func(i int) Value { return pairs[i] }))
+		r.createDataProperty(a, stringKey("indices"), indices)
+	}
+	return a
+}
+
+// namedGroups returns the groups object of a match, holding value(i) for the
+// capturing group i under its name, or undefined when no group is named. Of
+// the groups sharing a name, the one that participated in the match is kept.
+func (r *Runtime) namedGroups(re *regexpObject, groups []Value, value func(i int) Value) Value {
+	var named *Object
+	for i, name := range re.groupNames {
+		if name == "" {
+			continue
+		}
+		if named == nil {
+			named = r.newObject(nil)
+		}
+		if groups[i+1] != Undefined || !named.hasOwnProperty(stringKey(name)) {
+			r.createDataProperty(named, stringKey(name), value(i+1))
+		}
+	}
+	if named == nil {
+		return Undefined
+	}
+	return named
+}
+
+// advanceStringIndex returns the index after the character at index, a
+// surrogate pair when matching code points.
+//
+// https://262.ecma-international.org/#sec-advancestringindex
+func advanceStringIndex(units []uint16, index int64, fullUnicode bool) int64 {
+	if !fullUnicode || index+1 >= int64(len(units)) {
+		return index + 1
+	}
+	if isHighSurrogate(rune(units[index])) && isLowSurrogate(rune(units[index+1])) {
+		return index + 2
+	}
+	return index + 1
+}
+
+// regexpReplace replaces the first match of rx in s, or every match when rx
+// is global, with replacement, a template or a function returning it.
+//
+// https://262.ecma-international.org/#sec-regexp.prototype-@@replace
+func (r *Runtime) regexpReplace(rx *Object, s string, replacement Value) string {
+	fn, functional := replacement.(*Object)
+	functional = functional && fn.call != nil
+	template := ""
+	if !functional {
+		template = r.toString(replacement)
+	}
+	flags := r.toString(rx.get(stringKey("flags"), rx))
+	global := strings.Contains(flags, "g")
+	if global {
+		r.putV(rx, stringKey("lastIndex"), Number(0), true)
+	}
+
+	units := toUTF16(s)
+	var results []*Object
+	for {
+		result, ok := r.regexpExec(rx, s).(*Object)
+		if !ok {
+			break
+		}
+		results = append(results, result)
+		if !global {
+			break
+		}
+		if r.toString(result.get(elementKey(0), result)) == "" {
+			lastIndex := r.toLength(rx.get(stringKey("lastIndex"), rx))
+			next := advanceStringIndex(units, lastIndex, strings.ContainsAny(flags, "uv"))
+			r.putV(rx, stringKey("lastIndex"), Number(next), true)
+		}
+	}
+
+	var replaced []uint16
+	next := 0
+	for _, result := range results {
+		n := r.lengthOfArrayLike(result) - 1
+		matched := r.toString(result.get(elementKey(0), result))
+		position := int(math.Max(math.Min(r.toIntegerOrInfinity(result.get(stringKey("index"), result)), float64(len(units))), 0))
+		captures := make([]Value, 0, n)
+		for i := int64(1); i <= n; i++ {
+			capture := result.get(elementKey(i), result)
+			if capture != Undefined {
+				capture = String(r.toString(capture))
+			}
+			captures = append(captures, capture)
+		}
+		named := result.get(stringKey("groups"), result)
+		var substitution string
+		if functional {
+			args := append([]Value{String(matched)}, captures...)
+			args = append(args, Number(position), String(s))
+			if named != Undefined {
+				args = append(args, named)
+			}
+			substitution = r.toString(fn.call(Undefined, args))
+		} else {
+			if named != Undefined {
+				named = r.toObject(named)
+			}
+			substitution = r.getSubstitution(matched, units, position, captures, named, template)
+		}
+		if position >= next {
+			replaced = append(replaced, units[next:position]...)
+			replaced = append(replaced, toUTF16(substitution)...)
+			next = position + stringLength(matched)
+		}
+	}
+	if next >= len(units) {
+		return fromUTF16(replaced)
+	}
+	return fromUTF16(append(replaced, units[next:]...))
+}
+
+// regexpSplit splits s around the matches of rx, along with their captures,
+// into at most limit strings. It matches with a sticky copy of rx, made by
+// its @@species constructor.
+//
+// https://262.ecma-international.org/#sec-regexp.prototype-@@split
+func (r *Runtime) regexpSplit(rx *Object, s string, limit Value) Value {
+	c := r.speciesConstructor(rx, r.regexpConstructor)
+	flags := r.toString(rx.get(stringKey("flags"), rx))
+	fullUnicode := strings.ContainsAny(flags, "uv")
+	if !strings.Contains(flags, "y") {
+		flags += "y"
+	}
+	splitter := r.constructObject(c, []Value{rx, String(flags)}, nil)
+	lim := uint32(math.MaxUint32)
+	if limit != Undefined {
+		lim = r.toUint32(limit)
+	}
+	if lim == 0 {
+		return r.newArray()
+	}
+	if s == "" {
+		if r.regexpExec(splitter, s) != Null {
+			return r.newArray()
+		}
+		return r.newArray(String(s))
+	}
+
+	units := toUTF16(s)
+	var parts []Value
+	p := 0
+	for q := 0; q < len(units); {
+		r.putV(splitter, stringKey("lastIndex"), Number(q), true)
+		z, ok := r.regexpExec(splitter, s).(*Object)
+		if !ok {
+			q = int(advanceStringIndex(units, int64(q), fullUnicode))
+			continue
+		}
+		e := int(math.Min(float64(r.toLength(splitter.get(stringKey("lastIndex"), splitter))), float64(len(units))))
+		if e == p {
+			q = int(advanceStringIndex(units, int64(q), fullUnicode))
+			continue
+		}
+		parts = append(parts, String(fromUTF16(units[p:q])))
+		if uint32(len(parts)) == lim {
+			return r.newArray(parts...)
+		}
+		p = e
+		for i, n := int64(1), r.lengthOfArrayLike(z); i < n; i++ {
+			parts = append(parts, z.get(elementKey(i), z))
+			if uint32(len(parts)) == lim {
+				return r.newArray(parts...)
+			}
+		}
+		q = p
+	}
+	return r.newArray(append(parts, String(fromUTF16(units[p:])))...)
+}
+
+// newRegExp returns a RegExp object with pattern and flags, failing with a
+// SyntaxError if they do not parse.
+//
+// https://262.ecma-international.org/#sec-regexpcreate
+func (r *Runtime) newRegExp(pattern, flags string, proto *Object) *Object {
+	parsed, err := regexp.Parse(pattern, flags)
+	var matcher *regexp.Matcher
+	if err == nil {
+		matcher, err = regexp.Compile(parsed)
+	}
+	if err != nil {
+		panic(r.newSyntaxError("Invalid regular expression: /%s/%s: %v", pattern, flags, err))
+	}
+	o := r.newObject(proto)
+	o.class = "RegExp"
+	o.internal = &regexpObject{source: pattern, flags: parsed.Flags.String(), matcher: matcher, groupNames: parsed.GroupNames}
+	o.defineOwnProperty(stringKey("lastIndex"), dataDescriptor(Number(0), writable))
+	return o
+}
@@ -0,0 +1,594 @@
+package runtime
+
+import (
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// /////////
+// String //
+// /////////
+
+func (r *Runtime) initString() {
+	proto := r.newStringObject("", r.objectPrototype)
+	r.stringPrototype = proto
+	ctor := r.newNativeConstructor("String", 1, func(this Value, args []Value) Value {
+		if len(args) == 0 {
+			return String("")
+		}
+		if s, ok := args[0].(*Symbol); ok {
+			return String(s.String())
+		}
+		return String(r.toString(args[0]))
+	}, func(args []Value, newTarget *Object) *Object {
+		s := ""
+		if len(args) > 0 {
+			s = r.toString(args[0])
+		}
+		return r.newStringObject(String(s), r.prototypeFromConstructor(newTarget, proto))
+	}, proto)
+	r.setGlobal("String", ctor)
+
+	r.method(ctor, "fromCharCode", 1, func(this Value, args []Value) Value {
+		units := make([]uint16, len(args))
+		for i, v := range args {
+			units[i] = uint16(r.toUint32(v))
+		}
+		return String(fromUTF16(units))
+	})
+	r.method(ctor, "fromCodePoint", 1, func(this Value, args []Value) Value {
+		var s strings.Builder
+		for _, v := range args {
+			n := r.toNumber(v)
+			if n != math.Trunc(n) || n < 0 || n > 0x10ffff {
+				panic(r.newRangeError("Invalid code point %s", r.toString(v)))
+			}
+			s.Write(appendRune(nil, rune(n)))
+		}
+		return String(joinSurrogates(s.String()))
+	})
+	r.method(ctor, "raw", 1, func(this Value, args []Value) Value {
+		cooked := r.toObject(arg(args, 0))
+		raw := r.toObject(cooked.get(stringKey("raw"), cooked))
+		n := r.lengthOfArrayLike(raw)
+		var s strings.Builder
+		for i := int64(0); i < n; i++ {
+			s.WriteString(r.toString(raw.get(elementKey(i), raw)))
+			if i+1 < n && int(i)+1 < len(args) {
+				s.WriteString(r.toString(args[i+1]))
+			}
+		}
+		return String(joinSurrogates(s.String()))
+	})
+
+	thisStringValue := func(v Value) String {
+		switch v := v.(type) {
+		case String:
+			return v
+		case *Object:
+			if s, ok := v.internal.(String); ok && v.class == "String" {
+				return s
+			}
+		}
+		panic(r.newTypeError("String.prototype.valueOf requires that 'this' be a String"))
+	}
+	r.method(proto, "toString", 0, func(this Value, args []Value) Value {
+		return thisStringValue(this)
+	})
+	r.method(proto, "valueOf", 0, func(this Value, args []Value) Value {
+		return thisStringValue(this)
+	})
+	iterator := r.newNativeFunction("[Symbol.iterator]", 0, func(this Value, args []Value) Value {
+		if isNullish(this) {
+			panic(r.newTypeError("String.prototype[Symbol.iterator] called on %s", this))
+		}
+		s := r.toString(this)
+		return r.newNativeIterator(r.stringIteratorPrototype, "String Iterator", func() (Value, bool) {
+			if len(s) == 0 {
+				return nil, false
+			}
+			_, width := decodeRune(s)
+			ch := s[:width]
+			s = s[width:]
+			return String(ch), true
+		})
+	})
+	proto.setHidden(symbolKey(SymbolIterator), iterator)
+
+	// method defines a method of String.prototype, which converts this to a
+	// string
+	method := func(name string, length int, call func(s string, args []Value) Value) {
+		r.method(proto, name, length, func(this Value, args []Value) Value {
+			if isNullish(this) {
+				panic(r.newTypeError("String.prototype.%s called on null or undefined", name))
+			}
+			return call(r.toString(this), args)
+		})
+	}
+	method("at", 1, func(s string, args []Value) Value {
+		n := stringLength(s)
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		if i < 0 {
+			i += float64(n)
+		}
+		if i < 0 || i >= float64(n) {
+			return Undefined
+		}
+		return String(substring(s, int(i), int(i)+1))
+	})
+	method("charAt", 1, func(s string, args []Value) Value {
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		if i < 0 || i >= float64(stringLength(s)) {
+			return String("")
+		}
+		return String(substring(s, int(i), int(i)+1))
+	})
+	method("charCodeAt", 1, func(s string, args []Value) Value {
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		if i < 0 || i >= float64(stringLength(s)) {
+			return Number(math.NaN())
+		}
+		return Number(codeUnitAt(s, int(i)))
+	})
+	method("codePointAt", 1, func(s string, args []Value) Value {
+		i := r.toIntegerOrInfinity(arg(args, 0))
+		units := toUTF16(s)
+		if i < 0 || i >= float64(len(units)) {
+			return Undefined
+		}
+		first := units[int(i)]
+		if utf16.IsSurrogate(rune(first)) && int(i)+1 < len(units) {
+			if ch := utf16.DecodeRune(rune(first), rune(units[int(i)+1])); ch != 0xfffd {
+				return Number(ch)
+			}
+		}
+		return Number(first)
+	})
+	method("concat", 1, func(s string, args []Value) Value {
+		var b strings.Builder
+		b.WriteString(s)
+		for _, v := range args {
+			b.WriteString(r.toString(v))
+		}
+		return String(joinSurrogates(b.String()))
+	})
+	method("includes", 1, func(s string, args []Value) Value {
+		search := r.searchString(arg(args, 0), "includes")
+		start := r.stringPosition(arg(args, 1), stringLength(s), 0)
+		return Bool(stringIndexOf(s, search, start) >= 0)
+	})
+	method("startsWith", 1, func(s string, args []Value) Value {
+		search := r.searchString(arg(args, 0), "startsWith")
+		units, prefix := toUTF16(s), toUTF16(search)
+		start := r.stringPosition(arg(args, 1), len(units), 0)
+		return Bool(start+len(prefix) <= len(units) && equalUnits(units[start:start+len(prefix)], prefix))
+	})
+	method("endsWith", 1, func(s string, args []Value) Value {
+		search := r.searchString(arg(args, 0), "endsWith")
+		units, suffix := toUTF16(s), toUTF16(search)
+		end := r.stringPosition(arg(args, 1), len(units), len(units))
+		return Bool(end-len(suffix) >= 0 && equalUnits(units[end-len(suffix):end], suffix))
+	})
+	method("indexOf", 1, func(s string, args []Value) Value {
+		search := r.toString(arg(args, 0))
+		start := r.stringPosition(arg(args, 1), stringLength(s), 0)
+		return Number(stringIndexOf(s, search, start))
+	})
+	method("lastIndexOf", 1, func(s string, args []Value) Value {
+		search := r.toString(arg(args, 0))
+		units, target := toUTF16(s), toUTF16(search)
+		start := len(units)
+		if n := r.toNumber(arg(args, 1)); !math.IsNaN(n) {
+			start = int(math.Min(math.Max(math.Trunc(n), 0), float64(len(units))))
+		}
+		for i := int(math.Min(float64(start), float64(len(units)-len(target)))); i >= 0; i-- {
+			if equalUnits(units[i:i+len(target)], target) {
+				return Number(i)
+			}
+		}
+		return Number(-1)
+	})
+	method("localeCompare", 1, func(s string, args []Value) Value {
+		return Number(sign(compareStrings(s, r.toString(arg(args, 0)))))
+	})
+	for _, pad := range []struct {
+		name  string
+		start bool
+	}{{"padStart", true}, {"padEnd", false}} {
+		pad := pad
+		method(pad.name, 1, func(s string, args []Value) Value {
+			return String(r.pad(s, arg(args, 0), arg(args, 1), pad.start))
+		})
+	}
+	method("repeat", 1, func(s string, args []Value) Value {
+		n := r.toIntegerOrInfinity(arg(args, 0))
+		if n < 0 || math.IsInf(n, 1) {
+			panic(r.newRangeError("Invalid count value: %s", numberToString(n)))
+		}
+		if s == "" || n == 0 {
+			return String("")
+		}
+		if float64(len(s))*n > maxStringLength {
+			panic(r.newRangeError("Invalid string length"))
+		}
+		return String(joinSurrogates(strings.Repeat(s, int(n))))
+	})
+	method("slice", 2, func(s string, args []Value) Value {
+		n := int64(stringLength(s))
+		start := r.relativeIndex(arg(args, 0), n, 0)
+		end := r.relativeIndex(arg(args, 1), n, n)
+		if start >= end {
+			return String("")
+		}
+		return String(substring(s, int(start), int(end)))
+	})
+	method("substring", 2, func(s string, args []Value) Value {
+		n := stringLength(s)
+		start := r.stringPosition(arg(args, 0), n, 0)
+		end := r.stringPosition(arg(args, 1), n, n)
+		if start > end {
+			start, end = end, start
+		}
+		return String(substring(s, start, end))
+	})
+	// https://262.ecma-international.org/#sec-string.prototype.substr
+	method("substr", 2, func(s string, args []Value) Value {
+		n := int64(stringLength(s))
+		start := r.relativeIndex(arg(args, 0), n, 0)
+		length := float64(n)
+		if v := arg(args, 1); v != Undefined {
+			length = r.toIntegerOrInfinity(v)
+		}
+		end := int64(math.Min(float64(start)+math.Max(length, 0), float64(n)))
+		if start >= end {
+			return String("")
+		}
+		return String(substring(s, int(start), int(end)))
+	})
+	method("toLowerCase", 0, func(s string, args []Value) Value {
+		return String(toLower(s))
+	})
+	method("toLocaleLowerCase", 0, func(s string, args []Value) Value {
+		return String(toLower(s))
+	})
+	method("toUpperCase", 0, func(s string, args []Value) Value {
+		return String(toUpper(s))
+	})
+	method("toLocaleUpperCase", 0, func(s string, args []Value) Value {
+		return String(toUpper(s))
+	})
+	method("trim", 0, func(s string, args []Value) Value {
+		return String(strings.TrimFunc(s, isWhiteSpace))
+	})
+	method("trimStart", 0, func(s string, args []Value) Value {
+		return String(strings.TrimLeftFunc(s, isWhiteSpace))
+	})
+	method("trimEnd", 0, func(s string, args []Value) Value {
+		return String(strings.TrimRightFunc(s, isWhiteSpace))
+	})
+
+	// the methods taking patterns defer to the methods of the pattern
+	// object, as those of regular expressions, and search strings otherwise
+	r.method(proto, "split", 2, func(this Value, args []Value) Value {
+		if isNullish(this) {
+			panic(r.newTypeError("String.prototype.split called on null or undefined"))
+		}
+		separator, limit := arg(args, 0), arg(args, 1)
+		if !isNullish(separator) {
+			if splitter := r.getMethod(separator, symbolKey(SymbolSplit)); splitter != nil {
+				return splitter.call(separator, []Value{this, limit})
+			}
+		}
+		s := r.toString(this)
+		lim := uint32(math.MaxUint32)
+		if limit != Undefined {
+			lim = r.toUint32(limit)
+		}
+		sep := r.toString(separator)
+		if lim == 0 {
+			return r.newArray()
+		}
+		if separator == Undefined {
+			return r.newArray(String(s))
+		}
+		units := toUTF16(s)
+		var parts []Value
+		if sep == "" {
+			for i := 0; i < len(units) && uint32(len(parts)) < lim; i++ {
+				parts = append(parts, String(fromUTF16(units[i:i+1])))
+			}
+			return r.newArray(parts...)
+		}
+		target := toUTF16(sep)
+		start := 0
+		for i := 0; i+len(target) <= len(units); {
+			if !equalUnits(units[i:i+len(target)], target) {
+				i++
+				continue
+			}
+			parts = append(parts, String(fromUTF16(units[start:i])))
+			if uint32(len(parts)) == lim {
+				return r.newArray(parts...)
+			}
+			i += len(target)
+			start = i
+		}
+		return r.newArray(append(parts, String(fromUTF16(units[start:])))...)
+	})
+	for _, replace := range []struct {
+		name string
+		all  bool
+	}{{"replace", false}, {"replaceAll", true}} {
+		replace := replace
+		r.method(proto, replace.name, 2, func(this Value, args []Value) Value {
+			if isNullish(this) {
+				panic(r.newTypeError("String.prototype.%s called on null or undefined", replace.name))
+			}
+			pattern, replacement := arg(args, 0), arg(args, 1)
+			if !isNullish(pattern) {
+				if o, ok := pattern.(*Object); ok && replace.all && o.class == "RegExp" {
+					if flags := r.toString(o.get(stringKey("flags"), o)); !strings.Contains(flags, "g") {
+						panic(r.newTypeError("replaceAll must be called with a global RegExp"))
+					}
+				}
+				if replacer := r.getMethod(pattern, symbolKey(SymbolReplace)); replacer != nil {
+					return replacer.call(pattern, []Value{this, replacement})
+				}
+			}
+			s := r.toString(this)
+			search := r.toString(pattern)
+			fn, functional := replacement.(*Object)
+			functional = functional && fn.call != nil
+			template := ""
+			if !functional {
+				template = r.toString(replacement)
+			}
+
+			units, target := toUTF16(s), toUTF16(search)
+			var positions []int
+			for i := stringIndexOf(s, search, 0); i >= 0; {
+				positions = append(positions, i)
+				if !replace.all {
+					break
+				}
+				next := i + len(target)
+				if len(target) == 0 {
+					next++
+				}
+				if next > len(units) {
+					break
+				}
+				i = stringIndexOf(s, search, next)
+			}
+			var result []uint16
+			end := 0
+			for _, p := range positions {
+				result = append(result, units[end:p]...)
+				var replaced string
+				if functional {
+					replaced = r.toString(fn.call(Undefined, []Value{String(search), Number(p), String(s)}))
+				} else {
+					replaced = r.getSubstitution(search, units, p, nil, Undefined, template)
+				}
+				result = append(result, toUTF16(replaced)...)
+				end = p + len(target)
+			}
+			return String(fromUTF16(append(result, units[end:]...)))
+		})
+	}
+}
+
+// maxStringLength bounds the length of the strings built by repeating and
+// padding, as engines do.
+const maxStringLength = 1<<29 - 24
+
+// searchString converts the search string of the method named name to a
+// string, failing on regular expressions.
+func (r *Runtime) searchString(v Value, name string) string {
+	if o, ok := v.(*Object); ok {
+		isRegExp := o.class == "RegExp"
+		if matcher := o.get(symbolKey(SymbolMatch), o); matcher != Undefined {
+			isRegExp = toBoolean(matcher)
+		}
+		if isRegExp {
+			panic(r.newTypeError("First argument to String.prototype.%s must not be a regular expression", name))
+		}
+	}
+	return r.toString(v)
+}
+
+// stringPosition converts v to a position in a string of length n, clamped
+// to its bounds, and fallback when undefined.
+func (r *Runtime) stringPosition(v Value, n, fallback int) int {
+	if v == Undefined {
+		return fallback
+	}
+	return int(math.Min(math.Max(r.toIntegerOrInfinity(v), 0), float64(n)))
+}
+
+// stringIndexOf returns the index of the first occurrence of search in s at
+// or after the index start, in code units, or -1.
+//
+// https://262.ecma-international.org/#sec-stringindexof
+func stringIndexOf(s, search string, start int) int {
+	if isASCII(s) && isASCII(search) {
+		if start > len(s) {
+			return -1
+		}
+		if i := strings.Index(s[start:], search); i >= 0 {
+			return start + i
+		}
+		return -1
+	}
+	units, target := toUTF16(s), toUTF16(search)
+	for i := start; i+len(target) <= len(units); i++ {
+		if equalUnits(units[i:i+len(target)], target) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalUnits(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getSubstitution returns the replacement of matched, found at the index
+// position of the code units of a string, expanding the $ patterns of
+// template with the captures of the match and its groups object, undefined
+// when none is named.
+//
+// https://262.ecma-international.org/#sec-getsubstitution
+func (r *Runtime) getSubstitution(matched string, units []uint16, position int, captures []Value, namedCaptures Value, template string) string {
+	if !strings.Contains(template, "$") {
+		return template
+	}
+	var s strings.Builder
+	capture := func(v Value) {
+		if v != Undefined {
+			s.WriteString(r.toString(v))
+		}
+	}
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+		if ch != '$' || i+1 == len(template) {
+			s.WriteByte(ch)
+			continue
+		}
+		switch next := template[i+1]; {
+		case next == '$':
+			s.WriteByte('$')
+		case next == '&':
+			s.WriteString(matched)
+		case next == '`':
+			s.WriteString(fromUTF16(units[:position]))
+		case next == '\'':
+			if end := position + stringLength(matched); end < len(units) {
+				s.WriteString(fromUTF16(units[end:]))
+			}
+		case isDecimalDigit(next):
+			n := int(next - '0')
+			if i+2 < len(template) && isDecimalDigit(template[i+2]) {
+				if nn := n*10 + int(template[i+2]-'0'); nn >= 1 && nn <= len(captures) {
+					capture(captures[nn-1])
+					i += 2
+					continue
+				}
+			}
+			if n < 1 || n > len(captures) {
+				s.WriteByte(ch)
+				continue
+			}
+			capture(captures[n-1])
+		case next == '<':
+			end := strings.IndexByte(template[i+2:], '>')
+			if namedCaptures == Undefined || end < 0 {
+				s.WriteByte(ch)
+				continue
+			}
+			name := template[i+2 : i+2+end]
+			capture(namedCaptures.(*Object).get(stringKey(name), namedCaptures))
+			i += 2 + end
+			continue
+		default:
+			s.WriteByte(ch)
+			continue
+		}
+		i++
+	}
+	return s.String()
+}
+
+// isDecimalDigit reports whether ch is one of the digits 0 to 9.
+func isDecimalDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// pad pads s to maxLength with fill, a space when undefined, at its start
+// or its end.
+//
+// https://262.ecma-international.org/#sec-stringpad
+func (r *Runtime) pad(s string, maxLength, fill Value, start bool) string {
+	length := r.toLength(maxLength)
+	n := int64(stringLength(s))
+	if length <= n {
+		return s
+	}
+	filler := " "
+	if fill != Undefined {
+		filler = r.toString(fill)
+	}
+	if filler == "" {
+		return s
+	}
+	if length > maxStringLength {
+		panic(r.newRangeError("Invalid string length"))
+	}
+	units := toUTF16(filler)
+	padding := make([]uint16, 0, length-n)
+	for int64(len(padding)) < length-n {
+		padding = append(padding, units...)
+	}
+	fillString := fromUTF16(padding[:length-n])
+	if start {
+		return concatStrings(fillString, s)
+	}
+	return concatStrings(s, fillString)
+}
+
+// toLower returns s in lower case, keeping the dot above of İ that the
+// simple case mappings lose.
+func toLower(s string) string {
+	return mapSurrogateFree(s, func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "İ", "i\u0307"))
+	})
+}
+
+// toUpper returns s in upper case, with the expansion of ß that the simple
+// case mappings lack.
+func toUpper(s string) string {
+	return mapSurrogateFree(s, func(s string) string {
+		return strings.ReplaceAll(strings.ToUpper(s), "ß", "SS")
+	})
+}
+
+// mapSurrogateFree applies f to the parts of s between its lone surrogates,
+// which Go's case mappings would replace with U+FFFD as invalid UTF-8.
+func mapSurrogateFree(s string, f func(string) string) string {
+	if strings.IndexByte(s, 0xed) < 0 {
+		return f(s)
+	}
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(s); {
+		ch, width := decodeRune(s[i:])
+		if utf16.IsSurrogate(ch) {
+			b.WriteString(f(s[start:i]))
+			b.WriteString(s[i : i+width])
+			start = i + width
+		}
+		i += width
+	}
+	b.WriteString(f(s[start:]))
+	return b.String()
+}
+
+// sign returns -1, 0 or 1 as n is negative, zero or positive.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,191 @@
+package runtime
+
+import "testing"
+
+// builtinTests are scripts exercising the standard library, along with
+// their completion value, or the error they throw.
+var builtinTests = []struct{ src, expected string }{
+	// Object
+	{src: "Object.keys({ b: 1, a: 2, 1: 3, 0: 4 }).join()", expected: `"0,1,b,a"`},
+	{src: "Object.entries({ a: 1, b: [2] }).join(';')", expected: `"a,1;b,2"`},
+	{src: "Object.values('ab').join()", expected: `"a,b"`},
+	{src: "Object.fromEntries([['a', 1], ['b', 2]]).b", expected: "2"},
+	{src: "Object.fromEntries(new Map([['a', 1]])).a", expected: "1"},
+	{src: "var o = Object.assign({ a: 1 }, null, { b: 2 }, 'x'); [o.a, o.b, o[0]].join()", expected: `"1,2,x"`},
+	{src: "var o = Object.create({ a: 1 }, { b: { value: 2, enumerable: true } }); [o.a, o.b, Object.keys(o)].join()", expected: `"1,2,b"`},
+	{src: "Object.getPrototypeOf(Object.create(null))", expected: "null"},
+	{src: "var o = {}; Object.defineProperty(o, 'x', { value: 1 }); o.x = 2; [o.x, Object.keys(o).length].join()", expected: `"1,0"`},
+	{src: "'use strict'; var o = Object.defineProperty({}, 'x', { value: 1 }); o.x = 2", expected: "Uncaught TypeError: Cannot assign to read only property 'x' of [object Object]"},
+	{src: "Object.defineProperty({}, 'x', { get() {}, value: 1 })", expected: "Uncaught TypeError: Invalid property descriptor"},
+	{src: "var o = Object.defineProperty({}, 'x', { get() { return 42 }, configurable: true }); var d = Object.getOwnPropertyDescriptor(o, 'x'); [typeof d.get, d.set, d.enumerable, d.configurable, o.x].join()", expected: `"function,,false,true,42"`},
+	{src: "var o = Object.defineProperties({}, { a: { value: 1, enumerable: true }, b: { value: 2 } }); JSON.stringify(Object.getOwnPropertyDescriptors(o).b)", expected: `"{\"value\":2,\"writable\":false,\"enumerable\":false,\"configurable\":false}"`},
+	{src: "Object.getOwnPropertyNames([1, 2]).join()", expected: `"0,1,length"`},
+	{src: "var s = Symbol('s'); var o = { [s]: 1, a: 2 }; [Object.getOwnPropertySymbols(o)[0] === s, Object.keys(o)].join()", expected: `"true,a"`},
+	{src: "var o = Object.freeze({ a: { b: 1 } }); o.a = 2; o.a.b = 3; [o.a.b, Object.isFrozen(o), Object.isSealed(o), Object.isExtensible(o)].join()", expected: `"3,true,true,false"`},
+	{src: "var o = Object.seal({ a: 1 }); o.a = 2; o.b = 3; delete o.a; [o.a, o.b, Object.isSealed(o), Object.isFrozen(o)].join()", expected: `"2,,true,false"`},
+	{src: "var o = Object.preventExtensions({ a: 1 }); o.b = 2; [Object.isExtensible(o), 'b' in o, Object.isFrozen(Object.preventExtensions({}))].join()", expected: `"false,false,true"`},
+	{src: "var o = Object.setPrototypeOf({}, Array.prototype); [o instanceof Array, Array.isArray(o)].join()", expected: `"true,false"`},
+	{src: "Object.setPrototypeOf(Object.preventExtensions({}), {})", expected: "Uncaught TypeError: Cannot set the prototype of"},
+	{src: "var a = {}; var b = Object.create(a); Object.setPrototypeOf(a, b)", expected: "Uncaught TypeError: Cannot set the prototype of"},
+	{src: "[Object.is(NaN, NaN), Object.is(0, -0), Object.is('a', 'a')].join()", expected: `"true,false,true"`},
+	{src: "[Object.hasOwn({ a: 1 }, 'a'), Object.hasOwn(Object.create({ a: 1 }), 'a')].join()", expected: `"true,false"`},
+	{src: "[{}.toString(), Object.prototype.toString.call([]), Object.prototype.toString.call(null), Object.prototype.toString.call(undefined), Object.prototype.toString.call(1)].join()", expected: `"[object Object],[object Array],[object Null],[object Undefined],[object Number]"`},
+	{src: "Object.prototype.toString.call(function () {}) + Object.prototype.toString.call(new Error) + Object.prototype.toString.call(Math) + Object.prototype.toString.call(JSON)", expected: `"[object Function][object Error][object Math][object JSON]"`},
+	{src: "String({ [Symbol.toStringTag]: 'Custom' })", expected: `"[object Custom]"`},
+	{src: "[Object.prototype.isPrototypeOf.call(Array.prototype, []), ({ a: 1 }).propertyIsEnumerable('a'), [].propertyIsEnumerable('length')].join()", expected: `"true,true,false"`},
+	{src: "var o = {}; o.__proto__ = Array.prototype; [o.__proto__ === Array.prototype, typeof o.push].join()", expected: `"true,function"`},
+	{src: "Object(1) instanceof Number && typeof Object('s') == 'object' && Object(null) instanceof Object", expected: "true"},
+
+	// Function
+	{src: "function f(a, b) { return this.x + a + b } [f.call({ x: 1 }, 2, 3), f.apply({ x: 4 }, [5, 6]), f.apply({ x: 'x' }, { length: 2, 0: 'a', 1: 'b' })].join()", expected: `"6,15,xab"`},
+	{src: "function f(a, b, c) { return [this.x, a, b, c].join() } var g = f.bind({ x: 1 }, 2); [g(3, 4), g.name, g.length].join(';')", expected: `"1,2,3,4;bound f;2"`},
+	{src: "function P(a, b) { this.sum = a + b } var B = P.bind(null, 1); var p = new B(2); [p.sum, p instanceof P, p instanceof B].join()", expected: `"3,true,true"`},
+	{src: "(function () {}).bind().bind().name", expected: `"bound bound "`},
+	{src: "new Function('a', 'b', 'return a + b')(1, 2)", expected: "3"},
+	{src: "Function('return this')() === globalThis", expected: "true"},
+	{src: "new Function('a', 'b', 'return a').length + new Function().name", expected: `"2anonymous"`},
+	{src: "Function('}), (function () {')", expected: "Uncaught SyntaxError"},
+	{src: "function add(a, b) {\n  return a + b\n}\nadd.toString()", expected: `"function add(a, b) {\n  return a + b\n}"`},
+	{src: "String((a) => a * 2) + String(function* g() { yield })", expected: `"(a) => a * 2function* g() { yield }"`},
+	{src: "Math.max.toString()", expected: `"function max() { [native code] }"`},
+	{src: "function F() {} var o = { [Symbol.hasInstance](v) { return v === 1 } }; [new F instanceof F, 1 instanceof o, F[Symbol.hasInstance]({})].join()", expected: `"true,true,false"`},
+	{src: "Function.prototype.call.call(1)", expected: "Uncaught TypeError"},
+
+	// Array
+	{src: "[Array(3).length, Array(1, 2).join(), Array.of(7).join(), new Array('3').length].join(';')", expected: `"3;1,2;7;1"`},
+	{src: "Array(-1)", expected: "Uncaught RangeError: Invalid array length"},
+	{src: "Array.from('héllo').join('-') + Array.from({ length: 2 }, (_, i) => i * 2)", expected: `"h-é-l-l-o0,2"`},
+	{src: "Array.from(new Set([1, 1, 2])).join()", expected: `"1,2"`},
+	{src: "[Array.isArray([]), Array.isArray({ length: 0 }), Array.isArray(Array.prototype)].join()", expected: `"true,false,true"`},
+	{src: "var a = [1, 2]; [a.push(3, 4), a.pop(), a.shift(), a.unshift(0), a].join(';')", expected: `"4;4;1;3;0,2,3"`},
+	{src: "[[1, 2, 3, 4].slice(1, -1), [1, 2, 3].slice(-2), [1, 2].slice(5).length].join(';')", expected: `"2,3;2,3;0"`},
+	{src: "var a = [1, 2, 3, 4, 5]; var removed = a.splice(1, 2, 'a', 'b', 'c'); [removed, a].join(';')", expected: `"2,3;1,a,b,c,4,5"`},
+	{src: "var a = [1, 2, 3]; [a.splice(-1), a.splice(0, 0, 9), a].join(';')", expected: `"3;;9,1,2"`},
+	{src: "[1].concat(2, [3, [4]], { length: 1, 0: 5 }).length", expected: "5"},
+	{src: "var o = { length: 2, 0: 'a', 1: 'b', [Symbol.isConcatSpreadable]: true }; [].concat(o).join()", expected: `"a,b"`},
+	{src: "[[1, 2, 3, 4, 5].copyWithin(0, 3), [1, 2, 3].fill(0, 1), [1, 2, 3].reverse()].join(';')", expected: `"4,5,3,4,5;1,0,0;3,2,1"`},
+	{src: "[3, 20, 100, 1].sort().join()", expected: `"1,100,20,3"`},
+	{src: "[3, 20, 100, 1, undefined, , 2].sort((a, b) => a - b).join()", expected: `"1,2,3,20,100,,"`},
+	{src: "[{ k: 1, v: 'a' }, { k: 0, v: 'b' }, { k: 1, v: 'c' }, { k: 0, v: 'd' }].sort((x, y) => x.k - y.k).map(x => x.v).join('')", expected: `"bdac"`},
+	{src: "[1, 2].sort(1)", expected: "Uncaught TypeError: The comparison function must be either a function or undefined"},
+	{src: "[[1, 2, 1].indexOf(1, 1), [1, 2, 1].lastIndexOf(1, -2), [NaN].indexOf(NaN), [NaN].includes(NaN), [1, 2].includes(2, -1)].join()", expected: `"2,0,-1,true,true"`},
+	{src: "var a = [5, 12, 8, 130]; [a.find(x => x > 10), a.findIndex(x => x > 10), a.findLast(x => x > 10), a.findLastIndex(x => x > 1000)].join()", expected: `"12,1,130,-1"`},
+	{src: "var seen = []; [1, , 3].forEach((v, i, a) => seen.push(i + ':' + v + ':' + a.length)); seen.join()", expected: `"0:1:3,2:3:3"`},
+	{src: "var a = [1, 2, 3]; a.forEach((v, i) => { if (i == 0) { a.push(4); a.length = 2 } }); a.join()", expected: `"1,2"`},
+	{src: "[[1, 2].every(x => x > 0), [].every(x => false), [1, 2].some(x => x > 1), [].some(x => true)].join()", expected: `"true,true,true,false"`},
+	{src: "[[1, 2, 3].map(x => x * 2), [1, 2, 3, 4].filter(x => x % 2), [1, , 3].map(x => x).length].join(';')", expected: `"2,4,6;1,3;3"`},
+	{src: "[[1, 2, 3].reduce((a, b) => a + b), [1, 2, 3].reduce((a, b) => a + b, 10), ['a', 'b'].reduceRight((a, b) => a + b)].join()", expected: `"6,16,ba"`},
+	{src: "[].reduce((a, b) => a)", expected: "Uncaught TypeError: Reduce of empty array with no initial value"},
+	{src: "[1, 2].map(1)", expected: "Uncaught TypeError: 1 is not a function"},
+	{src: "[[1, [2, [3, [4]]]].flat().length, [1, [2, [3, [4]]]].flat(Infinity).join(), [1, 2].flatMap(x => [x, x * 2]).join()].join(';')", expected: `"3;1,2,3,4;1,2,2,4"`},
+	{src: "var a = [3, 1, 2]; [a.toSorted(), a.toReversed(), a.toSpliced(0, 1), a.with(-1, 9), a].join(';')", expected: `"1,2,3;2,1,3;1,2;3,1,9;3,1,2"`},
+	{src: "[1].with(1, 0)", expected: "Uncaught RangeError: Invalid index : 1"},
+	{src: "[[1, 2, 3].at(-1), [1].at(1), [1, [2, 3], null, undefined].join('-')].join()", expected: `"3,,1-2,3--"`},
+	{src: "var a = [1]; a.push(a); a.join()", expected: `"1,"`},
+	{src: "[...['a', 'b'].entries()].join(';') + [...['a', 'b'].keys()]", expected: `"0,a;1,b0,1"`},
+	{src: "[][Symbol.iterator] === [].values && Object.keys(Array.prototype[Symbol.unscopables]).includes('flat')", expected: "true"},
+	{src: "var a = []; a[2 ** 32 - 2] = 1; a.length", expected: "4294967295"},
+	{src: "Array.prototype.map.call('abc', c => c.toUpperCase()).join('')", expected: `"ABC"`},
+
+	// String
+	{src: "String.fromCharCode(72, 105, 0x10041) + String.fromCodePoint(0x1F600).length", expected: `"HiA2"`},
+	{src: "String.fromCodePoint(-1)", expected: "Uncaught RangeError: Invalid code point -1"},
+	{src: "String.raw`a\\n${1}b`", expected: `"a\\n1b"`},
+	{src: "var s = '😀a'; [s.length, s.at(-1), s.charAt(2), s.charCodeAt(0), s.codePointAt(0), s.codePointAt(1), s.charAt(5)].join()", expected: `"3,a,a,55357,128512,56832,"`},
+	{src: "[...'a😀b'].length", expected: "3"},
+	{src: "var s = '😀'; [s[0] + s[1] === s, s.split('').join('') === s, s.slice(0, 1).concat(s.slice(1)) === s, `${s[0]}${s[1]}` === s, (s[1] + s[0]).length].join()", expected: `"true,true,true,true,2"`},
+	{src: "['\\ud800'.charCodeAt(0), '\\ud800'.length, String.fromCharCode(0xdc00).charCodeAt(0), String.fromCodePoint(0xd800).codePointAt(0), [...'\\ud800a'].length, 'a\\ud800b'.toUpperCase().charCodeAt(1)].join()", expected: `"55296,1,56320,55296,2,55296"`},
+	{src: "['abc'.includes('b'), 'abc'.startsWith('b', 1), 'abc'.endsWith('b', 2), 'abcabc'.indexOf('c', 3), 'abcabc'.lastIndexOf('a'), 'abc'.indexOf('')].join()", expected: `"true,true,true,5,3,0"`},
+	{src: "'abc'.startsWith(/a/)", expected: "Uncaught TypeError: First argument to String.prototype.startsWith must not be a regular expression"},
+	{src: "['abc'.padStart(5, '-'), 'abc'.padEnd(6, '12'), 'abc'.padStart(2), 'ab'.repeat(3)].join()", expected: `"--abc,abc121,abc,ababab"`},
+	{src: "'a'.repeat(-1)", expected: "Uncaught RangeError: Invalid count value: -1"},
+	{src: "['abcdef'.slice(-3, -1), 'abcdef'.substring(4, 1), 'abcdef'.substr(-4, 2), 'abc'.concat(1, [2])].join()", expected: `"de,bcd,cd,abc12"`},
+	{src: "['Straße'.toUpperCase(), 'İ'.toLowerCase().length, 'ΑΒΓ'.toLowerCase()].join()", expected: `"STRASSE,2,αβγ"`},
+	{src: "['  a \\n'.trim(), '  a '.trimStart(), ' a  '.trimEnd(), '\\ufeffa'.trim()].join('|')", expected: `"a|a | a|a"`},
+	{src: "['a,b,,c'.split(','), 'abc'.split(''), 'a,b,c'.split(',', 2), 'abc'.split(), ''.split(',').length].join(';')", expected: `"a,b,,c;a,b,c;a,b;abc;1"`},
+	{src: "var splitter = { [Symbol.split](s, limit) { return [s, limit] } }; 'ab'.split(splitter, 1).join()", expected: `"ab,1"`},
+	{src: "['aXbX'.replace('X', '-'), 'aXbX'.replaceAll('X', '-'), 'abc'.replace('b', '[$&$`$\\'$$]'), 'abc'.replace('b', (m, i, s) => m + i + s)].join()", expected: `"a-bX,a-b-,a[bac$]c,ab1abcc"`},
+	{src: "var replacer = { [Symbol.replace](s, r) { return r + s } }; 'ab'.replace(replacer, '-') + 'ab'.replaceAll(replacer, '+')", expected: `"-ab+ab"`},
+	{src: "'aa'.replaceAll(/a/, 'b')", expected: "Uncaught TypeError: replaceAll must be called with a global RegExp"},
+	{src: "['caat'.replace(/a+/, 'b'), 'aXbX'.replace(/x/gi, '-'), 'aa'.replaceAll(/a/g, 'b'), 'abc'.replace(/(?<x>b)/, '[$1$<x>$2$01$&]'), 'abc'.replace(/b/g, (m, i, s) => m + i + s), ''.replace(/x*/g, '-')].join()", expected: `"cbt,a-b-,bb,a[bb$2bb]c,ab1abcc,-"`},
+	{src: "['a1b22c'.split(/\\d+/), 'a1b2c'.split(/(\\d)/), 'abc'.split(/(?:)/), 'a,b,c'.split(/,/, 2), ''.split(/x/).length].join(';')", expected: `"a,b,c;a,1,b,2,c;a,b,c;a,b;1"`},
+	{src: "var re = /(?<d>\\d)/g, m = re.exec('a1b2'); [m.index, m[0], m.groups.d, m.input, re.lastIndex, re.exec('a1b2').index, re.exec('a1b2'), re.lastIndex].join()", expected: `"1,1,1,a1b2,2,3,,0"`},
+	{src: "[/^a/m.test('b\\na'), /^a/.test('b\\na'), /a/y.test('ba'), /(a)|b/d.exec('b').indices.join(';')].join()", expected: `"true,false,false,0,1;"`},
+	{src: "['a'.localeCompare('b'), 'b'.localeCompare('a'), 'a'.localeCompare('a')].join()", expected: `"-1,1,0"`},
+	{src: "typeof 'abc'.normalize", expected: `"undefined"`},
+	{src: "String.prototype.trim.call(null)", expected: "Uncaught TypeError: String.prototype.trim called on null or undefined"},
+	{src: "[String(Symbol('s')), String(null), String([1, [2]]), new String('ab').length, typeof new String('a')].join()", expected: `"Symbol(s),null,1,2,2,object"`},
+	{src: "var s = new String('ab'); [s[1], Object.keys(s), s.valueOf() === 'ab'].join(';')", expected: `"b;0,1;true"`},
+
+	// Number and Boolean
+	{src: "[(255).toString(16), (255).toString(2), (-255).toString(36), (0.5).toString(2), (3.75).toString(8), (1e21).toString(7).length].join()", expected: `"ff,11111111,-73,0.1,3.6,25"`},
+	{src: "(1).toString(1)", expected: "Uncaught RangeError: toString() radix must be between 2 and 36"},
+	{src: "[(0.5).toFixed(0), (1.5).toFixed(0), (2.5).toFixed(0), (1.005).toFixed(2), (1.45).toFixed(1), (1e21).toFixed(2), (-1.5).toFixed(0), (0).toFixed(2)].join()", expected: `"1,2,3,1.00,1.4,1e+21,-2,0.00"`},
+	{src: "[(123.456).toExponential(2), (0).toExponential(), (1e-7).toExponential(), (12345).toExponential()].join()", expected: `"1.23e+2,0e+0,1e-7,1.2345e+4"`},
+	{src: "[(25).toPrecision(1), (123.456).toPrecision(4), (0.000123).toPrecision(2), (1e21).toPrecision(3), (5).toPrecision()].join()", expected: `"3e+1,123.5,0.00012,1.00e+21,5"`},
+	{src: "(1).toFixed(101)", expected: "Uncaught RangeError: toFixed() digits argument must be between 0 and 100"},
+	{src: "[Number.MAX_SAFE_INTEGER, Number.EPSILON > 0, Number.MIN_VALUE, Number.isInteger(5.0), Number.isInteger('5'), Number.isSafeInteger(2 ** 53), Number.isNaN('x'), isNaN('x'), Number.isFinite('1'), isFinite('1')].join()", expected: `"9007199254740991,true,5e-324,true,false,false,false,true,false,true"`},
+	{src: "[parseInt('  0x1F'), parseInt('12px'), parseInt('z', 36), parseInt('1', 1), parseInt('-0'), parseFloat('3.14abc'), parseFloat('.5e1'), parseFloat('-Infinityx'), parseFloat('e1')].join()", expected: `"31,12,35,NaN,0,3.14,5,-Infinity,NaN"`},
+	{src: "Number.parseInt === parseInt && Number.parseFloat === parseFloat", expected: "true"},
+	{src: "[Number('  12  '), Number(''), Number('0x10'), Number('1_0'), Number(null), Number([5]), Number(true)].join()", expected: `"12,0,16,NaN,0,5,1"`},
+	{src: "Number.prototype.toFixed.call('1')", expected: "Uncaught TypeError"},
+	{src: "[Boolean(''), Boolean('0'), Boolean(NaN), new Boolean(false) ? 1 : 2, (true).toString(), new Boolean(1).valueOf()].join()", expected: `"false,true,false,1,true,true"`},
+
+	// Math
+	{src: "[Math.round(2.5), Math.round(-2.5), Math.round(-0.2), 1 / Math.round(-0.2), Math.trunc(-4.7), Math.sign(-3), Math.cbrt(27)].join()", expected: `"3,-2,0,-Infinity,-4,-1,3"`},
+	{src: "[Math.max(), Math.min(), Math.max(1, 3, 2), Math.min(1, NaN), 1 / Math.max(-0, 0), 1 / Math.min(0, -0)].join()", expected: `"-Infinity,Infinity,3,NaN,Infinity,-Infinity"`},
+	{src: "[Math.hypot(3, 4), Math.hypot(), Math.hypot(NaN, Infinity), Math.clz32(1), Math.imul(0xffffffff, 5), Math.fround(5.5), Math.fround(5.05) === 5.05].join()", expected: `"5,0,Infinity,31,-5,5.5,false"`},
+	{src: "[Math.pow(2, 10), Math.pow(NaN, 0), Math.pow(1, Infinity), Math.atan2(1, 1) === Math.PI / 4, Math.abs(-'2')].join()", expected: `"1024,1,NaN,true,2"`},
+	{src: "var x = Math.random(); x >= 0 && x < 1", expected: "true"},
+
+	// JSON
+	{src: "var o = JSON.parse('{\"a\": [1, 2.5e1, true, null, \"\\\\u0041\\\\n\"], \"b\": {}}'); [o.a[1], o.a[2], o.a[3], o.a[4], typeof o.b].join()", expected: `"25,true,,A\n,object"`},
+	{src: "JSON.parse('[1, 2,]')", expected: "Uncaught SyntaxError: Unexpected token ] in JSON at position 6"},
+	{src: "JSON.parse('{\"a\": 01}')", expected: "Uncaught SyntaxError"},
+	{src: "JSON.parse(\"'a'\")", expected: "Uncaught SyntaxError: Unexpected token ' in JSON at position 0"},
+	{src: "JSON.parse('')", expected: "Uncaught SyntaxError: Unexpected end of JSON input"},
+	{src: "JSON.parse('{\"a\": 1, \"b\": [1, 2]}', (k, v) => typeof v == 'number' ? v * 10 : v).b.join()", expected: `"10,20"`},
+	{src: "JSON.stringify(JSON.parse('{\"a\": 1, \"b\": 2}', (k, v) => k == 'a' ? undefined : v))", expected: `"{\"b\":2}"`},
+	{src: "JSON.stringify({ a: [1, { b: 2 }], c: 'x' }, null, 2)", expected: `"{\n  \"a\": [\n    1,\n    {\n      \"b\": 2\n    }\n  ],\n  \"c\": \"x\"\n}"`},
+	{src: "JSON.stringify([undefined, function () {}, Symbol(), NaN, -0, Infinity])", expected: `"[null,null,null,null,0,null]"`},
+	{src: "JSON.stringify(['\\ud800', '\\ude00\\ud83d', JSON.parse('\"\\\\ud83d\\ude00\"') === '😀', JSON.parse('\"\\\\ud800\"').charCodeAt(0)])", expected: `"[\"\\ud800\",\"\\ude00\\ud83d\",true,55296]"`},
+	{src: "JSON.stringify({ u: undefined, f() {}, [Symbol()]: 1, n: null, s: new String('s'), b: new Boolean(false) })", expected: `"{\"n\":null,\"s\":\"s\",\"b\":false}"`},
+	{src: "[JSON.stringify(undefined), JSON.stringify(() => 1), JSON.stringify('\\u2028\"\\n'), JSON.stringify({})].join()", expected: `",,\"\u2028\\\"\\n\",{}"`},
+	{src: "JSON.stringify({ a: 1, b: 2, c: { a: 3, d: 4 } }, ['a', 'c'])", expected: `"{\"a\":1,\"c\":{\"a\":3}}"`},
+	{src: "JSON.stringify({ a: 1, b: 'x' }, (k, v) => typeof v == 'number' ? v + 1 : v, '--')", expected: `"{\n--\"a\": 2,\n--\"b\": \"x\"\n}"`},
+	{src: "JSON.stringify({ toJSON(key) { return 'key:' + key } }) + JSON.stringify({ x: { toJSON(key) { return key } } })", expected: `"\"key:\"{\"x\":\"x\"}"`},
+	{src: "var o = {}; o.self = [o]; JSON.stringify(o)", expected: "Uncaught TypeError: Converting circular structure to JSON"},
+	{src: "JSON.stringify([[], {}], null, 4) + JSON.stringify([1], null, 20).length", expected: `"[\n    [],\n    {}\n]15"`},
+	{src: "Object.prototype.toString.call(JSON) + JSON[Symbol.toStringTag]", expected: `"[object JSON]JSON"`},
+
+	// Error and Symbol
+	{src: "var e = new TypeError('bad', { cause: 1 }); [e.name, e.message, e.cause, e instanceof Error, e + '', Object.keys(e).length].join()", expected: `"TypeError,bad,1,true,TypeError: bad,0"`},
+	{src: "[String(new Error), String(new RangeError()), Error('x').message, Error.prototype.toString.call({ name: 'N', message: 'm' })].join()", expected: `"Error,RangeError,x,N: m"`},
+	{src: "var e = new AggregateError([1, 2], 'all failed'); [e.name, e.message, e.errors.join(), e instanceof Error, Object.getPrototypeOf(AggregateError) === Error].join()", expected: `"AggregateError,all failed,1,2,true,true"`},
+	{src: "[Symbol('d').description, Symbol().description, Symbol('d').toString(), typeof Symbol.iterator].join()", expected: `"d,,Symbol(d),symbol"`},
+	{src: "[Symbol.for('k') === Symbol.for('k'), Symbol('k') === Symbol('k'), Symbol.keyFor(Symbol.for('k')), Symbol.keyFor(Symbol('k'))].join()", expected: `"true,false,k,"`},
+	{src: "new Symbol()", expected: "Uncaught TypeError: Symbol is not a constructor"},
+	{src: "Symbol() + ''", expected: "Uncaught TypeError: Cannot convert a Symbol value to a string"},
+
+	// Map, Set, WeakMap and WeakSet
+	{src: "var m = new Map([[1, 'a'], ['1', 'b']]); m.set(NaN, 'n').set(-0, 'z'); [m.get(1), m.get('1'), m.get(NaN), m.get(0), m.size, m.has(2)].join()", expected: `"a,b,n,z,4,false"`},
+	{src: "var m = new Map([['a', 1], ['b', 2], ['c', 3]]); var seen = []; m.forEach((v, k) => { seen.push(k); if (k == 'a') { m.delete('b'); m.set('d', 4) } }); seen.join()", expected: `"a,c,d"`},
+	{src: "var m = new Map([['a', 1], ['b', 2]]); var it = m.entries(); var first = it.next().value; m.delete('a'); m.set('a', 3); [first, [...it].join(';')].join('|')", expected: `"a,1|b,2;a,3"`},
+	{src: "var m = new Map([[1, 1]]); var it = m.keys(); m.clear(); m.set(2, 2); [...it].join() + m.size", expected: `"21"`},
+	{src: "var m = new Map([['a', 1]]); [[...m.keys()], [...m.values()], [...m].join(';'), m[Symbol.iterator] === m.entries, String(m)].join('|')", expected: `"a|1|a,1|true|[object Map]"`},
+	{src: "new Map([1])", expected: "Uncaught TypeError: Iterator value 1 is not an entry object"},
+	{src: "Map()", expected: "Uncaught TypeError: Constructor Map requires 'new'"},
+	{src: "Map.prototype.get.call({}, 1)", expected: "Uncaught TypeError: Method Map.prototype.get called on incompatible receiver [object Object]"},
+	{src: "var s = new Set('hello'); s.add('h').add('!'); [...s].join('') + s.size + s.has('l') + s.delete('x')", expected: `"helo!5truefalse"`},
+	{src: "var s = new Set([1, 2]); [[...s.entries()].join(';'), s.keys === s.values, 'size' in Set.prototype].join('|')", expected: `"1,1;2,2|true|true"`},
+	{src: "Object.getOwnPropertyDescriptor(Map.prototype, 'size').get.call(new Set)", expected: "Uncaught TypeError: Method Map.prototype.size called on incompatible receiver [object Set]"},
+	{src: "[Map[Symbol.species] === Map, Set[Symbol.species] === Set, WeakMap[Symbol.species]].join()", expected: `"true,true,"`},
+	{src: "var k = {}; var m = new WeakMap([[k, 1]]); [m.get(k), m.has({}), m.delete(k), m.has(k)].join()", expected: `"1,false,true,false"`},
+	{src: "new WeakMap().set(1, 1)", expected: "Uncaught TypeError: Invalid value used as weak map key"},
+	{src: "var k = Symbol(); var s = new WeakSet([k]); s.has(k) && !s.has(Symbol())", expected: "true"},
+	{src: "new WeakSet().add(Symbol.for('registered'))", expected: "Uncaught TypeError: Invalid value used in weak set"},
+}
+
+func TestBuiltins(t *testing.T) {
+	testScripts(t, builtinTests)
+}
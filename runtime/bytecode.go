@@ -0,0 +1,390 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// opcode is the operation of an instruction of the virtual machine, which
+// works on the operand stack of a frame.
+type opcode uint8
+
+const (
+	opConst      opcode = iota // push constants[a]
+	opUndefined                // push undefined
+	opThis                     // push this
+	opNewTarget                // push new.target
+	opImportMeta               // push import.meta
+	opCallee                   // push the function called
+	opPop                      // drop the top of the stack
+	opDup                      // push the top of the stack again
+	opDup2                     // push the two topmost values again
+	opSwap                     // swap the two topmost values
+	opRot3                     // move the top below the two values under it
+	opRot4                     // move the top below the three values under it
+	opPick                     // push the value a places below the top
+
+	// variables: the slots of the frame, the cells of the frame holding
+	// the variables closures capture, the cells captured by the closure,
+	// and the names resolved in the environment of the code at run time
+	opGetLocal   // push locals[a]
+	opSetLocal   // pop to locals[a], failing in its temporal dead zone
+	opInitLocal  // pop to locals[a], ending its temporal dead zone
+	opGetCell    // push cells[a]
+	opSetCell    // pop to cells[a]
+	opInitCell   // pop to cells[a], ending its temporal dead zone
+	opGetUpval   // push upvals[a]
+	opSetUpval   // pop to upvals[a]
+	opGetName    // push the value of names[a]
+	opSetName    // pop to names[a]
+	opInitName   // pop to names[a], ending its temporal dead zone
+	opTypeofName // push typeof names[a], which may be unresolvable
+	opDeleteName // push delete names[a]
+	opSetVar     // pop to the var scope binding of names[a]
+
+	// properties, with the keys of the instructions in keys[a]
+	opGetProp    // obj -> obj[key]
+	opGetElem    // obj key -> obj[key]
+	opSetProp    // obj v -> v, assigning obj[key]
+	opSetElem    // obj key v -> v, assigning obj[key]
+	opDeleteProp // obj -> delete obj[key]
+	opDeleteElem // obj key -> delete obj[key]
+	opGetSuper   // key -> super[key]
+	opSetSuper   // key v -> v, assigning super[key]
+	opToKey      // v -> the property key of v
+
+	// operators, with the token of the operator in a
+	opAdd
+	opSub
+	opMul
+	opLess
+	opGreater
+	opLessEq
+	opGreaterEq
+	opStrictEq
+	opStrictNe
+	opBinary
+	opUnary
+	opTypeof
+	opToNumeric
+	opInc
+	opDec
+
+	// control flow, jumping to the instruction at a
+	opJump
+	opJumpIfFalse        // pop, and jump if falsy
+	opJumpIfFalseKeep    // jump if the top is falsy, pop it otherwise
+	opJumpIfTrueKeep     // jump if the top is truthy, pop it otherwise
+	opJumpIfNotNullKeep  // jump if the top is not nullish, pop it otherwise
+	opJumpIfNotUndefined // jump if the top is not undefined, pop it otherwise
+	opShortCircuit       // if the top is nullish, pop b values, push undefined and jump
+	opReturn             // return the top
+	opPosition           // record the position positions[a] for stack traces
+	opThrow              // throw a new error named constants[b] with message constants[a]
+
+	// calls, describing the callee as constants[b] in errors
+	opCall       // fn this args... -> result, with a arguments
+	opCallSpread // fn this array -> result
+	opNew        // fn args... -> result, with a arguments
+	opNewSpread  // fn array -> result
+	opClosure    // push a closure of functions[a], named keys[b]
+	opMethod     // obj key -> obj, defining a method of kind b, the closure of functions[a]
+	opArguments  // push the arguments object
+	opArg        // push the argument at index a
+	opRestArgs   // push an array of the arguments from index a
+	opGenerator  // suspend the frame until the generator is first resumed
+	opYield      // v -> the value the generator is resumed with
+	opYieldStar  // v -> the result of yield* v
+	opAwait      // v -> the value of v once settled
+	opTemplate   // push the template object of nodes[a]
+	opRegExp     // push a new regular expression of nodes[a]
+	opToString   // v -> the string of v
+	opConcat     // concatenate the a topmost strings
+
+	// literals
+	opNewArray     // push an empty array
+	opArrayPush    // arr v -> arr
+	opArrayHole    // arr -> arr, with a hole
+	opArraySpread  // arr iterable -> arr
+	opNewObject    // push an empty object
+	opDefineProp   // obj v -> obj, defining obj[keys[a]]
+	opDefineElem   // obj key v -> obj, naming anonymous functions when b is 1
+	opSetProto     // obj proto -> obj
+	opCopyData     // obj v -> obj, copying the properties of v
+	opCoercible    // fail if the top is nullish, as destructuring does
+	opGetIterator  // iterable -> , opening its iterator
+	opIteratorNext // push the next value of the iterator
+	opIteratorRest // push an array of the rest of the iterator
+	opIteratorEnd  // close the iterator
+	opExcludeNew   // start collecting the keys excluded from an object rest
+	opExcludeKey   // exclude the key on top of the stack
+	opExcludeProp  // exclude keys[a]
+	opCopyRest     // v -> a copy of v without the excluded keys
+)
+
+var opcodeNames = [...]string{
+	opConst: "const", opUndefined: "undefined", opThis: "this", opNewTarget: "new.target",
+	opImportMeta: "import.meta", opCallee: "callee", opPop: "pop", opDup: "dup", opDup2: "dup2",
+	opSwap: "swap", opRot3: "rot3", opRot4: "rot4", opPick: "pick",
+	opGetLocal: "get.local", opSetLocal: "set.local", opInitLocal: "init.local",
+	opGetCell: "get.cell", opSetCell: "set.cell", opInitCell: "init.cell",
+	opGetUpval: "get.upval", opSetUpval: "set.upval",
+	opGetName: "get.name", opSetName: "set.name", opInitName: "init.name",
+	opTypeofName: "typeof.name", opDeleteName: "delete.name", opSetVar: "set.var",
+	opGetProp: "get.prop", opGetElem: "get.elem", opSetProp: "set.prop", opSetElem: "set.elem",
+	opDeleteProp: "delete.prop", opDeleteElem: "delete.elem", opGetSuper: "get.super",
+	opSetSuper: "set.super", opToKey: "to.key",
+	opAdd: "add", opSub: "sub", opMul: "mul", opLess: "lt", opGreater: "gt", opLessEq: "le",
+	opGreaterEq: "ge", opStrictEq: "eq.strict", opStrictNe: "ne.strict", opBinary: "binary",
+	opUnary: "unary", opTypeof: "typeof", opToNumeric: "to.numeric", opInc: "inc", opDec: "dec",
+	opJump: "jump", opJumpIfFalse: "jump.false", opJumpIfFalseKeep: "jump.false.keep",
+	opJumpIfTrueKeep: "jump.true.keep", opJumpIfNotNullKeep: "jump.notnull.keep",
+	opJumpIfNotUndefined: "jump.notundefined", opShortCircuit: "short.circuit",
+	opReturn: "return", opPosition: "position", opThrow: "throw",
+	opCall: "call", opCallSpread: "call.spread", opNew: "new", opNewSpread: "new.spread",
+	opClosure: "closure", opMethod: "method", opArguments: "arguments", opArg: "arg",
+	opRestArgs: "rest.args", opGenerator: "generator", opYield: "yield", opYieldStar: "yield*",
+	opAwait: "await", opTemplate: "template", opRegExp: "regexp", opToString: "to.string", opConcat: "concat",
+	opNewArray: "array", opArrayPush: "array.push", opArrayHole: "array.hole",
+	opArraySpread: "array.spread", opNewObject: "object", opDefineProp: "define.prop",
+	opDefineElem: "define.elem", opSetProto: "set.proto", opCopyData: "copy.data",
+	opCoercible: "coercible", opGetIterator: "iterator", opIteratorNext: "iterator.next",
+	opIteratorRest: "iterator.rest", opIteratorEnd: "iterator.end", opExcludeNew: "exclude",
+	opExcludeKey: "exclude.key", opExcludeProp: "exclude.prop", opCopyRest: "copy.rest",
+}
+
+func (op opcode) String() string {
+	if int(op) < len(opcodeNames) && opcodeNames[op] != "" {
+		return opcodeNames[op]
+	}
+	return "op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// instruction is an operation along with its operands.
+type instruction struct {
+	op   opcode
+	a, b int32
+}
+
+// stackEffects are the changes of the depth of the operand stack that the
+// instructions make, but those of the calls and opConcat depending on their
+// operands. Conditional jumps are counted as not taken.
+var stackEffects = [...]int{
+	opConst: 1, opUndefined: 1, opThis: 1, opNewTarget: 1, opImportMeta: 1, opCallee: 1,
+	opPop: -1, opDup: 1, opDup2: 2, opPick: 1,
+	opGetLocal: 1, opSetLocal: -1, opInitLocal: -1, opGetCell: 1, opSetCell: -1, opInitCell: -1,
+	opGetUpval: 1, opSetUpval: -1, opGetName: 1, opSetName: -1, opInitName: -1,
+	opTypeofName: 1, opDeleteName: 1, opSetVar: -1,
+	opGetElem: -1, opSetProp: -1, opSetElem: -2, opDeleteElem: -1, opSetSuper: -1,
+	opAdd: -1, opSub: -1, opMul: -1, opLess: -1, opGreater: -1, opLessEq: -1, opGreaterEq: -1,
+	opStrictEq: -1, opStrictNe: -1, opBinary: -1,
+	opJumpIfFalse: -1, opJumpIfFalseKeep: -1, opJumpIfTrueKeep: -1, opJumpIfNotNullKeep: -1,
+	opJumpIfNotUndefined: -1, opReturn: -1,
+	opCallSpread: -2, opNewSpread: -1, opClosure: 1, opMethod: -1, opArguments: 1, opArg: 1,
+	opRestArgs: 1, opTemplate: 1, opRegExp: 1,
+	opNewArray: 1, opArrayPush: -1, opArraySpread: -1, opNewObject: 1, opDefineProp: -1,
+	opDefineElem: -2, opSetProto: -1, opCopyData: -1, opGetIterator: -1, opIteratorNext: 1,
+	opIteratorRest: 1, opCopyRest: 0,
+}
+
+// stackEffect returns the change of the depth of the operand stack that the
+// instruction makes.
+func (in instruction) stackEffect() int {
+	switch in.op {
+	case opCall:
+		return -int(in.a) - 1
+	case opNew:
+		return -int(in.a)
+	case opConcat:
+		return 1 - int(in.a)
+	}
+	return stackEffects[in.op]
+}
+
+// method kinds, the operand of opMethod
+const (
+	methodPlain = iota
+	methodGetter
+	methodSetter
+)
+
+// code is the bytecode of a function, a script or a module. Its variables
+// resolve to the slots of the frames running it, to cells when closures
+// capture them, and to names looked up in the environment of the code when
+// they are declared outside of the compiled code, as global variables are.
+type code struct {
+	name         string
+	instructions []instruction
+	constants    []Value
+	keys         []propertyKey
+	names        []string
+	functions    []*functionTemplate
+	nodes        []parser.Node // the template literals and regular expressions
+	positions    []parser.Position
+
+	// the variables of the slots and cells of the frames
+	locals []slot
+	cells  []slot
+	// the names of the cells captured by closures of the function
+	upvals []string
+	// the cells of the parameters, in order, when the arguments object is
+	// mapped to them
+	paramCells []int
+
+	// the depth the operand stack reaches, which the frames running the
+	// code allocate room for
+	maxStack int
+
+	info      *functionInfo // nil for scripts and modules
+	strict    bool
+	generator bool
+	// whether the code destructures iterators, which are closed when an
+	// exception is thrown through the frame
+	iterates bool
+}
+
+// slot describes a variable held by a frame.
+type slot struct {
+	name string
+	// whether the variable is out of its temporal dead zone on entry,
+	// initialized to undefined
+	initialized bool
+	mutable     bool
+}
+
+// functionTemplate is a function nested in compiled code, along with where
+// its closures find the cells they capture.
+type functionTemplate struct {
+	node     parser.Node
+	code     *code
+	captures []capture
+}
+
+// capture is a cell captured by a closure: a cell of the frame creating the
+// closure, or one of the cells the function of the frame captures itself.
+type capture struct {
+	upval bool
+	index int
+}
+
+// Disassemble compiles the script or module src, parsed with options, and
+// returns a listing of its bytecode and that of the functions it defines.
+func Disassemble(src string, options parser.Options) (string, error) {
+	file, err := parser.ParseFile(src, options)
+	if err != nil {
+		return "", err
+	}
+	r := New()
+	s := newScript("<disassembly>", file)
+	stmts := statements(file.Program)
+	var code *code
+	if options.SourceType == parser.SourceTypeModule {
+		code = r.compileModule(s)
+	} else {
+		code = r.compileScript(s, declarationsOf(stmts, file.Program.Strict, nil).annexB)
+	}
+
+	var w strings.Builder
+	if code == nil {
+		fmt.Fprintf(&w, "<top level>: interpreted, as it uses with or eval\n")
+	} else {
+		code.disassemble(&w)
+	}
+	// the functions declared at the top level are created by the
+	// instantiation of the code rather than by the code itself
+	for _, stmt := range stmts {
+		if export, ok := stmt.(*parser.ExportNamedDeclaration); ok && export.Declaration != nil {
+			stmt = export.Declaration
+		}
+		if export, ok := stmt.(*parser.ExportDefaultDeclaration); ok {
+			stmt, _ = export.Declaration.(parser.Stmt)
+		}
+		if fn, ok := stmt.(*parser.FunctionDeclarationStmt); ok {
+			w.WriteString("\n")
+			if code := r.compileFunction(fn, s); code != nil {
+				code.disassemble(&w)
+			} else {
+				fmt.Fprintf(&w, "%s: interpreted, as it uses with or eval\n", functionCodeName(fn))
+			}
+		}
+	}
+	return w.String(), nil
+}
+
+// disassemble writes a listing of the instructions of the code, followed by
+// those of its nested functions.
+func (code *code) disassemble(w io.Writer) {
+	fmt.Fprintf(w, "%s: %d locals, %d cells\n", code.name, len(code.locals), len(code.cells))
+	for pc, in := range code.instructions {
+		fmt.Fprintf(w, "  %4d  %-18s", pc, in.op)
+		if operands := code.operands(in); operands != "" {
+			fmt.Fprintf(w, " %s", operands)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, fn := range code.functions {
+		fmt.Fprintln(w)
+		fn.code.disassemble(w)
+	}
+}
+
+// operands describes the operands of the instruction in.
+func (code *code) operands(in instruction) string {
+	switch in.op {
+	case opConst:
+		return describeConstant(code.constants[in.a])
+	case opGetLocal, opSetLocal, opInitLocal:
+		return fmt.Sprintf("%d (%s)", in.a, code.locals[in.a].name)
+	case opGetCell, opSetCell, opInitCell:
+		return fmt.Sprintf("%d (%s)", in.a, code.cells[in.a].name)
+	case opGetUpval, opSetUpval:
+		return fmt.Sprintf("%d (%s)", in.a, code.upvals[in.a])
+	case opPick, opArg, opRestArgs, opConcat:
+		return strconv.Itoa(int(in.a))
+	case opGetName, opSetName, opInitName, opTypeofName, opDeleteName, opSetVar:
+		return code.names[in.a]
+	case opGetProp, opSetProp, opDeleteProp, opDefineProp, opExcludeProp:
+		return code.keys[in.a].String()
+	case opAdd, opSub, opMul, opLess, opGreater, opLessEq, opGreaterEq, opStrictEq, opStrictNe, opBinary, opUnary:
+		op := l.TokenType(in.a)
+		return op.S()
+	case opJump, opJumpIfFalse, opJumpIfFalseKeep, opJumpIfTrueKeep, opJumpIfNotNullKeep, opJumpIfNotUndefined:
+		return "-> " + strconv.Itoa(int(in.a))
+	case opShortCircuit:
+		return fmt.Sprintf("-> %d, drop %d", in.a, in.b)
+	case opPosition:
+		pos := code.positions[in.a]
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column+1)
+	case opThrow:
+		return fmt.Sprintf("%s: %s", code.constants[in.b], code.constants[in.a])
+	case opCall, opNew:
+		return fmt.Sprintf("%d (%s)", in.a, code.constants[in.b])
+	case opCallSpread, opNewSpread:
+		return fmt.Sprint(code.constants[in.b])
+	case opClosure:
+		return fmt.Sprintf("%s, named %q", code.functions[in.a].code.name, code.keys[in.b])
+	case opMethod:
+		kinds := [...]string{methodPlain: "method", methodGetter: "getter", methodSetter: "setter"}
+		return fmt.Sprintf("%s %s", kinds[in.b], code.functions[in.a].code.name)
+	case opDefineElem:
+		if in.b == 1 {
+			return "naming functions"
+		}
+	case opTemplate, opRegExp:
+		return code.nodes[in.a].S()
+	}
+	return ""
+}
+
+// describeConstant formats a constant of a listing, quoting strings.
+func describeConstant(v Value) string {
+	if s, ok := v.(String); ok {
+		return strconv.Quote(string(s))
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,399 @@
+package runtime
+
+import (
+	"strings"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// closure is the internal state of a function defined by the code of a
+// script or module: its code and the environment it closes over.
+//
+// https://262.ecma-international.org/#sec-ecmascript-function-objects
+type closure struct {
+	node   parser.Node
+	info   *functionInfo
+	env    *environment
+	script *script
+	module *module
+	// the context of the code defining an arrow function, which provides
+	// its this, new.target and super
+	lexical    *context
+	homeObject *Object
+	// the bytecode of a compiled function, and the cells of the variables
+	// of the compiled code enclosing it that it captures
+	code   *code
+	upvals []*binding
+}
+
+// newFunction returns the function object of the function declaration or
+// expression node, closing over env and named after key. The functions of
+// methods, getters and setters have homeObject, and are not constructors.
+//
+// https://262.ecma-international.org/#sec-ordinaryfunctioncreate
+func (c *context) newFunction(node parser.Node, env *environment, key propertyKey, prefix string, homeObject *Object) *Object {
+	r := c.r
+	cl := &closure{node: node, info: c.script.functionInfo(node), env: env, script: c.script, module: c.module, homeObject: homeObject}
+	if r.engine == EngineVM && !withinWith(env) {
+		cl.code = r.compileFunction(node, c.script)
+	}
+	return c.newClosure(cl, key, prefix)
+}
+
+// withinWith reports whether env is within the scope of a with statement,
+// where the names of compiled code would not resolve statically.
+func withinWith(env *environment) bool {
+	for e := env; e != nil; e = e.outer {
+		if e.with {
+			return true
+		}
+	}
+	return false
+}
+
+// newClosure returns the function object of the closure cl, defined by the
+// code of c and named after key.
+func (c *context) newClosure(cl *closure, key propertyKey, prefix string) *Object {
+	r := c.r
+	info := cl.info
+	proto := r.functionPrototype
+	switch {
+	case info.generator:
+		proto = r.generatorFunction
+	case info.async:
+		proto = r.asyncFunction
+	}
+	fn := r.newFunctionObject(proto, "", info.length)
+	if info.arrow {
+		cl.lexical = c.thisContext
+	}
+	fn.internal = cl
+	fn.call = func(this Value, args []Value) Value {
+		return r.callClosure(fn, cl, this, args, nil)
+	}
+
+	switch {
+	case info.generator:
+		prototype := r.newObject(r.generatorPrototype)
+		fn.defineOwnProperty(stringKey("prototype"), dataDescriptor(prototype, writable))
+	case !info.arrow && !info.async && cl.homeObject == nil:
+		r.makeConstructor(fn)
+		fn.construct = func(args []Value, newTarget *Object) *Object {
+			if newTarget == nil {
+				newTarget = fn
+			}
+			o := r.newObject(r.prototypeFromConstructor(newTarget, r.objectPrototype))
+			if result, ok := r.callClosure(fn, cl, o, args, newTarget).(*Object); ok {
+				return result
+			}
+			return o
+		}
+	}
+	setFunctionName(fn, key, prefix)
+	return fn
+}
+
+// sourceText returns the source text of the function, as
+// Function.prototype.toString does.
+//
+// https://262.ecma-international.org/#sec-function.prototype.tostring
+func (cl *closure) sourceText() (string, bool) {
+	if cl.script == nil {
+		return "", false
+	}
+	span, ok := cl.script.file.Spans[cl.node]
+	if !ok {
+		return "", false
+	}
+	src := cl.script.file.Source
+	start, end := offsetOf(src, span.Start), offsetOf(src, span.End)
+	if start < 0 || end < start {
+		return "", false
+	}
+	return src[start:end], true
+}
+
+// offsetOf returns the byte offset of pos in src, or -1 past its end.
+func offsetOf(src string, pos parser.Position) int {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return -1
+		}
+		offset += i + 1
+	}
+	if offset+pos.Column > len(src) {
+		return -1
+	}
+	return offset + pos.Column
+}
+
+// callClosure calls the function fn defined by a script, with this and args,
+// as new when newTarget is not nil.
+//
+// https://262.ecma-international.org/#sec-ecmascript-function-objects-call-thisargument-argumentslist
+func (r *Runtime) callClosure(fn *Object, cl *closure, this Value, args []Value, newTarget *Object) Value {
+	info := cl.info
+	name := functionName(fn)
+	r.enter(name, cl.script)
+	defer r.leave()
+
+	c := &context{r: r, script: cl.script, module: cl.module, strict: info.strict, annexB: info.declarations.annexB}
+	if info.arrow {
+		c.thisContext = cl.lexical
+	} else {
+		// https://262.ecma-international.org/#sec-ordinarycallbindthis
+		c.thisContext = c
+		c.homeObject = cl.homeObject
+		c.function = true
+		c.newTarget = Undefined
+		if newTarget != nil {
+			c.newTarget = newTarget
+		}
+		switch {
+		case info.strict:
+			c.this = this
+		case isNullish(this):
+			c.this = r.global
+		default:
+			c.this = r.toObject(this)
+		}
+	}
+	if cl.code != nil {
+		c.env, c.varEnv = cl.env, cl.env
+		f := r.newFrame(c, cl.code, fn, args, cl.upvals)
+		if info.async {
+			return r.startAsync(c, name, func() Value {
+				return r.run(f)
+			})
+		}
+		if !info.generator {
+			return r.run(f)
+		}
+		// the parameters are bound before the generator is first resumed
+		r.run(f)
+		o := r.newGenerator(fn, name, cl.script, func() Value {
+			return r.run(f)
+		})
+		c.generator = o.internal.(*generator)
+		return o
+	}
+	if info.async {
+		// the parameters are bound within the body, their exceptions
+		// rejecting the promise
+		return r.startAsync(c, name, func() Value {
+			c.instantiateFunction(fn, cl, args)
+			return c.evaluateBody(info)
+		})
+	}
+	c.instantiateFunction(fn, cl, args)
+
+	if info.generator {
+		o := r.newGenerator(fn, name, cl.script, func() Value {
+			return c.evaluateBody(info)
+		})
+		c.generator = o.internal.(*generator)
+		return o
+	}
+	return c.evaluateBody(info)
+}
+
+// evaluateBody runs the body of a function and returns its result.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-evaluatebody
+func (c *context) evaluateBody(info *functionInfo) Value {
+	if info.expression != nil {
+		return c.evaluate(info.expression)
+	}
+	if v, returned := c.executeStatements(info.body); returned {
+		return v
+	}
+	return Undefined
+}
+
+// instantiateFunction creates the environments of a call to the function
+// fn: the bindings of its parameters, bound to args, of its arguments
+// object, and of the declarations of its body.
+//
+// https://262.ecma-international.org/#sec-functiondeclarationinstantiation
+func (c *context) instantiateFunction(fn *Object, cl *closure, args []Value) {
+	r := c.r
+	info := cl.info
+	env := newEnvironment(cl.env)
+	c.env = env
+	for _, name := range info.paramNames {
+		if _, ok := env.bindings[name]; !ok {
+			// parameters with default values are out of their temporal
+			// dead zone once bound, in order
+			env.declare(name, true, !info.hasParamExpressions)
+		}
+	}
+	if info.argumentsNeeded {
+		var arguments *Object
+		if info.strict || !info.simpleParams {
+			arguments = r.newArguments(fn, args, true)
+		} else {
+			params := make([]*binding, len(info.paramNames))
+			for i, name := range info.paramNames {
+				params[i] = env.bindings[name]
+			}
+			arguments = r.newMappedArguments(fn, args, params)
+		}
+		env.declare("arguments", !info.strict, true).value = arguments
+	}
+
+	if info.simpleParams {
+		// later duplicate parameters take precedence in sloppy mode code
+		for i, name := range info.paramNames {
+			env.bindings[name].value = arg(args, i)
+		}
+	} else {
+		for i, param := range info.params {
+			if rest, ok := param.(*parser.RestElement); ok {
+				var values []Value
+				if i < len(args) {
+					values = append(values, args[i:]...)
+				}
+				c.bindPattern(rest.Argument, r.newArray(values...), env)
+				break
+			}
+			c.bindPattern(param, arg(args, i), env)
+		}
+	}
+
+	d := info.declarations
+	varEnv := env
+	if !info.hasParamExpressions {
+		for _, name := range d.varNames {
+			if _, ok := env.bindings[name]; !ok {
+				env.declare(name, true, true)
+			}
+		}
+	} else {
+		// the var declarations get an environment of their own, separate
+		// from the closures of parameter expressions, initialized with
+		// the values of the parameters of the same name
+		varEnv = newEnvironment(env)
+		for _, name := range d.varNames {
+			b := varEnv.declare(name, true, true)
+			if param, ok := env.bindings[name]; ok {
+				b.value = param.value
+			}
+		}
+	}
+	for f := range d.annexB {
+		name := f.BindingIdentifier.Name
+		if _, ok := varEnv.bindings[name]; !ok && name != "arguments" {
+			varEnv.declare(name, true, true)
+		}
+	}
+	c.varEnv = varEnv
+
+	lexEnv := varEnv
+	if !info.strict {
+		// so that the var declarations of direct eval code are told from
+		// the lexical declarations of the body
+		lexEnv = newEnvironment(varEnv)
+	}
+	c.env = lexEnv
+	for _, decl := range d.lexical {
+		lexEnv.declare(decl.name, !decl.constant, false)
+	}
+	for _, f := range d.functions {
+		name := f.BindingIdentifier.Name
+		b, ok := varEnv.bindings[name]
+		if !ok {
+			b = varEnv.declare(name, true, true)
+		}
+		b.value = c.newFunction(f, lexEnv, stringKey(name), "", nil)
+	}
+}
+
+// newArguments returns the arguments object of a call to fn with args, whose
+// elements are not aliases of the parameters. The callee property of those
+// of strict mode functions, and of functions with non-simple parameter lists,
+// throws when accessed.
+//
+// https://262.ecma-international.org/#sec-createunmappedargumentsobject
+func (r *Runtime) newArguments(fn *Object, args []Value, unmapped bool) *Object {
+	o := r.newObject(r.objectPrototype)
+	o.class = "Arguments"
+	o.elements = append(make([]Value, 0, len(args)), args...)
+	o.setHidden(stringKey("length"), Number(len(args)))
+	o.setHidden(symbolKey(SymbolIterator), r.arrayValues)
+	if unmapped {
+		desc := descriptor{getter: r.throwTypeError, setter: r.throwTypeError, has: hasGet | hasSet | hasEnumerable | hasConfigurable}
+		o.defineOwnProperty(stringKey("callee"), desc)
+	} else {
+		o.setHidden(stringKey("callee"), fn)
+	}
+	return o
+}
+
+// argumentsMap is the parameter map of a mapped arguments object: the
+// binding of the parameter that each element aliases, by index, nil for the
+// elements that are not aliases. The elements it maps are holes of the
+// elements of the object, so that the fast paths reading those miss them.
+//
+// https://262.ecma-international.org/#sec-arguments-exotic-objects
+type argumentsMap []*binding
+
+// lookup returns the index denoted by key and the binding of the parameter
+// the element aliases, nil when it does not alias one.
+func (m argumentsMap) lookup(key propertyKey) (uint32, *binding) {
+	i, ok := key.index()
+	if !ok || int(i) >= len(m) {
+		return 0, nil
+	}
+	return i, m[i]
+}
+
+// newMappedArguments returns the arguments object of a call to fn, a sloppy
+// mode function with a simple parameter list, with args. Its elements alias
+// params, the bindings of the parameters in order, until they are deleted or
+// redefined: an assignment to either changes both.
+//
+// https://262.ecma-international.org/#sec-createmappedargumentsobject
+func (r *Runtime) newMappedArguments(fn *Object, args []Value, params []*binding) *Object {
+	o := r.newArguments(fn, args, false)
+	m := make(argumentsMap, len(args))
+	// of duplicate parameters, the last one is mapped
+	mapped := make(map[*binding]bool)
+	for i := len(params) - 1; i >= 0; i-- {
+		if b := params[i]; !mapped[b] {
+			mapped[b] = true
+			if i < len(args) {
+				m[i] = b
+				o.elements[i] = nil
+			}
+		}
+	}
+	o.internal = m
+	return o
+}
+
+// defineArgument defines the element i of a mapped arguments object, which
+// aliases a parameter. Redefining it with other than its default attributes
+// ends the aliasing, after assigning the value of desc to the parameter.
+//
+// https://262.ecma-international.org/#sec-arguments-exotic-objects-defineownproperty-p-desc
+func (o *Object) defineArgument(m argumentsMap, i uint32, desc descriptor) bool {
+	b := m[i]
+	keepsDefaults := !desc.isAccessor() &&
+		(desc.has&hasWritable == 0 || desc.flags&writable != 0) &&
+		(desc.has&hasEnumerable == 0 || desc.flags&enumerable != 0) &&
+		(desc.has&hasConfigurable == 0 || desc.flags&configurable != 0)
+	if keepsDefaults {
+		if desc.has&hasValue != 0 {
+			b.value = desc.value
+		}
+		return true
+	}
+	o.elements[i] = b.value
+	if desc.isData() && desc.has&hasValue != 0 {
+		b.value = desc.value
+	}
+	m[i] = nil
+	return o.defineElement(i, desc)
+}
@@ -0,0 +1,1649 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// compiler compiles the code of a script, a module or a function to
+// bytecode, along with the functions nested in it.
+//
+// The variables declared by the code resolve to slots of its frames, or to
+// cells when nested functions may capture them. The names it does not
+// declare resolve at run time in the environment of the code: the global
+// and module scopes, or the scope of the interpreted code defining the
+// function. Code using a with statement or a direct eval, in itself or in
+// the functions nested in it, is interpreted, so that compiled code never
+// encloses interpreted code.
+type compiler struct {
+	r      *Runtime
+	script *script
+	f      *funcState
+}
+
+// funcState is the state of the compilation of a function body, a script or
+// a module.
+type funcState struct {
+	outer *funcState
+	code  *code
+	scope *compileScope
+	// the scope of the var declarations of a function, where the functions
+	// Annex B binds in the var scope are copied to
+	varScope *compileScope
+	strict   bool
+	annexB   map[*parser.FunctionDeclarationStmt]bool
+	// the names the functions nested in the code refer to
+	captured map[string]bool
+	captures []capture
+	upvals   map[*variable]int
+	// the slot holding the completion value of a script
+	completion *variable
+	// the depth of the operand stack at the end of the code, as estimated
+	// for the maximum depth of the stack
+	depth int
+
+	constants map[Value]int
+	keys      map[propertyKey]int
+	names     map[string]int
+}
+
+// compileScope holds the variables declared by a scope of compiled code.
+type compileScope struct {
+	outer *compileScope
+	vars  map[string]*variable
+}
+
+// variable is a variable declared by compiled code, held in a slot or in a
+// cell of the frames running the code.
+type variable struct {
+	name    string
+	cell    bool
+	index   int
+	mutable bool
+	// whether assignments are ignored in sloppy mode code, as they are to
+	// the name of a function expression
+	silent bool
+}
+
+// location is what a name resolves to in compiled code.
+type location struct {
+	kind  locationKind
+	index int
+	v     *variable // nil for names
+}
+
+type locationKind int
+
+const (
+	inLocal locationKind = iota
+	inCell
+	inUpval
+	inName
+)
+
+// compileScript compiles the code of a script, returning nil when it is
+// not compilable. Its top level declarations are global, resolved by name,
+// and annexB holds the functions declared in its blocks that are copied to
+// the global object.
+func (r *Runtime) compileScript(s *script, annexB map[*parser.FunctionDeclarationStmt]bool) *code {
+	program := s.file.Program
+	if !compilable(program) {
+		return nil
+	}
+	c := &compiler{r: r, script: s}
+	f := c.enter(program, "<script>", program.Strict)
+	f.annexB = annexB
+	c.pushScope()
+	f.completion = c.declare("%completion", true, true)
+	c.statements(statements(program))
+	c.emit(opGetLocal, f.completion.index)
+	c.emit(opReturn)
+	return f.code
+}
+
+// compileModule compiles the code of a module, returning nil when it is not
+// compilable. Its top level declarations are bound in the module
+// environment, resolved by name.
+func (r *Runtime) compileModule(s *script) *code {
+	program := s.file.Program
+	if !compilable(program) {
+		return nil
+	}
+	c := &compiler{r: r, script: s}
+	f := c.enter(program, "<module>", true)
+	c.pushScope()
+	c.statements(statements(program))
+	c.emit(opUndefined)
+	c.emit(opReturn)
+	return f.code
+}
+
+// compileFunction compiles the function node defined by interpreted code,
+// or by the instantiation of a script or module, computing it on first use.
+// It returns nil when the function is not compilable.
+func (r *Runtime) compileFunction(node parser.Node, s *script) *code {
+	if code, ok := s.compiled[node]; ok {
+		return code
+	}
+	var code *code
+	if compilable(node) {
+		c := &compiler{r: r, script: s}
+		code = c.function(node, functionCodeName(node)).code
+	}
+	s.compiled[node] = code
+	return code
+}
+
+// compilable reports whether the names of the code of node, nested
+// functions included, resolve statically: whether it has no with statement
+// and no direct call to eval.
+func compilable(node parser.Node) bool {
+	ok := true
+	parser.Walk(node, func(node parser.Node) bool {
+		switch n := node.(type) {
+		case *parser.WithStatement:
+			ok = false
+		case *parser.ExprCall:
+			if id, isIdentifier := n.Callee.(*parser.ExprIdentifier); isIdentifier && id.Name == "eval" {
+				ok = false
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
+// capturedNames returns the names the functions nested in the function,
+// script or module node refer to, which closures may capture.
+func capturedNames(node parser.Node) map[string]bool {
+	names := make(map[string]bool)
+	parser.Walk(node, func(n parser.Node) bool {
+		switch n.(type) {
+		case *parser.FunctionDeclarationStmt, *parser.ExprFunction, *parser.ExprArrowFunction:
+			if n == node {
+				return true
+			}
+			parser.Walk(n, func(n parser.Node) bool {
+				if id, ok := n.(*parser.ExprIdentifier); ok {
+					names[id.Name] = true
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+// functionCodeName returns the name of a function declaration or
+// expression, empty when anonymous.
+func functionCodeName(node parser.Node) string {
+	switch fn := node.(type) {
+	case *parser.FunctionDeclarationStmt:
+		if fn.BindingIdentifier != nil {
+			return fn.BindingIdentifier.Name
+		}
+	case *parser.ExprFunction:
+		if fn.BindingIdentifier != nil {
+			return fn.BindingIdentifier.Name
+		}
+	}
+	return ""
+}
+
+// enter starts the compilation of the code of node, nested in the code
+// being compiled.
+func (c *compiler) enter(node parser.Node, name string, strict bool) *funcState {
+	f := &funcState{
+		outer:     c.f,
+		code:      &code{name: name, strict: strict},
+		strict:    strict,
+		captured:  capturedNames(node),
+		upvals:    make(map[*variable]int),
+		constants: make(map[Value]int),
+		keys:      make(map[propertyKey]int),
+		names:     make(map[string]int),
+	}
+	c.f = f
+	return f
+}
+
+// ///////////
+// Emitting //
+// ///////////
+
+// emit appends an instruction with up to two operands, and returns its
+// index.
+func (c *compiler) emit(op opcode, operands ...int) int {
+	in := instruction{op: op}
+	if len(operands) > 0 {
+		in.a = int32(operands[0])
+	}
+	if len(operands) > 1 {
+		in.b = int32(operands[1])
+	}
+	f := c.f
+	f.code.instructions = append(f.code.instructions, in)
+	if f.depth += in.stackEffect(); f.depth < 0 {
+		// past a jump, as that of a conditional expression
+		f.depth = 0
+	}
+	if f.depth > f.code.maxStack {
+		f.code.maxStack = f.depth
+	}
+	return len(f.code.instructions) - 1
+}
+
+// patch makes the jump at pc jump to the next instruction emitted.
+func (c *compiler) patch(pc int) {
+	c.f.code.instructions[pc].a = int32(len(c.f.code.instructions))
+}
+
+// constant returns the index of v in the constants of the code.
+func (c *compiler) constant(v Value) int {
+	f := c.f
+	if i, ok := f.constants[v]; ok {
+		return i
+	}
+	f.code.constants = append(f.code.constants, v)
+	f.constants[v] = len(f.code.constants) - 1
+	return len(f.code.constants) - 1
+}
+
+// key returns the index of the property key name in the keys of the code.
+func (c *compiler) key(name string) int {
+	f := c.f
+	key := stringKey(name)
+	if i, ok := f.keys[key]; ok {
+		return i
+	}
+	f.code.keys = append(f.code.keys, key)
+	f.keys[key] = len(f.code.keys) - 1
+	return len(f.code.keys) - 1
+}
+
+// name returns the index of name in the names of the code.
+func (c *compiler) name(name string) int {
+	f := c.f
+	if i, ok := f.names[name]; ok {
+		return i
+	}
+	f.code.names = append(f.code.names, name)
+	f.names[name] = len(f.code.names) - 1
+	return len(f.code.names) - 1
+}
+
+// push emits the instruction pushing the constant v.
+func (c *compiler) push(v Value) {
+	if v == Undefined {
+		c.emit(opUndefined)
+		return
+	}
+	c.emit(opConst, c.constant(v))
+}
+
+// throw emits the instruction throwing a new error of the type with name,
+// whose message is formatted as fmt.Sprintf does.
+func (c *compiler) throw(name, format string, args ...interface{}) {
+	c.emit(opThrow, c.constant(String(fmt.Sprintf(format, args...))), c.constant(String(name)))
+}
+
+// position emits the instruction recording the position of the statement
+// stmt for stack traces.
+func (c *compiler) position(stmt parser.Stmt) {
+	span, ok := c.script.file.Spans[stmt]
+	if !ok {
+		return
+	}
+	code := c.f.code
+	code.positions = append(code.positions, span.Start)
+	c.emit(opPosition, len(code.positions)-1)
+}
+
+// /////////
+// Scopes //
+// /////////
+
+func (c *compiler) pushScope() {
+	c.f.scope = &compileScope{outer: c.f.scope, vars: make(map[string]*variable)}
+}
+
+func (c *compiler) popScope() {
+	c.f.scope = c.f.scope.outer
+}
+
+// declare declares name in the innermost scope, in its temporal dead zone
+// unless initialized, and returns its variable. A name declared twice in a
+// scope, as the parameters of sloppy mode functions may be, is one
+// variable.
+func (c *compiler) declare(name string, mutable, initialized bool) *variable {
+	return c.declareIn(c.f.scope, name, mutable, initialized)
+}
+
+func (c *compiler) declareIn(s *compileScope, name string, mutable, initialized bool) *variable {
+	if v, ok := s.vars[name]; ok {
+		return v
+	}
+	f := c.f
+	v := &variable{name: name, mutable: mutable}
+	desc := slot{name: name, initialized: initialized, mutable: mutable}
+	if f.captured[name] {
+		v.cell, v.index = true, len(f.code.cells)
+		f.code.cells = append(f.code.cells, desc)
+	} else {
+		v.index = len(f.code.locals)
+		f.code.locals = append(f.code.locals, desc)
+	}
+	s.vars[name] = v
+	return v
+}
+
+// lookup returns the variable name refers to in the scopes of the code, nil
+// when not declared there.
+func (f *funcState) lookup(name string) *variable {
+	for s := f.scope; s != nil; s = s.outer {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// upval returns the index of the cell of the variable name declared by the
+// code enclosing the function, which the closures of the function capture.
+// It returns a nil variable when no compiled code encloses it.
+func (f *funcState) upval(name string) (int, *variable) {
+	if f.outer == nil {
+		return 0, nil
+	}
+	var from capture
+	v := f.outer.lookup(name)
+	if v != nil {
+		from.index = v.index
+	} else {
+		var i int
+		if i, v = f.outer.upval(name); v == nil {
+			return 0, nil
+		}
+		from = capture{upval: true, index: i}
+	}
+	if i, ok := f.upvals[v]; ok {
+		return i, v
+	}
+	f.captures = append(f.captures, from)
+	f.code.upvals = append(f.code.upvals, name)
+	f.upvals[v] = len(f.captures) - 1
+	return len(f.captures) - 1, v
+}
+
+// resolve returns what name refers to in the code being compiled.
+func (c *compiler) resolve(name string) location {
+	if v := c.f.lookup(name); v != nil {
+		if v.cell {
+			return location{kind: inCell, index: v.index, v: v}
+		}
+		return location{kind: inLocal, index: v.index, v: v}
+	}
+	if i, v := c.f.upval(name); v != nil {
+		return location{kind: inUpval, index: i, v: v}
+	}
+	return location{kind: inName, index: c.name(name)}
+}
+
+// load emits the instructions pushing the value of name.
+func (c *compiler) load(name string) {
+	c.loadLocation(c.resolve(name))
+}
+
+func (c *compiler) loadLocation(loc location) {
+	switch loc.kind {
+	case inLocal:
+		c.emit(opGetLocal, loc.index)
+	case inCell:
+		c.emit(opGetCell, loc.index)
+	case inUpval:
+		c.emit(opGetUpval, loc.index)
+	default:
+		c.emit(opGetName, loc.index)
+	}
+}
+
+// store emits the instructions popping the value on top of the stack to
+// name, initializing its binding if init is set, or assigning it.
+func (c *compiler) store(name string, init bool) {
+	loc := c.resolve(name)
+	switch {
+	case loc.kind == inName && init:
+		c.emit(opInitName, loc.index)
+		return
+	case loc.kind == inName:
+		c.emit(opSetName, loc.index)
+		return
+	case init:
+		c.initVariable(loc.v)
+		return
+	case !loc.v.mutable:
+		c.emit(opPop)
+		if loc.v.silent && !c.f.strict {
+			return
+		}
+		// in its temporal dead zone, the binding fails to be read first
+		c.loadLocation(loc)
+		c.emit(opPop)
+		c.throw("TypeError", "Assignment to constant variable.")
+		return
+	}
+	switch loc.kind {
+	case inLocal:
+		c.emit(opSetLocal, loc.index)
+	case inCell:
+		c.emit(opSetCell, loc.index)
+	default:
+		c.emit(opSetUpval, loc.index)
+	}
+}
+
+// initVariable emits the instruction popping the value on top of the stack
+// to the variable v of the code, ending its temporal dead zone.
+func (c *compiler) initVariable(v *variable) {
+	if v.cell {
+		c.emit(opInitCell, v.index)
+	} else {
+		c.emit(opInitLocal, v.index)
+	}
+}
+
+// ////////////
+// Functions //
+// ////////////
+
+// function compiles the function node nested in the code being compiled,
+// named name when anonymous, and returns it along with its captures.
+//
+// https://262.ecma-international.org/#sec-functiondeclarationinstantiation
+func (c *compiler) function(node parser.Node, name string) *functionTemplate {
+	info := c.script.functionInfo(node)
+	if name == "" {
+		name = "<anonymous>"
+	}
+	outer := c.f
+	f := c.enter(node, name, info.strict)
+	defer func() { c.f = outer }()
+	f.code.info, f.code.generator = info, info.generator
+	f.annexB = info.declarations.annexB
+
+	if e, ok := node.(*parser.ExprFunction); ok && e.BindingIdentifier != nil {
+		// https://262.ecma-international.org/#sec-runtime-semantics-instantiateordinaryfunctionexpression
+		c.pushScope()
+		v := c.declare(e.BindingIdentifier.Name, false, true)
+		v.silent = true
+		c.emit(opCallee)
+		c.initVariable(v)
+	}
+
+	c.pushScope()
+	params := f.scope
+	mapped := info.argumentsNeeded && !info.strict && info.simpleParams
+	for _, name := range info.paramNames {
+		if mapped {
+			// the elements of the arguments object alias the parameters
+			f.captured[name] = true
+		}
+		// parameters with default values are out of their temporal dead
+		// zone once bound, in order
+		c.declare(name, true, !info.hasParamExpressions)
+	}
+	if mapped {
+		for _, name := range info.paramNames {
+			f.code.paramCells = append(f.code.paramCells, params.vars[name].index)
+		}
+	}
+	if info.argumentsNeeded {
+		v := c.declare("arguments", !info.strict, true)
+		c.emit(opArguments)
+		c.initVariable(v)
+	}
+	if info.simpleParams {
+		// later duplicate parameters take precedence in sloppy mode code
+		for i, name := range info.paramNames {
+			c.emit(opArg, i)
+			c.initVariable(params.vars[name])
+		}
+	} else {
+		for i, param := range info.params {
+			if rest, ok := param.(*parser.RestElement); ok {
+				c.emit(opRestArgs, i)
+				c.bind(rest.Argument, true)
+				break
+			}
+			c.emit(opArg, i)
+			c.bind(param, true)
+		}
+	}
+
+	d := info.declarations
+	if info.hasParamExpressions {
+		// the var declarations get a scope of their own, separate from the
+		// closures of parameter expressions, initialized with the values
+		// of the parameters of the same name
+		c.pushScope()
+		for _, name := range d.varNames {
+			v := c.declare(name, true, true)
+			if param, ok := params.vars[name]; ok {
+				c.loadLocation(variableLocation(param))
+				c.initVariable(v)
+			}
+		}
+	} else {
+		for _, name := range d.varNames {
+			c.declare(name, true, true)
+		}
+	}
+	f.varScope = f.scope
+	for _, name := range annexBNames(d.annexB) {
+		if name != "arguments" {
+			c.declare(name, true, true)
+		}
+	}
+
+	c.pushScope()
+	for _, decl := range d.lexical {
+		c.declare(decl.name, !decl.constant, false)
+	}
+	for _, fn := range d.functions {
+		c.declareIn(f.varScope, fn.BindingIdentifier.Name, true, true)
+	}
+	for _, fn := range d.functions {
+		name := fn.BindingIdentifier.Name
+		c.closure(fn, name)
+		c.initVariable(f.varScope.vars[name])
+	}
+	if info.generator {
+		c.emit(opGenerator)
+	}
+
+	if info.expression != nil {
+		c.expr(info.expression)
+		c.emit(opReturn)
+	} else {
+		c.statements(info.body)
+		c.emit(opUndefined)
+		c.emit(opReturn)
+	}
+	return &functionTemplate{node: node, code: f.code, captures: f.captures}
+}
+
+// variableLocation returns the location of the variable v of the code being
+// compiled.
+func variableLocation(v *variable) location {
+	if v.cell {
+		return location{kind: inCell, index: v.index, v: v}
+	}
+	return location{kind: inLocal, index: v.index, v: v}
+}
+
+// annexBNames returns the sorted names of the functions Annex B binds in
+// the var scope.
+func annexBNames(functions map[*parser.FunctionDeclarationStmt]bool) []string {
+	var names []string
+	for fn := range functions {
+		names = append(names, fn.BindingIdentifier.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closure emits the instruction pushing a closure of the function node,
+// named name when anonymous.
+func (c *compiler) closure(node parser.Node, name string) {
+	tmpl := c.function(node, name)
+	c.f.code.functions = append(c.f.code.functions, tmpl)
+	c.emit(opClosure, len(c.f.code.functions)-1, c.key(name))
+}
+
+// /////////////
+// Statements //
+// /////////////
+
+func (c *compiler) statements(stmts []parser.Stmt) {
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+}
+
+// statement compiles stmt, updating the completion value of scripts.
+//
+// https://262.ecma-international.org/#sec-ecmascript-language-statements-and-declarations
+func (c *compiler) statement(stmt parser.Stmt) {
+	c.position(stmt)
+	switch s := stmt.(type) {
+	case *parser.ExpressionStatement:
+		c.expr(s.Expression)
+		c.complete()
+	case *parser.VariableStatement:
+		c.variables(s)
+	case *parser.FunctionDeclarationStmt:
+		if c.f.annexB[s] {
+			// https://262.ecma-international.org/#sec-web-compat-functiondeclarationinstantiation
+			name := s.BindingIdentifier.Name
+			c.load(name)
+			if c.f.varScope == nil {
+				c.emit(opSetVar, c.name(name))
+				return
+			}
+			for s := c.f.varScope; s != nil; s = s.outer {
+				if v, ok := s.vars[name]; ok {
+					c.initVariable(v)
+					return
+				}
+			}
+			c.emit(opPop)
+		}
+	case *parser.BlockStatement:
+		c.block(s, s.Stmts)
+	case *parser.EmptyStatement, *parser.DebuggerStatement:
+	case *parser.ReturnStatement:
+		if s.Argument == nil {
+			c.emit(opUndefined)
+		} else {
+			c.expr(s.Argument)
+		}
+		c.emit(opReturn)
+	case *parser.IfStatement:
+		// the completion value is undefined when the body has none
+		c.emit(opUndefined)
+		c.complete()
+		c.expr(s.Condition)
+		jump := c.emit(opJumpIfFalse)
+		c.body(s.ThenStmt)
+		if s.ElseStmt == nil {
+			c.patch(jump)
+			return
+		}
+		end := c.emit(opJump)
+		c.patch(jump)
+		c.body(s.ElseStmt)
+		c.patch(end)
+	case *parser.LabelledStatement:
+		c.statement(s.Body)
+	case *parser.ImportDeclaration, *parser.ExportAllDeclaration:
+	case *parser.ExportNamedDeclaration:
+		if s.Declaration != nil {
+			c.statement(s.Declaration)
+		}
+	case *parser.ExportDefaultDeclaration:
+		// https://262.ecma-international.org/#sec-exports-runtime-semantics-evaluation
+		if _, ok := s.Declaration.(*parser.FunctionDeclarationStmt); !ok {
+			c.named(s.Declaration, "default")
+			c.emit(opInitName, c.name(defaultExport))
+		}
+	default:
+		c.throw("SyntaxError", "Unsupported statement %s", stmt.S())
+	}
+}
+
+// complete emits the instruction popping the value on top of the stack,
+// to the completion value in scripts.
+func (c *compiler) complete() {
+	if c.f.completion == nil {
+		c.emit(opPop)
+		return
+	}
+	c.initVariable(c.f.completion)
+}
+
+// body compiles the body of an if statement, which in sloppy mode code may
+// be a function declaration scoped as if in a block.
+func (c *compiler) body(stmt parser.Stmt) {
+	if _, ok := stmt.(*parser.FunctionDeclarationStmt); ok {
+		c.block(stmt, []parser.Stmt{stmt})
+		return
+	}
+	c.statement(stmt)
+}
+
+// block compiles the statements of a block in a scope holding its lexical
+// declarations, with key identifying the block.
+//
+// https://262.ecma-international.org/#sec-blockdeclarationinstantiation
+func (c *compiler) block(key interface{}, stmts []parser.Stmt) {
+	info := c.script.blockDeclarations(key, stmts)
+	if len(info.lexical) == 0 && len(info.functions) == 0 {
+		c.statements(stmts)
+		return
+	}
+	c.pushScope()
+	for _, decl := range info.lexical {
+		c.declare(decl.name, !decl.constant, false)
+	}
+	for _, fn := range info.functions {
+		c.declare(fn.BindingIdentifier.Name, true, true)
+	}
+	for _, fn := range info.functions {
+		name := fn.BindingIdentifier.Name
+		c.closure(fn, name)
+		c.initVariable(c.f.scope.vars[name])
+	}
+	c.statements(stmts)
+	c.popScope()
+}
+
+// variables compiles the initializers of a var, let or const statement.
+//
+// https://262.ecma-international.org/#sec-variable-statement-runtime-semantics-evaluation
+func (c *compiler) variables(s *parser.VariableStatement) {
+	lexical := s.Kind.Type != l.TVar
+	for _, decl := range s.Declarations {
+		if decl.Identifier == nil {
+			c.expr(decl.Init)
+			c.bind(decl.Pattern, lexical)
+			continue
+		}
+		name := decl.Identifier.Name
+		switch {
+		case decl.Init != nil:
+			c.named(decl.Init, name)
+		case lexical:
+			c.emit(opUndefined)
+		default:
+			continue
+		}
+		c.store(name, lexical)
+	}
+}
+
+// //////////////
+// Expressions //
+// //////////////
+
+// expr compiles expr, whose value the instructions push.
+//
+// https://262.ecma-international.org/#sec-ecmascript-language-expressions
+func (c *compiler) expr(expr parser.Expr) {
+	switch e := expr.(type) {
+	case *parser.ExprLiteral[float64]:
+		c.push(Number(numberLiteral(e.Token)))
+	case *parser.ExprLiteral[string]:
+		switch e.Token.Type {
+		case l.TTrue:
+			c.push(Bool(true))
+		case l.TFalse:
+			c.push(Bool(false))
+		case l.TNull:
+			c.push(Null)
+		case l.TUndefined:
+			c.push(Undefined)
+		case l.TThis:
+			c.emit(opThis)
+		case l.TSuper:
+			c.throw("SyntaxError", "'super' keyword unexpected here")
+		default:
+			c.push(c.script.stringLiteral(e))
+		}
+	case *parser.ExprIdentifier:
+		c.load(e.Name)
+	case *parser.ExprParenthesized:
+		c.expr(e.Expression)
+	case *parser.ExprSequence:
+		for i, expr := range e.Expressions {
+			if i > 0 {
+				c.emit(opPop)
+			}
+			c.expr(expr)
+		}
+	case *parser.ExprUnaryOp:
+		c.unary(e)
+	case *parser.ExprBinaryOp:
+		c.binary(e)
+	case *parser.ExprAssign:
+		c.assign(e)
+	case *parser.ExprConditional:
+		c.expr(e.Test)
+		jump := c.emit(opJumpIfFalse)
+		c.expr(e.Consequent)
+		end := c.emit(opJump)
+		c.patch(jump)
+		c.expr(e.Alternate)
+		c.patch(end)
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			c.superKey(e)
+			c.emit(opGetSuper)
+			return
+		}
+		c.expr(e.Object)
+		c.getMember(e)
+	case *parser.ExprCall:
+		if isSuper(e.Callee) {
+			c.throw("SyntaxError", "'super' keyword unexpected here")
+			return
+		}
+		c.callee(e.Callee)
+		c.arguments(e.Arguments, opCall, opCallSpread, calleeName(e.Callee))
+	case *parser.ExprNew:
+		// https://262.ecma-international.org/#sec-evaluatenew
+		c.expr(e.Callee)
+		c.arguments(e.Arguments, opNew, opNewSpread, calleeName(e.Callee))
+	case *parser.ExprChain:
+		var exits []int
+		c.chainLink(e.Expression, false, &exits)
+		for _, exit := range exits {
+			c.patch(exit)
+		}
+	case *parser.ExprMetaProperty:
+		if meta, ok := e.Meta.(*parser.ExprLiteral[string]); ok && meta.Token.Type == l.TImport {
+			c.emit(opImportMeta)
+			return
+		}
+		c.emit(opNewTarget)
+	case *parser.ExprFunction, *parser.ExprArrowFunction:
+		c.named(e, "")
+	case *parser.ExprArray:
+		c.array(e.Elements)
+	case *parser.ExprObject:
+		c.object(e)
+	case *parser.ExprTemplateLiteral:
+		// https://262.ecma-international.org/#sec-template-literals-runtime-semantics-evaluation
+		n := 0
+		for i, quasi := range e.Quasis {
+			if s := cookString(quasi.Raw); s != "" {
+				c.push(String(s))
+				n++
+			}
+			if i < len(e.Expressions) {
+				c.expr(e.Expressions[i])
+				c.emit(opToString)
+				n++
+			}
+		}
+		switch n {
+		case 0:
+			c.push(String(""))
+		case 1:
+		default:
+			c.emit(opConcat, n)
+		}
+	case *parser.ExprTaggedTemplate:
+		// https://262.ecma-international.org/#sec-tagged-templates-runtime-semantics-evaluation
+		c.callee(e.Tag)
+		c.f.code.nodes = append(c.f.code.nodes, e.Quasi)
+		c.emit(opTemplate, len(c.f.code.nodes)-1)
+		for _, expr := range e.Quasi.Expressions {
+			c.expr(expr)
+		}
+		c.emit(opCall, len(e.Quasi.Expressions)+1, c.constant(String(calleeName(e.Tag))))
+	case *parser.ExprRegExp:
+		c.f.code.nodes = append(c.f.code.nodes, e)
+		c.emit(opRegExp, len(c.f.code.nodes)-1)
+	case *parser.ExprYield:
+		if e.Argument == nil {
+			c.emit(opUndefined)
+		} else {
+			c.expr(e.Argument)
+		}
+		if e.Delegate {
+			c.emit(opYieldStar)
+		} else {
+			c.emit(opYield)
+		}
+	case *parser.ExprAwait:
+		if info := c.f.code.info; info == nil || !info.async {
+			c.throw("SyntaxError", "Top-level await is not supported")
+			return
+		}
+		c.expr(e.Argument)
+		c.emit(opAwait)
+	case *parser.ExprImportCall:
+		c.throw("SyntaxError", "Dynamic import is not supported")
+	default:
+		c.throw("SyntaxError", "Unsupported expression %s", expr.S())
+	}
+}
+
+// named compiles expr, naming anonymous functions after name, as
+// initializers and assignments do.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-namedevaluation
+func (c *compiler) named(expr parser.Expr, name string) {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprFunction:
+		if e.BindingIdentifier != nil {
+			name = e.BindingIdentifier.Name
+		}
+		c.closure(e, name)
+	case *parser.ExprArrowFunction:
+		c.closure(e, name)
+	default:
+		c.expr(expr)
+	}
+}
+
+// isAnonymousFunction reports whether expr is a function expression without
+// a name, named after what it is assigned to.
+//
+// https://262.ecma-international.org/#sec-isanonymousfunctiondefinition
+func isAnonymousFunction(expr parser.Expr) bool {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprFunction:
+		return e.BindingIdentifier == nil
+	case *parser.ExprArrowFunction:
+		return true
+	}
+	return false
+}
+
+// unary compiles a unary or update expression.
+//
+// https://262.ecma-international.org/#sec-unary-operators
+func (c *compiler) unary(e *parser.ExprUnaryOp) {
+	switch e.Operator.Type {
+	case l.TTypeof:
+		if id, ok := parser.Unparenthesized(e.Operand).(*parser.ExprIdentifier); ok {
+			if loc := c.resolve(id.Name); loc.kind == inName {
+				c.emit(opTypeofName, loc.index)
+				return
+			}
+		}
+		c.expr(e.Operand)
+		c.emit(opTypeof)
+	case l.TDelete:
+		c.delete(e.Operand)
+	case l.TPlusPlus, l.TMinusMinus:
+		c.update(e)
+	default:
+		c.expr(e.Operand)
+		c.emit(opUnary, int(e.Operator.Type))
+	}
+}
+
+// update compiles an increment or a decrement.
+//
+// https://262.ecma-international.org/#sec-update-expressions
+func (c *compiler) update(e *parser.ExprUnaryOp) {
+	op := opInc
+	if e.Operator.Type == l.TMinusMinus {
+		op = opDec
+	}
+	switch target := parser.Unparenthesized(e.Operand).(type) {
+	case *parser.ExprIdentifier:
+		c.load(target.Name)
+		c.emit(opToNumeric)
+		if e.Postfix {
+			c.emit(opDup)
+			c.emit(op)
+		} else {
+			c.emit(op)
+			c.emit(opDup)
+		}
+		c.store(target.Name, false)
+	case *parser.ExprMemberAccess:
+		ref := c.reference(target)
+		c.dupReference(ref)
+		c.getReference(ref)
+		c.emit(opToNumeric)
+		if e.Postfix {
+			// keep the old value below the reference
+			c.emit(opDup)
+			c.emit(ref.rotate())
+		}
+		c.emit(op)
+		c.putReference(ref)
+		if e.Postfix {
+			c.emit(opPop)
+		}
+	default:
+		c.throw("SyntaxError", "Invalid left-hand side in assignment")
+	}
+}
+
+// delete compiles the delete operator applied to expr.
+//
+// https://262.ecma-international.org/#sec-delete-operator-runtime-semantics-evaluation
+func (c *compiler) delete(expr parser.Expr) {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprIdentifier:
+		loc := c.resolve(e.Name)
+		if loc.kind != inName {
+			// declared bindings are not deletable
+			c.push(Bool(false))
+			return
+		}
+		c.emit(opDeleteName, loc.index)
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			c.throw("ReferenceError", "Unsupported reference to 'super'")
+			return
+		}
+		c.expr(e.Object)
+		c.deleteMember(e)
+	case *parser.ExprChain:
+		member, ok := e.Expression.(*parser.ExprMemberAccess)
+		if !ok {
+			c.expr(e)
+			c.emit(opPop)
+			c.push(Bool(true))
+			return
+		}
+		var exits []int
+		c.chainLink(member.Object, false, &exits)
+		if member.Optional {
+			exits = append(exits, c.emit(opShortCircuit, 0, 1))
+		}
+		c.deleteMember(member)
+		end := c.emit(opJump)
+		for _, exit := range exits {
+			c.patch(exit)
+		}
+		// a short-circuited chain deletes nothing
+		c.emit(opPop)
+		c.push(Bool(true))
+		c.patch(end)
+	default:
+		c.expr(expr)
+		c.emit(opPop)
+		c.push(Bool(true))
+	}
+}
+
+// deleteMember compiles the deletion of the property of e from the object
+// on top of the stack.
+func (c *compiler) deleteMember(e *parser.ExprMemberAccess) {
+	if e.Computed {
+		c.expr(e.Property)
+		c.emit(opDeleteElem)
+		return
+	}
+	id, ok := e.Property.(*parser.ExprIdentifier)
+	if !ok {
+		c.throw("SyntaxError", "Unexpected private field %s", e.Property.S())
+		return
+	}
+	c.emit(opDeleteProp, c.key(id.Name))
+}
+
+// binaryOpcodes are the instructions of the binary operators with a fast
+// path for numbers.
+var binaryOpcodes = map[l.TokenType]opcode{
+	l.TPlus: opAdd, l.TPlusAssign: opAdd,
+	l.TMinus: opSub, l.TMinusAssign: opSub,
+	l.TStar: opMul, l.TStarAssign: opMul,
+	l.TLessThan: opLess, l.TGreaterThan: opGreater,
+	l.TLessThanEqual: opLessEq, l.TGreaterThanEqual: opGreaterEq,
+	l.TStrictEqual: opStrictEq, l.TStrictNotEqual: opStrictNe,
+}
+
+// operator emits the instruction applying the binary operator op to the
+// two topmost values.
+func (c *compiler) operator(op l.TokenType) {
+	if code, ok := binaryOpcodes[op]; ok {
+		c.emit(code, int(op))
+		return
+	}
+	c.emit(opBinary, int(op))
+}
+
+// binary compiles a binary expression, short-circuiting logical operators.
+func (c *compiler) binary(e *parser.ExprBinaryOp) {
+	c.expr(e.Left)
+	var jump opcode
+	switch e.Operator.Type {
+	case l.TLogicalAnd:
+		jump = opJumpIfFalseKeep
+	case l.TLogicalOr:
+		jump = opJumpIfTrueKeep
+	case l.TDoubleQuestionMark:
+		jump = opJumpIfNotNullKeep
+	default:
+		c.expr(e.Right)
+		c.operator(e.Operator.Type)
+		return
+	}
+	end := c.emit(jump)
+	c.expr(e.Right)
+	c.patch(end)
+}
+
+// assign compiles an assignment expression.
+//
+// https://262.ecma-international.org/#sec-assignment-operators-runtime-semantics-evaluation
+func (c *compiler) assign(e *parser.ExprAssign) {
+	op := e.Operator.Type
+	right := e.Right.(parser.Expr)
+	if op == l.TAssign {
+		switch left := e.Left.(type) {
+		case *parser.ObjectPattern, *parser.ArrayPattern:
+			c.expr(right)
+			c.emit(opDup)
+			c.bind(left, false)
+			return
+		}
+	}
+	var short opcode
+	switch op {
+	case l.TLogicalAndAssign:
+		short = opJumpIfFalseKeep
+	case l.TLogicalOrAssign:
+		short = opJumpIfTrueKeep
+	}
+	// the value assigned, named after identifiers
+	value := func() {
+		if id, ok := e.Left.(*parser.ExprIdentifier); ok && (op == l.TAssign || short != 0) {
+			c.named(right, id.Name)
+			return
+		}
+		c.expr(right)
+	}
+
+	switch target := parser.Unparenthesized(e.Left.(parser.Expr)).(type) {
+	case *parser.ExprIdentifier:
+		if op == l.TAssign {
+			value()
+		} else {
+			c.load(target.Name)
+			if short != 0 {
+				end := c.emit(short)
+				value()
+				c.emit(opDup)
+				c.store(target.Name, false)
+				c.patch(end)
+				return
+			}
+			value()
+			c.operator(op)
+		}
+		c.emit(opDup)
+		c.store(target.Name, false)
+	case *parser.ExprMemberAccess:
+		ref := c.reference(target)
+		if op == l.TAssign {
+			value()
+			c.putReference(ref)
+			return
+		}
+		c.dupReference(ref)
+		c.getReference(ref)
+		if short == 0 {
+			value()
+			c.operator(op)
+			c.putReference(ref)
+			return
+		}
+		jump := c.emit(short)
+		value()
+		c.putReference(ref)
+		end := c.emit(opJump)
+		// the old value is the result, dropping the reference below it
+		c.patch(jump)
+		if ref.size() == 1 {
+			c.emit(opSwap)
+		} else {
+			c.emit(opRot3)
+		}
+		for i := 0; i < ref.size(); i++ {
+			c.emit(opPop)
+		}
+		c.patch(end)
+	default:
+		c.throw("SyntaxError", "Invalid left-hand side in assignment")
+	}
+}
+
+// /////////////
+// References //
+// /////////////
+
+// memberRef is a property access whose object and key are on the stack.
+type memberRef struct {
+	super bool
+	// the index of the key of a non-computed property of an object, -1
+	// when the key is on the stack
+	key int
+}
+
+// size returns the number of values the reference holds on the stack.
+func (ref memberRef) size() int {
+	if ref.super || ref.key >= 0 {
+		return 1
+	}
+	return 2
+}
+
+// rotate returns the instruction moving the top of the stack below the
+// reference.
+func (ref memberRef) rotate() opcode {
+	if ref.size() == 1 {
+		return opRot3
+	}
+	return opRot4
+}
+
+// reference emits the instructions pushing the object and the key of a
+// property access, converted to a property key.
+func (c *compiler) reference(e *parser.ExprMemberAccess) memberRef {
+	if isSuper(e.Object) {
+		c.superKey(e)
+		return memberRef{super: true, key: -1}
+	}
+	c.expr(e.Object)
+	if e.Computed {
+		c.expr(e.Property)
+		c.emit(opToKey)
+		return memberRef{key: -1}
+	}
+	id, ok := e.Property.(*parser.ExprIdentifier)
+	if !ok {
+		c.throw("SyntaxError", "Unexpected private field %s", e.Property.S())
+		return memberRef{key: c.key("")}
+	}
+	return memberRef{key: c.key(id.Name)}
+}
+
+// superKey emits the instructions pushing the key of a super property
+// access.
+func (c *compiler) superKey(e *parser.ExprMemberAccess) {
+	if e.Computed {
+		c.expr(e.Property)
+		c.emit(opToKey)
+		return
+	}
+	id, ok := e.Property.(*parser.ExprIdentifier)
+	if !ok {
+		c.throw("SyntaxError", "Unexpected private field %s", e.Property.S())
+		return
+	}
+	c.push(String(id.Name))
+}
+
+func (c *compiler) dupReference(ref memberRef) {
+	if ref.size() == 1 {
+		c.emit(opDup)
+	} else {
+		c.emit(opDup2)
+	}
+}
+
+// getReference emits the instruction replacing the reference with the
+// value of the property.
+func (c *compiler) getReference(ref memberRef) {
+	switch {
+	case ref.super:
+		c.emit(opGetSuper)
+	case ref.key >= 0:
+		c.emit(opGetProp, ref.key)
+	default:
+		c.emit(opGetElem)
+	}
+}
+
+// putReference emits the instruction assigning the value on top of the
+// stack to the property, replacing the reference and the value with the
+// value.
+func (c *compiler) putReference(ref memberRef) {
+	switch {
+	case ref.super:
+		c.emit(opSetSuper)
+	case ref.key >= 0:
+		c.emit(opSetProp, ref.key)
+	default:
+		c.emit(opSetElem)
+	}
+}
+
+// getMember emits the instructions replacing the object on top of the
+// stack with the value of the property of e.
+func (c *compiler) getMember(e *parser.ExprMemberAccess) {
+	if e.Computed {
+		c.expr(e.Property)
+		c.emit(opGetElem)
+		return
+	}
+	id, ok := e.Property.(*parser.ExprIdentifier)
+	if !ok {
+		c.throw("SyntaxError", "Unexpected private field %s", e.Property.S())
+		return
+	}
+	c.emit(opGetProp, c.key(id.Name))
+}
+
+// ////////
+// Calls //
+// ////////
+
+// callee emits the instructions pushing the callee of a call, followed by
+// the this value of the call: the object of a property access, also within
+// an optional chain.
+func (c *compiler) callee(expr parser.Expr) {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprChain:
+		// (a?.b)() calls b with a as this, unless the chain short-circuits
+		var exits []int
+		c.chainLink(e.Expression, true, &exits)
+		if len(exits) == 0 {
+			return
+		}
+		end := c.emit(opJump)
+		for _, exit := range exits {
+			c.patch(exit)
+		}
+		// a short-circuited chain pushed undefined as the callee
+		c.emit(opUndefined)
+		c.patch(end)
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			c.superKey(e)
+			c.emit(opGetSuper)
+			c.emit(opThis)
+			return
+		}
+		c.expr(e.Object)
+		c.emit(opDup)
+		c.getMember(e)
+		c.emit(opSwap)
+	default:
+		c.expr(expr)
+		c.emit(opUndefined)
+	}
+}
+
+// arguments emits the instructions pushing the arguments of a call, and the
+// call itself: op, or spread when an argument is spread, with the array of
+// the arguments.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-argumentlistevaluation
+func (c *compiler) arguments(exprs []parser.Expr, op, spread opcode, callee string) {
+	name := c.constant(String(callee))
+	for _, expr := range exprs {
+		if _, ok := expr.(*parser.SpreadElement); ok {
+			c.array(exprs)
+			c.emit(spread, 0, name)
+			return
+		}
+	}
+	for _, expr := range exprs {
+		c.expr(expr)
+	}
+	c.emit(op, len(exprs), name)
+}
+
+// chainLink compiles a link of an optional chain, pushing its value,
+// followed by the this value of a call to it when this is set. The jumps
+// of the optional links short-circuiting the chain are added to exits.
+//
+// https://262.ecma-international.org/#sec-optional-chaining-chain-evaluation
+func (c *compiler) chainLink(expr parser.Expr, this bool, exits *[]int) {
+	switch e := expr.(type) {
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			c.callee(e)
+			if !this {
+				c.emit(opPop)
+			}
+			return
+		}
+		c.chainLink(e.Object, false, exits)
+		if e.Optional {
+			*exits = append(*exits, c.emit(opShortCircuit, 0, 1))
+		}
+		if this {
+			c.emit(opDup)
+		}
+		c.getMember(e)
+		if this {
+			c.emit(opSwap)
+		}
+	case *parser.ExprCall:
+		c.chainLink(e.Callee, true, exits)
+		if e.Optional {
+			c.emit(opSwap)
+			*exits = append(*exits, c.emit(opShortCircuit, 0, 2))
+			c.emit(opSwap)
+		}
+		c.arguments(e.Arguments, opCall, opCallSpread, calleeName(e.Callee))
+		if this {
+			c.emit(opUndefined)
+		}
+	default:
+		c.expr(expr)
+		if this {
+			c.emit(opUndefined)
+		}
+	}
+}
+
+// ///////////
+// Literals //
+// ///////////
+
+// array compiles an array literal, or the arguments of a call spreading
+// iterables.
+//
+// https://262.ecma-international.org/#sec-array-initializer-runtime-semantics-evaluation
+func (c *compiler) array(elements []parser.Expr) {
+	c.emit(opNewArray)
+	for _, element := range elements {
+		if element == parser.Expr(parser.ArrayHole) {
+			c.emit(opArrayHole)
+			continue
+		}
+		if spread, ok := element.(*parser.SpreadElement); ok {
+			c.expr(spread.Argument)
+			c.emit(opArraySpread)
+			continue
+		}
+		c.expr(element)
+		c.emit(opArrayPush)
+	}
+}
+
+// object compiles an object literal.
+//
+// https://262.ecma-international.org/#sec-object-initializer-runtime-semantics-evaluation
+func (c *compiler) object(e *parser.ExprObject) {
+	c.emit(opNewObject)
+	for _, p := range e.Properties {
+		if spread, ok := p.Value.(*parser.SpreadElement); ok {
+			c.expr(spread.Argument)
+			c.emit(opCopyData)
+			continue
+		}
+		if isProtoSetter(p) {
+			c.expr(p.Value)
+			c.emit(opSetProto)
+			continue
+		}
+		name, static := c.staticPropertyName(p.Key, p.Computed)
+		kind := -1
+		switch {
+		case p.Kind == parser.PropertyGet:
+			kind = methodGetter
+		case p.Kind == parser.PropertySet:
+			kind = methodSetter
+		case p.Method:
+			kind = methodPlain
+		}
+		switch {
+		case kind >= 0:
+			if static {
+				c.push(String(name))
+			} else {
+				c.expr(p.Key)
+				c.emit(opToKey)
+			}
+			tmpl := c.function(p.Value, name)
+			c.f.code.functions = append(c.f.code.functions, tmpl)
+			c.emit(opMethod, len(c.f.code.functions)-1, kind)
+		case static:
+			c.named(p.Value, name)
+			c.emit(opDefineProp, c.key(name))
+		default:
+			c.expr(p.Key)
+			c.emit(opToKey)
+			c.expr(p.Value)
+			naming := 0
+			if isAnonymousFunction(p.Value) {
+				naming = 1
+			}
+			c.emit(opDefineElem, 0, naming)
+		}
+	}
+}
+
+// staticPropertyName returns the name of a property in an object literal or
+// pattern when it is known without evaluating code.
+func (c *compiler) staticPropertyName(key parser.Expr, computed bool) (string, bool) {
+	if computed {
+		return "", false
+	}
+	switch k := key.(type) {
+	case *parser.ExprIdentifier:
+		return k.Name, true
+	case *parser.ExprLiteral[string]:
+		return string(c.script.stringLiteral(k)), true
+	case *parser.ExprLiteral[float64]:
+		return numberToString(numberLiteral(k.Token)), true
+	}
+	return "", false
+}
+
+// ///////////
+// Patterns //
+// ///////////
+
+// bind compiles the destructuring of the value on top of the stack, which
+// it pops, into the target of a binding or an assignment. The identifiers
+// of target are initialized if init is set, or assigned to.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-bindinginitialization
+// https://262.ecma-international.org/#sec-runtime-semantics-destructuringassignmentevaluation
+func (c *compiler) bind(target parser.Node, init bool) {
+	switch t := target.(type) {
+	case *parser.ExprIdentifier:
+		c.store(t.Name, init)
+	case *parser.ObjectPattern:
+		c.emit(opCoercible)
+		rest := false
+		for _, p := range t.Properties {
+			if _, ok := p.(*parser.RestElement); ok {
+				rest = true
+			}
+		}
+		if rest {
+			c.emit(opExcludeNew)
+		}
+		for _, p := range t.Properties {
+			switch p := p.(type) {
+			case *parser.PatternProperty:
+				if name, ok := c.staticPropertyName(p.Key, p.Computed); ok {
+					key := c.key(name)
+					if rest {
+						c.emit(opExcludeProp, key)
+					}
+					c.element(p.Value, init, func(refs int) {
+						c.emit(opPick, refs)
+						c.emit(opGetProp, key)
+					})
+					continue
+				}
+				c.expr(p.Key)
+				c.emit(opToKey)
+				if rest {
+					c.emit(opExcludeKey)
+				}
+				// the key stays below the reference of the target
+				c.element(p.Value, init, func(refs int) {
+					c.emit(opPick, refs+1)
+					c.emit(opPick, refs+1)
+					c.emit(opGetElem)
+				})
+				c.emit(opPop)
+			case *parser.RestElement:
+				c.element(p.Argument, init, func(refs int) {
+					c.emit(opPick, refs)
+					c.emit(opCopyRest)
+				})
+			}
+		}
+		c.emit(opPop)
+	case *parser.ArrayPattern:
+		c.f.code.iterates = true
+		c.emit(opGetIterator)
+		for _, element := range t.Elements {
+			switch element := element.(type) {
+			case nil:
+				c.emit(opIteratorNext)
+				c.emit(opPop)
+			case *parser.RestElement:
+				c.element(element.Argument, init, func(int) { c.emit(opIteratorRest) })
+			default:
+				c.element(element, init, func(int) { c.emit(opIteratorNext) })
+			}
+		}
+		c.emit(opIteratorEnd)
+	case *parser.AssignmentPattern:
+		// the value is on the stack already, below no reference
+		c.element(t, init, func(int) {})
+	default:
+		c.element(target, init, func(refs int) {
+			c.emit(opPick, refs)
+		})
+		c.emit(opPop)
+	}
+}
+
+// element compiles the binding of the target of an element or property of
+// a pattern, with its default value, to the value get pushes. The
+// reference of a target that is a property access is pushed before, its
+// size passed to get.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-iteratorbindinginitialization
+func (c *compiler) element(target parser.Node, init bool, get func(refs int)) {
+	var def parser.Expr
+	if assignment, ok := target.(*parser.AssignmentPattern); ok {
+		target, def = assignment.Left, assignment.Right
+	}
+	var ref *memberRef
+	if !init {
+		if e, ok := target.(parser.Expr); ok {
+			switch t := parser.Unparenthesized(e).(type) {
+			case *parser.ObjectPattern, *parser.ArrayPattern, *parser.ExprIdentifier:
+			case *parser.ExprMemberAccess:
+				ref = new(memberRef)
+				*ref = c.reference(t)
+			default:
+				c.throw("SyntaxError", "Invalid left-hand side in assignment")
+				return
+			}
+		}
+	}
+
+	refs := 0
+	if ref != nil {
+		refs = ref.size()
+	}
+	get(refs)
+	if def != nil {
+		jump := c.emit(opJumpIfNotUndefined)
+		if id, ok := target.(*parser.ExprIdentifier); ok {
+			c.named(def, id.Name)
+		} else {
+			c.expr(def)
+		}
+		c.patch(jump)
+	}
+	if ref != nil {
+		c.putReference(*ref)
+		c.emit(opPop)
+		return
+	}
+	if e, ok := target.(parser.Expr); ok {
+		if id, ok := parser.Unparenthesized(e).(*parser.ExprIdentifier); ok {
+			c.store(id.Name, init)
+			return
+		}
+	}
+	c.bind(target, init)
+}
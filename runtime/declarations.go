@@ -0,0 +1,330 @@
+package runtime
+
+import (
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+	"github.com/ruiconti/gojs/scope"
+)
+
+// functionInfo is what the calls of a function need to know about its code,
+// computed once per function.
+//
+// https://262.ecma-international.org/#sec-functiondeclarationinstantiation
+type functionInfo struct {
+	name       *parser.ExprIdentifier
+	params     []parser.Node
+	body       []parser.Stmt
+	expression parser.Expr // the concise body of an arrow function
+
+	strict    bool
+	arrow     bool
+	generator bool
+	async     bool
+
+	// length is the number of parameters before the first one with a
+	// default value, or the rest parameter
+	length     int
+	paramNames []string
+	// whether every parameter is a plain identifier
+	simpleParams bool
+	// whether some parameter has a default value or a computed key, which
+	// gets the parameters their own environment
+	hasParamExpressions bool
+	// whether the function gets an arguments object
+	argumentsNeeded bool
+	declarations    *declarations
+}
+
+// declarations are the names declared by the top level of a function body, a
+// script or a module.
+//
+// https://262.ecma-international.org/#sec-static-semantics-varscopeddeclarations
+type declarations struct {
+	// the names of var declarations, nested in blocks or not
+	varNames []string
+	// the function declarations initialized on entry, the last one of a
+	// name taking precedence
+	functions []*parser.FunctionDeclarationStmt
+	// let and const declarations
+	lexical []lexicalName
+	// the functions declared in blocks that are also bound in the var
+	// scope, as Annex B allows in sloppy mode code
+	annexB map[*parser.FunctionDeclarationStmt]bool
+}
+
+type lexicalName struct {
+	name     string
+	constant bool
+}
+
+// blockInfo are the lexical declarations of a block.
+type blockInfo struct {
+	lexical   []lexicalName
+	functions []*parser.FunctionDeclarationStmt
+}
+
+// functionInfo returns the description of the function node of the script,
+// computing it on first use.
+func (s *script) functionInfo(node parser.Node) *functionInfo {
+	if info, ok := s.functions[node]; ok {
+		return info
+	}
+	info := &functionInfo{}
+	switch fn := node.(type) {
+	case *parser.FunctionDeclarationStmt:
+		info.name, info.params, info.body = fn.BindingIdentifier, fn.Params, fn.Body
+		info.strict, info.generator, info.async = fn.Strict, fn.Generator, fn.Async
+	case *parser.ExprFunction:
+		info.name, info.params, info.body = fn.BindingIdentifier, fn.Params, fn.Body
+		info.strict, info.generator, info.async = fn.Strict, fn.Generator, fn.Async
+	case *parser.ExprArrowFunction:
+		info.params, info.body, info.expression = fn.Params, fn.Body, fn.Expression
+		info.strict, info.async, info.arrow = fn.Strict, fn.Async, true
+	}
+
+	info.simpleParams = true
+	info.length = -1
+	for i, param := range info.params {
+		for _, id := range scope.BindingIdentifiers(param) {
+			info.paramNames = append(info.paramNames, id.Name)
+		}
+		switch param.(type) {
+		case *parser.ExprIdentifier:
+			continue
+		case *parser.AssignmentPattern, *parser.RestElement:
+			if info.length < 0 {
+				info.length = i
+			}
+		}
+		info.simpleParams = false
+		parser.Walk(param, func(node parser.Node) bool {
+			switch node := node.(type) {
+			case *parser.AssignmentPattern:
+				info.hasParamExpressions = true
+			case *parser.PatternProperty:
+				info.hasParamExpressions = info.hasParamExpressions || node.Computed
+			}
+			return !info.hasParamExpressions
+		})
+	}
+	if info.length < 0 {
+		info.length = len(info.params)
+	}
+
+	info.declarations = declarationsOf(info.body, info.strict, info.paramNames)
+	info.argumentsNeeded = !info.arrow && usesArguments(node)
+	for _, name := range info.paramNames {
+		if name == "arguments" {
+			info.argumentsNeeded = false
+		}
+	}
+	if !info.hasParamExpressions {
+		for _, fn := range info.declarations.functions {
+			if fn.BindingIdentifier.Name == "arguments" {
+				info.argumentsNeeded = false
+			}
+		}
+		for _, decl := range info.declarations.lexical {
+			if decl.name == "arguments" {
+				info.argumentsNeeded = false
+			}
+		}
+	}
+	s.functions[node] = info
+	return info
+}
+
+// usesArguments reports whether the code of the function fn may refer to
+// its arguments object: by name, from arrow functions within it or through
+// a direct eval.
+func usesArguments(fn parser.Node) bool {
+	uses := false
+	parser.Walk(fn, func(node parser.Node) bool {
+		switch node := node.(type) {
+		case *parser.ExprIdentifier:
+			uses = uses || node.Name == "arguments" || node.Name == "eval"
+		case *parser.FunctionDeclarationStmt, *parser.ExprFunction:
+			return node == fn
+		}
+		return !uses
+	})
+	return uses
+}
+
+// declarationsOf returns the declarations of the top level statements of a
+// function body, script or module. Sloppy mode code binds functions
+// declared in blocks in the var scope too, unless that would clash with
+// names in paramNames or lexical declarations.
+func declarationsOf(stmts []parser.Stmt, strict bool, paramNames []string) *declarations {
+	d := &declarations{}
+	seenVars := make(map[string]bool)
+	var hoist func(stmt parser.Stmt, topLevel bool)
+	hoist = func(stmt parser.Stmt, topLevel bool) {
+		switch stmt := stmt.(type) {
+		case *parser.VariableStatement:
+			if stmt.Kind.Type == l.TVar {
+				for _, decl := range stmt.Declarations {
+					for _, id := range declarationIdentifiers(decl) {
+						if !seenVars[id.Name] {
+							seenVars[id.Name] = true
+							d.varNames = append(d.varNames, id.Name)
+						}
+					}
+				}
+			} else if topLevel {
+				for _, decl := range stmt.Declarations {
+					for _, id := range declarationIdentifiers(decl) {
+						d.lexical = append(d.lexical, lexicalName{name: id.Name, constant: stmt.Kind.Type == l.TConst})
+					}
+				}
+			}
+		case *parser.FunctionDeclarationStmt:
+			if topLevel {
+				d.functions = append(d.functions, stmt)
+			}
+		case *parser.BlockStatement:
+			for _, stmt := range stmt.Stmts {
+				hoist(stmt, false)
+			}
+		case *parser.IfStatement:
+			hoist(stmt.ThenStmt, false)
+			if stmt.ElseStmt != nil {
+				hoist(stmt.ElseStmt, false)
+			}
+		case *parser.WithStatement:
+			hoist(stmt.Body, false)
+		case *parser.LabelledStatement:
+			hoist(stmt.Body, topLevel)
+		case *parser.ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				hoist(stmt.Declaration, topLevel)
+			}
+		case *parser.ExportDefaultDeclaration:
+			if fn, ok := stmt.Declaration.(*parser.FunctionDeclarationStmt); ok {
+				hoist(fn, topLevel)
+			}
+		}
+	}
+	for _, stmt := range stmts {
+		hoist(stmt, true)
+	}
+	if !strict {
+		d.annexB = annexBFunctions(stmts, d, paramNames)
+	}
+	return d
+}
+
+// declarationIdentifiers returns the identifiers bound by decl.
+func declarationIdentifiers(decl *parser.VariableDeclaration) []*parser.ExprIdentifier {
+	if decl.Identifier != nil {
+		return []*parser.ExprIdentifier{decl.Identifier}
+	}
+	return scope.BindingIdentifiers(decl.Pattern)
+}
+
+// annexBFunctions returns the functions declared in the blocks of stmts that
+// a var declaration of their name could replace without clashing with the
+// lexical declarations of the blocks enclosing them or of the top level, or
+// with a parameter.
+//
+// https://262.ecma-international.org/#sec-block-level-function-declarations-web-legacy-compatibility-semantics
+func annexBFunctions(stmts []parser.Stmt, d *declarations, paramNames []string) map[*parser.FunctionDeclarationStmt]bool {
+	excluded := make(map[string]int)
+	for _, name := range paramNames {
+		excluded[name]++
+	}
+	for _, decl := range d.lexical {
+		excluded[decl.name]++
+	}
+
+	functions := make(map[*parser.FunctionDeclarationStmt]bool)
+	var visit func(stmts []parser.Stmt, isBlock bool)
+	visitBody := func(stmt parser.Stmt) {
+		switch stmt.(type) {
+		case *parser.FunctionDeclarationStmt:
+			// if (a) function f() {}, as if within a block
+			visit([]parser.Stmt{stmt}, true)
+		default:
+			visit([]parser.Stmt{stmt}, false)
+		}
+	}
+	visit = func(stmts []parser.Stmt, isBlock bool) {
+		var names []string
+		if isBlock {
+			info := blockDeclarationsOf(stmts)
+			for _, fn := range info.functions {
+				if excluded[fn.BindingIdentifier.Name] == 0 && !fn.Generator && !fn.Async {
+					functions[fn] = true
+				}
+			}
+			for _, decl := range info.lexical {
+				names = append(names, decl.name)
+			}
+			for _, fn := range info.functions {
+				names = append(names, fn.BindingIdentifier.Name)
+			}
+		}
+		for _, name := range names {
+			excluded[name]++
+		}
+		for _, stmt := range stmts {
+			switch stmt := stmt.(type) {
+			case *parser.BlockStatement:
+				visit(stmt.Stmts, true)
+			case *parser.IfStatement:
+				visitBody(stmt.ThenStmt)
+				if stmt.ElseStmt != nil {
+					visitBody(stmt.ElseStmt)
+				}
+			case *parser.WithStatement:
+				visitBody(stmt.Body)
+			case *parser.LabelledStatement:
+				visit([]parser.Stmt{stmt.Body}, false)
+			}
+		}
+		for _, name := range names {
+			excluded[name]--
+		}
+	}
+	visit(stmts, false)
+	return functions
+}
+
+// blockDeclarations returns the lexical declarations of a block of the
+// script, computing them on first use.
+func (s *script) blockDeclarations(key interface{}, stmts []parser.Stmt) *blockInfo {
+	if info, ok := s.blocks[key]; ok {
+		return info
+	}
+	info := blockDeclarationsOf(stmts)
+	s.blocks[key] = info
+	return info
+}
+
+// blockDeclarationsOf returns the let, const and function declarations of
+// the statements of a block.
+func blockDeclarationsOf(stmts []parser.Stmt) *blockInfo {
+	info := &blockInfo{}
+	var declare func(stmt parser.Stmt)
+	declare = func(stmt parser.Stmt) {
+		switch stmt := stmt.(type) {
+		case *parser.VariableStatement:
+			if stmt.Kind.Type == l.TVar {
+				return
+			}
+			for _, decl := range stmt.Declarations {
+				for _, id := range declarationIdentifiers(decl) {
+					info.lexical = append(info.lexical, lexicalName{name: id.Name, constant: stmt.Kind.Type == l.TConst})
+				}
+			}
+		case *parser.FunctionDeclarationStmt:
+			info.functions = append(info.functions, stmt)
+		case *parser.LabelledStatement:
+			declare(stmt.Body)
+		}
+	}
+	for _, stmt := range stmts {
+		declare(stmt)
+	}
+	return info
+}
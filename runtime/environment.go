@@ -0,0 +1,72 @@
+package runtime
+
+// binding is a variable of a declarative environment.
+type binding struct {
+	value Value
+	// whether the binding is out of its temporal dead zone
+	initialized bool
+	mutable     bool
+	// whether assignments to the immutable binding are ignored in sloppy
+	// mode code, as they are to the name of a function expression
+	silent bool
+	// whether the binding can be deleted, as the var declarations of eval
+	// code can
+	deletable bool
+	// the binding exported by another module that an import binding
+	// refers to
+	indirect *binding
+}
+
+// environment is an Environment Record: the bindings of a scope, chained to
+// the enclosing scope. Object environments back their bindings with the
+// properties of an object, as the global object or that of a with statement.
+//
+// https://262.ecma-international.org/#sec-environment-records
+type environment struct {
+	outer    *environment
+	bindings map[string]*binding
+	object   *Object
+	// whether the object environment is that of a with statement, whose
+	// object provides this to the functions called through it
+	with bool
+}
+
+func newEnvironment(outer *environment) *environment {
+	return &environment{outer: outer, bindings: make(map[string]*binding)}
+}
+
+// declare creates a binding for name, initialized unless lexical.
+func (env *environment) declare(name string, mutable, initialized bool) *binding {
+	b := &binding{value: Undefined, mutable: mutable, initialized: initialized}
+	env.bindings[name] = b
+	return b
+}
+
+// resolve returns the environment holding the binding of name, along with
+// the binding itself in declarative environments. It returns a nil
+// environment when name is not bound.
+//
+// https://262.ecma-international.org/#sec-getidentifierreference
+func (env *environment) resolve(name string) (*environment, *binding) {
+	for e := env; e != nil; e = e.outer {
+		if e.object == nil {
+			if b, ok := e.bindings[name]; ok {
+				return e, b
+			}
+			continue
+		}
+		key := stringKey(name)
+		if !e.object.hasProperty(key) {
+			continue
+		}
+		if e.with {
+			// names listed by @@unscopables are not bound by with
+			unscopables, ok := e.object.get(symbolKey(SymbolUnscopables), e.object).(*Object)
+			if ok && toBoolean(unscopables.get(key, unscopables)) {
+				continue
+			}
+		}
+		return e, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,150 @@
+package runtime
+
+import "github.com/ruiconti/gojs/parser"
+
+// directEval runs x as a direct call to eval does: as code in the scope of
+// the caller.
+//
+// https://262.ecma-international.org/#sec-function-calls-runtime-semantics-evaluation
+func (c *context) directEval(x Value) Value {
+	src, ok := x.(String)
+	if !ok {
+		return x
+	}
+	return c.r.evalCode(string(src), c)
+}
+
+// indirectEval runs x as other calls to eval do: as code in the global
+// scope.
+//
+// https://262.ecma-international.org/#sec-eval-x
+func (r *Runtime) indirectEval(x Value) Value {
+	src, ok := x.(String)
+	if !ok {
+		return x
+	}
+	return r.evalCode(string(src), r.newContext(nil, false))
+}
+
+// evalCode runs src as eval code called by the code of caller, and returns
+// its completion value. Its var and function declarations are added to the
+// var scope of the caller unless either of them is strict mode code, and
+// may be deleted.
+//
+// https://262.ecma-international.org/#sec-performeval
+func (r *Runtime) evalCode(src string, caller *context) Value {
+	file, err := parse(src, parser.Options{
+		SourceType:    parser.SourceTypeScript,
+		AnnexB:        true,
+		Strict:        caller.strict,
+		NewTarget:     caller.thisContext.function,
+		SuperProperty: caller.thisContext.homeObject != nil,
+	})
+	if err != nil {
+		panic(r.syntaxError(err))
+	}
+	s := newScript("<eval>", file)
+	r.enter("eval", s)
+	defer r.leave()
+
+	strict := caller.strict || file.Program.Strict
+	c := &context{r: r, script: s, strict: strict, thisContext: caller.thisContext, module: caller.module}
+	c.env = newEnvironment(caller.env)
+	c.varEnv = caller.varEnv
+	if strict {
+		c.varEnv = c.env
+	}
+	stmts := statements(file.Program)
+	d := declarationsOf(stmts, strict, nil)
+	c.annexB = d.annexB
+	r.instantiateEval(c, d)
+
+	v, _ := c.executeStatements(stmts)
+	if v == nil {
+		return Undefined
+	}
+	return v
+}
+
+// instantiateEval declares the names of eval code, failing when its var
+// declarations clash with the lexical declarations of the scopes between
+// the code and its var scope.
+//
+// https://262.ecma-international.org/#sec-evaldeclarationinstantiation
+func (r *Runtime) instantiateEval(c *context, d *declarations) {
+	lexEnv, varEnv := c.env, c.varEnv
+	global := varEnv == r.varEnv
+	if !c.strict {
+		names := append([]string(nil), d.varNames...)
+		for _, fn := range d.functions {
+			names = append(names, fn.BindingIdentifier.Name)
+		}
+		for _, name := range names {
+			if global {
+				if _, ok := r.globalEnv.bindings[name]; ok {
+					panic(r.newSyntaxError("Identifier '%s' has already been declared", name))
+				}
+			}
+			if lexicallyDeclared(lexEnv.outer, varEnv, name) {
+				panic(r.newSyntaxError("Identifier '%s' has already been declared", name))
+			}
+		}
+		for fn := range d.annexB {
+			name := fn.BindingIdentifier.Name
+			if lexicallyDeclared(lexEnv.outer, varEnv, name) || global && r.globalEnv.bindings[name] != nil {
+				delete(d.annexB, fn)
+			}
+		}
+	}
+	if global {
+		for _, fn := range d.functions {
+			r.checkGlobalFunction(fn.BindingIdentifier.Name)
+		}
+		for _, name := range d.varNames {
+			r.checkGlobalVar(name)
+		}
+	}
+
+	for _, decl := range d.lexical {
+		lexEnv.declare(decl.name, !decl.constant, false)
+	}
+	for _, fn := range d.functions {
+		name := fn.BindingIdentifier.Name
+		f := c.newFunction(fn, lexEnv, stringKey(name), "", nil)
+		if global {
+			r.createGlobalFunction(name, f, true)
+			continue
+		}
+		b, ok := varEnv.bindings[name]
+		if !ok {
+			b = varEnv.declare(name, true, true)
+			b.deletable = true
+		}
+		b.value = f
+	}
+	names := append([]string(nil), d.varNames...)
+	for fn := range d.annexB {
+		names = append(names, fn.BindingIdentifier.Name)
+	}
+	for _, name := range names {
+		if global {
+			r.createGlobalVar(name, true)
+		} else if _, ok := varEnv.bindings[name]; !ok {
+			varEnv.declare(name, true, true).deletable = true
+		}
+	}
+}
+
+// lexicallyDeclared reports whether name is bound by a declarative
+// environment from env up to, but excluding, varEnv.
+func lexicallyDeclared(env, varEnv *environment, name string) bool {
+	for e := env; e != nil && e != varEnv; e = e.outer {
+		if e.object != nil {
+			continue
+		}
+		if _, ok := e.bindings[name]; ok {
+			return true
+		}
+	}
+	return false
+}
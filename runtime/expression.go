@@ -0,0 +1,744 @@
+package runtime
+
+import (
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// evaluate evaluates expr and returns its value.
+//
+// https://262.ecma-international.org/#sec-ecmascript-language-expressions
+func (c *context) evaluate(expr parser.Expr) Value {
+	r := c.r
+	switch e := expr.(type) {
+	case *parser.ExprLiteral[float64]:
+		return Number(numberLiteral(e.Token))
+	case *parser.ExprLiteral[string]:
+		switch e.Token.Type {
+		case l.TTrue:
+			return Bool(true)
+		case l.TFalse:
+			return Bool(false)
+		case l.TNull:
+			return Null
+		case l.TUndefined:
+			return Undefined
+		case l.TThis:
+			return c.thisContext.this
+		case l.TSuper:
+			panic(r.newSyntaxError("'super' keyword unexpected here"))
+		}
+		return c.script.stringLiteral(e)
+	case *parser.ExprIdentifier:
+		return c.getIdentifier(e.Name)
+	case *parser.ExprParenthesized:
+		return c.evaluate(e.Expression)
+	case *parser.ExprSequence:
+		v := Undefined
+		for _, expr := range e.Expressions {
+			v = c.evaluate(expr)
+		}
+		return v
+	case *parser.ExprUnaryOp:
+		return c.evaluateUnary(e)
+	case *parser.ExprBinaryOp:
+		switch e.Operator.Type {
+		case l.TLogicalAnd:
+			if left := c.evaluate(e.Left); !toBoolean(left) {
+				return left
+			}
+			return c.evaluate(e.Right)
+		case l.TLogicalOr:
+			if left := c.evaluate(e.Left); toBoolean(left) {
+				return left
+			}
+			return c.evaluate(e.Right)
+		case l.TDoubleQuestionMark:
+			if left := c.evaluate(e.Left); !isNullish(left) {
+				return left
+			}
+			return c.evaluate(e.Right)
+		}
+		left := c.evaluate(e.Left)
+		return r.binaryOperation(e.Operator.Type, left, c.evaluate(e.Right))
+	case *parser.ExprAssign:
+		return c.evaluateAssign(e)
+	case *parser.ExprConditional:
+		if toBoolean(c.evaluate(e.Test)) {
+			return c.evaluate(e.Consequent)
+		}
+		return c.evaluate(e.Alternate)
+	case *parser.ExprMemberAccess:
+		return c.getValue(c.memberReference(e))
+	case *parser.ExprCall:
+		return c.evaluateCall(e)
+	case *parser.ExprNew:
+		// https://262.ecma-international.org/#sec-evaluatenew
+		callee := c.evaluate(e.Callee)
+		args := c.arguments(e.Arguments)
+		if !isConstructor(callee) {
+			panic(r.newTypeError("%s is not a constructor", calleeName(e.Callee)))
+		}
+		return r.constructObject(callee, args, nil)
+	case *parser.ExprChain:
+		if v, _, ok := c.evaluateChain(e.Expression); ok {
+			return v
+		}
+		return Undefined
+	case *parser.ExprMetaProperty:
+		if meta, ok := e.Meta.(*parser.ExprLiteral[string]); ok && meta.Token.Type == l.TImport {
+			return r.importMeta(c.module)
+		}
+		return c.thisContext.newTarget
+	case *parser.ExprFunction:
+		return c.functionExpression(e, stringKey(""))
+	case *parser.ExprArrowFunction:
+		return c.newFunction(e, c.env, stringKey(""), "", nil)
+	case *parser.ExprArray:
+		return c.evaluateArray(e)
+	case *parser.ExprObject:
+		return c.evaluateObject(e)
+	case *parser.ExprTemplateLiteral:
+		// https://262.ecma-international.org/#sec-template-literals-runtime-semantics-evaluation
+		var s strings.Builder
+		for i, quasi := range e.Quasis {
+			s.WriteString(cookString(quasi.Raw))
+			if i < len(e.Expressions) {
+				s.WriteString(r.toString(c.evaluate(e.Expressions[i])))
+			}
+		}
+		return String(joinSurrogates(s.String()))
+	case *parser.ExprTaggedTemplate:
+		// https://262.ecma-international.org/#sec-tagged-templates-runtime-semantics-evaluation
+		fn, this := c.callee(e.Tag)
+		args := []Value{r.templateObject(c.script, e.Quasi)}
+		for _, expr := range e.Quasi.Expressions {
+			args = append(args, c.evaluate(expr))
+		}
+		return c.call(fn, this, args, e.Tag)
+	case *parser.ExprRegExp:
+		return r.newRegExp(e.Pattern, e.Flags, r.regexpPrototype)
+	case *parser.ExprYield:
+		v := Undefined
+		if e.Argument != nil {
+			v = c.evaluate(e.Argument)
+		}
+		if e.Delegate {
+			return c.generator.yieldDelegate(r, v)
+		}
+		return c.generator.yieldValue(r, v)
+	case *parser.ExprAwait:
+		if c.generator == nil {
+			panic(r.newSyntaxError("Top-level await is not supported"))
+		}
+		return c.generator.await(r, c.evaluate(e.Argument))
+	case *parser.ExprImportCall:
+		panic(r.newSyntaxError("Dynamic import is not supported"))
+	}
+	panic(r.newSyntaxError("Unsupported expression %s", expr.S()))
+}
+
+// evaluateNamed evaluates expr, naming anonymous functions after key, as
+// initializers and assignments do.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-namedevaluation
+func (c *context) evaluateNamed(expr parser.Expr, key propertyKey) Value {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprFunction:
+		return c.functionExpression(e, key)
+	case *parser.ExprArrowFunction:
+		return c.newFunction(e, c.env, key, "", nil)
+	}
+	return c.evaluate(expr)
+}
+
+// functionExpression returns the function object of a function expression,
+// whose name, if any, is bound in the scope of its body.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-instantiateordinaryfunctionexpression
+func (c *context) functionExpression(e *parser.ExprFunction, key propertyKey) *Object {
+	if e.BindingIdentifier == nil {
+		return c.newFunction(e, c.env, key, "", nil)
+	}
+	name := e.BindingIdentifier.Name
+	env := newEnvironment(c.env)
+	b := env.declare(name, false, true)
+	b.silent = true
+	b.value = c.newFunction(e, env, stringKey(name), "", nil)
+	return b.value.(*Object)
+}
+
+// isSuper reports whether expr is the super keyword.
+func isSuper(expr parser.Expr) bool {
+	literal, ok := expr.(*parser.ExprLiteral[string])
+	return ok && literal.Token.Type == l.TSuper
+}
+
+// /////////////
+// References //
+// /////////////
+
+// reference is a Reference Record: what an identifier or a property access
+// resolves to, that can be read, assigned or deleted.
+//
+// https://262.ecma-international.org/#sec-reference-record-specification-type
+type reference struct {
+	// the environment and binding of an identifier, a nil environment
+	// when it is unresolvable, and a nil binding in object environments
+	env     *environment
+	binding *binding
+	name    string
+
+	property bool
+	base     Value
+	key      propertyKey
+	this     Value // the receiver, which differs from base for super
+	super    bool
+}
+
+// identifierReference resolves the identifier name.
+//
+// https://262.ecma-international.org/#sec-resolvebinding
+func (c *context) identifierReference(name string) reference {
+	env, b := c.env.resolve(name)
+	return reference{env: env, binding: b, name: name}
+}
+
+// memberReference evaluates the object and property of a property access.
+//
+// https://262.ecma-international.org/#sec-property-accessors-runtime-semantics-evaluation
+func (c *context) memberReference(e *parser.ExprMemberAccess) reference {
+	if isSuper(e.Object) {
+		// https://262.ecma-international.org/#sec-makesuperpropertyreference
+		home := c.thisContext.homeObject
+		if home == nil {
+			panic(c.r.newSyntaxError("'super' keyword unexpected here"))
+		}
+		var base Value = Null
+		if home.proto != nil {
+			base = home.proto
+		}
+		return reference{property: true, super: true, base: base, key: c.propertyKey(e), this: c.thisContext.this}
+	}
+	base := c.evaluate(e.Object)
+	return reference{property: true, base: base, key: c.propertyKey(e), this: base}
+}
+
+// propertyKey evaluates the property of a property access.
+func (c *context) propertyKey(e *parser.ExprMemberAccess) propertyKey {
+	if e.Computed {
+		return c.r.toPropertyKey(c.evaluate(e.Property))
+	}
+	id, ok := e.Property.(*parser.ExprIdentifier)
+	if !ok {
+		panic(c.r.newSyntaxError("Unexpected private field %s", e.Property.S()))
+	}
+	return stringKey(id.Name)
+}
+
+// reference evaluates expr as the target of an assignment.
+func (c *context) reference(expr parser.Node) reference {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprIdentifier:
+		return c.identifierReference(e.Name)
+	case *parser.ExprMemberAccess:
+		return c.memberReference(e)
+	}
+	panic(c.r.newSyntaxError("Invalid left-hand side in assignment"))
+}
+
+// getIdentifier returns the value of the identifier name.
+func (c *context) getIdentifier(name string) Value {
+	return c.getValue(c.identifierReference(name))
+}
+
+// getValue returns the value ref refers to.
+//
+// https://262.ecma-international.org/#sec-getvalue
+func (c *context) getValue(ref reference) Value {
+	r := c.r
+	switch {
+	case ref.super:
+		return r.toObject(ref.base).get(ref.key, ref.this)
+	case ref.property:
+		return r.getV(ref.base, ref.key)
+	case ref.binding != nil:
+		b := ref.binding
+		for b.indirect != nil {
+			b = b.indirect
+		}
+		if !b.initialized {
+			panic(r.newReferenceError("Cannot access '%s' before initialization", ref.name))
+		}
+		return b.value
+	case ref.env == nil:
+		panic(r.newReferenceError("%s is not defined", ref.name))
+	}
+	return ref.env.object.get(stringKey(ref.name), ref.env.object)
+}
+
+// putValue assigns v to what ref refers to.
+//
+// https://262.ecma-international.org/#sec-putvalue
+func (c *context) putValue(ref reference, v Value) {
+	r := c.r
+	switch {
+	case ref.super:
+		if !r.toObject(ref.base).set(ref.key, v, ref.this) && c.strict {
+			panic(r.newTypeError("Cannot assign to read only property '%s' of %s", ref.key, r.describe(ref.this)))
+		}
+	case ref.property:
+		r.putV(ref.base, ref.key, v, c.strict)
+	case ref.binding != nil:
+		b := ref.binding
+		switch {
+		case !b.initialized:
+			panic(r.newReferenceError("Cannot access '%s' before initialization", ref.name))
+		case b.mutable:
+			b.value = v
+		case !b.silent || c.strict:
+			panic(r.newTypeError("Assignment to constant variable."))
+		}
+	case ref.env == nil:
+		if c.strict {
+			panic(r.newReferenceError("%s is not defined", ref.name))
+		}
+		r.global.set(stringKey(ref.name), v, r.global)
+	default:
+		object := ref.env.object
+		if !object.set(stringKey(ref.name), v, object) && c.strict {
+			panic(r.newTypeError("Cannot assign to read only property '%s' of %s", ref.name, r.describe(object)))
+		}
+	}
+}
+
+// ////////////
+// Operators //
+// ////////////
+
+// evaluateUnary evaluates a unary or update expression.
+//
+// https://262.ecma-international.org/#sec-unary-operators
+func (c *context) evaluateUnary(e *parser.ExprUnaryOp) Value {
+	r := c.r
+	switch e.Operator.Type {
+	case l.TTypeof:
+		if id, ok := parser.Unparenthesized(e.Operand).(*parser.ExprIdentifier); ok {
+			ref := c.identifierReference(id.Name)
+			if ref.env == nil {
+				return String("undefined")
+			}
+			return String(typeOf(c.getValue(ref)))
+		}
+		return String(typeOf(c.evaluate(e.Operand)))
+	case l.TDelete:
+		return Bool(c.delete(e.Operand))
+	case l.TPlusPlus, l.TMinusMinus:
+		// https://262.ecma-international.org/#sec-update-expressions
+		ref := c.reference(e.Operand)
+		old := r.toNumeric(c.getValue(ref))
+		n := old + 1
+		if e.Operator.Type == l.TMinusMinus {
+			n = old - 1
+		}
+		c.putValue(ref, Number(n))
+		if e.Postfix {
+			return Number(old)
+		}
+		return Number(n)
+	}
+	return r.unaryOperation(e.Operator.Type, c.evaluate(e.Operand))
+}
+
+// delete applies the delete operator to expr, and reports whether the
+// property or binding is gone.
+//
+// https://262.ecma-international.org/#sec-delete-operator-runtime-semantics-evaluation
+func (c *context) delete(expr parser.Expr) bool {
+	r := c.r
+	var base Value
+	var key propertyKey
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprIdentifier:
+		return c.deleteIdentifier(e.Name)
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			panic(r.newReferenceError("Unsupported reference to 'super'"))
+		}
+		ref := c.memberReference(e)
+		base, key = ref.base, ref.key
+	case *parser.ExprChain:
+		member, ok := e.Expression.(*parser.ExprMemberAccess)
+		if !ok {
+			c.evaluate(e)
+			return true
+		}
+		object, _, ok := c.evaluateChain(member.Object)
+		if !ok || member.Optional && isNullish(object) {
+			return true
+		}
+		base, key = object, c.propertyKey(member)
+	default:
+		c.evaluate(expr)
+		return true
+	}
+	return r.deleteProperty(base, key, c.strict)
+}
+
+// deleteIdentifier applies the delete operator to the identifier name, and
+// reports whether its binding is gone.
+func (c *context) deleteIdentifier(name string) bool {
+	ref := c.identifierReference(name)
+	switch {
+	case ref.env == nil:
+		return true
+	case ref.binding != nil:
+		if ref.binding.deletable {
+			delete(ref.env.bindings, name)
+			return true
+		}
+		return false
+	}
+	return ref.env.object.delete(stringKey(name))
+}
+
+// deleteProperty deletes the property of base with key, and reports
+// whether it is gone, failing in strict mode code when it is not.
+func (r *Runtime) deleteProperty(base Value, key propertyKey, strict bool) bool {
+	if !r.toObject(base).delete(key) {
+		if strict {
+			panic(r.newTypeError("Cannot delete property '%s' of %s", key, r.describe(base)))
+		}
+		return false
+	}
+	return true
+}
+
+// evaluateAssign evaluates an assignment expression.
+//
+// https://262.ecma-international.org/#sec-assignment-operators-runtime-semantics-evaluation
+func (c *context) evaluateAssign(e *parser.ExprAssign) Value {
+	op := e.Operator.Type
+	if op == l.TAssign {
+		switch left := e.Left.(type) {
+		case *parser.ObjectPattern, *parser.ArrayPattern:
+			v := c.evaluate(e.Right)
+			c.bindPattern(left, v, nil)
+			return v
+		}
+		ref := c.reference(e.Left)
+		var v Value
+		if id, ok := e.Left.(*parser.ExprIdentifier); ok {
+			v = c.evaluateNamed(e.Right, stringKey(id.Name))
+		} else {
+			v = c.evaluate(e.Right)
+		}
+		c.putValue(ref, v)
+		return v
+	}
+
+	ref := c.reference(e.Left)
+	old := c.getValue(ref)
+	switch op {
+	case l.TLogicalAndAssign:
+		if !toBoolean(old) {
+			return old
+		}
+	case l.TLogicalOrAssign:
+		if toBoolean(old) {
+			return old
+		}
+	default:
+		v := c.r.binaryOperation(op, old, c.evaluate(e.Right))
+		c.putValue(ref, v)
+		return v
+	}
+	var v Value
+	if id, ok := e.Left.(*parser.ExprIdentifier); ok {
+		v = c.evaluateNamed(e.Right, stringKey(id.Name))
+	} else {
+		v = c.evaluate(e.Right)
+	}
+	c.putValue(ref, v)
+	return v
+}
+
+// ////////
+// Calls //
+// ////////
+
+// evaluateCall evaluates a call expression, recognizing direct calls to
+// eval.
+//
+// https://262.ecma-international.org/#sec-function-calls-runtime-semantics-evaluation
+func (c *context) evaluateCall(e *parser.ExprCall) Value {
+	if isSuper(e.Callee) {
+		panic(c.r.newSyntaxError("'super' keyword unexpected here"))
+	}
+	fn, this := c.callee(e.Callee)
+	args := c.arguments(e.Arguments)
+	if id, ok := e.Callee.(*parser.ExprIdentifier); ok && id.Name == "eval" && fn == Value(c.r.eval) {
+		return c.directEval(arg(args, 0))
+	}
+	return c.call(fn, this, args, e.Callee)
+}
+
+// callee evaluates the callee of a call, and returns it along with the this
+// value of the call: the object of a property access, also within an
+// optional chain, or that of a with statement binding an identifier.
+func (c *context) callee(expr parser.Expr) (Value, Value) {
+	switch e := parser.Unparenthesized(expr).(type) {
+	case *parser.ExprMemberAccess:
+		ref := c.memberReference(e)
+		return c.getValue(ref), ref.this
+	case *parser.ExprChain:
+		// (a?.b)() calls b with a as this, unless the chain short-circuits
+		if fn, this, ok := c.evaluateChain(e.Expression); ok {
+			return fn, this
+		}
+		return Undefined, Undefined
+	case *parser.ExprIdentifier:
+		ref := c.identifierReference(e.Name)
+		if ref.env != nil && ref.env.with {
+			return c.getValue(ref), ref.env.object
+		}
+		return c.getValue(ref), Undefined
+	}
+	return c.evaluate(expr), Undefined
+}
+
+// call calls fn with this and args, failing if fn, the value of callee, is
+// not a function.
+func (c *context) call(fn, this Value, args []Value, callee parser.Expr) Value {
+	f, ok := fn.(*Object)
+	if !ok || f.call == nil {
+		panic(c.r.newTypeError("%s is not a function", calleeName(callee)))
+	}
+	return f.call(this, args)
+}
+
+// arguments evaluates the arguments of a call, spreading iterables.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-argumentlistevaluation
+func (c *context) arguments(exprs []parser.Expr) []Value {
+	args := make([]Value, 0, len(exprs))
+	for _, expr := range exprs {
+		if spread, ok := expr.(*parser.SpreadElement); ok {
+			c.r.iterate(c.evaluate(spread.Argument), func(v Value) bool {
+				args = append(args, v)
+				return true
+			})
+			continue
+		}
+		args = append(args, c.evaluate(expr))
+	}
+	return args
+}
+
+// calleeName describes the callee of a call in error messages.
+func calleeName(expr parser.Expr) string {
+	switch e := expr.(type) {
+	case *parser.ExprIdentifier:
+		return e.Name
+	case *parser.ExprMemberAccess:
+		object := calleeName(e.Object)
+		if e.Computed {
+			return object + "[...]"
+		}
+		return object + "." + e.Property.S()
+	case *parser.ExprLiteral[float64]:
+		return e.Token.Lexeme
+	case *parser.ExprLiteral[string]:
+		switch e.Token.Type {
+		case l.TThis:
+			return "this"
+		case l.TSuper:
+			return "super"
+		case l.TNull, l.TUndefined, l.TTrue, l.TFalse:
+			return e.Token.Type.S()
+		}
+	case *parser.ExprParenthesized:
+		return calleeName(e.Expression)
+	case *parser.ExprChain:
+		return calleeName(e.Expression)
+	case *parser.ExprCall:
+		return calleeName(e.Callee) + "(...)"
+	}
+	return "(intermediate value)"
+}
+
+// evaluateChain evaluates the links of an optional chain, and returns the
+// value of expr along with the this value of a call to it. It returns false
+// when an optional link short-circuits the chain.
+//
+// https://262.ecma-international.org/#sec-optional-chaining-chain-evaluation
+func (c *context) evaluateChain(expr parser.Expr) (Value, Value, bool) {
+	r := c.r
+	switch e := expr.(type) {
+	case *parser.ExprMemberAccess:
+		if isSuper(e.Object) {
+			ref := c.memberReference(e)
+			return c.getValue(ref), ref.this, true
+		}
+		base, _, ok := c.evaluateChain(e.Object)
+		if !ok || e.Optional && isNullish(base) {
+			return nil, nil, false
+		}
+		return r.getV(base, c.propertyKey(e)), base, true
+	case *parser.ExprCall:
+		fn, this, ok := c.evaluateChain(e.Callee)
+		if !ok || e.Optional && isNullish(fn) {
+			return nil, nil, false
+		}
+		return c.call(fn, this, c.arguments(e.Arguments), e.Callee), Undefined, true
+	case *parser.ExprIdentifier:
+		fn, this := c.callee(e)
+		return fn, this, true
+	}
+	return c.evaluate(expr), Undefined, true
+}
+
+// ///////////
+// Literals //
+// ///////////
+
+// evaluateArray evaluates an array literal, whose elisions are holes.
+//
+// https://262.ecma-international.org/#sec-array-initializer-runtime-semantics-evaluation
+func (c *context) evaluateArray(e *parser.ExprArray) Value {
+	elements := make([]Value, 0, len(e.Elements))
+	for _, element := range e.Elements {
+		if element == parser.Expr(parser.ArrayHole) {
+			elements = append(elements, nil)
+			continue
+		}
+		if spread, ok := element.(*parser.SpreadElement); ok {
+			c.r.iterate(c.evaluate(spread.Argument), func(v Value) bool {
+				elements = append(elements, v)
+				return true
+			})
+			continue
+		}
+		elements = append(elements, c.evaluate(element))
+	}
+	return c.r.newArray(elements...)
+}
+
+// evaluateObject evaluates an object literal.
+//
+// https://262.ecma-international.org/#sec-object-initializer-runtime-semantics-evaluation
+func (c *context) evaluateObject(e *parser.ExprObject) Value {
+	r := c.r
+	o := r.newObject(r.objectPrototype)
+	for _, p := range e.Properties {
+		if spread, ok := p.Value.(*parser.SpreadElement); ok {
+			r.copyDataProperties(o, c.evaluate(spread.Argument), nil)
+			continue
+		}
+		if isProtoSetter(p) {
+			switch proto := c.evaluate(p.Value).(type) {
+			case *Object:
+				o.setPrototypeOf(proto)
+			case null:
+				o.setPrototypeOf(nil)
+			}
+			continue
+		}
+		key := c.propertyName(p.Key, p.Computed)
+		switch {
+		case p.Kind == parser.PropertyGet:
+			getter := c.newFunction(p.Value, c.env, key, "get", o)
+			o.defineOwnProperty(key, descriptor{getter: getter, flags: enumerable | configurable, has: hasGet | hasEnumerable | hasConfigurable})
+		case p.Kind == parser.PropertySet:
+			setter := c.newFunction(p.Value, c.env, key, "set", o)
+			o.defineOwnProperty(key, descriptor{setter: setter, flags: enumerable | configurable, has: hasSet | hasEnumerable | hasConfigurable})
+		case p.Method:
+			o.defineOwnProperty(key, dataDescriptor(c.newFunction(p.Value, c.env, key, "", o), defaultFlags))
+		default:
+			o.defineOwnProperty(key, dataDescriptor(c.evaluateNamed(p.Value, key), defaultFlags))
+		}
+	}
+	return o
+}
+
+// isProtoSetter reports whether the property definition p sets the
+// prototype of the object literal, as __proto__: v does.
+//
+// https://262.ecma-international.org/#sec-__proto__-property-names-in-object-initializers
+func isProtoSetter(p *parser.PropertyDefinition) bool {
+	if p.Computed || p.Method || p.Shorthand || p.Kind != parser.PropertyInit {
+		return false
+	}
+	switch key := p.Key.(type) {
+	case *parser.ExprIdentifier:
+		return key.Name == "__proto__"
+	case *parser.ExprLiteral[string]:
+		lexeme := key.Token.Lexeme
+		return len(lexeme) >= 2 && cookString(lexeme[1:len(lexeme)-1]) == "__proto__"
+	}
+	return false
+}
+
+// propertyName evaluates the name of a property in an object literal or
+// pattern.
+//
+// https://262.ecma-international.org/#sec-object-initializer-runtime-semantics-evaluation
+func (c *context) propertyName(key parser.Expr, computed bool) propertyKey {
+	if computed {
+		return c.r.toPropertyKey(c.evaluate(key))
+	}
+	switch k := key.(type) {
+	case *parser.ExprIdentifier:
+		return stringKey(k.Name)
+	case *parser.ExprLiteral[string]:
+		return stringKey(string(c.script.stringLiteral(k)))
+	case *parser.ExprLiteral[float64]:
+		return stringKey(numberToString(numberLiteral(k.Token)))
+	}
+	return c.r.toPropertyKey(c.evaluate(key))
+}
+
+// templateObject returns the template object passed to the tag of a tagged
+// template: the frozen array of the cooked strings, with the frozen array of
+// raw strings as its raw property. It is the same object on every evaluation
+// of the template.
+//
+// https://262.ecma-international.org/#sec-gettemplateobject
+func (r *Runtime) templateObject(s *script, template *parser.ExprTemplateLiteral) *Object {
+	if o, ok := s.templates[template]; ok {
+		return o
+	}
+	cooked := make([]Value, len(template.Quasis))
+	raw := make([]Value, len(template.Quasis))
+	for i, quasi := range template.Quasis {
+		cooked[i] = Undefined
+		if quasi.Cooked != nil {
+			cooked[i] = String(cookString(quasi.Raw))
+		}
+		raw[i] = String(quasi.Raw)
+	}
+	o := r.newArray(cooked...)
+	rawObject := r.newArray(raw...)
+	r.freeze(rawObject)
+	o.defineOwnProperty(stringKey("raw"), dataDescriptor(rawObject, 0))
+	r.freeze(o)
+	s.templates[template] = o
+	return o
+}
+
+// freeze makes the properties of o read-only and o non-extensible.
+//
+// https://262.ecma-international.org/#sec-setintegritylevel
+func (r *Runtime) freeze(o *Object) {
+	o.preventExtensions()
+	for _, key := range o.ownKeys() {
+		p, _ := o.getOwnProperty(key)
+		desc := descriptor{has: hasConfigurable}
+		if !p.isAccessor() {
+			desc.has |= hasWritable
+		}
+		r.definePropertyOrThrow(o, key, desc)
+	}
+}
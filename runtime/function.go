@@ -0,0 +1,157 @@
+package runtime
+
+// nativeFunction is the Go implementation of a built-in function.
+type nativeFunction func(this Value, args []Value) Value
+
+// arg returns the argument at index i, undefined when missing.
+func arg(args []Value, i int) Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return Undefined
+}
+
+// newFunctionObject returns a function object inheriting from proto, with
+// the name and length properties but no behaviour.
+//
+// https://262.ecma-international.org/#sec-ordinaryfunctioncreate
+func (r *Runtime) newFunctionObject(proto *Object, name string, length int) *Object {
+	fn := r.newObject(proto)
+	fn.class = "Function"
+	fn.defineOwnProperty(stringKey("length"), dataDescriptor(Number(length), configurable))
+	fn.defineOwnProperty(stringKey("name"), dataDescriptor(String(name), configurable))
+	return fn
+}
+
+// newNativeFunction returns a built-in function that is not a constructor.
+//
+// https://262.ecma-international.org/#sec-createbuiltinfunction
+func (r *Runtime) newNativeFunction(name string, length int, call nativeFunction) *Object {
+	fn := r.newFunctionObject(r.functionPrototype, name, length)
+	fn.call = call
+	return fn
+}
+
+// newNativeConstructor returns a built-in constructor, linked with its
+// prototype object. Calls without new run call, and new runs construct.
+func (r *Runtime) newNativeConstructor(name string, length int, call nativeFunction, construct func(args []Value, newTarget *Object) *Object, prototype *Object) *Object {
+	fn := r.newNativeFunction(name, length, call)
+	fn.construct = construct
+	fn.defineOwnProperty(stringKey("prototype"), dataDescriptor(prototype, 0))
+	prototype.setHidden(stringKey("constructor"), fn)
+	return fn
+}
+
+// method defines a built-in method on o.
+func (r *Runtime) method(o *Object, name string, length int, call nativeFunction) *Object {
+	fn := r.newNativeFunction(name, length, call)
+	o.setHidden(stringKey(name), fn)
+	return fn
+}
+
+// callFunction calls fn with this and args, failing if it is not a function.
+//
+// https://262.ecma-international.org/#sec-call
+func (r *Runtime) callFunction(fn Value, this Value, args ...Value) Value {
+	f, ok := fn.(*Object)
+	if !ok || f.call == nil {
+		panic(r.newTypeError("%s is not a function", r.describe(fn)))
+	}
+	return f.call(this, args)
+}
+
+// constructObject calls the constructor fn with new, failing if it is not a
+// constructor. The prototype of the new object is that of newTarget, fn
+// itself when nil.
+//
+// https://262.ecma-international.org/#sec-construct
+func (r *Runtime) constructObject(fn Value, args []Value, newTarget *Object) *Object {
+	f, ok := fn.(*Object)
+	if !ok || f.construct == nil {
+		panic(r.newTypeError("%s is not a constructor", r.describe(fn)))
+	}
+	if newTarget == nil {
+		newTarget = f
+	}
+	return f.construct(args, newTarget)
+}
+
+// prototypeFromConstructor returns the prototype property of newTarget,
+// fallback when it is not an object.
+//
+// https://262.ecma-international.org/#sec-getprototypefromconstructor
+func (r *Runtime) prototypeFromConstructor(newTarget *Object, fallback *Object) *Object {
+	if newTarget == nil {
+		return fallback
+	}
+	if proto, ok := newTarget.get(stringKey("prototype"), newTarget).(*Object); ok {
+		return proto
+	}
+	return fallback
+}
+
+// setFunctionName sets the name property of the function fn after key, with
+// an optional prefix, as "get" for getters.
+//
+// https://262.ecma-international.org/#sec-setfunctionname
+func setFunctionName(fn *Object, key propertyKey, prefix string) {
+	name := key.name
+	if key.symbol != nil {
+		name = ""
+		if description, ok := key.symbol.Description.(String); ok {
+			name = "[" + string(description) + "]"
+		}
+	}
+	if prefix != "" {
+		name = prefix + " " + name
+	}
+	fn.defineOwnProperty(stringKey("name"), dataDescriptor(String(name), configurable))
+}
+
+// makeConstructor gives the function fn a prototype object, whose
+// constructor is fn.
+//
+// https://262.ecma-international.org/#sec-makeconstructor
+func (r *Runtime) makeConstructor(fn *Object) {
+	prototype := r.newObject(r.objectPrototype)
+	prototype.setHidden(stringKey("constructor"), fn)
+	fn.defineOwnProperty(stringKey("prototype"), dataDescriptor(prototype, writable))
+}
+
+// ordinaryHasInstance reports whether the prototype of the function fn is on
+// the prototype chain of v.
+//
+// https://262.ecma-international.org/#sec-ordinaryhasinstance
+func (r *Runtime) ordinaryHasInstance(fn *Object, v Value) bool {
+	if fn.call == nil {
+		return false
+	}
+	if bound, ok := fn.internal.(*boundFunction); ok {
+		return r.instanceOf(v, bound.target)
+	}
+	o, ok := v.(*Object)
+	if !ok {
+		return false
+	}
+	proto, ok := fn.get(stringKey("prototype"), fn).(*Object)
+	if !ok {
+		panic(r.newTypeError("Function has non-object prototype '%s' in instanceof check", r.describe(fn.get(stringKey("prototype"), fn))))
+	}
+	for p := o.proto; p != nil; p = p.proto {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
+
+// functionName returns the name of the function fn, as found without
+// running any code.
+func functionName(fn *Object) string {
+	if p, ok := fn.props[stringKey("name")]; ok {
+		if name, ok := p.value.(String); ok {
+			return string(name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package runtime
+
+import goruntime "runtime"
+
+// resumeKind tells how a generator is resumed: by its next, return or throw
+// method.
+type resumeKind int
+
+const (
+	resumeNext resumeKind = iota
+	resumeReturn
+	resumeThrow
+)
+
+func (k resumeKind) String() string {
+	switch k {
+	case resumeReturn:
+		return "return"
+	case resumeThrow:
+		return "throw"
+	}
+	return "next"
+}
+
+type generatorState int
+
+const (
+	suspendedStart generatorState = iota
+	suspendedYield
+	executing
+	completed
+)
+
+// generator is the state of a generator object. The body of the generator
+// function runs on a goroutine of its own, which hands control back and
+// forth with the code resuming the generator: only one of them runs at a
+// time.
+//
+// https://262.ecma-international.org/#sec-generator-objects
+type generator struct {
+	state generatorState
+	name  string
+	s     *script
+	body  func() Value
+
+	resume  chan resumption
+	suspend chan suspension
+}
+
+// resumption is what resumes the body of a generator.
+type resumption struct {
+	kind  resumeKind
+	value Value
+}
+
+// suspension is what the body of a generator hands back when it yields or
+// completes.
+type suspension struct {
+	result    *Object // the iterator result
+	value     Value   // the value awaited, or returned once done
+	done      bool
+	exception *Exception
+	panic     interface{} // a Go panic, propagated to the resuming code
+}
+
+// generatorReturn unwinds the body of a generator resumed by its return
+// method.
+type generatorReturn struct {
+	value Value
+}
+
+// generatorAbandoned unwinds the body of a generator that can no longer be
+// resumed, ending its goroutine.
+type generatorAbandoned struct{}
+
+// newGenerator returns a generator object running body when first resumed,
+// inheriting from the prototype property of the generator function fn.
+//
+// https://262.ecma-international.org/#sec-generatorstart
+func (r *Runtime) newGenerator(fn *Object, name string, s *script, body func() Value) *Object {
+	o := r.newObject(r.prototypeFromConstructor(fn, r.generatorPrototype))
+	o.class = "Generator"
+	o.internal = &generator{name: name, s: s, body: body}
+	return o
+}
+
+// resumeGenerator resumes the generator object v, as its next, return and
+// throw methods do, and returns the iterator result it hands back.
+//
+// https://262.ecma-international.org/#sec-generatorresume
+func (r *Runtime) resumeGenerator(v Value, kind resumeKind, value Value) Value {
+	o, ok := v.(*Object)
+	var g *generator
+	if ok {
+		g, ok = o.internal.(*generator)
+	}
+	if !ok {
+		panic(r.newTypeError("%s method called on incompatible receiver %s", kind, r.describe(v)))
+	}
+
+	switch g.state {
+	case executing:
+		panic(r.newTypeError("Generator is already running"))
+	case suspendedStart:
+		if kind != resumeNext {
+			g.state = completed
+		}
+	}
+	if g.state == completed {
+		switch kind {
+		case resumeReturn:
+			return r.iterResult(value, true)
+		case resumeThrow:
+			panic(r.throw(value))
+		}
+		return r.iterResult(Undefined, true)
+	}
+
+	r.enter(g.name, g.s)
+	defer r.leave()
+	s := g.step(o, kind, value)
+	switch {
+	case s.exception != nil:
+		panic(s.exception)
+	case s.panic != nil:
+		panic(s.panic)
+	}
+	return s.result
+}
+
+// step hands control to the body of the generator object o, resumed as kind
+// tells with value, until it suspends or completes.
+func (g *generator) step(o *Object, kind resumeKind, value Value) suspension {
+	if g.state == suspendedStart {
+		g.start(o)
+	}
+	g.state = executing
+	g.resume <- resumption{kind: kind, value: value}
+	s := <-g.suspend
+	g.state = suspendedYield
+	if s.done {
+		g.state = completed
+		g.resume, g.suspend = nil, nil
+	}
+	return s
+}
+
+// start runs the body of the generator on its goroutine, waiting for it to be
+// resumed. The goroutine ends when the generator object o is collected
+// before the body completes.
+func (g *generator) start(o *Object) {
+	r := o.runtime
+	g.resume = make(chan resumption)
+	g.suspend = make(chan suspension)
+	resume, suspend := g.resume, g.suspend
+	goruntime.SetFinalizer(o, func(*Object) { close(resume) })
+
+	go func() {
+		defer func() {
+			switch x := recover().(type) {
+			case nil, generatorAbandoned:
+			case generatorReturn:
+				suspend <- suspension{result: r.iterResult(x.value, true), done: true}
+			case *Exception:
+				suspend <- suspension{exception: x, done: true}
+			default:
+				suspend <- suspension{panic: x, done: true}
+			}
+		}()
+		if _, ok := <-resume; !ok {
+			panic(generatorAbandoned{})
+		}
+		v := g.body()
+		suspend <- suspension{result: r.iterResult(v, true), value: v, done: true}
+	}()
+}
+
+// yield suspends the body of the generator, handing back the iterator result
+// result, and returns the value it is resumed with.
+//
+// https://262.ecma-international.org/#sec-generatoryield
+func (g *generator) yield(result *Object) resumption {
+	return g.suspendWith(suspension{result: result})
+}
+
+// suspendWith suspends the body of the generator, handing back s, and
+// returns what it is resumed with.
+func (g *generator) suspendWith(s suspension) resumption {
+	resume := g.resume
+	g.suspend <- s
+	received, ok := <-resume
+	if !ok {
+		panic(generatorAbandoned{})
+	}
+	return received
+}
+
+// yieldValue runs yield v within the body of the generator.
+func (g *generator) yieldValue(r *Runtime, v Value) Value {
+	received := g.yield(r.iterResult(v, false))
+	switch received.kind {
+	case resumeReturn:
+		panic(generatorReturn{received.value})
+	case resumeThrow:
+		panic(r.throw(received.value))
+	}
+	return received.value
+}
+
+// yieldDelegate runs yield* v within the body of the generator, resuming the
+// iterator of v as the generator is, until it is done.
+//
+// https://262.ecma-international.org/#sec-generator-function-definitions-runtime-semantics-evaluation
+func (g *generator) yieldDelegate(r *Runtime, v Value) Value {
+	it := r.getIterator(v)
+	received := resumption{kind: resumeNext, value: Undefined}
+	for {
+		var result Value
+		switch received.kind {
+		case resumeNext:
+			result = r.callFunction(it.next, it.iterator, received.value)
+		case resumeThrow:
+			method := r.getMethod(it.iterator, stringKey("throw"))
+			if method == nil {
+				r.closeIterator(it)
+				panic(r.newTypeError("The iterator does not provide a 'throw' method"))
+			}
+			result = method.call(it.iterator, []Value{received.value})
+		case resumeReturn:
+			method := r.getMethod(it.iterator, stringKey("return"))
+			if method == nil {
+				panic(generatorReturn{received.value})
+			}
+			result = method.call(it.iterator, []Value{received.value})
+		}
+		o, ok := result.(*Object)
+		if !ok {
+			panic(r.newTypeError("Iterator result %s is not an object", r.describe(result)))
+		}
+		if toBoolean(o.get(stringKey("done"), o)) {
+			value := o.get(stringKey("value"), o)
+			if received.kind == resumeReturn {
+				panic(generatorReturn{value})
+			}
+			return value
+		}
+		received = g.yield(o)
+	}
+}
@@ -0,0 +1,305 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	// inspectDepth is the nesting past which Inspect abbreviates objects
+	inspectDepth = 2
+	// inspectWidth is the length past which Inspect lists the members of an
+	// object one per line
+	inspectWidth = 72
+)
+
+// Inspect returns a description of v for people to read, as the consoles of
+// browsers show values: strings are quoted, and objects list their own
+// enumerable properties, or the contents of their internal slots, down to a
+// depth of two. It runs no code: accessors show as [Getter] and [Setter], but
+// those of objects reflecting Go values, which are read.
+func (r *Runtime) Inspect(v Value) string {
+	in := inspector{r: r}
+	return in.inspect(v, 0)
+}
+
+// inspector describes values for Inspect, with the stack of the objects
+// being described to detect cycles.
+type inspector struct {
+	r     *Runtime
+	stack []*Object
+}
+
+func (in *inspector) inspect(v Value, depth int) string {
+	switch v := v.(type) {
+	case String:
+		return inspectString(string(v))
+	case Number:
+		if v == 0 && math.Signbit(float64(v)) {
+			return "-0"
+		}
+	case *Object:
+		return in.object(v, depth)
+	}
+	return fmt.Sprint(v)
+}
+
+func (in *inspector) object(o *Object, depth int) string {
+	for _, parent := range in.stack {
+		if parent == o {
+			return "[Circular]"
+		}
+	}
+	in.stack = append(in.stack, o)
+	defer func() { in.stack = in.stack[:len(in.stack)-1] }()
+
+	var (
+		prefix  string // what the braces follow, if anything
+		members []string
+	)
+	open, close := "{", "}"
+	switch x := o.internal.(type) {
+	case *promise:
+		prefix = "Promise"
+		switch x.state {
+		case promisePending:
+			members = append(members, "<pending>")
+		case promiseFulfilled:
+			members = append(members, in.inspect(x.result, depth+1))
+		case promiseRejected:
+			members = append(members, "<rejected> "+in.inspect(x.result, depth+1))
+		}
+	case *orderedMap:
+		prefix = fmt.Sprintf("%s(%d)", o.class, x.size)
+		for e := x.head.next; e != nil; e = e.next {
+			switch {
+			case e.deleted:
+			case o.class == "Map":
+				members = append(members, in.inspect(e.key, depth+1)+" => "+in.inspect(e.value, depth+1))
+			default:
+				members = append(members, in.inspect(e.key, depth+1))
+			}
+		}
+	case *regexpObject:
+		return "/" + x.source + "/" + x.flags
+	case Bool, Number, String, *Symbol:
+		return fmt.Sprintf("[%s: %s]", o.class, in.inspect(x.(Value), depth+1))
+	default:
+		switch {
+		case o.call != nil:
+			prefix = in.function(o)
+		case o.class == "Error":
+			if stack, ok := o.props[stringKey("stack")]; ok {
+				if s, ok := stack.value.(String); ok {
+					return string(s)
+				}
+			}
+			return errorString(o)
+		case strings.HasPrefix(o.class, "Weak"):
+			return o.class + " { <items unknown> }"
+		case o.array:
+			if depth > inspectDepth && o.length > 0 {
+				return "[Array]"
+			}
+			open, close = "[", "]"
+			members = in.elements(o, depth)
+		default:
+			prefix = in.constructorName(o)
+		}
+	}
+
+	keys := in.keys(o)
+	if depth > inspectDepth && len(members)+len(keys) > 0 {
+		switch {
+		case o.call != nil:
+			return prefix
+		case o.array:
+			return "[Array]"
+		case prefix == "":
+			return "[Object]"
+		}
+		return "[" + prefix + "]"
+	}
+	for _, key := range keys {
+		members = append(members, in.property(o, key, depth))
+	}
+
+	if len(members) == 0 {
+		switch {
+		case o.call != nil:
+			return prefix
+		case prefix == "":
+			return open + close
+		}
+		return prefix + " " + open + close
+	}
+	if prefix != "" {
+		open = prefix + " " + open
+	}
+	line := open + " " + strings.Join(members, ", ") + " " + close
+	if len(line) <= inspectWidth && !strings.Contains(line, "\n") {
+		return line
+	}
+	return open + "\n  " + strings.ReplaceAll(strings.Join(members, ",\n"), "\n", "\n  ") + "\n" + close
+}
+
+// elements describes the elements of the array o, holes counted.
+func (in *inspector) elements(o *Object, depth int) []string {
+	var members []string
+	holes := 0
+	flush := func() {
+		switch {
+		case holes == 1:
+			members = append(members, "<1 empty item>")
+		case holes > 1:
+			members = append(members, fmt.Sprintf("<%d empty items>", holes))
+		}
+		holes = 0
+	}
+	for i := uint32(0); i < o.length; i++ {
+		p, ok := o.getOwnProperty(stringKey(strconv.FormatUint(uint64(i), 10)))
+		if !ok {
+			holes++
+			continue
+		}
+		flush()
+		members = append(members, in.value(o, p, depth))
+	}
+	flush()
+	return members
+}
+
+// keys returns the keys of the own enumerable properties of o but array
+// elements.
+func (in *inspector) keys(o *Object) []propertyKey {
+	var keys []propertyKey
+	for _, key := range o.ownKeys() {
+		if _, ok := key.index(); ok && o.array {
+			continue
+		}
+		if p, ok := o.getOwnProperty(key); ok && p.flags&enumerable != 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (in *inspector) property(o *Object, key propertyKey, depth int) string {
+	name := key.name
+	switch {
+	case key.symbol != nil:
+		name = "[" + key.symbol.String() + "]"
+	case !isIdentifierName(name):
+		name = inspectString(name)
+	}
+	p, _ := o.getOwnProperty(key)
+	return name + ": " + in.value(o, p, depth)
+}
+
+// value describes the value of the property p of o.
+func (in *inspector) value(o *Object, p property, depth int) string {
+	if !p.isAccessor() {
+		return in.inspect(p.value, depth+1)
+	}
+	if _, ok := o.internal.(goValue); ok && p.getter != nil {
+		if v, exception := in.r.try(func() Value { return in.r.callFunction(p.getter, o) }); exception == nil {
+			return in.inspect(v, depth+1)
+		}
+	}
+	switch {
+	case p.getter != nil && p.setter != nil:
+		return "[Getter/Setter]"
+	case p.getter != nil:
+		return "[Getter]"
+	}
+	return "[Setter]"
+}
+
+// function describes the function o by its kind and name.
+func (in *inspector) function(o *Object) string {
+	kind := "Function"
+	switch o.proto {
+	case in.r.asyncFunction:
+		kind = "AsyncFunction"
+	case in.r.generatorFunction:
+		kind = "GeneratorFunction"
+	}
+	if p, ok := o.props[stringKey("name")]; ok {
+		if name, ok := p.value.(String); ok && name != "" {
+			return fmt.Sprintf("[%s: %s]", kind, name)
+		}
+	}
+	return fmt.Sprintf("[%s (anonymous)]", kind)
+}
+
+// constructorName returns the name of the constructor of the prototype of
+// o, found without running any code, or "" for Object.
+func (in *inspector) constructorName(o *Object) string {
+	if o.proto == nil {
+		return "[Object: null prototype]"
+	}
+	if p, ok := o.proto.props[stringKey("constructor")]; ok && !p.isAccessor() {
+		if ctor, ok := p.value.(*Object); ok {
+			if name, ok := ctor.props[stringKey("name")]; ok {
+				if name, ok := name.value.(String); ok && name != "Object" {
+					return string(name)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// inspectString quotes s with single quotes, or with double quotes when it
+// only contains single ones.
+func inspectString(s string) string {
+	quote := '\''
+	if strings.ContainsRune(s, '\'') && !strings.ContainsRune(s, '"') {
+		quote = '"'
+	}
+	var b strings.Builder
+	b.WriteRune(quote)
+	for i := 0; i < len(s); {
+		c, width := decodeRune(s[i:])
+		i += width
+		switch c {
+		case quote, '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\x%02X`, c)
+			} else if utf16.IsSurrogate(c) {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
+
+// isIdentifierName reports whether name is written as an identifier, which
+// Inspect leaves unquoted as a property key.
+func isIdentifierName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '$' && c != '_' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
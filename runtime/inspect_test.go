@@ -0,0 +1,51 @@
+package runtime
+
+import "testing"
+
+func TestInspect(t *testing.T) {
+	tests := []struct{ src, expected string }{
+		{src: "undefined", expected: "undefined"},
+		{src: "-0", expected: "-0"},
+		{src: "'it\\'s\\n'", expected: `"it's\n"`},
+		{src: "Symbol('s')", expected: "Symbol(s)"},
+		{src: "[1, 'a', , , null]", expected: "[ 1, 'a', <2 empty items>, null ]"},
+		{src: "['\\ud800', '😀'[0] + '😀'[1]]", expected: "[ '\\uD800', '😀' ]"},
+		{src: "[]", expected: "[]"},
+		{src: "var a = [1]; a.x = 2; a", expected: "[ 1, x: 2 ]"},
+		{src: "({ a: 1, 'b-c': [2], [Symbol.iterator]: 3 })", expected: "{ a: 1, 'b-c': [ 2 ], [Symbol(Symbol.iterator)]: 3 }"},
+		{src: "({ a: { b: { c: { d: 1 } }, e: {} } })", expected: "{ a: { b: { c: [Object] }, e: {} } }"},
+		{src: "[[[[1]]]]", expected: "[ [ [ [Array] ] ] ]"},
+		{src: "var o = { name: 'o' }; o.self = o; o", expected: "{ name: 'o', self: [Circular] }"},
+		{src: "({ get a() { return 1 }, set b(v) {} })", expected: "{ a: [Getter], b: [Setter] }"},
+		{src: "Object.create(null)", expected: "[Object: null prototype] {}"},
+		{src: "function Point(x) { this.x = x } new Point(1)", expected: "Point { x: 1 }"},
+		{src: "[function f() {}, () => {}, async function g() {}, function* h() {}]", expected: "[\n  [Function: f],\n  [Function (anonymous)],\n  [AsyncFunction: g],\n  [GeneratorFunction: h]\n]"},
+		{src: "new Map([[1, 'a']])", expected: "Map(1) { 1 => 'a' }"},
+		{src: "var s = new Set([1, 2, 3]); s.delete(2); s", expected: "Set(2) { 1, 3 }"},
+		{src: "[Promise.resolve(1), Promise.reject(2), new Promise(() => {})]", expected: "[ Promise { 1 }, Promise { <rejected> 2 }, Promise { <pending> } ]"},
+		{src: "[new Number(1), new String('s'), /a+/g]", expected: "[ [Number: 1], [String: 's'], /a+/g ]"},
+		{src: "new TypeError('bad')", expected: "TypeError: bad\n    at <eval>:1:1"},
+		{src: "({ long: 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa', nested: { b: 'bbbbbbbbbbbbbbbbbbbbbbbb' } })", expected: "{\n  long: 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa',\n  nested: { b: 'bbbbbbbbbbbbbbbbbbbbbbbb' }\n}"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			r := New()
+			v, err := r.RunString(tc.src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := r.Inspect(v); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("Go values", func(t *testing.T) {
+		r := New()
+		r.Set("p", &point{X: 1, Y: 2})
+		v, _ := r.RunString("p")
+		if got, expected := r.Inspect(v), "{ X: 1, Y: 2 }"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+}
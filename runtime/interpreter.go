@@ -0,0 +1,359 @@
+package runtime
+
+import (
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// context is an execution context: the state of the code of a function
+// call, a script, a module or eval code as it runs.
+//
+// https://262.ecma-international.org/#sec-execution-contexts
+type context struct {
+	r      *Runtime
+	script *script
+	env    *environment // the LexicalEnvironment, changing as blocks run
+	varEnv *environment // the VariableEnvironment
+	strict bool
+
+	// thisContext is the context providing this, new.target and super to
+	// the code: the context itself, or that of the code enclosing an arrow
+	// function
+	thisContext *context
+	this        Value
+	newTarget   Value
+	homeObject  *Object // the object whose prototype super refers to
+	function    bool    // whether the context is that of a function, which defines new.target
+
+	// the functions declared in blocks that are copied to varEnv when their
+	// declaration is evaluated
+	annexB    map[*parser.FunctionDeclarationStmt]bool
+	generator *generator
+	module    *module
+}
+
+// newContext returns the context of code running at the top level of the
+// global environment, whose this is the global object.
+func (r *Runtime) newContext(s *script, strict bool) *context {
+	c := &context{r: r, script: s, env: r.globalEnv, varEnv: r.varEnv, strict: strict, this: r.global, newTarget: Undefined}
+	c.thisContext = c
+	return c
+}
+
+// statements returns the statements of a program.
+func statements(program *parser.NodeRoot) []parser.Stmt {
+	stmts := make([]parser.Stmt, len(program.Children))
+	for i, child := range program.Children {
+		stmts[i] = child
+	}
+	return stmts
+}
+
+// runScript evaluates a script in the global environment and returns its
+// completion value.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-scriptevaluation
+func (r *Runtime) runScript(s *script) Value {
+	r.enter("", s)
+	defer r.leave()
+	stmts := statements(s.file.Program)
+	c := r.newContext(s, s.file.Program.Strict)
+	d := declarationsOf(stmts, c.strict, nil)
+	c.annexB = d.annexB
+	r.instantiateGlobal(c, d)
+	if r.engine == EngineVM {
+		if code := r.compileScript(s, d.annexB); code != nil {
+			return r.run(r.newFrame(c, code, nil, nil, nil))
+		}
+	}
+	v, _ := c.executeStatements(stmts)
+	if v == nil {
+		return Undefined
+	}
+	return v
+}
+
+// instantiateGlobal declares the names of a script in the global
+// environment, failing when they clash with the lexical declarations of
+// scripts run before, or when the global object can not hold them.
+//
+// https://262.ecma-international.org/#sec-globaldeclarationinstantiation
+func (r *Runtime) instantiateGlobal(c *context, d *declarations) {
+	env := r.globalEnv
+	for _, decl := range d.lexical {
+		if _, ok := env.bindings[decl.name]; ok || r.varNames[decl.name] {
+			panic(r.newSyntaxError("Identifier '%s' has already been declared", decl.name))
+		}
+		if p, ok := r.global.getOwnProperty(stringKey(decl.name)); ok && p.flags&configurable == 0 {
+			panic(r.newSyntaxError("Identifier '%s' has already been declared", decl.name))
+		}
+	}
+	declared := make(map[string]bool)
+	for _, fn := range d.functions {
+		declared[fn.BindingIdentifier.Name] = true
+	}
+	for _, name := range d.varNames {
+		declared[name] = true
+	}
+	for name := range declared {
+		if _, ok := env.bindings[name]; ok {
+			panic(r.newSyntaxError("Identifier '%s' has already been declared", name))
+		}
+	}
+	for _, fn := range d.functions {
+		r.checkGlobalFunction(fn.BindingIdentifier.Name)
+	}
+	for _, name := range d.varNames {
+		r.checkGlobalVar(name)
+	}
+	for fn := range d.annexB {
+		name := fn.BindingIdentifier.Name
+		if _, ok := env.bindings[name]; ok {
+			delete(d.annexB, fn)
+			continue
+		}
+		if !declared[name] {
+			r.checkGlobalVar(name)
+			r.createGlobalVar(name, false)
+		}
+	}
+
+	for _, decl := range d.lexical {
+		env.declare(decl.name, !decl.constant, false)
+	}
+	for _, fn := range d.functions {
+		name := fn.BindingIdentifier.Name
+		r.createGlobalFunction(name, c.newFunction(fn, env, stringKey(name), "", nil), false)
+	}
+	for _, name := range d.varNames {
+		r.createGlobalVar(name, false)
+	}
+}
+
+// checkGlobalFunction fails when the global object can not hold a function
+// declared as name.
+//
+// https://262.ecma-international.org/#sec-candeclareglobalfunction
+func (r *Runtime) checkGlobalFunction(name string) {
+	p, ok := r.global.getOwnProperty(stringKey(name))
+	if !ok && !r.global.extensible || ok && p.flags&configurable == 0 && (p.isAccessor() || p.flags&(writable|enumerable) != writable|enumerable) {
+		panic(r.newTypeError("Cannot redefine global function '%s'", name))
+	}
+}
+
+// checkGlobalVar fails when the global object can not hold a variable
+// declared as name.
+//
+// https://262.ecma-international.org/#sec-candeclareglobalvar
+func (r *Runtime) checkGlobalVar(name string) {
+	if !r.global.hasOwnProperty(stringKey(name)) && !r.global.extensible {
+		panic(r.newTypeError("Cannot define global variable '%s'", name))
+	}
+}
+
+// createGlobalFunction defines the global property of the function fn
+// declared as name, deletable when declared by eval code.
+//
+// https://262.ecma-international.org/#sec-createglobalfunctionbinding
+func (r *Runtime) createGlobalFunction(name string, fn *Object, deletable bool) {
+	key := stringKey(name)
+	desc := dataDescriptor(fn, writable|enumerable)
+	if deletable {
+		desc.flags |= configurable
+	}
+	if p, ok := r.global.getOwnProperty(key); ok && p.flags&configurable == 0 {
+		desc = descriptor{value: fn, has: hasValue}
+	}
+	r.definePropertyOrThrow(r.global, key, desc)
+	r.varNames[name] = true
+}
+
+// createGlobalVar defines the global property of a variable declared as
+// name, unless it exists.
+//
+// https://262.ecma-international.org/#sec-createglobalvarbinding
+func (r *Runtime) createGlobalVar(name string, deletable bool) {
+	key := stringKey(name)
+	if !r.global.hasOwnProperty(key) && r.global.extensible {
+		flags := writable | enumerable
+		if deletable {
+			flags |= configurable
+		}
+		r.definePropertyOrThrow(r.global, key, dataDescriptor(Undefined, flags))
+	}
+	r.varNames[name] = true
+}
+
+// /////////////
+// Statements //
+// /////////////
+
+// executeStatements runs stmts and returns the completion value of the last
+// of them that has one, nil if none does, and whether a return statement
+// completed them.
+//
+// https://262.ecma-international.org/#sec-block-runtime-semantics-evaluation
+func (c *context) executeStatements(stmts []parser.Stmt) (Value, bool) {
+	var result Value
+	for _, stmt := range stmts {
+		v, returned := c.execute(stmt)
+		if returned {
+			return v, true
+		}
+		if v != nil {
+			result = v
+		}
+	}
+	return result, false
+}
+
+// execute runs stmt and returns its completion value, nil when empty, and
+// whether it is a return statement.
+func (c *context) execute(stmt parser.Stmt) (Value, bool) {
+	if span, ok := c.script.file.Spans[stmt]; ok {
+		c.r.at(span.Start)
+	}
+	switch s := stmt.(type) {
+	case *parser.ExpressionStatement:
+		return c.evaluate(s.Expression), false
+	case *parser.VariableStatement:
+		c.declareVariables(s)
+	case *parser.FunctionDeclarationStmt:
+		if c.annexB[s] {
+			// https://262.ecma-international.org/#sec-web-compat-functiondeclarationinstantiation
+			name := s.BindingIdentifier.Name
+			v := c.getIdentifier(name)
+			if c.varEnv.object != nil {
+				c.varEnv.object.set(stringKey(name), v, c.varEnv.object)
+			} else {
+				c.varEnv.bindings[name].value = v
+			}
+		}
+	case *parser.BlockStatement:
+		return c.executeBlock(s, s.Stmts)
+	case *parser.EmptyStatement, *parser.DebuggerStatement:
+	case *parser.ReturnStatement:
+		if s.Argument == nil {
+			return Undefined, true
+		}
+		return c.evaluate(s.Argument), true
+	case *parser.IfStatement:
+		var v Value
+		var returned bool
+		if toBoolean(c.evaluate(s.Condition)) {
+			v, returned = c.executeBody(s.ThenStmt)
+		} else if s.ElseStmt != nil {
+			v, returned = c.executeBody(s.ElseStmt)
+		}
+		if v == nil {
+			v = Undefined
+		}
+		return v, returned
+	case *parser.WithStatement:
+		// https://262.ecma-international.org/#sec-with-statement-runtime-semantics-evaluation
+		object := c.r.toObject(c.evaluate(s.Object))
+		outer := c.env
+		c.env = &environment{outer: outer, object: object, with: true}
+		v, returned := c.executeBody(s.Body)
+		c.env = outer
+		if v == nil {
+			v = Undefined
+		}
+		return v, returned
+	case *parser.LabelledStatement:
+		return c.execute(s.Body)
+	case *parser.ImportDeclaration, *parser.ExportAllDeclaration:
+	case *parser.ExportNamedDeclaration:
+		if s.Declaration != nil {
+			return c.execute(s.Declaration)
+		}
+	case *parser.ExportDefaultDeclaration:
+		// https://262.ecma-international.org/#sec-exports-runtime-semantics-evaluation
+		if _, ok := s.Declaration.(*parser.FunctionDeclarationStmt); !ok {
+			v := c.evaluateNamed(s.Declaration, stringKey("default"))
+			initialize(c.env, defaultExport, v)
+		}
+	default:
+		panic(c.r.newSyntaxError("Unsupported statement %s", stmt.S()))
+	}
+	return nil, false
+}
+
+// executeBody runs the body of an if or with statement, which in sloppy
+// mode code may be a function declaration scoped as if in a block.
+//
+// https://262.ecma-international.org/#sec-functiondeclarations-in-ifstatement-statement-clauses
+func (c *context) executeBody(stmt parser.Stmt) (Value, bool) {
+	if _, ok := stmt.(*parser.FunctionDeclarationStmt); ok {
+		return c.executeBlock(stmt, []parser.Stmt{stmt})
+	}
+	return c.execute(stmt)
+}
+
+// executeBlock runs the statements of a block in an environment holding its
+// lexical declarations, with key identifying the block.
+//
+// https://262.ecma-international.org/#sec-blockdeclarationinstantiation
+func (c *context) executeBlock(key interface{}, stmts []parser.Stmt) (Value, bool) {
+	info := c.script.blockDeclarations(key, stmts)
+	if len(info.lexical) == 0 && len(info.functions) == 0 {
+		return c.executeStatements(stmts)
+	}
+	outer := c.env
+	env := newEnvironment(outer)
+	for _, decl := range info.lexical {
+		env.declare(decl.name, !decl.constant, false)
+	}
+	c.env = env
+	for _, fn := range info.functions {
+		name := fn.BindingIdentifier.Name
+		env.declare(name, true, true).value = c.newFunction(fn, env, stringKey(name), "", nil)
+	}
+	v, returned := c.executeStatements(stmts)
+	c.env = outer
+	return v, returned
+}
+
+// declareVariables evaluates the initializers of a var, let or const
+// statement.
+//
+// https://262.ecma-international.org/#sec-variable-statement-runtime-semantics-evaluation
+func (c *context) declareVariables(s *parser.VariableStatement) {
+	lexical := s.Kind.Type != l.TVar
+	for _, decl := range s.Declarations {
+		if decl.Identifier == nil {
+			var env *environment
+			if lexical {
+				env = c.env
+			}
+			c.bindPattern(decl.Pattern, c.evaluate(decl.Init), env)
+			continue
+		}
+		name := decl.Identifier.Name
+		if !lexical {
+			if decl.Init != nil {
+				ref := c.identifierReference(name)
+				c.putValue(ref, c.evaluateNamed(decl.Init, stringKey(name)))
+			}
+			continue
+		}
+		v := Undefined
+		if decl.Init != nil {
+			v = c.evaluateNamed(decl.Init, stringKey(name))
+		}
+		initialize(c.env, name, v)
+	}
+}
+
+// initialize initializes the binding of name declared in env, or in the
+// environments enclosing it.
+//
+// https://262.ecma-international.org/#sec-initializereferencedbinding
+func initialize(env *environment, name string, v Value) {
+	b, ok := env.bindings[name]
+	if !ok {
+		_, b = env.resolve(name)
+	}
+	b.value = v
+	b.initialized = true
+}
@@ -0,0 +1,117 @@
+package runtime
+
+// iteratorRecord is an iterator along with its next method.
+//
+// https://262.ecma-international.org/#sec-iterator-records
+type iteratorRecord struct {
+	iterator *Object
+	next     Value
+	done     bool
+}
+
+// getIterator returns the iterator of v, given by its @@iterator method.
+//
+// https://262.ecma-international.org/#sec-getiterator
+func (r *Runtime) getIterator(v Value) *iteratorRecord {
+	method := r.getMethod(v, symbolKey(SymbolIterator))
+	if method == nil {
+		panic(r.newTypeError("%s is not iterable", r.describe(v)))
+	}
+	iterator, ok := method.call(v, nil).(*Object)
+	if !ok {
+		panic(r.newTypeError("Result of the Symbol.iterator method is not an object"))
+	}
+	return &iteratorRecord{iterator: iterator, next: iterator.get(stringKey("next"), iterator)}
+}
+
+// step returns the next value of the iterator, or false once it is done.
+//
+// https://262.ecma-international.org/#sec-iteratorstepvalue
+func (r *Runtime) step(it *iteratorRecord) (Value, bool) {
+	if it.done {
+		return Undefined, false
+	}
+	// an iterator failing to step is done, and is not closed
+	it.done = true
+	result, ok := r.callFunction(it.next, it.iterator).(*Object)
+	if !ok {
+		panic(r.newTypeError("Iterator result %s is not an object", r.describe(result)))
+	}
+	if toBoolean(result.get(stringKey("done"), result)) {
+		return Undefined, false
+	}
+	value := result.get(stringKey("value"), result)
+	it.done = false
+	return value, true
+}
+
+// closeIterator calls the return method of an iterator that is not done,
+// as consumers stopping early do.
+//
+// https://262.ecma-international.org/#sec-iteratorclose
+func (r *Runtime) closeIterator(it *iteratorRecord) {
+	if it.done {
+		return
+	}
+	it.done = true
+	method := r.getMethod(it.iterator, stringKey("return"))
+	if method == nil {
+		return
+	}
+	if _, ok := method.call(it.iterator, nil).(*Object); !ok {
+		panic(r.newTypeError("Iterator result is not an object"))
+	}
+}
+
+// closeOnPanic closes the iterator when the consumer fails, and propagates
+// its exception rather than any from the return method. It is deferred by
+// the consumers of iterators.
+func (r *Runtime) closeOnPanic(it *iteratorRecord) {
+	if x := recover(); x != nil {
+		r.closeQuietly(it)
+		panic(x)
+	}
+}
+
+// closeQuietly closes the iterator, ignoring the exceptions of its return
+// method, as consumers failing do.
+func (r *Runtime) closeQuietly(it *iteratorRecord) {
+	if it.done {
+		return
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			if _, ok := x.(*Exception); !ok {
+				panic(x)
+			}
+		}
+	}()
+	r.closeIterator(it)
+}
+
+// iterate calls yield on the values of the iterable v, until it returns
+// false, which closes the iterator.
+func (r *Runtime) iterate(v Value, yield func(Value) bool) {
+	it := r.getIterator(v)
+	defer r.closeOnPanic(it)
+	for {
+		value, ok := r.step(it)
+		if !ok {
+			return
+		}
+		if !yield(value) {
+			r.closeIterator(it)
+			return
+		}
+	}
+}
+
+// iterResult returns an iterator result object.
+//
+// https://262.ecma-international.org/#sec-createiterresultobject
+func (r *Runtime) iterResult(value Value, done bool) *Object {
+	o := r.newObject(r.objectPrototype)
+	o.setProperty("value", value)
+	o.setProperty("done", Bool(done))
+	return o
+}
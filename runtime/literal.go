@@ -0,0 +1,167 @@
+package runtime
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// stringLiteral returns the string value of a string literal of the script,
+// computing it on first use.
+func (s *script) stringLiteral(e *parser.ExprLiteral[string]) String {
+	if v, ok := s.strings[e]; ok {
+		return v
+	}
+	v := stringValue(e)
+	s.strings[e] = v
+	return v
+}
+
+// stringValue returns the string value of a string literal.
+//
+// https://262.ecma-international.org/#sec-static-semantics-sv
+func stringValue(e *parser.ExprLiteral[string]) String {
+	if lexeme := e.Token.Lexeme; len(lexeme) >= 2 {
+		return String(cookString(lexeme[1 : len(lexeme)-1]))
+	} else if literal, ok := e.Token.Literal.(string); ok {
+		// literals made up by the parser
+		return String(literal)
+	}
+	return ""
+}
+
+// cookString returns the value of the body of a string literal or template,
+// whose escape sequences are valid: single character escapes, legacy octal
+// escapes, \x, \u and \u{} escapes and line continuations. Escaped halves of
+// surrogate pairs combine into the character they encode.
+//
+// https://262.ecma-international.org/#sec-literals-string-literals
+func cookString(raw string) string {
+	if !strings.ContainsAny(raw, "\\\r") {
+		return raw
+	}
+	var cooked strings.Builder
+	// the high surrogate escaped last, pending its low half
+	var high rune
+	flush := func() {
+		if high != 0 {
+			cooked.Write(appendRune(nil, high))
+			high = 0
+		}
+	}
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		if ch == '\r' {
+			// template literals normalize their line terminators
+			flush()
+			cooked.WriteByte('\n')
+			if i+1 < len(raw) && raw[i+1] == '\n' {
+				i++
+			}
+			continue
+		}
+		if ch != '\\' || i+1 == len(raw) {
+			flush()
+			cooked.WriteByte(ch)
+			continue
+		}
+		i++
+		var code rune
+		switch ch = raw[i]; ch {
+		case 'n':
+			code = '\n'
+		case 't':
+			code = '\t'
+		case 'r':
+			code = '\r'
+		case 'b':
+			code = '\b'
+		case 'f':
+			code = '\f'
+		case 'v':
+			code = '\v'
+		case '\r':
+			if i+1 < len(raw) && raw[i+1] == '\n' {
+				i++
+			}
+			continue
+		case '\n':
+			continue
+		case 'x':
+			n, _ := strconv.ParseUint(raw[i+1:i+3], 16, 8)
+			code = rune(n)
+			i += 2
+		case 'u':
+			var digits string
+			if raw[i+1] == '{' {
+				end := strings.IndexByte(raw[i:], '}')
+				digits = raw[i+2 : i+end]
+				i += end
+			} else {
+				digits = raw[i+1 : i+5]
+				i += 4
+			}
+			n, _ := strconv.ParseUint(digits, 16, 32)
+			code = rune(n)
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// LegacyOctalEscapeSequence, of up to three digits below \400
+			n := rune(ch - '0')
+			max := 2
+			if ch > '3' {
+				max = 1
+			}
+			for j := 0; j < max && i+1 < len(raw) && raw[i+1] >= '0' && raw[i+1] <= '7'; j++ {
+				i++
+				n = n*8 + rune(raw[i]-'0')
+			}
+			code = n
+		default:
+			if strings.HasPrefix(raw[i:], "\u2028") || strings.HasPrefix(raw[i:], "\u2029") {
+				// line continuations
+				i += 2
+				continue
+			}
+			// NonEscapeCharacter, and \8 and \9
+			flush()
+			if ch < utf8.RuneSelf {
+				cooked.WriteByte(ch)
+			} else {
+				i--
+			}
+			continue
+		}
+
+		switch {
+		case high != 0 && code >= 0xDC00 && code <= 0xDFFF:
+			cooked.WriteRune(utf16.DecodeRune(high, code))
+			high = 0
+		case code >= 0xD800 && code <= 0xDBFF:
+			flush()
+			high = code
+		default:
+			flush()
+			cooked.Write(appendRune(nil, code))
+		}
+	}
+	flush()
+	return cooked.String()
+}
+
+// numberLiteral returns the value of a numeric literal.
+//
+// https://262.ecma-international.org/#sec-static-semantics-mv
+func numberLiteral(token l.Token) float64 {
+	if n, ok := token.Literal.(float64); ok {
+		return n
+	}
+	lexeme := strings.ReplaceAll(token.Lexeme, "_", "")
+	if len(lexeme) > 1 && lexeme[0] == '0' && strings.Trim(lexeme, "01234567") == "" {
+		// LegacyOctalIntegerLiteral
+		return parseInteger(lexeme[1:], 8)
+	}
+	return stringToNumber(lexeme)
+}
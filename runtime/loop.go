@@ -0,0 +1,131 @@
+package runtime
+
+import (
+	gocontext "context"
+	"sync"
+)
+
+// eventLoop holds the tasks posted to a Runtime, which RunUntilIdle runs.
+type eventLoop struct {
+	mu    sync.Mutex
+	tasks []func()
+	// the promises of NewPromise not settled yet, which keep RunUntilIdle
+	// waiting for the tasks settling them
+	pending int
+	wake    chan struct{}
+}
+
+// post queues task, and settles one of the pending promises when settle is
+// true.
+func (l *eventLoop) post(task func(), settle bool) {
+	l.mu.Lock()
+	l.tasks = append(l.tasks, task)
+	if settle {
+		l.pending--
+	}
+	l.mu.Unlock()
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the first queued task, or nil along with whether the loop is
+// idle: no task is queued, and none is awaited.
+func (l *eventLoop) next() (task func(), idle bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.tasks) == 0 {
+		return nil, l.pending == 0
+	}
+	task = l.tasks[0]
+	l.tasks[0] = nil
+	l.tasks = l.tasks[1:]
+	return task, false
+}
+
+// Post queues task to run on the goroutine running RunUntilIdle, after the
+// tasks posted before it and the promise jobs they queue. The task may use
+// the Runtime. Post is safe to call from any goroutine.
+func (r *Runtime) Post(task func()) {
+	r.loop.post(task, false)
+}
+
+// RunUntilIdle runs the queued promise jobs and the posted tasks, waiting for
+// those settling the promises of NewPromise, until none is left. It returns
+// ctx.Err() when ctx is done first, and an *Exception when a task throws,
+// leaving the tasks after it queued.
+func (r *Runtime) RunUntilIdle(ctx gocontext.Context) error {
+	for {
+		if err := r.runTask(r.runJobs); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		task, idle := r.loop.next()
+		switch {
+		case task != nil:
+			if err := r.runTask(task); err != nil {
+				return err
+			}
+		case idle:
+			return nil
+		default:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-r.loop.wake:
+			}
+		}
+	}
+}
+
+// runTask runs task, then the jobs it queues, and returns the exception it
+// throws.
+func (r *Runtime) runTask(task func()) (err error) {
+	defer r.recoverException(&err)
+	task()
+	r.runJobs()
+	return nil
+}
+
+// Resolver settles a promise created by NewPromise. Its methods are safe to
+// call from any goroutine: they post the task settling the promise, and only
+// the first call has an effect. The values they are passed must not be
+// objects a script is using concurrently.
+type Resolver struct {
+	r               *Runtime
+	once            sync.Once
+	resolve, reject *Object
+}
+
+// NewPromise returns a pending promise, along with its Resolver. Until it is
+// resolved or rejected, RunUntilIdle waits for it.
+func (r *Runtime) NewPromise() (*Object, *Resolver) {
+	o := r.newPromise(r.promisePrototype)
+	resolve, reject := r.createResolvingFunctions(o)
+	r.loop.mu.Lock()
+	r.loop.pending++
+	r.loop.mu.Unlock()
+	return o, &Resolver{r: r, resolve: resolve, reject: reject}
+}
+
+// Resolve resolves the promise with v, which it follows when v is a
+// thenable.
+func (res *Resolver) Resolve(v Value) {
+	res.settle(res.resolve, v)
+}
+
+// Reject rejects the promise with reason.
+func (res *Resolver) Reject(reason Value) {
+	res.settle(res.reject, reason)
+}
+
+func (res *Resolver) settle(fn *Object, v Value) {
+	res.once.Do(func() {
+		res.r.loop.post(func() {
+			fn.call(Undefined, []Value{v})
+		}, true)
+	})
+}
@@ -0,0 +1,551 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// ModuleLoader resolves the specifier of an import from the module named
+// referrer, returning the name of the imported module and its source. The
+// module loaded under a name is loaded once, whatever imports it.
+type ModuleLoader func(referrer, specifier string) (name, source string, err error)
+
+// SetModuleLoader sets the loader of the modules imported by the modules
+// run. Without one, modules can not import others.
+func (r *Runtime) SetModuleLoader(loader ModuleLoader) {
+	r.loader = loader
+}
+
+// RunModule loads, links and evaluates the module src named name, along with
+// the modules it imports, and returns its namespace object. A module already
+// run under name is not run again.
+//
+// It returns an error when a module does not parse or can not be loaded, and
+// an *Exception when linking or evaluating them throws.
+func (r *Runtime) RunModule(name, src string) (namespace *Object, err error) {
+	m, err := r.loadModule(name, src)
+	if err != nil {
+		return nil, err
+	}
+	defer r.recoverException(&err)
+	r.link(m)
+	r.evaluateModule(m)
+	r.runJobs()
+	return r.namespace(m), nil
+}
+
+// defaultExport is the name of the binding of an export default declaration
+// that has no name of its own, which no identifier can refer to.
+const defaultExport = "*default*"
+
+type moduleStatus int
+
+const (
+	moduleUnlinked moduleStatus = iota
+	moduleLinking
+	moduleLinked
+	moduleEvaluating
+	moduleEvaluated
+)
+
+// module is a Source Text Module Record: a parsed module with its imports and
+// exports, and its environment once linked.
+//
+// https://262.ecma-international.org/#sec-source-text-module-records
+type module struct {
+	name   string
+	script *script
+	status moduleStatus
+
+	// the specifiers of the imported modules, in order of appearance, and
+	// the modules they resolve to
+	specifiers []string
+	requested  map[string]*module
+
+	imports         []importEntry
+	localExports    map[string]string      // local names, by export name
+	indirectExports map[string]importEntry // by export name
+	starExports     []string               // specifiers
+
+	env       *environment
+	context   *context
+	namespace *Object
+	meta      *Object
+	// the exception its evaluation threw
+	exception *Exception
+}
+
+// importEntry is an imported binding: the export importName of the module
+// of specifier, bound as localName. The importName of namespace imports is
+// "*".
+type importEntry struct {
+	specifier  string
+	importName string
+	localName  string
+}
+
+// loadModule parses the module src named name, and loads the modules it
+// imports through the module loader.
+func (r *Runtime) loadModule(name, src string) (*module, error) {
+	if m, ok := r.modules[name]; ok {
+		return m, nil
+	}
+	file, err := parse(src, parser.Options{SourceType: parser.SourceTypeModule})
+	if err != nil {
+		return nil, err
+	}
+	m := r.parseModule(name, file)
+	r.modules[name] = m
+	for _, specifier := range m.specifiers {
+		dependency, err := r.loadDependency(m, specifier)
+		if err != nil {
+			delete(r.modules, name)
+			return nil, err
+		}
+		m.requested[specifier] = dependency
+	}
+	return m, nil
+}
+
+// loadDependency loads the module imported by m as specifier.
+func (r *Runtime) loadDependency(m *module, specifier string) (*module, error) {
+	if r.loader == nil {
+		return nil, fmt.Errorf("cannot import %q from %s: no module loader", specifier, m.name)
+	}
+	name, src, err := r.loader(m.name, specifier)
+	if err != nil {
+		return nil, fmt.Errorf("cannot import %q from %s: %w", specifier, m.name, err)
+	}
+	return r.loadModule(name, src)
+}
+
+// parseModule collects the imports and exports of the module file.
+//
+// https://262.ecma-international.org/#sec-parsemodule
+func (r *Runtime) parseModule(name string, file *parser.File) *module {
+	m := &module{
+		name:            name,
+		script:          newScript(name, file),
+		requested:       make(map[string]*module),
+		localExports:    make(map[string]string),
+		indirectExports: make(map[string]importEntry),
+	}
+	request := func(source parser.Expr) string {
+		specifier := r.moduleExportName(source)
+		if _, ok := m.requested[specifier]; !ok {
+			m.requested[specifier] = nil
+			m.specifiers = append(m.specifiers, specifier)
+		}
+		return specifier
+	}
+
+	imports := make(map[string]importEntry)
+	for _, child := range file.Program.Children {
+		s, ok := child.(*parser.ImportDeclaration)
+		if !ok {
+			continue
+		}
+		specifier := request(s.Source)
+		for _, spec := range s.Specifiers {
+			entry := importEntry{specifier: specifier, localName: spec.Local.Name}
+			switch spec.Kind {
+			case parser.ImportDefault:
+				entry.importName = "default"
+			case parser.ImportNamespace:
+				entry.importName = "*"
+			default:
+				entry.importName = r.moduleExportName(spec.Imported)
+			}
+			m.imports = append(m.imports, entry)
+			imports[entry.localName] = entry
+		}
+	}
+
+	for _, child := range file.Program.Children {
+		switch s := child.(type) {
+		case *parser.ExportNamedDeclaration:
+			switch decl := s.Declaration.(type) {
+			case *parser.VariableStatement:
+				for _, d := range decl.Declarations {
+					for _, id := range declarationIdentifiers(d) {
+						m.localExports[id.Name] = id.Name
+					}
+				}
+			case *parser.FunctionDeclarationStmt:
+				m.localExports[decl.BindingIdentifier.Name] = decl.BindingIdentifier.Name
+			}
+			if s.Source != nil {
+				specifier := request(s.Source)
+				for _, spec := range s.Specifiers {
+					exported := r.moduleExportName(spec.Exported)
+					m.indirectExports[exported] = importEntry{specifier: specifier, importName: r.moduleExportName(spec.Local)}
+				}
+				continue
+			}
+			for _, spec := range s.Specifiers {
+				local, exported := r.moduleExportName(spec.Local), r.moduleExportName(spec.Exported)
+				// re-exported imports are resolved in the module they
+				// come from, except for namespaces
+				if entry, ok := imports[local]; ok && entry.importName != "*" {
+					m.indirectExports[exported] = entry
+					continue
+				}
+				m.localExports[exported] = local
+			}
+		case *parser.ExportDefaultDeclaration:
+			m.localExports["default"] = defaultExport
+			if fn, ok := s.Declaration.(*parser.FunctionDeclarationStmt); ok && fn.BindingIdentifier != nil {
+				m.localExports["default"] = fn.BindingIdentifier.Name
+			}
+		case *parser.ExportAllDeclaration:
+			specifier := request(s.Source)
+			if s.Exported == nil {
+				m.starExports = append(m.starExports, specifier)
+				continue
+			}
+			m.indirectExports[r.moduleExportName(s.Exported)] = importEntry{specifier: specifier, importName: "*"}
+		}
+	}
+	return m
+}
+
+// moduleExportName returns the name of a ModuleExportName or module
+// specifier: an identifier or a string literal.
+func (r *Runtime) moduleExportName(e parser.Expr) string {
+	if id, ok := e.(*parser.ExprIdentifier); ok {
+		return id.Name
+	}
+	return string(stringValue(e.(*parser.ExprLiteral[string])))
+}
+
+// link creates the environments of m and of the modules it imports, and binds
+// their imports, failing when an import can not be resolved.
+//
+// https://262.ecma-international.org/#sec-moduledeclarationlinking
+func (r *Runtime) link(m *module) {
+	var modules []*module
+	var visit func(m *module)
+	visit = func(m *module) {
+		if m.status != moduleUnlinked {
+			return
+		}
+		m.status = moduleLinking
+		modules = append(modules, m)
+		for _, specifier := range m.specifiers {
+			visit(m.requested[specifier])
+		}
+	}
+	visit(m)
+	defer func() {
+		if x := recover(); x != nil {
+			for _, m := range modules {
+				m.status = moduleUnlinked
+				m.env, m.context = nil, nil
+			}
+			panic(x)
+		}
+	}()
+
+	for _, m := range modules {
+		r.instantiateModule(m)
+	}
+	for _, m := range modules {
+		r.bindImports(m)
+	}
+	for _, m := range modules {
+		m.status = moduleLinked
+	}
+}
+
+// instantiateModule creates the environment of m, with the bindings of its
+// declarations. Its function declarations are initialized, so that the
+// modules importing them can call them before m is evaluated.
+//
+// https://262.ecma-international.org/#sec-source-text-module-record-initialize-environment
+func (r *Runtime) instantiateModule(m *module) {
+	env := newEnvironment(r.globalEnv)
+	c := &context{r: r, script: m.script, env: env, varEnv: env, strict: true, this: Undefined, newTarget: Undefined, module: m}
+	c.thisContext = c
+	m.env, m.context = env, c
+
+	d := declarationsOf(statements(m.script.file.Program), true, nil)
+	for _, name := range d.varNames {
+		env.declare(name, true, true)
+	}
+	for _, decl := range d.lexical {
+		env.declare(decl.name, !decl.constant, false)
+	}
+	if m.localExports["default"] == defaultExport {
+		env.declare(defaultExport, false, false)
+	}
+	for _, fn := range d.functions {
+		name, key := defaultExport, "default"
+		if fn.BindingIdentifier != nil {
+			name, key = fn.BindingIdentifier.Name, fn.BindingIdentifier.Name
+		}
+		env.declare(name, true, true).value = c.newFunction(fn, env, stringKey(key), "", nil)
+	}
+}
+
+// bindImports binds the imports of m to the bindings they resolve to, and
+// checks that its indirect exports resolve.
+func (r *Runtime) bindImports(m *module) {
+	for _, entry := range m.imports {
+		imported := m.requested[entry.specifier]
+		if entry.importName == "*" {
+			m.env.declare(entry.localName, false, true).value = r.namespace(imported)
+			continue
+		}
+		m.env.bindings[entry.localName] = r.resolveImport(imported, entry.specifier, entry.importName)
+	}
+	for _, entry := range m.indirectExports {
+		if entry.importName != "*" {
+			r.resolveImport(m.requested[entry.specifier], entry.specifier, entry.importName)
+		}
+	}
+}
+
+// resolveImport returns a binding referring to the export name of the
+// module imported as specifier.
+func (r *Runtime) resolveImport(imported *module, specifier, name string) *binding {
+	res := imported.resolveExport(name, nil)
+	switch {
+	case res == nil:
+		panic(r.newSyntaxError("The requested module '%s' does not provide an export named '%s'", specifier, name))
+	case res == ambiguousExport:
+		panic(r.newSyntaxError("The requested module '%s' contains conflicting star exports for name '%s'", specifier, name))
+	case res.namespace:
+		return &binding{value: r.namespace(res.module), initialized: true}
+	}
+	return &binding{value: Undefined, initialized: true, indirect: res.module.env.bindings[res.name]}
+}
+
+// resolution is what an export resolves to: a binding of a module, or its
+// namespace.
+//
+// https://262.ecma-international.org/#resolvedbinding-record
+type resolution struct {
+	module    *module
+	name      string
+	namespace bool
+}
+
+// ambiguousExport is the resolution of a name that several star exports
+// provide.
+var ambiguousExport = &resolution{}
+
+// exportRequest is an export being resolved, that a circular import may
+// resolve again.
+type exportRequest struct {
+	module *module
+	name   string
+}
+
+// resolveExport resolves the export name of m. It returns nil when m does not
+// export name, or when its resolution is circular.
+//
+// https://262.ecma-international.org/#sec-resolveexport
+func (m *module) resolveExport(name string, visited map[exportRequest]bool) *resolution {
+	if visited == nil {
+		visited = make(map[exportRequest]bool)
+	}
+	request := exportRequest{m, name}
+	if visited[request] {
+		return nil
+	}
+	visited[request] = true
+
+	if local, ok := m.localExports[name]; ok {
+		return &resolution{module: m, name: local}
+	}
+	if entry, ok := m.indirectExports[name]; ok {
+		imported := m.requested[entry.specifier]
+		if entry.importName == "*" {
+			return &resolution{module: imported, namespace: true}
+		}
+		return imported.resolveExport(entry.importName, visited)
+	}
+	if name == "default" {
+		// star exports do not export defaults
+		return nil
+	}
+	var star *resolution
+	for _, specifier := range m.starExports {
+		res := m.requested[specifier].resolveExport(name, visited)
+		switch {
+		case res == ambiguousExport:
+			return ambiguousExport
+		case res == nil:
+		case star == nil:
+			star = res
+		case star.module != res.module || star.name != res.name || star.namespace != res.namespace:
+			return ambiguousExport
+		}
+	}
+	return star
+}
+
+// exportedNames returns the names m exports, including those of its star
+// exports, which may be ambiguous.
+//
+// https://262.ecma-international.org/#sec-getexportednames
+func (m *module) exportedNames(visited map[*module]bool) []string {
+	if visited[m] {
+		return nil
+	}
+	visited[m] = true
+	var names []string
+	for name := range m.localExports {
+		names = append(names, name)
+	}
+	for name := range m.indirectExports {
+		names = append(names, name)
+	}
+	for _, specifier := range m.starExports {
+		for _, name := range m.requested[specifier].exportedNames(visited) {
+			if name != "default" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// evaluateModule evaluates m after the modules it imports, unless evaluated
+// before, rethrowing the exception its evaluation threw. Modules being
+// evaluated, imported through a cycle, are skipped.
+//
+// https://262.ecma-international.org/#sec-moduleevaluation
+func (r *Runtime) evaluateModule(m *module) {
+	switch m.status {
+	case moduleEvaluating:
+		return
+	case moduleEvaluated:
+		if m.exception != nil {
+			panic(m.exception)
+		}
+		return
+	}
+	m.status = moduleEvaluating
+	defer func() {
+		m.status = moduleEvaluated
+		if x := recover(); x != nil {
+			if e, ok := x.(*Exception); ok {
+				m.exception = e
+			}
+			panic(x)
+		}
+	}()
+	for _, specifier := range m.specifiers {
+		r.evaluateModule(m.requested[specifier])
+	}
+
+	r.enter("", m.script)
+	defer r.leave()
+	if r.engine == EngineVM {
+		if code := r.compileModule(m.script); code != nil {
+			r.run(r.newFrame(m.context, code, nil, nil, nil))
+			return
+		}
+	}
+	m.context.executeStatements(statements(m.script.file.Program))
+}
+
+// importMeta returns the import.meta object of m, created on first use.
+//
+// https://262.ecma-international.org/#sec-meta-properties-runtime-semantics-evaluation
+func (r *Runtime) importMeta(m *module) *Object {
+	if m.meta == nil {
+		m.meta = r.newObject(nil)
+	}
+	return m.meta
+}
+
+// ////////////////////
+// Module namespaces //
+// ////////////////////
+
+// moduleNamespace is the internal state of a module namespace object: the
+// names a module exports, sorted, and what they resolve to.
+//
+// https://262.ecma-international.org/#sec-module-namespace-exotic-objects
+type moduleNamespace struct {
+	names   []string
+	exports map[string]*resolution
+}
+
+// namespace returns the namespace object of m, created on first use, whose
+// properties are the live bindings of the exports of m.
+//
+// https://262.ecma-international.org/#sec-getmodulenamespace
+func (r *Runtime) namespace(m *module) *Object {
+	if m.namespace != nil {
+		return m.namespace
+	}
+	ns := &moduleNamespace{exports: make(map[string]*resolution)}
+	for _, name := range m.exportedNames(make(map[*module]bool)) {
+		if _, ok := ns.exports[name]; ok {
+			continue
+		}
+		if res := m.resolveExport(name, nil); res != nil && res != ambiguousExport {
+			ns.exports[name] = res
+			ns.names = append(ns.names, name)
+		}
+	}
+	sort.Slice(ns.names, func(i, j int) bool { return compareStrings(ns.names[i], ns.names[j]) < 0 })
+
+	o := r.newObject(nil)
+	o.class = "Module"
+	o.internal = ns
+	o.setConstant(symbolKey(SymbolToStringTag), String("Module"))
+	o.preventExtensions()
+	m.namespace = o
+	return o
+}
+
+// property returns the property of the export name, failing when its
+// binding is not initialized.
+func (ns *moduleNamespace) property(r *Runtime, name string) (property, bool) {
+	res, ok := ns.exports[name]
+	if !ok {
+		return property{}, false
+	}
+	if res.namespace {
+		return property{value: r.namespace(res.module), flags: writable | enumerable}, true
+	}
+	b := res.module.env.bindings[res.name]
+	for b.indirect != nil {
+		b = b.indirect
+	}
+	if !b.initialized {
+		panic(r.newReferenceError("Cannot access '%s' before initialization", name))
+	}
+	return property{value: b.value, flags: writable | enumerable}, true
+}
+
+// keys returns the keys of the properties of the namespace o: its exports,
+// then its symbols.
+func (ns *moduleNamespace) keys(o *Object) []propertyKey {
+	keys := make([]propertyKey, 0, len(ns.names)+len(o.keys))
+	for _, name := range ns.names {
+		keys = append(keys, stringKey(name))
+	}
+	return append(keys, o.keys...)
+}
+
+// define applies desc to the property of the export name, which it can not
+// change.
+//
+// https://262.ecma-international.org/#sec-module-namespace-exotic-objects-defineownproperty-p-desc
+func (ns *moduleNamespace) define(r *Runtime, name string, desc descriptor) bool {
+	current, ok := ns.property(r, name)
+	if !ok || desc.isAccessor() ||
+		desc.has&hasConfigurable != 0 && desc.flags&configurable != 0 ||
+		desc.has&hasEnumerable != 0 && desc.flags&enumerable == 0 ||
+		desc.has&hasWritable != 0 && desc.flags&writable == 0 {
+		return false
+	}
+	return desc.has&hasValue == 0 || sameValue(desc.value, current.value)
+}
@@ -0,0 +1,855 @@
+package runtime
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// propertyKey is the key of a property: a string or a symbol.
+type propertyKey struct {
+	name   string
+	symbol *Symbol
+}
+
+func stringKey(name string) propertyKey    { return propertyKey{name: name} }
+func symbolKey(symbol *Symbol) propertyKey { return propertyKey{symbol: symbol} }
+
+// value returns the key as a JavaScript value.
+func (k propertyKey) value() Value {
+	if k.symbol != nil {
+		return k.symbol
+	}
+	return String(k.name)
+}
+
+func (k propertyKey) String() string {
+	if k.symbol != nil {
+		return k.symbol.String()
+	}
+	return k.name
+}
+
+// index returns the array index denoted by the key: the canonical numeric
+// string of an integer up to 2^32 - 2.
+//
+// https://262.ecma-international.org/#array-index
+func (k propertyKey) index() (uint32, bool) {
+	if k.symbol != nil || k.name == "" || len(k.name) > 10 || k.name[0] == '0' && len(k.name) > 1 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(k.name, 10, 32)
+	if err != nil || n == math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+func indexKey(i uint32) propertyKey {
+	return stringKey(strconv.FormatUint(uint64(i), 10))
+}
+
+// propertyFlags are the attributes of a property.
+type propertyFlags uint8
+
+const (
+	writable propertyFlags = 1 << iota
+	enumerable
+	configurable
+	accessor // a getter and setter pair, rather than a value
+
+	// the attributes of properties created by assignment
+	defaultFlags = writable | enumerable | configurable
+	// the attributes of built-in methods
+	methodFlags = writable | configurable
+)
+
+// property is an own property of an object.
+//
+// https://262.ecma-international.org/#sec-property-attributes
+type property struct {
+	value  Value
+	getter *Object // nil when undefined
+	setter *Object // nil when undefined
+	flags  propertyFlags
+}
+
+func (p *property) isAccessor() bool { return p.flags&accessor != 0 }
+
+// descriptor is a Property Descriptor, whose fields may be absent.
+//
+// https://262.ecma-international.org/#sec-property-descriptor-specification-type
+type descriptor struct {
+	value  Value
+	getter Value
+	setter Value
+	flags  propertyFlags
+	has    descriptorFields
+}
+
+// descriptorFields tells which fields of a descriptor are present.
+type descriptorFields uint8
+
+const (
+	hasValue descriptorFields = 1 << iota
+	hasWritable
+	hasGet
+	hasSet
+	hasEnumerable
+	hasConfigurable
+)
+
+func (d *descriptor) isAccessor() bool { return d.has&(hasGet|hasSet) != 0 }
+func (d *descriptor) isData() bool     { return d.has&(hasValue|hasWritable) != 0 }
+
+// dataDescriptor returns the descriptor of a data property with value and
+// flags.
+func dataDescriptor(value Value, flags propertyFlags) descriptor {
+	return descriptor{value: value, flags: flags, has: hasValue | hasWritable | hasEnumerable | hasConfigurable}
+}
+
+// Object is a JavaScript object: a collection of properties with a
+// prototype. Functions, arrays and the other exotic objects are objects with
+// internal slots.
+//
+// https://262.ecma-international.org/#sec-object-type
+type Object struct {
+	runtime    *Runtime
+	proto      *Object
+	class      string // the kind of object, as reported by Object.prototype.toString
+	extensible bool
+
+	props map[propertyKey]*property
+	keys  []propertyKey // the keys of props, in order of creation
+
+	// elements holds the properties of an array whose key is an index below
+	// len(elements), with default attributes. A nil element is a hole, or a
+	// property of props
+	elements []Value
+	array    bool
+	length   uint32 // the length of an array
+	lengthRO bool   // whether the length of an array is read-only
+
+	// call and construct are the behaviours of functions, nil on objects
+	// that can not be called or constructed
+	call      func(this Value, args []Value) Value
+	construct func(args []Value, newTarget *Object) *Object
+
+	// internal holds the internal slots of exotic and built-in objects, as
+	// the primitive value of a wrapper or the state of a generator
+	internal interface{}
+}
+
+func (o *Object) Type() string { return "object" }
+
+func (o *Object) String() string {
+	return "[object " + o.class + "]"
+}
+
+// newObject returns an ordinary object with proto as its prototype.
+func (r *Runtime) newObject(proto *Object) *Object {
+	return &Object{runtime: r, proto: proto, class: "Object", extensible: true}
+}
+
+// newArray returns an array holding elements.
+func (r *Runtime) newArray(elements ...Value) *Object {
+	o := r.newObject(r.arrayPrototype)
+	o.class = "Array"
+	o.array = true
+	o.elements = elements
+	o.length = uint32(len(elements))
+	return o
+}
+
+// newPrimitiveObject returns an object wrapping the primitive value v.
+func (r *Runtime) newPrimitiveObject(v Value, proto *Object, class string) *Object {
+	o := r.newObject(proto)
+	o.class = class
+	o.internal = v
+	return o
+}
+
+// newStringObject returns a String exotic object wrapping s, whose code units
+// are its read-only index properties.
+//
+// https://262.ecma-international.org/#sec-string-exotic-objects
+func (r *Runtime) newStringObject(s String, proto *Object) *Object {
+	o := r.newPrimitiveObject(s, proto, "String")
+	o.defineOwnProperty(stringKey("length"), dataDescriptor(Number(stringLength(string(s))), 0))
+	return o
+}
+
+// stringIndex returns the code unit of the wrapped string at the index key,
+// on String objects.
+func (o *Object) stringIndex(key propertyKey) (Value, bool) {
+	s, ok := o.internal.(String)
+	if !ok || o.class != "String" {
+		return nil, false
+	}
+	i, ok := key.index()
+	if !ok || int(i) >= stringLength(string(s)) {
+		return nil, false
+	}
+	return String(substring(string(s), int(i), int(i)+1)), true
+}
+
+// ///////////////////
+// Internal methods //
+// ///////////////////
+
+// getOwnProperty returns the own property of o with key.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-getownproperty-p
+func (o *Object) getOwnProperty(key propertyKey) (property, bool) {
+	if ns, ok := o.internal.(*moduleNamespace); ok && key.symbol == nil {
+		return ns.property(o.runtime, key.name)
+	}
+	if m, ok := o.internal.(argumentsMap); ok {
+		if _, b := m.lookup(key); b != nil {
+			return property{value: b.value, flags: defaultFlags}, true
+		}
+	}
+	if o.elements != nil {
+		if i, ok := key.index(); ok && int(i) < len(o.elements) && o.elements[i] != nil {
+			return property{value: o.elements[i], flags: defaultFlags}, true
+		}
+	}
+	if o.array && key.symbol == nil && key.name == "length" {
+		flags := writable
+		if o.lengthRO {
+			flags = 0
+		}
+		return property{value: Number(o.length), flags: flags}, true
+	}
+	if p, ok := o.props[key]; ok {
+		return *p, true
+	}
+	if o.class == "String" {
+		if s, ok := o.stringIndex(key); ok {
+			return property{value: s, flags: enumerable}, true
+		}
+	}
+	return property{}, false
+}
+
+// hasOwnProperty reports whether o has an own property with key.
+func (o *Object) hasOwnProperty(key propertyKey) bool {
+	_, ok := o.getOwnProperty(key)
+	return ok
+}
+
+// hasProperty reports whether o or its prototypes have a property with key.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-hasproperty-p
+func (o *Object) hasProperty(key propertyKey) bool {
+	for obj := o; obj != nil; obj = obj.proto {
+		if obj.hasOwnProperty(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the value of the property of o with key, calling its getter
+// with receiver as this.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-get-p-receiver
+func (o *Object) get(key propertyKey, receiver Value) Value {
+	for obj := o; obj != nil; obj = obj.proto {
+		if obj.elements != nil && key.symbol == nil {
+			if i, ok := key.index(); ok && int(i) < len(obj.elements) && obj.elements[i] != nil {
+				return obj.elements[i]
+			}
+		}
+		p, ok := obj.getOwnProperty(key)
+		if !ok {
+			continue
+		}
+		if !p.isAccessor() {
+			return p.value
+		}
+		if p.getter == nil {
+			return Undefined
+		}
+		return p.getter.call(receiver, nil)
+	}
+	return Undefined
+}
+
+// set assigns v to the property of o with key, calling its setter with
+// receiver as this, and reports whether it succeeded.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-set-p-v-receiver
+func (o *Object) set(key propertyKey, v Value, receiver Value) bool {
+	if _, ok := o.internal.(*moduleNamespace); ok {
+		return false
+	}
+	// the fast path of a plain assignment to an existing element
+	if o.elements != nil && receiver == Value(o) {
+		if i, ok := key.index(); ok && int(i) < len(o.elements) && o.elements[i] != nil {
+			o.elements[i] = v
+			return true
+		}
+	}
+
+	var owner property
+	found := false
+	for obj := o; obj != nil; obj = obj.proto {
+		if p, ok := obj.getOwnProperty(key); ok {
+			owner, found = p, true
+			break
+		}
+	}
+	if !found {
+		owner = property{value: Undefined, flags: defaultFlags}
+	}
+
+	if owner.isAccessor() {
+		if owner.setter == nil {
+			return false
+		}
+		owner.setter.call(receiver, []Value{v})
+		return true
+	}
+	if owner.flags&writable == 0 {
+		return false
+	}
+	target, ok := receiver.(*Object)
+	if !ok {
+		return false
+	}
+	if existing, ok := target.getOwnProperty(key); ok {
+		if existing.isAccessor() || existing.flags&writable == 0 {
+			return false
+		}
+		return target.defineOwnProperty(key, descriptor{value: v, has: hasValue})
+	}
+	return target.defineOwnProperty(key, dataDescriptor(v, defaultFlags))
+}
+
+// delete removes the own property of o with key, and reports whether it is
+// gone, which it is not when non-configurable.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-delete-p
+func (o *Object) delete(key propertyKey) bool {
+	if m, ok := o.internal.(argumentsMap); ok {
+		if i, b := m.lookup(key); b != nil {
+			m[i] = nil
+			return true
+		}
+	}
+	if o.elements != nil {
+		if i, ok := key.index(); ok && int(i) < len(o.elements) && o.elements[i] != nil {
+			o.elements[i] = nil
+			return true
+		}
+	}
+	p, ok := o.getOwnProperty(key)
+	if !ok {
+		return true
+	}
+	if p.flags&configurable == 0 {
+		return false
+	}
+	o.removeProperty(key)
+	return true
+}
+
+// removeProperty removes key from the properties of o.
+func (o *Object) removeProperty(key propertyKey) {
+	delete(o.props, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// ownKeys returns the keys of the own properties of o: array indexes in
+// ascending order, then strings and symbols in order of creation.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-ownpropertykeys
+func (o *Object) ownKeys() []propertyKey {
+	if ns, ok := o.internal.(*moduleNamespace); ok {
+		return ns.keys(o)
+	}
+	var indexes []uint32
+	for i, v := range o.elements {
+		if v != nil {
+			indexes = append(indexes, uint32(i))
+		}
+	}
+	sorted := true
+	if m, ok := o.internal.(argumentsMap); ok {
+		for i, b := range m {
+			if b != nil {
+				indexes = append(indexes, uint32(i))
+				sorted = false
+			}
+		}
+	}
+	if s, ok := o.internal.(String); ok && o.class == "String" {
+		for i := 0; i < stringLength(string(s)); i++ {
+			indexes = append(indexes, uint32(i))
+		}
+	}
+	sorted = sorted && len(indexes) > 0
+	var names, symbols []propertyKey
+	for _, key := range o.keys {
+		switch i, ok := key.index(); {
+		case ok:
+			indexes = append(indexes, i)
+			sorted = false
+		case key.symbol != nil:
+			symbols = append(symbols, key)
+		default:
+			names = append(names, key)
+		}
+	}
+	if !sorted {
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	}
+
+	keys := make([]propertyKey, 0, len(indexes)+len(names)+len(symbols)+1)
+	for _, i := range indexes {
+		keys = append(keys, indexKey(i))
+	}
+	if o.array {
+		keys = append(keys, stringKey("length"))
+	}
+	keys = append(keys, names...)
+	return append(keys, symbols...)
+}
+
+// preventExtensions makes o non-extensible.
+func (o *Object) preventExtensions() {
+	o.extensible = false
+}
+
+// setPrototypeOf sets the prototype of o to proto, and reports whether it
+// succeeded, which it does not on non-extensible objects or when it would
+// create a cycle.
+//
+// https://262.ecma-international.org/#sec-ordinary-object-internal-methods-and-internal-slots-setprototypeof-v
+func (o *Object) setPrototypeOf(proto *Object) bool {
+	if proto == o.proto {
+		return true
+	}
+	if !o.extensible {
+		return false
+	}
+	for p := proto; p != nil; p = p.proto {
+		if p == o {
+			return false
+		}
+	}
+	o.proto = proto
+	return true
+}
+
+// defineOwnProperty creates or updates the own property of o with key as
+// desc tells, and reports whether it succeeded.
+//
+// https://262.ecma-international.org/#sec-validateandapplypropertydescriptor
+func (o *Object) defineOwnProperty(key propertyKey, desc descriptor) bool {
+	if ns, ok := o.internal.(*moduleNamespace); ok && key.symbol == nil {
+		return ns.define(o.runtime, key.name, desc)
+	}
+	if o.array {
+		if key.symbol == nil && key.name == "length" {
+			return o.defineLength(desc)
+		}
+		if i, ok := key.index(); ok {
+			if i >= o.length && o.lengthRO {
+				return false
+			}
+			if !o.defineElement(i, desc) {
+				return false
+			}
+			if i >= o.length {
+				o.length = i + 1
+			}
+			return true
+		}
+	}
+	if m, ok := o.internal.(argumentsMap); ok {
+		if i, b := m.lookup(key); b != nil {
+			return o.defineArgument(m, i, desc)
+		}
+	}
+	if o.elements != nil {
+		// the elements of arguments objects
+		if i, ok := key.index(); ok {
+			return o.defineElement(i, desc)
+		}
+	}
+	if o.class == "String" {
+		if _, ok := o.stringIndex(key); ok {
+			current, _ := o.getOwnProperty(key)
+			return isCompatible(current, desc)
+		}
+	}
+	return o.defineOrdinary(key, desc)
+}
+
+// defineElement defines the index property i of an array, keeping it in
+// elements while it has default attributes.
+func (o *Object) defineElement(i uint32, desc descriptor) bool {
+	inElements := int(i) < len(o.elements) && o.elements[i] != nil
+	if inElements {
+		if desc.isAccessor() || desc.has&hasWritable != 0 && desc.flags&writable == 0 ||
+			desc.has&hasEnumerable != 0 && desc.flags&enumerable == 0 ||
+			desc.has&hasConfigurable != 0 && desc.flags&configurable == 0 {
+			// the attributes change: move the element to props
+			value := o.elements[i]
+			o.elements[i] = nil
+			o.addProperty(indexKey(i), &property{value: value, flags: defaultFlags})
+			return o.defineOrdinary(indexKey(i), desc)
+		}
+		if desc.has&hasValue != 0 {
+			o.elements[i] = desc.value
+		}
+		return true
+	}
+
+	key := indexKey(i)
+	_, inProps := o.props[key]
+	isDefault := desc.has&hasValue != 0 && !desc.isAccessor() &&
+		desc.flags&defaultFlags == defaultFlags && desc.has&(hasWritable|hasEnumerable|hasConfigurable) == hasWritable|hasEnumerable|hasConfigurable
+	// dense enough to keep in elements
+	if !inProps && isDefault && o.extensible && int(i) <= len(o.elements)+1024 {
+		if o.elements == nil {
+			o.elements = make([]Value, 0, 4)
+		}
+		for int(i) >= len(o.elements) {
+			o.elements = append(o.elements, nil)
+		}
+		o.elements[i] = desc.value
+		return true
+	}
+	return o.defineOrdinary(key, desc)
+}
+
+// defineLength defines the length of an array, deleting the elements past a
+// shorter length.
+//
+// https://262.ecma-international.org/#sec-arraysetlength
+func (o *Object) defineLength(desc descriptor) bool {
+	if desc.isAccessor() || desc.has&hasConfigurable != 0 && desc.flags&configurable != 0 ||
+		desc.has&hasEnumerable != 0 && desc.flags&enumerable != 0 {
+		return false
+	}
+	if desc.has&hasValue == 0 {
+		if desc.has&hasWritable != 0 && desc.flags&writable != 0 && o.lengthRO {
+			return false
+		}
+		if desc.has&hasWritable != 0 && desc.flags&writable == 0 {
+			o.lengthRO = true
+		}
+		return true
+	}
+	n := o.runtime.toNumber(desc.value)
+	length := toUint32(n)
+	if float64(length) != n {
+		panic(o.runtime.newRangeError("Invalid array length"))
+	}
+	if o.lengthRO {
+		return length == o.length
+	}
+	ok := o.truncate(length)
+	if desc.has&hasWritable != 0 && desc.flags&writable == 0 {
+		o.lengthRO = true
+	}
+	return ok
+}
+
+// truncate sets the length of an array, deleting the properties past it. It
+// stops at the first non-configurable one.
+func (o *Object) truncate(length uint32) bool {
+	if length < o.length {
+		if int(length) < len(o.elements) {
+			for i := length; int(i) < len(o.elements); i++ {
+				o.elements[i] = nil
+			}
+			o.elements = o.elements[:length]
+		}
+		var indexes []uint32
+		for key := range o.props {
+			if i, ok := key.index(); ok && i >= length {
+				indexes = append(indexes, i)
+			}
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] > indexes[j] })
+		for _, i := range indexes {
+			if o.props[indexKey(i)].flags&configurable == 0 {
+				o.length = i + 1
+				return false
+			}
+			o.removeProperty(indexKey(i))
+		}
+	}
+	o.length = length
+	return true
+}
+
+// isCompatible reports whether defining desc on the non-configurable,
+// non-writable property current changes nothing.
+func isCompatible(current property, desc descriptor) bool {
+	if desc.has&hasConfigurable != 0 && desc.flags&configurable != current.flags&configurable ||
+		desc.has&hasEnumerable != 0 && desc.flags&enumerable != current.flags&enumerable ||
+		desc.isAccessor() || desc.has&hasWritable != 0 && desc.flags&writable != 0 {
+		return false
+	}
+	return desc.has&hasValue == 0 || sameValue(desc.value, current.value)
+}
+
+// defineOrdinary applies desc to the property of props with key.
+func (o *Object) defineOrdinary(key propertyKey, desc descriptor) bool {
+	current, ok := o.props[key]
+	if !ok {
+		if !o.extensible {
+			return false
+		}
+		p := &property{value: Undefined}
+		if desc.isAccessor() {
+			p.flags = accessor
+			p.getter, _ = desc.getter.(*Object)
+			p.setter, _ = desc.setter.(*Object)
+			p.value = nil
+		} else if desc.has&hasValue != 0 {
+			p.value = desc.value
+		}
+		p.flags |= desc.flags & (writable | enumerable | configurable)
+		if desc.isAccessor() {
+			p.flags &^= writable
+		}
+		o.addProperty(key, p)
+		return true
+	}
+
+	if current.flags&configurable == 0 {
+		if desc.has&hasConfigurable != 0 && desc.flags&configurable != 0 ||
+			desc.has&hasEnumerable != 0 && desc.flags&enumerable != current.flags&enumerable {
+			return false
+		}
+		switch {
+		case !desc.isAccessor() && !desc.isData():
+		case current.isAccessor() != desc.isAccessor():
+			return false
+		case current.isAccessor():
+			if desc.has&hasGet != 0 && !sameValue(objectOrUndefined(current.getter), desc.getter) ||
+				desc.has&hasSet != 0 && !sameValue(objectOrUndefined(current.setter), desc.setter) {
+				return false
+			}
+		case current.flags&writable == 0:
+			if desc.has&hasWritable != 0 && desc.flags&writable != 0 ||
+				desc.has&hasValue != 0 && !sameValue(desc.value, current.value) {
+				return false
+			}
+		}
+	}
+
+	switch {
+	case desc.isAccessor() && !current.isAccessor():
+		current.value = nil
+		current.flags = current.flags&(enumerable|configurable) | accessor
+	case desc.isData() && current.isAccessor():
+		current.getter, current.setter = nil, nil
+		current.value = Undefined
+		current.flags &= enumerable | configurable
+	}
+	if desc.has&hasValue != 0 {
+		current.value = desc.value
+	}
+	if desc.has&hasGet != 0 {
+		current.getter, _ = desc.getter.(*Object)
+	}
+	if desc.has&hasSet != 0 {
+		current.setter, _ = desc.setter.(*Object)
+	}
+	for _, field := range []struct {
+		has  descriptorFields
+		flag propertyFlags
+	}{{hasWritable, writable}, {hasEnumerable, enumerable}, {hasConfigurable, configurable}} {
+		if desc.has&field.has != 0 {
+			current.flags = current.flags&^field.flag | desc.flags&field.flag
+		}
+	}
+	return true
+}
+
+func (o *Object) addProperty(key propertyKey, p *property) {
+	if o.props == nil {
+		o.props = make(map[propertyKey]*property)
+	}
+	o.props[key] = p
+	o.keys = append(o.keys, key)
+}
+
+func objectOrUndefined(o *Object) Value {
+	if o == nil {
+		return Undefined
+	}
+	return o
+}
+
+// //////////////////////
+// Abstract operations //
+// //////////////////////
+
+// setProperty defines a data property with default attributes on an object
+// being created, as built-ins and literals do.
+func (o *Object) setProperty(name string, v Value) {
+	o.defineOwnProperty(stringKey(name), dataDescriptor(v, defaultFlags))
+}
+
+// setHidden defines a non-enumerable property, as the methods and
+// properties of built-in objects are.
+func (o *Object) setHidden(key propertyKey, v Value) {
+	o.defineOwnProperty(key, dataDescriptor(v, methodFlags))
+}
+
+// setConstant defines a property that can not be changed.
+func (o *Object) setConstant(key propertyKey, v Value) {
+	o.defineOwnProperty(key, dataDescriptor(v, 0))
+}
+
+// setAccessor defines a non-enumerable accessor property.
+func (o *Object) setAccessor(key propertyKey, getter, setter *Object) {
+	desc := descriptor{getter: objectOrUndefined(getter), setter: objectOrUndefined(setter), flags: configurable, has: hasGet | hasSet | hasEnumerable | hasConfigurable}
+	o.defineOwnProperty(key, desc)
+}
+
+// getV returns the property of v with key, looked up on the prototype of
+// primitives.
+//
+// https://262.ecma-international.org/#sec-getv
+func (r *Runtime) getV(v Value, key propertyKey) Value {
+	switch v := v.(type) {
+	case *Object:
+		return v.get(key, v)
+	case String:
+		if key.symbol == nil {
+			if key.name == "length" {
+				return Number(stringLength(string(v)))
+			}
+			if i, ok := key.index(); ok && int(i) < stringLength(string(v)) {
+				return String(substring(string(v), int(i), int(i)+1))
+			}
+		}
+		return r.stringPrototype.get(key, v)
+	case Number:
+		return r.numberPrototype.get(key, v)
+	case Bool:
+		return r.booleanPrototype.get(key, v)
+	case *Symbol:
+		return r.symbolPrototype.get(key, v)
+	}
+	panic(r.newTypeError("Cannot read properties of %s (reading '%s')", v, key))
+}
+
+// putV assigns to the property of v with key, failing in strict mode code if
+// the assignment does not succeed.
+//
+// https://262.ecma-international.org/#sec-putvalue
+func (r *Runtime) putV(v Value, key propertyKey, value Value, strict bool) {
+	var ok bool
+	switch base := v.(type) {
+	case *Object:
+		ok = base.set(key, value, base)
+	case String, Number, Bool, *Symbol:
+		ok = r.toObject(base).set(key, value, base)
+	default:
+		panic(r.newTypeError("Cannot set properties of %s (setting '%s')", v, key))
+	}
+	if !ok && strict {
+		panic(r.newTypeError("Cannot assign to read only property '%s' of %s", key, r.describe(v)))
+	}
+}
+
+// getMethod returns the function of the property of v with key, or nil if
+// it is undefined or null.
+//
+// https://262.ecma-international.org/#sec-getmethod
+func (r *Runtime) getMethod(v Value, key propertyKey) *Object {
+	method := r.getV(v, key)
+	if isNullish(method) {
+		return nil
+	}
+	fn, ok := method.(*Object)
+	if !ok || fn.call == nil {
+		panic(r.newTypeError("%s is not a function", r.describe(method)))
+	}
+	return fn
+}
+
+// createDataProperty defines an enumerable data property, failing if it
+// can not be defined.
+//
+// https://262.ecma-international.org/#sec-createdatapropertyorthrow
+func (r *Runtime) createDataProperty(o *Object, key propertyKey, v Value) {
+	if !o.defineOwnProperty(key, dataDescriptor(v, defaultFlags)) {
+		panic(r.newTypeError("Cannot define property %s", key))
+	}
+}
+
+// definePropertyOrThrow defines a property, failing if it can not be
+// defined.
+//
+// https://262.ecma-international.org/#sec-definepropertyorthrow
+func (r *Runtime) definePropertyOrThrow(o *Object, key propertyKey, desc descriptor) {
+	if !o.defineOwnProperty(key, desc) {
+		panic(r.newTypeError("Cannot redefine property: %s", key))
+	}
+}
+
+// lengthOfArrayLike returns the length of an array-like object.
+//
+// https://262.ecma-international.org/#sec-lengthofarraylike
+func (r *Runtime) lengthOfArrayLike(o *Object) int64 {
+	if o.array {
+		return int64(o.length)
+	}
+	return r.toLength(o.get(stringKey("length"), o))
+}
+
+// copyDataProperties copies the enumerable own properties of source to
+// target, but those with excluded keys.
+//
+// https://262.ecma-international.org/#sec-copydataproperties
+func (r *Runtime) copyDataProperties(target *Object, source Value, excluded map[propertyKey]bool) {
+	if isNullish(source) {
+		return
+	}
+	from := r.toObject(source)
+	for _, key := range from.ownKeys() {
+		if excluded[key] {
+			continue
+		}
+		if p, ok := from.getOwnProperty(key); ok && p.flags&enumerable != 0 {
+			r.createDataProperty(target, key, from.get(key, from))
+		}
+	}
+}
+
+// describe returns a short description of v for error messages.
+func (r *Runtime) describe(v Value) string {
+	switch v := v.(type) {
+	case String:
+		return strconv.Quote(string(v))
+	case *Object:
+		if v.call != nil {
+			if name, ok := v.getOwnProperty(stringKey("name")); ok {
+				if name, ok := name.value.(String); ok && name != "" {
+					return "function " + string(name)
+				}
+			}
+			return "function"
+		}
+		return v.String()
+	case *Symbol:
+		return v.String()
+	}
+	return v.(interface{ String() string }).String()
+}
@@ -0,0 +1,317 @@
+package runtime
+
+import (
+	"math"
+
+	l "github.com/ruiconti/gojs/lexer"
+)
+
+// binaryOperation applies the arithmetic, bitwise, relational or equality
+// operator op to the values of its operands.
+//
+// https://262.ecma-international.org/#sec-applystringornumericbinaryoperator
+func (r *Runtime) binaryOperation(op l.TokenType, left, right Value) Value {
+	switch op {
+	case l.TPlus, l.TPlusAssign:
+		return r.add(left, right)
+	case l.TStrictEqual:
+		return Bool(strictEquals(left, right))
+	case l.TStrictNotEqual:
+		return Bool(!strictEquals(left, right))
+	case l.TEqual:
+		return Bool(r.looseEquals(left, right))
+	case l.TNotEqual:
+		return Bool(!r.looseEquals(left, right))
+	case l.TLessThan:
+		return Bool(r.lessThan(left, right, true) == compareTrue)
+	case l.TGreaterThan:
+		return Bool(r.lessThan(right, left, false) == compareTrue)
+	case l.TLessThanEqual:
+		return Bool(r.lessThan(right, left, false) == compareFalse)
+	case l.TGreaterThanEqual:
+		return Bool(r.lessThan(left, right, true) == compareFalse)
+	case l.TInstanceof:
+		return Bool(r.instanceOf(left, right))
+	case l.TIn:
+		o, ok := right.(*Object)
+		if !ok {
+			panic(r.newTypeError("Cannot use 'in' operator to search for '%s' in %s", r.toString(r.toPrimitive(left, "string")), r.describe(right)))
+		}
+		return Bool(o.hasProperty(r.toPropertyKey(left)))
+	}
+
+	a, b := r.toNumeric(left), r.toNumeric(right)
+	switch op {
+	case l.TMinus, l.TMinusAssign:
+		return Number(a - b)
+	case l.TStar, l.TStarAssign:
+		return Number(a * b)
+	case l.TSlash, l.TSlashAssign:
+		return Number(a / b)
+	case l.TPercent, l.TPercentAssign:
+		return Number(remainder(a, b))
+	case l.TStarStar:
+		return Number(exponentiate(a, b))
+	case l.TLeftShift, l.TLeftShiftAssign:
+		return Number(int32(toUint32(a) << (toUint32(b) & 31)))
+	case l.TRightShift, l.TRightShiftAssign:
+		return Number(int32(toUint32(a)) >> (toUint32(b) & 31))
+	case l.TUnsignedRightShift, l.TUnsignedRightShiftAssign:
+		return Number(toUint32(a) >> (toUint32(b) & 31))
+	case l.TAnd, l.TAndAssign:
+		return Number(int32(toUint32(a) & toUint32(b)))
+	case l.TOr, l.TOrAssign:
+		return Number(int32(toUint32(a) | toUint32(b)))
+	case l.TXor, l.TXorAssign:
+		return Number(int32(toUint32(a) ^ toUint32(b)))
+	}
+	panic(r.newSyntaxError("Unexpected operator %s", op.S()))
+}
+
+// add applies the + operator, which concatenates strings and adds numbers.
+//
+// https://262.ecma-international.org/#sec-applystringornumericbinaryoperator
+func (r *Runtime) add(left, right Value) Value {
+	if a, ok := left.(Number); ok {
+		if b, ok := right.(Number); ok {
+			return a + b
+		}
+	}
+	left, right = r.toPrimitive(left, "default"), r.toPrimitive(right, "default")
+	_, leftString := left.(String)
+	_, rightString := right.(String)
+	if leftString || rightString {
+		return String(concatStrings(r.toString(left), r.toString(right)))
+	}
+	return Number(r.toNumeric(left) + r.toNumeric(right))
+}
+
+// remainder returns the remainder of a divided by b, with the sign of a.
+//
+// https://262.ecma-international.org/#sec-numeric-types-number-remainder
+func remainder(a, b float64) float64 {
+	if math.IsInf(b, 0) && !math.IsInf(a, 0) && !math.IsNaN(a) {
+		return a
+	}
+	return math.Mod(a, b)
+}
+
+// exponentiate returns a raised to the power b, which unlike math.Pow is NaN
+// when |a| is 1 and b is infinite.
+//
+// https://262.ecma-international.org/#sec-numeric-types-number-exponentiate
+func exponentiate(a, b float64) float64 {
+	if math.IsNaN(b) || (a == 1 || a == -1) && math.IsInf(b, 0) {
+		return math.NaN()
+	}
+	return math.Pow(a, b)
+}
+
+// unaryOperation applies the unary operator op to the value of its operand,
+// but for delete and typeof, which take a reference.
+//
+// https://262.ecma-international.org/#sec-unary-operators
+func (r *Runtime) unaryOperation(op l.TokenType, v Value) Value {
+	switch op {
+	case l.TVoid:
+		return Undefined
+	case l.TBang:
+		return Bool(!toBoolean(v))
+	case l.TPlus:
+		return Number(r.toNumber(v))
+	case l.TMinus:
+		return Number(-r.toNumeric(v))
+	case l.TTilde:
+		return Number(^r.toInt32(v))
+	}
+	panic(r.newSyntaxError("Unexpected operator %s", op.S()))
+}
+
+// ///////////
+// Equality //
+// ///////////
+
+// strictEquals applies the === operator.
+//
+// https://262.ecma-international.org/#sec-isstrictlyequal
+func strictEquals(a, b Value) bool {
+	if a, ok := a.(Number); ok {
+		b, ok := b.(Number)
+		return ok && a == b
+	}
+	return a == b
+}
+
+// looseEquals applies the == operator, converting operands of different
+// types.
+//
+// https://262.ecma-international.org/#sec-islooselyequal
+func (r *Runtime) looseEquals(a, b Value) bool {
+	for {
+		if a.Type() == b.Type() && (a == Null) == (b == Null) {
+			return strictEquals(a, b)
+		}
+		if isNullish(a) && isNullish(b) {
+			return true
+		}
+		switch x := a.(type) {
+		case Number:
+			switch y := b.(type) {
+			case String:
+				return float64(x) == stringToNumber(string(y))
+			case Bool:
+				b = Number(r.toNumber(y))
+				continue
+			case *Object:
+				b = r.toPrimitive(y, "default")
+				continue
+			}
+		case String:
+			switch y := b.(type) {
+			case Number:
+				return stringToNumber(string(x)) == float64(y)
+			case Bool:
+				b = Number(r.toNumber(y))
+				continue
+			case *Object:
+				b = r.toPrimitive(y, "default")
+				continue
+			}
+		case Bool:
+			a = Number(r.toNumber(x))
+			continue
+		case *Symbol:
+			if y, ok := b.(*Object); ok {
+				b = r.toPrimitive(y, "default")
+				continue
+			}
+		case *Object:
+			switch b.(type) {
+			case Number, String, Bool, *Symbol:
+				a = r.toPrimitive(x, "default")
+				continue
+			}
+		}
+		if _, ok := b.(Bool); ok {
+			b = Number(r.toNumber(b))
+			continue
+		}
+		return false
+	}
+}
+
+// sameValue reports whether a and b are the same value, which unlike ===
+// tells +0 from -0 and NaN from nothing else.
+//
+// https://262.ecma-international.org/#sec-samevalue
+func sameValue(a, b Value) bool {
+	if x, ok := a.(Number); ok {
+		y, ok := b.(Number)
+		if !ok {
+			return false
+		}
+		if math.IsNaN(float64(x)) {
+			return math.IsNaN(float64(y))
+		}
+		return x == y && math.Signbit(float64(x)) == math.Signbit(float64(y))
+	}
+	return a == b
+}
+
+// sameValueZero reports whether a and b are the same value, +0 and -0
+// included.
+//
+// https://262.ecma-international.org/#sec-samevaluezero
+func sameValueZero(a, b Value) bool {
+	if x, ok := a.(Number); ok {
+		if y, ok := b.(Number); ok && math.IsNaN(float64(x)) {
+			return math.IsNaN(float64(y))
+		}
+	}
+	return strictEquals(a, b)
+}
+
+// /////////////
+// Relational //
+// /////////////
+
+// comparison is the result of IsLessThan, undefined when an operand is NaN.
+type comparison int
+
+const (
+	compareFalse comparison = iota
+	compareTrue
+	compareUndefined
+)
+
+// lessThan compares a and b, converting a first when leftFirst.
+//
+// https://262.ecma-international.org/#sec-islessthan
+func (r *Runtime) lessThan(a, b Value, leftFirst bool) comparison {
+	if leftFirst {
+		a = r.toPrimitive(a, "number")
+		b = r.toPrimitive(b, "number")
+	} else {
+		b = r.toPrimitive(b, "number")
+		a = r.toPrimitive(a, "number")
+	}
+	if x, ok := a.(String); ok {
+		if y, ok := b.(String); ok {
+			if compareStrings(string(x), string(y)) < 0 {
+				return compareTrue
+			}
+			return compareFalse
+		}
+	}
+	x, y := r.toNumeric(a), r.toNumeric(b)
+	switch {
+	case math.IsNaN(x) || math.IsNaN(y):
+		return compareUndefined
+	case x < y:
+		return compareTrue
+	}
+	return compareFalse
+}
+
+// compareStrings compares a and b by their UTF-16 code units, which sorts
+// supplementary characters before the last characters of the BMP unlike
+// UTF-8 does.
+func compareStrings(a, b string) int {
+	if isASCII(a) && isASCII(b) {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	x, y := toUTF16(a), toUTF16(b)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(x) - len(y)
+}
+
+// instanceOf applies the instanceof operator, through the @@hasInstance
+// method of target if any.
+//
+// https://262.ecma-international.org/#sec-instanceofoperator
+func (r *Runtime) instanceOf(v, target Value) bool {
+	o, ok := target.(*Object)
+	if !ok {
+		panic(r.newTypeError("Right-hand side of 'instanceof' is not an object"))
+	}
+	if method := r.getMethod(o, symbolKey(SymbolHasInstance)); method != nil {
+		return toBoolean(r.callFunction(method, o, v))
+	}
+	if o.call == nil {
+		panic(r.newTypeError("Right-hand side of 'instanceof' is not callable"))
+	}
+	return r.ordinaryHasInstance(o, v)
+}
@@ -0,0 +1,108 @@
+package runtime
+
+import "github.com/ruiconti/gojs/parser"
+
+// bindPattern destructures v into the target of a binding or an
+// assignment. The identifiers of target are bound in env, or assigned to
+// when env is nil.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-bindinginitialization
+// https://262.ecma-international.org/#sec-runtime-semantics-destructuringassignmentevaluation
+func (c *context) bindPattern(target parser.Node, v Value, env *environment) {
+	r := c.r
+	switch t := target.(type) {
+	case *parser.ExprIdentifier:
+		if env != nil {
+			initialize(env, t.Name, v)
+			return
+		}
+		c.putValue(c.identifierReference(t.Name), v)
+	case *parser.ObjectPattern:
+		if isNullish(v) {
+			panic(r.newTypeError("Cannot destructure '%s' as it is %s.", r.toString(v), r.toString(v)))
+		}
+		var excluded map[propertyKey]bool
+		for _, p := range t.Properties {
+			switch p := p.(type) {
+			case *parser.PatternProperty:
+				key := c.propertyName(p.Key, p.Computed)
+				if excluded == nil {
+					excluded = make(map[propertyKey]bool)
+				}
+				excluded[key] = true
+				c.bindElement(p.Value, func() Value { return r.getV(v, key) }, env)
+			case *parser.RestElement:
+				rest := r.newObject(r.objectPrototype)
+				c.bindElement(p.Argument, func() Value {
+					r.copyDataProperties(rest, v, excluded)
+					return rest
+				}, env)
+			}
+		}
+	case *parser.ArrayPattern:
+		it := r.getIterator(v)
+		defer r.closeOnPanic(it)
+		for _, element := range t.Elements {
+			switch element := element.(type) {
+			case nil:
+				r.step(it)
+			case *parser.RestElement:
+				c.bindElement(element.Argument, func() Value {
+					var values []Value
+					for {
+						value, ok := r.step(it)
+						if !ok {
+							return r.newArray(values...)
+						}
+						values = append(values, value)
+					}
+				}, env)
+			default:
+				c.bindElement(element, func() Value {
+					value, _ := r.step(it)
+					return value
+				}, env)
+			}
+		}
+		r.closeIterator(it)
+	case *parser.AssignmentPattern:
+		c.bindElement(t, func() Value { return v }, env)
+	default:
+		c.putValue(c.reference(target), v)
+	}
+}
+
+// bindElement binds the target of an element or property of a pattern,
+// with its default value, to the value get returns. The reference of a
+// target that is a property access is evaluated before get is called.
+//
+// https://262.ecma-international.org/#sec-runtime-semantics-iteratorbindinginitialization
+func (c *context) bindElement(target parser.Node, get func() Value, env *environment) {
+	var init parser.Expr
+	if assignment, ok := target.(*parser.AssignmentPattern); ok {
+		target, init = assignment.Left, assignment.Right
+	}
+	var ref *reference
+	if env == nil {
+		switch parser.Unparenthesized(target).(type) {
+		case *parser.ObjectPattern, *parser.ArrayPattern:
+		default:
+			ref = new(reference)
+			*ref = c.reference(target)
+		}
+	}
+
+	v := get()
+	if init != nil && v == Undefined {
+		if id, ok := target.(*parser.ExprIdentifier); ok {
+			v = c.evaluateNamed(init, stringKey(id.Name))
+		} else {
+			v = c.evaluate(init)
+		}
+	}
+	if ref != nil {
+		c.putValue(*ref, v)
+		return
+	}
+	c.bindPattern(target, v, env)
+}
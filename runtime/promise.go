@@ -0,0 +1,296 @@
+package runtime
+
+type promiseState int
+
+const (
+	promisePending promiseState = iota
+	promiseFulfilled
+	promiseRejected
+)
+
+// promise is the state of a Promise object.
+//
+// https://262.ecma-international.org/#sec-properties-of-promise-instances
+type promise struct {
+	state  promiseState
+	result Value
+	// the reactions to run once the promise settles, as it is fulfilled or
+	// rejected
+	fulfillReactions []*promiseReaction
+	rejectReactions  []*promiseReaction
+}
+
+// promiseReaction is a handler of the settlement of a promise, whose result
+// settles the promise of capability.
+//
+// https://262.ecma-international.org/#sec-promisereaction-records
+type promiseReaction struct {
+	capability *promiseCapability // nil for the reactions of await
+	fulfill    bool
+	handler    Value // Undefined passes the settlement on
+}
+
+// promiseCapability is a promise along with the functions resolving and
+// rejecting it.
+//
+// https://262.ecma-international.org/#sec-promisecapability-records
+type promiseCapability struct {
+	promise *Object
+	resolve Value
+	reject  Value
+}
+
+// thisPromise returns the state of the Promise object v, if it is one.
+func thisPromise(v Value) (*promise, bool) {
+	if o, ok := v.(*Object); ok {
+		p, ok := o.internal.(*promise)
+		return p, ok
+	}
+	return nil, false
+}
+
+// newPromise returns a pending promise inheriting from proto.
+func (r *Runtime) newPromise(proto *Object) *Object {
+	o := r.newObject(proto)
+	o.class = "Promise"
+	o.internal = &promise{}
+	return o
+}
+
+// createResolvingFunctions returns the functions resolving and rejecting the
+// promise o, of which only the first call has an effect.
+//
+// https://262.ecma-international.org/#sec-createresolvingfunctions
+func (r *Runtime) createResolvingFunctions(o *Object) (resolve, reject *Object) {
+	alreadyResolved := false
+	resolve = r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+		if alreadyResolved {
+			return Undefined
+		}
+		alreadyResolved = true
+		r.resolvePromise(o, arg(args, 0))
+		return Undefined
+	})
+	reject = r.newNativeFunction("", 1, func(this Value, args []Value) Value {
+		if alreadyResolved {
+			return Undefined
+		}
+		alreadyResolved = true
+		r.rejectPromise(o, arg(args, 0))
+		return Undefined
+	})
+	return resolve, reject
+}
+
+// resolvePromise resolves the promise o with resolution: it is fulfilled
+// with it, unless resolution is a thenable, which o then follows.
+//
+// https://262.ecma-international.org/#sec-promise-resolve-functions
+func (r *Runtime) resolvePromise(o *Object, resolution Value) {
+	if resolution == o {
+		r.rejectPromise(o, r.newTypeError("Chaining cycle detected for promise #<Promise>").Value)
+		return
+	}
+	thenable, ok := resolution.(*Object)
+	if !ok {
+		r.fulfillPromise(o, resolution)
+		return
+	}
+	then, exception := r.try(func() Value {
+		return thenable.get(stringKey("then"), thenable)
+	})
+	switch {
+	case exception != nil:
+		r.rejectPromise(o, exception.Value)
+	case !isCallable(then):
+		r.fulfillPromise(o, resolution)
+	default:
+		// https://262.ecma-international.org/#sec-newpromiseresolvethenablejob
+		r.enqueueJob(func() {
+			resolve, reject := r.createResolvingFunctions(o)
+			if _, exception := r.try(func() Value {
+				return r.callFunction(then, thenable, resolve, reject)
+			}); exception != nil {
+				reject.call(Undefined, []Value{exception.Value})
+			}
+		})
+	}
+}
+
+// fulfillPromise fulfills the pending promise o with value.
+//
+// https://262.ecma-international.org/#sec-fulfillpromise
+func (r *Runtime) fulfillPromise(o *Object, value Value) {
+	p := o.internal.(*promise)
+	reactions := p.fulfillReactions
+	p.state, p.result = promiseFulfilled, value
+	p.fulfillReactions, p.rejectReactions = nil, nil
+	r.triggerPromiseReactions(reactions, value)
+}
+
+// rejectPromise rejects the pending promise o with reason.
+//
+// https://262.ecma-international.org/#sec-rejectpromise
+func (r *Runtime) rejectPromise(o *Object, reason Value) {
+	p := o.internal.(*promise)
+	reactions := p.rejectReactions
+	p.state, p.result = promiseRejected, reason
+	p.fulfillReactions, p.rejectReactions = nil, nil
+	r.triggerPromiseReactions(reactions, reason)
+}
+
+// https://262.ecma-international.org/#sec-triggerpromisereactions
+func (r *Runtime) triggerPromiseReactions(reactions []*promiseReaction, argument Value) {
+	for _, reaction := range reactions {
+		r.enqueueReactionJob(reaction, argument)
+	}
+}
+
+// enqueueReactionJob queues the job running the handler of reaction with
+// argument, the value or reason of the settled promise.
+//
+// https://262.ecma-international.org/#sec-newpromisereactionjob
+func (r *Runtime) enqueueReactionJob(reaction *promiseReaction, argument Value) {
+	r.enqueueJob(func() {
+		var result Value
+		var exception *Exception
+		switch {
+		case reaction.handler != Undefined:
+			result, exception = r.try(func() Value {
+				return r.callFunction(reaction.handler, Undefined, argument)
+			})
+		case reaction.fulfill:
+			result = argument
+		default:
+			exception = r.throw(argument)
+		}
+		if reaction.capability == nil {
+			return
+		}
+		if exception != nil {
+			r.callFunction(reaction.capability.reject, Undefined, exception.Value)
+			return
+		}
+		r.callFunction(reaction.capability.resolve, Undefined, result)
+	})
+}
+
+// newPromiseCapability creates a promise with the constructor c, along with
+// the functions it passes to its executor.
+//
+// https://262.ecma-international.org/#sec-newpromisecapability
+func (r *Runtime) newPromiseCapability(c Value) *promiseCapability {
+	if !isConstructor(c) {
+		panic(r.newTypeError("%s is not a constructor", r.describe(c)))
+	}
+	capability := &promiseCapability{resolve: Undefined, reject: Undefined}
+	executor := r.newNativeFunction("", 2, func(this Value, args []Value) Value {
+		if capability.resolve != Undefined || capability.reject != Undefined {
+			panic(r.newTypeError("Promise executor has already been invoked with non-undefined arguments"))
+		}
+		capability.resolve, capability.reject = arg(args, 0), arg(args, 1)
+		return Undefined
+	})
+	o := r.constructObject(c, []Value{executor}, nil)
+	if !isCallable(capability.resolve) || !isCallable(capability.reject) {
+		panic(r.newTypeError("Promise resolve or reject function is not callable"))
+	}
+	capability.promise = o
+	return capability
+}
+
+// newIntrinsicCapability returns a capability for a new promise of the
+// intrinsic constructor, which runs no code.
+func (r *Runtime) newIntrinsicCapability() *promiseCapability {
+	o := r.newPromise(r.promisePrototype)
+	resolve, reject := r.createResolvingFunctions(o)
+	return &promiseCapability{promise: o, resolve: resolve, reject: reject}
+}
+
+// performPromiseThen adds the handlers onFulfilled and onRejected to the
+// promise o, whose results settle the promise of capability, if any.
+//
+// https://262.ecma-international.org/#sec-performpromisethen
+func (r *Runtime) performPromiseThen(o *Object, onFulfilled, onRejected Value, capability *promiseCapability) Value {
+	if !isCallable(onFulfilled) {
+		onFulfilled = Undefined
+	}
+	if !isCallable(onRejected) {
+		onRejected = Undefined
+	}
+	fulfillReaction := &promiseReaction{capability: capability, fulfill: true, handler: onFulfilled}
+	rejectReaction := &promiseReaction{capability: capability, handler: onRejected}
+	p := o.internal.(*promise)
+	switch p.state {
+	case promisePending:
+		p.fulfillReactions = append(p.fulfillReactions, fulfillReaction)
+		p.rejectReactions = append(p.rejectReactions, rejectReaction)
+	case promiseFulfilled:
+		r.enqueueReactionJob(fulfillReaction, p.result)
+	case promiseRejected:
+		r.enqueueReactionJob(rejectReaction, p.result)
+	}
+	if capability == nil {
+		return Undefined
+	}
+	return capability.promise
+}
+
+// promiseResolve returns x when it is a promise made by the constructor c,
+// and otherwise a new promise of c resolved with x.
+//
+// https://262.ecma-international.org/#sec-promise-resolve
+func (r *Runtime) promiseResolve(c *Object, x Value) *Object {
+	if _, ok := thisPromise(x); ok {
+		o := x.(*Object)
+		if o.get(stringKey("constructor"), o) == c {
+			return o
+		}
+	}
+	capability := r.newPromiseCapability(c)
+	r.callFunction(capability.resolve, Undefined, x)
+	return capability.promise
+}
+
+// try calls fn, and returns the exception it throws instead of propagating
+// it.
+func (r *Runtime) try(fn func() Value) (v Value, exception *Exception) {
+	defer func() {
+		if x := recover(); x != nil {
+			e, ok := x.(*Exception)
+			if !ok {
+				panic(x)
+			}
+			exception = e
+		}
+	}()
+	return fn(), nil
+}
+
+// ////////////
+// Job queue //
+// ////////////
+
+// enqueueJob queues job to run once no code is running, after those queued
+// before.
+//
+// https://262.ecma-international.org/#sec-hostenqueuepromisejob
+func (r *Runtime) enqueueJob(job func()) {
+	r.jobs = append(r.jobs, job)
+}
+
+// runJobs runs the queued jobs, and those they queue in turn, unless code is
+// running: the jobs then run when it returns.
+func (r *Runtime) runJobs() {
+	if len(r.stack) > 0 || r.runningJobs {
+		return
+	}
+	r.runningJobs = true
+	defer func() { r.runningJobs = false }()
+	for len(r.jobs) > 0 {
+		job := r.jobs[0]
+		r.jobs[0] = nil
+		r.jobs = r.jobs[1:]
+		job()
+	}
+}
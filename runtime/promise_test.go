@@ -0,0 +1,149 @@
+package runtime
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// promiseTests are scripts pushing to the array log, along with its contents
+// once the runtime is idle.
+var promiseTests = []struct{ src, expected string }{
+	// jobs run in order, once the script completes
+	{src: "Promise.resolve().then(() => log.push('a1')).then(() => log.push('a2')); Promise.resolve().then(() => log.push('b1')).then(() => log.push('b2')); log.push('sync')", expected: "sync,a1,b1,a2,b2"},
+	{src: "new Promise(resolve => { log.push('executor'); resolve(1) }).then(v => log.push(v)); log.push('sync')", expected: "executor,sync,1"},
+	{src: "new Promise((resolve, reject) => { resolve(1); reject(2); resolve(3) }).then(v => log.push(v))", expected: "1"},
+	{src: "new Promise(() => JSON.parse('{')).then(null, e => log.push(e.name))", expected: "SyntaxError"},
+	{src: "Promise.reject(1).then(v => log.push('fulfilled')).catch(e => log.push('caught ' + e)).then(v => log.push(v))", expected: "caught 1,1"},
+	{src: "Promise.resolve(1).then(v => v.x.y).catch(e => log.push(e.name))", expected: "TypeError"},
+	{src: "Promise.resolve(1).then(2).then(v => log.push(v))", expected: "1"},
+	{src: "var p = Promise.resolve(); var q = p.then(() => q); q.catch(e => log.push(e.message))", expected: "Chaining cycle detected for promise #<Promise>"},
+	{src: "Promise.resolve({ then(resolve) { log.push('then'); resolve(2) } }).then(v => log.push(v)); log.push('sync')", expected: "sync,then,2"},
+	{src: "Promise.resolve({ get then() { return null.then } }).catch(e => log.push(e.name))", expected: "TypeError"},
+	{src: "var p = Promise.resolve(1); log.push(Promise.resolve(p) === p, Promise.reject(p) === p)", expected: "true,false"},
+	{src: "Promise.resolve(1).finally(() => log.push('finally')).then(v => log.push(v)); Promise.reject(2).finally(() => 3).catch(e => log.push(e))", expected: "finally,1,2"},
+	{src: "Promise.resolve(1).finally(() => Promise.reject('override')).catch(e => log.push(e))", expected: "override"},
+	{src: "var r = Promise.withResolvers(); r.promise.then(v => log.push(v)); r.resolve('resolved')", expected: "resolved"},
+
+	// combinators
+	{src: "Promise.all([1, Promise.resolve(2), { then(f) { f(3) } }]).then(v => log.push(v.join('+')))", expected: "1+2+3"},
+	{src: "Promise.all([]).then(v => log.push(v.length))", expected: "0"},
+	{src: "Promise.all([Promise.resolve(1), Promise.reject('no')]).catch(e => log.push(e))", expected: "no"},
+	{src: "Promise.all(1).catch(e => log.push(e.name))", expected: "TypeError"},
+	{src: "Promise.allSettled([1, Promise.reject(2)]).then(v => log.push(JSON.stringify(v)))", expected: `[{"status":"fulfilled","value":1},{"status":"rejected","reason":2}]`},
+	{src: "Promise.any([Promise.reject(1), Promise.resolve(2)]).then(v => log.push(v))", expected: "2"},
+	{src: "Promise.any([Promise.reject(1), Promise.reject(2)]).catch(e => log.push(e.name, e.message, e.errors.join()))", expected: "AggregateError,All promises were rejected,1,2"},
+	{src: "Promise.any([]).catch(e => log.push(e instanceof AggregateError))", expected: "true"},
+	{src: "Promise.race([new Promise(() => {}), Promise.resolve('fast')]).then(v => log.push(v))", expected: "fast"},
+
+	// subclassing through the species constructor
+	{src: "function P(executor) { log.push('P'); return new Promise(executor) } P.resolve = Promise.resolve; var p = Promise.resolve(1); p.constructor = { [Symbol.species]: P }; p.then(v => log.push(v))", expected: "P,1"},
+	{src: "Promise()", expected: "TypeError: Promise constructor cannot be invoked without 'new'"},
+	{src: "new Promise(1)", expected: "TypeError: Promise resolver 1 is not a function"},
+	{src: "Promise.prototype.then.call({})", expected: "TypeError: Method Promise.prototype.then called on incompatible receiver [object Object]"},
+
+	// async functions
+	{src: "async function f() { log.push('f1'); await null; log.push('f2') } f(); Promise.resolve().then(() => log.push('p')); log.push('sync')", expected: "f1,sync,f2,p"},
+	{src: "async function f() { return 1 } async function g() { return Promise.resolve(2) } g().then(v => log.push(v)); f().then(v => log.push(v)); Promise.resolve().then(() => log.push('a')).then(() => log.push('b')).then(() => log.push('c'))", expected: "1,a,b,2,c"},
+	{src: "async function add(a, b) { return await a + await b } add(1, Promise.resolve(2)).then(v => log.push(v))", expected: "3"},
+	{src: "var f = async (x) => x * 2; var o = { async m() { return this.v }, v: 'm' }; f(2).then(v => log.push(v)); o.m().then(v => log.push(v))", expected: "4,m"},
+	{src: "async function f() { return null.bad } f().catch(e => log.push(e.name)); log.push('sync')", expected: "sync,TypeError"},
+	{src: "async function f() { await Promise.reject('rejected'); log.push('unreached') } f().catch(e => log.push(e))", expected: "rejected"},
+	{src: "async function f(a = null.x) {} f().catch(e => log.push(e.name))", expected: "TypeError"},
+	{src: "async function f(n) { return n == 0 ? 0 : n + await f(n - 1) } f(100).then(v => log.push(v))", expected: "5050"},
+	{src: "async function f() { return await { then(resolve) { resolve('thenable') } } } f().then(v => log.push(v))", expected: "thenable"},
+	{src: "async function f() {} log.push(f() instanceof Promise, Object.getPrototypeOf(f)[Symbol.toStringTag], 'prototype' in f)", expected: "true,AsyncFunction,false"},
+	{src: "async function f() {} new f()", expected: "TypeError: f is not a constructor"},
+}
+
+func TestPromises(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			for _, tc := range promiseTests {
+				t.Run(tc.src, func(t *testing.T) {
+					r := New()
+					r.SetEngine(e.engine)
+					got := ""
+					if _, err := r.RunString("var log = []; " + tc.src); err != nil {
+						got = err.Error()
+					} else if err := r.RunUntilIdle(gocontext.Background()); err != nil {
+						got = err.Error()
+					} else {
+						v, _ := r.RunString("log.join()")
+						got = string(v.(String))
+					}
+					if got != tc.expected && got != "Uncaught "+tc.expected {
+						t.Errorf("expected %s, got %s", tc.expected, got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRunUntilIdle(t *testing.T) {
+	r := New()
+	p, resolver := r.NewPromise()
+	r.GlobalObject().setProperty("p", p)
+	if _, err := r.RunString("var log = []; (async () => log.push(await p))()"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		resolver.Resolve(String("from Go"))
+		resolver.Reject(String("ignored"))
+	}()
+	if err := r.RunUntilIdle(gocontext.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := r.RunString("log.join()"); v != String("from Go") {
+		t.Errorf("expected the promise to be resolved from Go, got %v", v)
+	}
+
+	// tasks run in order, each followed by the jobs it queues
+	var order []string
+	r.Post(func() {
+		order = append(order, "task 1")
+		fn, _ := r.RunString("(() => Promise.resolve().then(() => log.push('job')))")
+		r.Call(fn, Undefined)
+	})
+	r.Post(func() {
+		v, _ := r.RunString("log.pop()")
+		order = append(order, string(v.(String)), "task 2")
+	})
+	if err := r.RunUntilIdle(gocontext.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(order); got != 3 || order[1] != "job" {
+		t.Errorf("expected the job to run between the tasks, got %v", order)
+	}
+}
+
+func TestRunUntilIdle_Canceled(t *testing.T) {
+	r := New()
+	r.NewPromise()
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.RunUntilIdle(ctx); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded waiting for the promise, got %v", err)
+	}
+}
+
+func TestRunUntilIdle_Exception(t *testing.T) {
+	r := New()
+	fn, _ := r.RunString("(function () { return null.task })")
+	ran := false
+	r.Post(func() { r.callFunction(fn, Undefined) })
+	r.Post(func() { ran = true })
+	var exception *Exception
+	if err := r.RunUntilIdle(gocontext.Background()); !errors.As(err, &exception) {
+		t.Fatalf("expected an exception, got %v", err)
+	}
+	if ran {
+		t.Errorf("expected the task after the exception not to run yet")
+	}
+	if err := r.RunUntilIdle(gocontext.Background()); err != nil || !ran {
+		t.Errorf("expected the remaining task to run, got %v", err)
+	}
+}
@@ -0,0 +1,600 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	valueType = reflect.TypeOf((*Value)(nil)).Elem()
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	// the type of the functions JavaScript functions export to as interface{}
+	anyFuncType = reflect.TypeOf(func(...interface{}) (interface{}, error) { return nil, nil })
+)
+
+// goValue is the internal state of an object reflecting a Go value: a
+// pointer to a struct, or a function.
+type goValue struct {
+	v reflect.Value
+}
+
+// goError is the internal state of an Error object thrown for a Go error.
+type goError struct {
+	err error
+}
+
+// ///////////////////
+// Go to JavaScript //
+// ///////////////////
+
+// Set defines the global variable name with the value of v, converted as
+// ToValue does. A function is named name.
+func (r *Runtime) Set(name string, v interface{}) error {
+	var value Value
+	if fn := reflect.ValueOf(v); fn.Kind() == reflect.Func && !fn.IsNil() {
+		value = r.wrapFunc(fn, name)
+	} else {
+		var err error
+		if value, err = r.ToValue(v); err != nil {
+			return err
+		}
+	}
+	r.global.setProperty(name, value)
+	return nil
+}
+
+// ToValue converts the Go value v to a JavaScript value:
+//
+//   - nil, and nil pointers, maps, slices and functions, to null
+//   - a Value to itself
+//   - booleans, numbers and strings to their primitive values
+//   - functions to functions converting their arguments with ExportTo, and
+//     their results with ToValue: several results make an array, and a non-nil
+//     error as the last result is thrown, as an Error object
+//   - structs, and pointers to structs, to objects reflecting them: an
+//     accessor property for each exported field reads and writes it, and each
+//     exported method is a method of the object. A struct is copied first
+//   - maps with string keys to objects, and slices and arrays to arrays,
+//     holding copies of their elements
+//   - other pointers to the value they point to
+//
+// It returns an error for the other types, as channels are.
+func (r *Runtime) ToValue(v interface{}) (Value, error) {
+	return r.toValue(reflect.ValueOf(v))
+}
+
+func (r *Runtime) toValue(v reflect.Value) (Value, error) {
+	if !v.IsValid() {
+		return Null, nil
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return Null, nil
+		}
+		return r.toValue(v.Elem())
+	}
+	if v.Type().Implements(valueType) && v.CanInterface() {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return Null, nil
+		}
+		return v.Interface().(Value), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return Bool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Number(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Number(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return Number(v.Float()), nil
+	case reflect.String:
+		return String(v.String()), nil
+	case reflect.Func:
+		if v.IsNil() {
+			return Null, nil
+		}
+		return r.wrapFunc(v, ""), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return Null, nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return r.wrapStruct(v), nil
+		}
+		return r.toValue(v.Elem())
+	case reflect.Struct:
+		// the fields of an addressable struct are those of its container
+		if v.CanAddr() {
+			return r.wrapStruct(v.Addr()), nil
+		}
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return r.wrapStruct(p), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			return Null, nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		o := r.newObject(r.objectPrototype)
+		for _, key := range keys {
+			value, err := r.toValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			o.setProperty(key.String(), value)
+		}
+		return o, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return Null, nil
+		}
+		elements := make([]Value, v.Len())
+		for i := range elements {
+			element, err := r.toValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return r.newArray(elements...), nil
+	}
+	return nil, fmt.Errorf("can not convert %s to a JavaScript value", v.Type())
+}
+
+// mustValue converts v as toValue does, throwing a TypeError when it can
+// not.
+func (r *Runtime) mustValue(v reflect.Value) Value {
+	value, err := r.toValue(v)
+	if err != nil {
+		panic(r.newTypeError("%v", err))
+	}
+	return value
+}
+
+// wrapFunc returns a function named name calling the Go function fn.
+func (r *Runtime) wrapFunc(fn reflect.Value, name string) *Object {
+	t := fn.Type()
+	length := t.NumIn()
+	if t.IsVariadic() {
+		length--
+	}
+	o := r.newNativeFunction(name, length, func(this Value, args []Value) Value {
+		return r.goCall(func() Value {
+			in := make([]reflect.Value, 0, len(args))
+			for i := 0; i < t.NumIn(); i++ {
+				if t.IsVariadic() && i == t.NumIn()-1 {
+					for ; i < len(args); i++ {
+						in = append(in, r.exportArg(args, i, t.In(t.NumIn()-1).Elem()))
+					}
+					break
+				}
+				in = append(in, r.exportArg(args, i, t.In(i)))
+			}
+			return r.goResults(fn.Call(in))
+		})
+	})
+	o.internal = goValue{fn}
+	return o
+}
+
+// exportArg converts the argument at index i to a Go value of type t,
+// throwing a TypeError when it can not.
+func (r *Runtime) exportArg(args []Value, i int, t reflect.Type) reflect.Value {
+	x, err := r.export(arg(args, i), t)
+	if err != nil {
+		panic(r.newTypeError("argument %d: %v", i, err))
+	}
+	return x
+}
+
+// goResults returns the value of the results of a Go function: undefined
+// for none, and an array for several. A non-nil error as the last result is
+// thrown instead.
+func (r *Runtime) goResults(out []reflect.Value) Value {
+	if n := len(out); n > 0 && out[n-1].Type() == errorType {
+		if err := out[n-1]; !err.IsNil() {
+			panic(r.goError(err.Interface().(error)))
+		}
+		out = out[:n-1]
+	}
+	switch len(out) {
+	case 0:
+		return Undefined
+	case 1:
+		return r.mustValue(out[0])
+	}
+	values := make([]Value, len(out))
+	for i, x := range out {
+		values[i] = r.mustValue(x)
+	}
+	return r.newArray(values...)
+}
+
+// wrapStruct returns an object reflecting the struct p points to. The fields
+// of embedded structs are those of the object, as they are promoted.
+func (r *Runtime) wrapStruct(p reflect.Value) *Object {
+	o := r.newObject(r.objectPrototype)
+	o.internal = goValue{p}
+	s := p.Elem()
+	for _, field := range reflect.VisibleFields(s.Type()) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, index := field.Name, field.Index
+		getter := r.newNativeFunction("get "+name, 0, func(this Value, args []Value) Value {
+			return r.goCall(func() Value {
+				f, err := s.FieldByIndexErr(index)
+				if err != nil {
+					return Undefined
+				}
+				return r.mustValue(f)
+			})
+		})
+		setter := r.newNativeFunction("set "+name, 1, func(this Value, args []Value) Value {
+			return r.goCall(func() Value {
+				f, err := s.FieldByIndexErr(index)
+				if err != nil || !f.CanSet() {
+					panic(r.newTypeError("Cannot assign to read only property '%s' of object", name))
+				}
+				x, err := r.export(arg(args, 0), f.Type())
+				if err != nil {
+					panic(r.newTypeError("%v", err))
+				}
+				f.Set(x)
+				return Undefined
+			})
+		})
+		o.defineOwnProperty(stringKey(name), descriptor{getter: getter, setter: setter, flags: enumerable, has: hasGet | hasSet | hasEnumerable | hasConfigurable})
+	}
+	for i := 0; i < p.NumMethod(); i++ {
+		name := p.Type().Method(i).Name
+		o.setHidden(stringKey(name), r.wrapFunc(p.Method(i), name))
+	}
+	return o
+}
+
+// goCall calls fn, Go code called from a script, and throws what it panics
+// with: an *Exception as it is, and other values as Error objects.
+func (r *Runtime) goCall(fn func() Value) Value {
+	defer func() {
+		switch x := recover().(type) {
+		case nil:
+		case *Exception:
+			panic(x)
+		case error:
+			panic(r.goError(x))
+		default:
+			panic(r.goError(fmt.Errorf("%v", x)))
+		}
+	}()
+	return fn()
+}
+
+// goError returns an exception throwing err: the exception err is, or
+// wraps, or else an Error object with its message, which the exception
+// unwraps to.
+func (r *Runtime) goError(err error) *Exception {
+	var exception *Exception
+	if errors.As(err, &exception) {
+		return exception
+	}
+	o := r.newErrorObject(r.errorPrototypes["Error"], err.Error())
+	o.internal = goError{err}
+	return r.throw(o)
+}
+
+// ///////////////////
+// JavaScript to Go //
+// ///////////////////
+
+// ExportTo converts the JavaScript value v to the Go value target points to,
+// as its type requires:
+//
+//   - booleans, numbers and strings as Boolean, Number and String convert v.
+//     Integers are truncated, and must be in the range of their type
+//   - slices and arrays from array-like objects, maps with string keys from
+//     the enumerable own properties of objects, and structs from the
+//     properties of objects named after their exported fields
+//   - pointers from null and undefined to nil, and else to the conversion of
+//     v to the type they point to
+//   - functions from functions, called with their arguments converted with
+//     ToValue. A function returning an error as its last result returns the
+//     exception thrown with it, and else panics with the exception
+//   - Value, and the other interfaces v implements, from v itself
+//   - interface{} from undefined and null to nil, booleans to bool, numbers
+//     to float64, strings to string, arrays to []interface{}, functions to
+//     func(...interface{}) (interface{}, error) and other objects to
+//     map[string]interface{}
+//
+// Strings convert with their lone surrogates replaced with U+FFFD, as valid
+// UTF-8. Objects reflecting Go values convert back to those values, when
+// their types allow. ExportTo returns an error when v does not convert, and an
+// *Exception when the conversion throws.
+func (r *Runtime) ExportTo(v Value, target interface{}) (err error) {
+	p := reflect.ValueOf(target)
+	if p.Kind() != reflect.Pointer || p.IsNil() {
+		return fmt.Errorf("export target must be a non-nil pointer, got %T", target)
+	}
+	defer r.recoverException(&err)
+	x, err := r.export(v, p.Type().Elem())
+	if err != nil {
+		return err
+	}
+	p.Elem().Set(x)
+	return nil
+}
+
+// export converts v to a Go value of type t, as ExportTo does.
+func (r *Runtime) export(v Value, t reflect.Type) (reflect.Value, error) {
+	e := exporter{r: r}
+	return e.export(v, t)
+}
+
+// exporter converts JavaScript values to Go values, with the stack of the
+// objects being converted to detect cycles.
+type exporter struct {
+	r     *Runtime
+	stack []*Object
+}
+
+func (e *exporter) export(v Value, t reflect.Type) (reflect.Value, error) {
+	r := e.r
+	fail := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("can not convert %s to %s", r.describe(v), t)
+	}
+	emptyInterface := t.Kind() == reflect.Interface && t.NumMethod() == 0
+	if o, ok := v.(*Object); ok {
+		if g, ok := o.internal.(goValue); ok {
+			switch {
+			case g.v.Type().AssignableTo(t):
+				return convert(g.v, t), nil
+			case g.v.Kind() == reflect.Pointer && g.v.Elem().Type().AssignableTo(t):
+				return convert(g.v.Elem(), t), nil
+			}
+		}
+	}
+	if !emptyInterface && reflect.TypeOf(v).AssignableTo(t) {
+		return convert(reflect.ValueOf(v), t), nil
+	}
+
+	x := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		x.SetBool(toBoolean(v))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := math.Trunc(r.toNumber(v))
+		if math.IsNaN(n) || n < math.MinInt64 || n >= -math.MinInt64 || x.OverflowInt(int64(n)) {
+			return fail()
+		}
+		x.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := math.Trunc(r.toNumber(v))
+		if math.IsNaN(n) || n < 0 || n >= 1<<64 || x.OverflowUint(uint64(n)) {
+			return fail()
+		}
+		x.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		x.SetFloat(r.toNumber(v))
+	case reflect.String:
+		x.SetString(toWellFormed(r.toString(v)))
+	case reflect.Pointer:
+		if isNullish(v) {
+			return x, nil
+		}
+		elem, err := e.export(v, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		x = reflect.New(t.Elem())
+		x.Elem().Set(elem)
+	case reflect.Func:
+		if isNullish(v) {
+			return x, nil
+		}
+		if !isCallable(v) {
+			return fail()
+		}
+		return e.exportFunc(v.(*Object), t), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && isNullish(v) {
+			return x, nil
+		}
+		o, ok := v.(*Object)
+		if !ok {
+			return fail()
+		}
+		n := int(r.lengthOfArrayLike(o))
+		if t.Kind() == reflect.Array && n != t.Len() {
+			return reflect.Value{}, fmt.Errorf("can not convert an array of length %d to %s", n, t)
+		}
+		if t.Kind() == reflect.Slice {
+			x = reflect.MakeSlice(t, n, n)
+		}
+		if err := e.enter(o); err != nil {
+			return reflect.Value{}, err
+		}
+		defer e.leave()
+		for i := 0; i < n; i++ {
+			elem, err := e.export(o.get(stringKey(strconv.Itoa(i)), o), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			x.Index(i).Set(elem)
+		}
+	case reflect.Map:
+		if isNullish(v) {
+			return x, nil
+		}
+		o, ok := v.(*Object)
+		if !ok || t.Key().Kind() != reflect.String {
+			return fail()
+		}
+		if err := e.enter(o); err != nil {
+			return reflect.Value{}, err
+		}
+		defer e.leave()
+		x = reflect.MakeMap(t)
+		for _, key := range r.enumerableOwnProperties(o, "keys") {
+			elem, err := e.export(o.get(stringKey(string(key.(String))), o), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			x.SetMapIndex(reflect.ValueOf(toWellFormed(string(key.(String)))).Convert(t.Key()), elem)
+		}
+	case reflect.Struct:
+		o, ok := v.(*Object)
+		if !ok {
+			return fail()
+		}
+		if err := e.enter(o); err != nil {
+			return reflect.Value{}, err
+		}
+		defer e.leave()
+		for _, field := range reflect.VisibleFields(t) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			value := o.get(stringKey(field.Name), o)
+			if value == Undefined {
+				continue
+			}
+			f, err := x.FieldByIndexErr(field.Index)
+			if err != nil {
+				continue
+			}
+			elem, err := e.export(value, field.Type)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			f.Set(elem)
+		}
+	case reflect.Interface:
+		if !emptyInterface {
+			return fail()
+		}
+		elem, err := e.exportAny(v)
+		if err != nil || !elem.IsValid() {
+			return x, err
+		}
+		x.Set(elem)
+	default:
+		return fail()
+	}
+	return x, nil
+}
+
+// exportAny converts v to the Go value of its type, which ExportTo documents
+// for interface{}, or to the invalid Value for nil.
+func (e *exporter) exportAny(v Value) (reflect.Value, error) {
+	switch v := v.(type) {
+	case Bool:
+		return reflect.ValueOf(bool(v)), nil
+	case Number:
+		return reflect.ValueOf(float64(v)), nil
+	case String:
+		return reflect.ValueOf(toWellFormed(string(v))), nil
+	case *Symbol:
+		return reflect.ValueOf(v), nil
+	case *Object:
+		if g, ok := v.internal.(goValue); ok {
+			return g.v, nil
+		}
+		switch {
+		case v.call != nil:
+			return e.exportFunc(v, anyFuncType), nil
+		case v.array:
+			return e.export(v, reflect.TypeOf([]interface{}{}))
+		}
+		return e.export(v, reflect.TypeOf(map[string]interface{}{}))
+	}
+	return reflect.Value{}, nil
+}
+
+// exportFunc returns a Go function of type t calling the function fn.
+func (e *exporter) exportFunc(fn *Object, t reflect.Type) reflect.Value {
+	r := e.r
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.New(t.Out(i)).Elem()
+		}
+		var err error
+		func() {
+			defer r.recoverException(&err)
+			var args []Value
+			for i, x := range in {
+				if t.IsVariadic() && i == len(in)-1 {
+					for j := 0; j < x.Len(); j++ {
+						args = append(args, r.mustValue(x.Index(j)))
+					}
+					break
+				}
+				args = append(args, r.mustValue(x))
+			}
+			result := r.callFunction(fn, Undefined, args...)
+			r.runJobs()
+			results := out
+			if returnsError {
+				results = out[:len(out)-1]
+			}
+			switch len(results) {
+			case 0:
+			case 1:
+				results[0] = r.exportResult(result, t.Out(0))
+			default:
+				for i := range results {
+					results[i] = r.exportResult(r.getV(result, stringKey(strconv.Itoa(i))), t.Out(i))
+				}
+			}
+		}()
+		switch {
+		case err == nil:
+		case returnsError:
+			out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		default:
+			panic(err)
+		}
+		return out
+	})
+}
+
+// exportResult converts the result of a function to a Go value of type t,
+// throwing a TypeError when it can not.
+func (r *Runtime) exportResult(v Value, t reflect.Type) reflect.Value {
+	x, err := r.export(v, t)
+	if err != nil {
+		panic(r.newTypeError("%v", err))
+	}
+	return x
+}
+
+func (e *exporter) enter(o *Object) error {
+	for _, parent := range e.stack {
+		if parent == o {
+			return errors.New("can not convert a cyclic structure")
+		}
+	}
+	e.stack = append(e.stack, o)
+	return nil
+}
+
+func (e *exporter) leave() {
+	e.stack = e.stack[:len(e.stack)-1]
+}
+
+// convert returns x as a value of type t, which it is assignable to.
+func convert(x reflect.Value, t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	v.Set(x)
+	return v
+}
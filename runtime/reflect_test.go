@@ -0,0 +1,230 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+	name string
+}
+
+func (p *point) Scale(k int) { p.X, p.Y = p.X*k, p.Y*k }
+
+func (p point) String() string { return fmt.Sprintf("(%d, %d)", p.X, p.Y) }
+
+type labeled struct {
+	point
+	Label string
+}
+
+var errNotFound = errors.New("not found")
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		src      string
+		expected string
+	}{
+		{value: func(a, b int) int { return a + b }, src: "v(1, '2')", expected: "3"},
+		{value: func(a, b int) int { return a + b }, src: "[v.name, v.length]", expected: "v,2"},
+		{value: func(sep string, parts ...string) string { return strings.Join(parts, sep) }, src: "v('-', 'a', 'b', 'c')", expected: "a-b-c"},
+		{value: func(n int) (int, int) { return n / 2, n % 2 }, src: "v(7)", expected: "3,1"},
+		{value: func() {}, src: "v()", expected: "undefined"},
+		{value: func(f func(int) int) int { return f(20) + 1 }, src: "v(n => n * 2)", expected: "41"},
+		{value: func(n int) (int, error) { return n, nil }, src: "v(1.9)", expected: "1"},
+		{value: func(o map[string]int) int { return o["a"] + o["b"] }, src: "v({ a: 1, b: 2 })", expected: "3"},
+		{value: func(p *point) int { return p.X }, src: "v({ X: 4 })", expected: "4"},
+		{value: func(x interface{}) string { return fmt.Sprintf("%T", x) }, src: "[v(1), v('s'), v([1]), v({}), v(null)].join(' ')", expected: "float64 string []interface {} map[string]interface {} <nil>"},
+		{value: func(v Value) string { return v.Type() }, src: "v(Symbol())", expected: "symbol"},
+		{value: func(n int) int { return n }, src: "v('x')", expected: `TypeError: argument 0: can not convert "x" to int`},
+		{value: func(n uint8) uint8 { return n }, src: "v(256)", expected: "TypeError: argument 0: can not convert 256 to uint8"},
+
+		{value: &point{X: 1, Y: 2}, src: "v.X + v.Y", expected: "3"},
+		{value: &point{X: 1, Y: 2}, src: "v.Scale(3); [v.X, v.Y, v.String()]", expected: "3,6,(3, 6)"},
+		{value: &point{X: 1, Y: 2}, src: "JSON.stringify(v)", expected: `{"X":1,"Y":2}`},
+		{value: &point{X: 1, Y: 2}, src: "[Object.keys(v), 'name' in v]", expected: "X,Y,false"},
+		{value: &point{X: 1, Y: 2}, src: "v.X = 'a'", expected: `TypeError: can not convert "a" to int`},
+		{value: labeled{point: point{X: 1, Y: 2}, Label: "p"}, src: "[v.Label, v.X, v.String()]", expected: "p,1,(1, 2)"},
+		{value: map[string][]int{"a": {1, 2}, "b": nil}, src: "JSON.stringify(v)", expected: `{"a":[1,2],"b":null}`},
+		{value: []interface{}{1, "a", true, nil}, src: "v.map(x => typeof x)", expected: "number,string,boolean,object"},
+		{value: [2]float64{0.5, 1}, src: "Array.isArray(v) && v[0] + v[1]", expected: "1.5"},
+		{value: String("s"), src: "v", expected: "s"},
+
+		// Go errors and panics are thrown
+		{value: func() error { return errNotFound }, src: "v()", expected: "Error: not found"},
+		{value: func() { var p *point; p.Scale(1) }, src: "v()", expected: "Error: runtime error: invalid memory address or nil pointer dereference"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			r := New()
+			if err := r.Set("v", tc.value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := ""
+			if v, err := r.RunString(tc.src); err != nil {
+				got = strings.TrimPrefix(err.Error(), "Uncaught ")
+			} else {
+				got = r.toString(v)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSet_Struct(t *testing.T) {
+	r := New()
+	p := &point{X: 1, Y: 2}
+	r.Set("p", p)
+	if _, err := r.RunString("p.X = 10; p.Scale(2)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.X != 20 || p.Y != 4 {
+		t.Errorf("expected the script to update the struct, got %v", p)
+	}
+	if err := r.Set("c", make(chan int)); err == nil || err.Error() != "can not convert chan int to a JavaScript value" {
+		t.Errorf("expected an error converting a channel, got %v", err)
+	}
+}
+
+func TestExportTo(t *testing.T) {
+	p := &point{X: 1}
+	tests := []struct {
+		src      string
+		target   interface{}
+		expected interface{}
+	}{
+		{src: "true", target: new(bool), expected: true},
+		{src: "'3'", target: new(int), expected: 3},
+		{src: "-1.5", target: new(int8), expected: int8(-1)},
+		{src: "1.5", target: new(float32), expected: float32(1.5)},
+		{src: "[1, 2]", target: new(string), expected: "1,2"},
+		{src: "[1, '2', 3]", target: new([]int), expected: []int{1, 2, 3}},
+		{src: "({ length: 2, 0: 'a', 1: 'b' })", target: new([2]string), expected: [2]string{"a", "b"}},
+		{src: "({ a: [1], b: [] })", target: new(map[string][]int), expected: map[string][]int{"a": {1}, "b": {}}},
+		{src: "({ X: 1, Y: '2', name: 'hidden' })", target: new(point), expected: point{X: 1, Y: 2}},
+		{src: "({ Label: 'l', X: 3 })", target: new(labeled), expected: labeled{point: point{X: 3}, Label: "l"}},
+		{src: "({ X: 1 })", target: new(*point), expected: &point{X: 1}},
+		{src: "null", target: new(*point), expected: (*point)(nil)},
+		{src: "null", target: new([]int), expected: []int(nil)},
+		{src: "({ a: [1, 'b', null, { c: true }] })", target: new(interface{}), expected: map[string]interface{}{"a": []interface{}{1.0, "b", nil, map[string]interface{}{"c": true}}}},
+		{src: "undefined", target: new(interface{}), expected: nil},
+		{src: "'s'", target: new(Value), expected: String("s")},
+		{src: "p", target: new(*point), expected: p},
+		{src: "p", target: new(interface{}), expected: p},
+		{src: "p", target: new(fmt.Stringer), expected: p},
+	}
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			r := New()
+			r.Set("p", p)
+			v, err := r.RunString(tc.src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := r.ExportTo(v, tc.target); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := reflect.ValueOf(tc.target).Elem().Interface(); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExportTo_Errors(t *testing.T) {
+	tests := []struct {
+		src      string
+		target   interface{}
+		expected string
+	}{
+		{src: "NaN", target: new(int), expected: "can not convert NaN to int"},
+		{src: "-1", target: new(uint), expected: "can not convert -1 to uint"},
+		{src: "1", target: new([]int), expected: "can not convert 1 to []int"},
+		{src: "[1, 2]", target: new([3]int), expected: "can not convert an array of length 2 to [3]int"},
+		{src: "var a = []; a.push(a); a", target: new(interface{}), expected: "can not convert a cyclic structure"},
+		{src: "({ toString() { return null.x } })", target: new(string), expected: "Uncaught TypeError: Cannot read properties of null (reading 'x')"},
+		{src: "1", target: new(chan int), expected: "can not convert 1 to chan int"},
+		{src: "1", target: 0, expected: "export target must be a non-nil pointer, got int"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			r := New()
+			v, err := r.RunString(tc.src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := r.ExportTo(v, tc.target); err == nil || err.Error() != tc.expected {
+				t.Errorf("expected %s, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestExportTo_Function(t *testing.T) {
+	r := New()
+	v, _ := r.RunString("(function add(a, b) { if (!b) return null.x; return a + b })")
+	var add func(a, b int) (int, error)
+	if err := r.ExportTo(v, &add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := add(1, 2); err != nil || n != 3 {
+		t.Errorf("expected 3, got %v, %v", n, err)
+	}
+	var call func(args ...interface{}) (interface{}, error)
+	r.ExportTo(v, &call)
+	if result, err := call("a", "b"); err != nil || result != "ab" {
+		t.Errorf("expected ab, got %v, %v", result, err)
+	}
+	var exception *Exception
+	if _, err := call(1); !errors.As(err, &exception) {
+		t.Errorf("expected an exception, got %v", err)
+	}
+
+	var mustAdd func(a, b int) int
+	r.ExportTo(v, &mustAdd)
+	defer func() {
+		if _, ok := recover().(*Exception); !ok {
+			t.Errorf("expected a panic with the exception")
+		}
+	}()
+	mustAdd(1, 2)
+	mustAdd(1, 0)
+}
+
+func TestGoErrors(t *testing.T) {
+	r := New()
+	r.Set("find", func(key string) (string, error) {
+		return "", fmt.Errorf("%s: %w", key, errNotFound)
+	})
+	r.Set("callback", func(f func() error) error { return f() })
+	_, err := r.RunScript("script.js", "function lookup() { return find('k') }\nlookup()")
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("expected the error returned by Go, got %v", err)
+	}
+	if got, expected := fmt.Sprintf("%+v", err), "Uncaught Error: k: not found\n    at lookup (script.js:1:21)\n    at script.js:2:1"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	// exceptions pass through Go functions unchanged
+	_, err = r.RunString("callback(() => null.x)")
+	var exception *Exception
+	if !errors.As(err, &exception) || !strings.Contains(err.Error(), "TypeError") || exception.Unwrap() != nil {
+		t.Errorf("expected the TypeError thrown by the script, got %v", err)
+	}
+
+	// panics in Go functions are thrown, and may be caught
+	r.Set("boom", func() { panic("boom") })
+	if _, err := r.RunString("var caught; new Promise(() => boom()).catch(e => caught = e.message)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := r.RunString("caught"); v != String("boom") {
+		t.Errorf("expected the panic to be caught, got %v", v)
+	}
+}
@@ -0,0 +1,478 @@
+// Package runtime evaluates parsed JavaScript programs, with the semantics of
+// the ECMAScript specification: primitive values and their conversions,
+// objects with prototype chains, closures, this binding, generators, promises,
+// async functions and exceptions.
+//
+// A Runtime holds a global object and runs scripts and modules against it.
+// The promise jobs they queue run once they complete. It is not safe for
+// concurrent use, but for Post and the methods of Resolver: other goroutines
+// hand work to the event loop that RunUntilIdle runs.
+//
+//	rt := runtime.New()
+//	v, err := rt.RunString("[1, 2, 3].length * 2")
+//
+// Go values are exposed to scripts with Set, and script values converted
+// back with ExportTo:
+//
+//	rt.Set("double", func(n int) int { return n * 2 })
+//	v, err = rt.RunString("double(21)")
+//	var n int
+//	err = rt.ExportTo(v, &n)
+//
+//	p, resolver := rt.NewPromise()
+//	go func() { resolver.Resolve(runtime.String(fetch())) }()
+//	err = rt.RunUntilIdle(ctx)
+//
+// https://262.ecma-international.org/#sec-executable-code-and-execution-contexts
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// maxCallDepth bounds the nesting of function calls, past which a RangeError
+// is thrown rather than exhausting the Go stack.
+const maxCallDepth = 4096
+
+// Runtime is an execution environment: a realm with its global object and
+// built-in objects, and the call stack of the running code.
+//
+// https://262.ecma-international.org/#sec-code-realms
+type Runtime struct {
+	global    *Object
+	globalEnv *environment // the declarative part, holding let and const
+	varEnv    *environment // the object part, backed by global
+
+	// the intrinsic objects of the realm
+	objectPrototype         *Object
+	functionPrototype       *Object
+	arrayPrototype          *Object
+	stringPrototype         *Object
+	numberPrototype         *Object
+	booleanPrototype        *Object
+	symbolPrototype         *Object
+	iteratorPrototype       *Object
+	arrayIteratorPrototype  *Object
+	stringIteratorPrototype *Object
+	arrayValues             *Object // Array.prototype.values
+	generatorFunction       *Object // the prototype of generator functions
+	generatorPrototype      *Object
+	asyncFunction           *Object // the prototype of async functions
+	promiseConstructor      *Object
+	promisePrototype        *Object
+	regexpPrototype         *Object
+	regexpConstructor       *Object
+	errorPrototypes         map[string]*Object // by the name of the error type
+	throwTypeError          *Object
+	eval                    *Object
+
+	// the names of the var and function declarations of the scripts run,
+	// which their lexical declarations can not redeclare
+	varNames map[string]bool
+
+	modules map[string]*module
+	loader  ModuleLoader
+
+	engine Engine
+
+	// the symbols of the global symbol registry, by key
+	symbols map[string]*Symbol
+
+	// the promise jobs to run once no code is running, and whether they
+	// are running
+	jobs        []func()
+	runningJobs bool
+	loop        eventLoop
+
+	stack []StackFrame
+	// the arrays being joined, which join as empty strings where they
+	// contain themselves
+	joining map[*Object]bool
+}
+
+// New returns a Runtime with a fresh global object.
+func New() *Runtime {
+	r := &Runtime{
+		varNames: make(map[string]bool),
+		modules:  make(map[string]*module),
+		joining:  make(map[*Object]bool),
+		symbols:  make(map[string]*Symbol),
+		loop:     eventLoop{wake: make(chan struct{}, 1)},
+	}
+	r.initIntrinsics()
+	r.varEnv = &environment{object: r.global}
+	r.globalEnv = newEnvironment(r.varEnv)
+	return r
+}
+
+// Engine is how a Runtime runs code.
+type Engine int
+
+const (
+	// EngineVM compiles code to bytecode run by a virtual machine, but
+	// for code using a with statement or a direct call to eval, which is
+	// interpreted.
+	EngineVM Engine = iota
+	// EngineInterpreter walks the syntax tree of the code.
+	EngineInterpreter
+)
+
+// SetEngine sets how the scripts and modules run afterwards are run.
+func (r *Runtime) SetEngine(e Engine) {
+	r.engine = e
+}
+
+// GlobalObject returns the global object, whose properties are the global
+// variables.
+func (r *Runtime) GlobalObject() *Object {
+	return r.global
+}
+
+// RunString runs src as a script and returns its completion value, the value
+// of the last expression statement evaluated.
+func (r *Runtime) RunString(src string) (Value, error) {
+	return r.RunScript("<eval>", src)
+}
+
+// RunScript runs the script src, named name in stack traces, and returns its
+// completion value. It returns a *parser.SyntaxError, or an error from the
+// parser, when src does not parse, a *parser.EarlyError when it breaks an
+// early error rule, and an *Exception when an exception is thrown out of the
+// script.
+func (r *Runtime) RunScript(name, src string) (result Value, err error) {
+	file, err := parse(src, parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.recoverException(&err)
+	result = r.runScript(newScript(name, file))
+	r.runJobs()
+	return result, nil
+}
+
+// Call calls the function fn with this and args, and returns its result.
+func (r *Runtime) Call(fn Value, this Value, args ...Value) (result Value, err error) {
+	defer r.recoverException(&err)
+	result = r.callFunction(fn, this, args...)
+	r.runJobs()
+	return result, nil
+}
+
+// Get returns the value of the property of o named name, calling its getter.
+func (o *Object) Get(name string) (v Value, err error) {
+	defer o.runtime.recoverException(&err)
+	return o.get(stringKey(name), o), nil
+}
+
+// recoverException recovers the JavaScript exception propagating as a
+// panic, and sets err to it.
+func (r *Runtime) recoverException(err *error) {
+	switch x := recover().(type) {
+	case nil:
+	case *Exception:
+		*err = x
+	default:
+		panic(x)
+	}
+}
+
+// /////////////
+// Exceptions //
+// /////////////
+
+// Exception is a JavaScript exception thrown out of the running code, along
+// with the call stack where it was thrown.
+//
+// Within the runtime, exceptions propagate as panics carrying an *Exception,
+// recovered where the runtime returns to its caller.
+type Exception struct {
+	// Value is the thrown value, usually an Error object
+	Value Value
+	// Stack holds the calls active when the exception was thrown, the
+	// innermost first
+	Stack []StackFrame
+}
+
+func (e *Exception) Error() string {
+	return "Uncaught " + errorString(e.Value)
+}
+
+// Unwrap returns the Go error the exception throws, when a Go function called
+// from the script returned or panicked with it.
+func (e *Exception) Unwrap() error {
+	if o, ok := e.Value.(*Object); ok {
+		if err, ok := o.internal.(goError); ok {
+			return err.err
+		}
+	}
+	return nil
+}
+
+// Format formats the exception as Error does. The %+v verb adds the stack,
+// one call per line.
+func (e *Exception) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", e.Error())
+	default:
+		io.WriteString(f, e.Error())
+		if verb == 'v' && f.Flag('+') {
+			for _, frame := range e.Stack {
+				io.WriteString(f, "\n    at ")
+				io.WriteString(f, frame.String())
+			}
+		}
+	}
+}
+
+// StackFrame is a call active when an exception was thrown.
+type StackFrame struct {
+	// Function is the name of the called function, empty at the top level
+	// of a script
+	Function string
+	// Source is the name of the script the code of the function is part of
+	Source   string
+	Position parser.Position
+}
+
+func (f StackFrame) String() string {
+	location := f.Source
+	if f.Position.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", f.Source, f.Position.Line, f.Position.Column+1)
+	}
+	if f.Function == "" {
+		return location
+	}
+	return fmt.Sprintf("%s (%s)", f.Function, location)
+}
+
+// throw returns an exception throwing v from the current call stack.
+func (r *Runtime) throw(v Value) *Exception {
+	return &Exception{Value: v, Stack: r.captureStack()}
+}
+
+// captureStack returns the active calls, the innermost first.
+func (r *Runtime) captureStack() []StackFrame {
+	stack := make([]StackFrame, len(r.stack))
+	for i, frame := range r.stack {
+		stack[len(r.stack)-1-i] = frame
+	}
+	return stack
+}
+
+// newError returns an exception throwing a new error of the type with name,
+// whose message is formatted as fmt.Sprintf does.
+func (r *Runtime) newError(name string, format string, args ...interface{}) *Exception {
+	return r.throw(r.newErrorObject(r.errorPrototypes[name], fmt.Sprintf(format, args...)))
+}
+
+func (r *Runtime) newTypeError(format string, args ...interface{}) *Exception {
+	return r.newError("TypeError", format, args...)
+}
+
+func (r *Runtime) newRangeError(format string, args ...interface{}) *Exception {
+	return r.newError("RangeError", format, args...)
+}
+
+func (r *Runtime) newReferenceError(format string, args ...interface{}) *Exception {
+	return r.newError("ReferenceError", format, args...)
+}
+
+func (r *Runtime) newSyntaxError(format string, args ...interface{}) *Exception {
+	return r.newError("SyntaxError", format, args...)
+}
+
+// parse parses src with options, like parser.ParseFile, and fails with the
+// first early error of the code too, so that none of it runs.
+//
+// https://262.ecma-international.org/#sec-parse-script
+func parse(src string, options parser.Options) (*parser.File, error) {
+	file, err := parser.ParseFile(src, options)
+	if err != nil {
+		return nil, err
+	}
+	if errs := parser.Validate(file.Program, options); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if err := unsupported(file, options); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// unsupported returns a SyntaxError at the first async generator of file, or
+// at the first statement awaiting at the top level of a module, which the
+// runtime does not run.
+func unsupported(file *parser.File, options parser.Options) error {
+	var err error
+	syntaxError := func(node parser.Node, message string) {
+		start := file.Spans[node].Start
+		err = &parser.SyntaxError{Line: start.Line, Column: start.Column, Err: errors.New(message)}
+	}
+	for _, child := range file.Program.Children {
+		parser.Walk(child, func(node parser.Node) bool {
+			switch n := node.(type) {
+			case *parser.FunctionDeclarationStmt:
+				if n.Async && n.Generator && err == nil {
+					syntaxError(node, "Async generators are not supported")
+				}
+			case *parser.ExprFunction:
+				if n.Async && n.Generator && err == nil {
+					syntaxError(node, "Async generators are not supported")
+				}
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+		if options.SourceType != parser.SourceTypeModule {
+			continue
+		}
+		parser.Walk(child, func(node parser.Node) bool {
+			switch node.(type) {
+			case *parser.FunctionDeclarationStmt, *parser.ExprFunction, *parser.ExprArrowFunction:
+				return false
+			case *parser.ExprAwait:
+				if err == nil {
+					syntaxError(child, "Top-level await is not supported")
+				}
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syntaxError returns an exception throwing the SyntaxError of a source that
+// does not parse, or breaks an early error rule.
+func (r *Runtime) syntaxError(err error) *Exception {
+	var syntaxErr *parser.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return r.newSyntaxError("%v", syntaxErr.Err)
+	}
+	var earlyErr *parser.EarlyError
+	if errors.As(err, &earlyErr) {
+		return r.newSyntaxError("%s", earlyErr.Message)
+	}
+	return r.newSyntaxError("%v", err)
+}
+
+// newErrorObject returns an error object with proto and message, along with
+// the stack of the calls creating it.
+func (r *Runtime) newErrorObject(proto *Object, message string) *Object {
+	o := r.newObject(proto)
+	o.class = "Error"
+	if message != "" {
+		o.setHidden(stringKey("message"), String(message))
+	}
+	var stack strings.Builder
+	stack.WriteString(errorString(o))
+	for _, frame := range r.captureStack() {
+		stack.WriteString("\n    at ")
+		stack.WriteString(frame.String())
+	}
+	o.setHidden(stringKey("stack"), String(stack.String()))
+	return o
+}
+
+// errorString describes v as thrown: the name and message of error objects,
+// found without running any code.
+func errorString(v Value) string {
+	o, ok := v.(*Object)
+	if !ok {
+		if s, ok := v.(String); ok {
+			return fmt.Sprintf("%q", string(s))
+		}
+		return fmt.Sprint(v)
+	}
+	if o.class != "Error" {
+		return o.String()
+	}
+	lookup := func(name string) string {
+		for obj := o; obj != nil; obj = obj.proto {
+			if p, ok := obj.props[stringKey(name)]; ok {
+				if s, ok := p.value.(String); ok {
+					return string(s)
+				}
+				return ""
+			}
+		}
+		return ""
+	}
+	name, message := lookup("name"), lookup("message")
+	switch {
+	case name == "":
+		return message
+	case message == "":
+		return name
+	}
+	return name + ": " + message
+}
+
+// /////////////
+// Call stack //
+// /////////////
+
+// enter pushes the frame of a call to the function named name, defined in
+// script, failing when the stack is too deep.
+func (r *Runtime) enter(name string, s *script) {
+	if len(r.stack) >= maxCallDepth {
+		panic(r.newRangeError("Maximum call stack size exceeded"))
+	}
+	frame := StackFrame{Function: name}
+	if s != nil {
+		frame.Source = s.name
+	}
+	r.stack = append(r.stack, frame)
+}
+
+// leave pops the frame of the innermost call.
+func (r *Runtime) leave() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+// at records the position of the code the innermost call runs.
+func (r *Runtime) at(pos parser.Position) {
+	if len(r.stack) > 0 {
+		r.stack[len(r.stack)-1].Position = pos
+	}
+}
+
+// script is a parsed script or module, with the spans of its statements.
+// It holds what the evaluation of its code computes once, which is dropped
+// along with it when neither the run nor the functions it defines need it.
+type script struct {
+	name string
+	file *parser.File
+
+	// the template objects of tagged templates, the values of string
+	// literals and the declarations of functions and blocks
+	templates map[*parser.ExprTemplateLiteral]*Object
+	strings   map[*parser.ExprLiteral[string]]String
+	functions map[parser.Node]*functionInfo
+	blocks    map[interface{}]*blockInfo
+	// the bytecode of the functions defined by interpreted code, nil for
+	// those that are not compilable
+	compiled map[parser.Node]*code
+}
+
+func newScript(name string, file *parser.File) *script {
+	return &script{
+		name:      name,
+		file:      file,
+		templates: make(map[*parser.ExprTemplateLiteral]*Object),
+		strings:   make(map[*parser.ExprLiteral[string]]String),
+		functions: make(map[parser.Node]*functionInfo),
+		blocks:    make(map[interface{}]*blockInfo),
+		compiled:  make(map[parser.Node]*code),
+	}
+}
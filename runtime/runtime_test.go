@@ -0,0 +1,401 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// show formats the completion value of a script, quoting strings.
+func show(v Value) string {
+	if s, ok := v.(String); ok {
+		return strconv.Quote(string(s))
+	}
+	return fmt.Sprint(v)
+}
+
+// scriptTests are scripts along with their completion value, or the error
+// they throw.
+var scriptTests = []struct{ src, expected string }{
+	// conversions
+	{src: "1 + 2 * 3", expected: "7"},
+	{src: "'a' + 1", expected: `"a1"`},
+	{src: "'3' * '4'", expected: "12"},
+	{src: "[] + {}", expected: `"[object Object]"`},
+	{src: "[1, [2, 3]] + ''", expected: `"1,2,3"`},
+	{src: "+' 42 '", expected: "42"},
+	{src: "+'0b101'", expected: "5"},
+	{src: "+'1e'", expected: "NaN"},
+	{src: "+[]", expected: "0"},
+	{src: "0.1 + 0.2", expected: "0.30000000000000004"},
+	{src: "1 / 0", expected: "Infinity"},
+	{src: "1e21 + ''", expected: `"1e+21"`},
+	{src: "-1 >>> 0", expected: "4294967295"},
+	{src: "1 << 31", expected: "-2147483648"},
+	{src: "-7 % 3", expected: "-1"},
+	{src: "2 ** -1", expected: "0.5"},
+	{src: "!!'' + !!'0'", expected: "1"},
+	{src: "var o = { valueOf() { return 2 }, toString() { return 'o' } }; [o + 1, `${o}`].join()", expected: `"3,o"`},
+	{src: "var o = { [Symbol.toPrimitive](hint) { return hint } }; [+o, `${o}`, o + ''].join()", expected: `"NaN,string,default"`},
+
+	// equality and comparison
+	{src: "1 == '1'", expected: "true"},
+	{src: "1 === '1'", expected: "false"},
+	{src: "null == undefined", expected: "true"},
+	{src: "null == 0", expected: "false"},
+	{src: "NaN == NaN", expected: "false"},
+	{src: "[1] == 1", expected: "true"},
+	{src: "-0 === 0", expected: "true"},
+	{src: "'b' > 'a'", expected: "true"},
+	{src: "'10' < '9'", expected: "true"},
+	{src: "'10' < 9", expected: "false"},
+	{src: "undefined < 1", expected: "false"},
+
+	// typeof, delete and void
+	{src: "typeof null", expected: `"object"`},
+	{src: "typeof missing", expected: `"undefined"`},
+	{src: "typeof function () {}", expected: `"function"`},
+	{src: "typeof Symbol()", expected: `"symbol"`},
+	{src: "var o = { a: 1 }; delete o.a; 'a' in o", expected: "false"},
+	{src: "var v = 1; delete v", expected: "false"},
+	{src: "void 0", expected: "undefined"},
+
+	// functions, closures and this
+	{src: "function f(a, b = 2) { return a + b } f(1)", expected: "3"},
+	{src: "function f(a, b = a * 2, ...rest) { return [a, b, rest.length] } f(1) + ''", expected: `"1,2,0"`},
+	{src: "function f(a, b, c = 1) {} f.length", expected: "2"},
+	{src: "var f = function () {}; f.name", expected: `"f"`},
+	{src: "var counter = (function () { let n = 0; return () => ++n })(); counter(); counter()", expected: "2"},
+	{src: "var fs = []; function add(i) { fs[fs.length] = () => i } add(1); add(2); fs[0]() + fs[1]()", expected: "3"},
+	{src: "function C(x) { this.x = x } C.prototype.get = function () { return this.x }; new C(5).get()", expected: "5"},
+	{src: "function C() { return { y: 1 } } new C().y", expected: "1"},
+	{src: "function C() {} new C() instanceof C", expected: "true"},
+	{src: "(function () { 'use strict'; return this })()", expected: "undefined"},
+	{src: "(function () { return this === globalThis })()", expected: "true"},
+	{src: "var o = { m() { return () => this } }; o.m()() === o", expected: "true"},
+	{src: "var o = { m() { return super.toString === Object.prototype.toString } }; o.m()", expected: "true"},
+	{src: "function f() { return arguments.length } f(1, 2, 3)", expected: "3"},
+	{src: "function f() { return new.target === f } new f() instanceof f", expected: "true"},
+	{src: "var f = function g() { g = 1; return typeof g }; f()", expected: `"function"`},
+	{src: "var o = { get x() { return this._x * 2 }, set x(v) { this._x = v } }; o.x = 2; o.x", expected: "4"},
+	{src: "var o = { __proto__: { a: 1 } }; o.a", expected: "1"},
+
+	// destructuring and spread
+	{src: "var [a, , b = 5, ...rest] = [1, 2, undefined, 4, 5]; [a, b, rest].join('|')", expected: `"1|5|4,5"`},
+	{src: "var { p, q: { r }, ...others } = { p: 1, q: { r: 2 }, s: 3 }; p + r + others.s", expected: "6"},
+	{src: "var a, b; [a, b] = [b, a] = [1, 2]; a + ',' + b", expected: `"1,2"`},
+	{src: "var o = {}; ({ x: o.y } = { x: 1 }); o.y", expected: "1"},
+	{src: "function f(...args) { return args.length } f(...[1, 2], 3, ...'ab')", expected: "5"},
+	{src: "[...'héllo'].length", expected: "5"},
+	{src: "var o = { ...{ a: 1, b: 2 }, b: 3 }; o.a + o.b", expected: "4"},
+	{src: "[, 1].length", expected: "2"},
+
+	// optional chaining and logical operators
+	{src: "var n = null; n?.x.y.z", expected: "undefined"},
+	{src: "var o = { f() { return this } }; o?.f() === o", expected: "true"},
+	{src: "var o = {}; o.f?.()", expected: "undefined"},
+	{src: "var o = { m() { return this } }; (o?.m)() === o && (o?.['m'])() === o", expected: "true"},
+	{src: "var o = { a: { m() { return this } } }; (o?.a.m)() === o.a", expected: "true"},
+	{src: "var n = null; (n?.m)()", expected: "Uncaught TypeError: n.m is not a function"},
+	{src: "null ?? 'b'", expected: `"b"`},
+	{src: "0 || 'b'", expected: `"b"`},
+	{src: "var a = 0; a ||= 2; a &&= a + 1", expected: "3"},
+
+	// strings, templates and regular expressions
+	{src: `'\x41B\103😀'.length`, expected: "5"},
+	{src: "'abc'[1]", expected: `"b"`},
+	{src: "`x${1 + 1}y`", expected: `"x2y"`},
+	{src: "function tag(s, ...v) { return s.raw[0] + v[0] + s[1] } tag`a\\n${1}b`", expected: `"a\\n1b"`},
+	{src: "function tag(s) { return s } tag`a` === tag`a`", expected: "false"},
+	{src: "function f() { return (s => s)`a` } f() === f()", expected: "true"},
+	{src: "/a+b/gi.flags", expected: `"gi"`},
+	{src: "String(/a/y)", expected: `"/a/y"`},
+
+	// generators
+	{src: "function* g() { yield 1; yield 2; return 3 } var it = g(); [it.next().value, it.next().value, it.next().value, it.next().done].join()", expected: `"1,2,3,true"`},
+	{src: "function* g() { var x = yield 1; yield x * 2 } var it = g(); it.next(); it.next(21).value", expected: "42"},
+	{src: "function* g() { yield* [1, 2]; yield 3 } [...g()].join()", expected: `"1,2,3"`},
+	{src: "function* g() { yield 1; yield 2 } var it = g(); it.next(); [it.return(7).value, it.next().done].join()", expected: `"7,true"`},
+	{src: "function* g() { yield 1 } var it = g(); it.throw(new Error('boom'))", expected: "Uncaught Error: boom"},
+	{src: "function* g() { yield 1; yield 2 } var [a] = g(); a", expected: "1"},
+	{src: "function* g() { it.next() } var it = g(); it.next()", expected: "Uncaught TypeError: Generator is already running"},
+
+	// scoping
+	{src: "let a = 1; { let a = 2; } a", expected: "1"},
+	{src: "let tdz = tdz", expected: "Uncaught ReferenceError: Cannot access 'tdz' before initialization"},
+	{src: "const k = 1; k = 2", expected: "Uncaught TypeError: Assignment to constant variable."},
+	{src: "x", expected: "Uncaught ReferenceError: x is not defined"},
+	{src: "'use strict'; undeclared = 1", expected: "Uncaught ReferenceError: undeclared is not defined"},
+	{src: "implicit = 1; globalThis.implicit", expected: "1"},
+	{src: "var g = 1; globalThis.g", expected: "1"},
+	{src: "if (true) { function ab() { return 1 } } ab()", expected: "1"},
+	{src: "typeof hoisted; function hoisted() {}", expected: `"function"`},
+	{src: "with ({ w: 1 }) { w + 1 }", expected: "2"},
+	{src: "var o = { f() { return this } }; with (o) { f() === o }", expected: "true"},
+	{src: "outer: { 1 }", expected: "1"},
+	{src: "1; if (false) 2", expected: "undefined"},
+	{src: "1; {}", expected: "1"},
+	{src: "function f() { let a = 1; { let a = 2; var g = () => a } return a + g() } f()", expected: "3"},
+	{src: "function f() { var g = () => v; let v = 1; return g() } f()", expected: "1"},
+	{src: "function f() { var g = () => v; g(); let v } f()", expected: "Uncaught ReferenceError: Cannot access 'v' before initialization"},
+	{src: "function f() { const c = 1; (() => { c++ })() } f()", expected: "Uncaught TypeError: Assignment to constant variable."},
+	{src: "function f(a) { if (a) { function g() { return 1 } } return typeof g } f(true) + f(false)", expected: `"functionundefined"`},
+	{src: "function f(a, b = () => a) { var a = 2; return [a, b()] } f(1) + ''", expected: `"2,1"`},
+	{src: "function f(a, a) { return a } f(1, 2)", expected: "2"},
+	{src: "function f() { return typeof arguments[0] } f('x')", expected: `"string"`},
+	{src: "function f(a) { a = 2; return arguments[0] } f(1)", expected: "2"},
+	{src: "function f(a, b) { arguments[0] = 3; arguments[1] = 4; return [a, b, arguments.length] + '' } f(1)", expected: `"3,,1"`},
+	{src: "function f(a) { 'use strict'; a = 2; return arguments[0] } f(1)", expected: "1"},
+	{src: "function f(a = 0) { a = 2; return arguments[0] } f(1)", expected: "1"},
+	{src: "function f(a, a) { a = 3; return [arguments[0], arguments[1]] + '' } f(1, 2)", expected: `"1,3"`},
+	{src: "function f(a) { delete arguments[0]; arguments[0] = 2; return [a, Object.keys(arguments)] + '' } f(1)", expected: `"1,0"`},
+	{src: "function f(a) { Object.defineProperty(arguments, '0', { value: 2, writable: false }); a = 3; return [a, arguments[0]] + '' } f(1)", expected: `"3,2"`},
+	{src: "function f(a) { return () => { a = 2; return arguments[0] } } f(1)()", expected: "2"},
+	{src: "function f(a, b) { return [...arguments, arguments.callee === f] + '' } f(1, 2)", expected: `"1,2,true"`},
+	{src: "var f = function g() { 'use strict'; g = 1 }; f()", expected: "Uncaught TypeError: Assignment to constant variable."},
+	{src: "function f() { var o = { a: [1, 2] }; o.a[1] += 3; o.a[0]++; return o.a.join() } f()", expected: `"2,5"`},
+	{src: "function f() { var o = {}, k = 'p'; return [o[k] ||= 2, o[k] &&= 3, o.q &&= 4, o[k] ||= 5] + '' } f()", expected: `"2,3,,3"`},
+	{src: "function f() { var a, b, o = {}; [a, [b, o.c] = [2, 3], ...o.d] = [1, , 4]; return [a, b, o.c, o.d] + '' } f()", expected: `"1,2,3,4"`},
+	{src: "function f(o) { delete o?.a.b; return o?.a?.b } f({ a: { b: 1 } })", expected: "undefined"},
+
+	// eval
+	{src: "eval('var z = 4; z * 2') + z", expected: "12"},
+	{src: "(0, eval)('1 + 1')", expected: "2"},
+	{src: "function f() { var v = 'local'; return eval('v') } f()", expected: `"local"`},
+	{src: "var v = 'global'; function f() { var v = 'local'; return (0, eval)('v') } f()", expected: `"global"`},
+	{src: "function f() { 'use strict'; eval('var e = 1'); return typeof e } f()", expected: `"undefined"`},
+	{src: "eval('var d = 1'); delete d", expected: "true"},
+	{src: "function f() { let l; eval('var l') } f()", expected: "Uncaught SyntaxError: Identifier 'l' has already been declared"},
+	{src: "eval('(')", expected: "Uncaught SyntaxError"},
+	{src: "eval('return 1')", expected: "Uncaught SyntaxError: illegal return statement outside of a function"},
+	{src: "function f() { 'use strict'; eval('with (a) {}') } f()", expected: "Uncaught SyntaxError: strict mode code may not include a with statement"},
+	{src: "eval('new.target')", expected: "Uncaught SyntaxError: new.target expression is not allowed here"},
+	{src: "eval('super.x')", expected: "Uncaught SyntaxError: 'super' keyword unexpected here"},
+	{src: "var o = { m() { return eval('super.toString') === Object.prototype.toString } }; o.m()", expected: "true"},
+	{src: "function F() { this.t = (() => eval('new.target'))() } new F().t === F", expected: "true"},
+	{src: "Function('return 1; let q; let q')", expected: "Uncaught SyntaxError: identifier 'q' has already been declared"},
+
+	// early errors
+	{src: "return 5", expected: "1:0: illegal return statement outside of a function"},
+	{src: "{ let q; let q }", expected: "1:13: identifier 'q' has already been declared"},
+	{src: "'use strict'; 010", expected: "1:14: octal literals are not allowed in strict mode"},
+	{src: "'use strict'; with (a) {}", expected: "1:14: strict mode code may not include a with statement"},
+	{src: "const c", expected: "1:6: missing initializer in const declaration 'c'"},
+	{src: "log = 1;\nif (false) { var o = { async *m() {} } }", expected: "2:31: Async generators are not supported"},
+	{src: "eval('async function* g() {}')", expected: "Uncaught SyntaxError: Async generators are not supported"},
+
+	// exceptions
+	{src: "null.x", expected: "Uncaught TypeError: Cannot read properties of null (reading 'x')"},
+	{src: "var o = {}; o.f()", expected: "Uncaught TypeError: o.f is not a function"},
+	{src: "new 1", expected: "Uncaught TypeError: 1 is not a constructor"},
+	{src: "1 in 1", expected: "Uncaught TypeError"},
+	{src: "function f() { f() } f()", expected: "Uncaught RangeError: Maximum call stack size exceeded"},
+	{src: "var [a] = {}", expected: "Uncaught TypeError"},
+	{src: "var { a } = null", expected: "Uncaught TypeError"},
+	{src: "new TypeError('bad', { cause: 1 }).cause", expected: "1"},
+	{src: "String(new RangeError('bad'))", expected: `"RangeError: bad"`},
+}
+
+// engines are the engines the tests run scripts with, by name.
+var engines = []struct {
+	name   string
+	engine Engine
+}{
+	{name: "vm", engine: EngineVM},
+	{name: "interpreter", engine: EngineInterpreter},
+}
+
+func TestRunString(t *testing.T) {
+	testScripts(t, scriptTests)
+}
+
+// testScripts runs each of tests with every engine, matching their
+// completion value or, on the prefix, the error they throw.
+func testScripts(t *testing.T, tests []struct{ src, expected string }) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			for _, tc := range tests {
+				t.Run(tc.src, func(t *testing.T) {
+					r := New()
+					r.SetEngine(e.engine)
+					v, err := r.RunString(tc.src)
+					got := ""
+					if err != nil {
+						got = err.Error()
+					} else {
+						got = show(v)
+					}
+					// exceptions match on their prefix
+					if got != tc.expected && (err == nil || !strings.HasPrefix(got, tc.expected)) {
+						t.Errorf("expected %s, got %s", tc.expected, got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRunScript_DropsScripts(t *testing.T) {
+	// what the runtime computes for the code of a script goes with it once
+	// no function it defines is reachable
+	r := New()
+	collected := make(chan bool, 1)
+	func() {
+		file, err := parser.ParseFile("var o = { a: `t${1}` }; (function f(a) { { let b = a; return b } })(o.a)", parser.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.Walk(file.Program, func(node parser.Node) bool {
+			if fn, ok := node.(*parser.ExprFunction); ok {
+				runtime.SetFinalizer(fn, func(*parser.ExprFunction) { collected <- true })
+			}
+			return true
+		})
+		r.runScript(newScript("script.js", file))
+	}()
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		select {
+		case <-collected:
+			runtime.KeepAlive(r)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Errorf("expected the code of the script to be collected after its run")
+	runtime.KeepAlive(r)
+}
+
+func TestRunScript_Globals(t *testing.T) {
+	r := New()
+	for _, src := range []string{"let a = 1; var b = 2; function c() { return a + b }", "c() + a"} {
+		if _, err := r.RunScript("script.js", src); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, src := range []string{"var a", "let b", "let c", "let d; let c"} {
+		if _, err := r.RunScript("script.js", src); err == nil {
+			t.Errorf("expected %q to fail", src)
+		}
+	}
+	if _, err := r.RunString("d"); err == nil {
+		t.Errorf("expected the script failing to declare d to declare nothing")
+	}
+}
+
+func TestRunScript_EarlyErrors(t *testing.T) {
+	r := New()
+	_, err := r.RunScript("script.js", "globalThis.ran = true; return 5")
+	var earlyErr *parser.EarlyError
+	if !errors.As(err, &earlyErr) || earlyErr.Code != parser.ErrReturnOutsideFunction {
+		t.Fatalf("expected an early error, got %v", err)
+	}
+	if v, _ := r.RunString("typeof ran"); v != String("undefined") {
+		t.Errorf("expected no code to run before the early error, got ran %v", v)
+	}
+}
+
+func TestException_Stack(t *testing.T) {
+	src := "function inner() {\n  null.f();\n}\nfunction outer() {\n  inner();\n}\nouter();"
+	_, err := New().RunScript("stack.js", src)
+	var exception *Exception
+	if !errors.As(err, &exception) {
+		t.Fatalf("expected an exception, got %v", err)
+	}
+	var frames []string
+	for _, frame := range exception.Stack {
+		frames = append(frames, frame.String())
+	}
+	expected := "inner (stack.js:2:3)|outer (stack.js:5:3)|stack.js:7:1"
+	if got := strings.Join(frames, "|"); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	v, err := New().RunScript("stack.js", "function f() {\n  return new Error('boom').stack\n}\nf()")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "Error: boom\n    at f (stack.js:2:3)\n    at stack.js:4:1"
+	if got := string(v.(String)); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestCall(t *testing.T) {
+	r := New()
+	fn, err := r.RunString("(function (a, b) { return this.base + a * b })")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	this, _ := r.RunString("({ base: 1 })")
+	v, err := r.Call(fn, this, Number(2), Number(3))
+	if err != nil || v != Number(7) {
+		t.Errorf("expected 7, got %v, %v", v, err)
+	}
+	if _, err := r.Call(Number(1), Undefined); err == nil {
+		t.Errorf("expected calling a number to fail")
+	}
+}
+
+func TestRunModule(t *testing.T) {
+	modules := map[string]string{
+		"counter.js":  "export let count = 0; export function increment() { count++ } export default function () { return 'default' }",
+		"reexport.js": "export * from 'counter.js'; export { increment as inc } from 'counter.js'; export * as counter from 'counter.js'",
+		"even.js":     "import { odd } from 'odd.js'; export function even(n) { return n === 0 || odd(n - 1) }",
+		"odd.js":      "import { even } from 'even.js'; export function odd(n) { return n !== 0 && even(n - 1) }",
+		"throws.js":   "null.x",
+		"awaits.js":   "await null",
+	}
+	tcs := []struct {
+		src      string
+		expected string
+	}{
+		{src: "import f, { count, increment } from 'counter.js'; increment(); export const result = count + f()", expected: `"1default"`},
+		{src: "import * as ns from 'reexport.js'; ns.inc(); export const result = ns.count + ns.counter.count", expected: "2"},
+		{src: "import { even } from 'even.js'; export const result = even(10)", expected: "true"},
+		{src: "export const result = this", expected: "undefined"},
+		{src: "export default 1 + 1; import * as self from 'self.js'; export const result = self.default", expected: "2"},
+		{src: "import { missing } from 'counter.js'", expected: "Uncaught SyntaxError: The requested module 'counter.js' does not provide an export named 'missing'"},
+		{src: "import { count } from 'counter.js'; count = 1", expected: "Uncaught TypeError: Assignment to constant variable."},
+		{src: "import 'throws.js'", expected: "Uncaught TypeError: Cannot read properties of null (reading 'x')"},
+		{src: "import 'missing.js'", expected: `cannot import "missing.js" from self.js: no such module`},
+		{src: "export { missing }", expected: "1:9: export 'missing' is not defined in module [undeclared-export]"},
+		{src: "export let result = 1; if (false) result = await 2", expected: "1:23: Top-level await is not supported"},
+		{src: "export const result = async () => await 1; async function* g() {}", expected: "1:43: Async generators are not supported"},
+		{src: "export const result = 1; import 'awaits.js'", expected: "1:0: Top-level await is not supported"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.src, func(t *testing.T) {
+			r := New()
+			r.SetModuleLoader(func(referrer, specifier string) (string, string, error) {
+				if specifier == "self.js" {
+					return specifier, tc.src, nil
+				}
+				src, ok := modules[specifier]
+				if !ok {
+					return "", "", errors.New("no such module")
+				}
+				return specifier, src, nil
+			})
+			ns, err := r.RunModule("self.js", tc.src)
+			got := ""
+			if err == nil {
+				var v Value
+				v, err = ns.Get("result")
+				got = show(v)
+			}
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
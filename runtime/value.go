@@ -0,0 +1,645 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Value is a JavaScript value: Undefined, Null, a Bool, a Number, a String, a
+// *Symbol or an *Object.
+//
+// https://262.ecma-international.org/#sec-ecmascript-language-types
+type Value interface {
+	// Type returns the name of the type of the value, as typeof does.
+	Type() string
+}
+
+type undefined struct{}
+
+func (undefined) Type() string   { return "undefined" }
+func (undefined) String() string { return "undefined" }
+
+type null struct{}
+
+func (null) Type() string   { return "object" }
+func (null) String() string { return "null" }
+
+var (
+	// Undefined is the value of uninitialized variables and missing
+	// properties
+	Undefined Value = undefined{}
+	// Null is the intentional absence of an object
+	Null Value = null{}
+)
+
+// Bool is a Boolean value.
+type Bool bool
+
+func (b Bool) Type() string { return "boolean" }
+
+func (b Bool) String() string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// Number is a Number value, an IEEE 754 double.
+type Number float64
+
+func (n Number) Type() string   { return "number" }
+func (n Number) String() string { return numberToString(float64(n)) }
+
+// String is a String value. JavaScript strings are sequences of UTF-16 code
+// units, held here in WTF-8: UTF-8 that also encodes lone surrogates, as
+// three bytes each, so that they survive being split off and joined back.
+// Lengths and indexes count code units, and a surrogate pair is always held
+// as the UTF-8 of its code point, so equal strings have equal bytes. Strings
+// are valid UTF-8 unless they hold lone surrogates.
+type String string
+
+func (s String) Type() string   { return "string" }
+func (s String) String() string { return string(s) }
+
+// Symbol is a Symbol value, unique to its creation.
+type Symbol struct {
+	// Description is the description given to Symbol(), if any
+	Description Value
+}
+
+func (s *Symbol) Type() string { return "symbol" }
+
+func (s *Symbol) String() string {
+	if s.Description == Undefined {
+		return "Symbol()"
+	}
+	return fmt.Sprintf("Symbol(%s)", s.Description)
+}
+
+func newSymbol(description string) *Symbol {
+	return &Symbol{Description: String(description)}
+}
+
+// The well-known symbols, shared by every runtime.
+//
+// https://262.ecma-international.org/#sec-well-known-symbols
+var (
+	SymbolAsyncIterator      = newSymbol("Symbol.asyncIterator")
+	SymbolHasInstance        = newSymbol("Symbol.hasInstance")
+	SymbolIsConcatSpreadable = newSymbol("Symbol.isConcatSpreadable")
+	SymbolIterator           = newSymbol("Symbol.iterator")
+	SymbolMatch              = newSymbol("Symbol.match")
+	SymbolMatchAll           = newSymbol("Symbol.matchAll")
+	SymbolReplace            = newSymbol("Symbol.replace")
+	SymbolSearch             = newSymbol("Symbol.search")
+	SymbolSpecies            = newSymbol("Symbol.species")
+	SymbolSplit              = newSymbol("Symbol.split")
+	SymbolToPrimitive        = newSymbol("Symbol.toPrimitive")
+	SymbolToStringTag        = newSymbol("Symbol.toStringTag")
+	SymbolUnscopables        = newSymbol("Symbol.unscopables")
+)
+
+// isNullish reports whether v is undefined or null.
+func isNullish(v Value) bool {
+	return v == Undefined || v == Null
+}
+
+// isCallable reports whether v is a function.
+func isCallable(v Value) bool {
+	o, ok := v.(*Object)
+	return ok && o.call != nil
+}
+
+// isConstructor reports whether v is a function that can be called with new.
+func isConstructor(v Value) bool {
+	o, ok := v.(*Object)
+	return ok && o.construct != nil
+}
+
+// typeOf returns the result of the typeof operator.
+func typeOf(v Value) string {
+	if o, ok := v.(*Object); ok && o.call != nil {
+		return "function"
+	}
+	return v.Type()
+}
+
+// ///////////////////
+// Type conversions //
+// ///////////////////
+
+// toBoolean converts v to a boolean.
+//
+// https://262.ecma-international.org/#sec-toboolean
+func toBoolean(v Value) bool {
+	switch v := v.(type) {
+	case Bool:
+		return bool(v)
+	case Number:
+		return v != 0 && !math.IsNaN(float64(v))
+	case String:
+		return v != ""
+	case *Symbol, *Object:
+		return true
+	}
+	return false
+}
+
+// toPrimitive converts v to a primitive value, preferring hint ("default",
+// "number" or "string") for objects.
+//
+// https://262.ecma-international.org/#sec-toprimitive
+func (r *Runtime) toPrimitive(v Value, hint string) Value {
+	o, ok := v.(*Object)
+	if !ok {
+		return v
+	}
+	exotic := r.getMethod(o, symbolKey(SymbolToPrimitive))
+	if exotic != nil {
+		result := r.callFunction(exotic, o, String(hint))
+		if _, ok := result.(*Object); ok {
+			panic(r.newTypeError("Cannot convert object to primitive value"))
+		}
+		return result
+	}
+	if hint == "default" {
+		hint = "number"
+	}
+	return r.ordinaryToPrimitive(o, hint)
+}
+
+// ordinaryToPrimitive calls the valueOf and toString methods of o, in the
+// order preferred by hint, returning the first primitive result.
+//
+// https://262.ecma-international.org/#sec-ordinarytoprimitive
+func (r *Runtime) ordinaryToPrimitive(o *Object, hint string) Value {
+	methods := [2]string{"valueOf", "toString"}
+	if hint == "string" {
+		methods[0], methods[1] = methods[1], methods[0]
+	}
+	for _, name := range methods {
+		method := o.get(stringKey(name), o)
+		if fn, ok := method.(*Object); ok && fn.call != nil {
+			result := fn.call(o, nil)
+			if _, ok := result.(*Object); !ok {
+				return result
+			}
+		}
+	}
+	panic(r.newTypeError("Cannot convert object to primitive value"))
+}
+
+// toNumber converts v to a number.
+//
+// https://262.ecma-international.org/#sec-tonumber
+func (r *Runtime) toNumber(v Value) float64 {
+	switch v := v.(type) {
+	case Number:
+		return float64(v)
+	case Bool:
+		if v {
+			return 1
+		}
+		return 0
+	case String:
+		return stringToNumber(string(v))
+	case *Symbol:
+		panic(r.newTypeError("Cannot convert a Symbol value to a number"))
+	case *Object:
+		return r.toNumber(r.toPrimitive(v, "number"))
+	}
+	if v == Null {
+		return 0
+	}
+	return math.NaN()
+}
+
+// toNumeric converts v to a number, the only numeric type without BigInt.
+func (r *Runtime) toNumeric(v Value) float64 {
+	return r.toNumber(v)
+}
+
+// toIntegerOrInfinity converts v to an integral number, or an infinity.
+//
+// https://262.ecma-international.org/#sec-tointegerorinfinity
+func (r *Runtime) toIntegerOrInfinity(v Value) float64 {
+	n := r.toNumber(v)
+	if math.IsNaN(n) {
+		return 0
+	}
+	return math.Trunc(n) + 0 // +0 rather than -0
+}
+
+// toInt32 converts v to a signed 32-bit integer, modulo 2^32.
+//
+// https://262.ecma-international.org/#sec-toint32
+func (r *Runtime) toInt32(v Value) int32 {
+	return int32(toUint32(r.toNumber(v)))
+}
+
+// toUint32 converts v to an unsigned 32-bit integer, modulo 2^32.
+//
+// https://262.ecma-international.org/#sec-touint32
+func (r *Runtime) toUint32(v Value) uint32 {
+	return toUint32(r.toNumber(v))
+}
+
+func toUint32(n float64) uint32 {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0
+	}
+	if n >= 0 && n <= math.MaxUint32 {
+		return uint32(n)
+	}
+	n = math.Mod(math.Trunc(n), 1<<32)
+	if n < 0 {
+		n += 1 << 32
+	}
+	return uint32(n)
+}
+
+// toLength converts v to an integer suitable as the length of an array-like
+// object.
+//
+// https://262.ecma-international.org/#sec-tolength
+func (r *Runtime) toLength(v Value) int64 {
+	n := r.toIntegerOrInfinity(v)
+	switch {
+	case n <= 0:
+		return 0
+	case n > maxSafeInteger:
+		return maxSafeInteger
+	}
+	return int64(n)
+}
+
+const maxSafeInteger = 1<<53 - 1
+
+// toString converts v to a string.
+//
+// https://262.ecma-international.org/#sec-tostring
+func (r *Runtime) toString(v Value) string {
+	switch v := v.(type) {
+	case String:
+		return string(v)
+	case Number:
+		return numberToString(float64(v))
+	case Bool:
+		return v.String()
+	case *Symbol:
+		panic(r.newTypeError("Cannot convert a Symbol value to a string"))
+	case *Object:
+		return r.toString(r.toPrimitive(v, "string"))
+	}
+	if v == Null {
+		return "null"
+	}
+	return "undefined"
+}
+
+// toObject converts v to an object, wrapping primitives.
+//
+// https://262.ecma-international.org/#sec-toobject
+func (r *Runtime) toObject(v Value) *Object {
+	switch v := v.(type) {
+	case *Object:
+		return v
+	case Bool:
+		return r.newPrimitiveObject(v, r.booleanPrototype, "Boolean")
+	case Number:
+		return r.newPrimitiveObject(v, r.numberPrototype, "Number")
+	case String:
+		return r.newStringObject(v, r.stringPrototype)
+	case *Symbol:
+		return r.newPrimitiveObject(v, r.symbolPrototype, "Symbol")
+	}
+	panic(r.newTypeError("Cannot convert %s to object", v))
+}
+
+// toPropertyKey converts v to a property key.
+//
+// https://262.ecma-international.org/#sec-topropertykey
+func (r *Runtime) toPropertyKey(v Value) propertyKey {
+	switch v := v.(type) {
+	case String:
+		return stringKey(string(v))
+	case Number:
+		if i := int64(v); float64(i) == float64(v) && i >= 0 && i < 1<<53 {
+			return stringKey(strconv.FormatInt(i, 10))
+		}
+	}
+	key := r.toPrimitive(v, "string")
+	if s, ok := key.(*Symbol); ok {
+		return symbolKey(s)
+	}
+	return stringKey(r.toString(key))
+}
+
+// /////////////////////
+// Numbers to strings //
+// /////////////////////
+
+// numberToString returns the shortest decimal representation of n that reads
+// back as n, formatted as JavaScript does.
+//
+// https://262.ecma-international.org/#sec-numeric-types-number-tostring
+func numberToString(n float64) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case n == 0:
+		return "0"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	case n < 0:
+		return "-" + numberToString(-n)
+	case n < 1e21 && n == math.Trunc(n):
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	// the digits s and the exponent e - 1 of the scientific notation of n, so
+	// that n is 0.s × 10^e
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(n, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exponent)
+	e++
+	k := len(digits)
+
+	switch {
+	case k <= e && e <= 21:
+		return digits + strings.Repeat("0", e-k)
+	case 0 < e && e <= 21:
+		return digits[:e] + "." + digits[e:]
+	case -6 < e && e <= 0:
+		return "0." + strings.Repeat("0", -e) + digits
+	}
+	sign := "+"
+	if e-1 < 0 {
+		sign = "-"
+	}
+	exp := strconv.Itoa(abs(e - 1))
+	if k == 1 {
+		return digits + "e" + sign + exp
+	}
+	return digits[:1] + "." + digits[1:] + "e" + sign + exp
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// isWhiteSpace reports whether ch is a WhiteSpace or LineTerminator, which
+// StringToNumber and String.prototype.trim skip.
+func isWhiteSpace(ch rune) bool {
+	switch ch {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00a0', '\u1680', '\u2028', '\u2029', '\u202f', '\u205f', '\u3000', '\ufeff':
+		return true
+	}
+	return ch >= '\u2000' && ch <= '\u200a'
+}
+
+// stringToNumber parses a StringNumericLiteral, returning NaN when s is not
+// one.
+//
+// https://262.ecma-international.org/#sec-stringtonumber
+func stringToNumber(s string) float64 {
+	s = strings.TrimFunc(s, isWhiteSpace)
+	if s == "" {
+		return 0
+	}
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			return parseInteger(s[2:], base)
+		}
+	}
+
+	unsigned := s
+	if s[0] == '+' || s[0] == '-' {
+		unsigned = s[1:]
+	}
+	if unsigned == "Infinity" {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	// StrUnsignedDecimalLiteral, which unlike Go has no underscores, hex
+	// floats or spelled out infinities and NaN
+	digits, dot := 0, false
+	for i := 0; i < len(unsigned); i++ {
+		switch ch := unsigned[i]; {
+		case ch >= '0' && ch <= '9':
+			digits++
+		case ch == '.' && !dot:
+			dot = true
+		case (ch == 'e' || ch == 'E') && digits > 0:
+			exponent := unsigned[i+1:]
+			if exponent != "" && (exponent[0] == '+' || exponent[0] == '-') {
+				exponent = exponent[1:]
+			}
+			if exponent == "" || strings.TrimLeft(exponent, "0123456789") != "" {
+				return math.NaN()
+			}
+			i = len(unsigned)
+		default:
+			return math.NaN()
+		}
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil && !math.IsInf(n, 0) {
+		return math.NaN()
+	}
+	return n
+}
+
+// parseInteger parses the digits of an integer in base, returning NaN unless
+// every character of s is a digit.
+func parseInteger(s string, base int) float64 {
+	n := 0.0
+	for _, ch := range s {
+		digit := digitValue(ch)
+		if digit >= base {
+			return math.NaN()
+		}
+		n = n*float64(base) + float64(digit)
+	}
+	return n
+}
+
+// digitValue returns the value of ch as a digit of base up to 36, or 36 when
+// ch is not a digit.
+func digitValue(ch rune) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'z':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'Z':
+		return int(ch-'A') + 10
+	}
+	return 36
+}
+
+// /////////////////
+// UTF-16 strings //
+// /////////////////
+
+// isASCII reports whether s holds ASCII characters only, whose UTF-8 and
+// UTF-16 indexes agree.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// stringLength returns the length of s in UTF-16 code units.
+func stringLength(s string) int {
+	if isASCII(s) {
+		return len(s)
+	}
+	n := 0
+	for i := 0; i < len(s); {
+		ch, width := decodeRune(s[i:])
+		i += width
+		n++
+		if ch >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
+
+// decodeRune returns the first code point of s and its width in bytes, like
+// utf8.DecodeRuneInString, but decoding the WTF-8 of lone surrogates too.
+func decodeRune(s string) (rune, int) {
+	ch, width := utf8.DecodeRuneInString(s)
+	if ch == utf8.RuneError && width == 1 && len(s) >= 3 &&
+		s[0] == 0xed && s[1]&0xe0 == 0xa0 && s[2]&0xc0 == 0x80 {
+		return 0xd000 | rune(s[1]&0x3f)<<6 | rune(s[2]&0x3f), 3
+	}
+	return ch, width
+}
+
+// appendRune appends the WTF-8 of ch, which may be a lone surrogate, to b.
+func appendRune(b []byte, ch rune) []byte {
+	if utf16.IsSurrogate(ch) {
+		return append(b, 0xed, 0x80|byte(ch>>6)&0x3f, 0x80|byte(ch)&0x3f)
+	}
+	return utf8.AppendRune(b, ch)
+}
+
+// toUTF16 returns the code units of s.
+func toUTF16(s string) []uint16 {
+	units := make([]uint16, 0, len(s))
+	for i := 0; i < len(s); {
+		ch, width := decodeRune(s[i:])
+		i += width
+		if ch >= 0x10000 {
+			high, low := utf16.EncodeRune(ch)
+			units = append(units, uint16(high), uint16(low))
+		} else {
+			units = append(units, uint16(ch))
+		}
+	}
+	return units
+}
+
+// fromUTF16 returns the string of code units, which may hold lone
+// surrogates.
+func fromUTF16(units []uint16) string {
+	b := make([]byte, 0, len(units))
+	for i := 0; i < len(units); i++ {
+		ch := rune(units[i])
+		if isHighSurrogate(ch) && i+1 < len(units) && isLowSurrogate(rune(units[i+1])) {
+			ch = utf16.DecodeRune(ch, rune(units[i+1]))
+			i++
+		}
+		b = appendRune(b, ch)
+	}
+	return string(b)
+}
+
+func isHighSurrogate(ch rune) bool { return ch >= 0xd800 && ch <= 0xdbff }
+func isLowSurrogate(ch rune) bool  { return ch >= 0xdc00 && ch <= 0xdfff }
+
+// concatStrings returns the concatenation of a and b, joining a lone high
+// surrogate at the end of a and a lone low surrogate at the start of b into
+// their code point.
+func concatStrings(a, b string) string {
+	if len(a) >= 3 && len(b) >= 3 && a[len(a)-3] == 0xed && b[0] == 0xed {
+		high, _ := decodeRune(a[len(a)-3:])
+		low, _ := decodeRune(b)
+		if isHighSurrogate(high) && isLowSurrogate(low) {
+			return a[:len(a)-3] + string(utf16.DecodeRune(high, low)) + b[3:]
+		}
+	}
+	return a + b
+}
+
+// joinSurrogates returns s with the lone surrogates that form pairs, which
+// appear when strings are built piecewise, joined into their code points.
+func joinSurrogates(s string) string {
+	if strings.IndexByte(s, 0xed) < 0 {
+		return s
+	}
+	return fromUTF16(toUTF16(s))
+}
+
+// toWellFormed returns s with its lone surrogates replaced with U+FFFD, as
+// the UTF-8 that Go code expects.
+func toWellFormed(s string) string {
+	if strings.IndexByte(s, 0xed) < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		ch, width := decodeRune(s[i:])
+		i += width
+		if utf16.IsSurrogate(ch) {
+			ch = utf8.RuneError
+		}
+		b = utf8.AppendRune(b, ch)
+	}
+	return string(b)
+}
+
+// codeUnitAt returns the code unit of s at index, which is within bounds.
+func codeUnitAt(s string, index int) uint16 {
+	if isASCII(s) {
+		return uint16(s[index])
+	}
+	return toUTF16(s)[index]
+}
+
+// substring returns the code units of s from start up to end, which are
+// within bounds.
+func substring(s string, start, end int) string {
+	if isASCII(s) {
+		return s[start:end]
+	}
+	return fromUTF16(toUTF16(s)[start:end])
+}
@@ -0,0 +1,568 @@
+package runtime
+
+import (
+	"strings"
+
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// frame is the state of compiled code as it runs: its variables, operand
+// stack and the position of the next instruction.
+type frame struct {
+	c    *context
+	code *code
+	fn   *Object // nil for scripts and modules
+	args []Value
+
+	// the variables of the code, nil in their temporal dead zone, and the
+	// cells of those that closures capture
+	locals []Value
+	cells  []binding
+	upvals []*binding
+
+	stack []Value
+	pc    int
+
+	// the iterators being destructured, and the keys excluded from the
+	// object rest properties being collected
+	iterators []*iteratorRecord
+	excluded  []map[propertyKey]bool
+}
+
+// newFrame returns the frame of a run of code in the context c, the call of
+// the function fn with args, whose closure captures upvals.
+func (r *Runtime) newFrame(c *context, code *code, fn *Object, args []Value, upvals []*binding) *frame {
+	f := &frame{c: c, code: code, fn: fn, args: args, upvals: upvals}
+	// the locals and the operand stack share an allocation
+	n := len(code.locals)
+	buf := make([]Value, n, n+code.maxStack)
+	for i, slot := range code.locals {
+		if slot.initialized {
+			buf[i] = Undefined
+		}
+	}
+	f.locals, f.stack = buf, buf[n:]
+	if len(code.cells) > 0 {
+		f.cells = make([]binding, len(code.cells))
+		for i, slot := range code.cells {
+			f.cells[i] = binding{value: Undefined, initialized: slot.initialized, mutable: slot.mutable}
+		}
+	}
+	return f
+}
+
+// closure returns a closure of the function tmpl nested in the code of the
+// frame, named after key and prefix, with homeObject for methods.
+func (f *frame) closure(tmpl *functionTemplate, key propertyKey, prefix string, homeObject *Object) *Object {
+	upvals := make([]*binding, len(tmpl.captures))
+	for i, capture := range tmpl.captures {
+		if capture.upval {
+			upvals[i] = f.upvals[capture.index]
+		} else {
+			upvals[i] = &f.cells[capture.index]
+		}
+	}
+	c := f.c
+	cl := &closure{node: tmpl.node, info: tmpl.code.info, env: c.env, script: c.script, module: c.module, homeObject: homeObject, code: tmpl.code, upvals: upvals}
+	return c.newClosure(cl, key, prefix)
+}
+
+// closeIterators closes the iterators being destructured when an exception
+// is thrown through the frame.
+func (f *frame) closeIterators() {
+	if x := recover(); x != nil {
+		for i := len(f.iterators) - 1; i >= 0; i-- {
+			f.c.r.closeQuietly(f.iterators[i])
+		}
+		f.iterators = nil
+		panic(x)
+	}
+}
+
+// run runs the instructions of the frame from its current position, and
+// returns the value the code returns. It returns nil when the frame of a
+// generator function is suspended until the generator is first resumed.
+func (r *Runtime) run(f *frame) Value {
+	if f.code.iterates {
+		defer f.closeIterators()
+	}
+	c := f.c
+	code := f.code
+	instructions := code.instructions
+	locals := f.locals
+	stack := f.stack
+
+	pop := func() Value {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	tdz := func(name string) *Exception {
+		return r.newReferenceError("Cannot access '%s' before initialization", name)
+	}
+
+	for pc := f.pc; ; pc++ {
+		in := instructions[pc]
+		switch in.op {
+		case opConst:
+			stack = append(stack, code.constants[in.a])
+		case opUndefined:
+			stack = append(stack, Undefined)
+		case opThis:
+			stack = append(stack, c.thisContext.this)
+		case opNewTarget:
+			stack = append(stack, c.thisContext.newTarget)
+		case opImportMeta:
+			stack = append(stack, r.importMeta(c.module))
+		case opCallee:
+			stack = append(stack, f.fn)
+		case opPop:
+			stack = stack[:len(stack)-1]
+		case opDup:
+			stack = append(stack, stack[len(stack)-1])
+		case opDup2:
+			stack = append(stack, stack[len(stack)-2], stack[len(stack)-1])
+		case opSwap:
+			n := len(stack)
+			stack[n-1], stack[n-2] = stack[n-2], stack[n-1]
+		case opRot3:
+			n := len(stack)
+			stack[n-3], stack[n-2], stack[n-1] = stack[n-1], stack[n-3], stack[n-2]
+		case opRot4:
+			n := len(stack)
+			stack[n-4], stack[n-3], stack[n-2], stack[n-1] = stack[n-1], stack[n-4], stack[n-3], stack[n-2]
+		case opPick:
+			stack = append(stack, stack[len(stack)-1-int(in.a)])
+
+		case opGetLocal:
+			v := locals[in.a]
+			if v == nil {
+				panic(tdz(code.locals[in.a].name))
+			}
+			stack = append(stack, v)
+		case opSetLocal:
+			if locals[in.a] == nil {
+				panic(tdz(code.locals[in.a].name))
+			}
+			locals[in.a] = pop()
+		case opInitLocal:
+			locals[in.a] = pop()
+		case opGetCell:
+			b := &f.cells[in.a]
+			if !b.initialized {
+				panic(tdz(code.cells[in.a].name))
+			}
+			stack = append(stack, b.value)
+		case opSetCell:
+			b := &f.cells[in.a]
+			if !b.initialized {
+				panic(tdz(code.cells[in.a].name))
+			}
+			b.value = pop()
+		case opInitCell:
+			b := &f.cells[in.a]
+			b.value, b.initialized = pop(), true
+		case opGetUpval:
+			b := f.upvals[in.a]
+			if !b.initialized {
+				panic(tdz(code.upvals[in.a]))
+			}
+			stack = append(stack, b.value)
+		case opSetUpval:
+			b := f.upvals[in.a]
+			if !b.initialized {
+				panic(tdz(code.upvals[in.a]))
+			}
+			b.value = pop()
+		case opGetName:
+			stack = append(stack, c.getIdentifier(code.names[in.a]))
+		case opSetName:
+			name := code.names[in.a]
+			c.putValue(c.identifierReference(name), pop())
+		case opInitName:
+			initialize(c.env, code.names[in.a], pop())
+		case opTypeofName:
+			ref := c.identifierReference(code.names[in.a])
+			if ref.env == nil {
+				stack = append(stack, String("undefined"))
+			} else {
+				stack = append(stack, String(typeOf(c.getValue(ref))))
+			}
+		case opDeleteName:
+			stack = append(stack, Bool(c.deleteIdentifier(code.names[in.a])))
+		case opSetVar:
+			object := c.varEnv.object
+			object.set(stringKey(code.names[in.a]), pop(), object)
+
+		case opGetProp:
+			n := len(stack) - 1
+			if o, ok := stack[n].(*Object); ok {
+				stack[n] = o.get(code.keys[in.a], o)
+			} else {
+				stack[n] = r.getV(stack[n], code.keys[in.a])
+			}
+		case opGetElem:
+			key := pop()
+			n := len(stack) - 1
+			if o, ok := stack[n].(*Object); ok && o.elements != nil {
+				if i, ok := key.(Number); ok && i >= 0 && int(i) < len(o.elements) && float64(int(i)) == float64(i) {
+					if v := o.elements[int(i)]; v != nil {
+						stack[n] = v
+						continue
+					}
+				}
+			}
+			stack[n] = r.getV(stack[n], r.toPropertyKey(key))
+		case opSetProp:
+			v := pop()
+			r.putV(pop(), code.keys[in.a], v, code.strict)
+			stack = append(stack, v)
+		case opSetElem:
+			v, key := pop(), pop()
+			base := pop()
+			stack = append(stack, v)
+			if o, ok := base.(*Object); ok && o.elements != nil {
+				if i, ok := key.(Number); ok && i >= 0 && int(i) < len(o.elements) && float64(int(i)) == float64(i) {
+					if o.elements[int(i)] != nil {
+						o.elements[int(i)] = v
+						continue
+					}
+				}
+			}
+			r.putV(base, r.toPropertyKey(key), v, code.strict)
+		case opDeleteProp:
+			n := len(stack) - 1
+			stack[n] = Bool(r.deleteProperty(stack[n], code.keys[in.a], code.strict))
+		case opDeleteElem:
+			key := r.toPropertyKey(pop())
+			n := len(stack) - 1
+			stack[n] = Bool(r.deleteProperty(stack[n], key, code.strict))
+		case opGetSuper:
+			n := len(stack) - 1
+			base, this := f.superBase()
+			stack[n] = r.toObject(base).get(r.toPropertyKey(stack[n]), this)
+		case opSetSuper:
+			v := pop()
+			key := r.toPropertyKey(pop())
+			base, this := f.superBase()
+			if !r.toObject(base).set(key, v, this) && code.strict {
+				panic(r.newTypeError("Cannot assign to read only property '%s' of %s", key, r.describe(this)))
+			}
+			stack = append(stack, v)
+		case opToKey:
+			n := len(stack) - 1
+			if _, ok := stack[n].(*Object); ok {
+				stack[n] = r.toPropertyKey(stack[n]).value()
+			}
+
+		case opAdd:
+			b := pop()
+			n := len(stack) - 1
+			stack[n] = r.add(stack[n], b)
+		case opSub, opMul, opLess, opGreater, opLessEq, opGreaterEq:
+			b := pop()
+			n := len(stack) - 1
+			x, ok := stack[n].(Number)
+			y, ok2 := b.(Number)
+			if !ok || !ok2 {
+				stack[n] = r.binaryOperation(tokenOf(in), stack[n], b)
+				continue
+			}
+			switch in.op {
+			case opSub:
+				stack[n] = x - y
+			case opMul:
+				stack[n] = x * y
+			case opLess:
+				stack[n] = Bool(x < y)
+			case opGreater:
+				stack[n] = Bool(x > y)
+			case opLessEq:
+				stack[n] = Bool(x <= y)
+			default:
+				stack[n] = Bool(x >= y)
+			}
+		case opStrictEq:
+			b := pop()
+			n := len(stack) - 1
+			stack[n] = Bool(strictEquals(stack[n], b))
+		case opStrictNe:
+			b := pop()
+			n := len(stack) - 1
+			stack[n] = Bool(!strictEquals(stack[n], b))
+		case opBinary:
+			b := pop()
+			n := len(stack) - 1
+			stack[n] = r.binaryOperation(tokenOf(in), stack[n], b)
+		case opUnary:
+			n := len(stack) - 1
+			stack[n] = r.unaryOperation(tokenOf(in), stack[n])
+		case opTypeof:
+			n := len(stack) - 1
+			stack[n] = String(typeOf(stack[n]))
+		case opToNumeric:
+			n := len(stack) - 1
+			if _, ok := stack[n].(Number); !ok {
+				stack[n] = Number(r.toNumeric(stack[n]))
+			}
+		case opInc:
+			n := len(stack) - 1
+			stack[n] = stack[n].(Number) + 1
+		case opDec:
+			n := len(stack) - 1
+			stack[n] = stack[n].(Number) - 1
+
+		case opJump:
+			pc = int(in.a) - 1
+		case opJumpIfFalse:
+			if !toBoolean(pop()) {
+				pc = int(in.a) - 1
+			}
+		case opJumpIfFalseKeep:
+			if !toBoolean(stack[len(stack)-1]) {
+				pc = int(in.a) - 1
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case opJumpIfTrueKeep:
+			if toBoolean(stack[len(stack)-1]) {
+				pc = int(in.a) - 1
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case opJumpIfNotNullKeep:
+			if !isNullish(stack[len(stack)-1]) {
+				pc = int(in.a) - 1
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case opJumpIfNotUndefined:
+			if stack[len(stack)-1] != Undefined {
+				pc = int(in.a) - 1
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case opShortCircuit:
+			if isNullish(stack[len(stack)-1]) {
+				stack = append(stack[:len(stack)-int(in.b)], Undefined)
+				pc = int(in.a) - 1
+			}
+		case opReturn:
+			return pop()
+		case opPosition:
+			r.at(code.positions[in.a])
+		case opThrow:
+			panic(r.newError(string(code.constants[in.b].(String)), "%s", code.constants[in.a]))
+
+		case opCall:
+			base := len(stack) - int(in.a) - 2
+			fn, ok := stack[base].(*Object)
+			if !ok || fn.call == nil {
+				panic(r.newTypeError("%s is not a function", code.constants[in.b]))
+			}
+			// the functions of scripts read their arguments before they
+			// return, so that they may be passed the stack itself
+			args := stack[base+2 : len(stack) : len(stack)]
+			if _, ok := fn.internal.(*closure); !ok {
+				args = append([]Value(nil), args...)
+			}
+			this := stack[base+1]
+			stack = stack[:base]
+			stack = append(stack, fn.call(this, args))
+		case opCallSpread:
+			array := pop().(*Object)
+			this := pop()
+			fn, ok := pop().(*Object)
+			if !ok || fn.call == nil {
+				panic(r.newTypeError("%s is not a function", code.constants[in.b]))
+			}
+			stack = append(stack, fn.call(this, array.elements))
+		case opNew:
+			base := len(stack) - int(in.a) - 1
+			fn := stack[base]
+			args := make([]Value, in.a)
+			copy(args, stack[base+1:])
+			stack = stack[:base]
+			if !isConstructor(fn) {
+				panic(r.newTypeError("%s is not a constructor", code.constants[in.b]))
+			}
+			stack = append(stack, r.constructObject(fn, args, nil))
+		case opNewSpread:
+			array := pop().(*Object)
+			fn := pop()
+			if !isConstructor(fn) {
+				panic(r.newTypeError("%s is not a constructor", code.constants[in.b]))
+			}
+			stack = append(stack, r.constructObject(fn, array.elements, nil))
+		case opClosure:
+			stack = append(stack, f.closure(code.functions[in.a], code.keys[in.b], "", nil))
+		case opMethod:
+			key := r.toPropertyKey(pop())
+			o := stack[len(stack)-1].(*Object)
+			tmpl := code.functions[in.a]
+			switch in.b {
+			case methodGetter:
+				getter := f.closure(tmpl, key, "get", o)
+				o.defineOwnProperty(key, descriptor{getter: getter, flags: enumerable | configurable, has: hasGet | hasEnumerable | hasConfigurable})
+			case methodSetter:
+				setter := f.closure(tmpl, key, "set", o)
+				o.defineOwnProperty(key, descriptor{setter: setter, flags: enumerable | configurable, has: hasSet | hasEnumerable | hasConfigurable})
+			default:
+				o.defineOwnProperty(key, dataDescriptor(f.closure(tmpl, key, "", o), defaultFlags))
+			}
+		case opArguments:
+			if info := code.info; info.strict || !info.simpleParams {
+				stack = append(stack, r.newArguments(f.fn, f.args, true))
+			} else {
+				params := make([]*binding, len(code.paramCells))
+				for i, cell := range code.paramCells {
+					params[i] = &f.cells[cell]
+				}
+				stack = append(stack, r.newMappedArguments(f.fn, f.args, params))
+			}
+		case opArg:
+			stack = append(stack, arg(f.args, int(in.a)))
+		case opRestArgs:
+			var values []Value
+			if int(in.a) < len(f.args) {
+				values = append(values, f.args[in.a:]...)
+			}
+			stack = append(stack, r.newArray(values...))
+		case opGenerator:
+			f.pc, f.stack = pc+1, stack
+			return nil
+		case opYield:
+			n := len(stack) - 1
+			stack[n] = c.generator.yieldValue(r, stack[n])
+		case opYieldStar:
+			n := len(stack) - 1
+			stack[n] = c.generator.yieldDelegate(r, stack[n])
+		case opAwait:
+			n := len(stack) - 1
+			stack[n] = c.generator.await(r, stack[n])
+		case opTemplate:
+			stack = append(stack, r.templateObject(f.c.script, code.nodes[in.a].(*parser.ExprTemplateLiteral)))
+		case opRegExp:
+			e := code.nodes[in.a].(*parser.ExprRegExp)
+			stack = append(stack, r.newRegExp(e.Pattern, e.Flags, r.regexpPrototype))
+		case opToString:
+			n := len(stack) - 1
+			if _, ok := stack[n].(String); !ok {
+				stack[n] = String(r.toString(stack[n]))
+			}
+		case opConcat:
+			var s strings.Builder
+			base := len(stack) - int(in.a)
+			for _, v := range stack[base:] {
+				s.WriteString(string(v.(String)))
+			}
+			stack = append(stack[:base], String(joinSurrogates(s.String())))
+
+		case opNewArray:
+			stack = append(stack, r.newArray())
+		case opArrayPush:
+			v := pop()
+			o := stack[len(stack)-1].(*Object)
+			o.elements = append(o.elements, v)
+			o.length++
+		case opArrayHole:
+			o := stack[len(stack)-1].(*Object)
+			o.elements = append(o.elements, nil)
+			o.length++
+		case opArraySpread:
+			iterable := pop()
+			o := stack[len(stack)-1].(*Object)
+			r.iterate(iterable, func(v Value) bool {
+				o.elements = append(o.elements, v)
+				o.length++
+				return true
+			})
+		case opNewObject:
+			stack = append(stack, r.newObject(r.objectPrototype))
+		case opDefineProp:
+			v := pop()
+			o := stack[len(stack)-1].(*Object)
+			o.defineOwnProperty(code.keys[in.a], dataDescriptor(v, defaultFlags))
+		case opDefineElem:
+			v := pop()
+			key := r.toPropertyKey(pop())
+			o := stack[len(stack)-1].(*Object)
+			if in.b == 1 {
+				setFunctionName(v.(*Object), key, "")
+			}
+			o.defineOwnProperty(key, dataDescriptor(v, defaultFlags))
+		case opSetProto:
+			proto := pop()
+			o := stack[len(stack)-1].(*Object)
+			switch proto := proto.(type) {
+			case *Object:
+				o.setPrototypeOf(proto)
+			case null:
+				o.setPrototypeOf(nil)
+			}
+		case opCopyData:
+			v := pop()
+			r.copyDataProperties(stack[len(stack)-1].(*Object), v, nil)
+		case opCoercible:
+			if v := stack[len(stack)-1]; isNullish(v) {
+				panic(r.newTypeError("Cannot destructure '%s' as it is %s.", r.toString(v), r.toString(v)))
+			}
+		case opGetIterator:
+			f.iterators = append(f.iterators, r.getIterator(pop()))
+		case opIteratorNext:
+			v, _ := r.step(f.iterators[len(f.iterators)-1])
+			stack = append(stack, v)
+		case opIteratorRest:
+			it := f.iterators[len(f.iterators)-1]
+			var values []Value
+			for {
+				v, ok := r.step(it)
+				if !ok {
+					break
+				}
+				values = append(values, v)
+			}
+			stack = append(stack, r.newArray(values...))
+		case opIteratorEnd:
+			n := len(f.iterators) - 1
+			r.closeIterator(f.iterators[n])
+			f.iterators = f.iterators[:n]
+		case opExcludeNew:
+			f.excluded = append(f.excluded, make(map[propertyKey]bool))
+		case opExcludeKey:
+			f.excluded[len(f.excluded)-1][r.toPropertyKey(stack[len(stack)-1])] = true
+		case opExcludeProp:
+			f.excluded[len(f.excluded)-1][code.keys[in.a]] = true
+		case opCopyRest:
+			n := len(stack) - 1
+			rest := r.newObject(r.objectPrototype)
+			r.copyDataProperties(rest, stack[n], f.excluded[len(f.excluded)-1])
+			f.excluded = f.excluded[:len(f.excluded)-1]
+			stack[n] = rest
+		default:
+			panic(r.newSyntaxError("Unknown instruction %s", in.op))
+		}
+	}
+}
+
+// superBase returns the object whose properties super refers to in the
+// code of the frame, along with the this value of the accesses.
+//
+// https://262.ecma-international.org/#sec-makesuperpropertyreference
+func (f *frame) superBase() (Value, Value) {
+	c := f.c.thisContext
+	if c.homeObject == nil {
+		panic(f.c.r.newSyntaxError("'super' keyword unexpected here"))
+	}
+	var base Value = Null
+	if c.homeObject.proto != nil {
+		base = c.homeObject.proto
+	}
+	return base, c.this
+}
+
+// tokenOf returns the operator of the instruction in.
+func tokenOf(in instruction) l.TokenType {
+	return l.TokenType(in.a)
+}
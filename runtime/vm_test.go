@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+func TestDisassemble(t *testing.T) {
+	tcs := []struct {
+		name     string
+		src      string
+		module   bool
+		expected []string
+	}{
+		{
+			name: "script",
+			src:  "var total = 1 + 2",
+			expected: []string{
+				"<script>: 1 locals, 0 cells",
+				"const              1",
+				"add                +",
+				"set.name           total",
+			},
+		},
+		{
+			name: "function",
+			src:  "function f(a, b) { return a.x * b[0] }",
+			expected: []string{
+				"f: 2 locals, 0 cells",
+				"get.local          0 (a)",
+				"get.prop           x",
+				"get.elem",
+				"mul                *",
+			},
+		},
+		{
+			name: "closure",
+			src:  "function counter() { let n = 0; return () => ++n }",
+			expected: []string{
+				"counter: 0 locals, 1 cells",
+				"init.cell          0 (n)",
+				`closure            <anonymous>, named ""`,
+				"get.upval          0 (n)",
+				"set.upval          0 (n)",
+			},
+		},
+		{
+			name:     "async",
+			src:      "async function f(p) { return await p }",
+			expected: []string{"f: 1 locals, 0 cells", "get.local          0 (p)", "await"},
+		},
+		{
+			name:     "module",
+			src:      "export default () => 1",
+			module:   true,
+			expected: []string{"<module>: 0 locals, 0 cells", `named "default"`, "init.name          *default*"},
+		},
+		{
+			name:     "interpreted",
+			src:      "function f(s) { return eval(s) }",
+			expected: []string{"f: interpreted, as it uses with or eval"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			options := parser.Options{SourceType: parser.SourceTypeScript, AnnexB: true}
+			if tc.module {
+				options = parser.Options{SourceType: parser.SourceTypeModule}
+			}
+			listing, err := Disassemble(tc.src, options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, line := range tc.expected {
+				if !strings.Contains(listing, line) {
+					t.Errorf("expected the listing to contain %q, got\n%s", line, listing)
+				}
+			}
+		})
+	}
+}
+
+// benchmarks are scripts evaluating to a function to be timed, which
+// recurse in place of loops.
+var benchmarks = []struct {
+	name string
+	src  string
+}{
+	{
+		name: "fib",
+		src:  "function fib(n) { return n < 2 ? n : fib(n - 1) + fib(n - 2) } () => fib(20)",
+	},
+	{
+		name: "sort",
+		src: `function swap(a, i, j) { const t = a[i]; a[i] = a[j]; a[j] = t }
+function partition(a, hi, pivot, i, j) {
+  if (j >= hi) { swap(a, i, hi); return i }
+  if (a[j] < pivot) { swap(a, i, j); return partition(a, hi, pivot, i + 1, j + 1) }
+  return partition(a, hi, pivot, i, j + 1)
+}
+function sort(a, lo, hi) {
+  if (lo >= hi) return
+  const p = partition(a, hi, a[hi], lo, lo)
+  sort(a, lo, p - 1)
+  sort(a, p + 1, hi)
+}
+function fill(a, i, n, seed) {
+  if (i === n) return a
+  a[i] = seed % 1000
+  return fill(a, i + 1, n, seed * 16807 % 2147483647)
+}
+() => sort(fill([], 0, 300, 1), 0, 299)`,
+	},
+	{
+		name: "strings",
+		src:  "function build(s, i, n) { return i === n ? s : build(s + `${i},`, i + 1, n) } () => build('', 0, 1000).length",
+	},
+}
+
+func BenchmarkRun(b *testing.B) {
+	for _, bench := range benchmarks {
+		for _, e := range engines {
+			b.Run(bench.name+"/"+e.name, func(b *testing.B) {
+				r := New()
+				r.SetEngine(e.engine)
+				fn, err := r.RunString(bench.src)
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := r.Call(fn, Undefined); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package scope
+
+import (
+	l "github.com/ruiconti/gojs/lexer"
+	"github.com/ruiconti/gojs/parser"
+)
+
+// Analyze builds the scope tree of a parsed program and returns its global
+// scope. Module code gets a module scope nested in the global one.
+//
+// Declarations are bound when their scope is entered: var and function
+// declarations are hoisted to the closest function (or the top level), while
+// let and const are bound in their block but stay in their temporal dead zone
+// until evaluated. References are then resolved in source order.
+func Analyze(program parser.Node, options parser.Options) *Scope {
+	global := newScope(ScopeGlobal, program, nil)
+	root, ok := program.(*parser.NodeRoot)
+	if !ok {
+		return global
+	}
+	global.Strict = root.Strict
+
+	a := &analyzer{
+		global:      global,
+		scope:       global,
+		initialized: make(map[*Variable]bool),
+	}
+	if options.SourceType == parser.SourceTypeModule {
+		a.enter(ScopeModule, program)
+	}
+
+	stmts := make([]parser.Stmt, 0, len(root.Children))
+	for _, child := range root.Children {
+		stmts = append(stmts, child)
+	}
+	a.hoist(stmts, true)
+	a.declareLexical(stmts, true)
+	a.markExported(stmts)
+	a.walkStmts(stmts)
+	return global
+}
+
+type analyzer struct {
+	global *Scope
+	scope  *Scope // the scope being walked
+
+	// lexical variables whose declaration has been evaluated
+	initialized map[*Variable]bool
+}
+
+func (a *analyzer) enter(kind ScopeKind, node parser.Node) *Scope {
+	a.scope = newScope(kind, node, a.scope)
+	return a.scope
+}
+
+func (a *analyzer) leave() {
+	a.scope = a.scope.Parent
+}
+
+// declare binds id in the current scope. Redeclarations, as of var and
+// function declarations, add to the existing variable.
+func (a *analyzer) declare(id *parser.ExprIdentifier, kind DeclarationKind) *Variable {
+	scope := a.scope
+	if variable, ok := scope.names[id.Name]; ok {
+		variable.Identifiers = append(variable.Identifiers, id)
+		if kind == DeclFunction && variable.Kind == DeclVar {
+			variable.Kind = DeclFunction
+		}
+		return variable
+	}
+	variable := &Variable{
+		Name:        id.Name,
+		Kind:        kind,
+		Scope:       scope,
+		Identifiers: []*parser.ExprIdentifier{id},
+	}
+	scope.names[id.Name] = variable
+	scope.Variables = append(scope.Variables, variable)
+	return variable
+}
+
+// hoist declares the var declarations of stmts, including those nested in
+// blocks. When topLevel, stmts are the body of a function or of the program,
+// whose function declarations are hoisted too.
+func (a *analyzer) hoist(stmts []parser.Stmt, topLevel bool) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *parser.VariableStatement:
+			if stmt.Kind.Type == l.TVar {
+				for _, name := range declarationNames(stmt) {
+					a.declare(name, DeclVar)
+				}
+			}
+		case *parser.FunctionDeclarationStmt:
+			if topLevel && stmt.BindingIdentifier != nil {
+				a.declare(stmt.BindingIdentifier, DeclFunction)
+			}
+		case *parser.BlockStatement:
+			a.hoist(stmt.Stmts, false)
+		case *parser.IfStatement:
+			a.hoist([]parser.Stmt{stmt.ThenStmt}, false)
+			if stmt.ElseStmt != nil {
+				a.hoist([]parser.Stmt{stmt.ElseStmt}, false)
+			}
+		case *parser.WithStatement:
+			a.hoist([]parser.Stmt{stmt.Body}, false)
+		case *parser.LabelledStatement:
+			a.hoist([]parser.Stmt{stmt.Body}, topLevel)
+		case *parser.ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				a.hoist([]parser.Stmt{stmt.Declaration}, topLevel)
+			}
+		case *parser.ExportDefaultDeclaration:
+			if fn, ok := stmt.Declaration.(*parser.FunctionDeclarationStmt); ok {
+				a.hoist([]parser.Stmt{fn}, topLevel)
+			}
+		}
+	}
+}
+
+// declareLexical declares the let, const and import declarations of stmts,
+// and their function declarations unless topLevel, where they are hoisted.
+func (a *analyzer) declareLexical(stmts []parser.Stmt, topLevel bool) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *parser.VariableStatement:
+			kind := DeclLet
+			switch stmt.Kind.Type {
+			case l.TVar:
+				continue
+			case l.TConst:
+				kind = DeclConst
+			}
+			for _, name := range declarationNames(stmt) {
+				a.declare(name, kind)
+			}
+		case *parser.FunctionDeclarationStmt:
+			if !topLevel && stmt.BindingIdentifier != nil {
+				a.declare(stmt.BindingIdentifier, DeclFunction)
+			}
+		case *parser.ImportDeclaration:
+			for _, specifier := range stmt.Specifiers {
+				a.declare(specifier.Local, DeclImport)
+			}
+		case *parser.LabelledStatement:
+			a.declareLexical([]parser.Stmt{stmt.Body}, topLevel)
+		case *parser.ExportNamedDeclaration:
+			if stmt.Declaration != nil {
+				a.declareLexical([]parser.Stmt{stmt.Declaration}, topLevel)
+			}
+		}
+	}
+}
+
+// markExported marks the variables declared by the export declarations of
+// stmts, the top level statements of a module.
+func (a *analyzer) markExported(stmts []parser.Stmt) {
+	for _, stmt := range stmts {
+		var declaration parser.Node
+		switch stmt := stmt.(type) {
+		case *parser.ExportNamedDeclaration:
+			declaration = stmt.Declaration
+		case *parser.ExportDefaultDeclaration:
+			declaration = stmt.Declaration
+		}
+		var names []*parser.ExprIdentifier
+		switch declaration := declaration.(type) {
+		case *parser.FunctionDeclarationStmt:
+			if declaration.BindingIdentifier != nil {
+				names = append(names, declaration.BindingIdentifier)
+			}
+		case *parser.VariableStatement:
+			names = declarationNames(declaration)
+		}
+		for _, name := range names {
+			if variable := a.scope.names[name.Name]; variable != nil {
+				variable.Exported = true
+			}
+		}
+	}
+}
+
+// reference resolves an occurrence of id from the current scope.
+func (a *analyzer) reference(id *parser.ExprIdentifier, read, write, init bool) *Reference {
+	ref := &Reference{Identifier: id, Scope: a.scope, Read: read, Write: write, Init: init}
+	a.scope.References = append(a.scope.References, ref)
+
+	var crossed []*Scope // the function scopes the reference escapes from
+	for scope := a.scope; scope != nil; scope = scope.Parent {
+		variable := scope.names[id.Name]
+		if variable == nil && id.Name == "arguments" && scope.Kind == ScopeFunction {
+			if _, arrow := scope.Node.(*parser.ExprArrowFunction); !arrow {
+				variable = &Variable{Name: id.Name, Kind: DeclImplicit, Scope: scope}
+				scope.names[id.Name] = variable
+				scope.Variables = append(scope.Variables, variable)
+			}
+		}
+		if variable != nil {
+			ref.Variable = variable
+			variable.References = append(variable.References, ref)
+			ref.TDZ = variable.Kind.Lexical() && !a.initialized[variable] && len(crossed) == 0
+			if len(crossed) > 0 && scope != a.global {
+				variable.Captured = true
+				for _, fn := range crossed {
+					fn.capture(variable)
+				}
+			}
+			return ref
+		}
+
+		switch scope.Kind {
+		case ScopeFunction:
+			crossed = append(crossed, scope)
+		case ScopeWith:
+			ref.Dynamic = true
+		}
+	}
+
+	a.global.Implicit = append(a.global.Implicit, ref)
+	if id.Name == "eval" {
+		for scope := a.scope; scope != nil; scope = scope.Parent {
+			scope.Eval = true
+		}
+	}
+	return ref
+}
+
+func (s *Scope) capture(variable *Variable) {
+	for _, captured := range s.Captures {
+		if captured == variable {
+			return
+		}
+	}
+	s.Captures = append(s.Captures, variable)
+}
+
+func (a *analyzer) walkStmts(stmts []parser.Stmt) {
+	for _, stmt := range stmts {
+		a.walkStmt(stmt)
+	}
+}
+
+func (a *analyzer) walkStmt(stmt parser.Node) {
+	switch stmt := stmt.(type) {
+	case *parser.ExpressionStatement:
+		a.walkExpr(stmt.Expression)
+	case *parser.VariableStatement:
+		for _, decl := range stmt.Declarations {
+			if decl.Init != nil {
+				a.walkExpr(decl.Init)
+			}
+			var target parser.Node = decl.Pattern
+			if decl.Identifier != nil {
+				target = decl.Identifier
+			}
+			a.walkBinding(target, func(id *parser.ExprIdentifier) {
+				if variable := a.scope.Lookup(id.Name); variable != nil && variable.Kind.Lexical() {
+					a.initialized[variable] = true
+				}
+				if decl.Init != nil {
+					a.reference(id, false, true, true)
+				}
+			})
+		}
+	case *parser.ReturnStatement:
+		if stmt.Argument != nil {
+			a.walkExpr(stmt.Argument)
+		}
+	case *parser.IfStatement:
+		a.walkExpr(stmt.Condition)
+		a.walkSubstatement(stmt.ThenStmt)
+		if stmt.ElseStmt != nil {
+			a.walkSubstatement(stmt.ElseStmt)
+		}
+	case *parser.BlockStatement:
+		a.enter(ScopeBlock, stmt)
+		a.declareLexical(stmt.Stmts, false)
+		a.walkStmts(stmt.Stmts)
+		a.leave()
+	case *parser.WithStatement:
+		a.walkExpr(stmt.Object)
+		a.enter(ScopeWith, stmt)
+		a.walkSubstatement(stmt.Body)
+		a.leave()
+	case *parser.LabelledStatement:
+		a.walkStmt(stmt.Body)
+	case *parser.FunctionDeclarationStmt:
+		a.walkFunction(stmt, nil, stmt.Params, stmt.Body, nil, stmt.Strict)
+	case *parser.ExportNamedDeclaration:
+		if stmt.Declaration != nil {
+			a.walkStmt(stmt.Declaration)
+		} else if stmt.Source == nil {
+			// export { a as b } refers to the local binding a
+			for _, specifier := range stmt.Specifiers {
+				if local, ok := specifier.Local.(*parser.ExprIdentifier); ok {
+					a.reference(local, true, false, false)
+				}
+			}
+		}
+	case *parser.ExportDefaultDeclaration:
+		if _, ok := stmt.Declaration.(*parser.FunctionDeclarationStmt); ok {
+			a.walkStmt(stmt.Declaration)
+		} else {
+			a.walkExpr(stmt.Declaration)
+		}
+	}
+}
+
+// walkSubstatement walks the body of an if or with statement. Annex B allows a
+// function declaration there, which is scoped as if in a block of its own.
+func (a *analyzer) walkSubstatement(stmt parser.Stmt) {
+	fn, ok := stmt.(*parser.FunctionDeclarationStmt)
+	if !ok {
+		a.walkStmt(stmt)
+		return
+	}
+	a.enter(ScopeBlock, stmt)
+	a.declareLexical([]parser.Stmt{fn}, false)
+	a.walkStmt(fn)
+	a.leave()
+}
+
+// walkFunction walks a function in a scope of its own, where its parameters and
+// body declarations are bound. name is only set on function expressions, whose
+// name is bound within the function rather than in the enclosing scope.
+func (a *analyzer) walkFunction(node parser.Node, name *parser.ExprIdentifier, params []parser.Node, body []parser.Stmt, concise parser.Expr, strict bool) {
+	scope := a.enter(ScopeFunction, node)
+	scope.Strict = scope.Strict || strict
+	defer a.leave()
+
+	for _, param := range params {
+		for _, id := range BindingIdentifiers(param) {
+			a.declare(id, DeclParameter)
+		}
+	}
+	a.hoist(body, true)
+	a.declareLexical(body, true)
+	if name != nil && scope.names[name.Name] == nil {
+		a.declare(name, DeclFunction)
+	}
+
+	for _, param := range params {
+		a.walkBinding(param, func(*parser.ExprIdentifier) {})
+	}
+	a.walkStmts(body)
+	if concise != nil {
+		a.walkExpr(concise)
+	}
+}
+
+// walkBinding walks a binding or assignment target, calling bind on each
+// identifier it binds, in order. Default values and computed keys are walked
+// as expressions.
+func (a *analyzer) walkBinding(node parser.Node, bind func(*parser.ExprIdentifier)) {
+	switch node := node.(type) {
+	case *parser.ExprIdentifier:
+		bind(node)
+	case *parser.ExprParenthesized:
+		a.walkBinding(node.Expression, bind)
+	case *parser.ObjectPattern:
+		for _, property := range node.Properties {
+			a.walkBinding(property, bind)
+		}
+	case *parser.PatternProperty:
+		if node.Computed {
+			a.walkExpr(node.Key)
+		}
+		a.walkBinding(node.Value, bind)
+	case *parser.ArrayPattern:
+		for _, element := range node.Elements {
+			if element != nil {
+				a.walkBinding(element, bind)
+			}
+		}
+	case *parser.AssignmentPattern:
+		a.walkExpr(node.Right)
+		a.walkBinding(node.Left, bind)
+	case *parser.RestElement:
+		a.walkBinding(node.Argument, bind)
+	default:
+		// member expressions of assignment targets, as in [a.b] = c
+		a.walkExpr(node)
+	}
+}
+
+func (a *analyzer) walkExpr(expr parser.Node) {
+	switch expr := expr.(type) {
+	case *parser.ExprIdentifier:
+		a.reference(expr, true, false, false)
+	case *parser.ExprUnaryOp:
+		if id, ok := parser.Unparenthesized(expr.Operand).(*parser.ExprIdentifier); ok {
+			switch expr.Operator.Type {
+			case l.TPlusPlus, l.TMinusMinus:
+				a.reference(id, true, true, false)
+				return
+			case l.TTypeof:
+				a.reference(id, true, false, false).Typeof = true
+				return
+			}
+		}
+		a.walkExpr(expr.Operand)
+	case *parser.ExprBinaryOp:
+		a.walkExpr(expr.Left)
+		a.walkExpr(expr.Right)
+	case *parser.ExprAssign:
+		// compound assignments, as in a += 1, read their target too
+		read := expr.Operator.Type != l.TAssign
+		a.walkBinding(expr.Left, func(id *parser.ExprIdentifier) {
+			a.reference(id, read, true, false)
+		})
+		a.walkExpr(expr.Right)
+	case *parser.ExprConditional:
+		a.walkExpr(expr.Test)
+		a.walkExpr(expr.Consequent)
+		a.walkExpr(expr.Alternate)
+	case *parser.ExprNew:
+		a.walkExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			a.walkExpr(argument)
+		}
+	case *parser.ExprCall:
+		a.walkExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			a.walkExpr(argument)
+		}
+	case *parser.ExprMemberAccess:
+		a.walkExpr(expr.Object)
+		if expr.Computed {
+			a.walkExpr(expr.Property)
+		}
+	case *parser.ExprChain:
+		a.walkExpr(expr.Expression)
+	case *parser.ExprImportCall:
+		a.walkExpr(expr.Source)
+	case *parser.SpreadElement:
+		a.walkExpr(expr.Argument)
+	case *parser.ExprYield:
+		if expr.Argument != nil {
+			a.walkExpr(expr.Argument)
+		}
+	case *parser.ExprAwait:
+		a.walkExpr(expr.Argument)
+	case *parser.ExprParenthesized:
+		a.walkExpr(expr.Expression)
+	case *parser.ExprSequence:
+		for _, expr := range expr.Expressions {
+			a.walkExpr(expr)
+		}
+	case *parser.ExprArray:
+		for _, element := range expr.Elements {
+			a.walkExpr(element)
+		}
+	case *parser.ExprObject:
+		for _, property := range expr.Properties {
+			if property.Computed {
+				a.walkExpr(property.Key)
+			}
+			a.walkExpr(property.Value)
+		}
+	case *parser.ExprTemplateLiteral:
+		for _, expr := range expr.Expressions {
+			a.walkExpr(expr)
+		}
+	case *parser.ExprTaggedTemplate:
+		a.walkExpr(expr.Tag)
+		a.walkExpr(expr.Quasi)
+	case *parser.ExprFunction:
+		a.walkFunction(expr, expr.BindingIdentifier, expr.Params, expr.Body, nil, expr.Strict)
+	case *parser.ExprArrowFunction:
+		a.walkFunction(expr, nil, expr.Params, expr.Body, expr.Expression, expr.Strict)
+	}
+}
+
+// declarationNames returns the identifiers bound by the declarations of stmt.
+func declarationNames(stmt *parser.VariableStatement) []*parser.ExprIdentifier {
+	var names []*parser.ExprIdentifier
+	for _, decl := range stmt.Declarations {
+		if decl.Identifier != nil {
+			names = append(names, decl.Identifier)
+		} else {
+			names = append(names, BindingIdentifiers(decl.Pattern)...)
+		}
+	}
+	return names
+}
+
+// BindingIdentifiers returns the identifiers bound by a binding target, as a
+// declaration or parameter, in order.
+func BindingIdentifiers(node parser.Node) []*parser.ExprIdentifier {
+	var names []*parser.ExprIdentifier
+	switch node := node.(type) {
+	case *parser.ExprIdentifier:
+		names = append(names, node)
+	case *parser.ObjectPattern:
+		for _, property := range node.Properties {
+			names = append(names, BindingIdentifiers(property)...)
+		}
+	case *parser.PatternProperty:
+		names = append(names, BindingIdentifiers(node.Value)...)
+	case *parser.ArrayPattern:
+		for _, element := range node.Elements {
+			if element != nil {
+				names = append(names, BindingIdentifiers(element)...)
+			}
+		}
+	case *parser.AssignmentPattern:
+		names = append(names, BindingIdentifiers(node.Left)...)
+	case *parser.RestElement:
+		names = append(names, BindingIdentifiers(node.Argument)...)
+	}
+	return names
+}
@@ -0,0 +1,166 @@
+// Package scope builds the scope tree of a parsed program and resolves every
+// identifier reference to the variable it denotes.
+//
+// The parser has no try statements and no classes yet, so there are neither
+// catch nor class scopes.
+//
+// https://262.ecma-international.org/#sec-environment-records
+package scope
+
+import (
+	"fmt"
+
+	"github.com/ruiconti/gojs/parser"
+)
+
+// ScopeKind tells apart the syntactic constructs that introduce a scope.
+type ScopeKind string
+
+const (
+	ScopeGlobal   ScopeKind = "global"
+	ScopeModule   ScopeKind = "module"
+	ScopeFunction ScopeKind = "function"
+	ScopeBlock    ScopeKind = "block"
+	ScopeWith     ScopeKind = "with"
+)
+
+// DeclarationKind tells apart the ways a variable can be declared.
+type DeclarationKind string
+
+const (
+	DeclVar       DeclarationKind = "var"
+	DeclLet       DeclarationKind = "let"
+	DeclConst     DeclarationKind = "const"
+	DeclFunction  DeclarationKind = "function"
+	DeclParameter DeclarationKind = "parameter"
+	DeclImport    DeclarationKind = "import"
+	// the 'arguments' object of non-arrow functions, declared on first use
+	DeclImplicit DeclarationKind = "implicit"
+)
+
+// Lexical reports whether variables of kind are in their temporal dead zone
+// until their declaration is evaluated.
+func (k DeclarationKind) Lexical() bool {
+	return k == DeclLet || k == DeclConst
+}
+
+// Scope is a region of the program where declared names are visible.
+type Scope struct {
+	Kind     ScopeKind
+	Node     parser.Node // the node introducing the scope
+	Parent   *Scope      // nil on the global scope
+	Children []*Scope
+	Strict   bool // whether the code of the scope is strict mode code
+
+	// Variables declared in the scope, in order of declaration
+	Variables []*Variable
+	// References occurring directly within the scope, in source order
+	References []*Reference
+	// Captures are the variables of enclosing scopes, other than the global
+	// one, referenced from within a function scope or its nested scopes
+	Captures []*Variable
+
+	// Implicit holds, on the global scope, the references that resolve to no
+	// declaration and so denote implicit globals
+	Implicit []*Reference
+
+	// Eval is whether the code of the scope, or of its nested scopes, refers
+	// to the global eval, whose direct calls may refer to any variable in
+	// sight by a name computed at run time
+	Eval bool
+
+	names map[string]*Variable
+}
+
+func newScope(kind ScopeKind, node parser.Node, parent *Scope) *Scope {
+	scope := &Scope{
+		Kind:   kind,
+		Node:   node,
+		Parent: parent,
+		names:  make(map[string]*Variable),
+	}
+	if parent != nil {
+		scope.Strict = parent.Strict
+		parent.Children = append(parent.Children, scope)
+	}
+	return scope
+}
+
+func (s *Scope) String() string {
+	return fmt.Sprintf("%s scope (%d variables)", s.Kind, len(s.Variables))
+}
+
+// Variable returns the variable declared in the scope itself with name, if
+// there is one.
+func (s *Scope) Variable(name string) *Variable {
+	return s.names[name]
+}
+
+// Lookup returns the variable that name resolves to from the scope, searching
+// the enclosing scopes outwards.
+func (s *Scope) Lookup(name string) *Variable {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if variable := scope.names[name]; variable != nil {
+			return variable
+		}
+	}
+	return nil
+}
+
+// Function returns the closest function scope enclosing s, or the global or
+// module scope at the top level.
+func (s *Scope) Function() *Scope {
+	scope := s
+	for scope.Parent != nil && scope.Kind != ScopeFunction && scope.Kind != ScopeModule {
+		scope = scope.Parent
+	}
+	return scope
+}
+
+// Variable is a name bound in a scope, possibly declared several times, as
+// var and function declarations may be.
+type Variable struct {
+	Name        string
+	Kind        DeclarationKind
+	Scope       *Scope
+	Identifiers []*parser.ExprIdentifier // the declaring identifiers
+	References  []*Reference
+	// whether the variable is referenced from a function nested in its scope
+	Captured bool
+	// whether an export declaration of a module declares the variable, for
+	// the importing modules to refer to
+	Exported bool
+}
+
+func (v *Variable) String() string {
+	return fmt.Sprintf("%s %s", v.Kind, v.Name)
+}
+
+// Reference is an occurrence of an identifier that reads or writes a variable.
+type Reference struct {
+	Identifier *parser.ExprIdentifier
+	Scope      *Scope    // the scope the reference occurs in
+	Variable   *Variable // nil when the reference denotes an implicit global
+
+	Read  bool
+	Write bool
+	// whether the write is the initialization of a declaration
+	Init bool
+	// whether the reference is evaluated before the declaration of its
+	// lexical variable, within the same function, which throws a
+	// ReferenceError
+	TDZ bool
+	// whether the reference occurs within a 'with' statement nested in the
+	// scope of its variable, where the object may shadow it
+	Dynamic bool
+	// whether the reference is the operand of typeof, which does not throw
+	// on undeclared globals
+	Typeof bool
+}
+
+func (r *Reference) String() string {
+	if r.Variable == nil {
+		return fmt.Sprintf("%s -> global", r.Identifier.Name)
+	}
+	return fmt.Sprintf("%s -> %s", r.Identifier.Name, r.Variable)
+}
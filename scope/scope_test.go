@@ -0,0 +1,257 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/ruiconti/gojs/internal"
+	"github.com/ruiconti/gojs/parser"
+)
+
+func analyze(t *testing.T, src string, options parser.Options) *Scope {
+	t.Helper()
+	logger := internal.NewSimpleLogger(internal.ModeDebug)
+	return Analyze(parser.ParseWithOptions(logger, src, options), options)
+}
+
+// references returns the references to name within scope and then within its
+// nested scopes.
+func references(scope *Scope, name string) []*Reference {
+	var refs []*Reference
+	for _, ref := range scope.References {
+		if ref.Identifier.Name == name {
+			refs = append(refs, ref)
+		}
+	}
+	for _, child := range scope.Children {
+		refs = append(refs, references(child, name)...)
+	}
+	return refs
+}
+
+func variableNames(scope *Scope) []string {
+	var names []string
+	for _, variable := range scope.Variables {
+		names = append(names, string(variable.Kind)+" "+variable.Name)
+	}
+	return names
+}
+
+func assertNames(t *testing.T, got, expected []string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestAnalyze_Hoisting(t *testing.T) {
+	global := analyze(t, `f(); var a = 1; function f() { var a; { var b; } return a; } var a;`, parser.Options{})
+	assertNames(t, variableNames(global), []string{"var a", "function f"})
+	if n := len(global.Variable("a").Identifiers); n != 2 {
+		t.Errorf("expected a to be declared twice, got %d", n)
+	}
+	if ref := references(global, "f")[0]; ref.Variable != global.Variable("f") || ref.TDZ {
+		t.Errorf("expected f to resolve to the hoisted function, got %v", ref)
+	}
+
+	fn := global.Children[0]
+	assertNames(t, variableNames(fn), []string{"var a", "var b"})
+	if ref := references(fn, "a")[0]; ref.Variable != fn.Variable("a") {
+		t.Errorf("expected a to resolve to the local variable, got %v", ref)
+	}
+}
+
+func TestAnalyze_BlockScoping(t *testing.T) {
+	global := analyze(t, `let a = 1; { const a = 2; a; function f() {} } a; f;`, parser.Options{})
+	assertNames(t, variableNames(global), []string{"let a"})
+	block := global.Children[0]
+	if block.Kind != ScopeBlock {
+		t.Fatalf("expected a block scope, got %s", block.Kind)
+	}
+	assertNames(t, variableNames(block), []string{"const a", "function f"})
+
+	outer, inner := global.Variable("a").References, block.Variable("a").References
+	if len(outer) != 2 || len(inner) != 2 {
+		t.Fatalf("expected 2 references to each a, got %v and %v", outer, inner)
+	}
+	if !outer[0].Write || !outer[0].Init || outer[1].Scope != global {
+		t.Errorf("expected the initialization and a read of the outer a, got %v", outer)
+	}
+	if !inner[1].Read || inner[1].Scope != block {
+		t.Errorf("expected a read of the inner a, got %v", inner)
+	}
+	if ref := references(global, "f")[0]; ref.Variable != nil {
+		t.Errorf("expected f to be an implicit global out of its block, got %v", ref)
+	}
+}
+
+func TestAnalyze_TDZ(t *testing.T) {
+	global := analyze(t, `a; let a = a; a; function f() { return b; } const b = 1;`, parser.Options{})
+	refs := references(global, "a")
+	expected := []bool{true, true, false, false}
+	for i, ref := range refs {
+		if ref.TDZ != expected[i] {
+			t.Errorf("reference %d: expected TDZ %v, got %v", i, expected[i], ref.TDZ)
+		}
+	}
+	if ref := references(global, "b")[0]; ref.TDZ {
+		t.Errorf("expected b not to be in its TDZ from within a function")
+	}
+}
+
+func TestAnalyze_ImplicitGlobals(t *testing.T) {
+	global := analyze(t, `x = 1; typeof y; function f() { z++; }`, parser.Options{})
+	var names []string
+	for _, ref := range global.Implicit {
+		names = append(names, ref.Identifier.Name)
+	}
+	assertNames(t, names, []string{"x", "y", "z"})
+	if ref := global.Implicit[2]; !ref.Read || !ref.Write {
+		t.Errorf("expected z++ to read and write z")
+	}
+	if ref := global.Implicit[1]; !ref.Typeof {
+		t.Errorf("expected y to be the operand of typeof")
+	}
+}
+
+func TestAnalyze_Closures(t *testing.T) {
+	src := `function outer(p) { let a = 1; var b; return function inner() { return () => a + b + p + c; }; }`
+	global := analyze(t, src, parser.Options{})
+	outer := global.Children[0]
+	inner := outer.Children[0]
+	arrow := inner.Children[0]
+
+	if len(outer.Captures) != 0 {
+		t.Errorf("expected outer to capture nothing, got %v", outer.Captures)
+	}
+	for _, fn := range []*Scope{inner, arrow} {
+		var names []string
+		for _, variable := range fn.Captures {
+			names = append(names, variable.Name)
+		}
+		assertNames(t, names, []string{"a", "b", "p"})
+	}
+	for _, name := range []string{"a", "b", "p"} {
+		if !outer.Variable(name).Captured {
+			t.Errorf("expected %s to be captured", name)
+		}
+	}
+	if ref := references(global, "c")[0]; ref.Variable != nil {
+		t.Errorf("expected c to be an implicit global, got %v", ref)
+	}
+}
+
+func TestAnalyze_Functions(t *testing.T) {
+	t.Run("parameters and defaults", func(t *testing.T) {
+		global := analyze(t, `function f(a, { b } = a, ...c) { a; }`, parser.Options{})
+		fn := global.Children[0]
+		assertNames(t, variableNames(fn), []string{"parameter a", "parameter b", "parameter c"})
+		if refs := references(fn, "a"); len(refs) != 2 || refs[0].Variable != fn.Variable("a") {
+			t.Errorf("expected 2 references to the parameter a, got %v", refs)
+		}
+	})
+
+	t.Run("function expression names", func(t *testing.T) {
+		global := analyze(t, `(function f() { f; }); f;`, parser.Options{})
+		fn := global.Children[0]
+		if ref := references(fn, "f")[0]; ref.Variable != fn.Variable("f") {
+			t.Errorf("expected f to resolve within the function, got %v", ref)
+		}
+		if len(global.Implicit) != 1 {
+			t.Errorf("expected the outer f to be an implicit global")
+		}
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+		global := analyze(t, `function f() { return () => arguments; } arguments;`, parser.Options{})
+		fn := global.Children[0]
+		variable := fn.Variable("arguments")
+		if variable == nil || variable.Kind != DeclImplicit || !variable.Captured {
+			t.Fatalf("expected an implicit arguments captured by the arrow function, got %v", variable)
+		}
+		if len(global.Implicit) != 1 {
+			t.Errorf("expected the top-level arguments to be an implicit global")
+		}
+	})
+
+	t.Run("strictness", func(t *testing.T) {
+		global := analyze(t, `function f() { 'use strict'; { } } function g() {}`, parser.Options{})
+		if !global.Children[0].Strict || !global.Children[0].Children[0].Strict || global.Children[1].Strict {
+			t.Errorf("expected only f and its block to be strict")
+		}
+	})
+}
+
+func TestAnalyze_With(t *testing.T) {
+	global := analyze(t, `var a; with (o) { a; b = 1; var c; }`, parser.Options{})
+	with := global.Children[0]
+	if with.Kind != ScopeWith || with.Children[0].Kind != ScopeBlock {
+		t.Fatalf("expected a with scope around a block scope")
+	}
+	assertNames(t, variableNames(global), []string{"var a", "var c"})
+	for _, name := range []string{"a", "b"} {
+		if ref := references(global, name)[0]; !ref.Dynamic {
+			t.Errorf("expected %s to be resolved dynamically", name)
+		}
+	}
+	if ref := references(global, "o")[0]; ref.Dynamic {
+		t.Errorf("expected the object of the with statement to be resolved statically")
+	}
+}
+
+func TestAnalyze_Eval(t *testing.T) {
+	global := analyze(t, `function f() { function g() { eval('x') } } function h(eval) { eval('y') }`, parser.Options{})
+	f := global.Children[0]
+	g, h := f.Children[0], global.Children[1]
+	if !global.Eval || !f.Eval || !g.Eval {
+		t.Errorf("expected the call of eval to mark g and the scopes enclosing it")
+	}
+	if h.Eval {
+		t.Errorf("expected a parameter named eval not to be the global eval")
+	}
+}
+
+func TestAnalyze_Module(t *testing.T) {
+	src := `import { a } from "m"; export { a as b }; export function f() { return a; } let c = a;`
+	global := analyze(t, src, parser.Options{SourceType: parser.SourceTypeModule})
+	if len(global.Variables) != 0 || len(global.Children) != 1 {
+		t.Fatalf("expected an empty global scope with a module scope")
+	}
+	module := global.Children[0]
+	if module.Kind != ScopeModule || !module.Strict {
+		t.Fatalf("expected a strict module scope, got %v", module)
+	}
+	assertNames(t, variableNames(module), []string{"function f", "import a", "let c"})
+	if refs := module.Variable("a").References; len(refs) != 3 {
+		t.Errorf("expected 3 references to a, got %v", refs)
+	}
+	if !module.Variable("a").Captured {
+		t.Errorf("expected a to be captured by f")
+	}
+	// a is exported by a specifier, not by its declaration
+	for _, name := range []string{"a", "c"} {
+		if module.Variable(name).Exported {
+			t.Errorf("expected %s not to be exported by a declaration", name)
+		}
+	}
+	if !module.Variable("f").Exported {
+		t.Errorf("expected f to be exported")
+	}
+}
+
+func TestAnalyze_Exported(t *testing.T) {
+	src := `export const [a, { b }] = o; export var c; export default function d() {} function e() {}`
+	global := analyze(t, src, parser.Options{SourceType: parser.SourceTypeModule})
+	module := global.Children[0]
+	var exported []string
+	for _, variable := range module.Variables {
+		if variable.Exported {
+			exported = append(exported, variable.Name)
+		}
+	}
+	assertNames(t, exported, []string{"c", "d", "a", "b"})
+}
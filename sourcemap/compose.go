@@ -0,0 +1,47 @@
+package sourcemap
+
+// Compose returns the source map of a source generated from another generated
+// source: outer maps the final source to the intermediate one, and inner maps
+// the intermediate source to the original ones. Each mapping of outer is
+// looked up in inner, and dropped if inner maps it to no source. The name of
+// a mapping is that of inner at the exact position, if any, or that of outer.
+func Compose(outer, inner *Map) (*Map, error) {
+	mappings, err := outer.Decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := inner.decode(); err != nil {
+		return nil, err
+	}
+
+	g := NewGenerator(outer.File)
+	for _, mapping := range mappings {
+		if mapping.Source == "" {
+			continue
+		}
+		original, ok := inner.Lookup(mapping.OriginalLine, mapping.OriginalColumn)
+		if !ok || original.Source == "" {
+			continue
+		}
+		name := mapping.Name
+		if original.Name != "" && original.GeneratedColumn == mapping.OriginalColumn {
+			name = original.Name
+		}
+		g.Add(Mapping{
+			GeneratedLine:   mapping.GeneratedLine,
+			GeneratedColumn: mapping.GeneratedColumn,
+			Source:          original.Source,
+			OriginalLine:    original.OriginalLine,
+			OriginalColumn:  original.OriginalColumn,
+			Name:            name,
+		})
+	}
+
+	root := sourcePrefix(inner.SourceRoot)
+	for i, source := range inner.Sources {
+		if i < len(inner.SourcesContent) && inner.SourcesContent[i] != nil {
+			g.SetContent(root+source, *inner.SourcesContent[i])
+		}
+	}
+	return g.Map(), nil
+}
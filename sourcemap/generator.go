@@ -0,0 +1,93 @@
+package sourcemap
+
+import "sort"
+
+// Generator builds the source map of a generated source from its mappings.
+type Generator struct {
+	file     string
+	mappings []Mapping
+	contents map[string]string
+}
+
+// NewGenerator returns a Generator of the source map of the generated source
+// named file.
+func NewGenerator(file string) *Generator {
+	return &Generator{file: file, contents: make(map[string]string)}
+}
+
+// Add adds a mapping. Of the mappings of a generated position, the last one
+// added is kept.
+func (g *Generator) Add(mapping Mapping) {
+	if n := len(g.mappings); n > 0 {
+		last := &g.mappings[n-1]
+		if last.GeneratedLine == mapping.GeneratedLine && last.GeneratedColumn == mapping.GeneratedColumn {
+			*last = mapping
+			return
+		}
+	}
+	g.mappings = append(g.mappings, mapping)
+}
+
+// SetContent records the content of source, embedded in the map.
+func (g *Generator) SetContent(source, content string) {
+	g.contents[source] = content
+}
+
+// Map returns the source map of the mappings added so far.
+func (g *Generator) Map() *Map {
+	mappings := append([]Mapping(nil), g.mappings...)
+	sort.SliceStable(mappings, func(i, j int) bool {
+		a, b := mappings[i], mappings[j]
+		return a.GeneratedLine < b.GeneratedLine || a.GeneratedLine == b.GeneratedLine && a.GeneratedColumn < b.GeneratedColumn
+	})
+
+	m := &Map{Version: 3, File: g.file}
+	sources := make(map[string]int)
+	names := make(map[string]int)
+	index := func(list *[]string, indexes map[string]int, s string) int {
+		i, ok := indexes[s]
+		if !ok {
+			i = len(*list)
+			indexes[s] = i
+			*list = append(*list, s)
+		}
+		return i
+	}
+
+	var encoded []byte
+	var line, generatedColumn, source, originalLine, originalColumn, name int
+	for i, mapping := range mappings {
+		for ; line < mapping.GeneratedLine; line++ {
+			encoded = append(encoded, ';')
+			generatedColumn = 0
+		}
+		if i > 0 && mappings[i-1].GeneratedLine == line {
+			encoded = append(encoded, ',')
+		}
+		encoded = appendVLQ(encoded, mapping.GeneratedColumn-generatedColumn)
+		generatedColumn = mapping.GeneratedColumn
+		if mapping.Source == "" {
+			continue
+		}
+		s := index(&m.Sources, sources, mapping.Source)
+		encoded = appendVLQ(encoded, s-source)
+		encoded = appendVLQ(encoded, mapping.OriginalLine-originalLine)
+		encoded = appendVLQ(encoded, mapping.OriginalColumn-originalColumn)
+		source, originalLine, originalColumn = s, mapping.OriginalLine, mapping.OriginalColumn
+		if mapping.Name != "" {
+			n := index(&m.Names, names, mapping.Name)
+			encoded = appendVLQ(encoded, n-name)
+			name = n
+		}
+	}
+	m.Mappings = string(encoded)
+
+	contents := make([]*string, len(m.Sources))
+	for i, source := range m.Sources {
+		if content, ok := g.contents[source]; ok {
+			contents[i] = &content
+			m.SourcesContent = contents
+		}
+	}
+	return m
+}
@@ -0,0 +1,178 @@
+// Package sourcemap reads and writes Source Map v3 files, which map the
+// positions of a generated source back to the sources it was generated from.
+//
+// Lines and columns are 0-based, and columns are counted in UTF-16 code units,
+// as in the source map format.
+//
+// https://tc39.es/source-map/
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Map is a source map, as encoded in JSON.
+type Map struct {
+	Version    int      `json:"version"`
+	File       string   `json:"file,omitempty"`
+	SourceRoot string   `json:"sourceRoot,omitempty"`
+	Sources    []string `json:"sources"`
+	// SourcesContent holds the content of each of Sources, nil where
+	// unknown
+	SourcesContent []*string `json:"sourcesContent,omitempty"`
+	Names          []string  `json:"names"`
+	Mappings       string    `json:"mappings"`
+
+	// the decoded mappings, grouped by generated line
+	lines [][]Mapping
+}
+
+// Mapping maps a position of the generated source to a position of one of
+// the original sources.
+type Mapping struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	// Source is the original source, prefixed with the SourceRoot of the
+	// map it was decoded from. It is empty on a mapping of generated code
+	// that comes from no source
+	Source         string
+	OriginalLine   int
+	OriginalColumn int
+	// Name is the original name of the identifier at the position, if any
+	Name string
+}
+
+// Parse reads a source map from its JSON encoding. Index maps, made of
+// sections, are not supported.
+func Parse(data []byte) (*Map, error) {
+	var m struct {
+		Map
+		Sections json.RawMessage `json:"sections"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid source map: %v", err)
+	}
+	if m.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", m.Version)
+	}
+	if m.Sections != nil {
+		return nil, fmt.Errorf("index source maps are not supported")
+	}
+	if _, err := m.Decode(); err != nil {
+		return nil, err
+	}
+	return &m.Map, nil
+}
+
+// Decode returns the mappings of m, sorted by generated position.
+func (m *Map) Decode() ([]Mapping, error) {
+	if err := m.decode(); err != nil {
+		return nil, err
+	}
+	var mappings []Mapping
+	for _, line := range m.lines {
+		mappings = append(mappings, line...)
+	}
+	return mappings, nil
+}
+
+func (m *Map) decode() error {
+	if m.lines != nil {
+		return nil
+	}
+	root := sourcePrefix(m.SourceRoot)
+
+	// every field but the generated column is relative to the previous
+	// segment of the whole map
+	var source, originalLine, originalColumn, name int
+	lines := [][]Mapping{}
+	for generatedLine, segments := range strings.Split(m.Mappings, ";") {
+		var line []Mapping
+		generatedColumn := 0
+		for _, segment := range strings.Split(segments, ",") {
+			if segment == "" {
+				continue
+			}
+			var fields [5]int
+			n := 0
+			for rest := segment; rest != ""; n++ {
+				if n == len(fields) {
+					return fmt.Errorf("invalid mapping segment %q: too many fields", segment)
+				}
+				value, size, err := decodeVLQ(rest)
+				if err != nil {
+					return fmt.Errorf("invalid mapping segment %q: %v", segment, err)
+				}
+				fields[n] = value
+				rest = rest[size:]
+			}
+			if n != 1 && n != 4 && n != 5 {
+				return fmt.Errorf("invalid mapping segment %q: %d fields", segment, n)
+			}
+
+			generatedColumn += fields[0]
+			mapping := Mapping{GeneratedLine: generatedLine, GeneratedColumn: generatedColumn}
+			if n >= 4 {
+				source += fields[1]
+				originalLine += fields[2]
+				originalColumn += fields[3]
+				if source < 0 || source >= len(m.Sources) {
+					return fmt.Errorf("invalid mapping segment %q: no source %d", segment, source)
+				}
+				mapping.Source = root + m.Sources[source]
+				mapping.OriginalLine, mapping.OriginalColumn = originalLine, originalColumn
+			}
+			if n == 5 {
+				name += fields[4]
+				if name < 0 || name >= len(m.Names) {
+					return fmt.Errorf("invalid mapping segment %q: no name %d", segment, name)
+				}
+				mapping.Name = m.Names[name]
+			}
+			line = append(line, mapping)
+		}
+		sort.SliceStable(line, func(i, j int) bool { return line[i].GeneratedColumn < line[j].GeneratedColumn })
+		lines = append(lines, line)
+	}
+	m.lines = lines
+	return nil
+}
+
+// sourcePrefix returns the prefix of the sources of a map with the given
+// SourceRoot.
+func sourcePrefix(root string) string {
+	if root != "" && !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+	return root
+}
+
+// Lookup returns the mapping of the generated position at line and column:
+// the last mapping of the line starting at or before the column.
+func (m *Map) Lookup(line, column int) (Mapping, bool) {
+	if err := m.decode(); err != nil || line < 0 || line >= len(m.lines) {
+		return Mapping{}, false
+	}
+	mappings := m.lines[line]
+	i := sort.Search(len(mappings), func(i int) bool { return mappings[i].GeneratedColumn > column })
+	if i == 0 {
+		return Mapping{}, false
+	}
+	return mappings[i-1], true
+}
+
+// MarshalJSON encodes m in JSON, with empty lists rather than nulls.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	type plain Map
+	encoded := plain(*m)
+	if encoded.Sources == nil {
+		encoded.Sources = []string{}
+	}
+	if encoded.Names == nil {
+		encoded.Names = []string{}
+	}
+	return json.Marshal(encoded)
+}
@@ -0,0 +1,206 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVLQ(t *testing.T) {
+	tcs := []struct {
+		value   int
+		encoded string
+	}{
+		{value: 0, encoded: "A"},
+		{value: 1, encoded: "C"},
+		{value: -1, encoded: "D"},
+		{value: 15, encoded: "e"},
+		{value: 16, encoded: "gB"},
+		{value: -16, encoded: "hB"},
+		{value: 1000, encoded: "w+B"},
+		{value: 123456789, encoded: "qxmvrH"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.encoded, func(t *testing.T) {
+			if got := string(appendVLQ(nil, tc.value)); got != tc.encoded {
+				t.Errorf("expected %d to encode as %q, got %q", tc.value, tc.encoded, got)
+			}
+			value, size, err := decodeVLQ(tc.encoded + "A")
+			if err != nil || value != tc.value || size != len(tc.encoded) {
+				t.Errorf("expected %q to decode as %d, got %d (size %d, %v)", tc.encoded, tc.value, value, size, err)
+			}
+		})
+	}
+
+	for _, encoded := range []string{"g", "!", "gggggggggggggB"} {
+		if _, _, err := decodeVLQ(encoded); err == nil {
+			t.Errorf("expected an error decoding %q", encoded)
+		}
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator("out.js")
+	g.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 0, Source: "a.js", OriginalLine: 0, OriginalColumn: 0})
+	g.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 9, Source: "a.js", OriginalLine: 0, OriginalColumn: 9, Name: "first"})
+	g.Add(Mapping{GeneratedLine: 2, GeneratedColumn: 4, Source: "b.js", OriginalLine: 3, OriginalColumn: 2, Name: "second"})
+	// replaces the mapping before, at the same position
+	g.Add(Mapping{GeneratedLine: 2, GeneratedColumn: 4, Source: "b.js", OriginalLine: 3, OriginalColumn: 2})
+	g.Add(Mapping{GeneratedLine: 2, GeneratedColumn: 10})
+	g.SetContent("b.js", "b")
+
+	m := g.Map()
+	if expected := "AAAA,SAASA;;ICGP,M"; m.Mappings != expected {
+		t.Errorf("expected mappings %q, got %q", expected, m.Mappings)
+	}
+	if expected := []string{"a.js", "b.js"}; !reflect.DeepEqual(m.Sources, expected) {
+		t.Errorf("expected sources %q, got %q", expected, m.Sources)
+	}
+	if expected := []string{"first"}; !reflect.DeepEqual(m.Names, expected) {
+		t.Errorf("expected names %q, got %q", expected, m.Names)
+	}
+	if len(m.SourcesContent) != 2 || m.SourcesContent[0] != nil || *m.SourcesContent[1] != "b" {
+		t.Errorf("expected the content of b.js only, got %v", m.SourcesContent)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := parsed.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mapping{
+		{GeneratedLine: 0, GeneratedColumn: 0, Source: "a.js", OriginalLine: 0, OriginalColumn: 0},
+		{GeneratedLine: 0, GeneratedColumn: 9, Source: "a.js", OriginalLine: 0, OriginalColumn: 9, Name: "first"},
+		{GeneratedLine: 2, GeneratedColumn: 4, Source: "b.js", OriginalLine: 3, OriginalColumn: 2},
+		{GeneratedLine: 2, GeneratedColumn: 10},
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("expected mappings\n%+v\ngot\n%+v", expected, mappings)
+	}
+}
+
+func TestMap_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewGenerator("").Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"version":3,"sources":[],"names":[],"mappings":""}`; string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`{"version":3,"sourceRoot":"src","sources":["a.js"],"names":["x"],"mappings":"AAAA;;CACAA"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mapping{
+		{GeneratedLine: 0, GeneratedColumn: 0, Source: "src/a.js"},
+		{GeneratedLine: 2, GeneratedColumn: 1, Source: "src/a.js", OriginalLine: 1, OriginalColumn: 0, Name: "x"},
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("expected mappings\n%+v\ngot\n%+v", expected, mappings)
+	}
+
+	errors := []struct {
+		data     string
+		expected string
+	}{
+		{data: `[]`, expected: "invalid source map"},
+		{data: `{"version":2,"mappings":""}`, expected: "unsupported source map version 2"},
+		{data: `{"version":3,"sections":[]}`, expected: "index source maps are not supported"},
+		{data: `{"version":3,"sources":[],"mappings":"AAAA"}`, expected: "no source 0"},
+		{data: `{"version":3,"sources":["a.js"],"mappings":"AAAAA"}`, expected: "no name 0"},
+		{data: `{"version":3,"sources":["a.js"],"mappings":"AA"}`, expected: "2 fields"},
+		{data: `{"version":3,"sources":["a.js"],"mappings":"AAAAAA"}`, expected: "too many fields"},
+		{data: `{"version":3,"sources":["a.js"],"mappings":"A!"}`, expected: "invalid mapping segment"},
+	}
+	for _, tc := range errors {
+		t.Run(tc.data, func(t *testing.T) {
+			if _, err := Parse([]byte(tc.data)); err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected an error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestMap_Lookup(t *testing.T) {
+	g := NewGenerator("")
+	g.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 2, Source: "a.js", OriginalLine: 5, OriginalColumn: 1})
+	g.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 8, Source: "a.js", OriginalLine: 6, OriginalColumn: 3})
+	m := g.Map()
+
+	tcs := []struct {
+		line, column int
+		ok           bool
+		originalLine int
+	}{
+		{line: 0, column: 0},
+		{line: 0, column: 2, ok: true, originalLine: 5},
+		{line: 0, column: 7, ok: true, originalLine: 5},
+		{line: 0, column: 8, ok: true, originalLine: 6},
+		{line: 0, column: 100, ok: true, originalLine: 6},
+		{line: 1, column: 0},
+		{line: -1, column: 0},
+	}
+	for _, tc := range tcs {
+		mapping, ok := m.Lookup(tc.line, tc.column)
+		if ok != tc.ok || ok && mapping.OriginalLine != tc.originalLine {
+			t.Errorf("expected %d:%d to map to line %d (%v), got %+v (%v)", tc.line, tc.column, tc.originalLine, tc.ok, mapping, ok)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	// original.ts -> intermediate.js
+	inner := NewGenerator("intermediate.js")
+	inner.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 0, Source: "original.ts", OriginalLine: 2, OriginalColumn: 0})
+	inner.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 6, Source: "original.ts", OriginalLine: 2, OriginalColumn: 6, Name: "total"})
+	inner.Add(Mapping{GeneratedLine: 1, GeneratedColumn: 0})
+	inner.SetContent("original.ts", "content")
+
+	// intermediate.js -> out.js
+	outer := NewGenerator("out.js")
+	outer.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 0, Source: "intermediate.js", OriginalLine: 0, OriginalColumn: 0})
+	outer.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 4, Source: "intermediate.js", OriginalLine: 0, OriginalColumn: 6, Name: "a"})
+	outer.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 6, Source: "intermediate.js", OriginalLine: 0, OriginalColumn: 8, Name: "b"})
+	outer.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 8, Source: "intermediate.js", OriginalLine: 1, OriginalColumn: 0})
+	outer.Add(Mapping{GeneratedLine: 0, GeneratedColumn: 10})
+
+	m, err := Compose(outer.Map(), inner.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.File != "out.js" {
+		t.Errorf("expected file out.js, got %q", m.File)
+	}
+	if len(m.SourcesContent) != 1 || *m.SourcesContent[0] != "content" {
+		t.Errorf("expected the content of original.ts, got %v", m.SourcesContent)
+	}
+	mappings, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mapping{
+		{GeneratedLine: 0, GeneratedColumn: 0, Source: "original.ts", OriginalLine: 2, OriginalColumn: 0},
+		// the name of the inner map at the exact position
+		{GeneratedLine: 0, GeneratedColumn: 4, Source: "original.ts", OriginalLine: 2, OriginalColumn: 6, Name: "total"},
+		// the name of the outer map past it
+		{GeneratedLine: 0, GeneratedColumn: 6, Source: "original.ts", OriginalLine: 2, OriginalColumn: 6, Name: "b"},
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("expected mappings\n%+v\ngot\n%+v", expected, mappings)
+	}
+}
@@ -0,0 +1,62 @@
+package sourcemap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The fields of a mapping segment are Base64 VLQs: the sign is the lowest bit
+// of the first digit, and each digit holds 5 bits of the value, lowest first,
+// with a sixth bit telling whether more digits follow.
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+const (
+	vlqShift        = 5
+	vlqContinuation = 1 << vlqShift
+	vlqMask         = vlqContinuation - 1
+)
+
+// appendVLQ appends the Base64 VLQ of n to dst.
+func appendVLQ(dst []byte, n int) []byte {
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+	for {
+		digit := v & vlqMask
+		v >>= vlqShift
+		if v > 0 {
+			digit |= vlqContinuation
+		}
+		dst = append(dst, base64Digits[digit])
+		if v == 0 {
+			return dst
+		}
+	}
+}
+
+// decodeVLQ decodes the Base64 VLQ at the start of s, returning its value and
+// the length of its digits.
+func decodeVLQ(s string) (int, int, error) {
+	var v, shift int
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64Digits, s[i])
+		if digit < 0 {
+			return 0, 0, fmt.Errorf("invalid base64 digit %q", s[i])
+		}
+		if shift > 60 {
+			return 0, 0, fmt.Errorf("VLQ overflows")
+		}
+		v |= (digit & vlqMask) << shift
+		shift += vlqShift
+		if digit&vlqContinuation == 0 {
+			n := v >> 1
+			if v&1 == 1 {
+				n = -n
+			}
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated VLQ")
+}